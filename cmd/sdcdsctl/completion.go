@@ -0,0 +1,74 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"github.com/spf13/cobra"
+)
+
+// the log levels accepted by the data-server
+var logLevels = []string{"trace", "debug", "info", "warning", "error"}
+
+// completeDatastores completes the names of the datastores of the data-server.
+func completeDatastores(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx, cancel := requestContext(cmd)
+	defer cancel()
+	cc, err := newClientConn()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer cc.Close()
+	rsp, err := sdcpb.NewDataServerClient(cc).ListDataStore(ctx, &sdcpb.ListDataStoreRequest{})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	names := make([]string, 0, len(rsp.GetDatastores()))
+	for _, ds := range rsp.GetDatastores() {
+		if strings.HasPrefix(ds.GetName(), toComplete) {
+			names = append(names, ds.GetName())
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeIntents completes the names of the intents of the datastore given by the datastore flag,
+// the priority of the intent is the description of the completion.
+func completeIntents(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if datastoreName == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, cancel := requestContext(cmd)
+	defer cancel()
+	cc, err := newClientConn()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer cc.Close()
+	rsp, err := sdcpb.NewDataServerClient(cc).ListIntent(ctx, &sdcpb.ListIntentRequest{Name: datastoreName})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	names := make([]string, 0, len(rsp.GetIntent()))
+	for _, in := range rsp.GetIntent() {
+		if strings.HasPrefix(in.GetIntent(), toComplete) {
+			names = append(names, fmt.Sprintf("%s\tpriority %d", in.GetIntent(), in.GetPriority()))
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
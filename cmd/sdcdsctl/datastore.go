@@ -0,0 +1,177 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var datastoreCmd = &cobra.Command{
+	Use:     "datastore",
+	Aliases: []string{"ds"},
+	Short:   "list and inspect datastores",
+}
+
+var datastoreListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the datastores",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+		cc, err := newClientConn()
+		if err != nil {
+			return err
+		}
+		defer cc.Close()
+		var hdr metadata.MD
+		rsp, err := sdcpb.NewDataServerClient(cc).ListDataStore(ctx, &sdcpb.ListDataStoreRequest{}, grpc.Header(&hdr))
+		if err != nil {
+			return err
+		}
+		infos, err := datastoreInfos(hdr)
+		if err != nil {
+			return err
+		}
+		if format != formatTable {
+			// the datastores along with their runtime information
+			dss := make([]any, 0, len(rsp.GetDatastores()))
+			for _, ds := range rsp.GetDatastores() {
+				v, err := messageValue(ds)
+				if err != nil {
+					return err
+				}
+				if info, ok := infos[ds.GetName()]; ok {
+					v["runtime"] = info
+				}
+				dss = append(dss, v)
+			}
+			return printValue(map[string]any{"datastores": dss})
+		}
+		rows := make([][]string, 0, len(rsp.GetDatastores()))
+		for _, ds := range rsp.GetDatastores() {
+			sc := ds.GetSchema()
+			info := infos[ds.GetName()]
+			if info == nil {
+				// a data-server that does not report the runtime information
+				info = &datastoreInfo{}
+			}
+			rows = append(rows, []string{
+				ds.GetName(),
+				fmt.Sprintf("%s/%s/%s", sc.GetVendor(), sc.GetName(), sc.GetVersion()),
+				ds.GetTarget().GetType(),
+				ds.GetTarget().GetAddress(),
+				ds.GetTarget().GetStatus().String(),
+				strconv.Itoa(info.Intents),
+				formatTime(info.LastSync),
+				info.lastApplyResult(),
+			})
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+		printTable([]string{"Name", "Schema", "Protocol", "Address", "State", "Intents", "Last Sync", "Last Apply"}, rows)
+		return nil
+	},
+}
+
+var datastoreGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "show a datastore",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+		cc, err := newClientConn()
+		if err != nil {
+			return err
+		}
+		defer cc.Close()
+		rsp, err := sdcpb.NewDataServerClient(cc).GetDataStore(ctx, &sdcpb.GetDataStoreRequest{Name: datastoreName})
+		if err != nil {
+			return err
+		}
+		return printMessage(rsp)
+	},
+}
+
+// datastoreInfoHeader is the gRPC response header of ListDataStore and GetDataStore carrying
+// the runtime information of the datastores, one JSON encoded datastoreInfo per datastore.
+const datastoreInfoHeader = "sdcio-datastore-info-bin"
+
+// datastoreInfo is the runtime information of a datastore, see the server package.
+type datastoreInfo struct {
+	Name            string     `json:"name" yaml:"name"`
+	ConnectionState string     `json:"connection-state,omitempty" yaml:"connection-state,omitempty"`
+	SyncState       string     `json:"sync-state,omitempty" yaml:"sync-state,omitempty"`
+	SchemaVendor    string     `json:"schema-vendor,omitempty" yaml:"schema-vendor,omitempty"`
+	SchemaVersion   string     `json:"schema-version,omitempty" yaml:"schema-version,omitempty"`
+	Intents         int        `json:"intents" yaml:"intents"`
+	LastSync        *time.Time `json:"last-sync,omitempty" yaml:"last-sync,omitempty"`
+	LastApply       *struct {
+		Intent   string        `json:"intent,omitempty" yaml:"intent,omitempty"`
+		Time     time.Time     `json:"time" yaml:"time"`
+		Duration time.Duration `json:"duration" yaml:"duration"`
+		Error    string        `json:"error,omitempty" yaml:"error,omitempty"`
+	} `json:"last-apply,omitempty" yaml:"last-apply,omitempty"`
+	Maintenance string `json:"maintenance,omitempty" yaml:"maintenance,omitempty"`
+	Dirty       bool   `json:"dirty,omitempty" yaml:"dirty,omitempty"`
+}
+
+// lastApplyResult returns the time and the result of the last apply.
+func (i *datastoreInfo) lastApplyResult() string {
+	if i.LastApply == nil {
+		return "-"
+	}
+	result := "ok"
+	if i.LastApply.Error != "" {
+		result = "failed: " + i.LastApply.Error
+	}
+	return fmt.Sprintf("%s (%s, %s)", formatTime(&i.LastApply.Time), i.LastApply.Intent, result)
+}
+
+// datastoreInfos returns the runtime information of the datastores carried by the response header, by datastore name.
+func datastoreInfos(hdr metadata.MD) (map[string]*datastoreInfo, error) {
+	infos := make(map[string]*datastoreInfo)
+	for _, v := range hdr.Get(datastoreInfoHeader) {
+		info := new(datastoreInfo)
+		if err := json.Unmarshal([]byte(v), info); err != nil {
+			return nil, fmt.Errorf("malformed datastore info: %w", err)
+		}
+		infos[info.Name] = info
+	}
+	return infos, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
+
+func init() {
+	rootCmd.AddCommand(datastoreCmd)
+	datastoreCmd.AddCommand(datastoreListCmd)
+	datastoreCmd.AddCommand(datastoreGetCmd)
+	datastoreFlag(datastoreGetCmd)
+}
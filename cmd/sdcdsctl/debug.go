@@ -0,0 +1,154 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/sdcio/data-server/pkg/dslog"
+)
+
+// the full method names of the debug service of the data-server
+const (
+	debugGetRuntimeInfo  = "/sdcio.data.debug.Debug/GetRuntimeInfo"
+	debugGetLogLevels    = "/sdcio.data.debug.Debug/GetLogLevels"
+	debugSetLogLevel     = "/sdcio.data.debug.Debug/SetLogLevel"
+	debugGetBlame        = "/sdcio.data.debug.Debug/GetBlame"
+	debugGetIntentSchema = "/sdcio.data.debug.Debug/GetIntentSchema"
+)
+
+var (
+	logModule string
+	logLevel  string
+)
+
+var blameCmd = &cobra.Command{
+	Use:   "blame",
+	Short: "show the intended configuration of a datastore along with the owner of every value",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		req, err := structpb.NewStruct(map[string]any{"datastore": datastoreName})
+		if err != nil {
+			return err
+		}
+		rsp, err := invokeDebug(cmd, debugGetBlame, req)
+		if err != nil {
+			return err
+		}
+		if format != formatTable {
+			return printValue(rsp.AsMap())
+		}
+		if b := rsp.GetFields()["blame"].GetStructValue(); b != nil {
+			printBlame(b, 0)
+		}
+		return nil
+	},
+}
+
+// printBlame prints a blame tree element and its childs, indented by their depth.
+func printBlame(b *structpb.Struct, depth int) {
+	f := b.GetFields()
+	indent := strings.Repeat("  ", depth)
+	if v, ok := f["value"]; ok {
+		line := fmt.Sprintf("%s%s: %s [%s/%d]", indent, f["name"].GetStringValue(), v.GetStringValue(),
+			f["owner"].GetStringValue(), int64(f["priority"].GetNumberValue()))
+		if f["running-differs"].GetBoolValue() {
+			line += fmt.Sprintf(" running=%s", f["running-value"].GetStringValue())
+		}
+		fmt.Println(line)
+	} else {
+		fmt.Printf("%s%s\n", indent, f["name"].GetStringValue())
+	}
+	for _, c := range f["childs"].GetListValue().GetValues() {
+		printBlame(c.GetStructValue(), depth+1)
+	}
+}
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "query and tune the runtime of the data-server",
+}
+
+var debugRuntimeCmd = &cobra.Command{
+	Use:   "runtime",
+	Short: "show the runtime information of the data-server",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runDebug(cmd, debugGetRuntimeInfo, &emptypb.Empty{})
+	},
+}
+
+var debugLogLevelsCmd = &cobra.Command{
+	Use:   "log-levels",
+	Short: "show the log levels of the modules of the data-server",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runDebug(cmd, debugGetLogLevels, &emptypb.Empty{})
+	},
+}
+
+var debugSetLogLevelCmd = &cobra.Command{
+	Use:   "set-log-level",
+	Short: "set the log level of a module of the data-server, of all modules if no module is given",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		req, err := structpb.NewStruct(map[string]any{"module": logModule, "level": logLevel})
+		if err != nil {
+			return err
+		}
+		return runDebug(cmd, debugSetLogLevel, req)
+	},
+}
+
+func invokeDebug(cmd *cobra.Command, method string, req any) (*structpb.Struct, error) {
+	ctx, cancel := requestContext(cmd)
+	defer cancel()
+	cc, err := newClientConn()
+	if err != nil {
+		return nil, err
+	}
+	defer cc.Close()
+	rsp := new(structpb.Struct)
+	if err = cc.Invoke(ctx, method, req, rsp); err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+// runDebug invokes the debug method and prints its response, as JSON in the table format since the
+// responses of the debug service are not tabular.
+func runDebug(cmd *cobra.Command, method string, req any) error {
+	rsp, err := invokeDebug(cmd, method, req)
+	if err != nil {
+		return err
+	}
+	return printValue(rsp.AsMap())
+}
+
+func init() {
+	rootCmd.AddCommand(blameCmd, debugCmd)
+	datastoreFlag(blameCmd)
+	debugCmd.AddCommand(debugRuntimeCmd, debugLogLevelsCmd, debugSetLogLevelCmd)
+	debugSetLogLevelCmd.Flags().StringVar(&logModule, "module", "", "module name, all modules if empty")
+	debugSetLogLevelCmd.Flags().StringVar(&logLevel, "level", "", "log level, e.g. debug or info")
+	_ = debugSetLogLevelCmd.MarkFlagRequired("level")
+	_ = debugSetLogLevelCmd.RegisterFlagCompletionFunc("module", cobra.FixedCompletions(dslog.Modules, cobra.ShellCompDirectiveNoFileComp))
+	_ = debugSetLogLevelCmd.RegisterFlagCompletionFunc("level", cobra.FixedCompletions(logLevels, cobra.ShellCompDirectiveNoFileComp))
+}
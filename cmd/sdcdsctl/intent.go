@@ -0,0 +1,394 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/yaml.v2"
+
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// the gRPC headers of the data-server, see the server package
+const (
+	setIntentNoOpHeader           = "sdcio-set-intent-no-op"
+	setIntentIdempotencyKeyHeader = "sdcio-idempotency-key"
+	revealSecretsHeader           = "sdcio-reveal-secrets"
+)
+
+var (
+	intentName     string
+	priority       int32
+	intentFile     string
+	dryRun         bool
+	revealSecrets  bool
+	idempotencyKey string
+	interactive    bool
+)
+
+var intentCmd = &cobra.Command{
+	Use:   "intent",
+	Short: "list, get, set and delete intents",
+}
+
+var intentListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the intents of a datastore",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+		cc, err := newClientConn()
+		if err != nil {
+			return err
+		}
+		defer cc.Close()
+		rsp, err := sdcpb.NewDataServerClient(cc).ListIntent(ctx, &sdcpb.ListIntentRequest{Name: datastoreName})
+		if err != nil {
+			return err
+		}
+		if format != formatTable {
+			return printMessage(rsp)
+		}
+		rows := make([][]string, 0, len(rsp.GetIntent()))
+		for _, in := range rsp.GetIntent() {
+			rows = append(rows, []string{in.GetIntent(), strconv.Itoa(int(in.GetPriority()))})
+		}
+		printTable([]string{"Intent", "Priority"}, rows)
+		return nil
+	},
+}
+
+var intentGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "get an intent",
+	Long:  "get an intent. The text output is the intent file format accepted by 'intent set'.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+		cc, err := newClientConn()
+		if err != nil {
+			return err
+		}
+		defer cc.Close()
+		if revealSecrets {
+			ctx = metadata.AppendToOutgoingContext(ctx, revealSecretsHeader, "true")
+		}
+		rsp, err := sdcpb.NewDataServerClient(cc).GetIntent(ctx, &sdcpb.GetIntentRequest{
+			Name:     datastoreName,
+			Intent:   intentName,
+			Priority: priority,
+		})
+		if err != nil {
+			return err
+		}
+		if format != formatTable {
+			return printMessage(rsp)
+		}
+		defs, err := toIntentDefs(rsp.GetIntent().GetUpdate())
+		if err != nil {
+			return err
+		}
+		return printJSON(defs)
+	},
+}
+
+var intentSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "create or update an intent from a file",
+	Long: `create or update an intent from a file. The file holds a JSON list of the values of the intent, e.g.
+[{"path": "/interface[name=ethernet-1/1]", "value": {"description": "uplink"}}]`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		defs, err := readIntentFile(intentFile)
+		if err != nil {
+			return err
+		}
+		upds, err := fromIntentDefs(defs)
+		if err != nil {
+			return err
+		}
+		if len(upds) == 0 {
+			return errors.New("the intent file holds no values, use 'intent delete' to delete an intent")
+		}
+		if interactive && !dryRun {
+			changed, err := printIntentDiff(cmd, defs)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				fmt.Fprintln(os.Stderr, "the intent is unchanged, nothing to apply")
+				return nil
+			}
+			if !confirm(cmd, "apply the intent?") {
+				return nil
+			}
+		}
+		return setIntent(cmd, &sdcpb.SetIntentRequest{
+			Name:     datastoreName,
+			Intent:   intentName,
+			Priority: priority,
+			Update:   upds,
+			DryRun:   dryRun,
+		})
+	},
+}
+
+var intentDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "show the differences between an intent and an intent file",
+	Long: `show the differences between an intent and an intent file as a unified diff of the rendered intents.
+The diff is colorized if stdout is a terminal, see the color flag.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		defs, err := readIntentFile(intentFile)
+		if err != nil {
+			return err
+		}
+		changed, err := printIntentDiff(cmd, defs)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			fmt.Fprintln(os.Stderr, "the intent is unchanged")
+		}
+		return nil
+	},
+}
+
+var intentDeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "delete an intent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if interactive && !dryRun && !confirm(cmd, fmt.Sprintf("delete the intent %s with priority %d?", intentName, priority)) {
+			return nil
+		}
+		return setIntent(cmd, &sdcpb.SetIntentRequest{
+			Name:     datastoreName,
+			Intent:   intentName,
+			Priority: priority,
+			Delete:   true,
+			DryRun:   dryRun,
+		})
+	},
+}
+
+func setIntent(cmd *cobra.Command, req *sdcpb.SetIntentRequest) error {
+	ctx, cancel := requestContext(cmd)
+	defer cancel()
+	cc, err := newClientConn()
+	if err != nil {
+		return err
+	}
+	defer cc.Close()
+	if idempotencyKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, setIntentIdempotencyKeyHeader, idempotencyKey)
+	}
+	var hdr metadata.MD
+	rsp, err := sdcpb.NewDataServerClient(cc).SetIntent(ctx, req, grpc.Header(&hdr))
+	if err != nil {
+		return err
+	}
+	for _, w := range rsp.GetWarnings() {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	if vals := hdr.Get(setIntentNoOpHeader); len(vals) > 0 && vals[0] == "true" {
+		fmt.Fprintln(os.Stderr, "the intent is unchanged, nothing was applied")
+	}
+	if format != formatTable {
+		return printMessage(rsp)
+	}
+	printDiff(rsp)
+	return nil
+}
+
+// confirm asks the user for confirmation on stdin, it returns false unless the user answers yes.
+func confirm(cmd *cobra.Command, question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && answer == "" {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// printIntentDiff prints the unified diff of the stored intent and the given values, rendered in the
+// file format of the intents. An intent that does not exist yet is rendered empty.
+// It returns false if the intents do not differ.
+func printIntentDiff(cmd *cobra.Command, defs []*intentDef) (bool, error) {
+	ctx, cancel := requestContext(cmd)
+	defer cancel()
+	cc, err := newClientConn()
+	if err != nil {
+		return false, err
+	}
+	defer cc.Close()
+	client := sdcpb.NewDataServerClient(cc)
+	lrsp, err := client.ListIntent(ctx, &sdcpb.ListIntentRequest{Name: datastoreName})
+	if err != nil {
+		return false, err
+	}
+	stored := make([]*intentDef, 0)
+	for _, in := range lrsp.GetIntent() {
+		if in.GetIntent() != intentName || in.GetPriority() != priority {
+			continue
+		}
+		rsp, err := client.GetIntent(ctx, &sdcpb.GetIntentRequest{
+			Name:     datastoreName,
+			Intent:   intentName,
+			Priority: priority,
+		})
+		if err != nil {
+			return false, err
+		}
+		if stored, err = toIntentDefs(rsp.GetIntent().GetUpdate()); err != nil {
+			return false, err
+		}
+	}
+	from, err := renderIntent(stored)
+	if err != nil {
+		return false, err
+	}
+	to, err := renderIntent(defs)
+	if err != nil {
+		return false, err
+	}
+	name := fmt.Sprintf("%s/%s/%d", datastoreName, intentName, priority)
+	return writeUnifiedDiff(os.Stdout, name, intentFile, from, to, useColor()), nil
+}
+
+// renderIntent renders the values of an intent sorted by path, as YAML in the YAML format and as indented JSON otherwise.
+func renderIntent(defs []*intentDef) ([]string, error) {
+	defs = slices.Clone(defs)
+	slices.SortStableFunc(defs, func(a, b *intentDef) int { return strings.Compare(a.Path, b.Path) })
+	var b []byte
+	var err error
+	if format == formatYAML {
+		b, err = yaml.Marshal(defs)
+	} else {
+		b, err = json.MarshalIndent(defs, "", "  ")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n"), nil
+}
+
+func readIntentFile(name string) ([]*intentDef, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	defs := make([]*intentDef, 0)
+	if err = json.Unmarshal(b, &defs); err != nil {
+		return nil, fmt.Errorf("malformed intent file %s: %w", name, err)
+	}
+	return defs, nil
+}
+
+// printDiff prints the changes of the device of a SetIntentResponse, the deleted paths prefixed by '-'
+// and the updated values prefixed by '+'.
+func printDiff(rsp *sdcpb.SetIntentResponse) {
+	for _, p := range rsp.GetDelete() {
+		fmt.Printf("- %s\n", utils.ToXPathOpts(p, utils.XPathOpts{Absolute: true}))
+	}
+	for _, u := range rsp.GetUpdate() {
+		fmt.Printf("+ %s: %s\n", utils.ToXPathOpts(u.GetPath(), utils.XPathOpts{Absolute: true}), utils.TypedValueToString(u.GetValue()))
+	}
+}
+
+// intentDef is a value of an intent file.
+type intentDef struct {
+	Path  string `json:"path,omitempty" yaml:"path,omitempty"`
+	Value any    `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+func fromIntentDefs(defs []*intentDef) ([]*sdcpb.Update, error) {
+	upds := make([]*sdcpb.Update, 0, len(defs))
+	for _, def := range defs {
+		p, err := utils.ParsePath(def.Path)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(def.Value)
+		if err != nil {
+			return nil, err
+		}
+		upds = append(upds, &sdcpb.Update{
+			Path:  p,
+			Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: b}},
+		})
+	}
+	return upds, nil
+}
+
+func toIntentDefs(upds []*sdcpb.Update) ([]*intentDef, error) {
+	defs := make([]*intentDef, 0, len(upds))
+	for _, u := range upds {
+		v, err := utils.GetJsonValue(u.GetValue(), false)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, &intentDef{
+			Path:  utils.ToXPathOpts(u.GetPath(), utils.XPathOpts{Absolute: true}),
+			Value: v,
+		})
+	}
+	return defs, nil
+}
+
+func init() {
+	rootCmd.AddCommand(intentCmd)
+	intentCmd.AddCommand(intentListCmd, intentGetCmd, intentSetCmd, intentDiffCmd, intentDeleteCmd)
+	for _, c := range []*cobra.Command{intentListCmd, intentGetCmd, intentSetCmd, intentDiffCmd, intentDeleteCmd} {
+		datastoreFlag(c)
+	}
+	for _, c := range []*cobra.Command{intentGetCmd, intentSetCmd, intentDiffCmd, intentDeleteCmd} {
+		c.Flags().StringVarP(&intentName, "intent", "i", "", "intent name")
+		c.Flags().Int32VarP(&priority, "priority", "p", 0, "intent priority")
+		_ = c.MarkFlagRequired("intent")
+		_ = c.MarkFlagRequired("priority")
+		_ = c.RegisterFlagCompletionFunc("intent", completeIntents)
+	}
+	for _, c := range []*cobra.Command{intentSetCmd, intentDeleteCmd} {
+		c.Flags().BoolVar(&dryRun, "dry-run", false, "only compute the changes of the device, print them as a diff")
+		c.Flags().StringVar(&idempotencyKey, "idempotency-key", "", "key identifying the request across retries")
+		c.Flags().BoolVar(&interactive, "interactive", false, "ask for confirmation before applying the changes, 'intent set' shows the diff of the intent first")
+	}
+	for _, c := range []*cobra.Command{intentSetCmd, intentDiffCmd} {
+		c.Flags().StringVar(&intentFile, "file", "", "intent file")
+		_ = c.MarkFlagRequired("file")
+		_ = c.MarkFlagFilename("file", "json")
+	}
+	intentGetCmd.Flags().BoolVar(&revealSecrets, "reveal-secrets", false, "return the sensitive values in clear text, requires a privileged role")
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+// printMessage prints the message in the output format, the table format prints the message as text.
+func printMessage(m proto.Message) error {
+	switch format {
+	case formatJSON:
+		b, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(m)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	case formatYAML:
+		v, err := messageValue(m)
+		if err != nil {
+			return err
+		}
+		return printYAML(v)
+	}
+	fmt.Print(prototext.MarshalOptions{Multiline: true, Indent: "  "}.Format(m))
+	return nil
+}
+
+// messageValue returns the JSON mapping of the protobuf message as generic value, e.g. to extend it
+// or to print it as YAML.
+func messageValue(m proto.Message) (map[string]any, error) {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	v := make(map[string]any)
+	if err = json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// printValue prints the value as YAML in the YAML format and as indented JSON otherwise.
+func printValue(v any) error {
+	if format == formatYAML {
+		return printYAML(v)
+	}
+	return printJSON(v)
+}
+
+// printJSON prints the value as indented JSON.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printYAML(v any) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(b)
+	return err
+}
+
+// printTable prints the rows as a table.
+func printTable(header []string, rows [][]string) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAutoFormatHeaders(false)
+	table.SetAutoWrapText(false)
+	table.AppendBulk(rows)
+	table.Render()
+}
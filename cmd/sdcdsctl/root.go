@@ -0,0 +1,87 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// the output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+var formats = []string{formatTable, formatJSON, formatYAML}
+
+var (
+	addr          string
+	format        string
+	color         string
+	timeout       time.Duration
+	datastoreName string
+)
+
+var rootCmd = &cobra.Command{
+	Use:          "sdcdsctl",
+	Short:        "command line client of the data-server",
+	Long:         "command line client of the data-server. Run 'sdcdsctl completion --help' to set up the shell completion.",
+	SilenceUsage: true,
+	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+		if !slices.Contains(formats, format) {
+			return fmt.Errorf("unknown format %q, must be one of %v", format, formats)
+		}
+		if !slices.Contains(colorModes, color) {
+			return fmt.Errorf("unknown color mode %q, must be one of %v", color, colorModes)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&addr, "address", "a", "localhost:56000", "data-server address")
+	rootCmd.PersistentFlags().StringVarP(&format, "format", "f", formatTable, "output format, one of: table, json, yaml")
+	rootCmd.PersistentFlags().StringVar(&color, "color", colorAuto, "colorize the diffs, one of: auto, always, never")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", time.Minute, "timeout of the requests, 0 disables it")
+	_ = rootCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(formats, cobra.ShellCompDirectiveNoFileComp))
+	_ = rootCmd.RegisterFlagCompletionFunc("color", cobra.FixedCompletions(colorModes, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// datastoreFlag adds the mandatory datastore flag to the command.
+func datastoreFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&datastoreName, "datastore", "d", "", "datastore name")
+	_ = cmd.MarkFlagRequired("datastore")
+	_ = cmd.RegisterFlagCompletionFunc("datastore", completeDatastores)
+}
+
+// requestContext returns the context of a unary request, bound by the timeout.
+func requestContext(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(cmd.Context())
+	}
+	return context.WithTimeout(cmd.Context(), timeout)
+}
+
+func newClientConn() (*grpc.ClientConn, error) {
+	return grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
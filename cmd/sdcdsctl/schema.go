@@ -0,0 +1,233 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// schemaDatastoreHeader selects the schema of a datastore in the schema server RPCs, see the server package.
+const schemaDatastoreHeader = "sdcio-datastore"
+
+var schemaPath string
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "browse the schema of a datastore",
+	Long:  "browse the schema of a datastore. Requires the schema server RPCs of the data-server to be enabled.",
+}
+
+var schemaGetCmd = &cobra.Command{
+	Use:   "get",
+	Short: "show the schema of a path, along with its children, the types of its leafs and their constraints",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		p, err := utils.ParsePath(schemaPath)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := requestContext(cmd)
+		defer cancel()
+		cc, err := newClientConn()
+		if err != nil {
+			return err
+		}
+		defer cc.Close()
+		ctx = metadata.AppendToOutgoingContext(ctx, schemaDatastoreHeader, datastoreName)
+		rsp, err := sdcpb.NewSchemaServerClient(cc).GetSchema(ctx, &sdcpb.GetSchemaRequest{
+			Path:            p,
+			WithDescription: true,
+		})
+		if err != nil {
+			return err
+		}
+		if format != formatTable {
+			return printMessage(rsp)
+		}
+		printSchema(rsp.GetSchema())
+		return nil
+	},
+}
+
+var schemaJSONSchemaCmd = &cobra.Command{
+	Use:   "json-schema",
+	Short: "generate the JSON Schema of the intent values at a path",
+	Long: `generate the JSON Schema of the intent values at a path, e.g. to validate intent files before applying them.
+The schema follows the draft 2020-12 dialect, which is also used by OpenAPI 3.1. Requires the debug service of the data-server.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		req, err := structpb.NewStruct(map[string]any{"datastore": datastoreName, "path": schemaPath})
+		if err != nil {
+			return err
+		}
+		return runDebug(cmd, debugGetIntentSchema, req)
+	},
+}
+
+// printSchema prints a summary of the schema element, its children and its leafs.
+func printSchema(sc *sdcpb.SchemaElem) {
+	switch {
+	case sc.GetContainer() != nil:
+		c := sc.GetContainer()
+		kind := "container"
+		if len(c.GetKeys()) > 0 {
+			keys := make([]string, 0, len(c.GetKeys()))
+			for _, k := range c.GetKeys() {
+				keys = append(keys, k.GetName())
+			}
+			kind = fmt.Sprintf("list, keys: %s", strings.Join(keys, ", "))
+		}
+		fmt.Printf("%s (%s)\n", c.GetName(), kind)
+		printDescription(c.GetDescription())
+		if c.GetIsPresence() {
+			fmt.Println("  presence container")
+		}
+		for _, m := range c.GetMandatoryChildren() {
+			fmt.Printf("  mandatory: %s\n", m.GetName())
+		}
+		printMust(c.GetMustStatements())
+		fmt.Println()
+
+		rows := make([][]string, 0, len(c.GetKeys())+len(c.GetFields())+len(c.GetLeaflists())+len(c.GetChildren()))
+		for _, l := range c.GetKeys() {
+			rows = append(rows, []string{l.GetName(), "key", typeName(l.GetType()), "true", l.GetDefault(), typeConstraints(l.GetType())})
+		}
+		for _, l := range c.GetFields() {
+			rows = append(rows, []string{l.GetName(), "leaf", typeName(l.GetType()), strconv.FormatBool(l.GetIsMandatory()), l.GetDefault(), typeConstraints(l.GetType())})
+		}
+		for _, l := range c.GetLeaflists() {
+			rows = append(rows, []string{l.GetName(), "leaf-list", typeName(l.GetType()), strconv.FormatBool(l.GetMinElements() > 0), strings.Join(l.GetDefaults(), ","), typeConstraints(l.GetType())})
+		}
+		children := append([]string{}, c.GetChildren()...)
+		sort.Strings(children)
+		for _, ch := range children {
+			rows = append(rows, []string{ch, "container", "", "", "", ""})
+		}
+		printTable([]string{"Name", "Kind", "Type", "Mandatory", "Default", "Constraints"}, rows)
+	case sc.GetField() != nil:
+		l := sc.GetField()
+		fmt.Printf("%s (leaf)\n", l.GetName())
+		printDescription(l.GetDescription())
+		printLeafType(l.GetType())
+		fmt.Printf("  mandatory: %t\n", l.GetIsMandatory())
+		if l.GetDefault() != "" {
+			fmt.Printf("  default: %s\n", l.GetDefault())
+		}
+		printMust(l.GetMustStatements())
+	case sc.GetLeaflist() != nil:
+		l := sc.GetLeaflist()
+		fmt.Printf("%s (leaf-list)\n", l.GetName())
+		printDescription(l.GetDescription())
+		printLeafType(l.GetType())
+		if l.GetMinElements() > 0 || l.GetMaxElements() > 0 {
+			fmt.Printf("  elements: %d..%d\n", l.GetMinElements(), l.GetMaxElements())
+		}
+		if len(l.GetDefaults()) > 0 {
+			fmt.Printf("  defaults: %s\n", strings.Join(l.GetDefaults(), ", "))
+		}
+		printMust(l.GetMustStatements())
+	}
+}
+
+func printDescription(d string) {
+	if d != "" {
+		fmt.Printf("  %s\n", strings.Join(strings.Fields(d), " "))
+	}
+}
+
+func printMust(musts []*sdcpb.MustStatement) {
+	for _, m := range musts {
+		fmt.Printf("  must: %s\n", m.GetStatement())
+	}
+}
+
+func printLeafType(t *sdcpb.SchemaLeafType) {
+	fmt.Printf("  type: %s\n", typeName(t))
+	if c := typeConstraints(t); c != "" {
+		fmt.Printf("  constraints: %s\n", c)
+	}
+}
+
+func typeName(t *sdcpb.SchemaLeafType) string {
+	if t.GetType() == "union" {
+		names := make([]string, 0, len(t.GetUnionTypes()))
+		for _, ut := range t.GetUnionTypes() {
+			names = append(names, typeName(ut))
+		}
+		return fmt.Sprintf("union(%s)", strings.Join(names, "|"))
+	}
+	if t.GetTypeName() != "" && t.GetTypeName() != t.GetType() {
+		return fmt.Sprintf("%s (%s)", t.GetTypeName(), t.GetType())
+	}
+	return t.GetType()
+}
+
+// typeConstraints returns the ranges, lengths, patterns, enum values and leafref of the type.
+func typeConstraints(t *sdcpb.SchemaLeafType) string {
+	var cs []string
+	minMax := func(mms []*sdcpb.SchemaMinMaxType) string {
+		rs := make([]string, 0, len(mms))
+		for _, mm := range mms {
+			rs = append(rs, fmt.Sprintf("%s..%s", numberString(mm.GetMin()), numberString(mm.GetMax())))
+		}
+		return strings.Join(rs, "|")
+	}
+	if len(t.GetRange()) > 0 {
+		cs = append(cs, "range "+minMax(t.GetRange()))
+	}
+	if len(t.GetLength()) > 0 {
+		cs = append(cs, "length "+minMax(t.GetLength()))
+	}
+	for _, p := range t.GetPatterns() {
+		if p.GetInverted() {
+			cs = append(cs, "not pattern "+p.GetPattern())
+			continue
+		}
+		cs = append(cs, "pattern "+p.GetPattern())
+	}
+	if len(t.GetEnumNames()) > 0 {
+		cs = append(cs, "enum "+strings.Join(t.GetEnumNames(), "|"))
+	}
+	if t.GetLeafref() != "" {
+		cs = append(cs, "leafref "+t.GetLeafref())
+	}
+	return strings.Join(cs, "; ")
+}
+
+func numberString(n *sdcpb.Number) string {
+	if n.GetNegative() {
+		return fmt.Sprintf("-%d", n.GetValue())
+	}
+	return strconv.FormatUint(n.GetValue(), 10)
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	schemaCmd.AddCommand(schemaGetCmd, schemaJSONSchemaCmd)
+	for _, c := range []*cobra.Command{schemaGetCmd, schemaJSONSchemaCmd} {
+		datastoreFlag(c)
+		c.Flags().StringVar(&schemaPath, "path", "", "schema path, e.g. /interface/subinterface, the root if empty")
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "watch the events of datastores",
+}
+
+var watchDeviationsCmd = &cobra.Command{
+	Use:   "deviations",
+	Short: "watch the deviations between the intended and the running configuration of a datastore",
+	Long:  "watch the deviations between the intended and the running configuration of a datastore, until interrupted.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		cc, err := newClientConn()
+		if err != nil {
+			return err
+		}
+		defer cc.Close()
+		// a watch runs until interrupted, hence it is not bound by the timeout
+		stream, err := sdcpb.NewDataServerClient(cc).WatchDeviations(cmd.Context(), &sdcpb.WatchDeviationRequest{
+			Name: []string{datastoreName},
+		})
+		if err != nil {
+			return err
+		}
+		for {
+			rsp, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err = printDeviation(rsp); err != nil {
+				return err
+			}
+		}
+	},
+}
+
+// printDeviation prints a deviation event, one line per event in the table and JSON formats
+// and one document per event in the YAML format.
+func printDeviation(rsp *sdcpb.WatchDeviationResponse) error {
+	switch format {
+	case formatJSON:
+		b, err := protojson.Marshal(rsp)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	case formatYAML:
+		fmt.Println("---")
+		return printMessage(rsp)
+	}
+	switch rsp.GetEvent() {
+	case sdcpb.DeviationEvent_START, sdcpb.DeviationEvent_END, sdcpb.DeviationEvent_CLEAR:
+		fmt.Printf("%s %s\n", rsp.GetName(), rsp.GetEvent())
+		return nil
+	}
+	fmt.Printf("%s %s %s intent=%s %s expected=%s current=%s\n",
+		rsp.GetName(),
+		rsp.GetEvent(),
+		rsp.GetReason(),
+		rsp.GetIntent(),
+		utils.ToXPathOpts(rsp.GetPath(), utils.XPathOpts{Absolute: true}),
+		utils.TypedValueToString(rsp.GetExpectedValue()),
+		utils.TypedValueToString(rsp.GetCurrentValue()),
+	)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.AddCommand(watchDeviationsCmd)
+	datastoreFlag(watchDeviationsCmd)
+}
@@ -18,13 +18,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
-	log "github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 
 	"github.com/sdcio/data-server/pkg/config"
@@ -32,6 +30,8 @@ import (
 	"github.com/sdcio/data-server/pkg/server"
 )
 
+var log = dslog.New(dslog.ModuleMain)
+
 var configFile string
 var debug bool
 var trace bool
@@ -53,15 +53,18 @@ func main() {
 		return
 	}
 
-	log.SetFormatter(&log.TextFormatter{FullTimestamp: true})
-	log.SetLevel(log.InfoLevel)
+	// the flags take precedence over the configured log level
+	var flagLevel string
 	if debug {
-		log.SetLevel(log.DebugLevel)
-		slog.SetLogLoggerLevel(slog.LevelDebug)
+		flagLevel = "debug"
 	}
 	if trace {
-		log.SetLevel(log.TraceLevel)
-		slog.SetLogLoggerLevel(dslog.TraceLevel)
+		flagLevel = "trace"
+	}
+	err := dslog.Configure(dslog.FormatText, flagLevel, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to configure logging: %v\n", err)
+		os.Exit(1)
 	}
 	log.Infof("data-server %s-%s", version, commit)
 
@@ -75,6 +78,16 @@ START:
 		log.Errorf("failed to read config: %v", err)
 		os.Exit(1)
 	}
+	level := cfg.Logging.Level
+	if flagLevel != "" {
+		level = flagLevel
+	}
+	err = dslog.Configure(cfg.Logging.Format, level, cfg.Logging.Modules)
+	if err != nil {
+		log.Errorf("failed to configure logging: %v", err)
+		os.Exit(1)
+	}
+	dslog.SetMaxValueLength(cfg.Logging.MaxValueLength)
 	b, err := json.MarshalIndent(cfg, "", "  ")
 	if err != nil {
 		log.Errorf("failed to marshal config: %v", err)
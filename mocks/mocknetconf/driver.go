@@ -156,6 +156,21 @@ func (mr *MockDriverMockRecorder) Lock(target any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockDriver)(nil).Lock), target)
 }
 
+// RPC mocks base method.
+func (m *MockDriver) RPC(rpc string) (*types.NetconfResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RPC", rpc)
+	ret0, _ := ret[0].(*types.NetconfResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RPC indicates an expected call of RPC.
+func (mr *MockDriverMockRecorder) RPC(rpc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RPC", reflect.TypeOf((*MockDriver)(nil).RPC), rpc)
+}
+
 // Unlock mocks base method.
 func (m *MockDriver) Unlock(target string) (*types.NetconfResponse, error) {
 	m.ctrl.T.Helper()
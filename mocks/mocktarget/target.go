@@ -168,6 +168,22 @@ func (mr *MockTargetSourceMockRecorder) ToJsonIETF(onlyNewOrUpdated any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ToJsonIETF", reflect.TypeOf((*MockTargetSource)(nil).ToJsonIETF), onlyNewOrUpdated)
 }
 
+// ToProtoBranchReplaces mocks base method.
+func (m *MockTargetSource) ToProtoBranchReplaces(ctx context.Context, ietf bool) ([]*schema_server.Update, []*schema_server.Path, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ToProtoBranchReplaces", ctx, ietf)
+	ret0, _ := ret[0].([]*schema_server.Update)
+	ret1, _ := ret[1].([]*schema_server.Path)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ToProtoBranchReplaces indicates an expected call of ToProtoBranchReplaces.
+func (mr *MockTargetSourceMockRecorder) ToProtoBranchReplaces(ctx, ietf any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ToProtoBranchReplaces", reflect.TypeOf((*MockTargetSource)(nil).ToProtoBranchReplaces), ctx, ietf)
+}
+
 // ToProtoDeletes mocks base method.
 func (m *MockTargetSource) ToProtoDeletes(ctx context.Context) ([]*schema_server.Path, error) {
 	m.ctrl.T.Helper()
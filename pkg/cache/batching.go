@@ -0,0 +1,286 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	batchOperationRead   = "read"
+	batchOperationModify = "modify"
+)
+
+var (
+	batchedCallsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "data_server",
+		Subsystem: "cache",
+		Name:      "batched_calls_total",
+		Help:      "Number of cache calls that were coalesced into batches",
+	}, []string{"operation"})
+	batchesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "data_server",
+		Subsystem: "cache",
+		Name:      "batches_total",
+		Help:      "Number of batched calls sent to the cache",
+	}, []string{"operation"})
+	batchSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "data_server",
+		Subsystem: "cache",
+		Name:      "batch_size",
+		Help:      "Number of paths sent to the cache per batched call",
+		Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+	}, []string{"operation"})
+)
+
+// Collectors returns the prometheus collectors of the cache package.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{batchedCallsTotal, batchesTotal, batchSize}
+}
+
+// BatchingClient is a Client that coalesces the Read and Modify calls issued concurrently,
+// e.g. while populating and validating trees, into fewer and larger calls to the cache.
+// Calls are batched per cache and options. A batch is sent once the flush interval elapsed
+// since its first call or once it holds the maximum number of paths. The callers block until
+// their batch is sent.
+type BatchingClient struct {
+	Client
+	flushInterval time.Duration
+	maxSize       int
+
+	m        sync.Mutex
+	reads    map[batchKey]*readBatch
+	modifies map[batchKey]*modifyBatch
+}
+
+// NewBatchingClient wraps the Client, batching its reads and modifications.
+func NewBatchingClient(c Client, flushInterval time.Duration, maxSize int) *BatchingClient {
+	return &BatchingClient{
+		Client:        c,
+		flushInterval: flushInterval,
+		maxSize:       maxSize,
+		reads:         map[batchKey]*readBatch{},
+		modifies:      map[batchKey]*modifyBatch{},
+	}
+}
+
+type batchKey struct {
+	name   string
+	opts   Opts
+	period time.Duration
+}
+
+func newBatchKey(name string, opts *Opts) batchKey {
+	k := batchKey{name: name}
+	if opts != nil {
+		k.opts = *opts
+	}
+	return k
+}
+
+type readBatch struct {
+	once   sync.Once
+	done   chan struct{}
+	calls  int
+	paths  [][]string
+	result []*Update
+}
+
+type modifyBatch struct {
+	once  sync.Once
+	done  chan struct{}
+	calls int
+	dels  [][]string
+	upds  []*Update
+	err   error
+}
+
+// Read adds the paths to the pending read batch of the cache and returns the values below the paths
+// once the batch was read. Only reads with the same options and period are batched together.
+func (c *BatchingClient) Read(ctx context.Context, name string, opts *Opts, paths [][]string, period time.Duration) []*Update {
+	if len(paths) == 0 {
+		return c.Client.Read(ctx, name, opts, paths, period)
+	}
+	k := newBatchKey(name, opts)
+	k.period = period
+
+	c.m.Lock()
+	b, ok := c.reads[k]
+	if !ok {
+		b = &readBatch{done: make(chan struct{})}
+		c.reads[k] = b
+		time.AfterFunc(c.flushInterval, func() { c.flushRead(k, b) })
+	}
+	b.calls++
+	b.paths = append(b.paths, paths...)
+	full := len(b.paths) >= c.maxSize
+	c.m.Unlock()
+
+	if full {
+		c.flushRead(k, b)
+	}
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-b.done:
+	}
+
+	result := make([]*Update, 0, len(paths))
+	for _, u := range b.result {
+		if slices.ContainsFunc(paths, func(p []string) bool { return matchesPath(u.GetPath(), p) }) {
+			result = append(result, u)
+		}
+	}
+	return result
+}
+
+// ReadCh returns the values of the batched Read through a channel.
+func (c *BatchingClient) ReadCh(ctx context.Context, name string, opts *Opts, paths [][]string, period time.Duration) chan *Update {
+	ch := make(chan *Update, len(paths))
+	go func() {
+		defer close(ch)
+		for _, u := range c.Read(ctx, name, opts, paths, period) {
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- u:
+			}
+		}
+	}()
+	return ch
+}
+
+func (c *BatchingClient) flushRead(k batchKey, b *readBatch) {
+	c.m.Lock()
+	if c.reads[k] == b {
+		delete(c.reads, k)
+	}
+	c.m.Unlock()
+
+	b.once.Do(func() {
+		defer close(b.done)
+		paths := dedupPaths(b.paths)
+		observeBatch(batchOperationRead, b.calls, len(paths))
+		opts := k.opts
+		// the batch is shared by all callers, it must not be canceled by one of them
+		b.result = c.Client.Read(context.Background(), k.name, &opts, paths, k.period)
+	})
+}
+
+// Modify adds the deletes and updates to the pending modify batch of the cache and returns
+// once the batch was applied. The deletes of the batch are applied before its updates, a batch
+// is hence flushed before a delete that covers one of its updates is added.
+func (c *BatchingClient) Modify(ctx context.Context, name string, opts *Opts, dels [][]string, upds []*Update) error {
+	if len(dels) == 0 && len(upds) == 0 {
+		return c.Client.Modify(ctx, name, opts, dels, upds)
+	}
+	k := newBatchKey(name, opts)
+
+	c.m.Lock()
+	b, ok := c.modifies[k]
+	for ok && deletesUpdates(dels, b.upds) {
+		c.m.Unlock()
+		c.flushModify(k, b)
+		c.m.Lock()
+		b, ok = c.modifies[k]
+	}
+	if !ok {
+		b = &modifyBatch{done: make(chan struct{})}
+		c.modifies[k] = b
+		time.AfterFunc(c.flushInterval, func() { c.flushModify(k, b) })
+	}
+	b.calls++
+	b.dels = append(b.dels, dels...)
+	b.upds = append(b.upds, upds...)
+	full := len(b.dels)+len(b.upds) >= c.maxSize
+	c.m.Unlock()
+
+	if full {
+		c.flushModify(k, b)
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.done:
+		return b.err
+	}
+}
+
+func (c *BatchingClient) flushModify(k batchKey, b *modifyBatch) {
+	c.m.Lock()
+	if c.modifies[k] == b {
+		delete(c.modifies, k)
+	}
+	c.m.Unlock()
+
+	b.once.Do(func() {
+		defer close(b.done)
+		observeBatch(batchOperationModify, b.calls, len(b.dels)+len(b.upds))
+		opts := k.opts
+		b.err = c.Client.Modify(context.Background(), k.name, &opts, b.dels, b.upds)
+	})
+}
+
+func observeBatch(operation string, calls int, size int) {
+	batchedCallsTotal.WithLabelValues(operation).Add(float64(calls))
+	batchesTotal.WithLabelValues(operation).Inc()
+	batchSize.WithLabelValues(operation).Observe(float64(size))
+}
+
+// matchesPath returns true if the path is below the given prefix, wildcard elements of the prefix
+// match any element.
+func matchesPath(path []string, prefix []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i, e := range prefix {
+		if e != "*" && e != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// deletesUpdates returns true if one of the deletes removes one of the updates.
+func deletesUpdates(dels [][]string, upds []*Update) bool {
+	for _, d := range dels {
+		for _, u := range upds {
+			if matchesPath(u.GetPath(), d) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func dedupPaths(paths [][]string) [][]string {
+	seen := make(map[string]struct{}, len(paths))
+	result := make([][]string, 0, len(paths))
+	for _, p := range paths {
+		k := strings.Join(p, "\x00")
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		result = append(result, p)
+	}
+	return result
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sdcio/cache/proto/cachepb"
+)
+
+// maxConfigChanges is the number of CONFIG store modifications kept per cache, older ones are dropped
+// and the changes since a sequence number ahead of them are reported as incomplete.
+const maxConfigChanges = 1000
+
+// StoreChange is a modification of a store, the deletes are applied before the updates.
+type StoreChange struct {
+	Deletes [][]string
+	Updates []*Update
+}
+
+// ChangeLogClient is a Client that records the modifications of the CONFIG stores and counts the
+// modifications of the INTENDED stores, for users that keep state derived from the stores up to date
+// incrementally. The changes of a cache are numbered, the log is complete from a sequence number on.
+// Creating, deleting, cloning, committing to or pruning a cache, as well as failed modifications,
+// invalidate the log, since the resulting content is not known.
+type ChangeLogClient struct {
+	Client
+	m    sync.Mutex
+	logs map[string]*changeLog
+}
+
+type changeLog struct {
+	// seq is the sequence number of the last CONFIG modification
+	seq uint64
+	// first is the sequence number the changes are recorded completely after
+	first   uint64
+	changes []*sequencedChange
+	// intended counts the modifications of the INTENDED store
+	intended uint64
+}
+
+type sequencedChange struct {
+	seq uint64
+	StoreChange
+}
+
+// NewChangeLogClient wraps the Client, recording the modifications of the CONFIG and INTENDED stores.
+func NewChangeLogClient(c Client) *ChangeLogClient {
+	return &ChangeLogClient{Client: c, logs: map[string]*changeLog{}}
+}
+
+// ConfigChanges returns the modifications of the CONFIG store of the cache after the sequence number since,
+// in the order they were applied, along with the sequence number of the last one. It returns false if the
+// changes are not completely known, e.g. the log was invalidated after since.
+func (c *ChangeLogClient) ConfigChanges(name string, since uint64) ([]*StoreChange, uint64, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	l := c.getLog(name)
+	if since < l.first || since > l.seq {
+		return nil, l.seq, false
+	}
+	result := make([]*StoreChange, 0, len(l.changes))
+	for _, ch := range l.changes {
+		if ch.seq > since {
+			result = append(result, &ch.StoreChange)
+		}
+	}
+	return result, l.seq, true
+}
+
+// ConfigSeq returns the sequence number of the last modification of the CONFIG store of the cache,
+// to be passed to ConfigChanges by users that read the CONFIG store afterwards.
+func (c *ChangeLogClient) ConfigSeq(name string) uint64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.getLog(name).seq
+}
+
+// Acknowledge drops the recorded CONFIG modifications of the cache up to the sequence number seq,
+// once they are taken over by the user.
+func (c *ChangeLogClient) Acknowledge(name string, seq uint64) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	l := c.getLog(name)
+	i := 0
+	for i < len(l.changes) && l.changes[i].seq <= seq {
+		i++
+	}
+	l.changes = append(l.changes[:0:0], l.changes[i:]...)
+	l.first = max(l.first, min(seq, l.seq))
+}
+
+// IntendedModifications returns the number of modifications of the INTENDED store of the cache.
+// The number is raised as well, whenever the content of the INTENDED store is not known.
+func (c *ChangeLogClient) IntendedModifications(name string) uint64 {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.getLog(name).intended
+}
+
+// Invalidate marks the log of the cache as incomplete, e.g. after its stores were changed by someone else.
+func (c *ChangeLogClient) Invalidate(name string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.getLog(name).invalidate()
+}
+
+func (c *ChangeLogClient) getLog(name string) *changeLog {
+	l, ok := c.logs[name]
+	if !ok {
+		l = &changeLog{}
+		c.logs[name] = l
+	}
+	return l
+}
+
+func (l *changeLog) invalidate() {
+	l.seq++
+	l.first = l.seq
+	l.changes = nil
+	l.intended++
+}
+
+func (c *ChangeLogClient) Modify(ctx context.Context, name string, opts *Opts, dels [][]string, upds []*Update) error {
+	if opts == nil || opts.Store != cachepb.Store_CONFIG && opts.Store != cachepb.Store_INTENDED {
+		return c.Client.Modify(ctx, name, opts, dels, upds)
+	}
+	if opts.Store == cachepb.Store_INTENDED {
+		// counted ahead of the modification, so that it is covered when read concurrently
+		c.m.Lock()
+		c.getLog(name).intended++
+		c.m.Unlock()
+	}
+	err := c.Client.Modify(ctx, name, opts, dels, upds)
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	l := c.getLog(name)
+	if err != nil {
+		// the modification may have been partially applied
+		l.invalidate()
+		return err
+	}
+	if opts.Store == cachepb.Store_INTENDED {
+		return nil
+	}
+	l.seq++
+	l.changes = append(l.changes, &sequencedChange{seq: l.seq, StoreChange: StoreChange{Deletes: dels, Updates: upds}})
+	if len(l.changes) > maxConfigChanges {
+		dropped := len(l.changes) - maxConfigChanges
+		l.first = l.changes[dropped-1].seq
+		l.changes = append(l.changes[:0:0], l.changes[dropped:]...)
+	}
+	return nil
+}
+
+func (c *ChangeLogClient) Create(ctx context.Context, name string, ephemeral bool, cached bool) error {
+	c.Invalidate(name)
+	return c.Client.Create(ctx, name, ephemeral, cached)
+}
+
+func (c *ChangeLogClient) Delete(ctx context.Context, name string) error {
+	c.Invalidate(name)
+	return c.Client.Delete(ctx, name)
+}
+
+func (c *ChangeLogClient) Clone(ctx context.Context, name, clone string) error {
+	c.Invalidate(clone)
+	return c.Client.Clone(ctx, name, clone)
+}
+
+func (c *ChangeLogClient) Commit(ctx context.Context, name, candidate string) error {
+	err := c.Client.Commit(ctx, name, candidate)
+	c.Invalidate(name)
+	return err
+}
+
+func (c *ChangeLogClient) ApplyPrune(ctx context.Context, name, id string) error {
+	err := c.Client.ApplyPrune(ctx, name, id)
+	c.Invalidate(name)
+	return err
+}
@@ -0,0 +1,204 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+)
+
+// KeysIndexClient is a Client that keeps an in-memory index of the keys of the INTENDED store,
+// serving GetKeys of the INTENDED store without scanning the cache. The index of a cache is loaded
+// on its first GetKeys and updated incrementally on every Modify of its INTENDED store. It is
+// invalidated when the cache is created, deleted, pruned or a modification fails, and on Invalidate.
+type KeysIndexClient struct {
+	Client
+	m       sync.Mutex
+	indexes map[string]map[string][]*Update
+}
+
+// NewKeysIndexClient wraps the Client, indexing the keys of the INTENDED stores.
+func NewKeysIndexClient(c Client) *KeysIndexClient {
+	return &KeysIndexClient{Client: c, indexes: map[string]map[string][]*Update{}}
+}
+
+// Invalidate drops the index of the cache, e.g. after its INTENDED store was changed by someone else.
+// The index is reloaded on the next GetKeys.
+func (c *KeysIndexClient) Invalidate(name string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	delete(c.indexes, name)
+}
+
+// Restore sets the index of the cache to the given keys, e.g. saved when the data-server was stopped,
+// unless the index is loaded already. The keys must reflect the current INTENDED store.
+func (c *KeysIndexClient) Restore(name string, keys []*Update) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if _, ok := c.indexes[name]; ok {
+		return
+	}
+	index := make(map[string][]*Update, len(keys))
+	for _, u := range keys {
+		k := indexKey(u.GetPath())
+		index[k] = append(index[k], u)
+	}
+	c.indexes[name] = index
+}
+
+func (c *KeysIndexClient) GetKeys(ctx context.Context, name string, store cachepb.Store) (chan *Update, error) {
+	if store != cachepb.Store_INTENDED {
+		return c.Client.GetKeys(ctx, name, store)
+	}
+
+	c.m.Lock()
+	index, ok := c.indexes[name]
+	if !ok {
+		// the lock is held while loading, modifications applied meanwhile are added to the loaded index
+		var err error
+		index, err = c.loadIndex(ctx, name)
+		if err != nil {
+			c.m.Unlock()
+			return nil, err
+		}
+		c.indexes[name] = index
+	}
+	keys := make([]*Update, 0, len(index))
+	for _, upds := range index {
+		keys = append(keys, upds...)
+	}
+	c.m.Unlock()
+
+	ch := make(chan *Update)
+	go func() {
+		defer close(ch)
+		for _, k := range keys {
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- k:
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (c *KeysIndexClient) loadIndex(ctx context.Context, name string) (map[string][]*Update, error) {
+	ch, err := c.Client.GetKeys(ctx, name, cachepb.Store_INTENDED)
+	if err != nil {
+		return nil, err
+	}
+	index := map[string][]*Update{}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case u, ok := <-ch:
+			if !ok {
+				return index, nil
+			}
+			k := indexKey(u.GetPath())
+			index[k] = append(index[k], u)
+		}
+	}
+}
+
+func (c *KeysIndexClient) Modify(ctx context.Context, name string, opts *Opts, dels [][]string, upds []*Update) error {
+	err := c.Client.Modify(ctx, name, opts, dels, upds)
+	if opts == nil || opts.Store != cachepb.Store_INTENDED {
+		return err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	index, ok := c.indexes[name]
+	if !ok {
+		return err
+	}
+	if err != nil {
+		// the modification may have been partially applied
+		delete(c.indexes, name)
+		return err
+	}
+
+	for _, del := range dels {
+		for k, entries := range index {
+			if !matchesPath(entries[0].GetPath(), del) {
+				continue
+			}
+			entries = removeOwner(entries, opts.Owner)
+			if len(entries) == 0 {
+				delete(index, k)
+				continue
+			}
+			index[k] = entries
+		}
+	}
+	ts := time.Now().UnixNano()
+	for _, u := range upds {
+		k := indexKey(u.GetPath())
+		index[k] = append(removeOwner(index[k], opts.Owner), NewUpdate(u.GetPath(), nil, opts.Priority, opts.Owner, ts))
+	}
+	return nil
+}
+
+func (c *KeysIndexClient) Create(ctx context.Context, name string, ephemeral bool, cached bool) error {
+	c.Invalidate(name)
+	return c.Client.Create(ctx, name, ephemeral, cached)
+}
+
+func (c *KeysIndexClient) Delete(ctx context.Context, name string) error {
+	c.Invalidate(name)
+	return c.Client.Delete(ctx, name)
+}
+
+func (c *KeysIndexClient) Clone(ctx context.Context, name, clone string) error {
+	c.Invalidate(clone)
+	return c.Client.Clone(ctx, name, clone)
+}
+
+func (c *KeysIndexClient) Commit(ctx context.Context, name, candidate string) error {
+	err := c.Client.Commit(ctx, name, candidate)
+	c.Invalidate(name)
+	return err
+}
+
+func (c *KeysIndexClient) ApplyPrune(ctx context.Context, name, id string) error {
+	err := c.Client.ApplyPrune(ctx, name, id)
+	c.Invalidate(name)
+	return err
+}
+
+// removeOwner removes the entries of the owner, all entries if the owner is empty.
+func removeOwner(entries []*Update, owner string) []*Update {
+	if owner == "" {
+		return nil
+	}
+	result := entries[:0:0]
+	for _, e := range entries {
+		if e.Owner() != owner {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func indexKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
@@ -25,7 +25,6 @@ import (
 	"github.com/sdcio/cache/proto/cachepb"
 	"github.com/sdcio/schema-server/pkg/utils"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -0,0 +1,139 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+)
+
+// encryptedMagic prefixes the encrypted values. A protobuf encoding never starts with a zero byte,
+// hence encrypted values are distinguishable from the TypedValues stored in clear text.
+var encryptedMagic = []byte{0x00, 's', 'e', 'c', 0x01}
+
+// SecretsClient is a Client that encrypts the values at rest. All the values of the INTENTS store,
+// holding the intents as received, and the sensitive values of the INTENDED store are encrypted,
+// the values read are decrypted.
+type SecretsClient struct {
+	Client
+	aead      cipher.AEAD
+	sensitive func(path []string) bool
+}
+
+// NewSecretsClient wraps the Client, encrypting the values with the AES-256 key.
+// sensitive decides which values of the INTENDED store are encrypted.
+func NewSecretsClient(c Client, key []byte, sensitive func(path []string) bool) (*SecretsClient, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretsClient{Client: c, aead: aead, sensitive: sensitive}, nil
+}
+
+func (c *SecretsClient) encrypt(value []byte) ([]byte, error) {
+	prefixLen := len(encryptedMagic) + c.aead.NonceSize()
+	buf := make([]byte, prefixLen, prefixLen+len(value)+c.aead.Overhead())
+	copy(buf, encryptedMagic)
+	nonce := buf[len(encryptedMagic):]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(buf, nonce, value, nil), nil
+}
+
+func (c *SecretsClient) decrypt(value []byte) ([]byte, error) {
+	if !bytes.HasPrefix(value, encryptedMagic) {
+		return value, nil
+	}
+	value = value[len(encryptedMagic):]
+	if len(value) < c.aead.NonceSize() {
+		return nil, errors.New("malformed encrypted value")
+	}
+	nonce, ciphertext := value[:c.aead.NonceSize()], value[c.aead.NonceSize():]
+	return c.aead.Open(nil, nonce, ciphertext, nil)
+}
+
+func (c *SecretsClient) Modify(ctx context.Context, name string, opts *Opts, dels [][]string, upds []*Update) error {
+	if opts == nil || (opts.Store != cachepb.Store_INTENTS && opts.Store != cachepb.Store_INTENDED) {
+		return c.Client.Modify(ctx, name, opts, dels, upds)
+	}
+	encrypted := make([]*Update, 0, len(upds))
+	for _, u := range upds {
+		if opts.Store == cachepb.Store_INTENDED && !c.sensitive(u.GetPath()) {
+			encrypted = append(encrypted, u)
+			continue
+		}
+		b, err := c.encrypt(u.Bytes())
+		if err != nil {
+			return err
+		}
+		encrypted = append(encrypted, NewUpdate(u.GetPath(), b, u.Priority(), u.Owner(), u.TS()))
+	}
+	return c.Client.Modify(ctx, name, opts, dels, encrypted)
+}
+
+func (c *SecretsClient) decryptUpdate(u *Update) (*Update, bool) {
+	if !bytes.HasPrefix(u.Bytes(), encryptedMagic) {
+		return u, true
+	}
+	b, err := c.decrypt(u.Bytes())
+	if err != nil {
+		log.Errorf("failed to decrypt the value of %v: %v", u.GetPath(), err)
+		return nil, false
+	}
+	return NewUpdate(u.GetPath(), b, u.Priority(), u.Owner(), u.TS()), true
+}
+
+func (c *SecretsClient) Read(ctx context.Context, name string, opts *Opts, paths [][]string, period time.Duration) []*Update {
+	upds := c.Client.Read(ctx, name, opts, paths, period)
+	result := make([]*Update, 0, len(upds))
+	for _, u := range upds {
+		if u, ok := c.decryptUpdate(u); ok {
+			result = append(result, u)
+		}
+	}
+	return result
+}
+
+func (c *SecretsClient) ReadCh(ctx context.Context, name string, opts *Opts, paths [][]string, period time.Duration) chan *Update {
+	in := c.Client.ReadCh(ctx, name, opts, paths, period)
+	out := make(chan *Update)
+	go func() {
+		defer close(out)
+		for u := range in {
+			u, ok := c.decryptUpdate(u)
+			if !ok {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- u:
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+)
+
+// ClientStats are the statistics of the calls to a cache Client.
+type ClientStats struct {
+	Reads    uint64
+	Modifies uint64
+	KeyReads uint64
+	Errors   uint64
+}
+
+// StatsClient is a Client that keeps statistics of the reads and modifications.
+type StatsClient struct {
+	Client
+	reads    atomic.Uint64
+	modifies atomic.Uint64
+	keyReads atomic.Uint64
+	errors   atomic.Uint64
+}
+
+// NewStatsClient wraps the Client, keeping statistics of its calls.
+func NewStatsClient(c Client) *StatsClient {
+	return &StatsClient{Client: c}
+}
+
+// Stats returns the statistics of the calls since the client was created.
+func (c *StatsClient) Stats() *ClientStats {
+	return &ClientStats{
+		Reads:    c.reads.Load(),
+		Modifies: c.modifies.Load(),
+		KeyReads: c.keyReads.Load(),
+		Errors:   c.errors.Load(),
+	}
+}
+
+func (c *StatsClient) Modify(ctx context.Context, name string, opts *Opts, dels [][]string, upds []*Update) error {
+	c.modifies.Add(1)
+	err := c.Client.Modify(ctx, name, opts, dels, upds)
+	if err != nil {
+		c.errors.Add(1)
+	}
+	return err
+}
+
+func (c *StatsClient) Read(ctx context.Context, name string, opts *Opts, paths [][]string, period time.Duration) []*Update {
+	c.reads.Add(1)
+	return c.Client.Read(ctx, name, opts, paths, period)
+}
+
+func (c *StatsClient) ReadCh(ctx context.Context, name string, opts *Opts, paths [][]string, period time.Duration) chan *Update {
+	c.reads.Add(1)
+	return c.Client.ReadCh(ctx, name, opts, paths, period)
+}
+
+func (c *StatsClient) GetKeys(ctx context.Context, name string, store cachepb.Store) (chan *Update, error) {
+	c.keyReads.Add(1)
+	ch, err := c.Client.GetKeys(ctx, name, store)
+	if err != nil {
+		c.errors.Add(1)
+	}
+	return ch, err
+}
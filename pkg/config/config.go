@@ -24,9 +24,10 @@ import (
 	"time"
 
 	schemaConfig "github.com/sdcio/schema-server/pkg/config"
-	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+
+	"github.com/sdcio/data-server/pkg/dslog"
 )
 
 const ()
@@ -38,6 +39,71 @@ type Config struct {
 	SchemaServer *RemoteSchemaServer             `yaml:"schema-server,omitempty" json:"schema-server,omitempty"`
 	Cache        *CacheConfig                    `yaml:"cache,omitempty" json:"cache,omitempty"`
 	Prometheus   *PromConfig                     `yaml:"prometheus,omitempty" json:"prometheus,omitempty"`
+	// Normalization configures the normalization of values before they are compared
+	Normalization *Normalization `yaml:"normalization,omitempty" json:"normalization,omitempty"`
+	// Logging configures the format and the levels of the logs
+	Logging *Logging `yaml:"logging,omitempty" json:"logging,omitempty"`
+	// NetconfServer exposes the datastores as NETCONF servers
+	NetconfServer *NetconfServer `yaml:"netconf-server,omitempty" json:"netconf-server,omitempty"`
+}
+
+type Logging struct {
+	// Format of the log entries, one of: text, json
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// Level of all modules, one of: trace, debug, info, warn, error
+	Level string `yaml:"level,omitempty" json:"level,omitempty"`
+	// Modules override the level of individual modules.
+	// Any of: main, config, server, datastore, target, cache, schema, tree, utils
+	Modules map[string]string `yaml:"modules,omitempty" json:"modules,omitempty"`
+	// MaxValueLength truncates longer values in the log entries, e.g. certificates or banners. 0 disables the truncation.
+	MaxValueLength int `yaml:"max-value-length,omitempty" json:"max-value-length,omitempty"`
+}
+
+func (l *Logging) validateSetDefaults() error {
+	switch l.Format {
+	case "":
+		l.Format = dslog.FormatText
+	case dslog.FormatText, dslog.FormatJSON:
+	default:
+		return fmt.Errorf("unknown logging format: %s. Must be one of %s, %s", l.Format, dslog.FormatText, dslog.FormatJSON)
+	}
+	if l.Level == "" {
+		l.Level = defaultLogLevel
+	}
+	if l.MaxValueLength < 0 {
+		return fmt.Errorf("invalid logging max-value-length: %d", l.MaxValueLength)
+	}
+	return dslog.ValidateLevels(l.Level, l.Modules)
+}
+
+// Normalization configures the type aware normalization applied to the intent and the sync values,
+// e.g. MAC addresses, such that the values echoed back by devices compare equal to the intended ones.
+type Normalization struct {
+	// Disable lists the normalizations that are not applied.
+	// Any of: mac, ip, ip-prefix, decimal
+	Disable []string `yaml:"disable,omitempty" json:"disable,omitempty"`
+}
+
+const (
+	normalizationMAC      = "mac"
+	normalizationIP       = "ip"
+	normalizationIPPrefix = "ip-prefix"
+	normalizationDecimal  = "decimal"
+)
+
+func (n *Normalization) validateSetDefaults() error {
+	for _, d := range n.Disable {
+		switch d {
+		case normalizationMAC:
+		case normalizationIP:
+		case normalizationIPPrefix:
+		case normalizationDecimal:
+		default:
+			return fmt.Errorf("unknown normalization %q, must be one of: %s, %s, %s, %s",
+				d, normalizationMAC, normalizationIP, normalizationIPPrefix, normalizationDecimal)
+		}
+	}
+	return nil
 }
 
 type TLS struct {
@@ -72,6 +138,18 @@ func (c *Config) validateSetDefaults() error {
 	if err != nil {
 		return err
 	}
+	if c.Logging == nil {
+		c.Logging = &Logging{}
+	}
+	err = c.Logging.validateSetDefaults()
+	if err != nil {
+		return err
+	}
+	if c.NetconfServer != nil {
+		if err = c.NetconfServer.validateSetDefaults(); err != nil {
+			return err
+		}
+	}
 
 	// make sure either local or remote schema stores are enabled
 	if c.SchemaStore != nil && c.SchemaServer != nil {
@@ -117,6 +195,12 @@ func (c *Config) validateSetDefaults() error {
 			return err
 		}
 	}
+	if c.Normalization == nil {
+		c.Normalization = &Normalization{}
+	}
+	if err = c.Normalization.validateSetDefaults(); err != nil {
+		return err
+	}
 	if c.Cache == nil {
 		c.Cache = &CacheConfig{}
 	}
@@ -160,7 +244,14 @@ type GRPCServer struct {
 	SchemaServer   *SchemaServer `yaml:"schema-server,omitempty" json:"schema-server,omitempty"`
 	DataServer     *DataServer   `yaml:"data-server,omitempty" json:"data-server,omitempty"`
 	MaxRecvMsgSize int           `yaml:"max-recv-msg-size,omitempty" json:"max-recv-msg-size,omitempty"`
+	MaxSendMsgSize int           `yaml:"max-send-msg-size,omitempty" json:"max-send-msg-size,omitempty"`
 	RPCTimeout     time.Duration `yaml:"rpc-timeout,omitempty" json:"rpc-timeout,omitempty"`
+	// Reflection enables the gRPC server reflection, e.g. for grpcurl
+	Reflection bool `yaml:"reflection,omitempty" json:"reflection,omitempty"`
+	// Debug enables the debug service exposing runtime information of the server and its datastores
+	Debug bool `yaml:"debug,omitempty" json:"debug,omitempty"`
+	// GNMI enables the gNMI service, translating gNMI requests into the intent model
+	GNMI *GNMIServer `yaml:"gnmi,omitempty" json:"gnmi,omitempty"`
 }
 
 func (g *GRPCServer) validateSetDefaults() error {
@@ -170,9 +261,15 @@ func (g *GRPCServer) validateSetDefaults() error {
 	if g.MaxRecvMsgSize <= 0 {
 		g.MaxRecvMsgSize = defaultMaxRecvMsgSize
 	}
+	if g.MaxSendMsgSize <= 0 {
+		g.MaxSendMsgSize = defaultMaxSendMsgSize
+	}
 	if g.RPCTimeout <= 0 {
 		g.RPCTimeout = defaultRPCTimeout
 	}
+	if g.GNMI != nil {
+		return g.GNMI.validateSetDefaults()
+	}
 	return nil
 }
 
@@ -211,6 +308,64 @@ type DataServer struct {
 	MaxCandidates int `yaml:"max-candidates,omitempty" json:"max-candidates,omitempty"`
 }
 
+// GNMIServer configures the gNMI compatibility layer. The values set via gNMI are merged into a single intent
+// per datastore, the datastore is selected by the target of the gNMI request prefix.
+type GNMIServer struct {
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+	// Intent is the name of the intent holding the values set via gNMI
+	Intent string `yaml:"intent,omitempty" json:"intent,omitempty"`
+	// Priority of the intent holding the values set via gNMI
+	Priority int32 `yaml:"priority,omitempty" json:"priority,omitempty"`
+}
+
+func (g *GNMIServer) validateSetDefaults() error {
+	if g.Intent == "" {
+		g.Intent = defaultGNMIIntent
+	}
+	if g.Priority == 0 {
+		g.Priority = defaultGNMIPriority
+	}
+	if g.Priority < 0 {
+		return fmt.Errorf("invalid gnmi priority: %d", g.Priority)
+	}
+	return nil
+}
+
+// NetconfServer configures the NETCONF facade of the datastores. The clients select the datastore via the
+// SSH user, formatted as <username>@<datastore>, the datastore may be omitted if there is a single one.
+// get-config and get return the configuration of the device, edit-config is merged into a single intent per datastore.
+type NetconfServer struct {
+	// Address the SSH server listens on
+	Address string `yaml:"address,omitempty" json:"address,omitempty"`
+	// HostKey is the file of the PEM encoded private host key, an ephemeral key is generated if not set
+	HostKey string `yaml:"host-key,omitempty" json:"host-key,omitempty"`
+	// Credentials of the clients
+	Credentials *Creds `yaml:"credentials,omitempty" json:"credentials,omitempty"`
+	// Intent is the name of the intent holding the configuration set via edit-config
+	Intent string `yaml:"intent,omitempty" json:"intent,omitempty"`
+	// Priority of the intent holding the configuration set via edit-config
+	Priority int32 `yaml:"priority,omitempty" json:"priority,omitempty"`
+}
+
+func (n *NetconfServer) validateSetDefaults() error {
+	if n.Address == "" {
+		n.Address = defaultNetconfServerAddress
+	}
+	if n.Credentials == nil || n.Credentials.Username == "" || n.Credentials.Password == "" {
+		return errors.New("netconf-server requires the username and password of the clients")
+	}
+	if n.Intent == "" {
+		n.Intent = defaultNetconfServerIntent
+	}
+	if n.Priority == 0 {
+		n.Priority = defaultNetconfServerPriority
+	}
+	if n.Priority < 0 {
+		return fmt.Errorf("invalid netconf-server priority: %d", n.Priority)
+	}
+	return nil
+}
+
 type SchemaServer struct {
 	Enabled          bool   `yaml:"enabled,omitempty" json:"enabled,omitempty"`
 	SchemasDirectory string `yaml:"schemas-directory,omitempty" json:"schemas-directory,omitempty"`
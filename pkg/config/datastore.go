@@ -15,19 +15,86 @@
 package config
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"slices"
+	"strings"
 	"time"
 )
 
 const (
-	sbiNOOP    = "noop"
-	sbiNETCONF = "netconf"
-	sbiGNMI    = "gnmi"
+	sbiNOOP      = "noop"
+	sbiSimulated = "simulated"
+	sbiNETCONF   = "netconf"
+	sbiGNMI      = "gnmi"
+	sbiCLI       = "cli"
 
 	ncCommitDatastoreRunning   = "running"
 	ncCommitDatastoreCandidate = "candidate"
+
+	ncDefaultOperationMerge   = "merge"
+	ncDefaultOperationReplace = "replace"
+	ncDefaultOperationNone    = "none"
+
+	ncDefaultConfirmTimeout = time.Minute
+
+	conflictPolicyReject    = "reject"
+	conflictPolicyFirstWins = "first-wins"
+	conflictPolicyLastWins  = "last-wins"
+
+	tieBreakOwner  = "owner"
+	tieBreakNewest = "newest"
+
+	gnmiEncodingJSON     = "json"
+	gnmiEncodingJSONIETF = "json_ietf"
+	gnmiEncodingProto    = "proto"
+	gnmiEncodingASCII    = "ascii"
+
+	gnmiApplyModeUpdate       = "update"
+	gnmiApplyModeReplace      = "replace"
+	gnmiApplyModeUnionReplace = "union-replace"
+
+	deleteAggregationLeaf     = "leaf"
+	deleteAggregationInstance = "instance"
+	deleteAggregationAncestor = "ancestor"
+
+	applyModeIncremental = "incremental"
+	applyModeFullReplace = "full-replace"
+
+	pushModeImmediate = "immediate"
+	pushModeManual    = "manual"
+
+	validationScopeIntended           = "intended"
+	validationScopeIntendedAndRunning = "intended-and-running"
+
+	cliPlatformNokiaSRL   = "nokia_srl"
+	cliPlatformNokiaSROS  = "nokia_sros"
+	cliPlatformCiscoIOSXR = "cisco_iosxr"
+
+	statusPolicyIgnore = "ignore"
+	statusPolicyWarn   = "warn"
+	statusPolicyReject = "reject"
+
+	runningProtectionOverwrite = "overwrite"
+	runningProtectionForce     = "force"
+	runningProtectionNever     = "never"
+
+	walRecoveryReplay   = "replay"
+	walRecoveryRollback = "rollback"
+
+	hookPhasePre  = "pre"
+	hookPhasePost = "post"
+
+	hookFailurePolicyFail   = "fail"
+	hookFailurePolicyIgnore = "ignore"
+
+	hookPluginGRPC = "grpc"
+
+	syncDataTypeConfig = "config"
+	syncDataTypeState  = "state"
 )
 
 type DatastoreConfig struct {
@@ -35,33 +102,507 @@ type DatastoreConfig struct {
 	Schema *SchemaConfig `yaml:"schema,omitempty" json:"schema,omitempty"`
 	SBI    *SBI          `yaml:"sbi,omitempty" json:"sbi,omitempty"`
 	Sync   *Sync         `yaml:"sync,omitempty" json:"sync,omitempty"`
+	// ConflictPolicy defines how intents that write the same path with the same priority are handled.
+	// One of: reject, first-wins, last-wins
+	ConflictPolicy string `yaml:"conflict-policy,omitempty" json:"conflict-policy,omitempty"`
+	// TieBreak defines how the remaining same priority ties between intents are broken.
+	// One of: owner (lexically lower owner wins), newest (most recently modified value wins)
+	TieBreak string `yaml:"tie-break,omitempty" json:"tie-break,omitempty"`
+	// DeleteAggregation defines up to which level deletes sent to the target are aggregated.
+	// One of: leaf, instance (list instance or container), ancestor (highest removed ancestor)
+	DeleteAggregation string `yaml:"delete-aggregation,omitempty" json:"delete-aggregation,omitempty"`
+	// ValidationScope defines the config that must-statements and leafrefs are evaluated against.
+	// One of: intended (the merged intents only), intended-and-running (including the config of the device)
+	ValidationScope string `yaml:"validation-scope,omitempty" json:"validation-scope,omitempty"`
+	// Journal configures the journal of the requests sent to the target
+	Journal *Journal `yaml:"journal,omitempty" json:"journal,omitempty"`
+	// WAL enables the write-ahead log of the SetIntentRequests, such that operations interrupted by a crash
+	// are recovered on restart
+	WAL *WAL `yaml:"wal,omitempty" json:"wal,omitempty"`
+	// IdempotencyTTL is the time the outcomes of SetIntentRequests carrying an idempotency key are retained,
+	// such that retried requests return the original outcome instead of being applied again
+	IdempotencyTTL time.Duration `yaml:"idempotency-ttl,omitempty" json:"idempotency-ttl,omitempty"`
+	// Variables are resolved in the values of intents, e.g. {{ .hostname }}.
+	// The variable targetName is always set to the name of the datastore.
+	Variables map[string]string `yaml:"variables,omitempty" json:"variables,omitempty"`
+	// OmitKeyLeaves disables the synthesis of the key leaves of list entries from the intent paths and values.
+	// The keys are then only carried by the list entry paths and not written to the intended store as separate leaves.
+	OmitKeyLeaves bool `yaml:"omit-key-leaves,omitempty" json:"omit-key-leaves,omitempty"`
+	// ApplyMode defines how the intended config is pushed to the target.
+	// One of: incremental (the changes of an intent only), full-replace (the complete intended config,
+	// using a netconf copy-config or a gNMI replace at the root). full-replace is supported by gnmi and netconf targets.
+	ApplyMode string `yaml:"apply-mode,omitempty" json:"apply-mode,omitempty"`
+	// RunningProtection defines whether intents may overwrite config that is only present in running,
+	// hence learned from the device and not owned by any intent.
+	// One of: overwrite (overwrites are reported as warnings), force (requires the force flag), never
+	RunningProtection string `yaml:"running-protection,omitempty" json:"running-protection,omitempty"`
+	// IntentTimeouts bound the phases of a SetIntent, such that a hung schema server, cache or device
+	// does not block the intents of the datastore forever
+	IntentTimeouts *IntentTimeouts `yaml:"intent-timeouts,omitempty" json:"intent-timeouts,omitempty"`
+	// PriorityBands reserve priority ranges for the roles of the clients, e.g. 0-99 for system, 100-999 for services.
+	// Priorities that are not covered by a band can be used by all clients.
+	PriorityBands []*PriorityBand `yaml:"priority-bands,omitempty" json:"priority-bands,omitempty"`
+	// Hooks are the plugins run by the intent apply pipeline, in the configured order
+	Hooks []*Hook `yaml:"hooks,omitempty" json:"hooks,omitempty"`
+	// Secrets configures the sensitive values, which are encrypted at rest and redacted
+	Secrets *Secrets `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+	// ApplyWindows are the maintenance windows the changes are pushed to the device in. Outside of the windows
+	// intents are validated and stored, their changes are pushed once the next window opens.
+	// The changes are pushed immediately if no windows are configured.
+	ApplyWindows []*ApplyWindow `yaml:"apply-windows,omitempty" json:"apply-windows,omitempty"`
+	// PushMode defines when the changes of the intents are pushed to the device.
+	// One of: immediate (by the SetIntent, or once the next apply window opens), manual (SetIntent only updates
+	// the intended store, the accumulated changes are pushed in a single transaction by PushChanges)
+	PushMode string `yaml:"push-mode,omitempty" json:"push-mode,omitempty"`
+	// Lint configures the checks flagging suspicious, but valid intents. Their findings are
+	// returned as warnings of the SetIntent, they never reject an intent.
+	Lint *Lint `yaml:"lint,omitempty" json:"lint,omitempty"`
+	// StatusPolicy defines how intents configuring schema nodes with the YANG status deprecated
+	// or obsolete are handled
+	StatusPolicy *StatusPolicy `yaml:"status-policy,omitempty" json:"status-policy,omitempty"`
+	// IncrementalTree keeps the tree of the last SetIntent and applies the changes of the next intent and
+	// of running to it, instead of building the tree from the intended store and running per intent.
+	// The tree is rebuilt whenever the intended store was modified by other operations.
+	IncrementalTree bool `yaml:"incremental-tree,omitempty" json:"incremental-tree,omitempty"`
+	// WarmStart saves the index of the intended store keys when the datastore is stopped and restores it on the
+	// next start, such that the first SetIntent after a restart does not read all the intended store keys.
+	// The schemas of the restored keys are looked up in the background, warming the schema client up.
+	WarmStart bool `yaml:"warm-start,omitempty" json:"warm-start,omitempty"`
+}
+
+type Secrets struct {
+	// Paths of the sensitive values, e.g. system/aaa/authentication/user/*/password.
+	// The elements are separated by slashes, list entries are followed by their key values in the
+	// alphabetical order of the key names and * matches any single element.
+	Paths []string `yaml:"paths,omitempty" json:"paths,omitempty"`
+	// KeyFile is the file holding the hex encoded 32 byte AES-256 key the values are encrypted with
+	KeyFile string `yaml:"key-file,omitempty" json:"key-file,omitempty"`
+	// PrivilegedRoles may retrieve the sensitive values in clear text. The role of a client is the
+	// first organizational unit of its verified TLS client certificate.
+	PrivilegedRoles []string `yaml:"privileged-roles,omitempty" json:"privileged-roles,omitempty"`
+
+	key      []byte
+	patterns [][]string
+}
+
+func (s *Secrets) validateSetDefaults() error {
+	if s.KeyFile == "" {
+		return errors.New("secrets: missing key-file")
+	}
+	b, err := os.ReadFile(s.KeyFile)
+	if err != nil {
+		return fmt.Errorf("secrets: failed to read key-file: %w", err)
+	}
+	s.key, err = hex.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return fmt.Errorf("secrets: malformed key-file: %w", err)
+	}
+	if len(s.key) != 32 {
+		return fmt.Errorf("secrets: the key must be 32 bytes, got %d", len(s.key))
+	}
+	s.patterns = make([][]string, 0, len(s.Paths))
+	for _, p := range s.Paths {
+		p = strings.Trim(p, "/")
+		if p == "" {
+			return errors.New("secrets: empty path")
+		}
+		s.patterns = append(s.patterns, strings.Split(p, "/"))
+	}
+	return nil
+}
+
+// Key returns the AES-256 key the sensitive values are encrypted with.
+func (s *Secrets) Key() []byte {
+	return s.key
+}
+
+// IsSensitive returns true if the value of the path is sensitive.
+func (s *Secrets) IsSensitive(path []string) bool {
+	if s == nil {
+		return false
+	}
+	for _, pattern := range s.patterns {
+		if len(pattern) == len(path) && matchPathPattern(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsSensitive returns true if the value of the path is sensitive or, in case of a container,
+// holds sensitive values.
+func (s *Secrets) ContainsSensitive(path []string) bool {
+	if s == nil {
+		return false
+	}
+	for _, pattern := range s.patterns {
+		if len(pattern) >= len(path) && matchPathPattern(pattern[:len(path)], path) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPrivileged returns true if the role may retrieve the sensitive values in clear text.
+func (s *Secrets) IsPrivileged(role string) bool {
+	return s == nil || (role != "" && slices.Contains(s.PrivilegedRoles, role))
+}
+
+func matchPathPattern(pattern []string, path []string) bool {
+	for i, e := range pattern {
+		if e != "*" && e != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type Lint struct {
+	// Disabled turns the lint checks off
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	// RolePaths are the subtrees the intents of a role are expected to stay within, keyed by role.
+	// The paths have the format of the secrets paths. The intents of roles without paths are not checked.
+	RolePaths map[string][]string `yaml:"role-paths,omitempty" json:"role-paths,omitempty"`
+
+	rolePatterns map[string][][]string
+}
+
+func (l *Lint) validateSetDefaults() error {
+	l.rolePatterns = make(map[string][][]string, len(l.RolePaths))
+	for role, paths := range l.RolePaths {
+		if role == "" {
+			return errors.New("lint: empty role")
+		}
+		for _, p := range paths {
+			p = strings.Trim(p, "/")
+			if p == "" {
+				return fmt.Errorf("lint: empty path of role %s", role)
+			}
+			l.rolePatterns[role] = append(l.rolePatterns[role], strings.Split(p, "/"))
+		}
+	}
+	return nil
+}
+
+// IsEnabled returns true if the intents are linted.
+func (l *Lint) IsEnabled() bool {
+	return l == nil || !l.Disabled
+}
+
+// AllowsPath returns true if the path is below one of the paths of the role,
+// or if no paths are configured for the role.
+func (l *Lint) AllowsPath(role string, path []string) bool {
+	if l == nil {
+		return true
+	}
+	patterns, ok := l.rolePatterns[role]
+	if !ok {
+		return true
+	}
+	for _, pattern := range patterns {
+		if len(pattern) <= len(path) && matchPathPattern(pattern, path[:len(pattern)]) {
+			return true
+		}
+	}
+	return false
+}
+
+type StatusPolicy struct {
+	// Deprecated is the handling of deprecated nodes. One of: ignore, warn (default), reject
+	Deprecated string `yaml:"deprecated,omitempty" json:"deprecated,omitempty"`
+	// Obsolete is the handling of obsolete nodes. One of: ignore, warn (default), reject
+	Obsolete string `yaml:"obsolete,omitempty" json:"obsolete,omitempty"`
+}
+
+func (p *StatusPolicy) validateSetDefaults() error {
+	for _, v := range []*string{&p.Deprecated, &p.Obsolete} {
+		switch *v {
+		case "":
+			*v = statusPolicyWarn
+		case statusPolicyIgnore, statusPolicyWarn, statusPolicyReject:
+		default:
+			return fmt.Errorf("status-policy: unknown handling %s. Must be one of %s, %s, %s",
+				*v, statusPolicyIgnore, statusPolicyWarn, statusPolicyReject)
+		}
+	}
+	return nil
+}
+
+type Hook struct {
+	// Name identifies the hook in logs and errors
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Plugin is the registered plugin running the hook, grpc calls an external plugin via gRPC
+	Plugin string `yaml:"plugin,omitempty" json:"plugin,omitempty"`
+	// Phase defines when the hook runs.
+	// One of: pre (before validation, the hook may mutate the intent), post (after the intent is applied)
+	Phase string `yaml:"phase,omitempty" json:"phase,omitempty"`
+	// FailurePolicy defines whether a failing pre hook rejects the intent.
+	// One of: fail, ignore. Failing post hooks are only logged, the intent is already applied.
+	FailurePolicy string `yaml:"failure-policy,omitempty" json:"failure-policy,omitempty"`
+	// Timeout bounds a single run of the hook
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// Address of the external grpc plugin
+	Address string `yaml:"address,omitempty" json:"address,omitempty"`
+	// TLS config of the connection to the external grpc plugin
+	TLS *TLS `yaml:"tls,omitempty" json:"tls,omitempty"`
+	// Options are passed to the plugin
+	Options map[string]string `yaml:"options,omitempty" json:"options,omitempty"`
+}
+
+func (h *Hook) validateSetDefaults() error {
+	if h.Name == "" {
+		return errors.New("missing hook name")
+	}
+	if h.Plugin == "" {
+		return fmt.Errorf("hook %s is missing a plugin", h.Name)
+	}
+	if h.Plugin == hookPluginGRPC && h.Address == "" {
+		return fmt.Errorf("hook %s is missing the address of the grpc plugin", h.Name)
+	}
+	switch h.Phase {
+	case hookPhasePre:
+	case hookPhasePost:
+	default:
+		return fmt.Errorf("unknown phase of hook %s: %s. Must be one of %s, %s", h.Name, h.Phase, hookPhasePre, hookPhasePost)
+	}
+	switch h.FailurePolicy {
+	case "":
+		h.FailurePolicy = hookFailurePolicyFail
+	case hookFailurePolicyFail:
+	case hookFailurePolicyIgnore:
+	default:
+		return fmt.Errorf("unknown failure-policy of hook %s: %s. Must be one of %s, %s", h.Name, h.FailurePolicy, hookFailurePolicyFail, hookFailurePolicyIgnore)
+	}
+	if h.Timeout <= 0 {
+		h.Timeout = defaultHookTimeout
+	}
+	return nil
+}
+
+type ApplyWindow struct {
+	// Days the window opens on, e.g. sat, sun. The window opens every day if not set.
+	Days []string `yaml:"days,omitempty" json:"days,omitempty"`
+	// Start is the time of day the window opens, e.g. 02:00
+	Start string `yaml:"start,omitempty" json:"start,omitempty"`
+	// Duration the window stays open, at most 24h
+	Duration time.Duration `yaml:"duration,omitempty" json:"duration,omitempty"`
+	// Location is the time zone of the days and the start, e.g. Europe/Brussels. Defaults to the local time zone.
+	Location string `yaml:"location,omitempty" json:"location,omitempty"`
+
+	weekdays map[time.Weekday]struct{}
+	start    time.Duration
+	loc      *time.Location
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func (w *ApplyWindow) validateSetDefaults() error {
+	w.weekdays = make(map[time.Weekday]struct{}, len(w.Days))
+	for _, d := range w.Days {
+		wd, ok := weekdays[strings.ToLower(d)]
+		if !ok {
+			return fmt.Errorf("apply-window: unknown day: %s. Must be one of sun, mon, tue, wed, thu, fri, sat", d)
+		}
+		w.weekdays[wd] = struct{}{}
+	}
+	start, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return fmt.Errorf("apply-window: malformed start %q, expected hh:mm: %w", w.Start, err)
+	}
+	w.start = time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute
+	if w.Duration <= 0 || w.Duration > 24*time.Hour {
+		return fmt.Errorf("apply-window: the duration must be within (0, 24h], got %s", w.Duration)
+	}
+	w.loc = time.Local
+	if w.Location != "" {
+		w.loc, err = time.LoadLocation(w.Location)
+		if err != nil {
+			return fmt.Errorf("apply-window: unknown location: %w", err)
+		}
+	}
+	return nil
+}
+
+// opening returns the time the window opens on the day of t.
+func (w *ApplyWindow) opening(t time.Time) time.Time {
+	y, m, d := t.In(w.loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, w.loc).Add(w.start)
+}
+
+func (w *ApplyWindow) opensOn(t time.Time) bool {
+	if len(w.weekdays) == 0 {
+		return true
+	}
+	_, ok := w.weekdays[t.In(w.loc).Weekday()]
+	return ok
+}
+
+// IsOpen returns true if the window is open at t.
+func (w *ApplyWindow) IsOpen(t time.Time) bool {
+	t = t.In(w.loc)
+	// windows that opened the day before may still be open
+	for _, day := range []time.Time{t.AddDate(0, 0, -1), t} {
+		o := w.opening(day)
+		if w.opensOn(o) && !t.Before(o) && t.Before(o.Add(w.Duration)) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpening returns the first time after t the window opens.
+func (w *ApplyWindow) NextOpening(t time.Time) time.Time {
+	t = t.In(w.loc)
+	for i := 0; i <= 7; i++ {
+		o := w.opening(t.AddDate(0, 0, i))
+		if w.opensOn(o) && o.After(t) {
+			return o
+		}
+	}
+	// not reached, the window opens at least once a week
+	return t
+}
+
+// ApplyWindowOpen returns true if the changes may be pushed to the device at t,
+// hence one of the apply windows is open or no windows are configured.
+func (ds *DatastoreConfig) ApplyWindowOpen(t time.Time) bool {
+	if len(ds.ApplyWindows) == 0 {
+		return true
+	}
+	for _, w := range ds.ApplyWindows {
+		if w.IsOpen(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextApplyWindow returns the first time after t one of the apply windows opens, the zero time if no windows are configured.
+func (ds *DatastoreConfig) NextApplyWindow(t time.Time) time.Time {
+	var next time.Time
+	for _, w := range ds.ApplyWindows {
+		if o := w.NextOpening(t); next.IsZero() || o.Before(next) {
+			next = o
+		}
+	}
+	return next
+}
+
+type PriorityBand struct {
+	// Role that may set intents with a priority within the band. The role of a client is the
+	// first organizational unit of its verified TLS client certificate.
+	Role string `yaml:"role,omitempty" json:"role,omitempty"`
+	// Min is the lowest priority of the band
+	Min int32 `yaml:"min,omitempty" json:"min,omitempty"`
+	// Max is the highest priority of the band, no upper bound if not set
+	Max int32 `yaml:"max,omitempty" json:"max,omitempty"`
+}
+
+// Contains returns true if the priority is within the band.
+func (b *PriorityBand) Contains(priority int32) bool {
+	return priority >= b.Min && (b.Max == 0 || priority <= b.Max)
+}
+
+// PriorityBand returns the band the priority is reserved by, nil if it is not reserved.
+func (ds *DatastoreConfig) PriorityBand(priority int32) *PriorityBand {
+	for _, b := range ds.PriorityBands {
+		if b.Contains(priority) {
+			return b
+		}
+	}
+	return nil
+}
+
+type IntentTimeouts struct {
+	// Schema bounds the expansion of the intent values and their type checks against the schema
+	Schema time.Duration `yaml:"schema,omitempty" json:"schema,omitempty"`
+	// Populate bounds building the tree from the intent, the intended store and the running config,
+	// including the schema phase
+	Populate time.Duration `yaml:"populate,omitempty" json:"populate,omitempty"`
+	// Validation bounds the validation of the resulting tree
+	Validation time.Duration `yaml:"validation,omitempty" json:"validation,omitempty"`
+	// Apply bounds applying the changes to the device
+	Apply time.Duration `yaml:"apply,omitempty" json:"apply,omitempty"`
+}
+
+type WAL struct {
+	// Recovery defines how interrupted operations are recovered on restart.
+	// One of: replay (the interrupted request is applied again), rollback (the previous version of the intent is restored)
+	Recovery string `yaml:"recovery,omitempty" json:"recovery,omitempty"`
+}
+
+type Journal struct {
+	// Size is the number of journal entries retained
+	Size int `yaml:"size,omitempty" json:"size,omitempty"`
 }
 
 type SBI struct {
-	// Southbound interface type, one of: gnmi, netconf
+	// Southbound interface type, one of: gnmi, netconf, cli, noop or simulated.
+	// The simulated target applies the changes to a copy of the CONFIG store instead of a device.
 	Type string `yaml:"type,omitempty" json:"type,omitempty"`
 	// gNMI or netconf address
 	Address string `yaml:"address,omitempty" json:"address,omitempty"`
-	Port    uint32 `yaml:"port,omitempty" json:"port,omitempty"`
+	// Addresses are alternative addresses of the target (e.g. in-band and out-of-band management),
+	// they are tried in order after Address when connecting fails.
+	Addresses []string `yaml:"addresses,omitempty" json:"addresses,omitempty"`
+	Port      uint32   `yaml:"port,omitempty" json:"port,omitempty"`
 	// TLS config
 	TLS *TLS `yaml:"tls,omitempty" json:"tls,omitempty"`
 	// Target SBI credentials
 	Credentials    *Creds             `yaml:"credentials,omitempty" json:"credentials,omitempty"`
 	NetconfOptions *SBINetconfOptions `yaml:"netconf-options,omitempty" json:"netconf-options,omitempty"`
 	GnmiOptions    *SBIGnmiOptions    `yaml:"gnmi-options,omitempty" json:"gnmi-options,omitempty"`
+	CLIOptions     *SBICLIOptions     `yaml:"cli-options,omitempty" json:"cli-options,omitempty"`
 	// ConnectRetry
 	ConnectRetry time.Duration `yaml:"connect-retry,omitempty" json:"connect-retry,omitempty"`
+	// MaxBackoff caps the exponential backoff between reconnect attempts, which starts at ConnectRetry
+	MaxBackoff time.Duration `yaml:"max-backoff,omitempty" json:"max-backoff,omitempty"`
+	// MaxRetries limits the number of reconnect attempts after the connection broke, 0 retries forever
+	MaxRetries int `yaml:"max-retries,omitempty" json:"max-retries,omitempty"`
 	// Timeout
 	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// ConnectTimeout bounds the connection establishment, including the session setup (NETCONF hello, gNMI dial)
+	ConnectTimeout time.Duration `yaml:"connect-timeout,omitempty" json:"connect-timeout,omitempty"`
+	// Keepalive is the interval of the keepalive probes, 0 disables them.
+	// gNMI uses gRPC keepalive pings, NETCONF sends a get-config that selects no data.
+	Keepalive time.Duration `yaml:"keepalive,omitempty" json:"keepalive,omitempty"`
+	// IdleTimeout is the time to wait for the answer to a keepalive probe, before the session is considered dead
+	IdleTimeout time.Duration `yaml:"idle-timeout,omitempty" json:"idle-timeout,omitempty"`
 }
 
 type SBIGnmiOptions struct {
+	// encoding used in the Get, Set and Subscribe requests towards the target,
+	// one of: json, json_ietf, proto, ascii
 	Encoding string `yaml:"encoding,omitempty" json:"encoding,omitempty"`
+	// origin set in the Get, Set and Subscribe requests towards the target, e.g. openconfig, native
+	Origin string `yaml:"origin,omitempty" json:"origin,omitempty"`
+	// defines how changes are applied to the target, one of: update (fine-grained update and delete),
+	// replace or union-replace (the changed branches are replaced as a whole). Replace modes require json or json_ietf encoding.
+	ApplyMode string `yaml:"apply-mode,omitempty" json:"apply-mode,omitempty"`
+}
+
+type SBICLIOptions struct {
+	// scrapligo platform of the target, one of: nokia_srl, nokia_sros, cisco_iosxr
+	Platform string `yaml:"platform,omitempty" json:"platform,omitempty"`
+	// Go template rendering the updates and deletes into CLI commands, one command per line.
+	// Overrides the default template of the platform. The commit must be skipped if .DryRun is set.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
 }
 
 type SBINetconfOptions struct {
 	// if true, the namespace is included as an `xmlns` attribute in the netconf payloads
 	IncludeNS bool `yaml:"include-ns,omitempty" json:"include-ns,omitempty"`
+	// if true, the netconf filters qualify the elements with their module prefix,
+	// declared where the module is entered. Required by strict netconf servers for augmented nodes.
+	NamespacePrefixes bool `yaml:"namespace-prefixes,omitempty" json:"namespace-prefixes,omitempty"`
 	// sets the preferred NC version: 1.0 or 1.1
 	PreferredNCVersion string `yaml:"preferred-nc-version,omitempty" json:"preferred-nc-version,omitempty"`
 	// add a namespace when specifying a netconf operation such as 'delete' or 'remove'
@@ -70,6 +611,18 @@ type SBINetconfOptions struct {
 	UseOperationRemove bool `yaml:"use-operation-remove,omitempty" json:"use-operation-remove,omitempty"`
 	// for netconf targets: defines whether to commit to running or use a candidate.
 	CommitDatastore string `yaml:"commit-datastore,omitempty" json:"commit-datastore,omitempty"`
+	// the default-operation of the edit-config RPC: merge, replace or none
+	DefaultOperation string `yaml:"default-operation,omitempty" json:"default-operation,omitempty"`
+	// if true, values and list entries that do not yet exist on the target are sent
+	// with operation `create` rather than merged, such that the target rejects conflicting data.
+	CreateNew bool `yaml:"create-new,omitempty" json:"create-new,omitempty"`
+	// the RPC that returns the diff of the candidate against running, e.g.
+	// `<get-configuration compare="rollback" rollback="0" format="text"/>` on JunOS.
+	// Enables device diffs for dry-runs, requires the candidate commit-datastore.
+	DiffRPC string `yaml:"diff-rpc,omitempty" json:"diff-rpc,omitempty"`
+	// the timeout of the confirmed commits of multi-device transactions, the device rolls the changes back
+	// unless the commit is confirmed within the timeout. Requires the candidate commit-datastore.
+	ConfirmTimeout time.Duration `yaml:"confirm-timeout,omitempty" json:"confirm-timeout,omitempty"`
 }
 
 type Creds struct {
@@ -83,6 +636,19 @@ type Sync struct {
 	Buffer       int64           `yaml:"buffer,omitempty" json:"buffer,omitempty"`
 	WriteWorkers int64           `yaml:"write-workers,omitempty" json:"write-workers,omitempty"`
 	Config       []*SyncProtocol `yaml:"config,omitempty" json:"config,omitempty"`
+	// OutOfBand enables the detection of configuration changes performed outside of the data-server
+	OutOfBand *SyncOutOfBand `yaml:"out-of-band,omitempty" json:"out-of-band,omitempty"`
+	// TransactionalSwap buffers the notifications of a sync iteration and writes them into the cache at its end,
+	// while reads are held back. Reads never observe a partially synced store, at the cost of buffering a full sync in memory.
+	TransactionalSwap bool `yaml:"transactional-swap,omitempty" json:"transactional-swap,omitempty"`
+	// PauseOnApply pauses the sync while changes are pushed to the device, the sync restarts
+	// with a full sync afterwards, verifying the pushed changes.
+	PauseOnApply bool `yaml:"pause-on-apply,omitempty" json:"pause-on-apply,omitempty"`
+}
+
+type SyncOutOfBand struct {
+	// Reconcile restores the intended values of paths that were changed out-of-band
+	Reconcile bool `yaml:"reconcile,omitempty" json:"reconcile,omitempty"`
 }
 
 type SyncProtocol struct {
@@ -92,6 +658,14 @@ type SyncProtocol struct {
 	Interval time.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
 	Mode     string        `yaml:"mode,omitempty" json:"mode,omitempty"`
 	Encoding string        `yaml:"encoding,omitempty" json:"encoding,omitempty"`
+	// DataType selects the data that is synced, one of: config, state.
+	// State is retrieved using netconf <get> or gNMI Get/Subscribe and written into the STATE store.
+	DataType string `yaml:"data-type,omitempty" json:"data-type,omitempty"`
+	// Jitter adds a random delay of up to the given duration to every periodic sync,
+	// to spread the load of many targets syncing with the same interval.
+	Jitter time.Duration `yaml:"jitter,omitempty" json:"jitter,omitempty"`
+	// Align aligns the periodic syncs to multiples of the interval, e.g. every full hour for an interval of 1h.
+	Align bool `yaml:"align,omitempty" json:"align,omitempty"`
 }
 
 type CacheConfig struct {
@@ -102,6 +676,31 @@ type CacheConfig struct {
 	Dir       string `yaml:"dir,omitempty" json:"dir,omitempty"`
 	// Remote cache attr
 	Address string `yaml:"address,omitempty" json:"address,omitempty"`
+	// Batching coalesces the reads and modifications of the cache into fewer, larger calls
+	Batching *CacheBatching `yaml:"batching,omitempty" json:"batching,omitempty"`
+}
+
+type CacheBatching struct {
+	// FlushInterval is the maximum time a call waits for other calls to be batched with
+	FlushInterval time.Duration `yaml:"flush-interval,omitempty" json:"flush-interval,omitempty"`
+	// MaxSize is the number of paths a batch is sent at, regardless of the flush interval
+	MaxSize int `yaml:"max-size,omitempty" json:"max-size,omitempty"`
+}
+
+func (b *CacheBatching) validateSetDefaults() error {
+	if b.FlushInterval < 0 {
+		return fmt.Errorf("invalid cache batching flush-interval %s", b.FlushInterval)
+	}
+	if b.FlushInterval == 0 {
+		b.FlushInterval = defaultCacheBatchFlushInterval
+	}
+	if b.MaxSize < 0 {
+		return fmt.Errorf("invalid cache batching max-size %d", b.MaxSize)
+	}
+	if b.MaxSize == 0 {
+		b.MaxSize = defaultCacheBatchMaxSize
+	}
+	return nil
 }
 
 func (ds *DatastoreConfig) ValidateSetDefaults() error {
@@ -114,12 +713,180 @@ func (ds *DatastoreConfig) ValidateSetDefaults() error {
 			return err
 		}
 	}
+	switch ds.ConflictPolicy {
+	case "":
+		ds.ConflictPolicy = conflictPolicyFirstWins
+	case conflictPolicyReject:
+	case conflictPolicyFirstWins:
+	case conflictPolicyLastWins:
+	default:
+		return fmt.Errorf("unknown conflict-policy: %s. Must be one of %s, %s, %s",
+			ds.ConflictPolicy, conflictPolicyReject, conflictPolicyFirstWins, conflictPolicyLastWins)
+	}
+	switch ds.TieBreak {
+	case "":
+		ds.TieBreak = tieBreakOwner
+	case tieBreakOwner:
+	case tieBreakNewest:
+	default:
+		return fmt.Errorf("unknown tie-break: %s. Must be one of %s, %s", ds.TieBreak, tieBreakOwner, tieBreakNewest)
+	}
+	switch ds.DeleteAggregation {
+	case "":
+		ds.DeleteAggregation = deleteAggregationInstance
+	case deleteAggregationLeaf:
+	case deleteAggregationInstance:
+	case deleteAggregationAncestor:
+	default:
+		return fmt.Errorf("unknown delete-aggregation: %s. Must be one of %s, %s, %s",
+			ds.DeleteAggregation, deleteAggregationLeaf, deleteAggregationInstance, deleteAggregationAncestor)
+	}
+	switch ds.ValidationScope {
+	case "":
+		ds.ValidationScope = validationScopeIntendedAndRunning
+	case validationScopeIntended:
+	case validationScopeIntendedAndRunning:
+	default:
+		return fmt.Errorf("unknown validation-scope: %s. Must be one of %s, %s",
+			ds.ValidationScope, validationScopeIntended, validationScopeIntendedAndRunning)
+	}
+	switch ds.ApplyMode {
+	case "":
+		ds.ApplyMode = applyModeIncremental
+	case applyModeIncremental:
+	case applyModeFullReplace:
+		switch ds.SBI.Type {
+		case sbiNOOP, sbiSimulated, sbiNETCONF:
+		case sbiGNMI:
+			switch strings.ToLower(ds.SBI.GnmiOptions.Encoding) {
+			case gnmiEncodingJSON, gnmiEncodingJSONIETF:
+			default:
+				return fmt.Errorf("apply-mode %s requires gnmi encoding %s or %s", ds.ApplyMode, gnmiEncodingJSON, gnmiEncodingJSONIETF)
+			}
+		default:
+			return fmt.Errorf("apply-mode %s is not supported by sbi type %s", ds.ApplyMode, ds.SBI.Type)
+		}
+	default:
+		return fmt.Errorf("unknown apply-mode: %s. Must be one of %s, %s",
+			ds.ApplyMode, applyModeIncremental, applyModeFullReplace)
+	}
+	switch ds.PushMode {
+	case "":
+		ds.PushMode = pushModeImmediate
+	case pushModeImmediate:
+	case pushModeManual:
+		if len(ds.ApplyWindows) > 0 {
+			return fmt.Errorf("push-mode %s does not support apply-windows", ds.PushMode)
+		}
+	default:
+		return fmt.Errorf("unknown push-mode: %s. Must be one of %s, %s",
+			ds.PushMode, pushModeImmediate, pushModeManual)
+	}
+	for i, b := range ds.PriorityBands {
+		if b.Role == "" {
+			return fmt.Errorf("priority-band %d is missing a role", i)
+		}
+		if b.Min < 0 || (b.Max != 0 && b.Max < b.Min) {
+			return fmt.Errorf("priority-band %s has an invalid range %d-%d", b.Role, b.Min, b.Max)
+		}
+		for _, o := range ds.PriorityBands[:i] {
+			if (b.Max == 0 || b.Max >= o.Min) && (o.Max == 0 || o.Max >= b.Min) {
+				return fmt.Errorf("priority-band %s overlaps with priority-band %s", b.Role, o.Role)
+			}
+		}
+	}
+	names := make(map[string]struct{}, len(ds.Hooks))
+	for _, h := range ds.Hooks {
+		if err := h.validateSetDefaults(); err != nil {
+			return err
+		}
+		if _, ok := names[h.Name]; ok {
+			return fmt.Errorf("duplicate hook name: %s", h.Name)
+		}
+		names[h.Name] = struct{}{}
+	}
+	if ds.Secrets != nil {
+		if err := ds.Secrets.validateSetDefaults(); err != nil {
+			return err
+		}
+	}
+	for _, w := range ds.ApplyWindows {
+		if err := w.validateSetDefaults(); err != nil {
+			return err
+		}
+	}
+	if ds.Lint != nil {
+		if err := ds.Lint.validateSetDefaults(); err != nil {
+			return err
+		}
+	}
+	if ds.StatusPolicy == nil {
+		ds.StatusPolicy = &StatusPolicy{}
+	}
+	if err := ds.StatusPolicy.validateSetDefaults(); err != nil {
+		return err
+	}
+	switch ds.RunningProtection {
+	case "":
+		ds.RunningProtection = runningProtectionOverwrite
+	case runningProtectionOverwrite:
+	case runningProtectionForce:
+	case runningProtectionNever:
+	default:
+		return fmt.Errorf("unknown running-protection: %s. Must be one of %s, %s, %s",
+			ds.RunningProtection, runningProtectionOverwrite, runningProtectionForce, runningProtectionNever)
+	}
+	if ds.IntentTimeouts == nil {
+		ds.IntentTimeouts = &IntentTimeouts{}
+	}
+	ds.IntentTimeouts.setDefaults()
+	if ds.IdempotencyTTL <= 0 {
+		ds.IdempotencyTTL = defaultIdempotencyTTL
+	}
+	if ds.WAL != nil {
+		switch ds.WAL.Recovery {
+		case "":
+			ds.WAL.Recovery = walRecoveryReplay
+		case walRecoveryReplay:
+		case walRecoveryRollback:
+		default:
+			return fmt.Errorf("unknown wal recovery: %s. Must be one of %s, %s", ds.WAL.Recovery, walRecoveryReplay, walRecoveryRollback)
+		}
+	}
+	if ds.Journal == nil {
+		ds.Journal = &Journal{}
+	}
+	if ds.Journal.Size < 0 {
+		return fmt.Errorf("invalid journal size: %d", ds.Journal.Size)
+	}
+	if ds.Journal.Size == 0 {
+		ds.Journal.Size = defaultJournalSize
+	}
 	return nil
 }
 
+func (t *IntentTimeouts) setDefaults() {
+	for _, d := range []*time.Duration{&t.Schema, &t.Populate, &t.Validation, &t.Apply} {
+		if *d <= 0 {
+			*d = defaultIntentPhaseTimeout
+		}
+	}
+}
+
+// AddressList returns the addresses of the target in the order they are tried, without duplicates.
+func (s *SBI) AddressList() []string {
+	addrs := make([]string, 0, len(s.Addresses)+1)
+	for _, a := range append([]string{s.Address}, s.Addresses...) {
+		if a != "" && !slices.Contains(addrs, a) {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
 func (s *SBI) validateSetDefaults() error {
 	switch s.Type {
-	case sbiNOOP:
+	case sbiNOOP, sbiSimulated:
 		return nil
 	case sbiNETCONF:
 		switch s.NetconfOptions.CommitDatastore {
@@ -131,14 +898,70 @@ func (s *SBI) validateSetDefaults() error {
 			return fmt.Errorf("unknown commit-datastore: %s. Must be one of %s, %s",
 				s.NetconfOptions.CommitDatastore, ncCommitDatastoreCandidate, ncCommitDatastoreRunning)
 		}
+		switch s.NetconfOptions.DefaultOperation {
+		case "":
+			s.NetconfOptions.DefaultOperation = ncDefaultOperationMerge
+		case ncDefaultOperationMerge:
+		case ncDefaultOperationReplace:
+		case ncDefaultOperationNone:
+		default:
+			return fmt.Errorf("unknown default-operation: %s. Must be one of %s, %s, %s",
+				s.NetconfOptions.DefaultOperation, ncDefaultOperationMerge, ncDefaultOperationReplace, ncDefaultOperationNone)
+		}
+		switch {
+		case s.NetconfOptions.ConfirmTimeout == 0:
+			s.NetconfOptions.ConfirmTimeout = ncDefaultConfirmTimeout
+		case s.NetconfOptions.ConfirmTimeout < time.Second:
+			// the confirm-timeout of the netconf commit is in seconds
+			return fmt.Errorf("confirm-timeout must be at least 1s, got %s", s.NetconfOptions.ConfirmTimeout)
+		}
 	case sbiGNMI:
 		if s.GnmiOptions.Encoding == "" {
 			return errors.New("no encoding defined")
 		}
+		switch strings.ToLower(s.GnmiOptions.Encoding) {
+		case gnmiEncodingJSON:
+		case gnmiEncodingJSONIETF:
+		case gnmiEncodingProto:
+		case gnmiEncodingASCII:
+		default:
+			return fmt.Errorf("unknown gnmi encoding: %s. Must be one of %s, %s, %s, %s",
+				s.GnmiOptions.Encoding, gnmiEncodingJSON, gnmiEncodingJSONIETF, gnmiEncodingProto, gnmiEncodingASCII)
+		}
+		switch s.GnmiOptions.ApplyMode {
+		case "":
+			s.GnmiOptions.ApplyMode = gnmiApplyModeUpdate
+		case gnmiApplyModeUpdate:
+		case gnmiApplyModeReplace, gnmiApplyModeUnionReplace:
+			switch strings.ToLower(s.GnmiOptions.Encoding) {
+			case gnmiEncodingJSON, gnmiEncodingJSONIETF:
+			default:
+				return fmt.Errorf("gnmi apply-mode %s requires encoding %s or %s", s.GnmiOptions.ApplyMode, gnmiEncodingJSON, gnmiEncodingJSONIETF)
+			}
+		default:
+			return fmt.Errorf("unknown gnmi apply-mode: %s. Must be one of %s, %s, %s",
+				s.GnmiOptions.ApplyMode, gnmiApplyModeUpdate, gnmiApplyModeReplace, gnmiApplyModeUnionReplace)
+		}
+	case sbiCLI:
+		if s.CLIOptions == nil {
+			return errors.New("no cli-options defined")
+		}
+		switch s.CLIOptions.Platform {
+		case cliPlatformNokiaSRL:
+		case cliPlatformNokiaSROS:
+		case cliPlatformCiscoIOSXR:
+		default:
+			return fmt.Errorf("unknown cli platform: %s. Must be one of %s, %s, %s",
+				s.CLIOptions.Platform, cliPlatformNokiaSRL, cliPlatformNokiaSROS, cliPlatformCiscoIOSXR)
+		}
 	default:
 		return fmt.Errorf("unknown sbi type: %q", s.Type)
 	}
 
+	if s.Address == "" && len(s.Addresses) > 0 {
+		s.Address, s.Addresses = s.Addresses[0], s.Addresses[1:]
+	}
+
 	if s.Address == "" {
 		return errors.New("missing SBI address")
 	}
@@ -151,9 +974,25 @@ func (s *SBI) validateSetDefaults() error {
 		s.ConnectRetry = time.Second
 	}
 
+	if s.MaxBackoff < s.ConnectRetry {
+		s.MaxBackoff = max(s.ConnectRetry, defaultMaxBackoff)
+	}
+
+	if s.MaxRetries < 0 {
+		return errors.New("max-retries must not be negative")
+	}
+
 	if s.Timeout <= 0 {
 		s.Timeout = defaultTimeout
 	}
+
+	if s.ConnectTimeout <= 0 {
+		s.ConnectTimeout = defaultConnectTimeout
+	}
+
+	if s.Keepalive > 0 && s.IdleTimeout <= 0 {
+		s.IdleTimeout = defaultIdleTimeout
+	}
 	return nil
 }
 
@@ -168,6 +1007,17 @@ func (s *Sync) validateSetDefaults() error {
 	if s.WriteWorkers <= 0 {
 		s.WriteWorkers = defaultWriteWorkers
 	}
+	for _, sp := range s.Config {
+		switch sp.DataType {
+		case "":
+			sp.DataType = syncDataTypeConfig
+		case syncDataTypeConfig:
+		case syncDataTypeState:
+		default:
+			return fmt.Errorf("sync %s: unknown data-type: %s. Must be one of %s, %s",
+				sp.Name, sp.DataType, syncDataTypeConfig, syncDataTypeState)
+		}
+	}
 	return nil
 }
 
@@ -192,5 +1042,8 @@ func (c *CacheConfig) validateSetDefaults() error {
 			c.Dir = defaultCacheDir
 		}
 	}
+	if c.Batching != nil {
+		return c.Batching.validateSetDefaults()
+	}
 	return nil
 }
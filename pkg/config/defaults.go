@@ -19,19 +19,36 @@ import "time"
 const (
 	defaultGRPCAddress    = ":56000"
 	defaultMaxRecvMsgSize = 4 * 1024 * 1024
+	defaultMaxSendMsgSize = 4 * 1024 * 1024
 	defaultRPCTimeout     = 30 * time.Minute
+	defaultGNMIIntent     = "gnmi"
+	defaultGNMIPriority   = 100
+
+	defaultNetconfServerAddress  = ":56830"
+	defaultNetconfServerIntent   = "netconf"
+	defaultNetconfServerPriority = 100
 
 	defaultRemoteSchemaServerCacheTTL      = 300 * time.Second
 	defaultRemoteSchemaServerCacheCapacity = 1000
 
-	defaultNCPort             = 830
-	defaultCacheType          = "local"
-	defaultRemoteCacheAddress = "localhost:50100"
-	defaultBufferSize         = 1000
-	defaultStoreType          = "badgerdb"
-	defaultCacheDir           = "./cached/caches"
-	defaultWriteWorkers       = 16
-	defaultTimeout            = 30 * time.Second
+	defaultNCPort                  = 830
+	defaultCacheType               = "local"
+	defaultRemoteCacheAddress      = "localhost:50100"
+	defaultBufferSize              = 1000
+	defaultStoreType               = "badgerdb"
+	defaultCacheDir                = "./cached/caches"
+	defaultCacheBatchFlushInterval = 2 * time.Millisecond
+	defaultCacheBatchMaxSize       = 1000
+	defaultWriteWorkers            = 16
+	defaultTimeout                 = 30 * time.Second
+	defaultMaxBackoff              = time.Minute
+	defaultJournalSize             = 1000
+	defaultConnectTimeout          = 10 * time.Second
+	defaultIdleTimeout             = 20 * time.Second
+	defaultIntentPhaseTimeout      = 5 * time.Minute
+	defaultLogLevel                = "info"
+	defaultIdempotencyTTL          = 24 * time.Hour
+	defaultHookTimeout             = 10 * time.Second
 
 	defaultSchemaStorePath = "./schema-dir"
 )
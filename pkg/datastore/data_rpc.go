@@ -16,7 +16,6 @@ package datastore
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"math"
 	"strconv"
@@ -26,7 +25,6 @@ import (
 
 	"github.com/sdcio/cache/proto/cachepb"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/prototext"
@@ -44,6 +42,11 @@ const (
 )
 
 func (d *Datastore) Get(ctx context.Context, req *sdcpb.GetDataRequest, nCh chan *sdcpb.GetDataResponse) error {
+	return d.GetWithOpts(ctx, req, nil, nCh)
+}
+
+// GetWithOpts handles the GetDataRequest, applying the depth and fields options to the result.
+func (d *Datastore) GetWithOpts(ctx context.Context, req *sdcpb.GetDataRequest, opts *GetDataOpts, nCh chan *sdcpb.GetDataResponse) error {
 	defer close(nCh)
 	switch req.GetDatastore().GetType() {
 	case sdcpb.Type_MAIN:
@@ -84,26 +87,44 @@ func (d *Datastore) Get(ctx context.Context, req *sdcpb.GetDataRequest, nCh chan
 		paths = append(paths, utils.ToStrings(p, false, false))
 	}
 
+	filter := newGetDataFilter(req.GetPath(), opts)
+
+	// the origin is rendered as RFC 7952 metadata, which is available for JSON encodings only
+	origin := opts != nil && opts.Origin
+	if origin && req.GetEncoding() != sdcpb.Encoding_JSON && req.GetEncoding() != sdcpb.Encoding_JSON_IETF {
+		return status.Errorf(codes.InvalidArgument, "origin attribution requires JSON or JSON_IETF encoding, got %v", req.GetEncoding())
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	maxMsgSize := 0
+	if opts != nil {
+		maxMsgSize = opts.MaxMsgSize
+	}
+	chunker := newGetDataChunker(nCh, maxMsgSize)
+
+	// do not read while a sync iteration is swapped into the cache
+	d.syncSwapMutex.RLock()
+	defer d.syncSwapMutex.RUnlock()
+
 	switch req.GetEncoding() {
 	case sdcpb.Encoding_STRING:
-		err = d.handleGetDataUpdatesSTRING(ctx, name, req, paths, nCh)
+		err = d.handleGetDataUpdatesSTRING(ctx, name, req, paths, filter, chunker)
 	case sdcpb.Encoding_JSON:
-		err = d.handleGetDataUpdatesJSON(ctx, name, req, paths, nCh, false)
+		err = d.handleGetDataUpdatesJSON(ctx, name, req, paths, filter, origin, chunker, false)
 	case sdcpb.Encoding_JSON_IETF:
-		err = d.handleGetDataUpdatesJSON(ctx, name, req, paths, nCh, true)
+		err = d.handleGetDataUpdatesJSON(ctx, name, req, paths, filter, origin, chunker, true)
 	case sdcpb.Encoding_PROTO:
-		err = d.handleGetDataUpdatesPROTO(ctx, name, req, paths, nCh)
+		err = d.handleGetDataUpdatesPROTO(ctx, name, req, paths, filter, chunker)
 	}
 	if err != nil {
 		return err
 	}
-	return nil
+	return chunker.flush(ctx)
 }
 
-func (d *Datastore) handleGetDataUpdatesSTRING(ctx context.Context, name string, req *sdcpb.GetDataRequest, paths [][]string, out chan *sdcpb.GetDataResponse) error {
+func (d *Datastore) handleGetDataUpdatesSTRING(ctx context.Context, name string, req *sdcpb.GetDataRequest, paths [][]string, filter *getDataFilter, out *getDataChunker) error {
 NEXT_STORE:
 	for _, store := range getStores(req) {
 		in := d.cacheClient.ReadCh(ctx, name, &cache.Opts{
@@ -136,24 +157,20 @@ NEXT_STORE:
 						continue
 					}
 				}
+				// skip the updates that are filtered by depth or fields
+				if !filter.matches(scp) {
+					continue
+				}
 				tv, err := upd.Value()
 				if err != nil {
 					return err
 				}
-				notification := &sdcpb.Notification{
-					Timestamp: time.Now().UnixNano(),
-					Update: []*sdcpb.Update{{
-						Path:  scp,
-						Value: tv,
-					}},
-				}
-				rsp := &sdcpb.GetDataResponse{
-					Notification: []*sdcpb.Notification{notification},
-				}
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case out <- rsp:
+				err = out.add(ctx, &sdcpb.Update{
+					Path:  scp,
+					Value: tv,
+				})
+				if err != nil {
+					return err
 				}
 			}
 		}
@@ -161,9 +178,7 @@ NEXT_STORE:
 	return nil
 }
 
-func (d *Datastore) handleGetDataUpdatesJSON(ctx context.Context, name string, req *sdcpb.GetDataRequest, paths [][]string, out chan *sdcpb.GetDataResponse, ietf bool) error {
-	now := time.Now().UnixNano()
-
+func (d *Datastore) handleGetDataUpdatesJSON(ctx context.Context, name string, req *sdcpb.GetDataRequest, paths [][]string, filter *getDataFilter, origin bool, out *getDataChunker, ietf bool) error {
 	treeSCC := tree.NewTreeSchemaCacheClient(d.Name(), d.cacheClient, d.getValidationClient())
 	tc := tree.NewTreeContext(treeSCC, "")
 	root, err := tree.NewTreeRoot(ctx, tc)
@@ -171,6 +186,16 @@ func (d *Datastore) handleGetDataUpdatesJSON(ctx context.Context, name string, r
 		return err
 	}
 
+	// the owner and priority of values read from the intended store are taken as is,
+	// for all the other stores they are looked up in the intended store keys
+	var intendedIndex *tree.StoreIndex
+	if origin && req.GetDatastore().GetType() != sdcpb.Type_INTENDED {
+		intendedIndex, err = d.readStoreKeysMeta(ctx, cachepb.Store_INTENDED)
+		if err != nil {
+			return err
+		}
+	}
+
 	for _, store := range getStores(req) {
 		in := d.cacheClient.ReadCh(ctx, name, &cache.Opts{
 			Store:    store,
@@ -203,6 +228,13 @@ func (d *Datastore) handleGetDataUpdatesJSON(ctx context.Context, name string, r
 						continue
 					}
 				}
+				// skip the updates that are filtered by depth or fields
+				if !filter.matches(scp) {
+					continue
+				}
+				if intendedIndex != nil {
+					upd = originUpdate(upd, intendedIndex)
+				}
 				root.AddCacheUpdateRecursive(ctx, upd, false)
 			}
 		}
@@ -212,7 +244,12 @@ func (d *Datastore) handleGetDataUpdatesJSON(ctx context.Context, name string, r
 
 	var j any
 	// marshal map into JSON bytes
-	if ietf {
+	if origin {
+		j, err = root.ToJsonWithOrigin(ietf)
+		if err != nil {
+			return err
+		}
+	} else if ietf {
 		j, err = root.ToJsonIETF(false)
 		if err != nil {
 			return err
@@ -223,31 +260,25 @@ func (d *Datastore) handleGetDataUpdatesJSON(ctx context.Context, name string, r
 			return err
 		}
 	}
-	b, err := json.Marshal(j)
+	// the JSON is split into top level chunks that fit the maximum message size
+	chunks, err := out.splitJSON(j)
 	if err != nil {
 		err = fmt.Errorf("failed json builder indent : %v", err)
 		log.Error(err)
 		return err
 	}
-
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case out <- &sdcpb.GetDataResponse{
-		Notification: []*sdcpb.Notification{
-			{
-				Timestamp: now,
-				Update: []*sdcpb.Update{{
-					Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: b}},
-				}},
-			},
-		},
-	}:
+	for _, b := range chunks {
+		err = out.add(ctx, &sdcpb.Update{
+			Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: b}},
+		})
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (d *Datastore) handleGetDataUpdatesPROTO(ctx context.Context, name string, req *sdcpb.GetDataRequest, paths [][]string, out chan *sdcpb.GetDataResponse) error {
+func (d *Datastore) handleGetDataUpdatesPROTO(ctx context.Context, name string, req *sdcpb.GetDataRequest, paths [][]string, filter *getDataFilter, out *getDataChunker) error {
 	converter := utils.NewConverter(d.getValidationClient())
 NEXT_STORE:
 	for _, store := range getStores(req) {
@@ -281,6 +312,10 @@ NEXT_STORE:
 						continue
 					}
 				}
+				// skip the updates that are filtered by depth or fields
+				if !filter.matches(scp) {
+					continue
+				}
 				tv, err := upd.Value()
 				if err != nil {
 					return err
@@ -289,20 +324,12 @@ NEXT_STORE:
 				if err != nil {
 					return err
 				}
-				notification := &sdcpb.Notification{
-					Timestamp: time.Now().UnixNano(),
-					Update: []*sdcpb.Update{{
-						Path:  scp,
-						Value: ctv,
-					}},
-				}
-				rsp := &sdcpb.GetDataResponse{
-					Notification: []*sdcpb.Notification{notification},
-				}
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case out <- rsp:
+				err = out.add(ctx, &sdcpb.Update{
+					Path:  scp,
+					Value: ctv,
+				})
+				if err != nil {
+					return err
 				}
 			}
 		}
@@ -506,7 +533,7 @@ func (d *Datastore) validateUpdate(ctx context.Context, upd *sdcpb.Update) error
 	}
 	switch obj := rsp.GetSchema().Schema.(type) {
 	case *sdcpb.SchemaElem_Container:
-		if !pathIsKeyAsLeaf(upd.GetPath()) && !obj.Container.IsPresence {
+		if !utils.PathIsKeyAsLeaf(upd.GetPath()) && !obj.Container.IsPresence {
 			return fmt.Errorf("cannot set value on container %q object", obj.Container.Name)
 		}
 		// TODO: validate key as leaf
@@ -865,7 +892,7 @@ func (d *Datastore) setCandidate(ctx context.Context, req *sdcpb.SetDataRequest,
 	}
 
 	// debugging
-	if log.GetLevel() >= log.DebugLevel {
+	if log.IsDebugEnabled() {
 		for _, upd := range replaces {
 			log.Debugf("expanded replace:\n%s", prototext.Format(upd))
 		}
@@ -0,0 +1,144 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// getDataResponseOverhead is an upper bound of the GetDataResponse and Notification
+// framing, that is not part of the updates.
+const getDataResponseOverhead = 32
+
+// getDataChunker collects the updates of a GetData request into notifications. A notification
+// is sent as soon as the next update would exceed the maximum message size, such that the
+// response is streamed in chunks, rather than being assembled in memory.
+// Sending blocks until the receiver is ready, which propagates the backpressure of the
+// client stream to the reading of the cache.
+type getDataChunker struct {
+	out        chan *sdcpb.GetDataResponse
+	maxMsgSize int
+	pending    *sdcpb.Notification
+	size       int
+}
+
+// newGetDataChunker returns a chunker that sends to out. If maxMsgSize is <= 0
+// every update is sent in a notification of its own.
+func newGetDataChunker(out chan *sdcpb.GetDataResponse, maxMsgSize int) *getDataChunker {
+	return &getDataChunker{
+		out:        out,
+		maxMsgSize: maxMsgSize,
+	}
+}
+
+// add adds the update to the pending notification, sending the pending notification first if the update does not fit.
+func (c *getDataChunker) add(ctx context.Context, upd *sdcpb.Update) error {
+	n := proto.Size(upd)
+	size := protowire.SizeTag(1) + protowire.SizeBytes(n)
+	if c.pending != nil && (c.maxMsgSize <= 0 || c.size+size > c.maxMsgSize) {
+		if err := c.flush(ctx); err != nil {
+			return err
+		}
+	}
+	if c.pending == nil {
+		c.pending = &sdcpb.Notification{Timestamp: time.Now().UnixNano()}
+		c.size = getDataResponseOverhead
+	}
+	c.pending.Update = append(c.pending.Update, upd)
+	c.size += size
+	return nil
+}
+
+// flush sends the pending notification.
+func (c *getDataChunker) flush(ctx context.Context) error {
+	if c.pending == nil {
+		return nil
+	}
+	rsp := &sdcpb.GetDataResponse{
+		Notification: []*sdcpb.Notification{c.pending},
+	}
+	c.pending = nil
+	c.size = 0
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case c.out <- rsp:
+	}
+	return nil
+}
+
+// splitJSON splits the top level of the JSON object into objects whose encoding does not exceed
+// the maximum message size. An element that exceeds the size on its own is returned as a single object.
+// RFC 7952 annotations stay with the element they annotate.
+func (c *getDataChunker) splitJSON(j any) ([][]byte, error) {
+	b, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := j.(map[string]any)
+	if !ok || c.maxMsgSize <= 0 || len(b)+getDataResponseOverhead <= c.maxMsgSize {
+		return [][]byte{b}, nil
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if k[0] != '@' {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	result := [][]byte{}
+	chunk := map[string]any{}
+	var chunkSize int
+	for _, k := range keys {
+		elem := map[string]any{k: m[k]}
+		if a, ok := m["@"+k]; ok {
+			elem["@"+k] = a
+		}
+		eb, err := json.Marshal(elem)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunk) > 0 && chunkSize+len(eb)+getDataResponseOverhead > c.maxMsgSize {
+			cb, err := json.Marshal(chunk)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, cb)
+			chunk = map[string]any{}
+			chunkSize = 0
+		}
+		for ek, ev := range elem {
+			chunk[ek] = ev
+		}
+		chunkSize += len(eb)
+	}
+	if len(chunk) > 0 {
+		cb, err := json.Marshal(chunk)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, cb)
+	}
+	return result, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGetDataChunker(t *testing.T) {
+	updates := make([]*sdcpb.Update, 0, 100)
+	for i := 0; i < 100; i++ {
+		updates = append(updates, &sdcpb.Update{
+			Path: &sdcpb.Path{Elem: []*sdcpb.PathElem{
+				{Name: "interface", Key: map[string]string{"name": fmt.Sprintf("ethernet-1/%d", i+1)}},
+				{Name: "description"},
+			}},
+			Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: fmt.Sprintf("interface %d", i+1)}},
+		})
+	}
+
+	tests := []struct {
+		name       string
+		maxMsgSize int
+		wantRsps   int
+	}{
+		{name: "no limit, one update per response", maxMsgSize: 0, wantRsps: 100},
+		{name: "all updates fit a single response", maxMsgSize: 1024 * 1024, wantRsps: 1},
+		{name: "updates split into chunks", maxMsgSize: 1024},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			out := make(chan *sdcpb.GetDataResponse, len(updates))
+			c := newGetDataChunker(out, tt.maxMsgSize)
+			for _, u := range updates {
+				if err := c.add(ctx, u); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := c.flush(ctx); err != nil {
+				t.Fatal(err)
+			}
+			close(out)
+
+			rsps := 0
+			got := []*sdcpb.Update{}
+			for rsp := range out {
+				rsps++
+				if tt.maxMsgSize > 0 && proto.Size(rsp) > tt.maxMsgSize {
+					t.Errorf("response size %d exceeds max message size %d", proto.Size(rsp), tt.maxMsgSize)
+				}
+				for _, n := range rsp.GetNotification() {
+					got = append(got, n.GetUpdate()...)
+				}
+			}
+			if tt.wantRsps > 0 && rsps != tt.wantRsps {
+				t.Errorf("got %d responses, want %d", rsps, tt.wantRsps)
+			}
+			if tt.wantRsps == 0 && rsps < 2 {
+				t.Errorf("expected the updates to be split, got %d responses", rsps)
+			}
+			if len(got) != len(updates) {
+				t.Fatalf("got %d updates, want %d", len(got), len(updates))
+			}
+			for i := range updates {
+				if !proto.Equal(got[i], updates[i]) {
+					t.Errorf("update %d: got %v, want %v", i, got[i], updates[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetDataChunker_splitJSON(t *testing.T) {
+	j := map[string]any{}
+	for i := 0; i < 50; i++ {
+		k := fmt.Sprintf("leaf%02d", i)
+		j[k] = fmt.Sprintf("value %d", i)
+		j["@"+k] = map[string]any{"owner": "owner1"}
+	}
+
+	c := newGetDataChunker(nil, 512)
+	chunks, err := c.splitJSON(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) < 2 {
+		t.Fatalf("expected the json to be split, got %d chunks", len(chunks))
+	}
+
+	merged := map[string]any{}
+	for _, b := range chunks {
+		if len(b)+getDataResponseOverhead > 512 {
+			t.Errorf("chunk size %d exceeds max message size", len(b))
+		}
+		m := map[string]any{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range m {
+			if k[0] != '@' {
+				if _, ok := m["@"+k]; !ok {
+					t.Errorf("annotation of %s not part of the same chunk", k)
+				}
+			}
+			merged[k] = v
+		}
+	}
+	want := map[string]any{}
+	b, _ := json.Marshal(j)
+	_ = json.Unmarshal(b, &want)
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("merged chunks differ from the original json")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/tree"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+// GetDataOpts carries the GetData options that are not part of the sdcpb.GetDataRequest.
+type GetDataOpts struct {
+	// Depth limits the number of levels returned below the requested paths.
+	// 0 means unlimited.
+	Depth int
+	// Fields projects the result onto the given sub-paths, relative to the requested paths.
+	// Keys that are not set in a field path match any key value.
+	Fields []*sdcpb.Path
+	// Origin annotates every returned leaf with the owner and priority of the
+	// intent that set the value, as RFC 7952 metadata. Requires JSON or JSON_IETF encoding.
+	Origin bool
+	// MaxMsgSize bounds the size of the streamed responses, the updates are sent in
+	// notifications of up to this size. 0 sends every update in a response of its own.
+	MaxMsgSize int
+}
+
+// getDataFilter decides which of the updates read from the cache are part of the GetData response.
+// It is evaluated on the schema path before the value is decoded, such that updates that are
+// filtered out do not cause any conversion effort.
+type getDataFilter struct {
+	paths  []*sdcpb.Path
+	depth  int
+	fields []*sdcpb.Path
+}
+
+// newGetDataFilter returns the filter for the given request paths and options.
+// nil is returned if no filtering is required.
+func newGetDataFilter(paths []*sdcpb.Path, opts *GetDataOpts) *getDataFilter {
+	if opts == nil || (opts.Depth <= 0 && len(opts.Fields) == 0) {
+		return nil
+	}
+	return &getDataFilter{
+		paths:  paths,
+		depth:  opts.Depth,
+		fields: opts.Fields,
+	}
+}
+
+// matches returns true if the update with the given path is to be returned.
+func (f *getDataFilter) matches(p *sdcpb.Path) bool {
+	if f == nil {
+		return true
+	}
+	rel, ok := f.relativeElems(p)
+	if !ok {
+		return false
+	}
+	if f.depth > 0 && len(rel) > f.depth {
+		return false
+	}
+	if len(f.fields) == 0 {
+		return true
+	}
+	for _, field := range f.fields {
+		if elemsHavePrefix(rel, field.GetElem()) {
+			return true
+		}
+	}
+	return false
+}
+
+// relativeElems returns the path elements of p below the requested path it belongs to.
+func (f *getDataFilter) relativeElems(p *sdcpb.Path) ([]*sdcpb.PathElem, bool) {
+	if len(f.paths) == 0 {
+		return p.GetElem(), true
+	}
+	for _, rp := range f.paths {
+		if elemsHavePrefix(p.GetElem(), rp.GetElem()) {
+			return p.GetElem()[len(rp.GetElem()):], true
+		}
+	}
+	return nil, false
+}
+
+// elemsHavePrefix checks that elems starts with prefix. Keys that are not
+// defined in the prefix act as wildcards.
+func elemsHavePrefix(elems []*sdcpb.PathElem, prefix []*sdcpb.PathElem) bool {
+	if len(prefix) > len(elems) {
+		return false
+	}
+	for i, pe := range prefix {
+		if pe.GetName() != elems[i].GetName() {
+			return false
+		}
+		for k, v := range pe.GetKey() {
+			if v != "*" && elems[i].GetKey()[k] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// originUpdate returns the update with owner, priority and timestamp taken from the highest
+// precedence entry of the intended store index. If the path is not part of any intent,
+// the update is returned as is.
+func originUpdate(upd *cache.Update, intendedIndex *tree.StoreIndex) *cache.Update {
+	var origin *cache.Update
+	for _, u := range intendedIndex.Get(upd.GetPath()) {
+		if origin == nil || u.Priority() < origin.Priority() {
+			origin = u
+		}
+	}
+	if origin == nil {
+		return upd
+	}
+	return cache.NewUpdate(upd.GetPath(), upd.Bytes(), origin.Priority(), origin.Owner(), origin.TS())
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+func TestGetDataFilter_matches(t *testing.T) {
+	mustParse := func(p string) *sdcpb.Path {
+		path, err := utils.ParsePath(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		paths   []string
+		opts    *GetDataOpts
+		path    string
+		matches bool
+	}{
+		{
+			name:    "no options",
+			paths:   []string{"/interface"},
+			path:    "/interface[name=ethernet-1/1]/subinterface[index=0]/description",
+			matches: true,
+		},
+		{
+			name:    "within depth",
+			paths:   []string{"/interface[name=ethernet-1/1]"},
+			opts:    &GetDataOpts{Depth: 1},
+			path:    "/interface[name=ethernet-1/1]/description",
+			matches: true,
+		},
+		{
+			name:    "exceeding depth",
+			paths:   []string{"/interface[name=ethernet-1/1]"},
+			opts:    &GetDataOpts{Depth: 1},
+			path:    "/interface[name=ethernet-1/1]/subinterface[index=0]/description",
+			matches: false,
+		},
+		{
+			name:    "depth relative to root",
+			opts:    &GetDataOpts{Depth: 2},
+			path:    "/interface[name=ethernet-1/1]/description",
+			matches: true,
+		},
+		{
+			name:    "field matches",
+			paths:   []string{"/interface"},
+			opts:    &GetDataOpts{Fields: []*sdcpb.Path{mustParse("/description")}},
+			path:    "/interface[name=ethernet-1/1]/description",
+			matches: true,
+		},
+		{
+			name:    "field does not match",
+			paths:   []string{"/interface"},
+			opts:    &GetDataOpts{Fields: []*sdcpb.Path{mustParse("/description")}},
+			path:    "/interface[name=ethernet-1/1]/admin-state",
+			matches: false,
+		},
+		{
+			name:    "field with key",
+			paths:   []string{"/interface"},
+			opts:    &GetDataOpts{Fields: []*sdcpb.Path{mustParse("/subinterface[index=1]")}},
+			path:    "/interface[name=ethernet-1/1]/subinterface[index=0]/description",
+			matches: false,
+		},
+		{
+			name:    "field combined with depth",
+			paths:   []string{"/interface"},
+			opts:    &GetDataOpts{Depth: 1, Fields: []*sdcpb.Path{mustParse("/subinterface")}},
+			path:    "/interface[name=ethernet-1/1]/subinterface[index=0]/description",
+			matches: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := make([]*sdcpb.Path, 0, len(tt.paths))
+			for _, p := range tt.paths {
+				paths = append(paths, mustParse(p))
+			}
+			f := newGetDataFilter(paths, tt.opts)
+			if got := f.matches(mustParse(tt.path)); got != tt.matches {
+				t.Errorf("getDataFilter.matches() = %v, want %v", got, tt.matches)
+			}
+		})
+	}
+}
+
+func TestOriginUpdate(t *testing.T) {
+	path := []string{"interface", "ethernet-1/1", "description"}
+	val := testhelper.GetStringTvProto(t, "Foo")
+
+	intendedIndex := tree.NewStoreIndexFromUpdates(
+		cache.NewUpdate(path, nil, 10, "owner1", 0),
+		cache.NewUpdate(path, nil, 5, "owner2", 7),
+		cache.NewUpdate(path, nil, 20, "owner3", 0),
+	)
+
+	t.Run("path owned by intents", func(t *testing.T) {
+		got := originUpdate(cache.NewUpdate(path, val, 0, "", 42), intendedIndex)
+		if got.Owner() != "owner2" || got.Priority() != 5 {
+			t.Errorf("originUpdate() owner = %s, priority = %d, want owner2, 5", got.Owner(), got.Priority())
+		}
+		if !bytes.Equal(got.Bytes(), val) {
+			t.Errorf("originUpdate() must keep the value of the read update")
+		}
+		if got.TS() != 7 {
+			t.Errorf("originUpdate() timestamp = %d, want the intended timestamp 7", got.TS())
+		}
+	})
+
+	t.Run("path not owned by any intent", func(t *testing.T) {
+		upd := cache.NewUpdate([]string{"interface", "ethernet-1/2", "description"}, val, 0, "", 42)
+		if got := originUpdate(upd, intendedIndex); got != upd {
+			t.Errorf("originUpdate() expected the update to be returned as is, got %v", got)
+		}
+	})
+}
@@ -19,10 +19,14 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/sdcio/cache/proto/cachepb"
 	SchemaClient "github.com/sdcio/data-server/pkg/datastore/clients/schema"
 	"github.com/sdcio/data-server/pkg/utils"
 	"github.com/sdcio/data-server/pkg/utils/testhelper"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestDatastore_expandUpdateLeafAsKeys(t *testing.T) {
@@ -243,3 +247,219 @@ func TestDatastore_expandUpdateLeafAsKeys(t *testing.T) {
 		})
 	}
 }
+
+func TestConverter_ExpandUpdate_Encodings(t *testing.T) {
+	ifPath := &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}}}
+	leafPath := func(name string) *sdcpb.Path {
+		p := proto.Clone(ifPath).(*sdcpb.Path)
+		p.Elem = append(p.Elem, &sdcpb.PathElem{Name: name})
+		return p
+	}
+	protoBytes := func(t *testing.T, tv *sdcpb.TypedValue, asJSON bool) *sdcpb.TypedValue {
+		var b []byte
+		var err error
+		if asJSON {
+			b, err = protojson.Marshal(tv)
+		} else {
+			b, err = proto.Marshal(tv)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &sdcpb.TypedValue{Value: &sdcpb.TypedValue_ProtoBytes{ProtoBytes: b}}
+	}
+	ifLeaves := map[string]string{
+		"interface[name=ethernet-1/1]/description": prototext.Format(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "d1"}}),
+		"interface[name=ethernet-1/1]/mtu":         prototext.Format(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_UintVal{UintVal: 1500}}),
+	}
+
+	tests := []struct {
+		name    string
+		upd     func(t *testing.T) *sdcpb.Update
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "json container",
+			upd: func(t *testing.T) *sdcpb.Update {
+				return &sdcpb.Update{Path: ifPath, Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(`{"description":"d1","mtu":1500}`)}}}
+			},
+			want: ifLeaves,
+		},
+		{
+			name: "json_ietf container with namespaces",
+			upd: func(t *testing.T) *sdcpb.Update {
+				return &sdcpb.Update{Path: ifPath, Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"sdcio_model:description":"d1","sdcio_model:mtu":1500}`)}}}
+			},
+			want: ifLeaves,
+		},
+		{
+			name: "json container with anydata",
+			upd: func(t *testing.T) *sdcpb.Update {
+				return &sdcpb.Update{Path: &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "opaque"}}}, Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(`{"name":"foo","data":{"vendor":{"mode":"fast"}}}`)}}}
+			},
+			want: map[string]string{
+				"opaque/name": prototext.Format(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "foo"}}),
+				"opaque/data": prototext.Format(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(`{"vendor":{"mode":"fast"}}`)}}),
+			},
+		},
+		{
+			name: "json number leaf",
+			upd: func(t *testing.T) *sdcpb.Update {
+				return &sdcpb.Update{Path: leafPath("mtu"), Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(`1500`)}}}
+			},
+			want: map[string]string{"interface[name=ethernet-1/1]/mtu": ifLeaves["interface[name=ethernet-1/1]/mtu"]},
+		},
+		{
+			name: "json object leaf",
+			upd: func(t *testing.T) *sdcpb.Update {
+				return &sdcpb.Update{Path: leafPath("mtu"), Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(`{"mtu":1500}`)}}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "json_ietf leaflist",
+			upd: func(t *testing.T) *sdcpb.Update {
+				return &sdcpb.Update{
+					Path:  &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "leaflist"}, {Name: "entry"}}},
+					Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`["foo","bar"]`)}},
+				}
+			},
+			want: map[string]string{
+				"leaflist/entry": prototext.Format(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_LeaflistVal{LeaflistVal: &sdcpb.ScalarArray{Element: []*sdcpb.TypedValue{
+					{Value: &sdcpb.TypedValue_StringVal{StringVal: "foo"}},
+					{Value: &sdcpb.TypedValue_StringVal{StringVal: "bar"}},
+				}}}}),
+			},
+		},
+		{
+			name: "binary proto encoded json",
+			upd: func(t *testing.T) *sdcpb.Update {
+				return &sdcpb.Update{Path: ifPath, Value: protoBytes(t, &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(`{"description":"d1","mtu":1500}`)}}, false)}
+			},
+			want: ifLeaves,
+		},
+		{
+			name: "protojson encoded scalar",
+			upd: func(t *testing.T) *sdcpb.Update {
+				return &sdcpb.Update{Path: leafPath("mtu"), Value: protoBytes(t, &sdcpb.TypedValue{Value: &sdcpb.TypedValue_UintVal{UintVal: 1500}}, true)}
+			},
+			want: map[string]string{"interface[name=ethernet-1/1]/mtu": ifLeaves["interface[name=ethernet-1/1]/mtu"]},
+		},
+		{
+			name: "malformed proto encoding",
+			upd: func(t *testing.T) *sdcpb.Update {
+				return &sdcpb.Update{Path: leafPath("mtu"), Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_ProtoBytes{ProtoBytes: []byte(`{"uintVal":`)}}}
+			},
+			wantErr: true,
+		},
+	}
+
+	schemaClient, schema, err := testhelper.InitSDCIOSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	converter := utils.NewConverter(SchemaClient.NewSchemaClientBound(schema.GetSchema(), schemaClient))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upds, err := converter.ExpandUpdate(context.Background(), tt.upd(t), false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := map[string]string{}
+			for _, u := range upds {
+				got[utils.ToXPath(u.GetPath(), false)] = prototext.Format(u.GetValue())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandUpdate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_getStores(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *sdcpb.GetDataRequest
+		want []cachepb.Store
+	}{
+		{
+			name: "state from main",
+			req: &sdcpb.GetDataRequest{
+				DataType:  sdcpb.DataType_STATE,
+				Datastore: &sdcpb.DataStore{Type: sdcpb.Type_MAIN},
+			},
+			want: []cachepb.Store{cachepb.Store_STATE},
+		},
+		{
+			name: "all from main",
+			req: &sdcpb.GetDataRequest{
+				DataType:  sdcpb.DataType_ALL,
+				Datastore: &sdcpb.DataStore{Type: sdcpb.Type_MAIN},
+			},
+			want: []cachepb.Store{cachepb.Store_CONFIG, cachepb.Store_STATE},
+		},
+		{
+			name: "all from candidate",
+			req: &sdcpb.GetDataRequest{
+				DataType:  sdcpb.DataType_ALL,
+				Datastore: &sdcpb.DataStore{Type: sdcpb.Type_CANDIDATE, Name: "cand1"},
+			},
+			want: []cachepb.Store{cachepb.Store_CONFIG},
+		},
+		{
+			name: "config from intended",
+			req: &sdcpb.GetDataRequest{
+				DataType:  sdcpb.DataType_CONFIG,
+				Datastore: &sdcpb.DataStore{Type: sdcpb.Type_INTENDED},
+			},
+			want: []cachepb.Store{cachepb.Store_INTENDED},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getStores(tt.req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getStores() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func FuzzExpandUpdates(f *testing.F) {
+	for _, j := range []string{
+		`{}`,
+		`{"interface":[{"name":"ethernet-1/1","admin-state":"enable","subinterface":[{"index":0}]}]}`,
+		`{"interface":[{"name":"ethernet-1/1","description":12345678901234567890}]}`,
+		`{"choices":{"case1":{"case-elem":{"elem":"foo"}}}}`,
+		`{"leaflist":{"entry":["foo","bar"]}}`,
+		`{"network-instance":[{"name":"default","type":"sdcio_model_ni:default"}]}`,
+		`{"interface":{"name":"ethernet-1/1"}}`,
+		`{"interface":[{"name":null}]}`,
+		`{"patterntest":["hallo 0"]}`,
+		`[1,2,3]`,
+		`"foo"`,
+	} {
+		f.Add([]byte(j), false)
+		f.Add([]byte(j), true)
+	}
+
+	// the gomock based schema client can not be used within the fuzz target
+	schemaClient, schema, err := testhelper.InitSDCIOSchema()
+	if err != nil {
+		f.Fatal(err)
+	}
+	converter := utils.NewConverter(SchemaClient.NewSchemaClientBound(schema.GetSchema(), schemaClient))
+
+	f.Fuzz(func(t *testing.T, j []byte, ietf bool) {
+		tv := &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: j}}
+		if ietf {
+			tv = &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonIetfVal{JsonIetfVal: j}}
+		}
+		// malformed values must be reported as errors, not panic
+		_, _ = converter.ExpandUpdates(context.Background(), []*sdcpb.Update{{Path: &sdcpb.Path{}, Value: tv}}, true)
+	})
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sdcio/data-server/pkg/datastore/target"
+)
+
+// eventBroadcaster fans out events to all its subscribers.
+type eventBroadcaster[T any] struct {
+	name string
+	m    *sync.RWMutex
+	subs map[chan T]struct{}
+}
+
+func newEventBroadcaster[T any](name string) *eventBroadcaster[T] {
+	return &eventBroadcaster[T]{
+		name: name,
+		m:    new(sync.RWMutex),
+		subs: make(map[chan T]struct{}),
+	}
+}
+
+// subscribe returns a channel that receives the events until the context is done.
+func (b *eventBroadcaster[T]) subscribe(ctx context.Context) <-chan T {
+	ch := make(chan T, 100)
+	b.m.Lock()
+	b.subs[ch] = struct{}{}
+	b.m.Unlock()
+	go func() {
+		<-ctx.Done()
+		b.m.Lock()
+		delete(b.subs, ch)
+		b.m.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+// publish sends the event to all the subscribers. Events are dropped for subscribers that do not keep up.
+func (b *eventBroadcaster[T]) publish(ev T) {
+	b.m.RLock()
+	defer b.m.RUnlock()
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+			log.Warnf("dropping %s event, subscriber is too slow", b.name)
+		}
+	}
+}
+
+// WatchConnectionEvents returns a channel that receives the connection state changes of the target until the context is done.
+func (d *Datastore) WatchConnectionEvents(ctx context.Context) <-chan *target.ConnectionEvent {
+	return d.connectionEvents.subscribe(ctx)
+}
+
+// forwardConnectionEvents logs the connection state changes of the target and forwards them to the subscribers.
+func (d *Datastore) forwardConnectionEvents(ctx context.Context, src target.ConnectionEventSource) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-src.ConnectionEvents():
+			if ev.Err != nil {
+				log.Infof("%s: target connection %s: %v", d.Name(), ev.State, ev.Err)
+			} else {
+				log.Infof("%s: target connection %s", d.Name(), ev.State)
+			}
+			d.connectionEvents.publish(ev)
+		}
+	}
+}
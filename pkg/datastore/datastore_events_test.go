@@ -0,0 +1,50 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"testing"
+)
+
+func Test_eventBroadcaster(t *testing.T) {
+	b := newEventBroadcaster[int]("test")
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+
+	sub1 := b.subscribe(ctx1)
+	sub2 := b.subscribe(ctx2)
+
+	b.publish(1)
+	if v := <-sub1; v != 1 {
+		t.Errorf("subscriber 1: expected 1, got %d", v)
+	}
+	if v := <-sub2; v != 1 {
+		t.Errorf("subscriber 2: expected 1, got %d", v)
+	}
+
+	// the channel of a cancelled subscription is closed
+	cancel1()
+	if _, ok := <-sub1; ok {
+		t.Errorf("subscriber 1: expected closed channel")
+	}
+
+	b.publish(2)
+	if v := <-sub2; v != 2 {
+		t.Errorf("subscriber 2: expected 2, got %d", v)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"time"
+)
+
+// ApplyStats describes the last transaction sent to the target.
+type ApplyStats struct {
+	// Intent that caused the transaction
+	Intent string
+	// Start of the transaction
+	Start time.Time
+	// Duration it took the target to process the transaction
+	Duration time.Duration
+	// Error returned by the target, empty on success
+	Error string
+}
+
+// Info is the runtime information of a datastore.
+type Info struct {
+	// ConnectionState of the target
+	ConnectionState string
+	// SyncState of the sync loop
+	SyncState string
+	// Intents is the number of stored intents
+	Intents int
+	// LastSync is nil if no sync completed yet
+	LastSync *SyncStats
+	// LastApply is nil if no transaction was sent to the target yet
+	LastApply *ApplyStats
+	// Maintenance is nil if the datastore is not in maintenance mode
+	Maintenance *Maintenance
+	// SyncPause is nil if the sync is not paused via PauseSync
+	SyncPause *SyncPause
+	// Dirty is true while changes are not yet pushed to the device
+	Dirty bool
+}
+
+// Info returns the runtime information of the datastore.
+func (d *Datastore) Info(ctx context.Context) (*Info, error) {
+	intents, err := d.listRawIntent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Info{
+		ConnectionState: d.ConnectionState(),
+		SyncState:       d.SyncState(),
+		Intents:         len(intents),
+		LastSync:        d.LastSyncStats(),
+		LastApply:       d.LastApplyStats(),
+		Maintenance:     d.Maintenance(),
+		SyncPause:       d.SyncPause(),
+		Dirty:           d.Dirty(),
+	}, nil
+}
+
+// LastApplyStats returns the statistics of the last transaction sent to the target, nil if none was sent yet.
+func (d *Datastore) LastApplyStats() *ApplyStats {
+	d.ms.RLock()
+	defer d.ms.RUnlock()
+	if d.lastApply == nil {
+		return nil
+	}
+	stats := *d.lastApply
+	return &stats
+}
+
+func (d *Datastore) setLastApply(intentName string, start time.Time, sbiErr error) {
+	stats := &ApplyStats{
+		Intent:   intentName,
+		Start:    start,
+		Duration: time.Since(start),
+	}
+	if sbiErr != nil {
+		stats.Error = sbiErr.Error()
+	}
+	d.ms.Lock()
+	defer d.ms.Unlock()
+	d.lastApply = stats
+}
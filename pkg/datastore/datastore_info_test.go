@@ -0,0 +1,87 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/mocks/mocktarget"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/datastore/target"
+)
+
+// emptySource is a target.TargetSource without changes.
+type emptySource struct {
+	target.TargetSource
+}
+
+func (emptySource) ToProtoUpdates(context.Context, bool) ([]*sdcpb.Update, error) { return nil, nil }
+func (emptySource) ToProtoDeletes(context.Context) ([]*sdcpb.Path, error)         { return nil, nil }
+
+func TestDatastore_Info(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	store := map[string]*cache.Update{}
+	configureIntentsStoreMock(cacheClient, store)
+	sbi := mocktarget.NewMockTarget(controller)
+	sbi.EXPECT().Status().AnyTimes().Return("READY")
+
+	d := &Datastore{
+		config:      &config.DatastoreConfig{Name: "dev1"},
+		cacheClient: cacheClient,
+		sbi:         sbi,
+		ms:          new(sync.RWMutex),
+	}
+	ctx := context.Background()
+
+	for _, name := range []string{"intent1", "intent2"} {
+		if err := d.saveRawIntent(ctx, name, &sdcpb.SetIntentRequest{Intent: name, Priority: 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := d.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.ConnectionState != "READY" || info.Intents != 2 || info.LastApply != nil || info.LastSync != nil {
+		t.Errorf("unexpected info before the first apply: %+v", info)
+	}
+
+	sbi.EXPECT().Set(gomock.Any(), gomock.Any()).Return(nil, errors.New("rejected"))
+	if _, err = d.applyIntent(ctx, "intent2", "default", emptySource{}); err == nil {
+		t.Fatal("expected the apply to fail")
+	}
+
+	info, err = d.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.LastApply == nil || info.LastApply.Intent != "intent2" || info.LastApply.Error != "rejected" || info.LastApply.Start.IsZero() {
+		t.Errorf("unexpected last apply: %+v", info.LastApply)
+	}
+	// the journal entry of the apply must not be counted as intent
+	if info.Intents != 2 {
+		t.Errorf("expected 2 intents, got %d", info.Intents)
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/datastore/target"
+)
+
+// journalPrefix is the prefix of the journal entries keys in the intents store.
+var journalPrefix = "__sb_journal__"
+
+// JournalEntry is the record of a SetDataRequest sent to the target.
+type JournalEntry struct {
+	// Timestamp the request was sent at
+	Timestamp time.Time
+	// Intent that caused the request
+	Intent string
+	// Request is the SetDataRequest sent to the target
+	Request *sdcpb.SetDataRequest
+	// Duration it took the target to process the request
+	Duration time.Duration
+	// Error returned by the target, empty on success
+	Error string
+	// TransactionID of the change on the target. The southbound protocols do not
+	// report commit ids, hence the timestamp of the SetDataResponse is used.
+	TransactionID int64
+}
+
+// journalRecord is the persisted form of a JournalEntry.
+type journalRecord struct {
+	Timestamp     int64  `json:"timestamp"`
+	Intent        string `json:"intent"`
+	Request       []byte `json:"request,omitempty"`
+	Duration      int64  `json:"duration"`
+	Error         string `json:"error,omitempty"`
+	TransactionID int64  `json:"transaction-id,omitempty"`
+}
+
+// JournalQuery selects journal entries.
+type JournalQuery struct {
+	// Intent restricts the result to the entries of the given intent
+	Intent string
+	// Since restricts the result to the entries recorded at or after the given time
+	Since time.Time
+	// Limit restricts the result to the latest n entries, 0 means unlimited
+	Limit int
+}
+
+// recordJournal persists the outcome of sending the source to the target and
+// trims the journal to the configured size. Failures are only logged, they must
+// not affect the outcome of the transaction.
+func (d *Datastore) recordJournal(ctx context.Context, intentName string, source target.TargetSource, start time.Time, rsp *sdcpb.SetDataResponse, sbiErr error) {
+	je := &JournalEntry{
+		Timestamp:     start,
+		Intent:        intentName,
+		Duration:      time.Since(start),
+		TransactionID: rsp.GetTimestamp(),
+	}
+	if sbiErr != nil {
+		je.Error = sbiErr.Error()
+	}
+	req, err := journalRequest(ctx, d.config.Name, source)
+	if err != nil {
+		log.Errorf("datastore %s: failed building journal entry: %v", d.config.Name, err)
+	}
+	je.Request = req
+
+	err = d.writeJournalEntry(ctx, je)
+	if err != nil {
+		log.Errorf("datastore %s: failed writing journal entry: %v", d.config.Name, err)
+		return
+	}
+	err = d.trimJournal(ctx)
+	if err != nil {
+		log.Errorf("datastore %s: failed trimming journal: %v", d.config.Name, err)
+	}
+}
+
+// journalRequest builds the SetDataRequest that represents the changes of the source.
+func journalRequest(ctx context.Context, name string, source target.TargetSource) (*sdcpb.SetDataRequest, error) {
+	upds, err := source.ToProtoUpdates(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	dels, err := source.ToProtoDeletes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &sdcpb.SetDataRequest{
+		Name:   name,
+		Update: upds,
+		Delete: dels,
+	}, nil
+}
+
+func (d *Datastore) writeJournalEntry(ctx context.Context, je *JournalEntry) error {
+	rec := &journalRecord{
+		Timestamp:     je.Timestamp.UnixNano(),
+		Intent:        je.Intent,
+		Duration:      int64(je.Duration),
+		Error:         je.Error,
+		TransactionID: je.TransactionID,
+	}
+	if je.Request != nil {
+		b, err := proto.Marshal(je.Request)
+		if err != nil {
+			return err
+		}
+		rec.Request = b
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	upd, err := d.cacheClient.NewUpdate(
+		&sdcpb.Update{
+			Path: &sdcpb.Path{
+				Elem: []*sdcpb.PathElem{{Name: journalKey(je.Timestamp)}},
+			},
+			Value: &sdcpb.TypedValue{
+				Value: &sdcpb.TypedValue_BytesVal{BytesVal: b},
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return d.cacheClient.Modify(ctx, d.config.Name,
+		&cache.Opts{
+			Store: cachepb.Store_INTENTS,
+		},
+		nil,
+		[]*cache.Update{upd})
+}
+
+// journalKeys returns the keys of the journal entries, oldest first.
+func (d *Datastore) journalKeys(ctx context.Context) []string {
+	upds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store:    cachepb.Store_INTENTS,
+		KeysOnly: true,
+	}, [][]string{{"*"}}, 0)
+	keys := make([]string, 0, len(upds))
+	for _, upd := range upds {
+		if len(upd.GetPath()) == 0 || !strings.HasPrefix(upd.GetPath()[0], journalPrefix) {
+			continue
+		}
+		keys = append(keys, upd.GetPath()[0])
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// trimJournal removes the oldest entries exceeding the configured journal size.
+func (d *Datastore) trimJournal(ctx context.Context) error {
+	if d.config.Journal == nil || d.config.Journal.Size <= 0 {
+		return nil
+	}
+	keys := d.journalKeys(ctx)
+	excess := len(keys) - d.config.Journal.Size
+	if excess <= 0 {
+		return nil
+	}
+	dels := make([][]string, 0, excess)
+	for _, k := range keys[:excess] {
+		dels = append(dels, []string{k})
+	}
+	return d.cacheClient.Modify(ctx, d.config.Name,
+		&cache.Opts{
+			Store: cachepb.Store_INTENTS,
+		},
+		dels,
+		nil)
+}
+
+// Journal returns the journal entries matching the query, oldest first.
+func (d *Datastore) Journal(ctx context.Context, q *JournalQuery) ([]*JournalEntry, error) {
+	if q == nil {
+		q = &JournalQuery{}
+	}
+	keys := d.journalKeys(ctx)
+	if !q.Since.IsZero() {
+		since := journalKey(q.Since)
+		keys = keys[sort.SearchStrings(keys, since):]
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	paths := make([][]string, 0, len(keys))
+	for _, k := range keys {
+		paths = append(paths, []string{k})
+	}
+	upds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store: cachepb.Store_INTENTS,
+	}, paths, 0)
+
+	result := make([]*JournalEntry, 0, len(upds))
+	for _, upd := range upds {
+		je, err := decodeJournalEntry(upd)
+		if err != nil {
+			return nil, err
+		}
+		if q.Intent != "" && je.Intent != q.Intent {
+			continue
+		}
+		result = append(result, je)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[len(result)-q.Limit:]
+	}
+	return result, nil
+}
+
+func decodeJournalEntry(upd *cache.Update) (*JournalEntry, error) {
+	val, err := upd.Value()
+	if err != nil {
+		return nil, err
+	}
+	rec := &journalRecord{}
+	err = json.Unmarshal(val.GetBytesVal(), rec)
+	if err != nil {
+		return nil, fmt.Errorf("malformed journal entry %v: %w", upd.GetPath(), err)
+	}
+	je := &JournalEntry{
+		Timestamp:     time.Unix(0, rec.Timestamp),
+		Intent:        rec.Intent,
+		Duration:      time.Duration(rec.Duration),
+		Error:         rec.Error,
+		TransactionID: rec.TransactionID,
+	}
+	if len(rec.Request) > 0 {
+		je.Request = &sdcpb.SetDataRequest{}
+		err = proto.Unmarshal(rec.Request, je.Request)
+		if err != nil {
+			return nil, fmt.Errorf("malformed journal entry %v: %w", upd.GetPath(), err)
+		}
+	}
+	return je, nil
+}
+
+func journalKey(ts time.Time) string {
+	return fmt.Sprintf("%s%020d", journalPrefix, ts.UnixNano())
+}
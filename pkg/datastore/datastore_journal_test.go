@@ -0,0 +1,184 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// configureIntentsStoreMock backs the intents store of the mocked cache client by the given map.
+func configureIntentsStoreMock(cacheClient *mockcacheclient.MockClient, store map[string]*cache.Update) {
+	cacheClient.EXPECT().NewUpdate(gomock.Any()).AnyTimes().DoAndReturn(
+		func(upd *sdcpb.Update) (*cache.Update, error) {
+			b, err := proto.Marshal(upd.Value)
+			if err != nil {
+				return nil, err
+			}
+			return cache.NewUpdate(utils.ToStrings(upd.GetPath(), false, false), b, 0, "", 0), nil
+		},
+	)
+	cacheClient.EXPECT().Modify(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, name string, opts *cache.Opts, dels [][]string, upds []*cache.Update) error {
+			for _, del := range dels {
+				delete(store, strings.Join(del, "/"))
+			}
+			for _, upd := range upds {
+				store[strings.Join(upd.GetPath(), "/")] = upd
+			}
+			return nil
+		},
+	)
+	cacheClient.EXPECT().Read(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, name string, opts *cache.Opts, paths [][]string, period time.Duration) []*cache.Update {
+			if opts.Store != cachepb.Store_INTENTS {
+				return nil
+			}
+			result := []*cache.Update{}
+			for _, p := range paths {
+				if len(p) == 1 && p[0] == "*" {
+					keys := make([]string, 0, len(store))
+					for k := range store {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						result = append(result, store[k])
+					}
+					continue
+				}
+				if upd, ok := store[strings.Join(p, "/")]; ok {
+					result = append(result, upd)
+				}
+			}
+			return result
+		},
+	)
+}
+
+func TestDatastore_Journal(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	store := map[string]*cache.Update{}
+	configureIntentsStoreMock(cacheClient, store)
+
+	d := &Datastore{
+		config: &config.DatastoreConfig{
+			Name:    "dev1",
+			Journal: &config.Journal{Size: 3},
+		},
+		cacheClient: cacheClient,
+	}
+	ctx := context.Background()
+
+	// a raw intent living in the same store must not show up in the journal
+	err := d.saveRawIntent(ctx, "intent1", &sdcpb.SetIntentRequest{Intent: "intent1", Priority: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Unix(1700000000, 0)
+	entries := []*JournalEntry{
+		{Timestamp: base, Intent: "intent1", Duration: time.Second},
+		{Timestamp: base.Add(time.Minute), Intent: "intent2", Error: "rejected"},
+		{
+			Timestamp: base.Add(2 * time.Minute),
+			Intent:    "intent1",
+			Request: &sdcpb.SetDataRequest{
+				Name:   "dev1",
+				Delete: []*sdcpb.Path{{Elem: []*sdcpb.PathElem{{Name: "interface"}}}},
+			},
+			TransactionID: 42,
+		},
+		{Timestamp: base.Add(3 * time.Minute), Intent: "intent2"},
+	}
+	for _, je := range entries {
+		if err := d.writeJournalEntry(ctx, je); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.trimJournal(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		query *JournalQuery
+		want  []*JournalEntry
+	}{
+		{
+			name:  "all retained",
+			query: &JournalQuery{},
+			want:  entries[1:],
+		},
+		{
+			name:  "intent",
+			query: &JournalQuery{Intent: "intent1"},
+			want:  entries[2:3],
+		},
+		{
+			name:  "since",
+			query: &JournalQuery{Since: base.Add(2 * time.Minute)},
+			want:  entries[2:],
+		},
+		{
+			name:  "limit",
+			query: &JournalQuery{Limit: 1},
+			want:  entries[3:],
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.Journal(ctx, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d entries, got %d", len(tt.want), len(got))
+			}
+			for i, je := range got {
+				want := tt.want[i]
+				if !je.Timestamp.Equal(want.Timestamp) || je.Intent != want.Intent || je.Error != want.Error ||
+					je.Duration != want.Duration || je.TransactionID != want.TransactionID {
+					t.Errorf("entry %d: expected %+v, got %+v", i, want, je)
+				}
+				if !proto.Equal(je.Request, want.Request) {
+					t.Errorf("entry %d: expected request %v, got %v", i, want.Request, je.Request)
+				}
+			}
+		})
+	}
+
+	intents, err := d.listRawIntent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intents) != 1 || intents[0].GetIntent() != "intent1" {
+		t.Errorf("expected the raw intent only, got %v", intents)
+	}
+}
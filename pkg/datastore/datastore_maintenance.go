@@ -0,0 +1,93 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Maintenance describes the maintenance mode of a datastore, e.g. during a device upgrade.
+type Maintenance struct {
+	// Reason is reported along with the maintenance mode
+	Reason string
+	// QueueIntents holds the SetIntentRequests until the maintenance ends, instead of rejecting them
+	QueueIntents bool
+	// PauseSync stops syncing the config of the device, the sync restarts with a full sync once the maintenance ends
+	PauseSync bool
+	// Since is the time the maintenance started
+	Since time.Time
+}
+
+// SetMaintenance puts the datastore into maintenance mode, nil ends the maintenance.
+func (d *Datastore) SetMaintenance(m *Maintenance) {
+	d.maintenanceMutex.Lock()
+	prev := d.maintenance
+	if m != nil {
+		m.Since = time.Now()
+		if prev != nil {
+			m.Since = prev.Since
+		}
+	}
+	d.maintenance = m
+	switch {
+	case prev == nil && m != nil:
+		d.maintenanceEnd = make(chan struct{})
+		log.Infof("datastore %s: entering maintenance: %s", d.Name(), m.Reason)
+	case prev != nil && m == nil:
+		// release the queued intents
+		close(d.maintenanceEnd)
+		log.Infof("datastore %s: maintenance ended", d.Name())
+	}
+	d.maintenanceMutex.Unlock()
+
+	// the sync loop picks the maintenance up
+	d.notifySyncPause()
+}
+
+// Maintenance returns the maintenance of the datastore, nil if it is not in maintenance mode.
+func (d *Datastore) Maintenance() *Maintenance {
+	d.maintenanceMutex.RLock()
+	defer d.maintenanceMutex.RUnlock()
+	if d.maintenance == nil {
+		return nil
+	}
+	m := *d.maintenance
+	return &m
+}
+
+// awaitMaintenance rejects the SetIntentRequests during the maintenance, or holds them until it ends
+// if the maintenance queues the intents.
+func (d *Datastore) awaitMaintenance(ctx context.Context) error {
+	for {
+		d.maintenanceMutex.RLock()
+		m, end := d.maintenance, d.maintenanceEnd
+		d.maintenanceMutex.RUnlock()
+		if m == nil {
+			return nil
+		}
+		if !m.QueueIntents {
+			return status.Errorf(codes.Unavailable, "datastore %s is in maintenance: %s", d.Name(), m.Reason)
+		}
+		select {
+		case <-ctx.Done():
+			return status.Errorf(codes.Unavailable, "datastore %s is in maintenance: %s: %v", d.Name(), m.Reason, ctx.Err())
+		case <-end:
+		}
+	}
+}
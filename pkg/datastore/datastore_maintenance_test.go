@@ -0,0 +1,75 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_Maintenance(t *testing.T) {
+	d := &Datastore{config: &config.DatastoreConfig{Name: "dev1"}}
+	ctx := context.Background()
+
+	if err := d.awaitMaintenance(ctx); err != nil {
+		t.Fatalf("awaitMaintenance() without maintenance returned %v", err)
+	}
+
+	d.SetMaintenance(&Maintenance{Reason: "upgrade"})
+	if m := d.Maintenance(); m == nil || m.Reason != "upgrade" || m.Since.IsZero() {
+		t.Errorf("Maintenance() = %+v, want the upgrade maintenance", m)
+	}
+	if err := d.awaitMaintenance(ctx); status.Code(err) != codes.Unavailable {
+		t.Errorf("awaitMaintenance() returned %v, want %v", err, codes.Unavailable)
+	}
+
+	// queued intents are released once the maintenance ends
+	d.SetMaintenance(&Maintenance{Reason: "upgrade", QueueIntents: true})
+	done := make(chan error)
+	go func() {
+		done <- d.awaitMaintenance(ctx)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("awaitMaintenance() returned %v during the maintenance", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	d.SetMaintenance(nil)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("awaitMaintenance() returned %v after the maintenance", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("awaitMaintenance() did not return after the maintenance")
+	}
+	if m := d.Maintenance(); m != nil {
+		t.Errorf("Maintenance() = %+v after the maintenance ended", m)
+	}
+
+	// queued intents give up with their context
+	d.SetMaintenance(&Maintenance{Reason: "upgrade", QueueIntents: true})
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := d.awaitMaintenance(cctx); status.Code(err) != codes.Unavailable {
+		t.Errorf("awaitMaintenance() returned %v, want %v", err, codes.Unavailable)
+	}
+}
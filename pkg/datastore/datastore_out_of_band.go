@@ -0,0 +1,180 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+const (
+	OutOfBandOperationUpdate = "update"
+	OutOfBandOperationDelete = "delete"
+)
+
+var outOfBandChangesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "data_server",
+	Name:      "out_of_band_changes_total",
+	Help:      "Number of configuration changes that were performed on the target outside of the data-server",
+}, []string{"datastore", "operation"})
+
+// Collectors returns the prometheus collectors of the datastore package.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{outOfBandChangesTotal, syncPrunedPathsTotal, intentLockAcquiredTimestamp}
+}
+
+// OutOfBandChange describes a change of the target configuration that was not performed by the data-server,
+// detected while writing the synced configuration into the CONFIG store.
+type OutOfBandChange struct {
+	Path []string
+	// Operation is one of OutOfBandOperationUpdate, OutOfBandOperationDelete
+	Operation string
+	// PreviousValue is the value before the change, nil if the path did not exist
+	PreviousValue *sdcpb.TypedValue
+	// CurrentValue is the value after the change, nil for deletes
+	CurrentValue *sdcpb.TypedValue
+	// ExpectedValue is the highest precedence intended value, nil if the path is not part of an intent
+	ExpectedValue *sdcpb.TypedValue
+	Timestamp     time.Time
+
+	// expected is the intended cache update, used for reconciliation
+	expected *cache.Update
+}
+
+// WatchOutOfBandChanges returns a channel that receives the detected out-of-band changes until the context is done.
+// Changes are dropped if the receiver does not keep up.
+func (d *Datastore) WatchOutOfBandChanges(ctx context.Context) <-chan *OutOfBandChange {
+	return d.outOfBandEvents.subscribe(ctx)
+}
+
+// outOfBandEnabled returns true if out-of-band change detection is configured.
+func (d *Datastore) outOfBandEnabled() bool {
+	return d.config.Sync != nil && d.config.Sync.OutOfBand != nil
+}
+
+// detectOutOfBand compares the synced value of the path, nil for a delete, with the content of the CONFIG store
+// and the intended value. It returns the out-of-band change or nil if the value did not change or the change
+// was performed by the data-server.
+func (d *Datastore) detectOutOfBand(ctx context.Context, path []string, value *sdcpb.TypedValue) (*OutOfBandChange, error) {
+	var prev *sdcpb.TypedValue
+	prevUpds := d.cacheClient.Read(ctx, d.Name(), &cache.Opts{Store: cachepb.Store_CONFIG}, [][]string{path}, 0)
+	if len(prevUpds) > 0 {
+		var err error
+		prev, err = prevUpds[0].Value()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case value == nil && prev == nil:
+		return nil, nil
+	case value != nil && prev != nil && utils.EqualTypedValues(prev, value):
+		return nil, nil
+	case prev == nil && d.LastSyncStats() == nil:
+		// the initial sync populates the CONFIG store, additions are not out-of-band
+		return nil, nil
+	}
+
+	change := &OutOfBandChange{
+		Path:          path,
+		Operation:     OutOfBandOperationUpdate,
+		PreviousValue: prev,
+		CurrentValue:  value,
+		Timestamp:     time.Now(),
+	}
+	if value == nil {
+		change.Operation = OutOfBandOperationDelete
+	}
+
+	// the highest precedence intended value
+	for _, u := range d.cacheClient.Read(ctx, d.Name(), &cache.Opts{Store: cachepb.Store_INTENDED}, [][]string{path}, 0) {
+		if change.expected == nil || u.Priority() < change.expected.Priority() {
+			change.expected = u
+		}
+	}
+	if change.expected != nil {
+		expected, err := change.expected.Value()
+		if err != nil {
+			return nil, err
+		}
+		// a value equal to the intended one is the result of applying an intent
+		if value != nil && utils.EqualTypedValues(expected, value) {
+			return nil, nil
+		}
+		change.ExpectedValue = expected
+	}
+	return change, nil
+}
+
+// reportOutOfBand logs the change, counts it and sends it to the watchers.
+func (d *Datastore) reportOutOfBand(change *OutOfBandChange) {
+	log.Warnf("%s: out-of-band %s of %v: %v -> %v", d.Name(), change.Operation, change.Path, change.PreviousValue, change.CurrentValue)
+	outOfBandChangesTotal.WithLabelValues(d.Name(), change.Operation).Inc()
+	d.outOfBandEvents.publish(change)
+}
+
+// reconcileOutOfBand restores the intended values of the paths that were changed out-of-band.
+// Changes of paths that are not part of an intent are left as is.
+func (d *Datastore) reconcileOutOfBand(ctx context.Context, changes []*OutOfBandChange) error {
+	upds := make([]*cache.Update, 0, len(changes))
+	for _, c := range changes {
+		if c.expected != nil {
+			upds = append(upds, c.expected)
+		}
+	}
+	if len(upds) == 0 {
+		return nil
+	}
+	// an ongoing SetIntent applies the intended values anyways
+	ctx, release, ok := d.tryAcquireIntentLock(ctx, "")
+	if !ok {
+		log.Infof("%s: skipping out-of-band reconciliation, ongoing SetIntent", d.Name())
+		return nil
+	}
+	defer release()
+
+	tc := tree.NewTreeContext(tree.NewTreeSchemaCacheClient(d.Name(), d.cacheClient, d.getValidationClient()), "")
+	root, err := tree.NewTreeRoot(ctx, tc)
+	if err != nil {
+		return err
+	}
+	for _, u := range upds {
+		_, err = root.AddCacheUpdateRecursive(ctx, u, true)
+		if err != nil {
+			return err
+		}
+	}
+	root.FinishInsertionPhase()
+
+	_, err = d.sbi.Set(ctx, root)
+	if err != nil {
+		return err
+	}
+	log.Infof("%s: reconciled %d out-of-band changes", d.Name(), len(upds))
+
+	// optimistic writeback to the config store, as done by SetIntent
+	return d.cacheClient.Modify(ctx, d.Name(), &cache.Opts{
+		Store: cachepb.Store_CONFIG,
+	}, nil, upds)
+}
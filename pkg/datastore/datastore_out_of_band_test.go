@@ -0,0 +1,134 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDatastore_detectOutOfBand(t *testing.T) {
+	pathDesc := []string{"interface", "ethernet-1/1", "description"}
+	pathMtu := []string{"interface", "ethernet-1/1", "mtu"}
+	pathAdmin := []string{"interface", "ethernet-1/1", "admin-state"}
+
+	intendedStoreUpdates := []*cache.Update{
+		cache.NewUpdate(pathDesc, testhelper.GetStringTvProto(t, "Foo"), 10, "owner1", 0),
+		cache.NewUpdate(pathDesc, testhelper.GetStringTvProto(t, "Bar"), 5, "owner2", 0),
+	}
+	runningStoreUpdates := []*cache.Update{
+		cache.NewUpdate(pathDesc, testhelper.GetStringTvProto(t, "Bar"), tree.RunningValuesPrio, tree.RunningIntentName, 0),
+		cache.NewUpdate(pathMtu, testhelper.GetStringTvProto(t, "1500"), tree.RunningValuesPrio, tree.RunningIntentName, 0),
+	}
+
+	stringVal := func(s string) *sdcpb.TypedValue {
+		return &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: s}}
+	}
+
+	tests := []struct {
+		name          string
+		path          []string
+		value         *sdcpb.TypedValue
+		synced        bool
+		wantOperation string
+		wantExpected  *sdcpb.TypedValue
+	}{
+		{
+			name:  "unchanged",
+			path:  pathDesc,
+			value: stringVal("Bar"),
+		},
+		{
+			name:          "intended value changed",
+			path:          pathDesc,
+			value:         stringVal("Baz"),
+			wantOperation: OutOfBandOperationUpdate,
+			wantExpected:  stringVal("Bar"),
+		},
+		{
+			name:          "intended value deleted",
+			path:          pathDesc,
+			wantOperation: OutOfBandOperationDelete,
+			wantExpected:  stringVal("Bar"),
+		},
+		{
+			name:          "unmanaged value changed",
+			path:          pathMtu,
+			value:         stringVal("9000"),
+			wantOperation: OutOfBandOperationUpdate,
+		},
+		{
+			name:  "addition during initial sync",
+			path:  pathAdmin,
+			value: stringVal("enable"),
+		},
+		{
+			name:          "addition after initial sync",
+			path:          pathAdmin,
+			value:         stringVal("enable"),
+			synced:        true,
+			wantOperation: OutOfBandOperationUpdate,
+		},
+		{
+			name: "delete of non existing path",
+			path: pathAdmin,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			cacheClient := mockcacheclient.NewMockClient(controller)
+			testhelper.ConfigureCacheClientMock(t, cacheClient, intendedStoreUpdates, runningStoreUpdates, nil, nil)
+
+			d := &Datastore{
+				config:      &config.DatastoreConfig{Name: "dev1"},
+				cacheClient: cacheClient,
+				ms:          new(sync.RWMutex),
+			}
+			if tt.synced {
+				d.lastSync = &SyncStats{}
+			}
+
+			got, err := d.detectOutOfBand(context.Background(), tt.path, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantOperation == "" {
+				if got != nil {
+					t.Fatalf("expected no out-of-band change, got %v", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("expected out-of-band %s, got none", tt.wantOperation)
+			}
+			if got.Operation != tt.wantOperation {
+				t.Errorf("expected operation %s, got %s", tt.wantOperation, got.Operation)
+			}
+			if got.ExpectedValue.String() != tt.wantExpected.String() {
+				t.Errorf("expected value %v, got %v", tt.wantExpected, got.ExpectedValue)
+			}
+		})
+	}
+}
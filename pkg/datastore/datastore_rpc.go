@@ -18,14 +18,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sort"
-	"strings"
+	"runtime/pprof"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sdcio/cache/proto/cachepb"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 	"golang.org/x/sync/semaphore"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -35,8 +34,10 @@ import (
 	"github.com/sdcio/data-server/pkg/cache"
 	"github.com/sdcio/data-server/pkg/config"
 	"github.com/sdcio/data-server/pkg/datastore/clients"
+	"github.com/sdcio/data-server/pkg/datastore/hooks"
 	"github.com/sdcio/data-server/pkg/datastore/target"
 	"github.com/sdcio/data-server/pkg/schema"
+	"github.com/sdcio/data-server/pkg/tree"
 	"github.com/sdcio/data-server/pkg/utils"
 )
 
@@ -45,6 +46,12 @@ type Datastore struct {
 	config *config.DatastoreConfig
 
 	cacheClient cache.Client
+	// in-memory index of the INTENDED store keys, nil if the datastore was not created by New
+	intendedIndex *cache.KeysIndexClient
+	// the modifications of the stores, nil unless the incremental tree is enabled
+	changeLog *cache.ChangeLogClient
+	// the tree of the last SetIntent, applying the next intents to, see IncrementalTree
+	intentTree intentTreeBase
 
 	// SBI target of this datastore
 	sbi target.Target
@@ -59,15 +66,43 @@ type Datastore struct {
 
 	// sync channel, to be passed to the SBI Sync method
 	synCh chan *target.SyncUpdate
+	// triggers an immediate full resync of the target
+	resyncCh chan struct{}
+	// signals a change of the maintenance or the sync pauses to the sync loop, which pauses or resumes the sync
+	syncPauseCh chan struct{}
+	// the pause requested via PauseSync and the number of applies pausing the sync
+	syncPauseMutex sync.Mutex
+	syncPause      *SyncPause
+	applyPauses    int
+	// statistics of the last completed sync iteration and of the last transaction sent to the target
+	ms        *sync.RWMutex
+	lastSync  *SyncStats
+	lastApply *ApplyStats
+	syncState string
+
+	// subscribers of the detected out-of-band changes
+	outOfBandEvents *eventBroadcaster[*OutOfBandChange]
+	// subscribers of the target connection state changes
+	connectionEvents *eventBroadcaster[*target.ConnectionEvent]
+	// subscribers of the changes deferred until the next apply window
+	pendingEvents *eventBroadcaster[*PendingChangesEvent]
+	// set while changes are not yet pushed to the device
+	dirty atomic.Bool
+	// snapshot of the tree of the last intents that were set, read concurrently to setting the next ones
+	treeSnapshot atomic.Pointer[tree.Snapshot]
 
 	// stop cancel func
 	cfn context.CancelFunc
+	// tracks the background goroutines (target connection, sync, deviations), Stop waits for them
+	wg *sync.WaitGroup
 
-	// intent semaphore.
+	// intent lock.
 	// Used by SetIntent to guarantee that
 	// only one SetIntent
 	// is applied at a time.
-	intentMutex *sync.Mutex
+	intentLock *intentLock
+	// serializes the read-modify-write of the intents by MergeIntent
+	mergeMutex sync.Mutex
 
 	// keeps track of clients watching deviation updates
 	m                *sync.RWMutex
@@ -76,32 +111,91 @@ type Datastore struct {
 	// per path intent deviations (no unhandled)
 	md                       *sync.RWMutex
 	currentIntentsDeviations map[string][]*sdcpb.WatchDeviationResponse
+
+	// variables available to intent templates, set at runtime
+	variablesMutex sync.RWMutex
+	variables      map[string]string
+
+	// the maintenance mode, maintenanceEnd is closed once the maintenance ends
+	maintenanceMutex sync.RWMutex
+	maintenance      *Maintenance
+	maintenanceEnd   chan struct{}
+
+	// the hook pipeline of the intents, hooksErr is set if the configured hooks could not be created
+	hooks    *hooks.Pipeline
+	hooksErr error
+
+	// held for writing while the buffered notifications of a sync iteration are swapped into the cache,
+	// reads hold it for reading
+	syncSwapMutex sync.RWMutex
 }
 
 // New creates a new datastore, its schema server client and initializes the SBI target
 // func New(c *config.DatastoreConfig, schemaServer *config.RemoteSchemaServer) *Datastore {
 func New(ctx context.Context, c *config.DatastoreConfig, scc schema.Client, cc cache.Client, opts ...grpc.DialOption) *Datastore {
+	// encrypt the intents and the sensitive intended values at rest
+	if c.Secrets != nil {
+		sc, err := cache.NewSecretsClient(cc, c.Secrets.Key(), c.Secrets.IsSensitive)
+		if err != nil {
+			log.Errorf("datastore %s: failed to create the secrets encryption: %v", c.Name, err)
+		} else {
+			cc = sc
+		}
+	}
+	// track the modifications of the stores for the tree that is kept across the intents
+	var cl *cache.ChangeLogClient
+	if c.IncrementalTree {
+		cl = cache.NewChangeLogClient(cc)
+		cc = cl
+	}
+	// serve the intended store keys, read on every intent, from memory
+	ic := cache.NewKeysIndexClient(cc)
 	ds := &Datastore{
 		config:                   c,
 		schemaClient:             scc,
-		cacheClient:              cc,
-		intentMutex:              new(sync.Mutex),
+		cacheClient:              ic,
+		intendedIndex:            ic,
+		changeLog:                cl,
+		intentLock:               newIntentLock(),
 		m:                        new(sync.RWMutex),
 		deviationClients:         make(map[string]sdcpb.DataServer_WatchDeviationsServer),
 		md:                       new(sync.RWMutex),
 		currentIntentsDeviations: make(map[string][]*sdcpb.WatchDeviationResponse),
+		ms:                       new(sync.RWMutex),
+		outOfBandEvents:          newEventBroadcaster[*OutOfBandChange]("out-of-band change"),
+		connectionEvents:         newEventBroadcaster[*target.ConnectionEvent]("connection"),
+		pendingEvents:            newEventBroadcaster[*PendingChangesEvent]("pending changes"),
+		wg:                       new(sync.WaitGroup),
 	}
 	if c.Sync != nil {
 		ds.synCh = make(chan *target.SyncUpdate, c.Sync.Buffer)
+		ds.resyncCh = make(chan struct{}, 1)
+		ds.syncPauseCh = make(chan struct{}, 1)
+	}
+	ds.hooks, ds.hooksErr = hooks.New(c.Name, c.Hooks)
+	if ds.hooksErr != nil {
+		log.Errorf("datastore %s: failed to create hooks: %v", c.Name, ds.hooksErr)
 	}
 	ctx, cancel := context.WithCancel(ctx)
 	ds.cfn = cancel
+	// label the goroutines of the datastore, the goroutines started by them inherit the label
+	ctx = pprof.WithLabels(ctx, pprof.Labels(GoroutineLabel, c.Name))
 
 	// create cache instance if needed
 	// this is a blocking  call
 	ds.initCache(ctx)
+	if err := ds.migrateRawIntents(ctx); err != nil {
+		log.Errorf("datastore %s: failed migrating the raw intents: %v", c.Name, err)
+	}
+	ds.loadDirty(ctx)
+	if c.WarmStart {
+		ds.warmStart(ctx)
+	}
 
+	ds.wg.Add(1)
 	go func() {
+		defer ds.wg.Done()
+		pprof.SetGoroutineLabels(ctx)
 		// init sbi, this is a blocking call
 		err := ds.connectSBI(ctx, opts...)
 		if errors.Is(err, context.Canceled) {
@@ -111,9 +205,37 @@ func New(ctx context.Context, c *config.DatastoreConfig, scc schema.Client, cc c
 			log.Errorf("failed to create SBI for target %s: %v", ds.Config().Name, err)
 			return
 		}
+		// a simulated device starts off with the config of the CONFIG store
+		if seeder, ok := ds.sbi.(target.ConfigSeeder); ok {
+			ds.seedSimulation(ctx, seeder)
+		}
+		// surface the connection state changes of targets that report them
+		if src, ok := ds.sbi.(target.ConnectionEventSource); ok {
+			ds.wg.Add(1)
+			go func() {
+				defer ds.wg.Done()
+				ds.forwardConnectionEvents(ctx, src)
+			}()
+		}
 		// start syncing goroutine
 		if c.Sync != nil {
-			go ds.Sync(ctx)
+			ds.wg.Add(1)
+			go func() {
+				defer ds.wg.Done()
+				ds.Sync(ctx)
+			}()
+		}
+		// recover the intent operations interrupted by a crash
+		if c.WAL != nil {
+			ds.recoverWAL(ctx)
+		}
+		// push the changes deferred until the apply windows
+		if len(c.ApplyWindows) > 0 {
+			ds.wg.Add(1)
+			go func() {
+				defer ds.wg.Done()
+				ds.schedulePendingChanges(ctx)
+			}()
 		}
 		// start deviation goroutine
 		ds.DeviationMgr(ctx)
@@ -144,6 +266,20 @@ CREATE:
 	}
 }
 
+// seedSimulation seeds the config of a simulated target with the content of the CONFIG store.
+func (d *Datastore) seedSimulation(ctx context.Context, seeder target.ConfigSeeder) {
+	upds := []*sdcpb.Update{}
+	for cupd := range d.cacheClient.ReadCh(ctx, d.Name(), &cache.Opts{Store: cachepb.Store_CONFIG}, [][]string{nil}, 0) {
+		upd, err := d.cacheUpdateToUpdate(ctx, cupd)
+		if err != nil {
+			log.Errorf("datastore %s: failed converting the CONFIG store value %v for the simulation: %v", d.Name(), cupd.GetPath(), err)
+			continue
+		}
+		upds = append(upds, upd)
+	}
+	seeder.Seed(upds)
+}
+
 func (d *Datastore) connectSBI(ctx context.Context, opts ...grpc.DialOption) error {
 	var err error
 	d.sbi, err = target.New(ctx, d.config.Name, d.config.SBI, d.getValidationClient(), opts...)
@@ -297,11 +433,35 @@ func (d *Datastore) ConnectionState() string {
 	return d.sbi.Status()
 }
 
+// ActiveAddress returns the address the target is connected to.
+// If the target does not report it, the configured address is returned.
+func (d *Datastore) ActiveAddress() string {
+	if r, ok := d.sbi.(target.ActiveAddressReporter); ok {
+		if addr := r.ActiveAddress(); addr != "" {
+			return addr
+		}
+	}
+	return d.config.SBI.Address
+}
+
+// Stop stops the background goroutines of the datastore, waits for them to return and closes the target.
 func (d *Datastore) Stop() error {
 	if d == nil {
 		return nil
 	}
 	d.cfn()
+	if d.wg != nil {
+		d.wg.Wait()
+	}
+	if d.config != nil && d.config.WarmStart {
+		ctx, cancel := context.WithTimeout(context.Background(), warmStartTimeout)
+		err := d.saveWarmStart(ctx)
+		cancel()
+		if err != nil {
+			log.Errorf("datastore %s: failed saving the warm start records: %v", d.Name(), err)
+		}
+	}
+	d.hooks.Close()
 	if d.sbi == nil {
 		return nil
 	}
@@ -312,20 +472,131 @@ func (d *Datastore) Stop() error {
 	return nil
 }
 
+// InvalidateIntendedIndex drops the in-memory index of the INTENDED store keys, to be called when
+// the INTENDED store was changed outside of the datastore. The index is reloaded on the next intent,
+// the incremental tree is rebuilt.
+func (d *Datastore) InvalidateIntendedIndex() {
+	if d.intendedIndex != nil {
+		d.intendedIndex.Invalidate(d.Name())
+	}
+	if d.changeLog != nil {
+		d.changeLog.Invalidate(d.Name())
+	}
+}
+
 func (d *Datastore) DeleteCache(ctx context.Context) error {
 	return d.cacheClient.Delete(ctx, d.config.Name)
 }
 
-func (d *Datastore) Sync(ctx context.Context) {
-	// this semaphore controls the number of concurrent writes to the cache
-	sem := semaphore.NewWeighted(d.config.Sync.WriteWorkers)
+// Delete tears the datastore down and removes its data. An ongoing SetIntent is aborted,
+// the sync is stopped, the target sessions are closed and the cache, holding the CONFIG,
+// STATE, INTENDED and INTENTS stores as well as the candidates, is deleted.
+func (d *Datastore) Delete(ctx context.Context) error {
+	if d.intentLock != nil && d.IntentLockHolder() != nil {
+		if err := d.AbortIntent(false); err != nil {
+			log.Warnf("datastore %s: %v", d.Name(), err)
+		}
+	}
+	if err := d.Stop(); err != nil {
+		return err
+	}
+	if err := d.DeleteCache(ctx); err != nil {
+		return fmt.Errorf("failed to delete the datastore %s cache: %w", d.Name(), err)
+	}
+	return nil
+}
+
+// GoroutineLabel is the pprof label carrying the datastore name on the goroutines of a datastore.
+const GoroutineLabel = "datastore"
+
+// states of the sync loop reported by SyncState
+const (
+	SyncStateStarting = "starting"
+	SyncStateSyncing  = "syncing"
+	SyncStateInSync   = "in-sync"
+	SyncStatePaused   = "paused"
+	SyncStateStopped  = "stopped"
+)
+
+// SyncStats describes a completed sync iteration.
+type SyncStats struct {
+	// Start of the sync iteration
+	Start time.Time
+	// Duration of the sync iteration
+	Duration time.Duration
+	// Notifications received from the target
+	Notifications int
+	// Updates and Deletes contained in the notifications
+	Updates int
+	Deletes int
+}
+
+// ResyncTarget forces an immediate full sync of the target.
+// The running sync is restarted, such that the target sends all the synced data again.
+func (d *Datastore) ResyncTarget(ctx context.Context) error {
+	if d.resyncCh == nil {
+		return fmt.Errorf("datastore %s has no sync configured", d.Name())
+	}
+	select {
+	case d.resyncCh <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		// a resync is already pending
+	}
+	return nil
+}
+
+// LastSyncStats returns the statistics of the last completed sync iteration, nil if no sync completed yet.
+func (d *Datastore) LastSyncStats() *SyncStats {
+	d.ms.RLock()
+	defer d.ms.RUnlock()
+	if d.lastSync == nil {
+		return nil
+	}
+	stats := *d.lastSync
+	return &stats
+}
+
+// startTargetSync starts the sync of the target, the returned function stops it.
+func (d *Datastore) startTargetSync(ctx context.Context) context.CancelFunc {
+	ctx, cancel := context.WithCancel(ctx)
 	go d.sbi.Sync(ctx,
 		d.config.Sync,
 		d.synCh,
 	)
+	return cancel
+}
+
+// SyncState returns the state of the sync loop, empty if it is not running yet.
+func (d *Datastore) SyncState() string {
+	d.ms.RLock()
+	defer d.ms.RUnlock()
+	return d.syncState
+}
+
+func (d *Datastore) setSyncState(state string) {
+	d.ms.Lock()
+	defer d.ms.Unlock()
+	d.syncState = state
+}
+
+func (d *Datastore) Sync(ctx context.Context) {
+	d.setSyncState(SyncStateStarting)
+	defer d.setSyncState(SyncStateStopped)
+	// this semaphore controls the number of concurrent writes to the cache
+	sem := semaphore.NewWeighted(d.config.Sync.WriteWorkers)
+	stopTargetSync := d.startTargetSync(ctx)
+	defer func() { stopTargetSync() }()
+	paused := false
 
 	var err error
 	var pruneID string
+	var stats *SyncStats
+	// the notifications of the current sync iteration, if they are swapped in at its end
+	var shadow []*target.SyncUpdate
+	// the CONFIG store paths of the current sync iteration
+	var tracker *syncTracker
 MAIN:
 	for {
 		select {
@@ -334,9 +605,46 @@ MAIN:
 				log.Errorf("datastore %s sync stopped: %v", d.Name(), ctx.Err())
 			}
 			return
+		case <-d.syncPauseCh:
+			switch {
+			case d.syncPaused() && !paused:
+				log.Infof("%s: sync paused", d.Name())
+				stopTargetSync()
+				stopTargetSync = func() {}
+				// the interrupted sync iteration is discarded
+				pruneID, stats, shadow, tracker = "", nil, nil, nil
+				paused = true
+				d.setSyncState(SyncStatePaused)
+			case !d.syncPaused() && paused:
+				log.Infof("%s: sync resumed", d.Name())
+				paused = false
+				d.setSyncState(SyncStateStarting)
+				stopTargetSync = d.startTargetSync(ctx)
+			}
+		case <-d.resyncCh:
+			if paused {
+				log.Infof("%s: full resync requested, but the sync is paused", d.Name())
+				continue
+			}
+			log.Infof("%s: full resync requested", d.Name())
+			// restarting the target sync triggers a forced full sync
+			stopTargetSync()
+			stopTargetSync = d.startTargetSync(ctx)
 		case syncup := <-d.synCh:
 			if syncup.Start {
 				log.Debugf("%s: sync start", d.Name())
+				d.setSyncState(SyncStateSyncing)
+				stats = &SyncStats{Start: time.Now()}
+				if d.config.Sync.TransactionalSwap {
+					shadow = make([]*target.SyncUpdate, 0)
+				}
+				tracker = nil
+				if syncup.Store != target.SyncStoreState && len(syncup.Paths) > 0 {
+					tracker, err = d.newSyncTracker(ctx, syncup.Paths)
+					if err != nil {
+						log.Errorf("datastore %s failed to read the CONFIG store keys, stale paths are not pruned: %v", d.Name(), err)
+					}
+				}
 				for {
 					pruneID, err = d.cacheClient.CreatePruneID(ctx, d.Name(), syncup.Force)
 					if err != nil {
@@ -349,20 +657,40 @@ MAIN:
 			}
 			if syncup.End && pruneID != "" {
 				log.Debugf("%s: sync end", d.Name())
-				for {
-					err = d.cacheClient.ApplyPrune(ctx, d.Name(), pruneID)
-					if err != nil {
-						log.Errorf("datastore %s failed to prune cache after update: %v", d.Name(), err)
-						time.Sleep(time.Second)
-						continue // retry
-					}
-					break
+				if shadow != nil {
+					err = d.swapSyncShadow(ctx, shadow, pruneID, sem, tracker)
+				} else {
+					err = d.finishSync(ctx, pruneID, sem, tracker)
+				}
+				shadow, tracker = nil, nil
+				if err != nil {
+					log.Infof("datastore %s sync stopped", d.config.Name)
+					return
 				}
 				log.Debugf("%s: sync resetting pruneID", d.Name())
 				pruneID = ""
+				d.setSyncState(SyncStateInSync)
+				if stats != nil {
+					stats.Duration = time.Since(stats.Start)
+					log.Infof("%s: sync done in %s, %d notifications, %d updates, %d deletes",
+						d.Name(), stats.Duration, stats.Notifications, stats.Updates, stats.Deletes)
+					d.ms.Lock()
+					d.lastSync = stats
+					d.ms.Unlock()
+					stats = nil
+				}
 				continue // MAIN FOR loop
 			}
 			// a regular notification
+			if stats != nil {
+				stats.Notifications++
+				stats.Updates += len(syncup.Update.GetUpdate())
+				stats.Deletes += len(syncup.Update.GetDelete())
+			}
+			if shadow != nil {
+				shadow = append(shadow, syncup)
+				continue
+			}
 			log.Debugf("%s: sync acquire semaphore", d.Name())
 			err = sem.Acquire(ctx, 1)
 			if err != nil {
@@ -374,11 +702,57 @@ MAIN:
 				continue
 			}
 			log.Debugf("%s: sync acquired semaphore", d.Name())
-			go d.storeSyncMsg(ctx, syncup, sem)
+			go d.storeSyncMsg(ctx, syncup, sem, tracker)
 		}
 	}
 }
 
+// applyPrune removes the values from the cache that were not refreshed by the sync iteration of the given prune ID.
+func (d *Datastore) applyPrune(ctx context.Context, pruneID string) {
+	for {
+		err := d.cacheClient.ApplyPrune(ctx, d.Name(), pruneID)
+		if err != nil {
+			log.Errorf("datastore %s failed to prune cache after update: %v", d.Name(), err)
+			time.Sleep(time.Second)
+			continue // retry
+		}
+		return
+	}
+}
+
+// swapSyncShadow writes the buffered notifications of a sync iteration into the cache and prunes it,
+// while reads are held back, such that the reads see either the previous or the newly synced store.
+func (d *Datastore) swapSyncShadow(ctx context.Context, shadow []*target.SyncUpdate, pruneID string, sem *semaphore.Weighted, tracker *syncTracker) error {
+	d.syncSwapMutex.Lock()
+	defer d.syncSwapMutex.Unlock()
+
+	log.Debugf("%s: swapping in %d synced notifications", d.Name(), len(shadow))
+	for _, syncup := range shadow {
+		err := sem.Acquire(ctx, 1)
+		if err != nil {
+			return err
+		}
+		go d.storeSyncMsg(ctx, syncup, sem, tracker)
+	}
+	return d.finishSync(ctx, pruneID, sem, tracker)
+}
+
+// finishSync waits for the writes of the sync iteration to complete and prunes the cache,
+// removing the stale CONFIG store paths tracked by the tracker, if any.
+func (d *Datastore) finishSync(ctx context.Context, pruneID string, sem *semaphore.Weighted, tracker *syncTracker) error {
+	err := sem.Acquire(ctx, d.config.Sync.WriteWorkers)
+	if err != nil {
+		return err
+	}
+	sem.Release(d.config.Sync.WriteWorkers)
+
+	if tracker != nil {
+		d.pruneStale(ctx, tracker)
+	}
+	d.applyPrune(ctx, pruneID)
+	return nil
+}
+
 func isState(r *sdcpb.GetSchemaResponse) bool {
 	switch r := r.Schema.Schema.(type) {
 	case *sdcpb.SchemaElem_Container:
@@ -391,7 +765,7 @@ func isState(r *sdcpb.GetSchemaResponse) bool {
 	return false
 }
 
-func (d *Datastore) storeSyncMsg(ctx context.Context, syncup *target.SyncUpdate, sem *semaphore.Weighted) {
+func (d *Datastore) storeSyncMsg(ctx context.Context, syncup *target.SyncUpdate, sem *semaphore.Weighted, tracker *syncTracker) {
 	defer sem.Release(1)
 
 	converter := utils.NewConverter(d.getValidationClient())
@@ -415,11 +789,20 @@ func (d *Datastore) storeSyncMsg(ctx context.Context, syncup *target.SyncUpdate,
 	cNotification.Update = upds.Updates()
 
 	for _, x := range cNotification.GetUpdate() {
-		fmt.Printf("%s\n", x.String())
+		log.Tracef("datastore %s sync update: %s", d.Name(), x.String())
 	}
 
+	// state synced by the target is written into the STATE store
+	syncStore := cachepb.Store_CONFIG
+	if syncup.Store == target.SyncStoreState {
+		syncStore = cachepb.Store_STATE
+	}
+
+	// the changes performed outside of the data-server
+	outOfBand := make([]*OutOfBandChange, 0)
+
 	for _, del := range cNotification.GetDelete() {
-		store := cachepb.Store_CONFIG
+		store := syncStore
 		if d.config.Sync != nil && d.config.Sync.Validate {
 			scRsp, err := d.getSchema(ctx, del)
 			if err != nil {
@@ -431,6 +814,14 @@ func (d *Datastore) storeSyncMsg(ctx context.Context, syncup *target.SyncUpdate,
 			}
 		}
 		delPath := utils.ToStrings(del, false, false)
+		if store == cachepb.Store_CONFIG && d.outOfBandEnabled() {
+			change, err := d.detectOutOfBand(ctx, delPath, nil)
+			if err != nil {
+				log.Errorf("datastore %s failed to check delete path %v for out-of-band changes: %v", d.config.Name, delPath, err)
+			} else if change != nil {
+				outOfBand = append(outOfBand, change)
+			}
+		}
 		rctx, cancel := context.WithTimeout(ctx, time.Minute) // TODO:
 		defer cancel()
 		err = d.cacheClient.Modify(rctx, d.Config().Name,
@@ -444,7 +835,7 @@ func (d *Datastore) storeSyncMsg(ctx context.Context, syncup *target.SyncUpdate,
 	}
 
 	for _, upd := range cNotification.GetUpdate() {
-		store := cachepb.Store_CONFIG
+		store := syncStore
 		if d.config.Sync != nil && d.config.Sync.Validate {
 			scRsp, err := d.getSchema(ctx, upd.GetPath())
 			if err != nil {
@@ -461,6 +852,17 @@ func (d *Datastore) storeSyncMsg(ctx context.Context, syncup *target.SyncUpdate,
 			log.Errorf("datastore %s failed to create update from %v: %v", d.config.Name, upd, err)
 			continue
 		}
+		if store == cachepb.Store_CONFIG {
+			tracker.synced(cUpd.GetPath())
+		}
+		if store == cachepb.Store_CONFIG && d.outOfBandEnabled() {
+			change, err := d.detectOutOfBand(ctx, cUpd.GetPath(), upd.GetValue())
+			if err != nil {
+				log.Errorf("datastore %s failed to check update path %v for out-of-band changes: %v", d.config.Name, cUpd.GetPath(), err)
+			} else if change != nil {
+				outOfBand = append(outOfBand, change)
+			}
+		}
 
 		rctx, cancel := context.WithTimeout(ctx, time.Minute) // TODO:[KR] make this timeout configurable ?
 		defer cancel()
@@ -471,6 +873,16 @@ func (d *Datastore) storeSyncMsg(ctx context.Context, syncup *target.SyncUpdate,
 			log.Errorf("datastore %s failed to send modify request to cache: %v", d.config.Name, err)
 		}
 	}
+
+	for _, change := range outOfBand {
+		d.reportOutOfBand(change)
+	}
+	if len(outOfBand) > 0 && d.config.Sync.OutOfBand.Reconcile {
+		err = d.reconcileOutOfBand(ctx, outOfBand)
+		if err != nil {
+			log.Errorf("datastore %s failed to reconcile out-of-band changes: %v", d.config.Name, err)
+		}
+	}
 }
 
 type SdcpbUpdateDedup struct {
@@ -584,8 +996,6 @@ func (d *Datastore) DeviationMgr(ctx context.Context) {
 
 func (d *Datastore) runDeviationUpdate(ctx context.Context, dm map[string]sdcpb.DataServer_WatchDeviationsServer) {
 
-	sep := "/"
-
 	// send deviation START
 	for _, dc := range dm {
 		err := dc.Send(&sdcpb.WatchDeviationResponse{
@@ -605,7 +1015,7 @@ func (d *Datastore) runDeviationUpdate(ctx context.Context, dm map[string]sdcpb.
 	// go through config and calculate deviations
 	for upd := range d.cacheClient.ReadCh(ctx, d.Name(), &cache.Opts{Store: cachepb.Store_CONFIG}, [][]string{nil}, 0) {
 		// save the updates path as an already checked path
-		configPaths[strings.Join(upd.GetPath(), sep)] = struct{}{}
+		configPaths[tree.PathKey(upd.GetPath())] = struct{}{}
 
 		v, err := upd.Value()
 		if err != nil {
@@ -646,12 +1056,7 @@ func (d *Datastore) runDeviationUpdate(ctx context.Context, dm map[string]sdcpb.
 		}
 		// NOT_APPLIED or OVERRULED deviation
 		// sort intent updates by priority/TS
-		sort.Slice(intentsUpdates, func(i, j int) bool {
-			if intentsUpdates[i].Priority() == intentsUpdates[j].Priority() {
-				return intentsUpdates[i].TS() < intentsUpdates[j].TS()
-			}
-			return intentsUpdates[i].Priority() < intentsUpdates[j].Priority()
-		})
+		tree.UpdateSlice(intentsUpdates).SortByPathThenPriority()
 		// first intent
 		// // compare values with config
 		fiv, err := intentsUpdates[0].Value()
@@ -757,9 +1162,9 @@ func (d *Datastore) runDeviationUpdate(ctx context.Context, dm map[string]sdcpb.
 		return
 	}
 
-	for _, upds := range intendedUpdates {
+	intendedUpdates.Walk(func(_ tree.PathSlice, upds tree.UpdateSlice) bool {
 		for _, upd := range upds {
-			path := strings.Join(upd.GetPath(), sep)
+			path := tree.PathKey(upd.GetPath())
 			if _, exists := configPaths[path]; !exists {
 
 				// iv, err := upd.Value()
@@ -802,7 +1207,8 @@ func (d *Datastore) runDeviationUpdate(ctx context.Context, dm map[string]sdcpb.
 				}
 			}
 		}
-	}
+		return true
+	})
 
 	// send deviation event END
 	for _, dc := range dm {
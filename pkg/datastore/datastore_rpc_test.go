@@ -0,0 +1,70 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/mocks/mocktarget"
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(ctrl)
+	sbi := mocktarget.NewMockTarget(ctrl)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	d := &Datastore{
+		config:      &config.DatastoreConfig{Name: "dev1"},
+		cacheClient: cacheClient,
+		sbi:         sbi,
+		cfn:         cancel,
+		wg:          new(sync.WaitGroup),
+		intentLock:  newIntentLock(),
+	}
+
+	// a background goroutine, e.g. the sync, and an ongoing SetIntent
+	stopped := false
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		<-ctx.Done()
+		stopped = true
+	}()
+	intentCtx, release, _ := d.tryAcquireIntentLock(context.TODO(), "intent1")
+	defer release()
+
+	gomock.InOrder(
+		sbi.EXPECT().Close().Return(nil),
+		cacheClient.EXPECT().Delete(gomock.Any(), "dev1").Return(nil),
+	)
+
+	err := d.Delete(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stopped {
+		t.Errorf("expected the background goroutines to be stopped")
+	}
+	if intentCtx.Err() == nil {
+		t.Errorf("expected the ongoing SetIntent to be aborted")
+	}
+}
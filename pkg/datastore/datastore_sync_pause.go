@@ -0,0 +1,101 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"time"
+)
+
+// SyncPause describes a pause of the sync requested via PauseSync, e.g. during bulk intent migrations.
+type SyncPause struct {
+	// Reason is reported along with the pause
+	Reason string
+	// Since is the time the sync got paused
+	Since time.Time
+}
+
+// PauseSync pauses the sync of the device config until ResumeSync is called,
+// the sync restarts with a full sync once it is resumed.
+func (d *Datastore) PauseSync(reason string) {
+	d.syncPauseMutex.Lock()
+	p := &SyncPause{Reason: reason, Since: time.Now()}
+	if d.syncPause != nil {
+		p.Since = d.syncPause.Since
+	}
+	d.syncPause = p
+	d.syncPauseMutex.Unlock()
+	log.Infof("datastore %s: pausing sync: %s", d.Name(), reason)
+	d.notifySyncPause()
+}
+
+// ResumeSync resumes the sync paused by PauseSync. The sync stays paused while a maintenance pauses it.
+func (d *Datastore) ResumeSync() {
+	d.syncPauseMutex.Lock()
+	d.syncPause = nil
+	d.syncPauseMutex.Unlock()
+	log.Infof("datastore %s: resuming sync", d.Name())
+	d.notifySyncPause()
+}
+
+// SyncPause returns the pause requested via PauseSync, nil if the sync is not paused by it.
+func (d *Datastore) SyncPause() *SyncPause {
+	d.syncPauseMutex.Lock()
+	defer d.syncPauseMutex.Unlock()
+	if d.syncPause == nil {
+		return nil
+	}
+	p := *d.syncPause
+	return &p
+}
+
+// pauseSyncForApply pauses the sync while changes are pushed to the device, if configured.
+// The returned func resumes the sync, which restarts with a full sync verifying the pushed changes.
+func (d *Datastore) pauseSyncForApply() func() {
+	if d.config.Sync == nil || !d.config.Sync.PauseOnApply {
+		return func() {}
+	}
+	d.syncPauseMutex.Lock()
+	d.applyPauses++
+	d.syncPauseMutex.Unlock()
+	d.notifySyncPause()
+	return func() {
+		d.syncPauseMutex.Lock()
+		d.applyPauses--
+		d.syncPauseMutex.Unlock()
+		d.notifySyncPause()
+	}
+}
+
+// syncPaused returns true if the sync is paused by a maintenance, by PauseSync or while changes are applied.
+func (d *Datastore) syncPaused() bool {
+	if m := d.Maintenance(); m != nil && m.PauseSync {
+		return true
+	}
+	d.syncPauseMutex.Lock()
+	defer d.syncPauseMutex.Unlock()
+	return d.syncPause != nil || d.applyPauses > 0
+}
+
+// notifySyncPause signals a change of the pauses to the sync loop, which pauses or resumes the sync.
+func (d *Datastore) notifySyncPause() {
+	if d.syncPauseCh == nil {
+		return
+	}
+	select {
+	case d.syncPauseCh <- struct{}{}:
+	default:
+		// a change is already pending
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"testing"
+
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_PauseSync(t *testing.T) {
+	d := &Datastore{
+		config:      &config.DatastoreConfig{Name: "dev1", Sync: &config.Sync{PauseOnApply: true}},
+		syncPauseCh: make(chan struct{}, 1),
+	}
+
+	if d.syncPaused() {
+		t.Fatal("sync paused without any pause")
+	}
+
+	d.PauseSync("migration")
+	if p := d.SyncPause(); p == nil || p.Reason != "migration" || p.Since.IsZero() {
+		t.Errorf("SyncPause() = %+v, want the migration pause", p)
+	}
+	if !d.syncPaused() {
+		t.Error("sync not paused by PauseSync")
+	}
+	select {
+	case <-d.syncPauseCh:
+	default:
+		t.Error("the sync loop was not notified of the pause")
+	}
+
+	// the apply pause keeps the sync paused after resuming it
+	resume := d.pauseSyncForApply()
+	d.ResumeSync()
+	if p := d.SyncPause(); p != nil {
+		t.Errorf("SyncPause() = %+v after resuming", p)
+	}
+	if !d.syncPaused() {
+		t.Error("sync not paused while applying")
+	}
+	resume()
+	if d.syncPaused() {
+		t.Error("sync still paused after the apply")
+	}
+
+	// a maintenance pausing the sync keeps it paused as well
+	d.SetMaintenance(&Maintenance{Reason: "upgrade", PauseSync: true})
+	if !d.syncPaused() {
+		t.Error("sync not paused by the maintenance")
+	}
+	d.SetMaintenance(nil)
+	if d.syncPaused() {
+		t.Error("sync still paused after the maintenance")
+	}
+}
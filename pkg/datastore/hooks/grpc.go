@@ -0,0 +1,100 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks
+
+import (
+	"context"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+// The methods an external plugin implements. Both are called with the sdcpb.SetIntentRequest,
+// PreApply returns the sdcpb.SetIntentRequest to apply, PostApply an empty message.
+const (
+	grpcMethodPreApply  = "/sdcio.data.hook.Hook/PreApply"
+	grpcMethodPostApply = "/sdcio.data.hook.Hook/PostApply"
+)
+
+// The request metadata passed to external plugins.
+const (
+	// DatastoreHeader carries the name of the datastore
+	DatastoreHeader = "sdcio-hook-datastore"
+	// OptionHeaderPrefix prefixes the options of the hook
+	OptionHeaderPrefix = "sdcio-hook-option-"
+	// ApplyErrorHeader carries the error of a failed apply to PostApply
+	ApplyErrorHeader = "sdcio-hook-apply-error"
+)
+
+// grpcPlugin calls an external plugin via gRPC.
+type grpcPlugin struct {
+	cfg *config.Hook
+	cc  *grpc.ClientConn
+}
+
+func newGRPCPlugin(cfg *config.Hook) (Plugin, error) {
+	creds := insecure.NewCredentials()
+	if cfg.TLS != nil {
+		tlsCfg, err := cfg.TLS.NewConfig(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsCfg)
+	}
+	// the connection is established lazily, hence an unavailable plugin fails the hook runs only
+	cc, err := grpc.NewClient(cfg.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	return &grpcPlugin{cfg: cfg, cc: cc}, nil
+}
+
+func (p *grpcPlugin) outgoingContext(ctx context.Context, datastore string) context.Context {
+	kv := []string{DatastoreHeader, datastore}
+	for k, v := range p.cfg.Options {
+		kv = append(kv, OptionHeaderPrefix+k, v)
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+func (p *grpcPlugin) PreApply(ctx context.Context, datastore string, req *sdcpb.SetIntentRequest) error {
+	rsp := &sdcpb.SetIntentRequest{}
+	err := p.cc.Invoke(p.outgoingContext(ctx, datastore), grpcMethodPreApply, req, rsp)
+	if err != nil {
+		return err
+	}
+	proto.Reset(req)
+	proto.Merge(req, rsp)
+	return nil
+}
+
+func (p *grpcPlugin) PostApply(ctx context.Context, datastore string, req *sdcpb.SetIntentRequest, _ *sdcpb.SetIntentResponse, applyErr error) error {
+	ctx = p.outgoingContext(ctx, datastore)
+	if applyErr != nil {
+		ctx = metadata.AppendToOutgoingContext(ctx, ApplyErrorHeader, applyErr.Error())
+	}
+	return p.cc.Invoke(ctx, grpcMethodPostApply, req, &emptypb.Empty{})
+}
+
+func (p *grpcPlugin) Close() error {
+	return p.cc.Close()
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+const (
+	PhasePre  = "pre"
+	PhasePost = "post"
+
+	FailurePolicyFail   = "fail"
+	FailurePolicyIgnore = "ignore"
+)
+
+// Plugin runs the hooks of the intent apply pipeline.
+type Plugin interface {
+	// PreApply runs before the intent is validated, it may mutate the request, e.g. to inject
+	// defaults or to rewrite paths. An error rejects the intent, unless the failure policy is ignore.
+	PreApply(ctx context.Context, datastore string, req *sdcpb.SetIntentRequest) error
+	// PostApply runs after the intent was applied, applyErr is set if applying the intent failed.
+	PostApply(ctx context.Context, datastore string, req *sdcpb.SetIntentRequest, rsp *sdcpb.SetIntentResponse, applyErr error) error
+	// Close releases the resources of the plugin
+	Close() error
+}
+
+// Factory creates the Plugin of a hook.
+type Factory func(cfg *config.Hook) (Plugin, error)
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]Factory{
+		"grpc": newGRPCPlugin,
+	}
+)
+
+// Register registers the factory of a Go plugin, referenced by the plugin attribute of the hooks config.
+func Register(plugin string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	factories[plugin] = f
+}
+
+type hook struct {
+	cfg    *config.Hook
+	plugin Plugin
+}
+
+// Pipeline runs the hooks of a datastore.
+type Pipeline struct {
+	datastore string
+	pre       []*hook
+	post      []*hook
+}
+
+// New creates the hooks of the datastore.
+func New(datastore string, cfgs []*config.Hook) (*Pipeline, error) {
+	p := &Pipeline{datastore: datastore}
+	for _, cfg := range cfgs {
+		mu.RLock()
+		f, ok := factories[cfg.Plugin]
+		mu.RUnlock()
+		if !ok {
+			p.Close()
+			return nil, fmt.Errorf("hook %s: unknown plugin %s", cfg.Name, cfg.Plugin)
+		}
+		plugin, err := f(cfg)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("hook %s: %w", cfg.Name, err)
+		}
+		h := &hook{cfg: cfg, plugin: plugin}
+		switch cfg.Phase {
+		case PhasePre:
+			p.pre = append(p.pre, h)
+		case PhasePost:
+			p.post = append(p.post, h)
+		}
+	}
+	return p, nil
+}
+
+// RunPre runs the pre hooks in order and returns the request resulting from their mutations.
+// The given request is not modified.
+func (p *Pipeline) RunPre(ctx context.Context, req *sdcpb.SetIntentRequest) (*sdcpb.SetIntentRequest, error) {
+	if p == nil || len(p.pre) == 0 {
+		return req, nil
+	}
+	result := proto.Clone(req).(*sdcpb.SetIntentRequest)
+	for _, h := range p.pre {
+		// the failure policy ignore requires the mutations of a failing hook to be discarded
+		mutated := proto.Clone(result).(*sdcpb.SetIntentRequest)
+		hctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+		err := h.plugin.PreApply(hctx, p.datastore, mutated)
+		cancel()
+		if err == nil && (mutated.GetName() != req.GetName() || mutated.GetIntent() != req.GetIntent() || mutated.GetPriority() != req.GetPriority()) {
+			err = fmt.Errorf("the datastore, intent name and priority must not be changed")
+		}
+		if err != nil {
+			if h.cfg.FailurePolicy == FailurePolicyIgnore {
+				log.Warnf("datastore %s: ignoring failed pre hook %s of intent %s: %v", p.datastore, h.cfg.Name, req.GetIntent(), err)
+				continue
+			}
+			return nil, fmt.Errorf("pre hook %s rejected intent %s: %w", h.cfg.Name, req.GetIntent(), err)
+		}
+		result = mutated
+	}
+	return result, nil
+}
+
+// RunPost runs the post hooks in order. The intent is already applied, hence failures are only logged.
+func (p *Pipeline) RunPost(ctx context.Context, req *sdcpb.SetIntentRequest, rsp *sdcpb.SetIntentResponse, applyErr error) {
+	if p == nil {
+		return
+	}
+	for _, h := range p.post {
+		hctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+		err := h.plugin.PostApply(hctx, p.datastore, req, rsp, applyErr)
+		cancel()
+		if err != nil {
+			log.Errorf("datastore %s: post hook %s of intent %s failed: %v", p.datastore, h.cfg.Name, req.GetIntent(), err)
+		}
+	}
+}
+
+// Close closes the plugins of the hooks.
+func (p *Pipeline) Close() {
+	if p == nil {
+		return
+	}
+	for _, h := range append(p.pre, p.post...) {
+		if err := h.plugin.Close(); err != nil {
+			log.Errorf("datastore %s: failed to close hook %s: %v", p.datastore, h.cfg.Name, err)
+		}
+	}
+}
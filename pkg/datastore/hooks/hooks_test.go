@@ -0,0 +1,158 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hooks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+type testPlugin struct {
+	pre  func(req *sdcpb.SetIntentRequest) error
+	post func(req *sdcpb.SetIntentRequest, applyErr error)
+}
+
+func (p *testPlugin) PreApply(_ context.Context, _ string, req *sdcpb.SetIntentRequest) error {
+	return p.pre(req)
+}
+
+func (p *testPlugin) PostApply(_ context.Context, _ string, req *sdcpb.SetIntentRequest, _ *sdcpb.SetIntentResponse, applyErr error) error {
+	p.post(req, applyErr)
+	return nil
+}
+
+func (p *testPlugin) Close() error { return nil }
+
+func TestPipeline(t *testing.T) {
+	var posted []string
+	Register("test-defaults", func(cfg *config.Hook) (Plugin, error) {
+		return &testPlugin{
+			pre: func(req *sdcpb.SetIntentRequest) error {
+				req.Update = append(req.Update, &sdcpb.Update{Path: &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: cfg.Options["leaf"]}}}})
+				return nil
+			},
+		}, nil
+	})
+	Register("test-failing", func(cfg *config.Hook) (Plugin, error) {
+		return &testPlugin{
+			pre: func(req *sdcpb.SetIntentRequest) error {
+				req.Update = nil
+				return errors.New("failed")
+			},
+		}, nil
+	})
+	Register("test-rename", func(cfg *config.Hook) (Plugin, error) {
+		return &testPlugin{
+			pre: func(req *sdcpb.SetIntentRequest) error {
+				req.Intent = "other"
+				return nil
+			},
+		}, nil
+	})
+	Register("test-notify", func(cfg *config.Hook) (Plugin, error) {
+		return &testPlugin{
+			post: func(req *sdcpb.SetIntentRequest, applyErr error) {
+				posted = append(posted, req.GetIntent())
+			},
+		}, nil
+	})
+
+	hook := func(name, plugin, phase, failurePolicy string) *config.Hook {
+		return &config.Hook{Name: name, Plugin: plugin, Phase: phase, FailurePolicy: failurePolicy, Timeout: time.Second, Options: map[string]string{"leaf": name}}
+	}
+
+	tests := []struct {
+		name        string
+		hooks       []*config.Hook
+		wantUpdates []string
+		wantErr     bool
+	}{
+		{
+			name:        "mutating hooks run in order",
+			hooks:       []*config.Hook{hook("a", "test-defaults", PhasePre, FailurePolicyFail), hook("b", "test-defaults", PhasePre, FailurePolicyFail)},
+			wantUpdates: []string{"a", "b"},
+		},
+		{
+			name:        "ignored failure discards the mutations",
+			hooks:       []*config.Hook{hook("a", "test-defaults", PhasePre, FailurePolicyFail), hook("b", "test-failing", PhasePre, FailurePolicyIgnore)},
+			wantUpdates: []string{"a"},
+		},
+		{
+			name:    "failure rejects the intent",
+			hooks:   []*config.Hook{hook("a", "test-failing", PhasePre, FailurePolicyFail)},
+			wantErr: true,
+		},
+		{
+			name:    "intent must not be renamed",
+			hooks:   []*config.Hook{hook("a", "test-rename", PhasePre, FailurePolicyFail)},
+			wantErr: true,
+		},
+		{
+			name:  "post hooks do not run before apply",
+			hooks: []*config.Hook{hook("a", "test-notify", PhasePost, FailurePolicyFail)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New("dev1", tt.hooks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			req := &sdcpb.SetIntentRequest{Name: "dev1", Intent: "intent1", Priority: 10}
+			got, err := p.RunPre(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunPre() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(req.GetUpdate()) != 0 {
+				t.Errorf("RunPre() modified the given request")
+			}
+			updates := make([]string, 0, len(got.GetUpdate()))
+			for _, u := range got.GetUpdate() {
+				updates = append(updates, u.GetPath().GetElem()[0].GetName())
+			}
+			if len(updates) != len(tt.wantUpdates) {
+				t.Fatalf("RunPre() updates = %v, want %v", updates, tt.wantUpdates)
+			}
+			for i := range updates {
+				if updates[i] != tt.wantUpdates[i] {
+					t.Errorf("RunPre() updates = %v, want %v", updates, tt.wantUpdates)
+				}
+			}
+		})
+	}
+
+	p, err := New("dev1", []*config.Hook{hook("a", "test-notify", PhasePost, FailurePolicyFail)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.RunPost(context.Background(), &sdcpb.SetIntentRequest{Intent: "intent1"}, &sdcpb.SetIntentResponse{}, nil)
+	if len(posted) != 1 || posted[0] != "intent1" {
+		t.Errorf("RunPost() notified %v, want [intent1]", posted)
+	}
+
+	if _, err = New("dev1", []*config.Hook{hook("a", "unknown", PhasePre, FailurePolicyFail)}); err == nil {
+		t.Errorf("New() with an unknown plugin did not fail")
+	}
+}
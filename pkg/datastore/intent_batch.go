@@ -0,0 +1,184 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/datastore/target"
+	"github.com/sdcio/data-server/pkg/tree"
+)
+
+// batchOwner identifies batched SetIntents as the holder of the intent lock and in the journal.
+const batchOwner = "batch"
+
+// SetIntents sets several new, updated or deleted intents in a single pass. The intents are merged into one tree,
+// validated together and their changes are applied to the device in a single transaction, either all or none.
+// Unlike SetIntent, the batch is not run through the hooks, the write-ahead log or the idempotency keys.
+func (d *Datastore) SetIntents(ctx context.Context, reqs []*sdcpb.SetIntentRequest, dryRun bool) (*SetIntentResult, error) {
+	result := &SetIntentResult{Response: &sdcpb.SetIntentResponse{}}
+	if len(reqs) == 0 {
+		return result, nil
+	}
+	owners := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		if req.GetIntent() == "" {
+			return nil, status.Error(codes.InvalidArgument, "missing intent name")
+		}
+		if req.GetOnlyIntended() {
+			return nil, status.Errorf(codes.InvalidArgument, "intent %s: only-intended deletes are not supported in batches", req.GetIntent())
+		}
+		owners = append(owners, req.GetIntent())
+	}
+
+	if err := d.awaitMaintenance(ctx); err != nil {
+		return nil, err
+	}
+	ctx, release, ok := d.tryAcquireIntentLock(ctx, batchOwner)
+	if !ok {
+		return nil, status.Errorf(codes.ResourceExhausted, "datastore %s has an ongoing SetIntentRequest", d.Name())
+	}
+	defer release()
+
+	log.Infof("ds=%s: setting intents %v in one pass", d.Name(), owners)
+
+	tc := d.newIntentTreeContext(batchOwner)
+	tc.SetActualOwners(owners...)
+
+	populateCtx, cancel := d.intentPhaseContext(ctx, intentPhasePopulate)
+	defer cancel()
+	root, err := d.populateTree(populateCtx, tc, reqs...)
+	if err != nil {
+		return nil, intentPhaseError(populateCtx, intentPhasePopulate, err)
+	}
+	err = d.populateTreeWithRunning(populateCtx, tc, root)
+	if err != nil {
+		return nil, intentPhaseError(populateCtx, intentPhasePopulate, err)
+	}
+	root.FinishInsertionPhase()
+
+	for _, owner := range owners {
+		if tree.ConflictPolicy(d.config.ConflictPolicy) == tree.ConflictPolicyReject {
+			if conflicts := root.GetPriorityConflicts(owner); len(conflicts) > 0 {
+				conflictErrs := make([]error, 0, len(conflicts))
+				for _, c := range conflicts {
+					conflictErrs = append(conflictErrs, errors.New(c.String()))
+				}
+				return nil, fmt.Errorf("intent %q conflicts with intents of the same priority:\n%v", owner, errors.Join(conflictErrs...))
+			}
+		}
+		// the batch carries no force flag, hence overwrites of running are only accepted if the policy allows them
+		for _, o := range root.GetRunningOverwrites(owner) {
+			if tree.RunningProtection(d.config.RunningProtection) != tree.RunningProtectionOverwrite {
+				return nil, fmt.Errorf("intent %q overwrites config that is not owned by any intent:\n%s", owner, o.String())
+			}
+			result.Response.Warnings = append(result.Response.Warnings, o.String())
+		}
+	}
+
+	validationCtx, cancel := d.intentPhaseContext(ctx, intentPhaseValidation)
+	defer cancel()
+	validationErrors, validationWarnings := validateTree(validationCtx, root)
+	if err = validationCtx.Err(); err != nil {
+		return nil, intentPhaseError(validationCtx, intentPhaseValidation, err)
+	}
+	if len(validationErrors) > 0 {
+		return nil, fmt.Errorf("cumulated validation errors:\n%v", errors.Join(validationErrors...))
+	}
+	for _, e := range validationWarnings {
+		result.Response.Warnings = append(result.Response.Warnings, e.Error())
+	}
+
+	// the combined changes of the intents towards the device
+	updates := root.GetUpdatesDivergingFromRunning()
+	deletes, err := root.GetDeletes(tc.GetDeleteAggregation())
+	if err != nil {
+		return nil, err
+	}
+	delPaths := make(tree.PathSlices, 0, len(deletes))
+	for _, u := range updates {
+		upd, err := d.cacheUpdateToUpdate(ctx, u.GetUpdate())
+		if err != nil {
+			return nil, err
+		}
+		result.Response.Update = append(result.Response.Update, upd)
+	}
+	for _, del := range deletes {
+		p, err := del.SdcpbPath()
+		if err != nil {
+			return nil, err
+		}
+		result.Response.Delete = append(result.Response.Delete, p)
+		delPaths = append(delPaths, del.Path())
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	switch {
+	case (len(updates) > 0 || len(deletes) > 0) && d.applyDeferred(time.Now()):
+		next, err := d.deferChanges(ctx, owners, updates, deletes)
+		if err != nil {
+			return nil, fmt.Errorf("failed recording the deferred changes: %w", err)
+		}
+		result.Deferred = true
+		result.Response.Warnings = append(result.Response.Warnings, deferredMessage(next))
+	default:
+		var source target.TargetSource = root
+		if d.config.ApplyMode == applyModeFullReplace {
+			source, err = d.fullIntendedTree(ctx, tc, root, owners...)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// the changes are applied directly, there is no single owner a candidate could be created for
+		candidateName := fmt.Sprintf("%s-%d", batchOwner, time.Now().UnixNano())
+		applyCtx, cancel := d.intentPhaseContext(ctx, intentPhaseApply)
+		defer cancel()
+		dataResp, err := d.applyIntent(applyCtx, batchOwner, candidateName, source)
+		if err != nil {
+			return nil, intentPhaseError(applyCtx, intentPhaseApply, err)
+		}
+		result.Response.Warnings = append(result.Response.Warnings, dataResp.GetWarnings()...)
+	}
+
+	err = d.updateIntendedStore(ctx, root, reqs...)
+	if err != nil {
+		return nil, err
+	}
+
+	// fast and optimistic writeback to the config store, deferred changes are written back once they are pushed
+	if !result.Deferred {
+		if err = d.writeBackConfig(ctx, delPaths, updates.ToCacheUpdateSlice()); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, req := range reqs {
+		if err = d.storeRawIntent(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	log.Infof("ds=%s: set intents %v", d.Name(), owners)
+	return result, nil
+}
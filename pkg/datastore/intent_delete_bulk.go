@@ -0,0 +1,171 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/tree"
+)
+
+// bulkDeleteOwner identifies the bulk deletion of intents as the holder of the intent lock and in the journal.
+const bulkDeleteOwner = "bulk-delete"
+
+// DeleteIntentsRequest selects the intents that are removed by DeleteIntents.
+type DeleteIntentsRequest struct {
+	// Filter selects the intents to delete, all intents are deleted if nil
+	Filter func(*sdcpb.Intent) bool
+	// OnlyIntended removes the intents from the intended store without changing the device
+	OnlyIntended bool
+	// DryRun computes the changes towards the device without applying them
+	DryRun bool
+}
+
+// DeleteIntentsResult is the result of DeleteIntents.
+type DeleteIntentsResult struct {
+	// Intents are the deleted intents
+	Intents []*sdcpb.Intent
+	// Response carries the changes towards the device
+	Response *sdcpb.SetIntentResponse
+}
+
+// DeleteIntents deletes all the intents, or the ones selected by the filter, in one pass.
+// The values that take over from the deleted intents and the deletes are combined into a single
+// request towards the device, rather than applying a delete per intent.
+func (d *Datastore) DeleteIntents(ctx context.Context, req *DeleteIntentsRequest) (*DeleteIntentsResult, error) {
+	ctx, release, ok := d.tryAcquireIntentLock(ctx, bulkDeleteOwner)
+	if !ok {
+		return nil, status.Errorf(codes.ResourceExhausted, "datastore %s has an ongoing SetIntentRequest", d.Name())
+	}
+	defer release()
+
+	all, err := d.listRawIntent(ctx)
+	if err != nil {
+		return nil, err
+	}
+	intents := make([]*sdcpb.Intent, 0, len(all))
+	owners := make([]string, 0, len(all))
+	for _, in := range all {
+		if req.Filter == nil || req.Filter(in) {
+			intents = append(intents, in)
+			owners = append(owners, in.GetIntent())
+		}
+	}
+	result := &DeleteIntentsResult{Intents: intents, Response: &sdcpb.SetIntentResponse{}}
+	if len(intents) == 0 {
+		return result, nil
+	}
+	log.Infof("ds=%s: deleting %d intents", d.Name(), len(intents))
+
+	tc := d.newIntentTreeContext(bulkDeleteOwner)
+	root, err := tree.NewTreeRoot(ctx, tc)
+	if err != nil {
+		return nil, err
+	}
+	storeIndex, err := d.readStoreKeysMeta(ctx, cachepb.Store_INTENDED)
+	if err != nil {
+		return nil, err
+	}
+	tc.SetStoreIndex(storeIndex)
+	root.LoadIntendedStoreOwnersData(ctx, owners, nil)
+
+	err = d.populateTreeWithRunning(ctx, tc, root)
+	if err != nil {
+		return nil, err
+	}
+	root.FinishInsertionPhase()
+
+	validationErrors, validationWarnings := validateTree(ctx, root)
+	if len(validationErrors) > 0 {
+		return nil, fmt.Errorf("cumulated validation errors:\n%v", errors.Join(validationErrors...))
+	}
+	for _, e := range validationWarnings {
+		result.Response.Warnings = append(result.Response.Warnings, e.Error())
+	}
+
+	// the values taking over from the deleted intents and the deletes
+	updates := root.GetUpdatesDivergingFromRunning()
+	deletes, err := root.GetDeletes(tc.GetDeleteAggregation())
+	if err != nil {
+		return nil, err
+	}
+	delPaths := make(tree.PathSlices, 0, len(deletes))
+	for _, u := range updates {
+		upd, err := d.cacheUpdateToUpdate(ctx, u.GetUpdate())
+		if err != nil {
+			return nil, err
+		}
+		result.Response.Update = append(result.Response.Update, upd)
+	}
+	for _, del := range deletes {
+		p, err := del.SdcpbPath()
+		if err != nil {
+			return nil, err
+		}
+		result.Response.Delete = append(result.Response.Delete, p)
+		delPaths = append(delPaths, del.Path())
+	}
+
+	if req.DryRun {
+		return result, nil
+	}
+
+	if !req.OnlyIntended {
+		// the changes are applied directly, there is no single owner a candidate could be created for
+		candidateName := fmt.Sprintf("%s-%d", bulkDeleteOwner, time.Now().UnixNano())
+		dataResp, err := d.applyIntent(ctx, bulkDeleteOwner, candidateName, root)
+		if err != nil {
+			return nil, err
+		}
+		result.Response.Warnings = append(result.Response.Warnings, dataResp.GetWarnings()...)
+	}
+
+	for _, in := range intents {
+		err = d.cacheClient.Modify(ctx, d.Name(), &cache.Opts{
+			Store:    cachepb.Store_INTENDED,
+			Owner:    in.GetIntent(),
+			Priority: in.GetPriority(),
+		}, root.GetDeletesForOwner(in.GetIntent()).ToStringSlice(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed updating the intended store for %s: %w", d.Name(), err)
+		}
+	}
+	d.setTreeSnapshot(root)
+
+	if !req.OnlyIntended {
+		// fast and optimistic writeback to the config store
+		if err = d.writeBackConfig(ctx, delPaths, updates.ToCacheUpdateSlice()); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, in := range intents {
+		err = d.deleteRawIntent(ctx, in.GetIntent(), in.GetPriority())
+		if err != nil {
+			return nil, err
+		}
+	}
+	log.Infof("ds=%s: deleted %d intents", d.Name(), len(intents))
+	return result, nil
+}
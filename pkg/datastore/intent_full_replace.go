@@ -0,0 +1,60 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/sdcio/data-server/pkg/tree"
+)
+
+// applyModeFullReplace pushes the complete intended config to the target, rather than the changes of an intent.
+const applyModeFullReplace = "full-replace"
+
+// fullIntendedTree returns a tree that holds the complete intended config of the datastore,
+// as it results from the given, already validated tree of the intents.
+// The values of all other intents are read from the intended store, the running values are not included.
+func (d *Datastore) fullIntendedTree(ctx context.Context, tc *tree.TreeContext, root *tree.RootEntry, intentNames ...string) (*tree.RootEntry, error) {
+	full, err := tree.NewTreeRoot(ctx, d.newIntentTreeContext(intentNames[0]))
+	if err != nil {
+		return nil, err
+	}
+
+	if paths := tc.IntendedStorePrefixes(); len(paths) > 0 {
+		// one more than the intents highest priorities are read, such that the value that takes over
+		// from an updated or deleted value of the intents is included
+		for _, upd := range tc.ReadCurrentUpdatesHighestPriorities(ctx, paths, uint64(len(intentNames)+1)) {
+			if slices.Contains(intentNames, upd.Owner()) {
+				continue
+			}
+			if _, err := full.AddCacheUpdateRecursive(ctx, upd, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// the values of the intents are taken from the tree, they are not yet in the intended store
+	for _, intentName := range intentNames {
+		for _, upd := range root.GetIntendedForOwner(intentName) {
+			if _, err := full.AddCacheUpdateRecursive(ctx, upd, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	full.FinishInsertionPhase()
+	return full, nil
+}
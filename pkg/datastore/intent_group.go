@@ -0,0 +1,129 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	"github.com/sdcio/data-server/pkg/cache"
+)
+
+// intentGroupPrefix is the prefix of the intent group keys in the intents store.
+var intentGroupPrefix = "__intent_group__"
+
+var ErrIntentGroupNotFound = errors.New("intent group not found")
+
+// IntentGroup groups intents across datastores under a single service name,
+// that are applied and deleted as a whole.
+type IntentGroup struct {
+	// Name of the group, e.g. the service name
+	Name string `json:"name"`
+	// Members are the intents of the group
+	Members []*IntentGroupMember `json:"members"`
+}
+
+// IntentGroupMember references an intent of an IntentGroup.
+type IntentGroupMember struct {
+	Datastore string `json:"datastore"`
+	Intent    string `json:"intent"`
+	Priority  int32  `json:"priority"`
+}
+
+// SaveIntentGroup stores the intent group in the intents store of the datastore.
+func (d *Datastore) SaveIntentGroup(ctx context.Context, g *IntentGroup) error {
+	b, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+	upd, err := d.cacheClient.NewUpdate(
+		&sdcpb.Update{
+			Path: &sdcpb.Path{
+				Elem: []*sdcpb.PathElem{{Name: intentGroupKey(g.Name)}},
+			},
+			Value: &sdcpb.TypedValue{
+				Value: &sdcpb.TypedValue_BytesVal{BytesVal: b},
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return d.cacheClient.Modify(ctx, d.config.Name,
+		&cache.Opts{
+			Store: cachepb.Store_INTENTS,
+		},
+		nil,
+		[]*cache.Update{upd})
+}
+
+// GetIntentGroup returns the intent group stored in the datastore, ErrIntentGroupNotFound if it does not exist.
+func (d *Datastore) GetIntentGroup(ctx context.Context, name string) (*IntentGroup, error) {
+	upds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store: cachepb.Store_INTENTS,
+	}, [][]string{{intentGroupKey(name)}}, 0)
+	if len(upds) == 0 {
+		return nil, ErrIntentGroupNotFound
+	}
+	val, err := upds[0].Value()
+	if err != nil {
+		return nil, err
+	}
+	g := &IntentGroup{}
+	err = json.Unmarshal(val.GetBytesVal(), g)
+	if err != nil {
+		return nil, fmt.Errorf("malformed intent group %s: %w", name, err)
+	}
+	return g, nil
+}
+
+// ListIntentGroups returns the names of the intent groups stored in the datastore, sorted by name.
+func (d *Datastore) ListIntentGroups(ctx context.Context) []string {
+	upds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store:    cachepb.Store_INTENTS,
+		KeysOnly: true,
+	}, [][]string{{"*"}}, 0)
+	names := make([]string, 0, len(upds))
+	for _, upd := range upds {
+		if len(upd.GetPath()) == 0 || !strings.HasPrefix(upd.GetPath()[0], intentGroupPrefix) {
+			continue
+		}
+		names = append(names, strings.TrimPrefix(upd.GetPath()[0], intentGroupPrefix))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DeleteIntentGroup removes the intent group from the intents store of the datastore.
+// The intents of the group are not affected.
+func (d *Datastore) DeleteIntentGroup(ctx context.Context, name string) error {
+	return d.cacheClient.Modify(ctx, d.config.Name,
+		&cache.Opts{
+			Store: cachepb.Store_INTENTS,
+		},
+		[][]string{{intentGroupKey(name)}},
+		nil)
+}
+
+func intentGroupKey(name string) string {
+	return intentGroupPrefix + name
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_IntentGroup(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	store := map[string]*cache.Update{}
+	configureIntentsStoreMock(cacheClient, store)
+
+	d := &Datastore{
+		config:      &config.DatastoreConfig{Name: "dev1"},
+		cacheClient: cacheClient,
+	}
+	ctx := context.Background()
+
+	// a raw intent living in the same store must neither show up as a group, nor the groups as intents
+	err := d.saveRawIntent(ctx, "intent1", &sdcpb.SetIntentRequest{Intent: "intent1", Priority: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups := []*IntentGroup{
+		{
+			Name: "service2",
+			Members: []*IntentGroupMember{
+				{Datastore: "dev1", Intent: "service2-dev1", Priority: 10},
+			},
+		},
+		{
+			Name: "service1",
+			Members: []*IntentGroupMember{
+				{Datastore: "dev1", Intent: "service1", Priority: 10},
+				{Datastore: "dev2", Intent: "service1", Priority: 10},
+			},
+		},
+	}
+	for _, g := range groups {
+		if err := d.SaveIntentGroup(ctx, g); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, want := d.ListIntentGroups(ctx), []string{"service1", "service2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListIntentGroups() = %v, want %v", got, want)
+	}
+	intents, err := d.listRawIntent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intents) != 1 {
+		t.Errorf("listRawIntent() returned %d intents, want 1", len(intents))
+	}
+
+	got, err := d.GetIntentGroup(ctx, "service1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, groups[1]) {
+		t.Errorf("GetIntentGroup() = %v, want %v", got, groups[1])
+	}
+
+	if err := d.DeleteIntentGroup(ctx, "service1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.GetIntentGroup(ctx, "service1"); !errors.Is(err, ErrIntentGroupNotFound) {
+		t.Errorf("GetIntentGroup() of deleted group error = %v, want %v", err, ErrIntentGroupNotFound)
+	}
+	if got, want := d.ListIntentGroups(ctx), []string{"service2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListIntentGroups() = %v, want %v", got, want)
+	}
+}
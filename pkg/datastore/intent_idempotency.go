@@ -0,0 +1,206 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/pkg/cache"
+)
+
+// idempotencyPrefix is the prefix of the processed idempotency keys in the intents store.
+var idempotencyPrefix = "__idempotency__"
+
+// idempotencyRecord is the outcome of a SetIntentRequest carrying an idempotency key.
+type idempotencyRecord struct {
+	Timestamp int64  `json:"timestamp"`
+	Intent    string `json:"intent"`
+	Priority  int32  `json:"priority"`
+	// Response is the SetIntentResponse of a successful request
+	Response   []byte   `json:"response,omitempty"`
+	DeviceDiff string   `json:"device-diff,omitempty"`
+	NoOp       bool     `json:"no-op,omitempty"`
+	Removed    [][]byte `json:"removed,omitempty"`
+	// Code and Error are the status of a failed request
+	Code  codes.Code `json:"code,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+func idempotencyKey(key string) string {
+	return idempotencyPrefix + key
+}
+
+// newIdempotencyRecord returns the record of the outcome of the request.
+func newIdempotencyRecord(req *sdcpb.SetIntentRequest, result *SetIntentResult, err error) (*idempotencyRecord, error) {
+	rec := &idempotencyRecord{
+		Timestamp: time.Now().UnixNano(),
+		Intent:    req.GetIntent(),
+		Priority:  req.GetPriority(),
+	}
+	if err != nil {
+		st, _ := status.FromError(err)
+		rec.Code = st.Code()
+		rec.Error = st.Message()
+		return rec, nil
+	}
+	rec.Response, err = proto.Marshal(result.Response)
+	if err != nil {
+		return nil, err
+	}
+	rec.DeviceDiff = result.DeviceDiff
+	rec.NoOp = result.NoOp
+	for _, p := range result.Removed {
+		b, err := proto.Marshal(p)
+		if err != nil {
+			return nil, err
+		}
+		rec.Removed = append(rec.Removed, b)
+	}
+	return rec, nil
+}
+
+// outcome returns the original outcome of the request the record belongs to.
+// The uncovered entries of deleted intents are only retained as warnings of the response.
+func (r *idempotencyRecord) outcome(req *sdcpb.SetIntentRequest) (*SetIntentResult, error) {
+	if r.Intent != req.GetIntent() || r.Priority != req.GetPriority() {
+		return nil, status.Errorf(codes.InvalidArgument, "idempotency key already used for intent %s with priority %d", r.Intent, r.Priority)
+	}
+	if r.Error != "" {
+		return nil, status.Error(r.Code, r.Error)
+	}
+	result := &SetIntentResult{
+		Response:   &sdcpb.SetIntentResponse{},
+		DeviceDiff: r.DeviceDiff,
+		NoOp:       r.NoOp,
+	}
+	if err := proto.Unmarshal(r.Response, result.Response); err != nil {
+		return nil, err
+	}
+	for _, b := range r.Removed {
+		p := &sdcpb.Path{}
+		if err := proto.Unmarshal(b, p); err != nil {
+			return nil, err
+		}
+		result.Removed = append(result.Removed, p)
+	}
+	return result, nil
+}
+
+// getIdempotencyRecord returns the record of the key, nil if the key was not processed or its record expired.
+func (d *Datastore) getIdempotencyRecord(ctx context.Context, key string) (*idempotencyRecord, error) {
+	upds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store: cachepb.Store_INTENTS,
+	}, [][]string{{idempotencyKey(key)}}, 0)
+	if len(upds) == 0 {
+		return nil, nil
+	}
+	val, err := upds[0].Value()
+	if err != nil {
+		return nil, err
+	}
+	rec := &idempotencyRecord{}
+	if err = json.Unmarshal(val.GetBytesVal(), rec); err != nil {
+		return nil, fmt.Errorf("malformed idempotency record %s: %w", key, err)
+	}
+	if d.idempotencyExpired(rec) {
+		return nil, nil
+	}
+	return rec, nil
+}
+
+func (d *Datastore) idempotencyExpired(rec *idempotencyRecord) bool {
+	return time.Since(time.Unix(0, rec.Timestamp)) > d.config.IdempotencyTTL
+}
+
+// writeIdempotencyRecord stores the outcome of the request under the key,
+// removing the records that outlived the idempotency TTL along the way.
+func (d *Datastore) writeIdempotencyRecord(ctx context.Context, key string, req *sdcpb.SetIntentRequest, result *SetIntentResult, resultErr error) error {
+	rec, err := newIdempotencyRecord(req, result, resultErr)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	upd, err := d.cacheClient.NewUpdate(
+		&sdcpb.Update{
+			Path: &sdcpb.Path{
+				Elem: []*sdcpb.PathElem{{Name: idempotencyKey(key)}},
+			},
+			Value: &sdcpb.TypedValue{
+				Value: &sdcpb.TypedValue_BytesVal{BytesVal: b},
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	expired, err := d.expiredIdempotencyKeys(ctx)
+	if err != nil {
+		return err
+	}
+	return d.cacheClient.Modify(ctx, d.config.Name,
+		&cache.Opts{
+			Store: cachepb.Store_INTENTS,
+		},
+		expired,
+		[]*cache.Update{upd})
+}
+
+// expiredIdempotencyKeys returns the paths of the records that outlived the idempotency TTL.
+func (d *Datastore) expiredIdempotencyKeys(ctx context.Context) ([][]string, error) {
+	upds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store:    cachepb.Store_INTENTS,
+		KeysOnly: true,
+	}, [][]string{{"*"}}, 0)
+	paths := make([][]string, 0, len(upds))
+	for _, upd := range upds {
+		if len(upd.GetPath()) == 0 || !strings.HasPrefix(upd.GetPath()[0], idempotencyPrefix) {
+			continue
+		}
+		paths = append(paths, []string{upd.GetPath()[0]})
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	upds = d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store: cachepb.Store_INTENTS,
+	}, paths, 0)
+
+	result := make([][]string, 0, len(upds))
+	for _, upd := range upds {
+		val, err := upd.Value()
+		if err != nil {
+			return nil, err
+		}
+		rec := &idempotencyRecord{}
+		// malformed records are removed as well
+		if err = json.Unmarshal(val.GetBytesVal(), rec); err != nil || d.idempotencyExpired(rec) {
+			result = append(result, upd.GetPath())
+		}
+	}
+	return result, nil
+}
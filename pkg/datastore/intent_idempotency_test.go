@@ -0,0 +1,119 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_Idempotency(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	store := map[string]*cache.Update{}
+	configureIntentsStoreMock(cacheClient, store)
+
+	d := &Datastore{
+		config:      &config.DatastoreConfig{Name: "dev1", IdempotencyTTL: time.Hour},
+		cacheClient: cacheClient,
+	}
+	ctx := context.Background()
+
+	req := &sdcpb.SetIntentRequest{Name: "dev1", Intent: "intent1", Priority: 10}
+	result := &SetIntentResult{
+		Response: &sdcpb.SetIntentResponse{Warnings: []string{"warning"}},
+		Removed:  []*sdcpb.Path{{Elem: []*sdcpb.PathElem{{Name: "interface"}}}},
+	}
+
+	rec, err := d.getIdempotencyRecord(ctx, "key1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec != nil {
+		t.Fatalf("getIdempotencyRecord() returned a record of an unknown key")
+	}
+
+	if err = d.writeIdempotencyRecord(ctx, "key1", req, result, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = d.writeIdempotencyRecord(ctx, "key2", req, nil, status.Error(codes.FailedPrecondition, "failed")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the records must not show up as intents
+	intents, err := d.listRawIntent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intents) != 0 {
+		t.Errorf("listRawIntent() returned %d intents, want 0", len(intents))
+	}
+
+	rec, err = d.getIdempotencyRecord(ctx, "key1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := rec.outcome(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(got.Response, result.Response) || len(got.Removed) != 1 || !proto.Equal(got.Removed[0], result.Removed[0]) {
+		t.Errorf("outcome() = %v, want %v", got, result)
+	}
+
+	// the key must not be reused for another intent
+	_, err = rec.outcome(&sdcpb.SetIntentRequest{Name: "dev1", Intent: "intent2", Priority: 10})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("outcome() of another intent returned %v, want %v", err, codes.InvalidArgument)
+	}
+
+	rec, err = d.getIdempotencyRecord(ctx, "key2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = rec.outcome(req)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("outcome() of a failed request returned %v, want %v", err, codes.FailedPrecondition)
+	}
+
+	// expired records are ignored and removed with the next write
+	d.config.IdempotencyTTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	rec, err = d.getIdempotencyRecord(ctx, "key1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec != nil {
+		t.Errorf("getIdempotencyRecord() returned an expired record")
+	}
+	expired, err := d.expiredIdempotencyKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 2 {
+		t.Errorf("expiredIdempotencyKeys() returned %d keys, want 2", len(expired))
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	SchemaClient "github.com/sdcio/data-server/pkg/datastore/clients/schema"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// prefixedMemberPattern matches the member names prefixed by a module name, which the intents accept as
+// in JSON_IETF. The generated schemas use the plain member names and do not validate the prefixed ones.
+const prefixedMemberPattern = "^[^:]+:"
+
+// rootSchemaName is the name of the root container of the schema
+const rootSchemaName = "__root__"
+
+// unboundedElements is the max-elements of the lists and leaf-lists without max-elements statement
+const unboundedElements = math.MaxUint64
+
+// IntentJSONSchema returns the JSON Schema of the value of an intent update at the given path, derived from the
+// YANG schema of the datastore, such that clients can validate the intent payloads before submitting them.
+// The root path yields the schema of the whole configuration. The state only nodes are omitted.
+func (d *Datastore) IntentJSONSchema(ctx context.Context, p *sdcpb.Path) (map[string]any, error) {
+	scb := d.getValidationClient()
+	rsp, err := scb.GetSchema(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	// a list with the keys in the path is a single list entry
+	entry := true
+	if c := rsp.GetSchema().GetContainer(); c != nil && len(c.GetKeys()) > 0 {
+		var keys map[string]string
+		if n := len(p.GetElem()); n > 0 {
+			keys = p.GetElem()[n-1].GetKey()
+		}
+		entry = len(keys) > 0
+	}
+	s, err := elemJSONSchema(ctx, scb, p, rsp.GetSchema(), entry)
+	if err != nil {
+		return nil, err
+	}
+	s["$schema"] = utils.JSONSchemaDialect
+	s["title"] = fmt.Sprintf("%s %s", d.Name(), utils.ToXPathOpts(p, utils.XPathOpts{Absolute: true}))
+	return s, nil
+}
+
+// elemJSONSchema returns the JSON Schema of the schema element at the path. A list yields the schema of its entries
+// if entry is set and the schema of the array of its entries otherwise.
+func elemJSONSchema(ctx context.Context, scb SchemaClient.SchemaClientBound, p *sdcpb.Path, elem *sdcpb.SchemaElem, entry bool) (map[string]any, error) {
+	switch {
+	case elem.GetField() != nil:
+		return leafJSONSchema(elem.GetField()), nil
+	case elem.GetLeaflist() != nil:
+		ll := elem.GetLeaflist()
+		s := map[string]any{
+			"type":  "array",
+			"items": utils.LeafTypeJSONSchema(ll.GetType()),
+		}
+		if ll.GetDescription() != "" {
+			s["description"] = ll.GetDescription()
+		}
+		if ll.GetMinElements() > 0 {
+			s["minItems"] = ll.GetMinElements()
+		}
+		if ll.GetMaxElements() > 0 && ll.GetMaxElements() != unboundedElements {
+			s["maxItems"] = ll.GetMaxElements()
+		}
+		if len(ll.GetDefaults()) > 0 {
+			defaults := make([]any, 0, len(ll.GetDefaults()))
+			for _, v := range ll.GetDefaults() {
+				defaults = append(defaults, v)
+			}
+			s["default"] = defaults
+		}
+		return s, nil
+	case elem.GetContainer() != nil:
+		c := elem.GetContainer()
+		s, err := containerJSONSchema(ctx, scb, p, c)
+		if err != nil {
+			return nil, err
+		}
+		if len(c.GetKeys()) == 0 || entry {
+			return s, nil
+		}
+		// the keys of the entries of a list are not part of the path
+		required := make([]any, 0, len(c.GetKeys()))
+		for _, k := range c.GetKeys() {
+			required = append(required, k.GetName())
+		}
+		s["required"] = required
+		a := map[string]any{
+			"type":  "array",
+			"items": s,
+		}
+		if c.GetMinElements() > 0 {
+			a["minItems"] = c.GetMinElements()
+		}
+		if c.GetMaxElements() > 0 && c.GetMaxElements() != unboundedElements {
+			a["maxItems"] = c.GetMaxElements()
+		}
+		return a, nil
+	}
+	return nil, fmt.Errorf("unsupported schema element at %s", utils.ToXPath(p, false))
+}
+
+// containerJSONSchema returns the JSON Schema of a container or of a list entry.
+func containerJSONSchema(ctx context.Context, scb SchemaClient.SchemaClientBound, p *sdcpb.Path, c *sdcpb.ContainerSchema) (map[string]any, error) {
+	if c.GetName() == rootSchemaName {
+		var err error
+		if c, err = mergeModuleSchemas(ctx, scb, c); err != nil {
+			return nil, err
+		}
+	}
+	props := make(map[string]any, len(c.GetKeys())+len(c.GetFields())+len(c.GetLeaflists())+len(c.GetChildren()))
+	for _, k := range c.GetKeys() {
+		props[k.GetName()] = leafJSONSchema(k)
+	}
+	for _, f := range c.GetFields() {
+		if f.GetIsState() {
+			continue
+		}
+		props[f.GetName()] = leafJSONSchema(f)
+	}
+	for _, child := range c.GetChildren() {
+		cp := &sdcpb.Path{Origin: p.GetOrigin(), Elem: make([]*sdcpb.PathElem, 0, len(p.GetElem())+1)}
+		cp.Elem = append(cp.Elem, p.GetElem()...)
+		cp.Elem = append(cp.Elem, &sdcpb.PathElem{Name: child})
+		rsp, err := scb.GetSchema(ctx, cp)
+		if err != nil {
+			return nil, err
+		}
+		if rsp.GetSchema().GetContainer().GetIsState() {
+			continue
+		}
+		cs, err := elemJSONSchema(ctx, scb, cp, rsp.GetSchema(), false)
+		if err != nil {
+			return nil, err
+		}
+		props[child] = cs
+	}
+	for _, ll := range c.GetLeaflists() {
+		if ll.GetIsState() {
+			continue
+		}
+		s, err := elemJSONSchema(ctx, scb, p, &sdcpb.SchemaElem{Schema: &sdcpb.SchemaElem_Leaflist{Leaflist: ll}}, false)
+		if err != nil {
+			return nil, err
+		}
+		props[ll.GetName()] = s
+	}
+
+	s := map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"patternProperties":    map[string]any{prefixedMemberPattern: true},
+		"additionalProperties": false,
+	}
+	if c.GetDescription() != "" {
+		s["description"] = c.GetDescription()
+	}
+	mandatory := make([]any, 0, len(c.GetMandatoryChildren()))
+	for _, m := range c.GetMandatoryChildren() {
+		mandatory = append(mandatory, m.GetName())
+	}
+	if len(mandatory) > 0 {
+		// the mandatory children may be set by other intents, hence they are not required
+		s["x-yang-mandatory"] = mandatory
+	}
+	return s, nil
+}
+
+// mergeModuleSchemas returns the root container holding the top level nodes of all the modules.
+// The children of the root container returned by the schema are the modules.
+func mergeModuleSchemas(ctx context.Context, scb SchemaClient.SchemaClientBound, root *sdcpb.ContainerSchema) (*sdcpb.ContainerSchema, error) {
+	merged := &sdcpb.ContainerSchema{Name: root.GetName()}
+	for _, module := range root.GetChildren() {
+		rsp, err := scb.GetSchema(ctx, &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: module}}})
+		if err != nil {
+			return nil, err
+		}
+		mc := rsp.GetSchema().GetContainer()
+		merged.Fields = append(merged.Fields, mc.GetFields()...)
+		merged.Leaflists = append(merged.Leaflists, mc.GetLeaflists()...)
+		merged.Children = append(merged.Children, mc.GetChildren()...)
+	}
+	return merged, nil
+}
+
+func leafJSONSchema(l *sdcpb.LeafSchema) map[string]any {
+	s := utils.LeafTypeJSONSchema(l.GetType())
+	if l.GetDescription() != "" {
+		s["description"] = l.GetDescription()
+	}
+	if l.GetDefault() != "" {
+		s["default"] = l.GetDefault()
+	}
+	if l.GetIsMandatory() {
+		// mandatory leafs may be set by other intents, hence they are not required
+		s["x-yang-mandatory"] = true
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/utils"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+)
+
+func TestDatastore_IntentJSONSchema(t *testing.T) {
+	schemaClient, schema, err := testhelper.InitSDCIOSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Datastore{
+		config:       &config.DatastoreConfig{Name: "dev1", Schema: schema},
+		schemaClient: schemaClient,
+	}
+	ctx := context.Background()
+
+	t.Run("root", func(t *testing.T) {
+		s, err := d.IntentJSONSchema(ctx, &sdcpb.Path{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s["$schema"] != utils.JSONSchemaDialect {
+			t.Errorf("unexpected dialect %v", s["$schema"])
+		}
+		props := s["properties"].(map[string]any)
+		// the top level nodes of the modules, not the modules
+		for _, name := range []string{"interface", "network-instance", "choices", "leaflist"} {
+			if _, ok := props[name]; !ok {
+				t.Errorf("missing top level node %s", name)
+			}
+		}
+		if _, ok := props["sdcio_model"]; ok {
+			t.Error("unexpected module sdcio_model")
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		s, err := d.IntentJSONSchema(ctx, &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "interface"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s["type"] != "array" {
+			t.Fatalf("expected an array, got %v", s["type"])
+		}
+		entry := s["items"].(map[string]any)
+		if !reflect.DeepEqual(entry["required"], []any{"name"}) {
+			t.Errorf("expected the key to be required, got %v", entry["required"])
+		}
+		props := entry["properties"].(map[string]any)
+		if _, ok := props["subinterface"].(map[string]any)["items"]; !ok {
+			t.Errorf("expected the nested list to be an array, got %v", props["subinterface"])
+		}
+		want := map[string]any{"type": "string", "enum": []any{"disable", "enable"}, "default": "enable"}
+		got := props["admin-state"].(map[string]any)
+		delete(got, "description")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("admin-state: expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("list entry", func(t *testing.T) {
+		s, err := d.IntentJSONSchema(ctx, &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s["type"] != "object" {
+			t.Fatalf("expected an object, got %v", s["type"])
+		}
+		// the keys are part of the path
+		if _, ok := s["required"]; ok {
+			t.Errorf("unexpected required members %v", s["required"])
+		}
+	})
+
+	t.Run("leaf", func(t *testing.T) {
+		s, err := d.IntentJSONSchema(ctx, &sdcpb.Path{Elem: []*sdcpb.PathElem{
+			{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}},
+			{Name: "mtu"},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s["maximum"] != float64(65535) {
+			t.Errorf("expected the bound of uint16, got %v", s["maximum"])
+		}
+	})
+}
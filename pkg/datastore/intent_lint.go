@@ -0,0 +1,231 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sdcio/data-server/pkg/schema"
+	"github.com/sdcio/data-server/pkg/utils"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// the codes of the lint warnings
+const (
+	// LintEmptyUpdate flags updates that do not set any value
+	LintEmptyUpdate = "empty-update"
+	// LintDefaultValue flags values that are equal to the YANG default of the leaf
+	LintDefaultValue = "default-value"
+	// LintOutsideRolePaths flags values outside of the subtrees configured for the role of the client
+	LintOutsideRolePaths = "outside-role-paths"
+	// LintDeprecatedNode flags values of schema nodes with the YANG status deprecated
+	LintDeprecatedNode = "deprecated-node"
+	// LintObsoleteNode flags values of schema nodes with the YANG status obsolete
+	LintObsoleteNode = "obsolete-node"
+)
+
+// the handling of the nodes with the YANG status deprecated or obsolete
+const (
+	statusPolicyIgnore = "ignore"
+	statusPolicyWarn   = "warn"
+	statusPolicyReject = "reject"
+)
+
+// LintWarning flags a suspicious, but valid part of an intent.
+type LintWarning struct {
+	// Code identifies the check that raised the warning
+	Code string `json:"code"`
+	// Path is the xpath of the flagged value
+	Path string `json:"path"`
+	// Message describes the finding
+	Message string `json:"message"`
+}
+
+func (w *LintWarning) String() string {
+	return fmt.Sprintf("lint %s: %s: %s", w.Code, w.Path, w.Message)
+}
+
+// lintIntent runs the lint checks on the updates of the intent. The role is the one of the client
+// that sets the intent, the role paths are not checked if it is empty.
+// The values of deprecated and obsolete schema nodes are checked against the status-policy even if the
+// lint checks are disabled, an error is returned if the policy rejects them.
+func (d *Datastore) lintIntent(ctx context.Context, req *sdcpb.SetIntentRequest, role string) ([]*LintWarning, error) {
+	lint := d.config.Lint.IsEnabled()
+	statusClient, checkStatus := d.schemaClient.(schema.StatusClient)
+	if req.GetDelete() || (!lint && !checkStatus) {
+		return nil, nil
+	}
+	upds, err := d.expandSnippets(ctx, req.GetUpdate())
+	if err != nil {
+		return nil, err
+	}
+	upds, err = d.renderTemplates(upds)
+	if err != nil {
+		return nil, err
+	}
+	converter := utils.NewConverter(d.getValidationClient())
+
+	warnings := []*LintWarning{}
+	var rejected []error
+	// the status of the schema nodes, by their path without keys
+	statuses := map[string]string{}
+	for _, u := range upds {
+		xpath := utils.ToXPath(u.GetPath(), false)
+		if u.GetValue() == nil {
+			if lint {
+				warnings = append(warnings, &LintWarning{Code: LintEmptyUpdate, Path: xpath, Message: "update without value"})
+			}
+			continue
+		}
+		expanded, err := converter.ExpandUpdate(ctx, u, !d.config.OmitKeyLeaves)
+		if err != nil {
+			return nil, err
+		}
+		if len(expanded) == 0 {
+			if lint {
+				warnings = append(warnings, &LintWarning{Code: LintEmptyUpdate, Path: xpath, Message: "update does not set any value"})
+			}
+			continue
+		}
+		for _, eu := range expanded {
+			if checkStatus {
+				w, err := d.lintNodeStatus(ctx, statusClient, statuses, eu)
+				if err != nil {
+					return nil, err
+				}
+				if w != nil {
+					switch d.statusHandling(w.Code) {
+					case statusPolicyReject:
+						rejected = append(rejected, errors.New(w.String()))
+					case statusPolicyWarn:
+						warnings = append(warnings, w)
+					}
+				}
+			}
+			if !lint {
+				continue
+			}
+			ws, err := d.lintUpdate(ctx, eu, role)
+			if err != nil {
+				return nil, err
+			}
+			warnings = append(warnings, ws...)
+		}
+	}
+	if len(rejected) > 0 {
+		return nil, fmt.Errorf("intent %q configures nodes rejected by the status-policy:\n%v", req.GetIntent(), errors.Join(rejected...))
+	}
+	return warnings, nil
+}
+
+// lintNodeStatus returns a warning if the schema node of the value is deprecated or obsolete.
+func (d *Datastore) lintNodeStatus(ctx context.Context, sc schema.StatusClient, statuses map[string]string, u *sdcpb.Update) (*LintWarning, error) {
+	key := utils.ToXPath(u.GetPath(), true)
+	status, ok := statuses[key]
+	if !ok {
+		var err error
+		status, err = sc.GetStatus(ctx, d.Schema().GetSchema(), u.GetPath())
+		if err != nil {
+			return nil, err
+		}
+		statuses[key] = status
+	}
+	xpath := utils.ToXPath(u.GetPath(), false)
+	switch status {
+	case schema.StatusDeprecated:
+		return &LintWarning{Code: LintDeprecatedNode, Path: xpath, Message: "the schema node is deprecated"}, nil
+	case schema.StatusObsolete:
+		return &LintWarning{Code: LintObsoleteNode, Path: xpath, Message: "the schema node is obsolete"}, nil
+	}
+	return nil, nil
+}
+
+// statusHandling returns the status-policy handling of the deprecated-node and obsolete-node
+// warnings, warn if the datastore does not configure a status-policy.
+func (d *Datastore) statusHandling(code string) string {
+	p := d.config.StatusPolicy
+	if p == nil {
+		return statusPolicyWarn
+	}
+	switch {
+	case code == LintDeprecatedNode && p.Deprecated != "":
+		return p.Deprecated
+	case code == LintObsoleteNode && p.Obsolete != "":
+		return p.Obsolete
+	}
+	return statusPolicyWarn
+}
+
+// lintUpdate runs the lint checks on a single leaf or leaf-list value.
+func (d *Datastore) lintUpdate(ctx context.Context, u *sdcpb.Update, role string) ([]*LintWarning, error) {
+	var warnings []*LintWarning
+	xpath := utils.ToXPath(u.GetPath(), false)
+
+	if role != "" {
+		p, err := utils.CompletePath(nil, u.GetPath())
+		if err != nil {
+			return nil, err
+		}
+		if !d.config.Lint.AllowsPath(role, p) {
+			warnings = append(warnings, &LintWarning{Code: LintOutsideRolePaths, Path: xpath, Message: fmt.Sprintf("outside of the paths of role %s", role)})
+		}
+	}
+
+	rsp, err := d.getSchema(ctx, u.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	def, err := schemaDefault(rsp.GetSchema())
+	if err != nil || def == nil {
+		// the schema server reports unconvertible defaults, they are not the concern of the intent
+		return warnings, nil
+	}
+	val, err := utils.ConvertTypedValueToYANGType(rsp.GetSchema(), u.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	if proto.Equal(val, def) {
+		warnings = append(warnings, &LintWarning{Code: LintDefaultValue, Path: xpath, Message: fmt.Sprintf("value %s equals the default", utils.TypedValueToString(val))})
+	}
+	return warnings, nil
+}
+
+// schemaDefault returns the default value of the leaf or leaf-list, nil if it has none.
+func schemaDefault(schema *sdcpb.SchemaElem) (*sdcpb.TypedValue, error) {
+	switch s := schema.GetSchema().(type) {
+	case *sdcpb.SchemaElem_Field:
+		if s.Field.GetDefault() == "" {
+			return nil, nil
+		}
+		return utils.Convert(s.Field.GetDefault(), s.Field.GetType())
+	case *sdcpb.SchemaElem_Leaflist:
+		if len(s.Leaflist.GetDefaults()) == 0 {
+			return nil, nil
+		}
+		elems := make([]*sdcpb.TypedValue, 0, len(s.Leaflist.GetDefaults()))
+		for _, dv := range s.Leaflist.GetDefaults() {
+			tv, err := utils.Convert(dv, s.Leaflist.GetType())
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, tv)
+		}
+		return &sdcpb.TypedValue{Value: &sdcpb.TypedValue_LeaflistVal{LeaflistVal: &sdcpb.ScalarArray{Element: elems}}}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/utils"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+)
+
+func TestDatastore_lintIntent(t *testing.T) {
+	schemaClient, schema, err := testhelper.InitSDCIOSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.DatastoreConfig{
+		Name:   "dev1",
+		Schema: schema,
+		SBI:    &config.SBI{Type: "noop"},
+		Lint: &config.Lint{
+			RolePaths: map[string][]string{"services": {"/network-instance"}},
+		},
+	}
+	if err = cfg.ValidateSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	d := &Datastore{config: cfg, schemaClient: schemaClient}
+	ctx := context.Background()
+
+	ifPath, err := utils.ParsePath("/interface[name=ethernet-1/1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &sdcpb.SetIntentRequest{
+		Name:     "dev1",
+		Intent:   "intent1",
+		Priority: 10,
+		Update: []*sdcpb.Update{
+			{
+				Path:  ifPath,
+				Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(`{"admin-state":"enable","description":"uplink"}`)}},
+			},
+			{
+				Path: ifPath,
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		role string
+		want map[string]string
+	}{
+		{
+			name: "without role",
+			want: map[string]string{
+				LintDefaultValue: "interface[name=ethernet-1/1]/admin-state",
+				LintEmptyUpdate:  "interface[name=ethernet-1/1]",
+			},
+		},
+		{
+			name: "role outside of its paths",
+			role: "services",
+			want: map[string]string{
+				LintDefaultValue:     "interface[name=ethernet-1/1]/admin-state",
+				LintEmptyUpdate:      "interface[name=ethernet-1/1]",
+				LintOutsideRolePaths: "interface[name=ethernet-1/1]/description",
+			},
+		},
+		{
+			name: "role without paths",
+			role: "system",
+			want: map[string]string{
+				LintDefaultValue: "interface[name=ethernet-1/1]/admin-state",
+				LintEmptyUpdate:  "interface[name=ethernet-1/1]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := d.lintIntent(ctx, req, tt.role)
+			if err != nil {
+				t.Fatal(err)
+			}
+			codes := map[string]bool{}
+			for _, w := range warnings {
+				codes[w.Code] = true
+				if want, ok := tt.want[w.Code]; !ok {
+					t.Errorf("unexpected warning %s", w)
+				} else if w.Code != LintOutsideRolePaths && w.Path != want {
+					t.Errorf("warning %s: expected path %s", w, want)
+				}
+			}
+			for code, p := range tt.want {
+				if !codes[code] {
+					t.Errorf("missing %s warning for %s", code, p)
+				}
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		d := &Datastore{config: &config.DatastoreConfig{Name: "dev1", Schema: schema, Lint: &config.Lint{Disabled: true}}, schemaClient: schemaClient}
+		warnings, err := d.lintIntent(ctx, req, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(warnings) != 0 {
+			t.Errorf("expected no warnings, got %v", warnings)
+		}
+	})
+}
+
+func TestDatastore_lintIntent_StatusPolicy(t *testing.T) {
+	schemaClient, schema, err := testhelper.InitSDCIOSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ifPath, err := utils.ParsePath("/interface[name=ethernet-1/1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &sdcpb.SetIntentRequest{
+		Name:     "dev1",
+		Intent:   "intent1",
+		Priority: 10,
+		Update: []*sdcpb.Update{
+			{
+				Path:  ifPath,
+				Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(`{"speed":"100G","legacy":{"encapsulation":"dot1q"}}`)}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		policy     *config.StatusPolicy
+		wantCodes  []string
+		wantReject bool
+	}{
+		{
+			name:      "default",
+			wantCodes: []string{LintDeprecatedNode, LintObsoleteNode},
+		},
+		{
+			name:      "ignore deprecated",
+			policy:    &config.StatusPolicy{Deprecated: "ignore"},
+			wantCodes: []string{LintObsoleteNode},
+		},
+		{
+			name:       "reject obsolete",
+			policy:     &config.StatusPolicy{Obsolete: "reject"},
+			wantReject: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.DatastoreConfig{
+				Name:         "dev1",
+				Schema:       schema,
+				SBI:          &config.SBI{Type: "noop"},
+				Lint:         &config.Lint{Disabled: true},
+				StatusPolicy: tt.policy,
+			}
+			if err = cfg.ValidateSetDefaults(); err != nil {
+				t.Fatal(err)
+			}
+			d := &Datastore{config: cfg, schemaClient: schemaClient}
+			warnings, err := d.lintIntent(context.Background(), req, "")
+			if tt.wantReject {
+				if err == nil {
+					t.Fatal("expected the intent to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			codes := []string{}
+			for _, w := range warnings {
+				codes = append(codes, w.Code)
+			}
+			slices.Sort(codes)
+			if !reflect.DeepEqual(codes, tt.wantCodes) {
+				t.Errorf("expected warnings %v, got %v", tt.wantCodes, warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrIntentAborted is the cause of the cancellation of an intent that was aborted by an administrator.
+var ErrIntentAborted = errors.New("intent aborted by administrator")
+
+var intentLockAcquiredTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "data_server",
+	Name:      "intent_lock_acquired_timestamp_seconds",
+	Help:      "Time the SetIntent lock of the datastore was acquired by its current holder, 0 if it is free",
+}, []string{"datastore"})
+
+// IntentLockHolder describes the holder of the SetIntent lock of a datastore.
+type IntentLockHolder struct {
+	// Intent is the name of the intent, empty for the out-of-band reconciliation
+	Intent string
+	// Since is the time the lock was acquired
+	Since time.Time
+}
+
+// intentLock guarantees that only one SetIntent is applied at a time.
+// It keeps track of its holder, such that a stuck holder can be aborted.
+type intentLock struct {
+	m      sync.Mutex
+	held   bool
+	gen    uint64
+	holder IntentLockHolder
+	cancel context.CancelCauseFunc
+}
+
+func newIntentLock() *intentLock {
+	return &intentLock{}
+}
+
+// tryAcquire acquires the lock for the given intent if it is free. The returned context is cancelled
+// when the holder is aborted, the returned function releases the lock.
+func (l *intentLock) tryAcquire(ctx context.Context, intent string) (context.Context, func(), bool) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.held {
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	l.held = true
+	l.gen++
+	l.holder = IntentLockHolder{Intent: intent, Since: time.Now()}
+	l.cancel = cancel
+
+	gen := l.gen
+	return ctx, func() {
+		cancel(nil)
+		l.release(gen)
+	}, true
+}
+
+// release releases the lock, if it is still held by the holder of the given generation,
+// it might have been force-released in the meantime.
+func (l *intentLock) release(gen uint64) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if !l.held || l.gen != gen {
+		return
+	}
+	l.held = false
+	l.holder = IntentLockHolder{}
+	l.cancel = nil
+}
+
+// getHolder returns the holder of the lock, nil if the lock is free.
+func (l *intentLock) getHolder() *IntentLockHolder {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if !l.held {
+		return nil
+	}
+	h := l.holder
+	return &h
+}
+
+// abort cancels the context of the holder. The lock is released once the holder returns,
+// or immediately if force is set, even though the holder might still be running.
+func (l *intentLock) abort(force bool) error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if !l.held {
+		return errors.New("the intent lock is not held")
+	}
+	l.cancel(ErrIntentAborted)
+	if force {
+		l.held = false
+		l.holder = IntentLockHolder{}
+		l.cancel = nil
+	}
+	return nil
+}
+
+// tryAcquireIntentLock acquires the SetIntent lock of the datastore for the given intent, if it is free.
+// See intentLock.tryAcquire.
+func (d *Datastore) tryAcquireIntentLock(ctx context.Context, intent string) (context.Context, func(), bool) {
+	ctx, release, ok := d.intentLock.tryAcquire(ctx, intent)
+	if !ok {
+		return nil, nil, false
+	}
+	acquired := intentLockAcquiredTimestamp.WithLabelValues(d.Name())
+	acquired.SetToCurrentTime()
+	return ctx, func() {
+		release()
+		if d.intentLock.getHolder() == nil {
+			acquired.Set(0)
+		}
+	}, true
+}
+
+// IntentLockHolder returns the holder of the SetIntent lock, nil if no SetIntent is ongoing.
+func (d *Datastore) IntentLockHolder() *IntentLockHolder {
+	return d.intentLock.getHolder()
+}
+
+// AbortIntent aborts the ongoing SetIntent by cancelling its context.
+// If force is set, the lock is released immediately rather than once the SetIntent returned,
+// which allows new SetIntents while the aborted one might still be running.
+func (d *Datastore) AbortIntent(force bool) error {
+	h := d.intentLock.getHolder()
+	if err := d.intentLock.abort(force); err != nil {
+		return fmt.Errorf("datastore %s: %w", d.Name(), err)
+	}
+	if force {
+		intentLockAcquiredTimestamp.WithLabelValues(d.Name()).Set(0)
+	}
+	if h != nil {
+		log.Warnf("datastore %s: aborted intent %q holding the intent lock since %s, force=%t", d.Name(), h.Intent, h.Since, force)
+	}
+	return nil
+}
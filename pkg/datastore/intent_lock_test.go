@@ -0,0 +1,75 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_AbortIntent(t *testing.T) {
+	d := &Datastore{
+		config:     &config.DatastoreConfig{Name: "dev1"},
+		intentLock: newIntentLock(),
+	}
+
+	if err := d.AbortIntent(false); err == nil {
+		t.Errorf("expected an error aborting without holder")
+	}
+
+	ctx, release, ok := d.tryAcquireIntentLock(context.TODO(), "intent1")
+	if !ok {
+		t.Fatal("failed to acquire the free intent lock")
+	}
+	if h := d.IntentLockHolder(); h == nil || h.Intent != "intent1" {
+		t.Fatalf("expected intent1 to hold the lock, got %v", h)
+	}
+	if _, _, ok := d.tryAcquireIntentLock(context.TODO(), "intent2"); ok {
+		t.Fatal("acquired the held intent lock")
+	}
+
+	// abort without force keeps the lock until the holder returns
+	if err := d.AbortIntent(false); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(context.Cause(ctx), ErrIntentAborted) {
+		t.Errorf("expected the holder context to be aborted, got %v", context.Cause(ctx))
+	}
+	if d.IntentLockHolder() == nil {
+		t.Errorf("expected the lock to be held until released")
+	}
+	release()
+	if h := d.IntentLockHolder(); h != nil {
+		t.Errorf("expected the lock to be free, held by %v", h)
+	}
+
+	// force releases the lock, the late release of the aborted holder must not release the new holder
+	_, release, _ = d.tryAcquireIntentLock(context.TODO(), "intent1")
+	if err := d.AbortIntent(true); err != nil {
+		t.Fatal(err)
+	}
+	_, release2, ok := d.tryAcquireIntentLock(context.TODO(), "intent2")
+	if !ok {
+		t.Fatal("failed to acquire the force-released intent lock")
+	}
+	release()
+	if h := d.IntentLockHolder(); h == nil || h.Intent != "intent2" {
+		t.Errorf("expected intent2 to hold the lock, got %v", h)
+	}
+	release2()
+}
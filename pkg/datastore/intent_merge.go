@@ -0,0 +1,161 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// MergeIntentRequest carries changes to be merged into an intent, e.g. the deletes, replaces and updates of a
+// gNMI SetRequest, as opposed to the SetIntentRequest that declares the full content of the intent.
+type MergeIntentRequest struct {
+	Intent   string
+	Priority int32
+	// Deletes remove the values of the intent below the paths
+	Deletes []*sdcpb.Path
+	// Replaces replace the values of the intent below the paths of the updates
+	Replaces []*sdcpb.Update
+	// Updates add the values to the intent, overwriting the existing values of the same paths
+	Updates []*sdcpb.Update
+}
+
+// MergeIntent merges the changes into the stored intent and applies the resulting intent.
+// The intent is created if it does not exist and deleted once it no longer carries any value.
+func (d *Datastore) MergeIntent(ctx context.Context, req *MergeIntentRequest) (*SetIntentResult, error) {
+	// the stored intent is read and written back, hence merges are not interleaved
+	d.mergeMutex.Lock()
+	defer d.mergeMutex.Unlock()
+
+	var stored []*sdcpb.Update
+	r, err := d.getRawIntent(ctx, req.Intent, req.Priority)
+	switch {
+	case errors.Is(err, ErrIntentNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		stored = r.GetUpdate()
+	}
+
+	converter := utils.NewConverter(d.getValidationClient())
+	expand := func(upds []*sdcpb.Update) ([]*sdcpb.Update, error) {
+		// the merged intent carries the expanded snippets
+		upds, err := d.expandSnippets(ctx, upds)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		result := make([]*sdcpb.Update, 0, len(upds))
+		for _, upd := range upds {
+			rs, err := converter.ExpandUpdate(ctx, upd, false)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "failed expand update: %v", err)
+			}
+			result = append(result, rs...)
+		}
+		return result, nil
+	}
+	if stored, err = expand(stored); err != nil {
+		return nil, err
+	}
+	replaces, err := expand(req.Replaces)
+	if err != nil {
+		return nil, err
+	}
+	updates, err := expand(req.Updates)
+	if err != nil {
+		return nil, err
+	}
+
+	deletes := make([]*sdcpb.Path, 0, len(req.Deletes)+len(req.Replaces))
+	deletes = append(deletes, req.Deletes...)
+	for _, upd := range req.Replaces {
+		deletes = append(deletes, upd.GetPath())
+	}
+	merged, err := mergeIntentUpdates(stored, deletes, append(replaces, updates...))
+	if err != nil {
+		return nil, err
+	}
+
+	sreq := &sdcpb.SetIntentRequest{
+		Name:     d.Name(),
+		Intent:   req.Intent,
+		Priority: req.Priority,
+		Update:   merged,
+	}
+	if len(merged) == 0 {
+		if r == nil {
+			// nothing to delete
+			return &SetIntentResult{Response: &sdcpb.SetIntentResponse{}}, nil
+		}
+		sreq.Update = nil
+		sreq.Delete = true
+	}
+	return d.SetIntentWithOpts(ctx, sreq, nil)
+}
+
+// mergeIntentUpdates removes the stored leaf updates below the deletes and adds the updates, overwriting the
+// stored updates of the same paths. The result is sorted by path.
+func mergeIntentUpdates(stored []*sdcpb.Update, deletes []*sdcpb.Path, updates []*sdcpb.Update) ([]*sdcpb.Update, error) {
+	delPaths := make([][]string, 0, len(deletes))
+	for _, p := range deletes {
+		ps, err := utils.CompletePath(nil, p)
+		if err != nil {
+			return nil, err
+		}
+		delPaths = append(delPaths, ps)
+	}
+
+	merged := make(map[string]*sdcpb.Update, len(stored)+len(updates))
+STORED:
+	for _, upd := range stored {
+		ps, err := utils.CompletePath(nil, upd.GetPath())
+		if err != nil {
+			return nil, err
+		}
+		for _, dp := range delPaths {
+			if len(ps) >= len(dp) && slices.Equal(ps[:len(dp)], dp) {
+				continue STORED
+			}
+		}
+		merged[tree.PathKey(ps)] = upd
+	}
+	for _, upd := range updates {
+		ps, err := utils.CompletePath(nil, upd.GetPath())
+		if err != nil {
+			return nil, err
+		}
+		merged[tree.PathKey(ps)] = upd
+	}
+
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	result := make([]*sdcpb.Update, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, merged[k])
+	}
+	return result, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+func Test_mergeIntentUpdates(t *testing.T) {
+	upd := func(t *testing.T, p string, v string) *sdcpb.Update {
+		path, err := utils.ParsePath(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &sdcpb.Update{Path: path, Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: v}}}
+	}
+	path := func(t *testing.T, p string) *sdcpb.Path {
+		path, err := utils.ParsePath(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	stored := []*sdcpb.Update{
+		upd(t, "/interface[name=ethernet-1/1]/description", "d1"),
+		upd(t, "/interface[name=ethernet-1/1]/admin-state", "enable"),
+		upd(t, "/interface[name=ethernet-1/2]/description", "d2"),
+		upd(t, "/system/name", "dev1"),
+	}
+
+	tests := []struct {
+		name    string
+		deletes []*sdcpb.Path
+		updates []*sdcpb.Update
+		want    []*sdcpb.Update
+	}{
+		{
+			name:    "update overwrites the stored value",
+			updates: []*sdcpb.Update{upd(t, "/interface[name=ethernet-1/2]/description", "new")},
+			want: []*sdcpb.Update{
+				upd(t, "/interface[name=ethernet-1/1]/admin-state", "enable"),
+				upd(t, "/interface[name=ethernet-1/1]/description", "d1"),
+				upd(t, "/interface[name=ethernet-1/2]/description", "new"),
+				upd(t, "/system/name", "dev1"),
+			},
+		},
+		{
+			name:    "delete removes the stored values below the path",
+			deletes: []*sdcpb.Path{path(t, "/interface[name=ethernet-1/1]")},
+			want: []*sdcpb.Update{
+				upd(t, "/interface[name=ethernet-1/2]/description", "d2"),
+				upd(t, "/system/name", "dev1"),
+			},
+		},
+		{
+			name:    "replace",
+			deletes: []*sdcpb.Path{path(t, "/interface")},
+			updates: []*sdcpb.Update{upd(t, "/interface[name=ethernet-1/3]/description", "d3")},
+			want: []*sdcpb.Update{
+				upd(t, "/interface[name=ethernet-1/3]/description", "d3"),
+				upd(t, "/system/name", "dev1"),
+			},
+		},
+		{
+			name:    "delete all",
+			deletes: []*sdcpb.Path{path(t, "/interface"), path(t, "/system")},
+			want:    []*sdcpb.Update{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeIntentUpdates(stored, tt.deletes, tt.updates)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("mergeIntentUpdates() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
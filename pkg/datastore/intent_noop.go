@@ -0,0 +1,141 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// isNoOpSetIntent returns true if the request re-applies the stored raw intent unchanged and the values
+// of the intent are present on the device, such that processing the request would not change anything.
+// Intents with template values or snippet references are always processed, since the variables or
+// the snippets might have changed.
+func (d *Datastore) isNoOpSetIntent(ctx context.Context, req *sdcpb.SetIntentRequest) (bool, error) {
+	if req.GetDelete() || hasTemplates(req.GetUpdate()) || hasSnippetRefs(req.GetUpdate()) {
+		return false, nil
+	}
+	stored, err := d.getRawIntent(ctx, req.GetIntent(), req.GetPriority())
+	if err != nil {
+		if errors.Is(err, ErrIntentNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	reqBytes, err := normalizedIntentBytes(req)
+	if err != nil {
+		return false, err
+	}
+	storedBytes, err := normalizedIntentBytes(stored)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(reqBytes, storedBytes) {
+		return false, nil
+	}
+	drifted, err := d.intentDrifted(ctx, req.GetIntent())
+	if err != nil {
+		return false, err
+	}
+	return !drifted, nil
+}
+
+// normalizedIntentBytes returns the deterministic encoding of the intent content of the request.
+// Attributes that do not influence the resulting configuration are cleared and the updates are sorted by path.
+func normalizedIntentBytes(req *sdcpb.SetIntentRequest) ([]byte, error) {
+	n := proto.Clone(req).(*sdcpb.SetIntentRequest)
+	n.Name = ""
+	n.DryRun = false
+	slices.SortStableFunc(n.Update, func(a, b *sdcpb.Update) int {
+		return strings.Compare(utils.ToXPath(a.GetPath(), false), utils.ToXPath(b.GetPath(), false))
+	})
+	return proto.MarshalOptions{Deterministic: true}.Marshal(n)
+}
+
+// intentDrifted returns true if any of the values the intent contributes to the device, i.e. the values
+// it owns with the highest precedence, is missing or different in the CONFIG store.
+func (d *Datastore) intentDrifted(ctx context.Context, intentName string) (bool, error) {
+	storeIndex, err := d.readStoreKeysMeta(ctx, cachepb.Store_INTENDED)
+	if err != nil {
+		return false, err
+	}
+	paths := make([][]string, 0)
+	storeIndex.Walk(func(path tree.PathSlice, upds tree.UpdateSlice) bool {
+		if ownsHighestPrecedence(upds, intentName) {
+			paths = append(paths, path)
+		}
+		return true
+	})
+	if len(paths) == 0 {
+		return false, nil
+	}
+
+	intended := tree.UpdateSlice{}
+	for _, u := range d.cacheClient.Read(ctx, d.Name(), &cache.Opts{Store: cachepb.Store_INTENDED}, paths, 0) {
+		if u.Owner() == intentName {
+			intended = append(intended, u)
+		}
+	}
+	running := tree.UpdateSlice(d.cacheClient.Read(ctx, d.Name(), &cache.Opts{Store: cachepb.Store_CONFIG}, paths, 0))
+	if len(intended.SubtractByPath(running)) > 0 {
+		return true, nil
+	}
+
+	runningIndex := running.IndexByPath()
+	for key, iu := range intended.IndexByPath() {
+		ru := runningIndex[key]
+		iv, err := iu.Value()
+		if err != nil {
+			return false, err
+		}
+		rv, err := ru.Value()
+		if err != nil {
+			return false, err
+		}
+		if !utils.EqualTypedValues(iv, rv) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ownsHighestPrecedence returns true if the intent holds one of the entries with the highest precedence
+// in the key meta data of a path. Same priority ties are included, erring on the side of processing the intent.
+func ownsHighestPrecedence(upds tree.UpdateSlice, intentName string) bool {
+	highest := int32(math.MaxInt32)
+	for _, u := range upds {
+		if u.Owner() != tree.RunningIntentName && u.Priority() < highest {
+			highest = u.Priority()
+		}
+	}
+	for _, u := range upds {
+		if u.Owner() == intentName && u.Priority() == highest {
+			return true
+		}
+	}
+	return false
+}
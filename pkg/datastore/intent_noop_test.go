@@ -0,0 +1,196 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+)
+
+func TestDatastore_isNoOpSetIntent(t *testing.T) {
+	intentName := "intent1"
+	descPath := []string{"interface", "ethernet-1/1", "description"}
+	req := &sdcpb.SetIntentRequest{
+		Name:     "dev1",
+		Intent:   intentName,
+		Priority: 10,
+		Update: []*sdcpb.Update{
+			{
+				Path:  &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}, {Name: "description"}}},
+				Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "foo"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		stored   *sdcpb.SetIntentRequest
+		req      func() *sdcpb.SetIntentRequest
+		intended []*cache.Update
+		running  []*cache.Update
+		want     bool
+	}{
+		{
+			name:     "unchanged and in sync",
+			stored:   req,
+			req:      func() *sdcpb.SetIntentRequest { return req },
+			intended: []*cache.Update{cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "foo"), 10, intentName, 0)},
+			running:  []*cache.Update{cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "foo"), 0, "", 0)},
+			want:     true,
+		},
+		{
+			name:   "unchanged dry-run",
+			stored: req,
+			req: func() *sdcpb.SetIntentRequest {
+				r := proto.Clone(req).(*sdcpb.SetIntentRequest)
+				r.DryRun = true
+				return r
+			},
+			intended: []*cache.Update{cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "foo"), 10, intentName, 0)},
+			running:  []*cache.Update{cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "foo"), 0, "", 0)},
+			want:     true,
+		},
+		{
+			name:   "changed value",
+			stored: req,
+			req: func() *sdcpb.SetIntentRequest {
+				r := proto.Clone(req).(*sdcpb.SetIntentRequest)
+				r.Update[0].Value = &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "bar"}}
+				return r
+			},
+			intended: []*cache.Update{cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "foo"), 10, intentName, 0)},
+			running:  []*cache.Update{cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "foo"), 0, "", 0)},
+			want:     false,
+		},
+		{
+			name:     "drift on the device",
+			stored:   req,
+			req:      func() *sdcpb.SetIntentRequest { return req },
+			intended: []*cache.Update{cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "foo"), 10, intentName, 0)},
+			running:  []*cache.Update{cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "changed"), 0, "", 0)},
+			want:     false,
+		},
+		{
+			name:     "missing on the device",
+			stored:   req,
+			req:      func() *sdcpb.SetIntentRequest { return req },
+			intended: []*cache.Update{cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "foo"), 10, intentName, 0)},
+			want:     false,
+		},
+		{
+			name:     "shadowed value is not drift",
+			stored:   req,
+			req:      func() *sdcpb.SetIntentRequest { return req },
+			intended: []*cache.Update{cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "foo"), 10, intentName, 0), cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "other"), 5, "intent2", 0)},
+			running:  []*cache.Update{cache.NewUpdate(descPath, testhelper.GetStringTvProto(t, "other"), 0, "", 0)},
+			want:     true,
+		},
+		{
+			name:   "not stored yet",
+			req:    func() *sdcpb.SetIntentRequest { return req },
+			stored: nil,
+			want:   false,
+		},
+		{
+			name:   "delete",
+			stored: req,
+			req: func() *sdcpb.SetIntentRequest {
+				return &sdcpb.SetIntentRequest{Name: "dev1", Intent: intentName, Priority: 10, Delete: true}
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := gomock.NewController(t)
+			cacheClient := mockcacheclient.NewMockClient(controller)
+
+			intents := map[string]*cache.Update{}
+			if tt.stored != nil {
+				b, err := proto.Marshal(tt.stored)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tv, err := proto.Marshal(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_BytesVal{BytesVal: b}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				rin, err := rawIntentKey(tt.stored.GetIntent(), tt.stored.GetPriority())
+				if err != nil {
+					t.Fatal(err)
+				}
+				intents[rin] = cache.NewUpdate([]string{rin}, tv, 0, "", 0)
+			}
+			stores := map[cachepb.Store][]*cache.Update{
+				cachepb.Store_INTENDED: tt.intended,
+				cachepb.Store_CONFIG:   tt.running,
+			}
+
+			cacheClient.EXPECT().GetKeys(gomock.Any(), gomock.Any(), cachepb.Store_INTENDED).AnyTimes().DoAndReturn(
+				func(ctx context.Context, name string, store cachepb.Store) (chan *cache.Update, error) {
+					ch := make(chan *cache.Update, len(tt.intended))
+					for _, u := range tt.intended {
+						ch <- u
+					}
+					close(ch)
+					return ch, nil
+				},
+			)
+			cacheClient.EXPECT().Read(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+				func(ctx context.Context, name string, opts *cache.Opts, paths [][]string, period time.Duration) []*cache.Update {
+					result := []*cache.Update{}
+					for _, p := range paths {
+						if opts.Store == cachepb.Store_INTENTS {
+							if u, ok := intents[strings.Join(p, "/")]; ok {
+								result = append(result, u)
+							}
+							continue
+						}
+						for _, u := range stores[opts.Store] {
+							if strings.Join(u.GetPath(), "/") == strings.Join(p, "/") {
+								result = append(result, u)
+							}
+						}
+					}
+					return result
+				},
+			)
+
+			d := &Datastore{
+				config:      &config.DatastoreConfig{Name: "dev1"},
+				cacheClient: cacheClient,
+			}
+			got, err := d.isNoOpSetIntent(context.TODO(), tt.req())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("isNoOpSetIntent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/tree"
+)
+
+// pendingPrefix is the prefix of the changes in the intents store that are not yet pushed to the device.
+var pendingPrefix = "__pending__"
+
+// pendingIntentName is the owner of the pushes of the pending changes, e.g. in the journal.
+const pendingIntentName = "__pending__"
+
+// pushModeManual only updates the intended store on SetIntent, the changes are pushed by PushChanges.
+const pushModeManual = "manual"
+
+// pendingPushRetry is the interval failed pushes of the pending changes are retried in, while the apply window is open.
+const pendingPushRetry = 30 * time.Second
+
+const (
+	PendingChangesDeferred = "deferred"
+	PendingChangesPushed   = "pushed"
+	PendingChangesFailed   = "failed"
+)
+
+// PendingChangesEvent reports the changes of intents that are deferred until the next apply window,
+// and the outcome of pushing them to the device.
+type PendingChangesEvent struct {
+	// Operation is one of PendingChangesDeferred, PendingChangesPushed, PendingChangesFailed
+	Operation string
+	// Intents are the intents the changes belong to
+	Intents []string
+	// NextWindow is the time the next apply window opens, set for deferred changes
+	NextWindow time.Time
+	// Err is the error of a failed push
+	Err       error
+	Timestamp time.Time
+}
+
+// pendingChange is the record of the changes of an intent that were deferred until the next apply window.
+type pendingChange struct {
+	Timestamp int64 `json:"timestamp"`
+	// Intents are the intents the changes belong to, several for batched SetIntents
+	Intents []string `json:"intents"`
+	// Updates are the paths of the values to be pushed to the device
+	Updates [][]string `json:"updates,omitempty"`
+	// Deletes are the paths to be deleted from the device
+	Deletes [][]string `json:"deletes,omitempty"`
+}
+
+func pendingKey(ts int64, intents []string) string {
+	return fmt.Sprintf("%s%d_%s", pendingPrefix, ts, tree.PathKey(intents))
+}
+
+// WatchPendingChanges returns a channel that receives the events of the deferred changes until the context is done.
+// Events are dropped if the receiver does not keep up.
+func (d *Datastore) WatchPendingChanges(ctx context.Context) <-chan *PendingChangesEvent {
+	return d.pendingEvents.subscribe(ctx)
+}
+
+// Dirty returns true if the datastore has changes that are not yet pushed to the device.
+func (d *Datastore) Dirty() bool {
+	return d.dirty.Load()
+}
+
+// applyDeferred returns true if the changes are deferred at t, since they are pushed manually or no apply window is open.
+func (d *Datastore) applyDeferred(t time.Time) bool {
+	return d.config.PushMode == pushModeManual || !d.config.ApplyWindowOpen(t)
+}
+
+// deferChanges records the changes of the intents, such that they are pushed to the device by PushChanges
+// or once the next apply window opens. It returns the time the next window opens, the zero time without windows.
+func (d *Datastore) deferChanges(ctx context.Context, intents []string, updates tree.LeafVariantSlice, deletes []tree.DeleteEntry) (time.Time, error) {
+	now := time.Now()
+	rec := &pendingChange{
+		Timestamp: now.UnixNano(),
+		Intents:   intents,
+		Updates:   make([][]string, 0, len(updates)),
+		Deletes:   make([][]string, 0, len(deletes)),
+	}
+	for _, u := range updates {
+		rec.Updates = append(rec.Updates, u.GetPath())
+	}
+	for _, del := range deletes {
+		rec.Deletes = append(rec.Deletes, del.Path())
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	upd, err := d.cacheClient.NewUpdate(
+		&sdcpb.Update{
+			Path: &sdcpb.Path{
+				Elem: []*sdcpb.PathElem{{Name: pendingKey(rec.Timestamp, rec.Intents)}},
+			},
+			Value: &sdcpb.TypedValue{
+				Value: &sdcpb.TypedValue_BytesVal{BytesVal: b},
+			},
+		},
+	)
+	if err != nil {
+		return time.Time{}, err
+	}
+	err = d.cacheClient.Modify(ctx, d.config.Name,
+		&cache.Opts{
+			Store: cachepb.Store_INTENTS,
+		},
+		nil,
+		[]*cache.Update{upd})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	d.dirty.Store(true)
+	next := d.config.NextApplyWindow(now)
+	log.Infof("ds=%s intents=%v: %s", d.Name(), intents, deferredMessage(next))
+	d.pendingEvents.publish(&PendingChangesEvent{
+		Operation:  PendingChangesDeferred,
+		Intents:    intents,
+		NextWindow: next,
+		Timestamp:  now,
+	})
+	return next, nil
+}
+
+// deferredMessage describes until when the changes are deferred.
+func deferredMessage(next time.Time) string {
+	if next.IsZero() {
+		return "changes deferred until they are pushed"
+	}
+	return fmt.Sprintf("changes deferred until the next apply window opens at %s", next.Format(time.RFC3339))
+}
+
+// readPendingChanges returns the deferred changes along with the keys of their records, oldest first.
+func (d *Datastore) readPendingChanges(ctx context.Context) ([][]string, []*pendingChange, error) {
+	upds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store:    cachepb.Store_INTENTS,
+		KeysOnly: true,
+	}, [][]string{{"*"}}, 0)
+	paths := make([][]string, 0, len(upds))
+	for _, upd := range upds {
+		if len(upd.GetPath()) == 0 || !strings.HasPrefix(upd.GetPath()[0], pendingPrefix) {
+			continue
+		}
+		paths = append(paths, []string{upd.GetPath()[0]})
+	}
+	if len(paths) == 0 {
+		return nil, nil, nil
+	}
+	upds = d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store: cachepb.Store_INTENTS,
+	}, paths, 0)
+
+	keys := make([][]string, 0, len(upds))
+	result := make([]*pendingChange, 0, len(upds))
+	for _, upd := range upds {
+		val, err := upd.Value()
+		if err != nil {
+			return nil, nil, err
+		}
+		rec := &pendingChange{}
+		if err = json.Unmarshal(val.GetBytesVal(), rec); err != nil {
+			return nil, nil, fmt.Errorf("malformed pending change %s: %w", upd.GetPath()[0], err)
+		}
+		keys = append(keys, upd.GetPath())
+		result = append(result, rec)
+	}
+	return keys, result, nil
+}
+
+// PushChanges pushes the changes accumulated by the SetIntents in manual push-mode, or deferred until the next
+// apply window, to the device in a single transaction. It returns the intents whose changes were pushed.
+func (d *Datastore) PushChanges(ctx context.Context) ([]string, error) {
+	if m := d.Maintenance(); m != nil {
+		return nil, status.Errorf(codes.Unavailable, "datastore %s is in maintenance: %s", d.Name(), m.Reason)
+	}
+	ctx, release, ok := d.tryAcquireIntentLock(ctx, pendingIntentName)
+	if !ok {
+		return nil, status.Errorf(codes.ResourceExhausted, "datastore %s has an ongoing SetIntentRequest", d.Name())
+	}
+	defer release()
+	return d.pushPendingChanges(ctx)
+}
+
+// loadDirty marks the datastore dirty if changes remained pending, e.g. across a restart.
+func (d *Datastore) loadDirty(ctx context.Context) {
+	_, changes, err := d.readPendingChanges(ctx)
+	if err != nil {
+		log.Errorf("datastore %s: failed reading the pending changes: %v", d.Name(), err)
+		return
+	}
+	d.dirty.Store(len(changes) > 0)
+}
+
+// pushPendingChanges pushes the deferred changes to the device in a single transaction.
+// The pushed values are the current highest precedence values of the intended store, such that changes
+// that were superseded while they were pending are not pushed. The intent lock has to be held by the caller.
+func (d *Datastore) pushPendingChanges(ctx context.Context) ([]string, error) {
+	keys, changes, err := d.readPendingChanges(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		d.dirty.Store(false)
+		return nil, nil
+	}
+
+	intents := make([]string, 0, len(changes))
+	var subtrees, deleted tree.PathSlices
+	for _, c := range changes {
+		intents = append(intents, c.Intents...)
+		for _, p := range c.Updates {
+			subtrees = append(subtrees, p)
+		}
+		for _, p := range c.Deletes {
+			subtrees = append(subtrees, p)
+			deleted = append(deleted, p)
+		}
+	}
+
+	err = d.applyPendingChanges(ctx, subtrees, deleted)
+	if err != nil {
+		d.pendingEvents.publish(&PendingChangesEvent{
+			Operation: PendingChangesFailed,
+			Intents:   intents,
+			Err:       err,
+			Timestamp: time.Now(),
+		})
+		return nil, err
+	}
+
+	err = d.cacheClient.Modify(ctx, d.config.Name,
+		&cache.Opts{
+			Store: cachepb.Store_INTENTS,
+		},
+		keys,
+		nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed removing the pushed changes: %w", err)
+	}
+	d.dirty.Store(false)
+
+	log.Infof("ds=%s: pushed the pending changes of intents %v", d.Name(), intents)
+	d.pendingEvents.publish(&PendingChangesEvent{
+		Operation: PendingChangesPushed,
+		Intents:   intents,
+		Timestamp: time.Now(),
+	})
+	return intents, nil
+}
+
+// applyPendingChanges pushes the intended values below the subtrees to the device and removes the values
+// below the deleted subtrees that are no longer set by any intent.
+func (d *Datastore) applyPendingChanges(ctx context.Context, subtrees tree.PathSlices, deleted tree.PathSlices) error {
+	tc := d.newIntentTreeContext(pendingIntentName)
+	root, err := tree.NewTreeRoot(ctx, tc)
+	if err != nil {
+		return err
+	}
+	storeIndex, err := d.readStoreKeysMeta(ctx, cachepb.Store_INTENDED)
+	if err != nil {
+		return err
+	}
+	tc.SetStoreIndex(storeIndex)
+
+	fullReplace := d.config.ApplyMode == applyModeFullReplace
+	// the complete intended config is pushed in full-replace mode
+	paths := subtrees
+	if fullReplace {
+		paths = tc.IntendedStorePrefixes()
+	}
+	if len(paths) > 0 {
+		for _, upd := range tc.ReadCurrentUpdatesHighestPriorities(ctx, paths, 1) {
+			if _, err = root.AddCacheUpdateRecursive(ctx, upd, true); err != nil {
+				return err
+			}
+		}
+	}
+	if !fullReplace {
+		if err = d.populateTreeWithRunning(ctx, tc, root); err != nil {
+			return err
+		}
+		if err = root.DeleteUnownedRunning(ctx, pendingIntentName, deleted); err != nil {
+			return err
+		}
+	}
+	root.FinishInsertionPhase()
+
+	updates := root.GetUpdatesDivergingFromRunning()
+	deletes, err := root.GetDeletes(tc.GetDeleteAggregation())
+	if err != nil {
+		return err
+	}
+	if !fullReplace && len(updates) == 0 && len(deletes) == 0 {
+		// the device already carries the intended config
+		return nil
+	}
+
+	applyCtx, cancel := d.intentPhaseContext(ctx, intentPhaseApply)
+	defer cancel()
+	_, err = d.applyIntent(applyCtx, pendingIntentName, pendingIntentName, root)
+	if err != nil {
+		return intentPhaseError(applyCtx, intentPhaseApply, err)
+	}
+
+	delSl := make(tree.PathSlices, 0, len(deletes))
+	for _, del := range deletes {
+		delSl = append(delSl, del.Path())
+	}
+	// fast and optimistic writeback to the config store
+	return d.writeBackConfig(ctx, delSl, updates.ToCacheUpdateSlice())
+}
+
+// schedulePendingChanges pushes the deferred changes whenever an apply window opens.
+// Failed pushes are retried while the window is open.
+func (d *Datastore) schedulePendingChanges(ctx context.Context) {
+	for {
+		now := time.Now()
+		wait := time.Until(d.config.NextApplyWindow(now))
+		if d.config.ApplyWindowOpen(now) {
+			if _, err := d.PushChanges(ctx); err != nil {
+				log.Errorf("datastore %s: failed to push the pending changes, retrying in %s: %v", d.Name(), pendingPushRetry, err)
+				wait = min(wait, pendingPushRetry)
+			}
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/tree"
+)
+
+func TestDatastore_deferChanges(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	store := map[string]*cache.Update{}
+	configureIntentsStoreMock(cacheClient, store)
+
+	d := &Datastore{
+		config:        &config.DatastoreConfig{Name: "dev1"},
+		cacheClient:   cacheClient,
+		pendingEvents: newEventBroadcaster[*PendingChangesEvent]("pending changes"),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := d.WatchPendingChanges(ctx)
+
+	// no apply windows are configured, hence the changes are never deferred
+	if d.applyDeferred(time.Now()) {
+		t.Errorf("applyDeferred() = true without apply windows")
+	}
+
+	deletes := []tree.DeleteEntry{
+		tree.NewDeleteEntryImpl(&sdcpb.Path{}, tree.PathSlice{"interface", "ethernet-0/0"}),
+	}
+	for _, intent := range []string{"intent1", "intent2"} {
+		if _, err := d.deferChanges(ctx, []string{intent}, nil, deletes); err != nil {
+			t.Fatal(err)
+		}
+		ev := <-events
+		if ev.Operation != PendingChangesDeferred || !cmp.Equal(ev.Intents, []string{intent}) {
+			t.Errorf("unexpected event: %v", ev)
+		}
+	}
+
+	// the records must not show up as intents
+	intents, err := d.listRawIntent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intents) != 0 {
+		t.Errorf("listRawIntent() returned %d intents, want 0", len(intents))
+	}
+
+	keys, changes, err := d.readPendingChanges(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || len(changes) != 2 {
+		t.Fatalf("readPendingChanges() returned %d keys and %d changes, want 2", len(keys), len(changes))
+	}
+	for _, c := range changes {
+		if diff := cmp.Diff([][]string{{"interface", "ethernet-0/0"}}, c.Deletes); diff != "" {
+			t.Errorf("pending change of %v: deletes mismatch (-want +got):\n%s", c.Intents, diff)
+		}
+	}
+}
+
+func TestDatastore_PushChanges(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	store := map[string]*cache.Update{}
+	configureIntentsStoreMock(cacheClient, store)
+
+	d := &Datastore{
+		config:        &config.DatastoreConfig{Name: "dev1", PushMode: pushModeManual},
+		cacheClient:   cacheClient,
+		intentLock:    newIntentLock(),
+		pendingEvents: newEventBroadcaster[*PendingChangesEvent]("pending changes"),
+	}
+	ctx := context.Background()
+
+	// the changes are always deferred in manual push-mode
+	if !d.applyDeferred(time.Now()) {
+		t.Errorf("applyDeferred() = false in manual push-mode")
+	}
+
+	next, err := d.deferChanges(ctx, []string{"intent1"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.IsZero() {
+		t.Errorf("deferChanges() returned next window %s without apply windows", next)
+	}
+	if !d.Dirty() {
+		t.Errorf("Dirty() = false with pending changes")
+	}
+
+	// pushes are rejected during maintenance
+	d.SetMaintenance(&Maintenance{Reason: "upgrade"})
+	if _, err = d.PushChanges(ctx); status.Code(err) != codes.Unavailable {
+		t.Errorf("PushChanges() during maintenance: expected code %s, got %v", codes.Unavailable, err)
+	}
+	d.SetMaintenance(nil)
+
+	// pushes are rejected while a SetIntent is ongoing
+	_, release, ok := d.tryAcquireIntentLock(ctx, "intent2")
+	if !ok {
+		t.Fatal("failed to acquire the intent lock")
+	}
+	if _, err = d.PushChanges(ctx); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("PushChanges() during a SetIntent: expected code %s, got %v", codes.ResourceExhausted, err)
+	}
+	release()
+
+	// the dirty state is restored from the pending changes, e.g. after a restart
+	d.dirty.Store(false)
+	d.loadDirty(ctx)
+	if !d.Dirty() {
+		t.Errorf("loadDirty() did not pick up the pending changes")
+	}
+}
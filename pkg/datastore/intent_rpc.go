@@ -16,59 +16,168 @@ package datastore
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/sdcio/cache/proto/cachepb"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/sdcio/data-server/pkg/cache"
 	"github.com/sdcio/data-server/pkg/datastore/target"
+	"github.com/sdcio/data-server/pkg/tree"
 )
 
-var rawIntentPrefix = "__raw_intent__"
+// rawIntentPrefix is the prefix of the raw intent keys in the intents store.
+// The name and priority of the intent follow the prefix as a marshaled sdcpb.Intent,
+// such that intent names may contain arbitrary characters.
+var rawIntentPrefix = "__intent__"
 
-const (
-	intentRawNameSep = "_"
-)
+// legacyRawIntentPrefix is the prefix of the raw intent keys of previous versions, that
+// string-encoded the name and priority as <name>_<priority>. They are migrated on startup.
+var legacyRawIntentPrefix = "__raw_intent__"
 
 var ErrIntentNotFound = errors.New("intent not found")
 
+// GetIntentOpts carries the GetIntent options that are not part of the sdcpb.GetIntentRequest.
+type GetIntentOpts struct {
+	// RevealSecrets returns the sensitive values in clear text instead of redacting them
+	RevealSecrets bool
+}
+
 func (d *Datastore) GetIntent(ctx context.Context, req *sdcpb.GetIntentRequest) (*sdcpb.GetIntentResponse, error) {
+	return d.GetIntentWithOpts(ctx, req, nil)
+}
+
+// GetIntentWithOpts is GetIntent, honoring the options that are not part of the sdcpb.GetIntentRequest.
+func (d *Datastore) GetIntentWithOpts(ctx context.Context, req *sdcpb.GetIntentRequest, opts *GetIntentOpts) (*sdcpb.GetIntentResponse, error) {
 	r, err := d.getRawIntent(ctx, req.GetIntent(), req.GetPriority())
 	if err != nil {
 		return nil, err
 	}
 
+	upds := r.GetUpdate()
+	if opts == nil || !opts.RevealSecrets {
+		upds = d.redactUpdates(upds)
+	}
 	rsp := &sdcpb.GetIntentResponse{
 		Name: d.Name(),
 		Intent: &sdcpb.Intent{
 			Intent:   r.GetIntent(),
 			Priority: r.GetPriority(),
-			Update:   r.GetUpdate(),
+			Update:   upds,
 		},
 	}
 	return rsp, nil
 }
 
 func (d *Datastore) SetIntent(ctx context.Context, req *sdcpb.SetIntentRequest) (*sdcpb.SetIntentResponse, error) {
-	if !d.intentMutex.TryLock() {
+	result, err := d.SetIntentWithOpts(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result.Response, nil
+}
+
+// SetIntentWithOpts is SetIntent, honoring the options that are not part of the sdcpb.SetIntentRequest.
+func (d *Datastore) SetIntentWithOpts(ctx context.Context, req *sdcpb.SetIntentRequest, opts *SetIntentOpts) (*SetIntentResult, error) {
+	if err := d.awaitMaintenance(ctx); err != nil {
+		return nil, err
+	}
+	ctx, release, ok := d.tryAcquireIntentLock(ctx, req.GetIntent())
+	if !ok {
 		return nil, status.Errorf(codes.ResourceExhausted, "datastore %s has an ongoing SetIntentRequest", d.Name())
 	}
-	defer d.intentMutex.Unlock()
+	defer release()
 
 	log.Infof("received SetIntentRequest: ds=%s intent=%s", req.GetName(), req.GetIntent())
+
+	if opts == nil || opts.IdempotencyKey == "" {
+		return d.setIntentWithOpts(ctx, req, opts)
+	}
+
+	// retried requests, e.g. from controllers after a timeout, return the outcome of the original request
+	rec, err := d.getIdempotencyRecord(ctx, opts.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if rec != nil {
+		log.Infof("ds=%s intent=%s: idempotency key %s already processed, returning the original outcome", req.GetName(), req.GetIntent(), opts.IdempotencyKey)
+		return rec.outcome(req)
+	}
+	result, err := d.setIntentWithOpts(ctx, req, opts)
+	// dry-runs do not apply any changes and interrupted requests have to be applied by the retry
+	if !req.GetDryRun() && ctx.Err() == nil {
+		rerr := d.writeIdempotencyRecord(context.WithoutCancel(ctx), opts.IdempotencyKey, req, result, err)
+		if rerr != nil {
+			log.Errorf("%s: failed to store the outcome of idempotency key %s: %v", d.Name(), opts.IdempotencyKey, rerr)
+		}
+	}
+	return result, err
+}
+
+// setIntentWithOpts runs the SetIntentRequest through the hook pipeline, the intent lock has to be held by the caller.
+func (d *Datastore) setIntentWithOpts(ctx context.Context, req *sdcpb.SetIntentRequest, opts *SetIntentOpts) (*SetIntentResult, error) {
+	if d.hooksErr != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "datastore %s has invalid hooks: %v", d.Name(), d.hooksErr)
+	}
+	req, err := d.hooks.RunPre(ctx, req)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	result, err := d.processIntent(ctx, req, opts)
+	if req.GetDryRun() || (result != nil && result.NoOp) {
+		return result, err
+	}
+	var rsp *sdcpb.SetIntentResponse
+	if result != nil {
+		rsp = result.Response
+	}
+	d.hooks.RunPost(context.WithoutCancel(ctx), req, rsp, err)
+	return result, err
+}
+
+// processIntent processes the SetIntentRequest.
+func (d *Datastore) processIntent(ctx context.Context, req *sdcpb.SetIntentRequest, opts *SetIntentOpts) (*SetIntentResult, error) {
+	// short-circuit intents that are re-applied unchanged, e.g. by the reconcile loops of controllers.
+	// Replacing intents are never short-circuited, since values of other intents or running might have to be removed.
+	noOp := false
+	var err error
+	if opts == nil || !opts.Replace {
+		noOp, err = d.isNoOpSetIntent(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if noOp {
+		log.Infof("ds=%s intent=%s: intent unchanged and in sync, nothing to do", req.GetName(), req.GetIntent())
+		return &SetIntentResult{Response: &sdcpb.SetIntentResponse{}, NoOp: true}, nil
+	}
+
+	// record the request, such that it is recovered if its processing is interrupted by a crash
+	if d.config.WAL != nil && !req.GetDryRun() {
+		err = d.writeWAL(ctx, req, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed writing the write-ahead log record: %w", err)
+		}
+		defer func() {
+			err := d.deleteWAL(context.WithoutCancel(ctx), req.GetIntent())
+			if err != nil {
+				log.Errorf("%s: failed to delete the write-ahead log record of intent %s: %v", d.Name(), req.GetIntent(), err)
+			}
+		}()
+	}
+
 	now := time.Now().UnixNano()
 	candidateName := fmt.Sprintf("%s-%d", req.GetIntent(), now)
-	err := d.CreateCandidate(ctx, &sdcpb.DataStore{
+	err = d.CreateCandidate(ctx, &sdcpb.DataStore{
 		Type:     sdcpb.Type_CANDIDATE,
 		Name:     candidateName,
 		Owner:    req.GetIntent(),
@@ -78,20 +187,23 @@ func (d *Datastore) SetIntent(ctx context.Context, req *sdcpb.SetIntentRequest)
 		return nil, err
 	}
 	defer func() {
-		// delete candidate
-		err := d.cacheClient.DeleteCandidate(ctx, d.Name(), candidateName)
+		// delete candidate, also if the request was cancelled, timed out or panicked
+		err := d.cacheClient.DeleteCandidate(context.WithoutCancel(ctx), d.Name(), candidateName)
 		if err != nil {
 			log.Errorf("%s: failed to delete candidate %s: %v", d.Name(), candidateName, err)
 		}
 	}()
 
-	setIntentResponse, err := d.SetIntentUpdate(ctx, req, candidateName)
+	result, err := d.SetIntentUpdate(ctx, req, candidateName, opts)
 	if err != nil {
 		log.Errorf("%s: failed to SetIntentUpdate: %v", d.Name(), err)
+		if errors.Is(context.Cause(ctx), ErrIntentAborted) {
+			return nil, status.Errorf(codes.Aborted, "%v: %v", ErrIntentAborted, err)
+		}
 		return nil, err
 	}
 
-	return setIntentResponse, nil
+	return result, nil
 }
 
 func (d *Datastore) ListIntent(ctx context.Context, req *sdcpb.ListIntentRequest) (*sdcpb.ListIntentResponse, error) {
@@ -105,7 +217,54 @@ func (d *Datastore) ListIntent(ctx context.Context, req *sdcpb.ListIntentRequest
 	}, nil
 }
 
-func (d *Datastore) applyIntent(ctx context.Context, candidateName string, source target.TargetSource) (*sdcpb.SetDataResponse, error) {
+// BlameConfig returns the intended configuration as a tree, where every leaf is annotated with
+// the owner and priority of its value and whether the value on the device (running) differs.
+// The sdcpb.DataServer does not (yet) define a BlameConfig RPC, so it is exposed on the Datastore only.
+func (d *Datastore) BlameConfig(ctx context.Context) (*tree.BlameTreeElement, error) {
+	treeCacheSchemaClient := tree.NewTreeSchemaCacheClient(d.Name(), d.cacheClient, d.getValidationClient())
+	tc := tree.NewTreeContext(treeCacheSchemaClient, "")
+
+	root, err := tree.NewTreeRoot(ctx, tc)
+	if err != nil {
+		return nil, err
+	}
+
+	// read all the keys from the cache intended store, to then retrieve the highest priority values
+	storeIndex, err := d.readStoreKeysMeta(ctx, cachepb.Store_INTENDED)
+	if err != nil {
+		return nil, err
+	}
+	tc.SetStoreIndex(storeIndex)
+
+	for _, upd := range tc.ReadCurrentUpdatesHighestPriorities(ctx, tc.IntendedStorePrefixes(), 1) {
+		_, err = root.AddCacheUpdateRecursive(ctx, upd, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = d.populateTreeWithRunning(ctx, tc, root)
+	if err != nil {
+		return nil, err
+	}
+
+	root.FinishInsertionPhase()
+
+	result, err := root.BlameConfig()
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		// no intended configuration present
+		result = &tree.BlameTreeElement{}
+	}
+	return result, nil
+}
+
+// applyIntent sends the changes of the source to the target. If the target rejects them,
+// a *target.SBIError is returned, carrying the structured errors of the device.
+// The request and its outcome are recorded in the journal.
+func (d *Datastore) applyIntent(ctx context.Context, intentName string, candidateName string, source target.TargetSource) (*sdcpb.SetDataResponse, error) {
 	if candidateName == "" {
 		return nil, fmt.Errorf("missing candidate name")
 	}
@@ -117,8 +276,21 @@ func (d *Datastore) applyIntent(ctx context.Context, candidateName string, sourc
 	if d.sbi == nil {
 		return nil, fmt.Errorf("%s is not connected", d.config.Name)
 	}
+	defer d.pauseSyncForApply()()
 
-	rsp, err = d.sbi.Set(ctx, source)
+	start := time.Now()
+	switch d.config.ApplyMode {
+	case applyModeFullReplace:
+		replacer, ok := d.sbi.(target.FullReplacer)
+		if !ok {
+			return nil, fmt.Errorf("datastore %s: target does not support full config replaces", d.config.Name)
+		}
+		rsp, err = replacer.ReplaceAll(ctx, source)
+	default:
+		rsp, err = d.sbi.Set(ctx, source)
+	}
+	d.setLastApply(intentName, start, err)
+	d.recordJournal(ctx, intentName, source, start, rsp, err)
 	if err != nil {
 		return nil, err
 	}
@@ -133,7 +305,10 @@ func (d *Datastore) saveRawIntent(ctx context.Context, intentName string, req *s
 		return err
 	}
 	//
-	rin := rawIntentName(intentName, req.GetPriority())
+	rin, err := rawIntentKey(intentName, req.GetPriority())
+	if err != nil {
+		return err
+	}
 	upd, err := d.cacheClient.NewUpdate(
 		&sdcpb.Update{
 			Path: &sdcpb.Path{
@@ -160,7 +335,10 @@ func (d *Datastore) saveRawIntent(ctx context.Context, intentName string, req *s
 }
 
 func (d *Datastore) getRawIntent(ctx context.Context, intentName string, priority int32) (*sdcpb.SetIntentRequest, error) {
-	rin := rawIntentName(intentName, priority)
+	rin, err := rawIntentKey(intentName, priority)
+	if err != nil {
+		return nil, err
+	}
 	upds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
 		Store: cachepb.Store_INTENTS,
 	}, [][]string{{rin}}, 0)
@@ -191,22 +369,13 @@ func (d *Datastore) listRawIntent(ctx context.Context) ([]*sdcpb.Intent, error)
 	}
 	intents := make([]*sdcpb.Intent, 0, numUpds)
 	for _, upd := range upds {
-		if len(upd.GetPath()) == 0 {
-			return nil, fmt.Errorf("malformed raw intent name: %q", upd.GetPath()[0])
-		}
-		intentRawName := strings.TrimPrefix(upd.GetPath()[0], rawIntentPrefix)
-		intentNameComp := strings.Split(intentRawName, intentRawNameSep)
-		inc := len(intentNameComp)
-		if inc < 2 {
-			return nil, fmt.Errorf("malformed raw intent name: %q", upd.GetPath()[0])
+		// the intents store also holds e.g. the journal
+		if len(upd.GetPath()) == 0 || !strings.HasPrefix(upd.GetPath()[0], rawIntentPrefix) {
+			continue
 		}
-		pr, err := strconv.Atoi(intentNameComp[inc-1])
+		in, err := parseRawIntentKey(upd.GetPath()[0])
 		if err != nil {
-			return nil, fmt.Errorf("malformed raw intent name: %q: %v", upd.GetPath()[0], err)
-		}
-		in := &sdcpb.Intent{
-			Intent:   strings.Join(intentNameComp[:inc-1], intentRawNameSep),
-			Priority: int32(pr),
+			return nil, err
 		}
 		intents = append(intents, in)
 	}
@@ -220,14 +389,61 @@ func (d *Datastore) listRawIntent(ctx context.Context) ([]*sdcpb.Intent, error)
 }
 
 func (d *Datastore) deleteRawIntent(ctx context.Context, intentName string, priority int32) error {
+	rin, err := rawIntentKey(intentName, priority)
+	if err != nil {
+		return err
+	}
 	return d.cacheClient.Modify(ctx, d.config.Name,
 		&cache.Opts{
 			Store: cachepb.Store_INTENTS,
 		},
-		[][]string{{rawIntentName(intentName, priority)}},
+		[][]string{{rin}},
 		nil)
 }
 
+// migrateRawIntents moves the raw intents stored under legacy keys to the current keys.
+func (d *Datastore) migrateRawIntents(ctx context.Context) error {
+	upds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store:    cachepb.Store_INTENTS,
+		KeysOnly: true,
+	}, [][]string{{"*"}}, 0)
+	for _, upd := range upds {
+		if len(upd.GetPath()) == 0 || !strings.HasPrefix(upd.GetPath()[0], legacyRawIntentPrefix) {
+			continue
+		}
+		legacy := upd.GetPath()[0]
+		rupds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+			Store: cachepb.Store_INTENTS,
+		}, [][]string{{legacy}}, 0)
+		if len(rupds) == 0 {
+			continue
+		}
+		val, err := rupds[0].Value()
+		if err != nil {
+			return err
+		}
+		// the stored request carries the name and priority of the intent
+		req := &sdcpb.SetIntentRequest{}
+		if err = proto.Unmarshal(val.GetBytesVal(), req); err != nil {
+			return fmt.Errorf("malformed raw intent %q: %w", legacy, err)
+		}
+		if err = d.saveRawIntent(ctx, req.GetIntent(), req); err != nil {
+			return err
+		}
+		err = d.cacheClient.Modify(ctx, d.config.Name,
+			&cache.Opts{
+				Store: cachepb.Store_INTENTS,
+			},
+			[][]string{{legacy}},
+			nil)
+		if err != nil {
+			return err
+		}
+		log.Infof("datastore %s: migrated raw intent %s with priority %d", d.Name(), req.GetIntent(), req.GetPriority())
+	}
+	return nil
+}
+
 func (d *Datastore) cacheUpdateToUpdate(ctx context.Context, cupd *cache.Update) (*sdcpb.Update, error) {
 	scp, err := d.toPath(ctx, cupd.GetPath())
 	if err != nil {
@@ -243,6 +459,24 @@ func (d *Datastore) cacheUpdateToUpdate(ctx context.Context, cupd *cache.Update)
 	}, nil
 }
 
-func rawIntentName(name string, pr int32) string {
-	return fmt.Sprintf("%s%s%s%d", rawIntentPrefix, name, intentRawNameSep, pr)
+// rawIntentKey returns the intents store key of the raw intent.
+func rawIntentKey(name string, pr int32) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(&sdcpb.Intent{Intent: name, Priority: pr})
+	if err != nil {
+		return "", err
+	}
+	return rawIntentPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// parseRawIntentKey returns the intent name and priority of an intents store key.
+func parseRawIntentKey(k string) (*sdcpb.Intent, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(k, rawIntentPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("malformed raw intent key %q: %w", k, err)
+	}
+	in := &sdcpb.Intent{}
+	if err = proto.Unmarshal(b, in); err != nil {
+		return nil, fmt.Errorf("malformed raw intent key %q: %w", k, err)
+	}
+	return in, nil
 }
@@ -0,0 +1,66 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+
+	"github.com/sdcio/data-server/pkg/tree"
+)
+
+// IntentDebugInfo is the debug information of an intent, the tree built for the intent
+// with its choice resolutions and precedence decisions as well as the validation results.
+type IntentDebugInfo struct {
+	*tree.DebugInfo
+	ValidationErrors   []string
+	ValidationWarnings []string
+}
+
+// DebugIntent builds the tree for the stored intent, as if it was set again, validates
+// it and returns the decisions taken in the tree. Neither the device nor the cache are modified.
+func (d *Datastore) DebugIntent(ctx context.Context, intentName string, priority int32) (*IntentDebugInfo, error) {
+	req, err := d.getRawIntent(ctx, intentName, priority)
+	if err != nil {
+		return nil, err
+	}
+
+	tc := d.newIntentTreeContext(intentName)
+	root, err := d.populateTree(ctx, tc, req)
+	if err != nil {
+		return nil, err
+	}
+	err = d.populateTreeWithRunning(ctx, tc, root)
+	if err != nil {
+		return nil, err
+	}
+	root.FinishInsertionPhase()
+
+	validationErrors, validationWarnings := validateTree(ctx, root)
+
+	// the debug information is returned as a whole, hence the values are not truncated
+	tc.SetRedaction(d.treeRedaction(0))
+	result := &IntentDebugInfo{
+		DebugInfo:          root.DebugInfo(),
+		ValidationErrors:   make([]string, 0, len(validationErrors)),
+		ValidationWarnings: make([]string, 0, len(validationWarnings)),
+	}
+	for _, e := range validationErrors {
+		result.ValidationErrors = append(result.ValidationErrors, e.Error())
+	}
+	for _, e := range validationWarnings {
+		result.ValidationWarnings = append(result.ValidationWarnings, e.Error())
+	}
+	return result, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"fmt"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	"github.com/sdcio/data-server/pkg/datastore/target"
+	"github.com/sdcio/data-server/pkg/tree"
+)
+
+// SetIntentOpts carries the SetIntent options that are not part of the sdcpb.SetIntentRequest.
+type SetIntentOpts struct {
+	// DeviceDiff requests the diff generated by the target for dry-runs,
+	// e.g. via the commit dry-run of the device. Requires a target that supports device diffs.
+	DeviceDiff bool
+	// Replace makes the intent the exclusive owner of the subtrees it sets. Values below these subtrees
+	// of intents with lower priority and values that only exist in running are deleted from the device.
+	Replace bool
+	// Force allows the intent to overwrite config that is only present in running,
+	// if the running-protection of the datastore is set to force.
+	Force bool
+	// IdempotencyKey identifies the request across retries. The outcome of a request with a key is stored,
+	// such that retries of the request return the original outcome instead of applying the changes again.
+	IdempotencyKey string
+	// Role is the role of the client, the lint checks flag values outside of the paths configured for the role
+	Role string
+	// Transaction makes the intent a participant of a two-phase commit across datastores,
+	// its changes are only applied to the device once all participants prepared their changes.
+	Transaction *TransactionParticipant
+}
+
+// SetIntentResult is the result of a SetIntent with options.
+type SetIntentResult struct {
+	Response *sdcpb.SetIntentResponse
+	// DeviceDiff is the diff generated by the target, set if requested for a dry-run
+	DeviceDiff string
+	// NoOp is set if the request re-applied the stored intent unchanged without drift on the device,
+	// hence the request was answered without building the tree and without interacting with the device.
+	NoOp bool
+	// Removed holds the paths removed from the intended store for the deleted intent,
+	// the paths deleted from the device are part of the Response.
+	Removed []*sdcpb.Path
+	// Uncovered holds the values of the deleted intent that are now served by intents with lower precedence.
+	Uncovered []*tree.UncoveredEntry
+	// Deferred is set if the changes were stored, but not yet pushed to the device, since the datastore is in
+	// manual push-mode or no apply window is open. They are pushed by PushChanges or once the next apply window opens.
+	Deferred bool
+	// Lint holds the findings of the lint checks, they are also part of the warnings of the Response
+	Lint []*LintWarning
+}
+
+// deviceDiff returns the diff the target reports for the changes of the source, without applying them.
+func (d *Datastore) deviceDiff(ctx context.Context, source target.TargetSource) (string, error) {
+	if d.sbi == nil {
+		return "", fmt.Errorf("%s is not connected", d.config.Name)
+	}
+	differ, ok := d.sbi.(target.Differ)
+	if !ok {
+		return "", fmt.Errorf("the target of datastore %s does not support device diffs", d.config.Name)
+	}
+	return differ.Diff(ctx, source)
+}
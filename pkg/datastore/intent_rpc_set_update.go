@@ -18,19 +18,82 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/sdcio/cache/proto/cachepb"
 	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/datastore/target"
+	"github.com/sdcio/data-server/pkg/dslog"
 	"github.com/sdcio/data-server/pkg/tree"
 	"github.com/sdcio/data-server/pkg/utils"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
 )
 
+// newIntentTreeContext returns the TreeContext for a tree that is populated with the given intent,
+// set up according to the datastore configuration.
+func (d *Datastore) newIntentTreeContext(intentName string) *tree.TreeContext {
+	treeCacheSchemaClient := tree.NewTreeSchemaCacheClient(d.Name(), d.cacheClient, d.getValidationClient())
+	tc := tree.NewTreeContext(treeCacheSchemaClient, intentName)
+	tc.SetConflictPolicy(tree.ConflictPolicy(d.config.ConflictPolicy))
+	tc.SetTieBreak(tree.TieBreak(d.config.TieBreak))
+	tc.SetDeleteAggregation(tree.DeleteAggregation(d.config.DeleteAggregation))
+	tc.SetValidationScope(tree.ValidationScope(d.config.ValidationScope))
+	if d.config.SBI != nil && d.config.SBI.NetconfOptions != nil {
+		tc.SetCreateNew(d.config.SBI.NetconfOptions.CreateNew)
+	}
+	// the tree is rendered in the logs
+	tc.SetRedaction(d.treeRedaction(dslog.MaxValueLength()))
+	return tc
+}
+
+// validateTree validates the tree and returns the cumulated validation errors and warnings.
+func validateTree(ctx context.Context, root *tree.RootEntry) (validationErrors []error, validationWarnings []error) {
+	// we use a channel and cumulate all the errors
+	validationErrChan := make(chan error)
+	validationWarningsChan := make(chan error)
+
+	wg := sync.WaitGroup{}
+
+	go func() {
+		defer close(validationWarningsChan)
+		defer close(validationErrChan)
+		defer func() {
+			if r := recover(); r != nil {
+				log.Errorf("panic during validation: %v\n%s", r, debug.Stack())
+				validationErrChan <- fmt.Errorf("internal error during validation: %v", r)
+			}
+		}()
+		root.Validate(ctx, validationErrChan, validationWarningsChan, true)
+	}()
+
+	wg.Add(1)
+	go func() {
+		// read from the Error channel
+		for e := range validationErrChan {
+			validationErrors = append(validationErrors, e)
+		}
+		wg.Done()
+	}()
+
+	wg.Add(1)
+	go func() {
+		// read from the Warnings channel
+		for e := range validationWarningsChan {
+			validationWarnings = append(validationWarnings, e)
+		}
+		wg.Done()
+	}()
+
+	wg.Wait()
+	return validationErrors, validationWarnings
+}
+
 func (d *Datastore) populateTreeWithRunning(ctx context.Context, tc *tree.TreeContext, r *tree.RootEntry) error {
 	upds, err := tc.ReadRunningFull(ctx)
 	if err != nil {
@@ -48,74 +111,111 @@ func (d *Datastore) populateTreeWithRunning(ctx context.Context, tc *tree.TreeCo
 	return nil
 }
 
-func (d *Datastore) populateTree(ctx context.Context, req *sdcpb.SetIntentRequest, tc *tree.TreeContext) (r *tree.RootEntry, err error) {
+// populateTree returns a tree that merges the given intents with the intended store. The stored versions of
+// the intents are marked for deletion and the values of the requests are added as new values, such that
+// several intents, e.g. of a batched push, are computed in a single pass. The tree context has to carry the
+// owners of the intents as its actual owners.
+func (d *Datastore) populateTree(ctx context.Context, tc *tree.TreeContext, reqs ...*sdcpb.SetIntentRequest) (r *tree.RootEntry, err error) {
 	// create a new Tree
 	root, err := tree.NewTreeRoot(ctx, tc)
 	if err != nil {
 		return nil, err
 	}
+	err = d.addIntentsToTree(ctx, tc, root, reqs...)
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
 
+// addIntentsToTree merges the given intents with the intended store into the tree, see populateTree.
+func (d *Datastore) addIntentsToTree(ctx context.Context, tc *tree.TreeContext, root *tree.RootEntry, reqs ...*sdcpb.SetIntentRequest) error {
 	// read all the keys from the cache intended store but just the keys, no values are populated
 	storeIndex, err := d.readStoreKeysMeta(ctx, cachepb.Store_INTENDED)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	tc.SetStoreIndex(storeIndex)
 
 	converter := utils.NewConverter(d.getValidationClient())
 
-	// list of updates to be added to the cache
-	// Expands the value, in case of json to single typed value updates
-	expandedReqUpdates, err := converter.ExpandUpdates(ctx, req.GetUpdate(), true)
-	if err != nil {
-		return nil, err
-	}
+	schemaCtx, cancel := d.intentPhaseContext(ctx, intentPhaseSchema)
+	defer cancel()
 
-	// temp storage for cache.Update of the req. They are to be added later.
-	newCacheUpdates := make([]*cache.Update, 0, len(expandedReqUpdates))
+	// temp storage for cache.Update of the requests. They are to be added later.
+	newCacheUpdates := []*cache.Update{}
 
 	// Set of pathKeySet that need to be retrieved from the cache
 	pathKeySet := tree.NewPathSet()
 
-	for _, u := range expandedReqUpdates {
-		pathslice, err := utils.CompletePath(nil, u.GetPath())
-		if err != nil {
-			return nil, err
-		}
+	// the modification time of the request values
+	ts := time.Now().UnixNano()
 
-		pathKeySet.AddPath(pathslice)
-
-		// since we already have the pathslice, we construct the cache.Update, but keep it for later
-		// addition to the tree. First we need to mark the existing once for deltion
+	owners := make([]string, 0, len(reqs))
+	for _, req := range reqs {
+		if slices.Contains(owners, req.GetIntent()) {
+			return fmt.Errorf("intent %s is set more than once", req.GetIntent())
+		}
+		owners = append(owners, req.GetIntent())
 
-		// make sure typedValue is carrying the correct type
-		err = d.validateUpdate(ctx, u)
+		// replace the snippet references and resolve the template variables of the intent values,
+		// the snippets might carry template actions as well
+		reqUpdates, err := d.expandSnippets(ctx, req.GetUpdate())
 		if err != nil {
-			return nil, err
+			return err
+		}
+		reqUpdates, err = d.renderTemplates(reqUpdates)
+		if err != nil {
+			return err
 		}
 
-		// convert value to []byte for cache insertion
-		val, err := proto.Marshal(u.GetValue())
+		// list of updates to be added to the cache
+		// Expands the value, in case of json to single typed value updates
+		expandedReqUpdates, err := converter.ExpandUpdates(schemaCtx, reqUpdates, !d.config.OmitKeyLeaves)
 		if err != nil {
-			return nil, err
+			return intentPhaseError(schemaCtx, intentPhaseSchema, err)
 		}
 
-		// construct the cache.Update
-		newCacheUpdates = append(newCacheUpdates, cache.NewUpdate(pathslice, val, req.GetPriority(), req.GetIntent(), 0))
+		for _, u := range expandedReqUpdates {
+			pathslice, err := utils.CompletePath(nil, u.GetPath())
+			if err != nil {
+				return err
+			}
+
+			pathKeySet.AddPath(pathslice)
+
+			// since we already have the pathslice, we construct the cache.Update, but keep it for later
+			// addition to the tree. First we need to mark the existing once for deltion
+
+			// make sure typedValue is carrying the correct type
+			err = d.validateUpdate(schemaCtx, u)
+			if err != nil {
+				return intentPhaseError(schemaCtx, intentPhaseSchema, err)
+			}
+
+			// convert value to []byte for cache insertion
+			val, err := proto.Marshal(u.GetValue())
+			if err != nil {
+				return err
+			}
+
+			// construct the cache.Update
+			newCacheUpdates = append(newCacheUpdates, cache.NewUpdate(pathslice, val, req.GetPriority(), req.GetIntent(), ts))
+		}
 	}
 
-	root.LoadIntendedStoreOwnerData(ctx, req.GetIntent(), pathKeySet)
+	root.LoadIntendedStoreOwnersData(ctx, owners, pathKeySet)
 
-	// now add the cache.Updates from the actual request, after marking the old once for deletion.
+	// now add the cache.Updates from the actual requests, after marking the old once for deletion.
 	for _, upd := range newCacheUpdates {
 		// add the cache.Update to the tree
 		_, err = root.AddCacheUpdateRecursive(ctx, upd, true)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	return root, nil
+	return nil
 }
 
 // SetIntentUpdate Processes new and updated intents
@@ -134,21 +234,18 @@ func (d *Datastore) populateTree(ctx context.Context, req *sdcpb.SetIntentReques
 //  10. Now the tree can be queried for the highes priority values ".GetHighesPrio(true)". It will also consider the deleted flag and only return new or updated values.
 //     This is the calculation the yields the updates that will need to be pushed to the device.
 //  11. .GetDeletes() returns the entries that are still marked for deletion. The Paths will be extracted and then send to the device as deletes (path aggregation is
-//     applied, if e.g. a whole interface is delted, the deleted paths only contains the delete for the interface, not all its leafs)
+//     applied according to the configured delete-aggregation, by default if e.g. a whole interface is delted, the deleted paths only contains the delete for the interface, not all its leafs)
 //  12. All updates (New & Updated) for the specifc owner / intent are being retrieved from the tree to update the cache.
 //  13. All remaining deletes for the specifc owner / intent are being retrieved from the tree to remove them from the cache.
 //  14. The request towards southbound is created with the device updates / deletes. A candidate is created, and applied to the device.
 //  15. The owner based updates and deletes are being pushed into the cache.
 //  16. The raw intent (as received in the req) is stored as a blob in the cache.
-func (d *Datastore) SetIntentUpdate(ctx context.Context, req *sdcpb.SetIntentRequest, candidateName string) (*sdcpb.SetIntentResponse, error) {
-	logger := log.NewEntry(
-		log.New()).WithFields(log.Fields{
+func (d *Datastore) SetIntentUpdate(ctx context.Context, req *sdcpb.SetIntentRequest, candidateName string, opts *SetIntentOpts) (*SetIntentResult, error) {
+	logger := log.WithFields(map[string]any{
 		"ds":       d.Name(),
 		"intent":   req.GetIntent(),
 		"priority": req.GetPriority(),
 	})
-	logger.Logger.SetLevel(log.GetLevel())
-	logger.Logger.SetFormatter(&log.TextFormatter{FullTimestamp: true})
 	logger.Debugf("set intent update start")
 	defer logger.Debugf("set intent update end")
 
@@ -156,57 +253,74 @@ func (d *Datastore) SetIntentUpdate(ctx context.Context, req *sdcpb.SetIntentReq
 	// if they need to be applied based on the intent priority.
 	logger.Debugf("reading intent paths to be updated from intended store; looking for the highest priority values")
 
-	treeCacheSchemaClient := tree.NewTreeSchemaCacheClient(d.Name(), d.cacheClient, d.getValidationClient())
-	tc := tree.NewTreeContext(treeCacheSchemaClient, req.GetIntent())
+	tc := d.newIntentTreeContext(req.GetIntent())
 
-	root, err := d.populateTree(ctx, req, tc)
+	populateCtx, cancel := d.intentPhaseContext(ctx, intentPhasePopulate)
+	defer cancel()
+
+	root, round, err := d.populateIntentTree(populateCtx, tc, req)
 	if err != nil {
-		return nil, err
+		return nil, intentPhaseError(populateCtx, intentPhasePopulate, err)
 	}
 
-	err = d.populateTreeWithRunning(ctx, tc, root)
-	if err != nil {
-		return nil, err
+	if opts != nil && opts.Replace {
+		subtrees := make(tree.PathSlices, 0, len(req.GetUpdate()))
+		for _, u := range req.GetUpdate() {
+			p, err := utils.CompletePath(nil, u.GetPath())
+			if err != nil {
+				return nil, err
+			}
+			subtrees = append(subtrees, p)
+		}
+		err = root.ReplaceSubtrees(populateCtx, req.GetIntent(), req.GetPriority(), subtrees)
+		if err != nil {
+			return nil, intentPhaseError(populateCtx, intentPhasePopulate, err)
+		}
+	}
+	if err = populateCtx.Err(); err != nil {
+		return nil, intentPhaseError(populateCtx, intentPhasePopulate, err)
 	}
 
 	logger.Debugf("finish insertion phase")
 	root.FinishInsertionPhase()
 
-	// perform validation
-	// we use a channel and cumulate all the errors
-	validationErrors := []error{}
-	validationErrChan := make(chan error)
-
-	validationWarnings := []error{}
-	validationWarningsChan := make(chan error)
-
-	wg := sync.WaitGroup{}
-
-	go func() {
-		root.Validate(ctx, validationErrChan, validationWarningsChan, true)
-		close(validationErrChan)
-		close(validationWarningsChan)
-	}()
-
-	wg.Add(1)
-	go func() {
-		// read from the Error channel
-		for e := range validationErrChan {
-			validationErrors = append(validationErrors, e)
+	// reject the intent if it writes values that are also set by other intents with the same priority
+	if tree.ConflictPolicy(d.config.ConflictPolicy) == tree.ConflictPolicyReject {
+		if conflicts := root.GetPriorityConflicts(req.GetIntent()); len(conflicts) > 0 {
+			conflictErrs := make([]error, 0, len(conflicts))
+			for _, c := range conflicts {
+				conflictErrs = append(conflictErrs, errors.New(c.String()))
+			}
+			return nil, fmt.Errorf("intent %q conflicts with intents of the same priority:\n%v", req.GetIntent(), errors.Join(conflictErrs...))
 		}
-		wg.Done()
-	}()
+	}
 
-	wg.Add(1)
-	go func() {
-		// read from the Warnings channel
-		for e := range validationWarningsChan {
-			validationWarnings = append(validationWarnings, e)
+	// check the intent against the running-protection policy
+	runningOverwrites := root.GetRunningOverwrites(req.GetIntent())
+	if len(runningOverwrites) > 0 {
+		overwriteErrs := make([]error, 0, len(runningOverwrites))
+		for _, o := range runningOverwrites {
+			overwriteErrs = append(overwriteErrs, errors.New(o.String()))
 		}
-		wg.Done()
-	}()
+		switch tree.RunningProtection(d.config.RunningProtection) {
+		case tree.RunningProtectionNever:
+			return nil, fmt.Errorf("intent %q overwrites config that is not owned by any intent:\n%v", req.GetIntent(), errors.Join(overwriteErrs...))
+		case tree.RunningProtectionForce:
+			if opts == nil || !opts.Force {
+				return nil, fmt.Errorf("intent %q overwrites config that is not owned by any intent, requires force:\n%v", req.GetIntent(), errors.Join(overwriteErrs...))
+			}
+		}
+		logger.Warnf("intent overwrites config that is not owned by any intent:\n%v", errors.Join(overwriteErrs...))
+	}
 
-	wg.Wait()
+	// perform validation
+	validationCtx, cancel := d.intentPhaseContext(ctx, intentPhaseValidation)
+	defer cancel()
+	validationErrors, validationWarnings := validateTree(validationCtx, root)
+	if err = validationCtx.Err(); err != nil {
+		// the validation was aborted
+		return nil, intentPhaseError(validationCtx, intentPhaseValidation, err)
+	}
 	logger.Tracef("Tree after Validate:%s\n", root.String())
 
 	// check if errors are received
@@ -220,11 +334,25 @@ func (d *Datastore) SetIntentUpdate(ctx context.Context, req *sdcpb.SetIntentReq
 		// adding to response later on, when response struct is created
 	}
 
+	// flag suspicious values and reject the ones of nodes refused by the status-policy
+	var role string
+	if opts != nil {
+		role = opts.Role
+	}
+	lintWarnings, err := d.lintIntent(ctx, req, role)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range lintWarnings {
+		logger.Warnf("%s", w)
+	}
+
 	logger.Info("intent is valid")
 
-	// retrieve the data that is meant to be send southbound (towards the device)
-	updates := root.GetHighestPrecedence(true)
-	deletes, err := root.GetDeletes(true)
+	// retrieve the data that is meant to be send southbound (towards the device),
+	// values that the device already carries are not sent again
+	updates := root.GetUpdatesDivergingFromRunning()
+	deletes, err := root.GetDeletes(tc.GetDeleteAggregation())
 	if err != nil {
 		return nil, err
 	}
@@ -244,7 +372,7 @@ func (d *Datastore) SetIntentUpdate(ctx context.Context, req *sdcpb.SetIntentReq
 
 	// add all the highes priority updates to the setDataReq
 	for _, u := range updates {
-		sdcpbUpd, err := d.cacheUpdateToUpdate(ctx, u.Update)
+		sdcpbUpd, err := d.cacheUpdateToUpdate(ctx, u.GetUpdate())
 		if err != nil {
 			return nil, err
 		}
@@ -272,10 +400,53 @@ func (d *Datastore) SetIntentUpdate(ctx context.Context, req *sdcpb.SetIntentReq
 	for _, e := range validationWarnings {
 		setIntentResponse.Warnings = append(setIntentResponse.Warnings, e.Error())
 	}
+	for _, o := range runningOverwrites {
+		setIntentResponse.Warnings = append(setIntentResponse.Warnings, o.String())
+	}
+	for _, w := range lintWarnings {
+		setIntentResponse.Warnings = append(setIntentResponse.Warnings, w.String())
+	}
+
+	result := &SetIntentResult{Response: setIntentResponse, Lint: lintWarnings}
+	if req.GetDelete() {
+		result.Removed, err = root.GetDeletedPathsForOwner(req.GetIntent())
+		if err != nil {
+			return nil, err
+		}
+		result.Uncovered = root.GetUncovered(req.GetIntent())
+		// the SetIntentResponse cannot carry the uncovered values, hence they are reported as warnings
+		for _, u := range result.Uncovered {
+			setIntentResponse.Warnings = append(setIntentResponse.Warnings, u.String())
+		}
+	}
 
 	// if it is a dry run, return now, skipping updating the device or the cache
 	if req.DryRun {
-		return setIntentResponse, nil
+		if opts != nil && opts.DeviceDiff {
+			applyCtx, cancel := d.intentPhaseContext(ctx, intentPhaseApply)
+			defer cancel()
+			result.DeviceDiff, err = d.deviceDiff(applyCtx, root)
+			if err != nil {
+				return nil, intentPhaseError(applyCtx, intentPhaseApply, err)
+			}
+		}
+		return result, nil
+	}
+
+	// only if not the OnlyIntended flag is set, we transact to the device
+	// in manual push-mode or outside of the apply windows the changes are recorded, to be pushed later on
+	applyToDevice := !req.Delete || req.Delete && !req.OnlyIntended
+	deferred := applyToDevice && (len(updates) > 0 || len(deletes) > 0) && d.applyDeferred(time.Now())
+
+	// within a transaction, changes that are not sent to the device are prepared at this point
+	var participant *TransactionParticipant
+	if opts != nil {
+		participant = opts.Transaction
+	}
+	if participant != nil && (!applyToDevice || deferred) {
+		if err = participant.prepared(ctx); err != nil {
+			return nil, err
+		}
 	}
 
 	logger.Info("intent setting into candidate")
@@ -285,13 +456,36 @@ func (d *Datastore) SetIntentUpdate(ctx context.Context, req *sdcpb.SetIntentReq
 		return nil, err
 	}
 
-	// only if not the OnlyIntended flag is set, we transact to the device
-	if !req.Delete || req.Delete && !req.OnlyIntended {
+	if deferred {
+		next, err := d.deferChanges(ctx, []string{req.GetIntent()}, updates, deletes)
+		if err != nil {
+			return nil, fmt.Errorf("failed recording the deferred changes: %w", err)
+		}
+		result.Deferred = true
+		applyToDevice = false
+		setIntentResponse.Warnings = append(setIntentResponse.Warnings, deferredMessage(next))
+	}
+	if applyToDevice {
 		logger.Info("intent set into candidate")
-		// apply the resulting config to the device
-		dataResp, err := d.applyIntent(ctx, candidateName, root)
+		// apply the resulting config to the device, either the changes only
+		// or the complete intended config
+		var source target.TargetSource = root
+		if d.config.ApplyMode == applyModeFullReplace {
+			source, err = d.fullIntendedTree(ctx, tc, root, req.GetIntent())
+			if err != nil {
+				return nil, err
+			}
+		}
+		applyCtx, cancel := d.intentPhaseContext(ctx, intentPhaseApply)
+		defer cancel()
+		var dataResp *sdcpb.SetDataResponse
+		if participant != nil {
+			dataResp, err = d.applyIntentInTransaction(applyCtx, participant, req.GetIntent(), candidateName, source)
+		} else {
+			dataResp, err = d.applyIntent(applyCtx, req.GetIntent(), candidateName, source)
+		}
 		if err != nil {
-			return nil, err
+			return nil, intentPhaseError(applyCtx, intentPhaseApply, err)
 		}
 		setIntentResponse.Warnings = append(setIntentResponse.Warnings, dataResp.GetWarnings()...)
 
@@ -302,79 +496,95 @@ func (d *Datastore) SetIntentUpdate(ctx context.Context, req *sdcpb.SetIntentReq
 	// update intent in intended store //
 	/////////////////////////////////////
 
-	// retrieve the data that is meant to be send towards the cache
-	updatesOwner := root.GetUpdatesForOwner(req.GetIntent())
-	deletesOwner := root.GetDeletesForOwner(req.GetIntent())
-
 	// logging
-	strSl := tree.Map(updates.ToCacheUpdateSlice(), func(u *cache.Update) string { return u.String() })
+	strSl := tree.Map(updates.ToCacheUpdateSlice(), d.cacheUpdateString)
 	logger.Debugf("Updates\n%s", strings.Join(strSl, "\n"))
 
 	delSl := make(tree.PathSlices, 0, len(deletes))
 	for _, del := range deletes {
 		delSl = append(delSl, del.Path())
 	}
-	logger.Debugf("Deletes:\n%s", strings.Join(strSl, "\n"))
-
-	strSl = tree.Map(updatesOwner, func(u *cache.Update) string { return u.String() })
-	logger.Debugf("Updates Owner:\n%s", strings.Join(strSl, "\n"))
+	logger.Debugf("Deletes:\n%s", strings.Join(delSl.StringSlice(), "\n"))
 
-	strSl = deletesOwner.StringSlice()
-	logger.Debugf("Deletes Owner:\n%s", strings.Join(strSl, "\n"))
-
-	err = d.cacheClient.Modify(ctx, d.Name(), &cache.Opts{
-		Store:    cachepb.Store_INTENDED,
-		Owner:    req.GetIntent(),
-		Priority: req.GetPriority(),
-	}, deletesOwner.ToStringSlice(), updatesOwner)
+	err = d.updateIntendedStore(ctx, root, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed updating the intended store for %s: %w", d.Name(), err)
+		return nil, err
 	}
 
-	// fast and optimistic writeback to the config store
-	err = d.cacheClient.Modify(ctx, d.Name(), &cache.Opts{
-		Store: cachepb.Store_CONFIG,
-	}, delSl.ToStringSlice(), updates.ToCacheUpdateSlice())
+	// fast and optimistic writeback to the config store, deferred changes are written back once they are pushed
+	if !result.Deferred {
+		if err = d.writeBackConfig(ctx, delSl, updates.ToCacheUpdateSlice()); err != nil {
+			return nil, err
+		}
+	}
+
+	err = d.storeRawIntent(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed updating the running config store for %s: %w", d.Name(), err)
+		return nil, err
 	}
+	// the next intent is applied to the tree
+	d.keepIntentTree(root, round)
 
-	switch req.Delete {
-	case true:
-		err = d.deleteRawIntent(ctx, req.GetIntent(), req.GetPriority())
-		if err != nil {
-			return nil, err
+	logger.Infof("ds=%s intent=%s: intent saved", req.GetName(), req.GetIntent())
+	return result, nil
+}
+
+// updateIntendedStore writes the values of each of the intents from the tree to the intended store
+// and removes their values that are no longer set. The tree is kept as the datastores tree snapshot.
+func (d *Datastore) updateIntendedStore(ctx context.Context, root *tree.RootEntry, reqs ...*sdcpb.SetIntentRequest) error {
+	for _, req := range reqs {
+		// retrieve the data that is meant to be send towards the cache
+		updatesOwner := root.GetUpdatesForOwner(req.GetIntent())
+		deletesOwner := root.GetDeletesForOwner(req.GetIntent())
+
+		if log.IsDebugEnabled() {
+			strSl := tree.Map(updatesOwner, d.cacheUpdateString)
+			log.Debugf("ds=%s intent=%s: Updates Owner:\n%s", d.Name(), req.GetIntent(), strings.Join(strSl, "\n"))
+			log.Debugf("ds=%s intent=%s: Deletes Owner:\n%s", d.Name(), req.GetIntent(), strings.Join(deletesOwner.StringSlice(), "\n"))
 		}
-	case false:
-		// The request intent is also stored in the cache
-		// in the format it was received in
-		err = d.saveRawIntent(ctx, req.GetIntent(), req)
+
+		err := d.cacheClient.Modify(ctx, d.Name(), &cache.Opts{
+			Store:    cachepb.Store_INTENDED,
+			Owner:    req.GetIntent(),
+			Priority: req.GetPriority(),
+		}, deletesOwner.ToStringSlice(), updatesOwner)
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("failed updating the intended store for %s: %w", d.Name(), err)
 		}
 	}
-
-	logger.Infof("ds=%s intent=%s: intent saved", req.GetName(), req.GetIntent())
-	return setIntentResponse, nil
+	d.setTreeSnapshot(root)
+	return nil
 }
 
-func pathIsKeyAsLeaf(p *sdcpb.Path) bool {
-	numPElem := len(p.GetElem())
-	if numPElem < 2 {
-		return false
+// writeBackConfig optimistically writes the changes applied to the device to the CONFIG store,
+// ahead of the next sync. A single, the last, update per path is written.
+func (d *Datastore) writeBackConfig(ctx context.Context, deletes tree.PathSlices, updates tree.UpdateSlice) error {
+	updates = updates.DeduplicateByPath()
+	updates.SortByPathThenPriority()
+	err := d.cacheClient.Modify(ctx, d.Name(), &cache.Opts{
+		Store: cachepb.Store_CONFIG,
+	}, deletes.ToStringSlice(), updates)
+	if err != nil {
+		return fmt.Errorf("failed updating the running config store for %s: %w", d.Name(), err)
 	}
+	return nil
+}
 
-	_, ok := p.GetElem()[numPElem-2].GetKey()[p.GetElem()[numPElem-1].GetName()]
-	return ok
+// storeRawIntent stores the request, as received, for new and updated intents and removes it for deleted ones.
+func (d *Datastore) storeRawIntent(ctx context.Context, req *sdcpb.SetIntentRequest) error {
+	if req.GetDelete() {
+		return d.deleteRawIntent(ctx, req.GetIntent(), req.GetPriority())
+	}
+	return d.saveRawIntent(ctx, req.GetIntent(), req)
 }
 
-func (d *Datastore) readStoreKeysMeta(ctx context.Context, store cachepb.Store) (map[string]tree.UpdateSlice, error) {
+func (d *Datastore) readStoreKeysMeta(ctx context.Context, store cachepb.Store) (*tree.StoreIndex, error) {
 	entryCh, err := d.cacheClient.GetKeys(ctx, d.config.Name, store)
 	if err != nil {
 		return nil, err
 	}
 
-	result := map[string]tree.UpdateSlice{}
+	result := tree.NewStoreIndex()
 	for {
 		select {
 		case <-ctx.Done():
@@ -383,12 +593,7 @@ func (d *Datastore) readStoreKeysMeta(ctx context.Context, store cachepb.Store)
 			if !ok {
 				return result, nil
 			}
-			key := strings.Join(e.GetPath(), tree.KeysIndexSep)
-			_, exists := result[key]
-			if !exists {
-				result[key] = tree.UpdateSlice{}
-			}
-			result[key] = append(result[key], e)
+			result.Add(e)
 		}
 	}
 }
@@ -729,7 +729,7 @@ func TestDatastore_populateTree(t *testing.T) {
 			tc := tree.NewTreeContext(tree.NewTreeSchemaCacheClient(dsName, d.cacheClient, d.getValidationClient()), tt.intentName)
 
 			// Populate the root tree
-			root, err := d.populateTree(ctx, reqOne, tc)
+			root, err := d.populateTree(ctx, tc, reqOne)
 			if err != nil {
 				t.Error(err)
 			}
@@ -764,7 +764,7 @@ func TestDatastore_populateTree(t *testing.T) {
 			}
 
 			// get the deletes that are meant to be send down towards the device
-			deletes, err := root.GetDeletes(true)
+			deletes, err := root.GetDeletes(tree.DeleteAggregationInstance)
 			if err != nil {
 				t.Error(err)
 			}
@@ -793,3 +793,115 @@ func TestDatastore_populateTree(t *testing.T) {
 		})
 	}
 }
+
+func TestDatastore_populateTree_multipleIntents(t *testing.T) {
+	prio10 := int32(10)
+	prio5 := int32(5)
+	owner1 := "owner1"
+	owner2 := "owner2"
+	dsName := "dev1"
+
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	// the stored version of owner1 sets ethernet-1/2, which its new version no longer does
+	intendedStoreUpdates := []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-1/2", "name"}, testhelper.GetStringTvProto(t, "ethernet-1/2"), prio10, owner1, 0),
+		cache.NewUpdate([]string{"interface", "ethernet-1/2", "description"}, testhelper.GetStringTvProto(t, "Owner1 Description"), prio10, owner1, 0),
+	}
+	testhelper.ConfigureCacheClientMock(t, cacheClient, intendedStoreUpdates, nil, nil, nil)
+
+	schemaClient, schema, err := testhelper.InitSDCIOSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Datastore{
+		config: &config.DatastoreConfig{
+			Name:   dsName,
+			Schema: schema,
+		},
+		sbi:          mocktarget.NewMockTarget(controller),
+		cacheClient:  cacheClient,
+		schemaClient: schemaClient,
+	}
+	ctx := context.Background()
+
+	intentReq := func(intent string, prio int32, description string) *sdcpb.SetIntentRequest {
+		i := &sdcio_schema.SdcioModel_Interface{
+			Name:        ygot.String("ethernet-1/1"),
+			Description: ygot.String(description),
+		}
+		jsonConf, err := ygot.EmitJSON(i, &ygot.EmitJSONConfig{Format: ygot.RFC7951})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &sdcpb.SetIntentRequest{
+			Name:     dsName,
+			Intent:   intent,
+			Priority: prio,
+			Update: []*sdcpb.Update{{
+				Path:  &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "interface"}}},
+				Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(jsonConf)}},
+			}},
+		}
+	}
+	reqs := []*sdcpb.SetIntentRequest{
+		intentReq(owner1, prio10, "Owner1 Description"),
+		intentReq(owner2, prio5, "Owner2 Description"),
+	}
+
+	tc := tree.NewTreeContext(tree.NewTreeSchemaCacheClient(dsName, d.cacheClient, d.getValidationClient()), "")
+	tc.SetActualOwners(owner1, owner2)
+	root, err := d.populateTree(ctx, tc, reqs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.FinishInsertionPhase()
+
+	// the values of the intent with the higher precedence are sent to the device
+	expectedModify := []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "name"}, testhelper.GetStringTvProto(t, "ethernet-1/1"), prio5, owner2, 0),
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "description"}, testhelper.GetStringTvProto(t, "Owner2 Description"), prio5, owner2, 0),
+	}
+	if diff := testhelper.DiffCacheUpdates(expectedModify, root.GetHighestPrecedence(true).ToCacheUpdateSlice()); diff != "" {
+		t.Errorf("root.GetHighestPrecedence(true) mismatch (-want +got):\n%s", diff)
+	}
+
+	// each owner carries its own values towards the intended store
+	for _, tt := range []struct {
+		owner           string
+		expectedUpdates []*cache.Update
+		expectedDeletes [][]string
+	}{
+		{
+			owner: owner1,
+			expectedUpdates: []*cache.Update{
+				cache.NewUpdate([]string{"interface", "ethernet-1/1", "name"}, testhelper.GetStringTvProto(t, "ethernet-1/1"), prio10, owner1, 0),
+				cache.NewUpdate([]string{"interface", "ethernet-1/1", "description"}, testhelper.GetStringTvProto(t, "Owner1 Description"), prio10, owner1, 0),
+			},
+			expectedDeletes: [][]string{
+				{"interface", "ethernet-1/2", "name"},
+				{"interface", "ethernet-1/2", "description"},
+			},
+		},
+		{
+			owner: owner2,
+			expectedUpdates: []*cache.Update{
+				cache.NewUpdate([]string{"interface", "ethernet-1/1", "name"}, testhelper.GetStringTvProto(t, "ethernet-1/1"), prio5, owner2, 0),
+				cache.NewUpdate([]string{"interface", "ethernet-1/1", "description"}, testhelper.GetStringTvProto(t, "Owner2 Description"), prio5, owner2, 0),
+			},
+		},
+	} {
+		if diff := testhelper.DiffCacheUpdates(tt.expectedUpdates, root.GetUpdatesForOwner(tt.owner)); diff != "" {
+			t.Errorf("root.GetUpdatesForOwner(%s) mismatch (-want +got):\n%s", tt.owner, diff)
+		}
+		if diff := testhelper.DiffDoubleStringPathSlice(tt.expectedDeletes, root.GetDeletesForOwner(tt.owner).ToStringSlice()); diff != "" {
+			t.Errorf("root.GetDeletesForOwner(%s) mismatch (-want +got):\n%s", tt.owner, diff)
+		}
+	}
+
+	// an intent must not be part of the batch more than once
+	_, err = d.populateTree(ctx, tree.NewTreeContext(tree.NewTreeSchemaCacheClient(dsName, d.cacheClient, d.getValidationClient()), ""), reqs[0], reqs[0])
+	if err == nil {
+		t.Errorf("populateTree() with a duplicate intent: expected an error")
+	}
+}
@@ -217,7 +217,7 @@ func TestDatastore_validateTree(t *testing.T) {
 			}
 
 			// Populate the root tree
-			root, err := d.populateTree(ctx, reqOne, tree.NewTreeContext(tree.NewTreeSchemaCacheClient(dsName, d.cacheClient, d.getValidationClient()), tt.intentName))
+			root, err := d.populateTree(ctx, tree.NewTreeContext(tree.NewTreeSchemaCacheClient(dsName, d.cacheClient, d.getValidationClient()), tt.intentName), reqOne)
 			if err != nil {
 				t.Error(err)
 			}
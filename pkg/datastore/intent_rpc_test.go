@@ -0,0 +1,194 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/mocks/mocktarget"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestDatastore_BlameConfig(t *testing.T) {
+	owner1 := "owner1"
+	owner2 := "owner2"
+	prio10 := int32(10)
+	prio5 := int32(5)
+
+	pathName := []string{"interface", "ethernet-1/1", "name"}
+	pathDesc := []string{"interface", "ethernet-1/1", "description"}
+	pathLeafref := []string{"leafref-optional"}
+
+	// owner2 overrides the description of owner1
+	intendedStoreUpdates := []*cache.Update{
+		cache.NewUpdate(pathName, testhelper.GetStringTvProto(t, "ethernet-1/1"), prio10, owner1, 0),
+		cache.NewUpdate(pathDesc, testhelper.GetStringTvProto(t, "Foo"), prio10, owner1, 0),
+		cache.NewUpdate(pathDesc, testhelper.GetStringTvProto(t, "Bar"), prio5, owner2, 1700000000),
+		cache.NewUpdate(pathLeafref, testhelper.GetStringTvProto(t, "ethernet-1/1"), prio10, owner1, 0),
+	}
+	// the device still carries the description of owner1, the leafref-optional is missing
+	// and the admin-state was configured out of band
+	runningStoreUpdates := []*cache.Update{
+		cache.NewUpdate(pathName, testhelper.GetStringTvProto(t, "ethernet-1/1"), tree.RunningValuesPrio, tree.RunningIntentName, 0),
+		cache.NewUpdate(pathDesc, testhelper.GetStringTvProto(t, "Foo"), tree.RunningValuesPrio, tree.RunningIntentName, 0),
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "admin-state"}, testhelper.GetStringTvProto(t, "enable"), tree.RunningValuesPrio, tree.RunningIntentName, 0),
+	}
+
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	testhelper.ConfigureCacheClientMock(t, cacheClient, intendedStoreUpdates, runningStoreUpdates, nil, nil)
+
+	schemaClient, schema, err := testhelper.InitSDCIOSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := &Datastore{
+		config: &config.DatastoreConfig{
+			Name:   "dev1",
+			Schema: schema,
+		},
+		sbi:          mocktarget.NewMockTarget(controller),
+		cacheClient:  cacheClient,
+		schemaClient: schemaClient,
+	}
+
+	got, err := d.BlameConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stringVal := func(s string) *sdcpb.TypedValue {
+		return &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: s}}
+	}
+
+	expected := &tree.BlameTreeElement{
+		Childs: []*tree.BlameTreeElement{
+			{
+				Name: "interface",
+				Childs: []*tree.BlameTreeElement{
+					{
+						Name: "ethernet-1/1",
+						Childs: []*tree.BlameTreeElement{
+							{
+								Name:           "description",
+								Owner:          owner2,
+								Priority:       prio5,
+								Timestamp:      1700000000,
+								Value:          stringVal("Bar"),
+								RunningDiffers: true,
+								RunningValue:   stringVal("Foo"),
+							},
+							{
+								Name:     "name",
+								Owner:    owner1,
+								Priority: prio10,
+								Value:    stringVal("ethernet-1/1"),
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:           "leafref-optional",
+				Owner:          owner1,
+				Priority:       prio10,
+				Value:          stringVal("ethernet-1/1"),
+				RunningDiffers: true,
+			},
+		},
+	}
+
+	if diff := cmp.Diff(expected, got, protocmp.Transform()); diff != "" {
+		t.Errorf("BlameConfig() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDatastore_listRawIntent(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	store := map[string]*cache.Update{}
+	configureIntentsStoreMock(cacheClient, store)
+
+	d := &Datastore{
+		config:      &config.DatastoreConfig{Name: "dev1"},
+		cacheClient: cacheClient,
+	}
+	ctx := context.Background()
+
+	// intent names may contain the characters previously used to encode the name and priority
+	for _, in := range []*sdcpb.Intent{
+		{Intent: "intent_1_10", Priority: 5},
+		{Intent: "intent/2,a", Priority: -1},
+		{Intent: "intent1", Priority: 10},
+	} {
+		req := &sdcpb.SetIntentRequest{Intent: in.GetIntent(), Priority: in.GetPriority()}
+		if err := d.saveRawIntent(ctx, in.GetIntent(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// an intent stored by a previous version is migrated
+	legacy := &sdcpb.SetIntentRequest{Intent: "legacy_intent", Priority: 20}
+	b, err := proto.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upd, err := cacheClient.NewUpdate(&sdcpb.Update{
+		Path:  &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: legacyRawIntentPrefix + "legacy_intent_20"}}},
+		Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_BytesVal{BytesVal: b}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store[legacyRawIntentPrefix+"legacy_intent_20"] = upd
+	if err = d.migrateRawIntents(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store[legacyRawIntentPrefix+"legacy_intent_20"]; ok {
+		t.Errorf("migrateRawIntents() kept the legacy key")
+	}
+
+	got, err := d.listRawIntent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*sdcpb.Intent{
+		{Intent: "intent/2,a", Priority: -1},
+		{Intent: "intent_1_10", Priority: 5},
+		{Intent: "intent1", Priority: 10},
+		{Intent: "legacy_intent", Priority: 20},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("listRawIntent() mismatch (-want +got):\n%s", diff)
+	}
+
+	req, err := d.getRawIntent(ctx, "legacy_intent", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(legacy, req, protocmp.Transform()); diff != "" {
+		t.Errorf("getRawIntent() mismatch (-want +got):\n%s", diff)
+	}
+}
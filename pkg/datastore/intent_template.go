@@ -0,0 +1,128 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"strings"
+	"text/template"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// templateVarTargetName is the template variable that holds the name of the datastore
+	templateVarTargetName = "targetName"
+)
+
+// SetVariables replaces the variables of the datastore that are available to intent templates.
+// They take precedence over the variables defined in the datastore config.
+func (d *Datastore) SetVariables(vars map[string]string) {
+	d.variablesMutex.Lock()
+	defer d.variablesMutex.Unlock()
+	d.variables = maps.Clone(vars)
+}
+
+// Variables returns the variables available to intent templates, the ones defined in the
+// datastore config merged with the ones set via SetVariables.
+func (d *Datastore) Variables() map[string]string {
+	d.variablesMutex.RLock()
+	defer d.variablesMutex.RUnlock()
+	result := make(map[string]string, len(d.config.Variables)+len(d.variables))
+	maps.Copy(result, d.config.Variables)
+	maps.Copy(result, d.variables)
+	return result
+}
+
+// templateData returns the data intent templates are executed with.
+func (d *Datastore) templateData() map[string]string {
+	data := d.Variables()
+	data[templateVarTargetName] = d.Name()
+	return data
+}
+
+// renderTemplates resolves the variables contained in the values of the updates, e.g. {{ .targetName }}.
+// Updates without template actions are returned as is, the given updates are not modified.
+func (d *Datastore) renderTemplates(upds []*sdcpb.Update) ([]*sdcpb.Update, error) {
+	var data map[string]string
+	result := make([]*sdcpb.Update, 0, len(upds))
+	for _, upd := range upds {
+		tmpl, ok := updateTemplate(upd)
+		if !ok {
+			result = append(result, upd)
+			continue
+		}
+		if data == nil {
+			data = d.templateData()
+		}
+		rendered, err := renderTemplate(tmpl, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed rendering the value of %s: %w", upd.GetPath(), err)
+		}
+
+		upd = proto.Clone(upd).(*sdcpb.Update)
+		switch v := upd.GetValue().GetValue().(type) {
+		case *sdcpb.TypedValue_JsonVal:
+			v.JsonVal = []byte(rendered)
+		case *sdcpb.TypedValue_JsonIetfVal:
+			v.JsonIetfVal = []byte(rendered)
+		case *sdcpb.TypedValue_StringVal:
+			v.StringVal = rendered
+		}
+		result = append(result, upd)
+	}
+	return result, nil
+}
+
+// updateTemplate returns the value of the update as a template, false if it contains no template actions.
+func updateTemplate(upd *sdcpb.Update) (string, bool) {
+	var tmpl string
+	switch v := upd.GetValue().GetValue().(type) {
+	case *sdcpb.TypedValue_JsonVal:
+		tmpl = string(v.JsonVal)
+	case *sdcpb.TypedValue_JsonIetfVal:
+		tmpl = string(v.JsonIetfVal)
+	case *sdcpb.TypedValue_StringVal:
+		tmpl = v.StringVal
+	}
+	return tmpl, strings.Contains(tmpl, "{{")
+}
+
+// hasTemplates returns true if any of the update values contains template actions.
+func hasTemplates(upds []*sdcpb.Update) bool {
+	for _, upd := range upds {
+		if _, ok := updateTemplate(upd); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// renderTemplate executes the template with the data. Referencing an undefined variable is an error.
+func renderTemplate(tmpl string, data map[string]string) (string, error) {
+	t, err := template.New("intent").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	err = t.Execute(buf, data)
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
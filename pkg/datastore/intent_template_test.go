@@ -0,0 +1,107 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_renderTemplates(t *testing.T) {
+	jsonUpd := func(j string) *sdcpb.Update {
+		return &sdcpb.Update{
+			Path:  &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "system"}}},
+			Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(j)}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		cfgVars map[string]string
+		vars    map[string]string
+		upd     *sdcpb.Update
+		want    *sdcpb.Update
+		wantErr bool
+	}{
+		{
+			name: "no template",
+			upd:  jsonUpd(`{"name": "static"}`),
+			want: jsonUpd(`{"name": "static"}`),
+		},
+		{
+			name: "target name",
+			upd:  jsonUpd(`{"name": "{{ .targetName }}"}`),
+			want: jsonUpd(`{"name": "dev1"}`),
+		},
+		{
+			name:    "config and runtime variables",
+			cfgVars: map[string]string{"site": "site1", "asn": "65000"},
+			vars:    map[string]string{"asn": "65001"},
+			upd:     jsonUpd(`{"site": "{{ .site }}", "asn": {{ .asn }}}`),
+			want:    jsonUpd(`{"site": "site1", "asn": 65001}`),
+		},
+		{
+			name: "string value",
+			upd: &sdcpb.Update{
+				Path:  &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "description"}}},
+				Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "managed {{ .targetName }}"}},
+			},
+			want: &sdcpb.Update{
+				Path:  &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "description"}}},
+				Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "managed dev1"}},
+			},
+		},
+		{
+			name:    "undefined variable",
+			upd:     jsonUpd(`{"name": "{{ .undefined }}"}`),
+			wantErr: true,
+		},
+		{
+			name:    "invalid template",
+			upd:     jsonUpd(`{"name": "{{ .targetName "}`),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Datastore{
+				config: &config.DatastoreConfig{
+					Name:      "dev1",
+					Variables: tt.cfgVars,
+				},
+			}
+			d.SetVariables(tt.vars)
+
+			orig := proto.Clone(tt.upd)
+			got, err := d.renderTemplates([]*sdcpb.Update{tt.upd})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("renderTemplates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !proto.Equal(got[0], tt.want) {
+				t.Errorf("renderTemplates() = %v, want %v", got[0], tt.want)
+			}
+			if !proto.Equal(tt.upd, orig) {
+				t.Errorf("renderTemplates() modified the given update")
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the phases of a SetIntent that are bounded by the configured intent timeouts
+const (
+	intentPhaseSchema     = "schema"
+	intentPhasePopulate   = "populate"
+	intentPhaseValidation = "validation"
+	intentPhaseApply      = "apply"
+)
+
+// intentPhaseContext returns the context of the given SetIntent phase, bounded by the configured timeout of the phase.
+func (d *Datastore) intentPhaseContext(ctx context.Context, phase string) (context.Context, context.CancelFunc) {
+	var timeout time.Duration
+	if t := d.config.IntentTimeouts; t != nil {
+		switch phase {
+		case intentPhaseSchema:
+			timeout = t.Schema
+		case intentPhasePopulate:
+			timeout = t.Populate
+		case intentPhaseValidation:
+			timeout = t.Validation
+		case intentPhaseApply:
+			timeout = t.Apply
+		}
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// intentPhaseError returns a DeadlineExceeded error naming the phase, if the context of the phase expired,
+// the given error otherwise.
+func intentPhaseError(ctx context.Context, phase string, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if err == nil {
+			err = ctx.Err()
+		}
+		return status.Errorf(codes.DeadlineExceeded, "intent %s phase timed out: %v", phase, err)
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_intentPhaseContext(t *testing.T) {
+	d := &Datastore{
+		config: &config.DatastoreConfig{
+			Name:           "dev1",
+			IntentTimeouts: &config.IntentTimeouts{Validation: time.Millisecond},
+		},
+	}
+
+	// a phase without timeout is not bounded
+	ctx, cancel := d.intentPhaseContext(context.TODO(), intentPhaseApply)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("expected no deadline for the apply phase")
+	}
+	if err := intentPhaseError(ctx, intentPhaseApply, errors.New("failed")); status.Code(err) == codes.DeadlineExceeded {
+		t.Errorf("expected the error to be returned as is, got %v", err)
+	}
+
+	ctx, cancel = d.intentPhaseContext(context.TODO(), intentPhaseValidation)
+	defer cancel()
+	<-ctx.Done()
+	if err := intentPhaseError(ctx, intentPhaseValidation, nil); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("expected a DeadlineExceeded error, got %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	"github.com/sdcio/data-server/pkg/datastore/target"
+)
+
+// ErrTransactionAborted is returned by the participants of a transaction that was aborted,
+// since another participant failed to prepare or to commit its changes.
+var ErrTransactionAborted = errors.New("transaction aborted")
+
+// Transaction coordinates a two-phase commit of intents across datastores. Every participant
+// prepares its changes, validating the intent and staging the changes on the device where the
+// target supports it, and votes. The changes are only committed once all participants voted,
+// any participant failing before voting aborts the transaction and the staged changes are discarded.
+// Targets supporting confirmed commits commit on probation and only confirm the commit once all
+// participants committed, otherwise the devices roll the changes back.
+type Transaction struct {
+	prepare *txBarrier
+	confirm *txBarrier
+}
+
+// NewTransaction returns a Transaction for the given number of participants.
+func NewTransaction(participants int) *Transaction {
+	return &Transaction{
+		prepare: newTxBarrier(participants),
+		confirm: newTxBarrier(participants),
+	}
+}
+
+// Join returns a new participant of the transaction, to be passed to SetIntentWithOpts via the SetIntentOpts.
+func (t *Transaction) Join() *TransactionParticipant {
+	return &TransactionParticipant{tx: t}
+}
+
+// Abort aborts the transaction with the given cause, unless it is already decided.
+func (t *Transaction) Abort(cause error) {
+	t.prepare.abort(cause)
+	t.confirm.abort(cause)
+}
+
+// Committed returns true if all participants prepared their changes and committing them got started.
+func (t *Transaction) Committed() bool {
+	return t.prepare.passed()
+}
+
+// txBarrier is a decision of a Transaction, it passes once all participants voted
+// and fails once any participant aborts before.
+type txBarrier struct {
+	m            sync.Mutex
+	participants int
+	votes        int
+	decided      chan struct{}
+	pass         bool
+	cause        error
+}
+
+func newTxBarrier(participants int) *txBarrier {
+	return &txBarrier{
+		participants: participants,
+		decided:      make(chan struct{}),
+	}
+}
+
+func (b *txBarrier) abort(cause error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	if b.isDecided() {
+		return
+	}
+	b.cause = cause
+	close(b.decided)
+}
+
+func (b *txBarrier) vote() {
+	b.m.Lock()
+	defer b.m.Unlock()
+	if b.isDecided() {
+		return
+	}
+	b.votes++
+	if b.votes == b.participants {
+		b.pass = true
+		close(b.decided)
+	}
+}
+
+func (b *txBarrier) passed() bool {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.pass
+}
+
+// wait blocks until the barrier is decided, returning ErrTransactionAborted if it failed.
+// A participant that gives up waiting aborts the barrier.
+func (b *txBarrier) wait(ctx context.Context) error {
+	select {
+	case <-b.decided:
+	case <-ctx.Done():
+		b.abort(ctx.Err())
+		<-b.decided
+	}
+	b.m.Lock()
+	defer b.m.Unlock()
+	if !b.pass {
+		return fmt.Errorf("%w: %v", ErrTransactionAborted, b.cause)
+	}
+	return nil
+}
+
+// isDecided must be called with the lock held.
+func (b *txBarrier) isDecided() bool {
+	select {
+	case <-b.decided:
+		return true
+	default:
+		return false
+	}
+}
+
+// TransactionParticipant is the handle of a single intent of a Transaction.
+type TransactionParticipant struct {
+	tx           *Transaction
+	votedPrepare bool
+	votedCommit  bool
+}
+
+// prepared votes for the commit of the transaction and waits for the decision.
+func (p *TransactionParticipant) prepared(ctx context.Context) error {
+	if !p.votedPrepare {
+		p.votedPrepare = true
+		p.tx.prepare.vote()
+	}
+	return p.tx.prepare.wait(ctx)
+}
+
+// committed reports the commit of the changes, waiting for all participants to commit
+// if wait is set, e.g. to confirm or cancel a confirmed commit.
+func (p *TransactionParticipant) committed(ctx context.Context, wait bool) error {
+	if !p.votedCommit {
+		p.votedCommit = true
+		p.tx.confirm.vote()
+	}
+	if !wait {
+		return nil
+	}
+	return p.tx.confirm.wait(ctx)
+}
+
+// Done has to be called once SetIntentWithOpts of the participant returned. A participant that failed
+// before voting aborts the transaction, a participant that succeeded without voting, e.g. since the
+// intent was unchanged or a dry-run, has nothing to commit and votes for the commit of the others.
+func (p *TransactionParticipant) Done(err error) {
+	if err != nil {
+		if !p.votedPrepare {
+			p.tx.prepare.abort(err)
+		}
+		if !p.votedCommit {
+			p.tx.confirm.abort(err)
+		}
+	} else {
+		if !p.votedPrepare {
+			p.tx.prepare.vote()
+		}
+		if !p.votedCommit {
+			p.tx.confirm.vote()
+		}
+	}
+	p.votedPrepare = true
+	p.votedCommit = true
+}
+
+// applyIntentInTransaction sends the changes of the source to the target once all participants of the
+// transaction prepared their changes. Targets that support it stage the changes before the vote, such that
+// the device validates them, and commit or discard them depending on the decision. Targets that support
+// confirmed commits only confirm the commit once all participants committed their changes.
+func (d *Datastore) applyIntentInTransaction(ctx context.Context, p *TransactionParticipant, intentName string, candidateName string, source target.TargetSource) (*sdcpb.SetDataResponse, error) {
+	if candidateName == "" {
+		return nil, fmt.Errorf("missing candidate name")
+	}
+	if d.sbi == nil {
+		return nil, fmt.Errorf("%s is not connected", d.config.Name)
+	}
+	preparer, ok := d.sbi.(target.Preparer)
+	if !ok || !preparer.CanPrepare() || d.config.ApplyMode == applyModeFullReplace {
+		// the changes cannot be staged on the device, they are applied once all participants are prepared
+		if err := p.prepared(ctx); err != nil {
+			return nil, err
+		}
+		rsp, err := d.applyIntent(ctx, intentName, candidateName, source)
+		if err != nil {
+			return nil, err
+		}
+		return rsp, p.committed(ctx, false)
+	}
+	defer d.pauseSyncForApply()()
+
+	start := time.Now()
+	rsp, err := preparer.Prepare(ctx, source)
+	if err != nil {
+		d.setLastApply(intentName, start, err)
+		d.recordJournal(ctx, intentName, source, start, rsp, err)
+		return nil, err
+	}
+	if err = p.prepared(ctx); err != nil {
+		if derr := preparer.Discard(context.WithoutCancel(ctx)); derr != nil {
+			log.Errorf("datastore %s failed discarding the prepared changes of intent %s: %v", d.config.Name, intentName, derr)
+		}
+		return nil, err
+	}
+	err = d.commitPrepared(ctx, p, preparer, intentName)
+	d.setLastApply(intentName, start, err)
+	d.recordJournal(ctx, intentName, source, start, rsp, err)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("datastore %s/%s SetResponse from SBI: %v", d.config.Name, candidateName, rsp)
+	return rsp, nil
+}
+
+// commitPrepared commits the prepared changes. Via a confirmed commit, if the target supports it, that is only
+// confirmed once all participants committed their changes and cancelled otherwise, rolling the changes back.
+func (d *Datastore) commitPrepared(ctx context.Context, p *TransactionParticipant, preparer target.Preparer, intentName string) error {
+	confirmer, ok := d.sbi.(target.ConfirmedCommitter)
+	if !ok {
+		if err := preparer.Commit(ctx); err != nil {
+			return err
+		}
+		return p.committed(ctx, false)
+	}
+	if err := confirmer.CommitConfirmed(ctx); err != nil {
+		return err
+	}
+	if err := p.committed(ctx, true); err != nil {
+		if cerr := confirmer.CancelCommit(context.WithoutCancel(ctx)); cerr != nil {
+			// the device rolls back the changes once the confirm-timeout expires
+			log.Errorf("datastore %s failed cancelling the confirmed commit of intent %s: %v", d.config.Name, intentName, cerr)
+		}
+		return err
+	}
+	return confirmer.ConfirmCommit(ctx)
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTransaction(t *testing.T) {
+	tests := []struct {
+		name string
+		// the error each participant returns before voting, nil participants prepare their changes
+		failures      []error
+		wantCommitted bool
+	}{
+		{
+			name:          "all participants prepared",
+			failures:      []error{nil, nil, nil},
+			wantCommitted: true,
+		},
+		{
+			name:          "single participant",
+			failures:      []error{nil},
+			wantCommitted: true,
+		},
+		{
+			name:     "one participant fails to prepare",
+			failures: []error{nil, errors.New("validation failed"), nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := NewTransaction(len(tt.failures))
+			errs := make([]error, len(tt.failures))
+			wg := &sync.WaitGroup{}
+			for i, failure := range tt.failures {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					p := tx.Join()
+					if failure != nil {
+						p.Done(failure)
+						return
+					}
+					errs[i] = p.prepared(context.TODO())
+					p.Done(errs[i])
+				}()
+			}
+			wg.Wait()
+
+			if tx.Committed() != tt.wantCommitted {
+				t.Fatalf("expected committed %t, got %t", tt.wantCommitted, tx.Committed())
+			}
+			for i, failure := range tt.failures {
+				if failure != nil {
+					continue
+				}
+				if tt.wantCommitted && errs[i] != nil {
+					t.Errorf("participant %d: unexpected error %v", i, errs[i])
+				}
+				if !tt.wantCommitted && !errors.Is(errs[i], ErrTransactionAborted) {
+					t.Errorf("participant %d: expected the transaction to be aborted, got %v", i, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTransaction_DoneWithoutVote(t *testing.T) {
+	tx := NewTransaction(2)
+	// e.g. an unchanged intent, it has nothing to prepare but must not block the other participant
+	tx.Join().Done(nil)
+	if err := tx.Join().prepared(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+	if !tx.Committed() {
+		t.Errorf("expected the transaction to be committed")
+	}
+}
+
+func TestTransaction_WaitCancelled(t *testing.T) {
+	tx := NewTransaction(2)
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	err := tx.Join().prepared(ctx)
+	if !errors.Is(err, ErrTransactionAborted) {
+		t.Fatalf("expected the transaction to be aborted, got %v", err)
+	}
+	// the late vote of the other participant does not commit the aborted transaction
+	if err = tx.Join().prepared(context.TODO()); !errors.Is(err, ErrTransactionAborted) {
+		t.Errorf("expected the transaction to be aborted, got %v", err)
+	}
+	if tx.Committed() {
+		t.Errorf("expected the transaction not to be committed")
+	}
+}
+
+func TestTransaction_CommitFailure(t *testing.T) {
+	tx := NewTransaction(2)
+	p1, p2 := tx.Join(), tx.Join()
+	errs := make([]error, 2)
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if errs[0] = p1.prepared(context.TODO()); errs[0] != nil {
+			return
+		}
+		// e.g. a confirmed commit, that is only confirmed once all participants committed
+		errs[0] = p1.committed(context.TODO(), true)
+	}()
+	go func() {
+		defer wg.Done()
+		if errs[1] = p2.prepared(context.TODO()); errs[1] != nil {
+			return
+		}
+		// the commit on the device fails
+		p2.Done(errors.New("commit failed"))
+	}()
+	wg.Wait()
+
+	if errs[1] != nil {
+		t.Fatalf("unexpected prepare error %v", errs[1])
+	}
+	if !tx.Committed() {
+		t.Errorf("expected the commit to be started")
+	}
+	if !errors.Is(errs[0], ErrTransactionAborted) {
+		t.Errorf("expected the confirmation to be aborted, got %v", errs[0])
+	}
+}
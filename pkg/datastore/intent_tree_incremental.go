@@ -0,0 +1,137 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sdcio/data-server/pkg/tree"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+// intentTreeBase is the tree of the last SetIntent, kept if the incremental tree is enabled. The next SetIntent
+// takes it over, applying the modifications of running since and its intent, instead of building the tree from
+// the intended store and running. It is only used as long as the intended store was not modified by other
+// operations than the SetIntents working on it. As the tree is modified in place, a SetIntent that fails or is
+// a dry run leaves no tree behind and the next SetIntent builds a new one.
+type intentTreeBase struct {
+	m    sync.Mutex
+	root *tree.RootEntry
+	// the CONFIG store modifications and the number of INTENDED store modifications the tree reflects
+	configSeq    uint64
+	intendedMods uint64
+}
+
+// intentTreeRound is the origin of the tree of a SetIntent, see populateIntentTree.
+type intentTreeRound struct {
+	configSeq    uint64
+	intendedMods uint64
+}
+
+// populateIntentTree returns the tree of a SetIntent, merging the intent with the intended store and running.
+// The tree is derived from the tree of the last SetIntent if possible, see intentTreeBase.
+// The returned round is nil if the incremental tree is disabled or the request is a dry run.
+func (d *Datastore) populateIntentTree(ctx context.Context, tc *tree.TreeContext, req *sdcpb.SetIntentRequest) (*tree.RootEntry, *intentTreeRound, error) {
+	// dry runs leave the tree of the last SetIntent to the next one
+	if d.changeLog == nil || req.GetDryRun() {
+		root, err := d.populateTree(ctx, tc, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		return root, nil, d.populateTreeWithRunning(ctx, tc, root)
+	}
+
+	root, round, err := d.takeIntentTree(ctx, tc)
+	if err != nil {
+		return nil, nil, err
+	}
+	fresh := root == nil
+	if fresh {
+		// the intended store and running are read after noting the modifications of the stores,
+		// modifications applied meanwhile are taken over again by the next SetIntent
+		round = &intentTreeRound{
+			configSeq:    d.changeLog.ConfigSeq(d.Name()),
+			intendedMods: d.changeLog.IntendedModifications(d.Name()),
+		}
+		root, err = tree.NewTreeRoot(ctx, tc)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	err = d.addIntentsToTree(ctx, tc, root, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fresh {
+		err = d.populateTreeWithRunning(ctx, tc, root)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return root, round, nil
+}
+
+// takeIntentTree takes over the tree of the last SetIntent and applies the modifications of running to it.
+// It returns nil if there is no tree or it is outdated.
+func (d *Datastore) takeIntentTree(ctx context.Context, tc *tree.TreeContext) (*tree.RootEntry, *intentTreeRound, error) {
+	d.intentTree.m.Lock()
+	root, configSeq, intendedMods := d.intentTree.root, d.intentTree.configSeq, d.intentTree.intendedMods
+	d.intentTree.root = nil
+	d.intentTree.m.Unlock()
+
+	if root == nil || intendedMods != d.changeLog.IntendedModifications(d.Name()) {
+		return nil, nil, nil
+	}
+	changes, seq, ok := d.changeLog.ConfigChanges(d.Name(), configSeq)
+	if !ok {
+		log.Debugf("ds=%s: running changes since the last intent are unknown, rebuilding the tree", d.Name())
+		return nil, nil, nil
+	}
+
+	err := root.Reuse(tc)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, c := range changes {
+		err = root.ApplyRunningChanges(ctx, c.Deletes, c.Updates)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return root, &intentTreeRound{configSeq: seq, intendedMods: intendedMods}, nil
+}
+
+// keepIntentTree keeps the tree of a SetIntent, once its changes are stored in the intended store, for the
+// next SetIntent. The tree is dropped if the intended store was modified by other operations meanwhile.
+func (d *Datastore) keepIntentTree(root *tree.RootEntry, round *intentTreeRound) {
+	if round == nil {
+		return
+	}
+	d.intentTree.m.Lock()
+	defer d.intentTree.m.Unlock()
+
+	// the SetIntent modified the intended store once
+	intendedMods := d.changeLog.IntendedModifications(d.Name())
+	if intendedMods != round.intendedMods+1 {
+		d.intentTree.root = nil
+		return
+	}
+	root.Settle()
+	d.intentTree.root = root
+	d.intentTree.configSeq = round.configSeq
+	d.intentTree.intendedMods = intendedMods
+	d.changeLog.Acknowledge(d.Name(), round.configSeq)
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sdcio/cache/proto/cachepb"
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/mocks/mocktarget"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDatastore_IncrementalTree(t *testing.T) {
+	dsName := "dev1"
+	intent := "intent1"
+	description := []string{"interface", "ethernet-1/1", "description"}
+	name := []string{"interface", "ethernet-1/1", "name"}
+
+	// the stores hold the intent setting the description
+	intended := []*cache.Update{
+		cache.NewUpdate(name, testhelper.GetStringTvProto(t, "ethernet-1/1"), 10, intent, 0),
+		cache.NewUpdate(description, testhelper.GetStringTvProto(t, "first"), 10, intent, 0),
+	}
+	running := []*cache.Update{
+		cache.NewUpdate(name, testhelper.GetStringTvProto(t, "ethernet-1/1"), tree.RunningValuesPrio, tree.RunningIntentName, 0),
+		cache.NewUpdate(description, testhelper.GetStringTvProto(t, "first"), tree.RunningValuesPrio, tree.RunningIntentName, 0),
+	}
+
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	cacheClient.EXPECT().Modify(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	testhelper.ConfigureCacheClientMock(t, cacheClient, intended, running, nil, nil)
+
+	schemaClient, schema, err := testhelper.InitSDCIOSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	changeLog := cache.NewChangeLogClient(cacheClient)
+	d := &Datastore{
+		config: &config.DatastoreConfig{
+			Name:            dsName,
+			Schema:          schema,
+			IncrementalTree: true,
+		},
+		sbi:          mocktarget.NewMockTarget(controller),
+		cacheClient:  changeLog,
+		changeLog:    changeLog,
+		schemaClient: schemaClient,
+	}
+	ctx := context.Background()
+
+	newReq := func(description string) *sdcpb.SetIntentRequest {
+		return &sdcpb.SetIntentRequest{
+			Name:     dsName,
+			Intent:   intent,
+			Priority: 10,
+			Update: []*sdcpb.Update{{
+				Path:  &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}, {Name: "description"}}},
+				Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: description}},
+			}},
+		}
+	}
+	// setIntent computes the tree of the intent and stores it like SetIntentUpdate does
+	setIntent := func(req *sdcpb.SetIntentRequest) {
+		root, round, err := d.populateIntentTree(ctx, d.newIntentTreeContext(intent), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root.FinishInsertionPhase()
+		if err = d.updateIntendedStore(ctx, root, req); err != nil {
+			t.Fatal(err)
+		}
+		d.keepIntentTree(root, round)
+	}
+	kept := func() bool {
+		d.intentTree.m.Lock()
+		defer d.intentTree.m.Unlock()
+		return d.intentTree.root != nil
+	}
+
+	// the intent is re-applied unchanged, the tree is built and kept
+	setIntent(newReq("first"))
+	if !kept() {
+		t.Fatal("expected the tree to be kept")
+	}
+
+	// dry runs leave the tree alone
+	dryRun := newReq("dry")
+	dryRun.DryRun = true
+	if _, round, err := d.populateIntentTree(ctx, d.newIntentTreeContext(intent), dryRun); err != nil || round != nil || !kept() {
+		t.Fatalf("expected the dry run not to take the tree, err: %v", err)
+	}
+
+	// the next intent is applied to the kept tree, with the same result as on a new tree
+	req := newReq("second")
+	tc := d.newIntentTreeContext(intent)
+	incremental, round, err := d.populateIntentTree(ctx, tc, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if round == nil || kept() {
+		t.Fatal("expected the tree to be taken over")
+	}
+	incremental.FinishInsertionPhase()
+
+	tc = d.newIntentTreeContext(intent)
+	fresh, err := d.populateTree(ctx, tc, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = d.populateTreeWithRunning(ctx, tc, fresh); err != nil {
+		t.Fatal(err)
+	}
+	fresh.FinishInsertionPhase()
+
+	if len(incrementalTreeUpdates(incremental)) != 1 {
+		t.Errorf("expected the description update, got %v", incrementalTreeUpdates(incremental))
+	}
+	if diff := cmp.Diff(incrementalTreeUpdates(fresh), incrementalTreeUpdates(incremental)); diff != "" {
+		t.Errorf("updates mismatch (-fresh +incremental):\n%s", diff)
+	}
+	if diff := cmp.Diff(fresh.GetUpdatesForOwner(intent), incremental.GetUpdatesForOwner(intent), cmp.Comparer(func(a, b *cache.Update) bool { return a.EqualSkipPath(b) })); diff != "" {
+		t.Errorf("intended updates mismatch (-fresh +incremental):\n%s", diff)
+	}
+	if err = d.updateIntendedStore(ctx, incremental, req); err != nil {
+		t.Fatal(err)
+	}
+	d.keepIntentTree(incremental, round)
+
+	// the modifications of running are taken over
+	err = d.cacheClient.Modify(ctx, dsName, &cache.Opts{Store: cachepb.Store_CONFIG}, nil, []*cache.Update{
+		cache.NewUpdate(description, testhelper.GetStringTvProto(t, "device"), 0, "", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _, err := d.takeIntentTree(ctx, d.newIntentTreeContext(intent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root == nil {
+		t.Fatal("expected the tree to be kept")
+	}
+	runningDescription := ""
+	for _, u := range root.GetIntendedForOwner(tree.RunningIntentName) {
+		if !slices.Equal(u.GetPath(), description) {
+			continue
+		}
+		tv, err := u.Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		runningDescription = tv.GetStringVal()
+	}
+	if runningDescription != "device" {
+		t.Errorf("expected the running description %q, got %q", "device", runningDescription)
+	}
+	// the tree is not kept unless the SetIntent taking it over succeeds
+	if kept() {
+		t.Error("expected the taken tree not to be kept")
+	}
+
+	// the tree is dropped once the intended store is modified otherwise
+	setIntent(newReq("third"))
+	err = d.cacheClient.Modify(ctx, dsName, &cache.Opts{Store: cachepb.Store_INTENDED, Owner: "intent2"}, [][]string{description}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root, _, _ := d.takeIntentTree(ctx, d.newIntentTreeContext(intent)); root != nil {
+		t.Error("expected the tree to be outdated by the modification of the intended store")
+	}
+
+	// and rebuilt by the next intent
+	setIntent(newReq("fourth"))
+	if !kept() {
+		t.Fatal("expected the tree to be kept")
+	}
+	d.InvalidateIntendedIndex()
+	if root, _, _ := d.takeIntentTree(ctx, d.newIntentTreeContext(intent)); root != nil {
+		t.Error("expected the tree to be outdated by the invalidation")
+	}
+}
+
+// incrementalTreeUpdates returns the sorted updates towards the device of the tree.
+func incrementalTreeUpdates(root *tree.RootEntry) []string {
+	result := []string{}
+	for _, le := range root.GetUpdatesDivergingFromRunning() {
+		result = append(result, le.String())
+	}
+	slices.Sort(result)
+	return result
+}
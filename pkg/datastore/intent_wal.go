@@ -0,0 +1,193 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/pkg/cache"
+)
+
+// walPrefix is the prefix of the write-ahead log records in the intents store.
+var walPrefix = "__wal__"
+
+const (
+	walRecoveryReplay   = "replay"
+	walRecoveryRollback = "rollback"
+)
+
+// walRecord is the write-ahead log record of a SetIntentRequest, persisted before the request is processed
+// and removed once it completed. Records remaining on startup belong to operations interrupted by a crash.
+type walRecord struct {
+	Timestamp int64 `json:"timestamp"`
+	// Request is the accepted SetIntentRequest
+	Request []byte `json:"request"`
+	// Previous is the stored version of the intent the request replaces, if any
+	Previous []byte `json:"previous,omitempty"`
+	Replace  bool   `json:"replace,omitempty"`
+	Force    bool   `json:"force,omitempty"`
+}
+
+func walKey(intentName string) string {
+	return walPrefix + intentName
+}
+
+// writeWAL records the request in the write-ahead log, along with the stored version of the intent.
+func (d *Datastore) writeWAL(ctx context.Context, req *sdcpb.SetIntentRequest, opts *SetIntentOpts) error {
+	b, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	rec := &walRecord{
+		Timestamp: time.Now().UnixNano(),
+		Request:   b,
+	}
+	if opts != nil {
+		rec.Replace = opts.Replace
+		rec.Force = opts.Force
+	}
+	prev, err := d.getRawIntent(ctx, req.GetIntent(), req.GetPriority())
+	switch {
+	case errors.Is(err, ErrIntentNotFound):
+	case err != nil:
+		return err
+	default:
+		rec.Previous, err = proto.Marshal(prev)
+		if err != nil {
+			return err
+		}
+	}
+	b, err = json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	upd, err := d.cacheClient.NewUpdate(
+		&sdcpb.Update{
+			Path: &sdcpb.Path{
+				Elem: []*sdcpb.PathElem{{Name: walKey(req.GetIntent())}},
+			},
+			Value: &sdcpb.TypedValue{
+				Value: &sdcpb.TypedValue_BytesVal{BytesVal: b},
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return d.cacheClient.Modify(ctx, d.config.Name,
+		&cache.Opts{
+			Store: cachepb.Store_INTENTS,
+		},
+		nil,
+		[]*cache.Update{upd})
+}
+
+// deleteWAL removes the write-ahead log record of the intent, once its request completed.
+func (d *Datastore) deleteWAL(ctx context.Context, intentName string) error {
+	return d.cacheClient.Modify(ctx, d.config.Name,
+		&cache.Opts{
+			Store: cachepb.Store_INTENTS,
+		},
+		[][]string{{walKey(intentName)}},
+		nil)
+}
+
+// readWAL returns the write-ahead log records remaining in the intents store.
+func (d *Datastore) readWAL(ctx context.Context) ([]*walRecord, error) {
+	upds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store:    cachepb.Store_INTENTS,
+		KeysOnly: true,
+	}, [][]string{{"*"}}, 0)
+	paths := make([][]string, 0, len(upds))
+	for _, upd := range upds {
+		if len(upd.GetPath()) == 0 || !strings.HasPrefix(upd.GetPath()[0], walPrefix) {
+			continue
+		}
+		paths = append(paths, []string{upd.GetPath()[0]})
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	upds = d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store: cachepb.Store_INTENTS,
+	}, paths, 0)
+
+	result := make([]*walRecord, 0, len(upds))
+	for _, upd := range upds {
+		val, err := upd.Value()
+		if err != nil {
+			return nil, err
+		}
+		rec := &walRecord{}
+		if err = json.Unmarshal(val.GetBytesVal(), rec); err != nil {
+			return nil, err
+		}
+		result = append(result, rec)
+	}
+	return result, nil
+}
+
+// recoverWAL recovers the operations that were interrupted by a crash, by applying the interrupted request
+// again or by restoring the previous version of the intent, depending on the configured recovery.
+// Records that fail to recover are kept, such that they are retried on the next start.
+func (d *Datastore) recoverWAL(ctx context.Context) {
+	recs, err := d.readWAL(ctx)
+	if err != nil {
+		log.Errorf("datastore %s: failed reading the write-ahead log: %v", d.Name(), err)
+		return
+	}
+	for _, rec := range recs {
+		req := &sdcpb.SetIntentRequest{}
+		if err = proto.Unmarshal(rec.Request, req); err != nil {
+			log.Errorf("datastore %s: malformed write-ahead log record: %v", d.Name(), err)
+			continue
+		}
+		opts := &SetIntentOpts{Replace: rec.Replace, Force: rec.Force}
+
+		if d.config.WAL.Recovery == walRecoveryRollback {
+			// the options of the previous version are not known
+			opts = nil
+			if rec.Previous != nil {
+				prev := &sdcpb.SetIntentRequest{}
+				if err = proto.Unmarshal(rec.Previous, prev); err != nil {
+					log.Errorf("datastore %s: malformed write-ahead log record: %v", d.Name(), err)
+					continue
+				}
+				req = prev
+			} else {
+				// the intent did not exist before, hence it is removed
+				req = &sdcpb.SetIntentRequest{
+					Name:     req.GetName(),
+					Intent:   req.GetIntent(),
+					Priority: req.GetPriority(),
+					Delete:   true,
+				}
+			}
+		}
+		log.Infof("datastore %s: recovering interrupted operation of intent %s via %s", d.Name(), req.GetIntent(), d.config.WAL.Recovery)
+		_, err = d.SetIntentWithOpts(ctx, req, opts)
+		if err != nil {
+			log.Errorf("datastore %s: failed recovering interrupted operation of intent %s: %v", d.Name(), req.GetIntent(), err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_WAL(t *testing.T) {
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	store := map[string]*cache.Update{}
+	configureIntentsStoreMock(cacheClient, store)
+
+	d := &Datastore{
+		config:      &config.DatastoreConfig{Name: "dev1", WAL: &config.WAL{Recovery: walRecoveryReplay}},
+		cacheClient: cacheClient,
+	}
+	ctx := context.Background()
+
+	prev := &sdcpb.SetIntentRequest{Name: "dev1", Intent: "intent1", Priority: 10}
+	err := d.saveRawIntent(ctx, "intent1", prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &sdcpb.SetIntentRequest{Name: "dev1", Intent: "intent1", Priority: 10, Delete: true}
+	if err = d.writeWAL(ctx, req, &SetIntentOpts{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err = d.writeWAL(ctx, &sdcpb.SetIntentRequest{Name: "dev1", Intent: "intent2", Priority: 5}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the records must not show up as intents
+	intents, err := d.listRawIntent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intents) != 1 {
+		t.Errorf("listRawIntent() returned %d intents, want 1", len(intents))
+	}
+
+	recs, err := d.readWAL(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("readWAL() returned %d records, want 2", len(recs))
+	}
+	for _, rec := range recs {
+		got := &sdcpb.SetIntentRequest{}
+		if err := proto.Unmarshal(rec.Request, got); err != nil {
+			t.Fatal(err)
+		}
+		switch got.GetIntent() {
+		case "intent1":
+			if !proto.Equal(got, req) || !rec.Force {
+				t.Errorf("unexpected record of intent1: %v force=%t", got, rec.Force)
+			}
+			gotPrev := &sdcpb.SetIntentRequest{}
+			if err := proto.Unmarshal(rec.Previous, gotPrev); err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(gotPrev, prev) {
+				t.Errorf("unexpected previous version of intent1: %v", gotPrev)
+			}
+		case "intent2":
+			if rec.Previous != nil {
+				t.Errorf("expected no previous version of intent2")
+			}
+		default:
+			t.Errorf("unexpected record of intent %s", got.GetIntent())
+		}
+	}
+
+	for _, in := range []string{"intent1", "intent2"} {
+		if err = d.deleteWAL(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recs, err = d.readWAL(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 0 {
+		t.Errorf("readWAL() returned %d records after deletion, want 0", len(recs))
+	}
+}
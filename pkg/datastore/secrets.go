@@ -0,0 +1,81 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/dslog"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// redactedValue replaces the sensitive values in responses and logs.
+var redactedValue = &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: tree.RedactedValue}}
+
+// isSensitive returns true if the update with the given path holds sensitive values.
+func (d *Datastore) isSensitive(p *sdcpb.Path) bool {
+	return d.config.Secrets.ContainsSensitive(utils.ToStrings(p, false, false))
+}
+
+// redactUpdates returns the updates with the sensitive values redacted.
+// Values of containers, e.g. JSON blobs, holding sensitive values are redacted as a whole.
+func (d *Datastore) redactUpdates(upds []*sdcpb.Update) []*sdcpb.Update {
+	if d.config.Secrets == nil {
+		return upds
+	}
+	result := make([]*sdcpb.Update, 0, len(upds))
+	for _, u := range upds {
+		if d.isSensitive(u.GetPath()) {
+			u = &sdcpb.Update{Path: u.GetPath(), Value: redactedValue}
+		}
+		result = append(result, u)
+	}
+	return result
+}
+
+// RedactSetIntentRequest returns the request with the sensitive values redacted, e.g. for logging.
+func (d *Datastore) RedactSetIntentRequest(req *sdcpb.SetIntentRequest) *sdcpb.SetIntentRequest {
+	if d.config.Secrets == nil {
+		return req
+	}
+	result := proto.Clone(req).(*sdcpb.SetIntentRequest)
+	result.Update = d.redactUpdates(result.GetUpdate())
+	return result
+}
+
+// cacheUpdateString returns the string representation of the update for logging,
+// with sensitive values redacted and huge values truncated.
+func (d *Datastore) cacheUpdateString(u *cache.Update) string {
+	if d.config.Secrets.ContainsSensitive(u.GetPath()) {
+		u = d.redactCacheUpdate(u)
+	}
+	return tree.TruncateValue(u.String(), dslog.MaxValueLength())
+}
+
+func (d *Datastore) redactCacheUpdate(u *cache.Update) *cache.Update {
+	b, _ := proto.Marshal(redactedValue)
+	return cache.NewUpdate(u.GetPath(), b, u.Priority(), u.Owner(), u.TS())
+}
+
+// treeRedaction returns the redaction of the values rendered by the tree, truncating them to maxValueLen.
+func (d *Datastore) treeRedaction(maxValueLen int) *tree.Redaction {
+	r := &tree.Redaction{MaxValueLen: maxValueLen}
+	if d.config.Secrets != nil {
+		r.Sensitive = d.config.Secrets.IsSensitive
+	}
+	return r
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_Secrets(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	err := os.WriteFile(keyFile, []byte(strings.Repeat("ab", 32)+"\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.DatastoreConfig{
+		Name: "dev1",
+		SBI:  &config.SBI{Type: "noop"},
+		Secrets: &config.Secrets{
+			Paths:   []string{"/system/user/*/password"},
+			KeyFile: keyFile,
+		},
+	}
+	if err = cfg.ValidateSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	store := map[string]*cache.Update{}
+	configureIntentsStoreMock(cacheClient, store)
+	sc, err := cache.NewSecretsClient(cacheClient, cfg.Secrets.Key(), cfg.Secrets.IsSensitive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Datastore{config: cfg, cacheClient: sc}
+	ctx := context.Background()
+
+	password := &sdcpb.Update{
+		Path: &sdcpb.Path{Elem: []*sdcpb.PathElem{
+			{Name: "system"},
+			{Name: "user", Key: map[string]string{"name": "admin"}},
+			{Name: "password"},
+		}},
+		Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "secret"}},
+	}
+	user := &sdcpb.Update{
+		Path: &sdcpb.Path{Elem: []*sdcpb.PathElem{
+			{Name: "system"},
+			{Name: "user", Key: map[string]string{"name": "admin"}},
+		}},
+		Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(`{"password":"secret"}`)}},
+	}
+	description := &sdcpb.Update{
+		Path: &sdcpb.Path{Elem: []*sdcpb.PathElem{
+			{Name: "system"},
+			{Name: "description"},
+		}},
+		Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "secret"}},
+	}
+	req := &sdcpb.SetIntentRequest{
+		Name:     "dev1",
+		Intent:   "intent1",
+		Priority: 10,
+		Update:   []*sdcpb.Update{password, user, description},
+	}
+	if err = d.saveRawIntent(ctx, "intent1", req); err != nil {
+		t.Fatal(err)
+	}
+
+	// the intent is encrypted at rest
+	for k, u := range store {
+		if strings.Contains(string(u.Bytes()), "secret") {
+			t.Errorf("the value of %s is stored in clear text", k)
+		}
+	}
+
+	rsp, err := d.GetIntent(ctx, &sdcpb.GetIntentRequest{Name: "dev1", Intent: "intent1", Priority: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rsp.GetIntent().GetUpdate()
+	if len(got) != 3 {
+		t.Fatalf("GetIntent() returned %d updates, want 3", len(got))
+	}
+	for i, want := range []*sdcpb.TypedValue{redactedValue, redactedValue, description.GetValue()} {
+		if !proto.Equal(got[i].GetValue(), want) {
+			t.Errorf("GetIntent() update %d = %v, want %v", i, got[i].GetValue(), want)
+		}
+	}
+
+	rsp, err = d.GetIntentWithOpts(ctx, &sdcpb.GetIntentRequest{Name: "dev1", Intent: "intent1", Priority: 10}, &GetIntentOpts{RevealSecrets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(rsp.GetIntent().GetUpdate()[0], password) {
+		t.Errorf("GetIntentWithOpts() revealed %v, want %v", rsp.GetIntent().GetUpdate()[0], password)
+	}
+
+	// sensitive values of the intended store are encrypted, the others are not
+	b, _ := proto.Marshal(password.GetValue())
+	err = sc.Modify(ctx, "dev1", &cache.Opts{Store: cachepb.Store_INTENDED}, nil, []*cache.Update{
+		cache.NewUpdate([]string{"system", "user", "admin", "password"}, b, 10, "intent1", 0),
+		cache.NewUpdate([]string{"system", "description"}, b, 10, "intent1", 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(store["system/user/admin/password"].Bytes()), "secret") {
+		t.Errorf("the sensitive intended value is stored in clear text")
+	}
+	if !strings.Contains(string(store["system/description"].Bytes()), "secret") {
+		t.Errorf("the intended value is encrypted, although not sensitive")
+	}
+}
@@ -0,0 +1,370 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/pkg/cache"
+)
+
+const (
+	// SnippetCacheName is the cache instance holding the snippet library, shared by all datastores of the data-server
+	SnippetCacheName = "__snippets__"
+	// snippetRefKey is the JSON member that references snippets within intent values,
+	// either a single reference or a list of references
+	snippetRefKey = "@snippet"
+	// snippetVersionSep separates the name and the version of a snippet within references and keys
+	snippetVersionSep = "@"
+)
+
+var ErrSnippetNotFound = errors.New("snippet not found")
+
+// Snippet is a named, reusable part of the intent values. Intents reference snippets by the "@snippet" member
+// of JSON objects, e.g. {"@snippet": "base-interface", "description": "uplink"}. The reference is replaced with
+// the members of the snippet, the members next to the reference take precedence. A reference without version,
+// e.g. base-interface, resolves to the latest version of the snippet, base-interface@2 to version 2.
+type Snippet struct {
+	// Name of the snippet
+	Name string `json:"name"`
+	// Version is incremented on every change of the snippet, starting at 1
+	Version uint64 `json:"version"`
+	// Value is the JSON object the references are replaced with, it may reference other snippets
+	Value json.RawMessage `json:"value"`
+	// Timestamp of the creation of the version, unix nanoseconds
+	Timestamp int64 `json:"timestamp"`
+}
+
+// CreateSnippetCache creates the cache instance of the snippet library, if it does not exist.
+func CreateSnippetCache(ctx context.Context, cc cache.Client) error {
+	ok, err := cc.Exists(ctx, SnippetCacheName)
+	if err != nil || ok {
+		return err
+	}
+	return cc.Create(ctx, SnippetCacheName, false, false)
+}
+
+// SaveSnippet stores the value as the new version of the snippet. The value must be a JSON object
+// whose references resolve without cycles.
+func SaveSnippet(ctx context.Context, cc cache.Client, name string, value []byte) (*Snippet, error) {
+	if name == "" || strings.Contains(name, snippetVersionSep) {
+		return nil, fmt.Errorf("invalid snippet name %q", name)
+	}
+	versions := SnippetVersions(ctx, cc, name)
+	s := &Snippet{
+		Name:      name,
+		Version:   1,
+		Value:     value,
+		Timestamp: time.Now().UnixNano(),
+	}
+	if len(versions) > 0 {
+		s.Version = versions[len(versions)-1] + 1
+	}
+
+	var v any
+	if err := unmarshalJSON(value, &v); err != nil {
+		return nil, fmt.Errorf("malformed value of snippet %s: %w", name, err)
+	}
+	if _, ok := v.(map[string]any); !ok {
+		return nil, fmt.Errorf("the value of snippet %s is not a JSON object", name)
+	}
+	// the new version must not introduce cycles
+	r := &snippetResolver{get: func(ctx context.Context, ref string) (*Snippet, error) {
+		if n, _, _ := strings.Cut(ref, snippetVersionSep); n == name {
+			return s, nil
+		}
+		return GetSnippetRef(ctx, cc, ref)
+	}}
+	if _, err := r.resolve(ctx, v, []string{name}); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	upd, err := cc.NewUpdate(&sdcpb.Update{
+		Path:  &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: snippetKey(name, s.Version)}}},
+		Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_BytesVal{BytesVal: b}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = cc.Modify(ctx, SnippetCacheName, &cache.Opts{Store: cachepb.Store_INTENTS}, nil, []*cache.Update{upd})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetSnippet returns the version of the snippet, the latest version if the version is 0.
+// ErrSnippetNotFound is returned if it does not exist.
+func GetSnippet(ctx context.Context, cc cache.Client, name string, version uint64) (*Snippet, error) {
+	if version == 0 {
+		versions := SnippetVersions(ctx, cc, name)
+		if len(versions) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrSnippetNotFound, name)
+		}
+		version = versions[len(versions)-1]
+	}
+	upds := cc.Read(ctx, SnippetCacheName, &cache.Opts{Store: cachepb.Store_INTENTS}, [][]string{{snippetKey(name, version)}}, 0)
+	if len(upds) == 0 {
+		return nil, fmt.Errorf("%w: %s%s%d", ErrSnippetNotFound, name, snippetVersionSep, version)
+	}
+	val, err := upds[0].Value()
+	if err != nil {
+		return nil, err
+	}
+	s := &Snippet{}
+	if err = json.Unmarshal(val.GetBytesVal(), s); err != nil {
+		return nil, fmt.Errorf("malformed snippet %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// GetSnippetRef returns the snippet of the reference, either name or name@version.
+func GetSnippetRef(ctx context.Context, cc cache.Client, ref string) (*Snippet, error) {
+	name, v, ok := strings.Cut(ref, snippetVersionSep)
+	var version uint64
+	if ok {
+		var err error
+		version, err = strconv.ParseUint(v, 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid version of snippet reference %q", ref)
+		}
+	}
+	return GetSnippet(ctx, cc, name, version)
+}
+
+// ListSnippets returns the versions of all snippets, keyed by name.
+func ListSnippets(ctx context.Context, cc cache.Client) map[string][]uint64 {
+	upds := cc.Read(ctx, SnippetCacheName, &cache.Opts{Store: cachepb.Store_INTENTS, KeysOnly: true}, [][]string{{"*"}}, 0)
+	result := map[string][]uint64{}
+	for _, upd := range upds {
+		if len(upd.GetPath()) == 0 {
+			continue
+		}
+		name, v, ok := strings.Cut(upd.GetPath()[0], snippetVersionSep)
+		if !ok {
+			continue
+		}
+		version, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		result[name] = append(result[name], version)
+	}
+	for _, versions := range result {
+		slices.Sort(versions)
+	}
+	return result
+}
+
+// SnippetVersions returns the versions of the snippet in ascending order.
+func SnippetVersions(ctx context.Context, cc cache.Client, name string) []uint64 {
+	return ListSnippets(ctx, cc)[name]
+}
+
+// DeleteSnippet removes all versions of the snippet. Intents referencing the snippet can no longer be applied.
+func DeleteSnippet(ctx context.Context, cc cache.Client, name string) error {
+	versions := SnippetVersions(ctx, cc, name)
+	if len(versions) == 0 {
+		return fmt.Errorf("%w: %s", ErrSnippetNotFound, name)
+	}
+	keys := make([][]string, 0, len(versions))
+	for _, v := range versions {
+		keys = append(keys, []string{snippetKey(name, v)})
+	}
+	return cc.Modify(ctx, SnippetCacheName, &cache.Opts{Store: cachepb.Store_INTENTS}, keys, nil)
+}
+
+func snippetKey(name string, version uint64) string {
+	return name + snippetVersionSep + strconv.FormatUint(version, 10)
+}
+
+// expandSnippets replaces the snippet references within the JSON values of the updates with the snippets.
+// Updates without references are returned as is, the given updates are not modified.
+func (d *Datastore) expandSnippets(ctx context.Context, upds []*sdcpb.Update) ([]*sdcpb.Update, error) {
+	r := &snippetResolver{get: func(ctx context.Context, ref string) (*Snippet, error) {
+		return GetSnippetRef(ctx, d.cacheClient, ref)
+	}}
+	result := make([]*sdcpb.Update, 0, len(upds))
+	for _, upd := range upds {
+		if !hasSnippetRef(upd) {
+			result = append(result, upd)
+			continue
+		}
+		upd = proto.Clone(upd).(*sdcpb.Update)
+		var err error
+		switch v := upd.GetValue().GetValue().(type) {
+		case *sdcpb.TypedValue_JsonVal:
+			v.JsonVal, err = r.expand(ctx, v.JsonVal)
+		case *sdcpb.TypedValue_JsonIetfVal:
+			v.JsonIetfVal, err = r.expand(ctx, v.JsonIetfVal)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed expanding the snippets of %s: %w", upd.GetPath(), err)
+		}
+		result = append(result, upd)
+	}
+	return result, nil
+}
+
+// hasSnippetRef returns true if the JSON value of the update might reference snippets.
+func hasSnippetRef(upd *sdcpb.Update) bool {
+	var b []byte
+	switch v := upd.GetValue().GetValue().(type) {
+	case *sdcpb.TypedValue_JsonVal:
+		b = v.JsonVal
+	case *sdcpb.TypedValue_JsonIetfVal:
+		b = v.JsonIetfVal
+	}
+	return bytes.Contains(b, []byte(`"`+snippetRefKey+`"`))
+}
+
+// hasSnippetRefs returns true if any of the update values might reference snippets.
+func hasSnippetRefs(upds []*sdcpb.Update) bool {
+	return slices.ContainsFunc(upds, hasSnippetRef)
+}
+
+// snippetResolver resolves the snippet references of JSON values.
+type snippetResolver struct {
+	get func(ctx context.Context, ref string) (*Snippet, error)
+}
+
+func (r *snippetResolver) expand(ctx context.Context, b []byte) ([]byte, error) {
+	var v any
+	if err := unmarshalJSON(b, &v); err != nil {
+		return nil, err
+	}
+	v, err := r.resolve(ctx, v, nil)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// resolve replaces the references within the value. The stack holds the names of the snippets
+// that are being resolved, referencing one of them again is a cycle.
+func (r *snippetResolver) resolve(ctx context.Context, v any, stack []string) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		result := map[string]any{}
+		if ref, ok := v[snippetRefKey]; ok {
+			refs, err := snippetRefs(ref)
+			if err != nil {
+				return nil, err
+			}
+			for _, ref := range refs {
+				s, err := r.get(ctx, ref)
+				if err != nil {
+					return nil, err
+				}
+				if slices.Contains(stack, s.Name) {
+					return nil, fmt.Errorf("snippet cycle: %s -> %s", strings.Join(stack, " -> "), s.Name)
+				}
+				var sv any
+				if err := unmarshalJSON(s.Value, &sv); err != nil {
+					return nil, fmt.Errorf("malformed value of snippet %s: %w", ref, err)
+				}
+				sv, err = r.resolve(ctx, sv, append(stack, s.Name))
+				if err != nil {
+					return nil, err
+				}
+				m, ok := sv.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("the value of snippet %s is not a JSON object", ref)
+				}
+				mergeJSONObjects(result, m)
+			}
+		}
+		members := make(map[string]any, len(v))
+		for k, mv := range v {
+			if k == snippetRefKey {
+				continue
+			}
+			rv, err := r.resolve(ctx, mv, stack)
+			if err != nil {
+				return nil, err
+			}
+			members[k] = rv
+		}
+		// the members next to the reference take precedence over the ones of the snippets
+		mergeJSONObjects(result, members)
+		return result, nil
+	case []any:
+		result := make([]any, 0, len(v))
+		for _, e := range v {
+			re, err := r.resolve(ctx, e, stack)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, re)
+		}
+		return result, nil
+	}
+	return v, nil
+}
+
+// snippetRefs returns the references of the "@snippet" member, a string or a list of strings.
+func snippetRefs(v any) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		refs := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid snippet reference %v", e)
+			}
+			refs = append(refs, s)
+		}
+		return refs, nil
+	}
+	return nil, fmt.Errorf("invalid snippet reference %v", v)
+}
+
+// mergeJSONObjects merges src into dst, nested objects are merged, all other values of src replace the ones of dst.
+func mergeJSONObjects(dst, src map[string]any) {
+	for k, sv := range src {
+		sm, sok := sv.(map[string]any)
+		dm, dok := dst[k].(map[string]any)
+		if sok && dok {
+			mergeJSONObjects(dm, sm)
+			continue
+		}
+		dst[k] = sv
+	}
+}
+
+// unmarshalJSON decodes the JSON value, keeping the numbers as json.Number to not lose precision.
+func unmarshalJSON(b []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSnippetResolver_expand(t *testing.T) {
+	library := map[string][]string{
+		"base":   {`{"admin-state":"enable","mtu":1500}`, `{"admin-state":"enable","mtu":9000,"subinterface":[{"index":0}]}`},
+		"desc":   {`{"description":"managed by {{ .targetName }}"}`},
+		"combo":  {`{"@snippet":["base@1","desc"],"mtu":1600}`},
+		"loop-a": {`{"@snippet":"loop-b"}`},
+		"loop-b": {`{"@snippet":"loop-a"}`},
+		"list":   {`["not","an","object"]`},
+	}
+	r := &snippetResolver{get: func(ctx context.Context, ref string) (*Snippet, error) {
+		name, v, _ := strings.Cut(ref, snippetVersionSep)
+		versions, ok := library[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrSnippetNotFound, ref)
+		}
+		version := len(versions)
+		if v != "" {
+			version, _ = strconv.Atoi(v)
+		}
+		return &Snippet{Name: name, Version: uint64(version), Value: json.RawMessage(versions[version-1])}, nil
+	}}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "latest version",
+			value: `{"@snippet":"base","description":"uplink"}`,
+			want:  `{"admin-state":"enable","description":"uplink","mtu":9000,"subinterface":[{"index":0}]}`,
+		},
+		{
+			name:  "pinned version",
+			value: `{"@snippet":"base@1"}`,
+			want:  `{"admin-state":"enable","mtu":1500}`,
+		},
+		{
+			name:  "local members take precedence",
+			value: `{"@snippet":"base@1","mtu":1400}`,
+			want:  `{"admin-state":"enable","mtu":1400}`,
+		},
+		{
+			name:  "nested references",
+			value: `{"interface":[{"name":"ethernet-1/1","@snippet":"combo"}]}`,
+			want:  `{"interface":[{"admin-state":"enable","description":"managed by {{ .targetName }}","mtu":1600,"name":"ethernet-1/1"}]}`,
+		},
+		{
+			name:    "cycle",
+			value:   `{"@snippet":"loop-a"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown snippet",
+			value:   `{"@snippet":"unknown"}`,
+			wantErr: true,
+		},
+		{
+			name:    "snippet is not an object",
+			value:   `{"@snippet":"list"}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid reference",
+			value:   `{"@snippet":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.expand(context.Background(), []byte(tt.value))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var gotV, wantV any
+			if err := json.Unmarshal(got, &gotV); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantV); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotV, wantV) {
+				t.Errorf("expand() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
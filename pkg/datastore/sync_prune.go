@@ -0,0 +1,112 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+var syncPrunedPathsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "data_server",
+	Name:      "sync_pruned_paths_total",
+	Help:      "Number of paths removed from the CONFIG store, because they were no longer present in a sync of the target",
+}, []string{"datastore"})
+
+// syncTracker keeps track of the CONFIG store paths that are synced in a sync iteration,
+// to detect the paths that are no longer present on the target.
+type syncTracker struct {
+	m sync.Mutex
+	// the CONFIG store paths covered by the sync iteration, that were not synced yet
+	pending map[string][]string
+}
+
+// newSyncTracker returns a syncTracker for the CONFIG store paths below the given paths.
+func (d *Datastore) newSyncTracker(ctx context.Context, paths []*sdcpb.Path) (*syncTracker, error) {
+	prefixes := make([][]string, 0, len(paths))
+	for _, p := range paths {
+		prefixes = append(prefixes, utils.ToStrings(p, false, false))
+	}
+
+	keys, err := d.cacheClient.GetKeys(ctx, d.config.Name, cachepb.Store_CONFIG)
+	if err != nil {
+		return nil, err
+	}
+	st := &syncTracker{pending: map[string][]string{}}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case k, ok := <-keys:
+			if !ok {
+				return st, nil
+			}
+			path := k.GetPath()
+			if slices.ContainsFunc(prefixes, func(prefix []string) bool { return hasPathPrefix(path, prefix) }) {
+				st.pending[tree.PathKey(path)] = path
+			}
+		}
+	}
+}
+
+// hasPathPrefix returns true if the path is equal to or below the prefix.
+func hasPathPrefix(path, prefix []string) bool {
+	return len(path) >= len(prefix) && slices.Equal(path[:len(prefix)], prefix)
+}
+
+// synced marks the path as present on the target. It is safe to call on a nil syncTracker.
+func (st *syncTracker) synced(path []string) {
+	if st == nil {
+		return
+	}
+	st.m.Lock()
+	defer st.m.Unlock()
+	delete(st.pending, tree.PathKey(path))
+}
+
+// stale returns the paths that were not synced.
+func (st *syncTracker) stale() [][]string {
+	st.m.Lock()
+	defer st.m.Unlock()
+	result := make([][]string, 0, len(st.pending))
+	for _, p := range st.pending {
+		result = append(result, p)
+	}
+	return result
+}
+
+// pruneStale removes the paths from the CONFIG store that were not synced by the sync iteration.
+func (d *Datastore) pruneStale(ctx context.Context, st *syncTracker) {
+	stale := st.stale()
+	if len(stale) == 0 {
+		return
+	}
+	err := d.cacheClient.Modify(ctx, d.config.Name, &cache.Opts{Store: cachepb.Store_CONFIG}, stale, nil)
+	if err != nil {
+		log.Errorf("datastore %s failed to prune %d stale paths: %v", d.config.Name, len(stale), err)
+		return
+	}
+	log.Debugf("datastore %s pruned %d stale paths", d.config.Name, len(stale))
+	syncPrunedPathsTotal.WithLabelValues(d.config.Name).Add(float64(len(stale)))
+}
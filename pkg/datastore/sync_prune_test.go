@@ -0,0 +1,81 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_pruneStale(t *testing.T) {
+	running := [][]string{
+		{"interface", "ethernet-1/1", "description"},
+		{"interface", "ethernet-1/1", "name"},
+		{"interface", "ethernet-1/2", "name"},
+		{"network-instance", "default", "name"},
+	}
+	ctrl := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(ctrl)
+	cacheClient.EXPECT().GetKeys(gomock.Any(), "dev1", cachepb.Store_CONFIG).DoAndReturn(
+		func(ctx context.Context, name string, store cachepb.Store) (chan *cache.Update, error) {
+			ch := make(chan *cache.Update, len(running))
+			for _, p := range running {
+				ch <- cache.NewUpdate(p, nil, 0, "", 0)
+			}
+			close(ch)
+			return ch, nil
+		},
+	)
+
+	var pruned [][]string
+	cacheClient.EXPECT().Modify(gomock.Any(), "dev1", &cache.Opts{Store: cachepb.Store_CONFIG}, gomock.Any(), nil).DoAndReturn(
+		func(ctx context.Context, name string, opts *cache.Opts, dels [][]string, upds []*cache.Update) error {
+			pruned = dels
+			return nil
+		},
+	)
+
+	d := &Datastore{
+		config:      &config.DatastoreConfig{Name: "dev1"},
+		cacheClient: cacheClient,
+	}
+
+	// the sync covers the interfaces only
+	st, err := d.newSyncTracker(context.TODO(), []*sdcpb.Path{{Elem: []*sdcpb.PathElem{{Name: "interface"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.synced([]string{"interface", "ethernet-1/1", "description"})
+	st.synced([]string{"interface", "ethernet-1/1", "name"})
+
+	d.pruneStale(context.TODO(), st)
+
+	slices.SortFunc(pruned, func(a, b []string) int { return strings.Compare(strings.Join(a, "/"), strings.Join(b, "/")) })
+	want := [][]string{{"interface", "ethernet-1/2", "name"}}
+	if diff := cmp.Diff(want, pruned); diff != "" {
+		t.Errorf("pruneStale() mismatch (-want +got):\n%s", diff)
+	}
+}
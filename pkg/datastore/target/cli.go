@@ -0,0 +1,339 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/scrapli/scrapligo/driver/network"
+	"github.com/scrapli/scrapligo/driver/options"
+	"github.com/scrapli/scrapligo/platform"
+	"github.com/scrapli/scrapligo/util"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// cliTemplates are the default templates per scrapligo platform.
+// They render the updates and deletes into CLI commands, one command per line, including the commit.
+// The commit is skipped for dry-runs. Entering the configuration mode is handled by the scrapligo platform definition.
+var cliTemplates = map[string]string{
+	"nokia_srl": `{{- range .Deletes }}
+delete / {{ cliPath . }}
+{{- end }}
+{{- range .Updates }}
+set / {{ cliPath .Path }} {{ cliValue .Value }}
+{{- end }}
+{{- if not .DryRun }}
+commit now
+{{- end }}
+`,
+	"nokia_sros": `{{- range .Deletes }}
+delete /{{ cliPath . }}
+{{- end }}
+{{- range .Updates }}
+/{{ cliPath .Path }} {{ cliValue .Value }}
+{{- end }}
+{{- if not .DryRun }}
+commit
+{{- end }}
+`,
+	"cisco_iosxr": `{{- range .Deletes }}
+no {{ cliPath . }}
+{{- end }}
+{{- range .Updates }}
+{{ cliPath .Path }} {{ cliValue .Value }}
+{{- end }}
+{{- if not .DryRun }}
+commit
+{{- end }}
+`,
+}
+
+// cliDiffCommands are the commands per scrapligo platform that show the
+// uncommitted changes and discard them afterwards.
+var cliDiffCommands = map[string][2]string{
+	"nokia_srl":   {"diff", "discard now"},
+	"nokia_sros":  {"compare", "discard"},
+	"cisco_iosxr": {"show commit changes diff", "abort"},
+}
+
+var cliTemplateFuncs = template.FuncMap{
+	"cliPath":  cliPath,
+	"cliValue": cliValue,
+}
+
+// cliTemplateData is the data the CLI templates are executed with.
+type cliTemplateData struct {
+	Updates []*sdcpb.Update
+	Deletes []*sdcpb.Path
+	// DryRun is set if the changes are not to be committed
+	DryRun bool
+}
+
+type cliTarget struct {
+	name      string
+	sbiConfig *config.SBI
+	tmpl      *template.Template
+
+	m       *sync.Mutex
+	driver  *network.Driver
+	address string
+}
+
+func newCLITarget(_ context.Context, name string, cfg *config.SBI) (*cliTarget, error) {
+	tmpl, err := newCLITemplate(cfg.CLIOptions)
+	if err != nil {
+		return nil, err
+	}
+	t := &cliTarget{
+		name:      name,
+		sbiConfig: cfg,
+		tmpl:      tmpl,
+		m:         new(sync.Mutex),
+	}
+
+	opts := []util.Option{
+		options.WithAuthNoStrictKey(),
+		options.WithTransportType("standard"),
+		options.WithPort(int(cfg.Port)),
+		options.WithTimeoutOps(cfg.Timeout),
+	}
+	if cfg.Credentials != nil {
+		opts = append(opts,
+			options.WithAuthUsername(cfg.Credentials.Username),
+			options.WithAuthPassword(cfg.Credentials.Password),
+		)
+	}
+	// try the addresses in order, until a connection is established
+	var errs []error
+	for _, addr := range cfg.AddressList() {
+		err = t.open(addr, opts)
+		if err == nil {
+			t.address = addr
+			return t, nil
+		}
+		log.Debugf("%s: failed connecting to %s: %v", name, addr, err)
+		errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no address configured")
+	}
+	return t, errors.Join(errs...)
+}
+
+// open creates the network driver for the given address and opens the session.
+func (t *cliTarget) open(address string, opts []util.Option) error {
+	p, err := platform.NewPlatform(t.sbiConfig.CLIOptions.Platform, address, opts...)
+	if err != nil {
+		return err
+	}
+	t.driver, err = p.GetNetworkDriver()
+	if err != nil {
+		return err
+	}
+	return t.driver.Open()
+}
+
+// newCLITemplate parses the configured template, or the default template of the platform if none is configured.
+func newCLITemplate(opts *config.SBICLIOptions) (*template.Template, error) {
+	text := opts.Template
+	if text == "" {
+		var ok bool
+		text, ok = cliTemplates[opts.Platform]
+		if !ok {
+			return nil, fmt.Errorf("no default cli template for platform %q", opts.Platform)
+		}
+	}
+	return template.New(opts.Platform).Funcs(cliTemplateFuncs).Parse(text)
+}
+
+func (t *cliTarget) Get(_ context.Context, _ *sdcpb.GetDataRequest) (*sdcpb.GetDataResponse, error) {
+	return nil, fmt.Errorf("target %s: get is not supported by the cli target", t.name)
+}
+
+func (t *cliTarget) Set(ctx context.Context, source TargetSource) (*sdcpb.SetDataResponse, error) {
+	upds, err := source.ToProtoUpdates(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	deletes, err := source.ToProtoDeletes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds, err := t.renderCommands(upds, deletes, false)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("target %s: cli commands:\n%s", t.name, strings.Join(cmds, "\n"))
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	rsp, err := t.driver.SendConfigs(cmds)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.Failed != nil {
+		return nil, fmt.Errorf("target %s: %w", t.name, rsp.Failed)
+	}
+
+	result := &sdcpb.SetDataResponse{
+		Response:  make([]*sdcpb.UpdateResult, 0, len(upds)+len(deletes)),
+		Timestamp: time.Now().UnixNano(),
+	}
+	for _, upd := range upds {
+		result.Response = append(result.Response, &sdcpb.UpdateResult{
+			Path: upd.GetPath(),
+			Op:   sdcpb.UpdateResult_UPDATE,
+		})
+	}
+	for _, p := range deletes {
+		result.Response = append(result.Response, &sdcpb.UpdateResult{
+			Path: p,
+			Op:   sdcpb.UpdateResult_DELETE,
+		})
+	}
+	return result, nil
+}
+
+// Diff enters the changes of the source without committing them and returns the
+// diff reported by the target. The changes are discarded afterwards.
+func (t *cliTarget) Diff(ctx context.Context, source TargetSource) (string, error) {
+	diffCmds, ok := cliDiffCommands[t.sbiConfig.CLIOptions.Platform]
+	if !ok {
+		return "", fmt.Errorf("target %s: no diff commands for platform %q", t.name, t.sbiConfig.CLIOptions.Platform)
+	}
+	upds, err := source.ToProtoUpdates(ctx, true)
+	if err != nil {
+		return "", err
+	}
+	deletes, err := source.ToProtoDeletes(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	cmds, err := t.renderCommands(upds, deletes, true)
+	if err != nil {
+		return "", err
+	}
+	cmds = append(cmds, diffCmds[0], diffCmds[1])
+	log.Debugf("target %s: cli dry-run commands:\n%s", t.name, strings.Join(cmds, "\n"))
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	rsp, err := t.driver.SendConfigs(cmds)
+	if err != nil {
+		return "", err
+	}
+	if rsp.Failed != nil {
+		return "", fmt.Errorf("target %s: %w", t.name, rsp.Failed)
+	}
+	// the output of the diff command, which precedes the discard
+	if len(rsp.Responses) < 2 {
+		return "", fmt.Errorf("target %s: missing diff output", t.name)
+	}
+	return strings.TrimSpace(rsp.Responses[len(rsp.Responses)-2].Result), nil
+}
+
+// renderCommands executes the template and returns the non empty lines as commands.
+func (t *cliTarget) renderCommands(upds []*sdcpb.Update, deletes []*sdcpb.Path, dryRun bool) ([]string, error) {
+	buf := new(bytes.Buffer)
+	err := t.tmpl.Execute(buf, &cliTemplateData{Updates: upds, Deletes: deletes, DryRun: dryRun})
+	if err != nil {
+		return nil, err
+	}
+	cmds := make([]string, 0)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		cmds = append(cmds, line)
+	}
+	return cmds, nil
+}
+
+func (t *cliTarget) Status() string {
+	if t == nil || t.driver == nil || t.driver.Transport == nil {
+		return "NOT_CONNECTED"
+	}
+	if t.driver.Transport.IsAlive() {
+		return "CONNECTED"
+	}
+	return "NOT_CONNECTED"
+}
+
+// ActiveAddress returns the address the target is connected to.
+func (t *cliTarget) ActiveAddress() string {
+	return t.address
+}
+
+func (t *cliTarget) Sync(_ context.Context, _ *config.Sync, _ chan *SyncUpdate) {
+	log.Infof("target %s: sync is not supported by the cli target", t.name)
+}
+
+func (t *cliTarget) Close() error {
+	if t == nil || t.driver == nil {
+		return nil
+	}
+	return t.driver.Close()
+}
+
+// cliPath renders the path as space separated elements, each followed by its key values sorted by key name.
+func cliPath(p *sdcpb.Path) string {
+	parts := make([]string, 0, len(p.GetElem()))
+	for _, pe := range p.GetElem() {
+		parts = append(parts, pe.GetName())
+		keys := make([]string, 0, len(pe.GetKey()))
+		for k := range pe.GetKey() {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			parts = append(parts, cliQuote(pe.GetKey()[k]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// cliValue renders the value, leaf-lists are rendered in square brackets.
+func cliValue(tv *sdcpb.TypedValue) string {
+	if ll := tv.GetLeaflistVal(); ll != nil {
+		elems := make([]string, 0, len(ll.GetElement()))
+		for _, e := range ll.GetElement() {
+			elems = append(elems, cliValue(e))
+		}
+		return fmt.Sprintf("[ %s ]", strings.Join(elems, " "))
+	}
+	return cliQuote(utils.TypedValueToString(tv))
+}
+
+// cliQuote quotes values that are empty or contain whitespace or quotes.
+func cliQuote(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\"") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
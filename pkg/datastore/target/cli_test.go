@@ -0,0 +1,125 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sdcio/data-server/pkg/config"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+func TestCLITarget_renderCommands(t *testing.T) {
+	upds := []*sdcpb.Update{
+		{
+			Path: &sdcpb.Path{Elem: []*sdcpb.PathElem{
+				{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}},
+				{Name: "description"},
+			}},
+			Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "uplink to spine"}},
+		},
+		{
+			Path: &sdcpb.Path{Elem: []*sdcpb.PathElem{
+				{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}},
+				{Name: "mtu"},
+			}},
+			Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_UintVal{UintVal: 9000}},
+		},
+	}
+	deletes := []*sdcpb.Path{
+		{Elem: []*sdcpb.PathElem{
+			{Name: "interface", Key: map[string]string{"name": "ethernet-1/2"}},
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		opts     *config.SBICLIOptions
+		dryRun   bool
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name: "nokia_srl",
+			opts: &config.SBICLIOptions{Platform: "nokia_srl"},
+			expected: []string{
+				"delete / interface ethernet-1/2",
+				`set / interface ethernet-1/1 description "uplink to spine"`,
+				"set / interface ethernet-1/1 mtu 9000",
+				"commit now",
+			},
+		},
+		{
+			name:   "nokia_srl dry-run",
+			opts:   &config.SBICLIOptions{Platform: "nokia_srl"},
+			dryRun: true,
+			expected: []string{
+				"delete / interface ethernet-1/2",
+				`set / interface ethernet-1/1 description "uplink to spine"`,
+				"set / interface ethernet-1/1 mtu 9000",
+			},
+		},
+		{
+			name: "cisco_iosxr",
+			opts: &config.SBICLIOptions{Platform: "cisco_iosxr"},
+			expected: []string{
+				"no interface ethernet-1/2",
+				`interface ethernet-1/1 description "uplink to spine"`,
+				"interface ethernet-1/1 mtu 9000",
+				"commit",
+			},
+		},
+		{
+			name: "custom template",
+			opts: &config.SBICLIOptions{
+				Platform: "nokia_sros",
+				Template: "{{ range .Updates }}/configure {{ cliPath .Path }} {{ cliValue .Value }}\n{{ end }}commit",
+			},
+			expected: []string{
+				`/configure interface ethernet-1/1 description "uplink to spine"`,
+				"/configure interface ethernet-1/1 mtu 9000",
+				"commit",
+			},
+		},
+		{
+			name:    "invalid template",
+			opts:    &config.SBICLIOptions{Platform: "nokia_sros", Template: "{{ range .Updates }}"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := newCLITemplate(tt.opts)
+			if err != nil {
+				if !tt.wantErr {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatalf("expected error, got none")
+			}
+			ct := &cliTarget{name: "dev1", tmpl: tmpl}
+			cmds, err := ct.renderCommands(upds, deletes, tt.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expected, cmds); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
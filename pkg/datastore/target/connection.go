@@ -0,0 +1,284 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+type ConnectionState string
+
+const (
+	ConnectionStateConnecting   ConnectionState = "CONNECTING"
+	ConnectionStateConnected    ConnectionState = "CONNECTED"
+	ConnectionStateDisconnected ConnectionState = "NOT_CONNECTED"
+	// ConnectionStateFailed indicates that the maximum number of reconnect attempts is exceeded
+	ConnectionStateFailed ConnectionState = "FAILED"
+
+	// reconnectJitter is the fraction of the backoff that is randomly added or subtracted
+	reconnectJitter = 0.2
+)
+
+// errKeepaliveTimeout is reported when a keepalive probe is not answered within the idle timeout
+var errKeepaliveTimeout = errors.New("keepalive timeout")
+
+// ConnectionEvent reports a change of the connection state of a target.
+type ConnectionEvent struct {
+	Target string
+	State  ConnectionState
+	// Address is the address the target is connected to, set for ConnectionStateConnected
+	Address string
+	// Err is the error that caused the state change, if any
+	Err       error
+	Timestamp time.Time
+}
+
+// ConnectionEventSource is implemented by the targets that report their connection state changes.
+type ConnectionEventSource interface {
+	ConnectionEvents() <-chan *ConnectionEvent
+}
+
+// ActiveAddressReporter is implemented by the targets that report the address they are connected to.
+type ActiveAddressReporter interface {
+	ActiveAddress() string
+}
+
+// isConnectionError returns true if the error indicates a broken connection to the target,
+// as opposed to errors reported by the target.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch {
+	case errors.Is(err, errKeepaliveTimeout),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, net.ErrClosed),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EPIPE):
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	// the drivers do not always wrap the underlying errors
+	msg := err.Error()
+	for _, s := range []string{"EOF", "connection reset", "broken pipe", "use of closed network connection"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// reconnectBackoff returns the delay before the given reconnect attempt. The delay starts at initial,
+// doubles with every attempt up to max and is randomized by reconnectJitter.
+func reconnectBackoff(initial, max time.Duration, attempt int) time.Duration {
+	d := initial
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	jitter := time.Duration(float64(d) * reconnectJitter * (rand.Float64()*2 - 1))
+	return d + jitter
+}
+
+// connectionManager tracks the connection state of a target and reconnects it with exponential backoff
+// when a connection error is reported. If the target has multiple addresses, they are tried in order
+// on every (re)connect, such that the connection fails over to the next address.
+type connectionManager struct {
+	name    string
+	cfg     *config.SBI
+	connect func(address string) error
+	close   func() error
+
+	m      *sync.Mutex
+	state  ConnectionState
+	active string
+	events chan *ConnectionEvent
+	done   chan struct{}
+}
+
+func newConnectionManager(name string, cfg *config.SBI, connect func(address string) error, close func() error) *connectionManager {
+	return &connectionManager{
+		name:    name,
+		cfg:     cfg,
+		connect: connect,
+		close:   close,
+		m:       new(sync.Mutex),
+		state:   ConnectionStateDisconnected,
+		events:  make(chan *ConnectionEvent, 16),
+		done:    make(chan struct{}),
+	}
+}
+
+// Connect performs the initial connect.
+func (c *connectionManager) Connect() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.setState(ConnectionStateConnecting, nil)
+	err := c.connectAny()
+	if err != nil {
+		c.setState(ConnectionStateDisconnected, err)
+		return err
+	}
+	c.setState(ConnectionStateConnected, nil)
+	return nil
+}
+
+// connectAny tries the addresses of the target in order, until a connection is established.
+// Must be called with the mutex held.
+func (c *connectionManager) connectAny() error {
+	var errs []error
+	for _, addr := range c.cfg.AddressList() {
+		err := c.connect(addr)
+		if err == nil {
+			if c.active != "" && c.active != addr {
+				log.Warnf("%s: failed over from %s to %s", c.name, c.active, addr)
+			}
+			c.active = addr
+			return nil
+		}
+		log.Debugf("%s: failed connecting to %s: %v", c.name, addr, err)
+		errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+	}
+	if len(errs) == 0 {
+		return errors.New("no address configured")
+	}
+	return errors.Join(errs...)
+}
+
+func (c *connectionManager) State() ConnectionState {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.state
+}
+
+// ActiveAddress returns the address of the last successful connect.
+func (c *connectionManager) ActiveAddress() string {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.active
+}
+
+func (c *connectionManager) IsConnected() bool {
+	return c.State() == ConnectionStateConnected
+}
+
+// Events returns the channel the connection state changes are reported on.
+func (c *connectionManager) Events() <-chan *ConnectionEvent {
+	return c.events
+}
+
+// HandleError checks the error reported by an operation on the target.
+// Connection errors close the connection and start the reconnect.
+func (c *connectionManager) HandleError(err error) {
+	if !isConnectionError(err) {
+		return
+	}
+	c.m.Lock()
+	if c.state != ConnectionStateConnected {
+		c.m.Unlock()
+		return
+	}
+	c.setState(ConnectionStateDisconnected, err)
+	c.m.Unlock()
+
+	if cerr := c.close(); cerr != nil {
+		log.Debugf("%s: failed closing broken connection: %v", c.name, cerr)
+	}
+	go c.reconnect()
+}
+
+// Done returns a channel that is closed when the connection manager is stopped.
+func (c *connectionManager) Done() <-chan struct{} {
+	return c.done
+}
+
+// Stop ends pending reconnect attempts.
+func (c *connectionManager) Stop() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+}
+
+func (c *connectionManager) reconnect() {
+	for attempt := 0; ; attempt++ {
+		if c.cfg.MaxRetries > 0 && attempt >= c.cfg.MaxRetries {
+			c.m.Lock()
+			c.setState(ConnectionStateFailed, nil)
+			c.m.Unlock()
+			log.Errorf("%s: giving up reconnecting after %d attempts", c.name, attempt)
+			return
+		}
+		select {
+		case <-c.done:
+			return
+		case <-time.After(reconnectBackoff(c.cfg.ConnectRetry, c.cfg.MaxBackoff, attempt)):
+		}
+
+		c.m.Lock()
+		c.setState(ConnectionStateConnecting, nil)
+		err := c.connectAny()
+		if err == nil {
+			c.setState(ConnectionStateConnected, nil)
+			c.m.Unlock()
+			log.Infof("%s: reconnected to %s", c.name, c.active)
+			return
+		}
+		c.setState(ConnectionStateDisconnected, err)
+		c.m.Unlock()
+		log.Errorf("%s: reconnect attempt %d failed: %v", c.name, attempt+1, err)
+	}
+}
+
+func (c *connectionManager) newEvent(s ConnectionState, err error) *ConnectionEvent {
+	ev := &ConnectionEvent{Target: c.name, State: s, Err: err, Timestamp: time.Now()}
+	if s == ConnectionStateConnected {
+		ev.Address = c.active
+	}
+	return ev
+}
+
+// setState sets the state and reports the change, must be called with the mutex held.
+func (c *connectionManager) setState(s ConnectionState, err error) {
+	if c.state == s {
+		return
+	}
+	c.state = s
+	select {
+	case c.events <- c.newEvent(s, err):
+	default:
+		log.Warnf("%s: dropping connection event %s, receiver is too slow", c.name, s)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+// testConnectionManager returns a connection manager in the given state that does not connect anywhere
+func testConnectionManager(connected bool) *connectionManager {
+	c := newConnectionManager("TestDev", &config.SBI{Address: "dev"}, func(string) error { return nil }, func() error { return nil })
+	if connected {
+		c.state = ConnectionStateConnected
+	}
+	return c
+}
+
+func Test_isConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "eof", err: io.EOF, want: true},
+		{name: "wrapped eof", err: fmt.Errorf("edit-config: %w", io.EOF), want: true},
+		{name: "unwrapped driver error", err: errors.New("error reading from transport, cannot continue: EOF"), want: true},
+		{name: "rpc error", err: errors.New("rpc-error: invalid value"), want: false},
+		{name: "keepalive timeout", err: errKeepaliveTimeout, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionError(tt.err); got != tt.want {
+				t.Errorf("isConnectionError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_reconnectBackoff(t *testing.T) {
+	initial := time.Second
+	max := 10 * time.Second
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{attempt: 0, base: time.Second},
+		{attempt: 2, base: 4 * time.Second},
+		{attempt: 10, base: max},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			got := reconnectBackoff(initial, max, tt.attempt)
+			jitter := time.Duration(float64(tt.base) * reconnectJitter)
+			if got < tt.base-jitter || got > tt.base+jitter {
+				t.Errorf("reconnectBackoff() = %s, want %s +/- %s", got, tt.base, jitter)
+			}
+		})
+	}
+}
+
+func Test_connectionManager_reconnect(t *testing.T) {
+	attempts := 0
+	connect := func(string) error {
+		attempts++
+		// the initial connect and the first reconnect attempt fail
+		if attempts <= 2 {
+			return io.EOF
+		}
+		return nil
+	}
+	cfg := &config.SBI{Address: "dev", ConnectRetry: time.Millisecond, MaxBackoff: time.Millisecond}
+	c := newConnectionManager("TestDev", cfg, connect, func() error { return nil })
+
+	if err := c.Connect(); err == nil {
+		t.Fatal("expected initial connect to fail")
+	}
+	// pretend the initial connect succeeded, such that the connection breaks
+	c.state = ConnectionStateConnected
+	c.HandleError(errors.New("rpc-error: invalid value"))
+	if !c.IsConnected() {
+		t.Fatal("non connection errors must not break the connection")
+	}
+
+	c.HandleError(io.EOF)
+	expected := []ConnectionState{
+		ConnectionStateConnecting,
+		ConnectionStateDisconnected,
+		ConnectionStateDisconnected,
+		ConnectionStateConnecting,
+		ConnectionStateDisconnected,
+		ConnectionStateConnecting,
+		ConnectionStateConnected,
+	}
+	for i, s := range expected {
+		select {
+		case ev := <-c.Events():
+			if ev.State != s {
+				t.Errorf("event %d: expected state %s, got %s", i, s, ev.State)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d: timeout waiting for state %s", i, s)
+		}
+	}
+}
+
+func Test_connectionManager_maxRetries(t *testing.T) {
+	cfg := &config.SBI{Address: "dev", ConnectRetry: time.Millisecond, MaxBackoff: time.Millisecond, MaxRetries: 2}
+	c := newConnectionManager("TestDev", cfg, func(string) error { return io.EOF }, func() error { return nil })
+	c.state = ConnectionStateConnected
+	c.HandleError(io.EOF)
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case ev := <-c.Events():
+			if ev.State == ConnectionStateFailed {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timeout waiting for state %s", ConnectionStateFailed)
+		}
+	}
+}
+
+func Test_connectionManager_failover(t *testing.T) {
+	down := map[string]bool{"inband": true}
+	var tried []string
+	connect := func(addr string) error {
+		tried = append(tried, addr)
+		if down[addr] {
+			return syscall.ECONNREFUSED
+		}
+		return nil
+	}
+	cfg := &config.SBI{Address: "inband", Addresses: []string{"oob"}, ConnectRetry: time.Millisecond, MaxBackoff: time.Millisecond}
+	c := newConnectionManager("TestDev", cfg, connect, func() error { return nil })
+
+	if err := c.Connect(); err != nil {
+		t.Fatalf("expected connect to fail over, got %v", err)
+	}
+	if a := c.ActiveAddress(); a != "oob" {
+		t.Errorf("expected active address oob, got %s", a)
+	}
+	if diff := cmp.Diff([]string{"inband", "oob"}, tried); diff != "" {
+		t.Errorf("tried addresses mismatch (-want +got):\n%s", diff)
+	}
+
+	// the preferred address is tried first on reconnect
+	down = map[string]bool{"oob": true}
+	c.HandleError(io.EOF)
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case ev := <-c.Events():
+			if ev.State != ConnectionStateConnected || ev.Address != "inband" {
+				continue
+			}
+			if a := c.ActiveAddress(); a != "inband" {
+				t.Errorf("expected active address inband, got %s", a)
+			}
+			return
+		case <-timeout:
+			t.Fatal("timeout waiting for the reconnect to inband")
+		}
+	}
+}
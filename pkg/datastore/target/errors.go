@@ -0,0 +1,130 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/datastore/target/netconf/types"
+)
+
+// SBIErrorDetail is a single error reported by the target.
+type SBIErrorDetail struct {
+	Severity string
+	// Tag is the NETCONF error-tag or the gRPC status code
+	Tag string
+	// Type is the NETCONF error-type
+	Type   string
+	AppTag string
+	Path   string
+	// Message is the error message of the target
+	Message string
+}
+
+// SBIError is the error returned by the targets when the device rejects a request.
+// It implements GRPCStatus, such that the details reach the northbound clients as errdetails.ErrorInfo.
+type SBIError struct {
+	Target  string
+	Code    codes.Code
+	Details []*SBIErrorDetail
+}
+
+func (e *SBIError) Error() string {
+	msgs := make([]string, 0, len(e.Details))
+	for _, d := range e.Details {
+		msg := d.Tag
+		if d.Path != "" {
+			msg += " at " + d.Path
+		}
+		if d.Message != "" {
+			msg += ": " + d.Message
+		}
+		msgs = append(msgs, msg)
+	}
+	return fmt.Sprintf("target %s rejected the request: %s", e.Target, strings.Join(msgs, "; "))
+}
+
+// GRPCStatus returns the status carrying an errdetails.ErrorInfo per detail.
+func (e *SBIError) GRPCStatus() *status.Status {
+	st := status.New(e.Code, e.Error())
+	for _, d := range e.Details {
+		metadata := map[string]string{}
+		for k, v := range map[string]string{"severity": d.Severity, "type": d.Type, "app-tag": d.AppTag, "path": d.Path, "message": d.Message} {
+			if v != "" {
+				metadata[k] = v
+			}
+		}
+		withDetails, err := st.WithDetails(&errdetails.ErrorInfo{Reason: d.Tag, Domain: e.Target, Metadata: metadata})
+		if err != nil {
+			return st
+		}
+		st = withDetails
+	}
+	return st
+}
+
+// newNetconfSBIError turns the rpc-errors returned by the NETCONF driver into an SBIError.
+// Other errors are returned unchanged.
+func newNetconfSBIError(target string, err error) error {
+	var rpcErrs types.RPCErrors
+	if !errors.As(err, &rpcErrs) {
+		return err
+	}
+	sbiErr := &SBIError{Target: target, Code: codes.FailedPrecondition, Details: make([]*SBIErrorDetail, 0, len(rpcErrs))}
+	for _, re := range rpcErrs {
+		sbiErr.Details = append(sbiErr.Details, &SBIErrorDetail{
+			Severity: re.Severity,
+			Tag:      re.Tag,
+			Type:     re.Type,
+			AppTag:   re.AppTag,
+			Path:     re.Path,
+			Message:  re.Message,
+		})
+	}
+	return sbiErr
+}
+
+// newGNMISBIError turns the gRPC status returned by a gNMI target into an SBIError.
+// Errors that are not a gRPC status, and status codes that indicate a connection problem, are returned unchanged.
+func newGNMISBIError(target string, err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch st.Code() {
+	case codes.OK, codes.Unavailable, codes.Canceled, codes.DeadlineExceeded:
+		return err
+	}
+	sbiErr := &SBIError{Target: target, Code: st.Code()}
+	d := &SBIErrorDetail{Severity: "error", Tag: st.Code().String(), Message: st.Message()}
+	sbiErr.Details = append(sbiErr.Details, d)
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.ErrorInfo:
+			sbiErr.Details = append(sbiErr.Details, &SBIErrorDetail{Severity: "error", Tag: detail.GetReason(), Message: detail.GetMetadata()["message"]})
+		case *errdetails.BadRequest:
+			for _, fv := range detail.GetFieldViolations() {
+				sbiErr.Details = append(sbiErr.Details, &SBIErrorDetail{Severity: "error", Tag: codes.InvalidArgument.String(), Path: fv.GetField(), Message: fv.GetDescription()})
+			}
+		}
+	}
+	return sbiErr
+}
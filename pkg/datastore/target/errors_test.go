@@ -0,0 +1,119 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/datastore/target/netconf/types"
+)
+
+func Test_newNetconfSBIError(t *testing.T) {
+	doc := etree.NewDocument()
+	err := doc.ReadFromString(`<rpc-reply message-id="101" xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">
+  <rpc-error>
+    <error-type>application</error-type>
+    <error-tag>invalid-value</error-tag>
+    <error-severity>error</error-severity>
+    <error-path>/interfaces/interface[name='eth0']/mtu</error-path>
+    <error-message xml:lang="en">MTU out of range</error-message>
+  </rpc-error>
+  <rpc-error>
+    <error-type>application</error-type>
+    <error-tag>operation-failed</error-tag>
+    <error-severity>warning</error-severity>
+    <error-message>deprecated leaf</error-message>
+  </rpc-error>
+</rpc-reply>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	driverErr := fmt.Errorf("edit-config: %w", types.RPCErrors(types.ParseRPCErrors(doc, "error")))
+
+	err = newNetconfSBIError("dev1", driverErr)
+	var sbiErr *SBIError
+	if !errors.As(err, &sbiErr) {
+		t.Fatalf("expected an SBIError, got %T", err)
+	}
+	want := []*SBIErrorDetail{
+		{
+			Severity: "error",
+			Tag:      "invalid-value",
+			Type:     "application",
+			Path:     "/interfaces/interface[name='eth0']/mtu",
+			Message:  "MTU out of range",
+		},
+	}
+	if diff := cmp.Diff(want, sbiErr.Details); diff != "" {
+		t.Errorf("details mismatch (-want +got):\n%s", diff)
+	}
+
+	st := status.Convert(fmt.Errorf("set intent: %w", err))
+	if st.Code() != codes.FailedPrecondition {
+		t.Errorf("expected code %s, got %s", codes.FailedPrecondition, st.Code())
+	}
+	if len(st.Details()) != 1 {
+		t.Fatalf("expected 1 status detail, got %d", len(st.Details()))
+	}
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	if !ok {
+		t.Fatalf("expected ErrorInfo, got %T", st.Details()[0])
+	}
+	if info.GetReason() != "invalid-value" || info.GetMetadata()["path"] != want[0].Path {
+		t.Errorf("unexpected ErrorInfo: %v", info)
+	}
+
+	// other errors are returned unchanged
+	plain := errors.New("EOF")
+	if err := newNetconfSBIError("dev1", plain); err != plain {
+		t.Errorf("expected the error unchanged, got %v", err)
+	}
+}
+
+func Test_newGNMISBIError(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "invalid value").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "/interface[name=eth0]/mtu", Description: "out of range"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sbiErr *SBIError
+	if !errors.As(newGNMISBIError("dev1", st.Err()), &sbiErr) {
+		t.Fatal("expected an SBIError")
+	}
+	if sbiErr.Code != codes.InvalidArgument {
+		t.Errorf("expected code %s, got %s", codes.InvalidArgument, sbiErr.Code)
+	}
+	want := []*SBIErrorDetail{
+		{Severity: "error", Tag: "InvalidArgument", Message: "invalid value"},
+		{Severity: "error", Tag: "InvalidArgument", Path: "/interface[name=eth0]/mtu", Message: "out of range"},
+	}
+	if diff := cmp.Diff(want, sbiErr.Details); diff != "" {
+		t.Errorf("details mismatch (-want +got):\n%s", diff)
+	}
+
+	unavailable := status.Error(codes.Unavailable, "connection refused")
+	if err := newGNMISBIError("dev1", unavailable); err != unavailable {
+		t.Errorf("expected the error unchanged, got %v", err)
+	}
+}
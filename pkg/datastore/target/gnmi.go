@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 	"time"
@@ -29,8 +30,8 @@ import (
 	gtarget "github.com/openconfig/gnmic/pkg/target"
 	"github.com/openconfig/gnmic/pkg/types"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
 
@@ -46,16 +47,42 @@ type gnmiTarget struct {
 	target    *gtarget.Target
 	encodings map[gnmi.Encoding]struct{}
 	cfg       *config.SBI
+	// serializes the Get and Set RPCs on the device, subscriptions are not covered since they retry until cancelled
+	queue *opQueue
 }
 
+// newGNMITarget creates the gNMI target, connected via the first of the configured addresses that is reachable.
 func newGNMITarget(ctx context.Context, name string, cfg *config.SBI, opts ...grpc.DialOption) (*gnmiTarget, error) {
+	var errs []error
+	for _, addr := range cfg.AddressList() {
+		gt, err := newGNMITargetAddress(ctx, name, addr, cfg, opts...)
+		if err == nil {
+			return gt, nil
+		}
+		log.Debugf("%s: failed connecting to %s: %v", name, addr, err)
+		errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("no address configured")
+	}
+	return nil, errors.Join(errs...)
+}
+
+func newGNMITargetAddress(ctx context.Context, name string, address string, cfg *config.SBI, opts ...grpc.DialOption) (*gnmiTarget, error) {
 	tc := &types.TargetConfig{
 		Name:       name,
-		Address:    fmt.Sprintf("%s:%d", cfg.Address, cfg.Port),
-		Timeout:    10 * time.Second,
+		Address:    fmt.Sprintf("%s:%d", address, cfg.Port),
+		Timeout:    cfg.ConnectTimeout,
 		RetryTimer: 2 * time.Second,
 		BufferSize: 100,
 	}
+	if cfg.Keepalive > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.Keepalive,
+			Timeout:             cfg.IdleTimeout,
+			PermitWithoutStream: true,
+		}))
+	}
 	if cfg.Credentials != nil {
 		tc.Username = &cfg.Credentials.Username
 		tc.Password = &cfg.Credentials.Password
@@ -72,6 +99,7 @@ func newGNMITarget(ctx context.Context, name string, cfg *config.SBI, opts ...gr
 		target:    gtarget.NewTarget(tc),
 		encodings: make(map[gnmi.Encoding]struct{}),
 		cfg:       cfg,
+		queue:     newOpQueue(),
 	}
 	err := gt.target.CreateGNMIClient(ctx, opts...)
 	if err != nil {
@@ -80,6 +108,7 @@ func newGNMITarget(ctx context.Context, name string, cfg *config.SBI, opts ...gr
 	// discover supported encodings
 	capResp, err := gt.target.Capabilities(ctx)
 	if err != nil {
+		gt.target.Close()
 		return nil, err
 	}
 	for _, enc := range capResp.GetSupportedEncodings() {
@@ -87,6 +116,7 @@ func newGNMITarget(ctx context.Context, name string, cfg *config.SBI, opts ...gr
 	}
 
 	if _, exists := gt.encodings[gnmi.Encoding(encoding(cfg.GnmiOptions.Encoding))]; !exists {
+		gt.target.Close()
 		return nil, fmt.Errorf("encoding %q not supported", cfg.GnmiOptions.Encoding)
 	}
 
@@ -122,6 +152,11 @@ func sdcpbEncodingToGNMIENcoding(x sdcpb.Encoding) (gnmi.Encoding, error) {
 }
 
 func (t *gnmiTarget) Get(ctx context.Context, req *sdcpb.GetDataRequest) (*sdcpb.GetDataResponse, error) {
+	return t.get(ctx, req, opPriorityRead)
+}
+
+// get executes the gnmi get, queued with the given priority.
+func (t *gnmiTarget) get(ctx context.Context, req *sdcpb.GetDataRequest, prio opPriority) (*sdcpb.GetDataResponse, error) {
 	var err error
 	gnmiReq := &gnmi.GetRequest{
 		Path: make([]*gnmi.Path, 0, len(req.GetPath())),
@@ -141,8 +176,15 @@ func (t *gnmiTarget) Get(ctx context.Context, req *sdcpb.GetDataRequest) (*sdcpb
 	if err != nil {
 		return nil, err
 	}
+	gnmiReq.Prefix = t.originPrefix()
+	ctx, cancel := t.rpcContext(ctx)
+	defer cancel()
 	// execute the gnmi get
-	gnmiRsp, err := t.target.Get(ctx, gnmiReq)
+	var gnmiRsp *gnmi.GetResponse
+	err = t.queue.do(ctx, prio, func() error {
+		gnmiRsp, err = t.target.Get(ctx, gnmiReq)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -157,14 +199,100 @@ func (t *gnmiTarget) Get(ctx context.Context, req *sdcpb.GetDataRequest) (*sdcpb
 }
 
 func (t *gnmiTarget) Set(ctx context.Context, source TargetSource) (*sdcpb.SetDataResponse, error) {
-	var upds []*sdcpb.Update
-	var deletes []*sdcpb.Path
-	var err error
+	if t == nil {
+		return nil, fmt.Errorf("%s", "not connected")
+	}
+
+	setReq, err := t.setRequest(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	return t.set(ctx, setReq)
+}
 
+// setRequest creates the SetRequest that applies the changes of the source according to the apply mode.
+func (t *gnmiTarget) setRequest(ctx context.Context, source TargetSource) (*gnmi.SetRequest, error) {
+	switch t.cfg.GnmiOptions.ApplyMode {
+	case "replace", "union-replace":
+		return t.replaceSetRequest(ctx, source)
+	default:
+		return t.updateSetRequest(ctx, source)
+	}
+}
+
+// ReplaceAll replaces the complete configuration of the target with the content of the source,
+// using a single replace at the root path.
+func (t *gnmiTarget) ReplaceAll(ctx context.Context, source TargetSource) (*sdcpb.SetDataResponse, error) {
 	if t == nil {
 		return nil, fmt.Errorf("%s", "not connected")
 	}
 
+	var val *gnmi.TypedValue
+	switch strings.ToLower(t.cfg.GnmiOptions.Encoding) {
+	case "json":
+		jsonData, err := source.ToJson(false)
+		if err != nil {
+			return nil, err
+		}
+		jsonBytes, err := json.Marshal(jsonData)
+		if err != nil {
+			return nil, err
+		}
+		val = &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonVal{JsonVal: jsonBytes}}
+	case "json_ietf":
+		jsonData, err := source.ToJsonIETF(false)
+		if err != nil {
+			return nil, err
+		}
+		jsonBytes, err := json.Marshal(jsonData)
+		if err != nil {
+			return nil, err
+		}
+		val = &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: jsonBytes}}
+	default:
+		return nil, fmt.Errorf("full config replace requires encoding json or json_ietf, got %s", t.cfg.GnmiOptions.Encoding)
+	}
+
+	return t.set(ctx, &gnmi.SetRequest{
+		Prefix:  t.originPrefix(),
+		Replace: []*gnmi.Update{{Path: &gnmi.Path{}, Val: val}},
+	})
+}
+
+// set sends the SetRequest to the target and converts the response.
+func (t *gnmiTarget) set(ctx context.Context, setReq *gnmi.SetRequest) (*sdcpb.SetDataResponse, error) {
+	log.Debugf("gnmi set request:\n%s", prototext.Format(setReq))
+
+	ctx, cancel := t.rpcContext(ctx)
+	defer cancel()
+	var rsp *gnmi.SetResponse
+	err := t.queue.do(ctx, opPriorityApply, func() error {
+		var err error
+		rsp, err = t.target.Set(ctx, setReq)
+		return err
+	})
+	if err != nil {
+		return nil, newGNMISBIError(t.target.Config.Name, err)
+	}
+	schemaSetRsp := &sdcpb.SetDataResponse{
+		Response:  make([]*sdcpb.UpdateResult, 0, len(rsp.GetResponse())),
+		Timestamp: rsp.GetTimestamp(),
+	}
+	for _, updr := range rsp.GetResponse() {
+		schemaSetRsp.Response = append(schemaSetRsp.Response, &sdcpb.UpdateResult{
+			Path: utils.FromGNMIPath(rsp.GetPrefix(), updr.GetPath()),
+			Op:   sdcpb.UpdateResult_Operation(updr.GetOp()),
+		})
+	}
+	return schemaSetRsp, nil
+}
+
+// updateSetRequest creates the SetRequest that applies the changes as fine-grained updates and deletes.
+func (t *gnmiTarget) updateSetRequest(ctx context.Context, source TargetSource) (*gnmi.SetRequest, error) {
+	var upds []*sdcpb.Update
+	var deletes []*sdcpb.Path
+	var err error
+
 	switch strings.ToLower(t.cfg.GnmiOptions.Encoding) {
 	case "json":
 		jsonData, err := source.ToJson(true)
@@ -202,7 +330,7 @@ func (t *gnmiTarget) Set(ctx context.Context, source TargetSource) (*sdcpb.SetDa
 			return nil, err
 		}
 
-	case "proto":
+	case "proto", "ascii":
 		upds, err = source.ToProtoUpdates(ctx, true)
 		if err != nil {
 			return nil, err
@@ -214,6 +342,7 @@ func (t *gnmiTarget) Set(ctx context.Context, source TargetSource) (*sdcpb.SetDa
 	}
 
 	setReq := &gnmi.SetRequest{
+		Prefix: t.originPrefix(),
 		Delete: make([]*gnmi.Path, 0, len(deletes)),
 		Update: make([]*gnmi.Update, 0, len(upds)),
 	}
@@ -222,27 +351,51 @@ func (t *gnmiTarget) Set(ctx context.Context, source TargetSource) (*sdcpb.SetDa
 		setReq.Delete = append(setReq.Delete, gdel)
 	}
 	for _, upd := range upds {
-		gupd := t.convertKeyUpdates(upd)
+		var gupd *gnmi.Update
+		if strings.EqualFold(t.cfg.GnmiOptions.Encoding, "ascii") {
+			// with ascii encoding, all values including the keys are sent as their string representation
+			gupd = &gnmi.Update{
+				Path: utils.ToGNMIPath(upd.GetPath()),
+				Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_AsciiVal{AsciiVal: utils.TypedValueToString(upd.GetValue())}},
+			}
+		} else {
+			gupd = t.convertKeyUpdates(upd)
+		}
 		setReq.Update = append(setReq.Update, gupd)
 	}
+	return setReq, nil
+}
 
-	log.Debugf("gnmi set request:\n%s", prototext.Format(setReq))
-
-	rsp, err := t.target.Set(ctx, setReq)
+// replaceSetRequest creates the SetRequest that applies the changes by replacing the changed branches as a whole,
+// either as replace or union_replace depending on the apply mode.
+func (t *gnmiTarget) replaceSetRequest(ctx context.Context, source TargetSource) (*gnmi.SetRequest, error) {
+	ietf := strings.EqualFold(t.cfg.GnmiOptions.Encoding, "json_ietf")
+	replaces, deletes, err := source.ToProtoBranchReplaces(ctx, ietf)
 	if err != nil {
 		return nil, err
 	}
-	schemaSetRsp := &sdcpb.SetDataResponse{
-		Response:  make([]*sdcpb.UpdateResult, 0, len(rsp.GetResponse())),
-		Timestamp: rsp.GetTimestamp(),
+
+	setReq := &gnmi.SetRequest{
+		Prefix: t.originPrefix(),
+		Delete: make([]*gnmi.Path, 0, len(deletes)),
 	}
-	for _, updr := range rsp.GetResponse() {
-		schemaSetRsp.Response = append(schemaSetRsp.Response, &sdcpb.UpdateResult{
-			Path: utils.FromGNMIPath(rsp.GetPrefix(), updr.GetPath()),
-			Op:   sdcpb.UpdateResult_Operation(updr.GetOp()),
+	for _, del := range deletes {
+		setReq.Delete = append(setReq.Delete, utils.ToGNMIPath(del))
+	}
+	greplaces := make([]*gnmi.Update, 0, len(replaces))
+	for _, r := range replaces {
+		greplaces = append(greplaces, &gnmi.Update{
+			Path: utils.ToGNMIPath(r.GetPath()),
+			Val:  utils.ToGNMITypedValue(r.GetValue()),
 		})
 	}
-	return schemaSetRsp, nil
+	switch t.cfg.GnmiOptions.ApplyMode {
+	case "union-replace":
+		setReq.UnionReplace = greplaces
+	default:
+		setReq.Replace = greplaces
+	}
+	return setReq, nil
 }
 
 func (t *gnmiTarget) Status() string {
@@ -252,6 +405,18 @@ func (t *gnmiTarget) Status() string {
 	return t.target.ConnState()
 }
 
+// ActiveAddress returns the address the target is connected to.
+func (t *gnmiTarget) ActiveAddress() string {
+	if t == nil || t.target == nil || t.target.Config == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(t.target.Config.Address)
+	if err != nil {
+		return t.target.Config.Address
+	}
+	return host
+}
+
 func (t *gnmiTarget) Sync(octx context.Context, syncConfig *config.Sync, syncCh chan *SyncUpdate) {
 	if t != nil && t.target != nil && t.target.Config != nil {
 		log.Infof("starting target %s sync", t.target.Config.Name)
@@ -266,8 +431,11 @@ START:
 	ctx, cancel = context.WithCancel(octx)
 	defer cancel()
 
+	// the stores of the subscriptions, by subscription name
+	stores := make(map[string]string, len(syncConfig.Config))
 	// todo: do not run read subscriptions for GET
 	for _, gnmiSync := range syncConfig.Config {
+		stores[gnmiSync.Name] = syncStore(gnmiSync)
 		switch gnmiSync.Mode {
 		case "once":
 			err = t.periodicSync(ctx, gnmiSync)
@@ -297,7 +465,7 @@ START:
 			switch r := rsp.Response.Response.(type) {
 			case *gnmi.SubscribeResponse_Update:
 				syncCh <- &SyncUpdate{
-					Store:  rsp.SubscriptionName,
+					Store:  stores[rsp.SubscriptionName],
 					Update: utils.ToSchemaNotification(r.Update),
 				}
 			}
@@ -322,7 +490,46 @@ func (t *gnmiTarget) Close() error {
 	return t.target.Close()
 }
 
+// rpcContext returns the context bounding a single RPC by the configured timeout.
+func (t *gnmiTarget) rpcContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.cfg.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, t.cfg.Timeout)
+}
+
+// originPrefix returns the prefix carrying the configured origin, nil if no origin is configured.
+func (t *gnmiTarget) originPrefix() *gnmi.Path {
+	if t.cfg.GnmiOptions.Origin == "" {
+		return nil
+	}
+	return &gnmi.Path{Origin: t.cfg.GnmiOptions.Origin}
+}
+
+// syncEncoding returns the encoding of the sync protocol, falling back to the encoding configured for the target.
+func (t *gnmiTarget) syncEncoding(gnmiSync *config.SyncProtocol) string {
+	if gnmiSync.Encoding != "" {
+		return gnmiSync.Encoding
+	}
+	return t.cfg.GnmiOptions.Encoding
+}
+
+// subscribeOpts returns the subscribe request options that are common to all the sync subscriptions.
+func (t *gnmiTarget) subscribeOpts(gnmiSync *config.SyncProtocol) []gapi.GNMIOption {
+	opts := []gapi.GNMIOption{
+		gapi.EncodingCustom(encoding(t.syncEncoding(gnmiSync))),
+	}
+	if t.cfg.GnmiOptions.Origin != "" {
+		opts = append(opts, gapi.Prefix(t.cfg.GnmiOptions.Origin+":"))
+	}
+	return opts
+}
+
 func sdcpbEncoding(e string) int {
+	// the sdcpb counterpart of the gnmi ascii encoding is string
+	if strings.EqualFold(e, "ascii") {
+		return int(sdcpb.Encoding_STRING)
+	}
 	enc, ok := sdcpb.Encoding_value[strings.ToUpper(e)]
 	if ok {
 		return int(enc)
@@ -362,24 +569,26 @@ func (t *gnmiTarget) getSync(ctx context.Context, gnmiSync *config.SyncProtocol,
 	req := &sdcpb.GetDataRequest{
 		Name:     gnmiSync.Name,
 		Path:     paths,
-		DataType: sdcpb.DataType_CONFIG,
+		DataType: syncDataType(gnmiSync),
 		Datastore: &sdcpb.DataStore{
 			Type: sdcpb.Type_MAIN,
 		},
-		Encoding: sdcpb.Encoding(sdcpbEncoding(gnmiSync.Encoding)),
+		Encoding: sdcpb.Encoding(sdcpbEncoding(t.syncEncoding(gnmiSync))),
 	}
+	store := syncStore(gnmiSync)
 
-	go t.internalGetSync(ctx, req, syncCh)
+	go t.internalGetSync(ctx, req, store, true, syncCh)
 
 	go func() {
-		ticker := time.NewTicker(gnmiSync.Interval)
-		defer ticker.Stop()
+		timer := time.NewTimer(nextSyncDelay(gnmiSync, time.Now()))
+		defer timer.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
-				t.internalGetSync(ctx, req, syncCh)
+			case <-timer.C:
+				t.internalGetSync(ctx, req, store, false, syncCh)
+				timer.Reset(nextSyncDelay(gnmiSync, time.Now()))
 			}
 		}
 	}()
@@ -387,9 +596,9 @@ func (t *gnmiTarget) getSync(ctx context.Context, gnmiSync *config.SyncProtocol,
 	return nil
 }
 
-func (t *gnmiTarget) internalGetSync(ctx context.Context, req *sdcpb.GetDataRequest, syncCh chan *SyncUpdate) {
-	// execute gnmi get
-	resp, err := t.Get(ctx, req)
+func (t *gnmiTarget) internalGetSync(ctx context.Context, req *sdcpb.GetDataRequest, store string, force bool, syncCh chan *SyncUpdate) {
+	// execute gnmi get, behind the operations applying intents
+	resp, err := t.get(ctx, req, opPrioritySync)
 	if err != nil {
 		log.Errorf("sync error: %v", err)
 		return
@@ -397,11 +606,15 @@ func (t *gnmiTarget) internalGetSync(ctx context.Context, req *sdcpb.GetDataRequ
 
 	// push notifications into syncCh
 	syncCh <- &SyncUpdate{
+		Store: store,
 		Start: true,
+		Paths: req.GetPath(),
+		Force: force,
 	}
 	notificationsCount := 0
 	for _, n := range resp.GetNotification() {
 		syncCh <- &SyncUpdate{
+			Store:  store,
 			Update: n,
 		}
 		notificationsCount++
@@ -413,13 +626,11 @@ func (t *gnmiTarget) internalGetSync(ctx context.Context, req *sdcpb.GetDataRequ
 }
 
 func (t *gnmiTarget) periodicSync(ctx context.Context, gnmiSync *config.SyncProtocol) error {
-	opts := make([]gapi.GNMIOption, 0)
 	subscriptionOpts := make([]gapi.GNMIOption, 0)
 	for _, p := range gnmiSync.Paths {
 		subscriptionOpts = append(subscriptionOpts, gapi.Path(p))
 	}
-	opts = append(opts,
-		gapi.EncodingCustom(encoding(gnmiSync.Encoding)),
+	opts := append(t.subscribeOpts(gnmiSync),
 		gapi.SubscriptionListModeONCE(),
 		gapi.Subscription(subscriptionOpts...),
 	)
@@ -431,14 +642,15 @@ func (t *gnmiTarget) periodicSync(ctx context.Context, gnmiSync *config.SyncProt
 	go t.target.Subscribe(ctx, subReq, gnmiSync.Name)
 	// periodic subscribe ONCE
 	go func(gnmiSync *config.SyncProtocol) {
-		ticker := time.NewTicker(gnmiSync.Interval)
-		defer ticker.Stop()
+		timer := time.NewTimer(nextSyncDelay(gnmiSync, time.Now()))
+		defer timer.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
+			case <-timer.C:
 				t.target.Subscribe(ctx, subReq, gnmiSync.Name)
+				timer.Reset(nextSyncDelay(gnmiSync, time.Now()))
 			}
 		}
 	}(gnmiSync)
@@ -446,7 +658,6 @@ func (t *gnmiTarget) periodicSync(ctx context.Context, gnmiSync *config.SyncProt
 }
 
 func (t *gnmiTarget) streamSync(ctx context.Context, gnmiSync *config.SyncProtocol) error {
-	opts := make([]gapi.GNMIOption, 0)
 	subscriptionOpts := make([]gapi.GNMIOption, 0)
 	for _, p := range gnmiSync.Paths {
 		subscriptionOpts = append(subscriptionOpts, gapi.Path(p))
@@ -461,8 +672,7 @@ func (t *gnmiTarget) streamSync(ctx context.Context, gnmiSync *config.SyncProtoc
 	if gnmiSync.Interval > 0 {
 		subscriptionOpts = append(subscriptionOpts, gapi.SampleInterval(gnmiSync.Interval))
 	}
-	opts = append(opts,
-		gapi.EncodingCustom(encoding(gnmiSync.Encoding)),
+	opts := append(t.subscribeOpts(gnmiSync),
 		gapi.SubscriptionListModeSTREAM(),
 		gapi.Subscription(subscriptionOpts...),
 	)
@@ -477,7 +687,7 @@ func (t *gnmiTarget) streamSync(ctx context.Context, gnmiSync *config.SyncProtoc
 }
 
 func (t *gnmiTarget) convertKeyUpdates(upd *sdcpb.Update) *gnmi.Update {
-	if !pathIsKeyAsLeaf(upd.GetPath()) {
+	if !utils.PathIsKeyAsLeaf(upd.GetPath()) {
 		return &gnmi.Update{
 			Path: utils.ToGNMIPath(upd.GetPath()),
 			Val:  utils.ToGNMITypedValue(upd.GetValue()),
@@ -512,13 +722,3 @@ func (t *gnmiTarget) convertKeyUpdates(upd *sdcpb.Update) *gnmi.Update {
 		Val:  utils.ToGNMITypedValue(val),
 	}
 }
-
-func pathIsKeyAsLeaf(p *sdcpb.Path) bool {
-	numPElem := len(p.GetElem())
-	if numPElem < 2 {
-		return false
-	}
-
-	_, ok := p.GetElem()[numPElem-2].GetKey()[p.GetElem()[numPElem-1].GetName()]
-	return ok
-}
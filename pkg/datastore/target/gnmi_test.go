@@ -0,0 +1,225 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	gapi "github.com/openconfig/gnmic/pkg/api"
+	"github.com/sdcio/data-server/pkg/config"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestGnmiTarget_subscribeOpts(t *testing.T) {
+	tests := []struct {
+		name             string
+		gnmiOpts         *config.SBIGnmiOptions
+		syncEncoding     string
+		expectedEncoding gnmi.Encoding
+		expectedOrigin   string
+	}{
+		{
+			name:             "target encoding, no origin",
+			gnmiOpts:         &config.SBIGnmiOptions{Encoding: "json_ietf"},
+			expectedEncoding: gnmi.Encoding_JSON_IETF,
+		},
+		{
+			name:             "sync encoding overrides target encoding",
+			gnmiOpts:         &config.SBIGnmiOptions{Encoding: "json_ietf"},
+			syncEncoding:     "proto",
+			expectedEncoding: gnmi.Encoding_PROTO,
+		},
+		{
+			name:             "ascii with origin",
+			gnmiOpts:         &config.SBIGnmiOptions{Encoding: "ascii", Origin: "native"},
+			expectedEncoding: gnmi.Encoding_ASCII,
+			expectedOrigin:   "native",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gt := &gnmiTarget{cfg: &config.SBI{GnmiOptions: tt.gnmiOpts}}
+			syncProto := &config.SyncProtocol{Encoding: tt.syncEncoding}
+
+			opts := append(gt.subscribeOpts(syncProto),
+				gapi.SubscriptionListModeSTREAM(),
+				gapi.Subscription(gapi.Path("/interface")),
+			)
+			subReq, err := gapi.NewSubscribeRequest(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := subReq.GetSubscribe().GetEncoding(); got != tt.expectedEncoding {
+				t.Errorf("expected encoding %s, got %s", tt.expectedEncoding, got)
+			}
+			if got := subReq.GetSubscribe().GetPrefix().GetOrigin(); got != tt.expectedOrigin {
+				t.Errorf("expected origin %q, got %q", tt.expectedOrigin, got)
+			}
+			if got := gt.originPrefix().GetOrigin(); got != tt.expectedOrigin {
+				t.Errorf("expected prefix origin %q, got %q", tt.expectedOrigin, got)
+			}
+		})
+	}
+}
+
+func Test_sdcpbEncoding(t *testing.T) {
+	tests := map[string]sdcpb.Encoding{
+		"ascii":     sdcpb.Encoding_STRING,
+		"json":      sdcpb.Encoding_JSON,
+		"JSON_IETF": sdcpb.Encoding_JSON_IETF,
+		"proto":     sdcpb.Encoding_PROTO,
+	}
+	for in, expected := range tests {
+		if got := sdcpb.Encoding(sdcpbEncoding(in)); got != expected {
+			t.Errorf("sdcpbEncoding(%q) expected %s, got %s", in, expected, got)
+		}
+	}
+}
+
+// branchReplacesSource is a TargetSource that only provides the branch replaces
+type branchReplacesSource struct {
+	TargetSource
+	replaces []*sdcpb.Update
+	deletes  []*sdcpb.Path
+}
+
+func (b *branchReplacesSource) ToProtoBranchReplaces(ctx context.Context, ietf bool) ([]*sdcpb.Update, []*sdcpb.Path, error) {
+	return b.replaces, b.deletes, nil
+}
+
+func TestGnmiTarget_replaceSetRequest(t *testing.T) {
+	replacePath := &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}}}
+	deletePath := &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/2"}}}}
+	jsonVal := []byte(`{"description":"foo","name":"ethernet-1/1"}`)
+
+	gReplace := []*gnmi.Update{{
+		Path: &gnmi.Path{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}}},
+		Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: jsonVal}},
+	}}
+	gDelete := []*gnmi.Path{{Elem: []*gnmi.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/2"}}}}}
+
+	tests := []struct {
+		name     string
+		gnmiOpts *config.SBIGnmiOptions
+		expected *gnmi.SetRequest
+	}{
+		{
+			name:     "replace",
+			gnmiOpts: &config.SBIGnmiOptions{Encoding: "json_ietf", ApplyMode: "replace"},
+			expected: &gnmi.SetRequest{Replace: gReplace, Delete: gDelete},
+		},
+		{
+			name:     "union-replace with origin",
+			gnmiOpts: &config.SBIGnmiOptions{Encoding: "json_ietf", ApplyMode: "union-replace", Origin: "openconfig"},
+			expected: &gnmi.SetRequest{Prefix: &gnmi.Path{Origin: "openconfig"}, UnionReplace: gReplace, Delete: gDelete},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			source := &branchReplacesSource{
+				replaces: []*sdcpb.Update{{Path: replacePath, Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonIetfVal{JsonIetfVal: jsonVal}}}},
+				deletes:  []*sdcpb.Path{deletePath},
+			}
+
+			gt := &gnmiTarget{cfg: &config.SBI{GnmiOptions: tt.gnmiOpts}}
+			setReq, err := gt.replaceSetRequest(ctx, source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expected, setReq, protocmp.Transform()); diff != "" {
+				t.Errorf("replaceSetRequest() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_syncStore(t *testing.T) {
+	tests := []struct {
+		name         string
+		sync         *config.SyncProtocol
+		wantStore    string
+		wantDataType sdcpb.DataType
+	}{
+		{
+			name:         "config",
+			sync:         &config.SyncProtocol{DataType: "config"},
+			wantDataType: sdcpb.DataType_CONFIG,
+		},
+		{
+			name:         "unset",
+			sync:         &config.SyncProtocol{},
+			wantDataType: sdcpb.DataType_CONFIG,
+		},
+		{
+			name:         "state",
+			sync:         &config.SyncProtocol{DataType: "state"},
+			wantStore:    SyncStoreState,
+			wantDataType: sdcpb.DataType_STATE,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := syncStore(tt.sync); got != tt.wantStore {
+				t.Errorf("syncStore() = %q, want %q", got, tt.wantStore)
+			}
+			if got := syncDataType(tt.sync); got != tt.wantDataType {
+				t.Errorf("syncDataType() = %v, want %v", got, tt.wantDataType)
+			}
+		})
+	}
+}
+
+func Test_nextSyncDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 17, 30, 0, time.UTC)
+	tests := []struct {
+		name string
+		sync *config.SyncProtocol
+		min  time.Duration
+		max  time.Duration
+	}{
+		{
+			name: "interval",
+			sync: &config.SyncProtocol{Interval: time.Minute},
+			min:  time.Minute,
+			max:  time.Minute,
+		},
+		{
+			name: "aligned",
+			sync: &config.SyncProtocol{Interval: time.Hour, Align: true},
+			min:  42*time.Minute + 30*time.Second,
+			max:  42*time.Minute + 30*time.Second,
+		},
+		{
+			name: "jitter",
+			sync: &config.SyncProtocol{Interval: time.Minute, Jitter: 10 * time.Second},
+			min:  time.Minute,
+			max:  time.Minute + 10*time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextSyncDelay(tt.sync, now)
+			if got < tt.min || got > tt.max {
+				t.Errorf("nextSyncDelay() = %s, want between %s and %s", got, tt.min, tt.max)
+			}
+		})
+	}
+}
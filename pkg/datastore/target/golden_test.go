@@ -0,0 +1,310 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/mocks/mocknetconf"
+	"github.com/sdcio/data-server/pkg/config"
+	SchemaClient "github.com/sdcio/data-server/pkg/datastore/clients/schema"
+	"github.com/sdcio/data-server/pkg/datastore/target/netconf/types"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/encoding/prototext"
+)
+
+// the golden files are recorded with: go test ./pkg/datastore/target -run TestGolden -update-golden
+var updateGolden = flag.Bool("update-golden", false, "record the southbound payloads of the golden tests as the new golden files")
+
+const goldenDir = "testdata/golden"
+
+// goldenCase describes the change of an intent, the southbound payloads that are generated
+// for it are compared against the golden files of the case.
+type goldenCase struct {
+	name string
+	// intended is the stored version of the intent, nil for a new intent
+	intended []*sdcpb.Update
+	// running is the config of the device
+	running []*sdcpb.Update
+	// update is the new version of the intent, nil deletes the intent
+	update            []*sdcpb.Update
+	deleteAggregation tree.DeleteAggregation
+}
+
+func TestGolden(t *testing.T) {
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := testhelper.LoadConfigFixture(t, scb, "sdcio_config.json")
+
+	// ethernet-1/32 and the bridged subinterface of ethernet-1/31 are removed, ethernet-1/1 is changed
+	changed := goldenWithout(cfg, "interface[name=ethernet-1/32]", "interface[name=ethernet-1/31]/subinterface[index=1]")
+	changed = goldenWithValue(changed, "interface[name=ethernet-1/1]/description", &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "uplink 1 to spine1"}})
+	changed = goldenWithValue(changed, "interface[name=ethernet-1/1]/mtu", &sdcpb.TypedValue{Value: &sdcpb.TypedValue_UintVal{UintVal: 9200}})
+
+	tests := []goldenCase{
+		{
+			name:   "new-intent",
+			update: cfg,
+		},
+		{
+			name:     "update-intent",
+			intended: cfg,
+			running:  cfg,
+			update:   changed,
+		},
+		{
+			name:              "update-intent-leaf-aggregation",
+			intended:          cfg,
+			running:           cfg,
+			update:            changed,
+			deleteAggregation: tree.DeleteAggregationLeaf,
+		},
+		{
+			name:     "delete-intent",
+			intended: cfg,
+			running:  cfg,
+		},
+		{
+			name:              "delete-intent-ancestor-aggregation",
+			intended:          cfg,
+			running:           cfg,
+			deleteAggregation: tree.DeleteAggregationAncestor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("netconf", func(t *testing.T) {
+				source := goldenSource(t, scb, tt)
+				checkGolden(t, tt.name+".netconf.xml", goldenNetconfPayload(t, source))
+			})
+			t.Run("gnmi", func(t *testing.T) {
+				source := goldenSource(t, scb, tt)
+				checkGolden(t, tt.name+".gnmi.txt", goldenGnmiPayload(t, source))
+			})
+		})
+	}
+}
+
+// goldenSource populates the tree like the datastore does for a SetIntent of the case.
+func goldenSource(t *testing.T, scb SchemaClient.SchemaClientBound, gc goldenCase) *tree.RootEntry {
+	t.Helper()
+	ctx := context.Background()
+	owner := "intent1"
+	prio := int32(10)
+
+	intended := testhelper.FixtureCacheUpdates(t, gc.intended, prio, owner)
+	running := testhelper.FixtureCacheUpdates(t, gc.running, tree.RunningValuesPrio, tree.RunningIntentName)
+	cacheClient := mockcacheclient.NewMockClient(gomock.NewController(t))
+	testhelper.ConfigureCacheClientMock(t, cacheClient, intended, running, nil, nil)
+
+	tc := tree.NewTreeContext(tree.NewTreeSchemaCacheClient("dev1", cacheClient, scb), owner)
+	tc.SetDeleteAggregation(gc.deleteAggregation)
+	tc.SetStoreIndex(tree.NewStoreIndexFromUpdates(intended...))
+	root, err := tree.NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range running {
+		if _, err := root.AddCacheUpdateRecursive(ctx, u, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newUpds := testhelper.FixtureCacheUpdates(t, gc.update, prio, owner)
+	pathKeySet := tree.NewPathSet()
+	for _, u := range newUpds {
+		pathKeySet.AddPath(u.GetPath())
+	}
+	root.LoadIntendedStoreOwnerData(ctx, owner, pathKeySet)
+	for _, u := range newUpds {
+		if _, err := root.AddCacheUpdateRecursive(ctx, u, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.FinishInsertionPhase()
+	return root
+}
+
+// goldenNetconfPayload returns the edit-config payload the netconf target sends for the source.
+func goldenNetconfPayload(t *testing.T, source TargetSource) string {
+	t.Helper()
+	var payload string
+	d := mocknetconf.NewMockDriver(gomock.NewController(t))
+	d.EXPECT().EditConfig("candidate", gomock.Any()).DoAndReturn(
+		func(_ string, config string) (*types.NetconfResponse, error) {
+			payload = config
+			return types.NewNetconfResponse(etree.NewDocument()), nil
+		},
+	)
+	d.EXPECT().Commit().Return(nil)
+
+	tr := &ncTarget{
+		name:   "TestDev",
+		driver: d,
+		conn:   testConnectionManager(true),
+		sbiConfig: &config.SBI{NetconfOptions: &config.SBINetconfOptions{
+			CommitDatastore:        "candidate",
+			IncludeNS:              true,
+			OperationWithNamespace: true,
+			UseOperationRemove:     true,
+		}},
+	}
+	if _, err := tr.Set(TestCtx, source); err != nil {
+		t.Fatal(err)
+	}
+
+	// the payload is indented for readable diffs
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(payload); err != nil {
+		t.Fatal(err)
+	}
+	doc.Indent(2)
+	s, err := doc.WriteToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// goldenGnmiPayload returns the SetRequest the gnmi target sends for the source.
+func goldenGnmiPayload(t *testing.T, source TargetSource) string {
+	t.Helper()
+	gt := &gnmiTarget{cfg: &config.SBI{GnmiOptions: &config.SBIGnmiOptions{Encoding: "json_ietf"}}}
+	setReq, err := gt.setRequest(TestCtx, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return formatGoldenSetRequest(t, setReq)
+}
+
+// formatGoldenSetRequest renders the SetRequest with its paths as xpath, its deletes sorted and its JSON values
+// indented. Unlike the text format of the proto, this yields stable and readable diffs.
+func formatGoldenSetRequest(t *testing.T, setReq *gnmi.SetRequest) string {
+	t.Helper()
+	sb := &strings.Builder{}
+	if o := setReq.GetPrefix().GetOrigin(); o != "" {
+		fmt.Fprintf(sb, "origin: %s\n", o)
+	}
+	// the deletes of a SetRequest are applied as a whole, their order is not significant
+	deletes := make([]string, 0, len(setReq.GetDelete()))
+	for _, p := range setReq.GetDelete() {
+		deletes = append(deletes, goldenXPath(p))
+	}
+	slices.Sort(deletes)
+	for _, p := range deletes {
+		fmt.Fprintf(sb, "delete: %s\n", p)
+	}
+	ops := []struct {
+		name string
+		upds []*gnmi.Update
+	}{
+		{name: "replace", upds: setReq.GetReplace()},
+		{name: "union_replace", upds: setReq.GetUnionReplace()},
+		{name: "update", upds: setReq.GetUpdate()},
+	}
+	for _, op := range ops {
+		for _, u := range op.upds {
+			fmt.Fprintf(sb, "%s: %s\n", op.name, goldenXPath(u.GetPath()))
+			var b []byte
+			switch v := u.GetVal().GetValue().(type) {
+			case *gnmi.TypedValue_JsonIetfVal:
+				b = v.JsonIetfVal
+			case *gnmi.TypedValue_JsonVal:
+				b = v.JsonVal
+			default:
+				sb.WriteString(prototext.MarshalOptions{}.Format(u.GetVal()))
+				sb.WriteString("\n")
+				continue
+			}
+			var v any
+			if err := json.Unmarshal(b, &v); err != nil {
+				t.Fatal(err)
+			}
+			b, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sb.Write(b)
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func goldenXPath(p *gnmi.Path) string {
+	return "/" + utils.ToXPath(utils.FromGNMIPath(nil, p), false)
+}
+
+// checkGolden compares the payload with the golden file, which is written instead with -update-golden.
+func checkGolden(t *testing.T, name string, payload string) {
+	t.Helper()
+	file := filepath.Join(goldenDir, name)
+	if *updateGolden {
+		if err := os.MkdirAll(goldenDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(payload), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	golden, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("%v, record the golden file with -update-golden", err)
+	}
+	if diff := cmp.Diff(string(golden), payload); diff != "" {
+		t.Errorf("payload differs from %s (-golden +got):\n%s", file, diff)
+	}
+}
+
+// goldenWithout returns the updates that are not at or below the given xpaths.
+func goldenWithout(upds []*sdcpb.Update, xpaths ...string) []*sdcpb.Update {
+	return slices.DeleteFunc(slices.Clone(upds), func(u *sdcpb.Update) bool {
+		xpath := utils.ToXPath(u.GetPath(), false)
+		return slices.ContainsFunc(xpaths, func(p string) bool {
+			return xpath == p || strings.HasPrefix(xpath, p+"/")
+		})
+	})
+}
+
+// goldenWithValue returns the updates with the value of the given xpath replaced.
+func goldenWithValue(upds []*sdcpb.Update, xpath string, tv *sdcpb.TypedValue) []*sdcpb.Update {
+	result := make([]*sdcpb.Update, 0, len(upds))
+	for _, u := range upds {
+		if utils.ToXPath(u.GetPath(), false) == xpath {
+			u = &sdcpb.Update{Path: u.GetPath(), Value: tv}
+		}
+		result = append(result, u)
+	}
+	return result
+}
@@ -19,26 +19,25 @@ import (
 	"errors"
 	"fmt"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/beevik/etree"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 
 	"github.com/sdcio/data-server/pkg/config"
 	schemaClient "github.com/sdcio/data-server/pkg/datastore/clients/schema"
 	"github.com/sdcio/data-server/pkg/datastore/target/netconf"
 	"github.com/sdcio/data-server/pkg/datastore/target/netconf/driver/scrapligo"
+	"github.com/sdcio/data-server/pkg/datastore/target/netconf/types"
 	"github.com/sdcio/data-server/pkg/utils"
 )
 
 type ncTarget struct {
 	name   string
 	driver netconf.Driver
-
-	m         *sync.Mutex
-	connected bool
+	conn   *connectionManager
+	// serializes the operations on the device
+	queue *opQueue
 
 	schemaClient     schemaClient.SchemaClientBound
 	sbiConfig        *config.SBI
@@ -48,24 +47,90 @@ type ncTarget struct {
 func newNCTarget(_ context.Context, name string, cfg *config.SBI, schemaClient schemaClient.SchemaClientBound) (*ncTarget, error) {
 	t := &ncTarget{
 		name:             name,
-		m:                new(sync.Mutex),
-		connected:        false,
 		schemaClient:     schemaClient,
 		sbiConfig:        cfg,
 		xml2sdcpbAdapter: netconf.NewXML2sdcpbConfigAdapter(schemaClient),
+		queue:            newOpQueue(),
 	}
-	var err error
+	t.conn = newConnectionManager(name, cfg, t.connectDriver, t.closeDriver)
 	// create a new NETCONF driver
-	t.driver, err = scrapligo.NewScrapligoNetconfTarget(cfg)
+	err := t.conn.Connect()
 	if err != nil {
 		return t, err
 	}
-	t.connected = true
+	if cfg.Keepalive > 0 {
+		go t.keepalive()
+	}
 	return t, nil
 }
 
-func (t *ncTarget) Get(ctx context.Context, req *sdcpb.GetDataRequest) (*sdcpb.GetDataResponse, error) {
-	if !t.connected {
+// ncKeepaliveFilter is a subtree filter that selects no data
+const ncKeepaliveFilter = `<keepalive xmlns="urn:sdcio:data-server:keepalive"/>`
+
+// keepalive periodically probes the session with a get-config that selects no data,
+// such that idle sessions are kept open and dead sessions are detected.
+func (t *ncTarget) keepalive() {
+	ticker := time.NewTicker(t.sbiConfig.Keepalive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.conn.Done():
+			return
+		case <-ticker.C:
+			if !t.conn.IsConnected() {
+				continue
+			}
+			t.conn.HandleError(t.probe())
+		}
+	}
+}
+
+// probe sends a keepalive probe, it returns errKeepaliveTimeout if the probe is not answered within the idle timeout.
+// Errors reported by the target are ignored, since they prove the session is alive.
+func (t *ncTarget) probe() error {
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := t.driver.GetConfig("running", ncKeepaliveFilter)
+		errCh <- err
+	}()
+	select {
+	case err := <-errCh:
+		if isConnectionError(err) {
+			return err
+		}
+		return nil
+	case <-time.After(t.sbiConfig.IdleTimeout):
+		return errKeepaliveTimeout
+	}
+}
+
+// connectDriver creates a new NETCONF driver, connected to the target via the given address
+func (t *ncTarget) connectDriver(address string) error {
+	d, err := scrapligo.NewScrapligoNetconfTarget(address, t.sbiConfig)
+	if err != nil {
+		return err
+	}
+	t.driver = d
+	return nil
+}
+
+func (t *ncTarget) closeDriver() error {
+	if t.driver == nil {
+		return nil
+	}
+	return t.driver.Close()
+}
+
+func (t *ncTarget) Get(ctx context.Context, req *sdcpb.GetDataRequest) (rsp *sdcpb.GetDataResponse, err error) {
+	err = t.queue.do(ctx, opPriorityRead, func() error {
+		rsp, err = t.get(ctx, req)
+		return err
+	})
+	return rsp, err
+}
+
+func (t *ncTarget) get(ctx context.Context, req *sdcpb.GetDataRequest) (*sdcpb.GetDataResponse, error) {
+	if !t.conn.IsConnected() {
 		return nil, fmt.Errorf("not connected")
 	}
 	var source string
@@ -83,6 +148,7 @@ func (t *ncTarget) Get(ctx context.Context, req *sdcpb.GetDataRequest) (*sdcpb.G
 			HonorNamespace:         t.sbiConfig.NetconfOptions.IncludeNS,
 			OperationWithNamespace: t.sbiConfig.NetconfOptions.OperationWithNamespace,
 			UseOperationRemove:     t.sbiConfig.NetconfOptions.UseOperationRemove,
+			NamespacePrefixes:      t.sbiConfig.NetconfOptions.NamespacePrefixes,
 		})
 
 	// add all the requested paths to the document
@@ -100,14 +166,17 @@ func (t *ncTarget) Get(ctx context.Context, req *sdcpb.GetDataRequest) (*sdcpb.G
 	}
 	log.Debugf("netconf filter:\n%s", filterDoc)
 
-	// execute the GetConfig rpc
-	ncResponse, err := t.driver.GetConfig(source, filterDoc)
+	var ncResponse *types.NetconfResponse
+	switch req.GetDataType() {
+	case sdcpb.DataType_STATE:
+		// execute the Get rpc, which returns config and state
+		ncResponse, err = t.driver.Get(filterDoc)
+	default:
+		// execute the GetConfig rpc
+		ncResponse, err = t.driver.GetConfig(source, filterDoc)
+	}
 	if err != nil {
-		if strings.Contains(err.Error(), "EOF") {
-			t.Close()
-			t.connected = false
-			go t.reconnect()
-		}
+		t.conn.HandleError(err)
 		return nil, err
 	}
 
@@ -133,8 +202,16 @@ func (t *ncTarget) Get(ctx context.Context, req *sdcpb.GetDataRequest) (*sdcpb.G
 	return result, nil
 }
 
-func (t *ncTarget) Set(ctx context.Context, source TargetSource) (*sdcpb.SetDataResponse, error) {
-	if !t.connected {
+func (t *ncTarget) Set(ctx context.Context, source TargetSource) (rsp *sdcpb.SetDataResponse, err error) {
+	err = t.queue.do(ctx, opPriorityApply, func() error {
+		rsp, err = t.set(ctx, source)
+		return err
+	})
+	return rsp, err
+}
+
+func (t *ncTarget) set(ctx context.Context, source TargetSource) (*sdcpb.SetDataResponse, error) {
+	if !t.conn.IsConnected() {
 		return nil, fmt.Errorf("not connected")
 	}
 	switch t.sbiConfig.NetconfOptions.CommitDatastore {
@@ -148,31 +225,43 @@ func (t *ncTarget) Set(ctx context.Context, source TargetSource) (*sdcpb.SetData
 }
 
 func (t *ncTarget) Status() string {
-	if t == nil || t.driver == nil {
-		return "NOT_CONNECTED"
+	if t == nil || t.driver == nil || t.conn == nil {
+		return string(ConnectionStateDisconnected)
 	}
-	if t.driver.IsAlive() {
-		return "CONNECTED"
+	state := t.conn.State()
+	if state == ConnectionStateConnected && !t.driver.IsAlive() {
+		return string(ConnectionStateDisconnected)
 	}
-	return "NOT_CONNECTED"
+	return string(state)
+}
+
+// ActiveAddress returns the address the target is connected to.
+func (t *ncTarget) ActiveAddress() string {
+	return t.conn.ActiveAddress()
+}
+
+// ConnectionEvents returns the channel the connection state changes of the target are reported on.
+func (t *ncTarget) ConnectionEvents() <-chan *ConnectionEvent {
+	return t.conn.Events()
 }
 
 func (t *ncTarget) Sync(ctx context.Context, syncConfig *config.Sync, syncCh chan *SyncUpdate) {
-	log.Infof("starting target %s [%s] sync", t.name, t.sbiConfig.Address)
+	log.Infof("starting target %s [%s] sync", t.name, t.conn.ActiveAddress())
 
 	for _, ncc := range syncConfig.Config {
 		// periodic get
 		log.Debugf("target %s, starting sync: %s, Interval: %s, Paths: [ \"%s\" ]", t.name, ncc.Name, ncc.Interval.String(), strings.Join(ncc.Paths, "\", \""))
 		go func(ncSync *config.SyncProtocol) {
 			t.internalSync(ctx, ncSync, true, syncCh)
-			ticker := time.NewTicker(ncSync.Interval)
-			defer ticker.Stop()
+			timer := time.NewTimer(nextSyncDelay(ncSync, time.Now()))
+			defer timer.Stop()
 			for {
 				select {
 				case <-ctx.Done():
 					return
-				case <-ticker.C:
+				case <-timer.C:
 					t.internalSync(ctx, ncSync, false, syncCh)
+					timer.Reset(nextSyncDelay(ncSync, time.Now()))
 				}
 			}
 		}(ncc)
@@ -185,7 +274,7 @@ func (t *ncTarget) Sync(ctx context.Context, syncConfig *config.Sync, syncCh cha
 }
 
 func (t *ncTarget) internalSync(ctx context.Context, sc *config.SyncProtocol, force bool, syncCh chan *SyncUpdate) {
-	if !t.connected {
+	if !t.conn.IsConnected() {
 		return
 	}
 	// iterate syncConfig
@@ -205,31 +294,35 @@ func (t *ncTarget) internalSync(ctx context.Context, sc *config.SyncProtocol, fo
 	req := &sdcpb.GetDataRequest{
 		Name:     sc.Name,
 		Path:     paths,
-		DataType: sdcpb.DataType_CONFIG,
+		DataType: syncDataType(sc),
 		Datastore: &sdcpb.DataStore{
 			Type: sdcpb.Type_MAIN,
 		},
 	}
 
-	// execute netconf get
-	resp, err := t.Get(ctx, req)
+	// execute netconf get, behind the operations applying intents
+	var resp *sdcpb.GetDataResponse
+	err := t.queue.do(ctx, opPrioritySync, func() error {
+		var err error
+		resp, err = t.get(ctx, req)
+		return err
+	})
 	if err != nil {
 		log.Errorf("failed getting config: %T | %v", err, err)
-		if strings.Contains(err.Error(), "EOF") {
-			t.Close()
-			t.connected = false
-			go t.reconnect()
-		}
+		t.conn.HandleError(err)
 		return
 	}
 	// push notifications into syncCh
 	syncCh <- &SyncUpdate{
+		Store: syncStore(sc),
 		Start: true,
+		Paths: paths,
 		Force: force,
 	}
 	notificationsCount := 0
 	for _, n := range resp.GetNotification() {
 		syncCh <- &SyncUpdate{
+			Store:  syncStore(sc),
 			Update: n,
 		}
 		notificationsCount++
@@ -244,35 +337,15 @@ func (t *ncTarget) Close() error {
 	if t == nil {
 		return nil
 	}
+	if t.conn != nil {
+		t.conn.Stop()
+	}
 	if t.driver == nil {
 		return nil
 	}
 	return t.driver.Close()
 }
 
-func (t *ncTarget) reconnect() {
-	t.m.Lock()
-	defer t.m.Unlock()
-
-	if t.connected {
-		return
-	}
-
-	var err error
-	log.Infof("%s: NETCONF reconnecting...", t.name)
-	for {
-		t.driver, err = scrapligo.NewScrapligoNetconfTarget(t.sbiConfig)
-		if err != nil {
-			log.Errorf("failed to create NETCONF driver: %v", err)
-			time.Sleep(t.sbiConfig.ConnectRetry)
-			continue
-		}
-		log.Infof("%s: NETCONF reconnected...", t.name)
-		t.connected = true
-		return
-	}
-}
-
 func (t *ncTarget) setRunning(source TargetSource) (*sdcpb.SetDataResponse, error) {
 
 	xtree, err := source.ToXML(true, t.sbiConfig.NetconfOptions.IncludeNS, t.sbiConfig.NetconfOptions.OperationWithNamespace, t.sbiConfig.NetconfOptions.UseOperationRemove)
@@ -298,13 +371,8 @@ func (t *ncTarget) setRunning(source TargetSource) (*sdcpb.SetDataResponse, erro
 	resp, err := t.driver.EditConfig("running", xdoc)
 	if err != nil {
 		log.Errorf("datastore %s failed edit-config: %v", t.name, err)
-		if strings.Contains(err.Error(), "EOF") {
-			t.Close()
-			t.connected = false
-			go t.reconnect()
-			return nil, err
-		}
-		return nil, err
+		t.conn.HandleError(err)
+		return nil, newNetconfSBIError(t.name, err)
 	}
 
 	// retrieve netconf rpc-error -> warnings as string array
@@ -318,6 +386,74 @@ func (t *ncTarget) setRunning(source TargetSource) (*sdcpb.SetDataResponse, erro
 	}, nil
 }
 
+// ReplaceAll replaces the complete configuration of the commit-datastore with the content
+// of the source using a copy-config. The candidate is committed afterwards.
+func (t *ncTarget) ReplaceAll(ctx context.Context, source TargetSource) (rsp *sdcpb.SetDataResponse, err error) {
+	err = t.queue.do(ctx, opPriorityApply, func() error {
+		rsp, err = t.replaceAll(ctx, source)
+		return err
+	})
+	return rsp, err
+}
+
+func (t *ncTarget) replaceAll(_ context.Context, source TargetSource) (*sdcpb.SetDataResponse, error) {
+	if !t.conn.IsConnected() {
+		return nil, fmt.Errorf("not connected")
+	}
+	xtree, err := source.ToXML(false, t.sbiConfig.NetconfOptions.IncludeNS, t.sbiConfig.NetconfOptions.OperationWithNamespace, t.sbiConfig.NetconfOptions.UseOperationRemove)
+	if err != nil {
+		return nil, err
+	}
+	datastore := t.sbiConfig.NetconfOptions.CommitDatastore
+
+	rpc := etree.NewDocument()
+	copyConfig := rpc.CreateElement("copy-config")
+	copyConfig.CreateElement("target").CreateElement(datastore)
+	cfg := copyConfig.CreateElement("source").CreateElement("config")
+	for _, c := range xtree.ChildElements() {
+		cfg.AddChild(c.Copy())
+	}
+	xdoc, err := rpc.WriteToString()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("datastore %s copy-config:\n%s\n", t.name, xdoc)
+
+	resp, err := t.driver.RPC(xdoc)
+	if err != nil {
+		log.Errorf("datastore %s failed copy-config: %v", t.name, err)
+		if isConnectionError(err) {
+			t.conn.HandleError(err)
+			return nil, err
+		}
+		if datastore == "candidate" {
+			err2 := t.driver.Discard()
+			if err2 != nil {
+				log.Errorf("failed with %v while discarding pending changes after error %v", err2, err)
+			}
+		}
+		return nil, newNetconfSBIError(t.name, err)
+	}
+	warnings, err := filterRPCErrors(resp.Doc, "warning")
+	if err != nil {
+		return nil, fmt.Errorf("filtering netconf rpc-errors with severity warnings: %w", err)
+	}
+
+	if datastore == "candidate" {
+		log.Infof("datastore %s: committing changes on target", t.name)
+		err = t.driver.Commit()
+		if err != nil {
+			t.conn.HandleError(err)
+			return nil, newNetconfSBIError(t.name, err)
+		}
+	}
+	return &sdcpb.SetDataResponse{
+		Warnings:  warnings,
+		Timestamp: time.Now().UnixNano(),
+	}, nil
+}
+
 // filterRPCErrors takes the given etree.Document, filters the document for rpc-errors with the given severity
 // and returns them collectively as a []string
 func filterRPCErrors(xml *etree.Document, severity string) ([]string, error) {
@@ -334,22 +470,120 @@ func filterRPCErrors(xml *etree.Document, severity string) ([]string, error) {
 	return result, nil
 }
 
-func (t *ncTarget) setCandidate(source TargetSource) (*sdcpb.SetDataResponse, error) {
+// Diff edits the candidate with the changes of the source, retrieves the diff
+// via the configured diff-rpc and discards the candidate afterwards.
+func (t *ncTarget) Diff(ctx context.Context, source TargetSource) (diff string, err error) {
+	err = t.queue.do(ctx, opPriorityApply, func() error {
+		diff, err = t.diff(source)
+		return err
+	})
+	return diff, err
+}
+
+func (t *ncTarget) diff(source TargetSource) (string, error) {
+	if !t.conn.IsConnected() {
+		return "", fmt.Errorf("not connected")
+	}
+	if t.sbiConfig.NetconfOptions.DiffRPC == "" {
+		return "", fmt.Errorf("target %s: no diff-rpc configured", t.name)
+	}
+	if t.sbiConfig.NetconfOptions.CommitDatastore != "candidate" {
+		return "", fmt.Errorf("target %s: device diffs require the candidate commit-datastore", t.name)
+	}
 	xtree, err := source.ToXML(true, t.sbiConfig.NetconfOptions.IncludeNS, t.sbiConfig.NetconfOptions.OperationWithNamespace, t.sbiConfig.NetconfOptions.UseOperationRemove)
+	if err != nil {
+		return "", err
+	}
+	xdoc, err := xtree.WriteToString()
+	if err != nil {
+		return "", err
+	}
+	// no changes, no diff
+	if len(xdoc) == 0 {
+		return "", nil
+	}
+
+	_, err = t.driver.EditConfig("candidate", xdoc)
+	defer func() {
+		err := t.driver.Discard()
+		if err != nil {
+			log.Errorf("datastore %s failed discarding the dry-run changes: %v", t.name, err)
+		}
+	}()
+	if err != nil {
+		if isConnectionError(err) {
+			t.conn.HandleError(err)
+			return "", err
+		}
+		return "", newNetconfSBIError(t.name, err)
+	}
+
+	resp, err := t.driver.RPC(t.sbiConfig.NetconfOptions.DiffRPC)
+	if err != nil {
+		if isConnectionError(err) {
+			t.conn.HandleError(err)
+			return "", err
+		}
+		return "", newNetconfSBIError(t.name, err)
+	}
+	return diffText(resp.Doc), nil
+}
+
+// diffText returns the text content of the diff-rpc reply.
+func diffText(doc *etree.Document) string {
+	if doc == nil || doc.Root() == nil {
+		return ""
+	}
+	lines := []string{}
+	for _, e := range append([]*etree.Element{doc.Root()}, doc.Root().FindElements(".//*")...) {
+		if text := strings.TrimSpace(e.Text()); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (t *ncTarget) setCandidate(source TargetSource) (*sdcpb.SetDataResponse, error) {
+	rpcWarnings, changed, err := t.editCandidate(source)
 	if err != nil {
 		return nil, err
 	}
+	// if there was no data in the xml document, there is nothing to commit
+	if !changed {
+		return &sdcpb.SetDataResponse{
+			Timestamp: time.Now().UnixNano(),
+		}, nil
+	}
+
+	log.Infof("datastore %s: committing changes on target", t.name)
+	// commit the config
+	err = t.driver.Commit()
+	if err != nil {
+		t.conn.HandleError(err)
+		return nil, newNetconfSBIError(t.name, err)
+	}
+	return &sdcpb.SetDataResponse{
+		Warnings:  rpcWarnings,
+		Timestamp: time.Now().UnixNano(),
+	}, nil
+}
+
+// editCandidate edits the candidate with the changes of the source, returning the rpc warnings
+// and whether the source contained any changes. The candidate is discarded if the edit fails.
+func (t *ncTarget) editCandidate(source TargetSource) ([]string, bool, error) {
+	xtree, err := source.ToXML(true, t.sbiConfig.NetconfOptions.IncludeNS, t.sbiConfig.NetconfOptions.OperationWithNamespace, t.sbiConfig.NetconfOptions.UseOperationRemove)
+	if err != nil {
+		return nil, false, err
+	}
 
 	xdoc, err := xtree.WriteToString()
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
 	// if there was no data in the xml document, continue
 	if len(xdoc) == 0 {
-		return &sdcpb.SetDataResponse{
-			Timestamp: time.Now().UnixNano(),
-		}, nil
+		return nil, false, nil
 	}
 
 	log.Debugf("datastore %s XML:\n%s\n", t.name, xdoc)
@@ -358,33 +592,48 @@ func (t *ncTarget) setCandidate(source TargetSource) (*sdcpb.SetDataResponse, er
 	resp, err := t.driver.EditConfig("candidate", xdoc)
 	if err != nil {
 		log.Errorf("datastore %s failed edit-config: %v", t.name, err)
-		if strings.Contains(err.Error(), "EOF") {
-			t.Close()
-			t.connected = false
-			go t.reconnect()
-			return nil, err
+		if isConnectionError(err) {
+			t.conn.HandleError(err)
+			return nil, false, err
 		}
 		err2 := t.driver.Discard()
 		if err2 != nil {
 			// log failed discard
 			log.Errorf("failed with %v while discarding pending changes after error %v", err2, err)
 		}
-		return nil, err
+		return nil, false, newNetconfSBIError(t.name, err)
 	}
 	rpcWarnings, err := filterRPCErrors(resp.Doc, "warning")
 	if err != nil {
-		return nil, fmt.Errorf("filtering netconf rpc-errors with severity warnings: %w", err)
+		return nil, false, fmt.Errorf("filtering netconf rpc-errors with severity warnings: %w", err)
 	}
+	return rpcWarnings, true, nil
+}
 
-	log.Infof("datastore %s: committing changes on target", t.name)
-	// commit the config
-	err = t.driver.Commit()
+// CanPrepare returns true if the target commits via the candidate datastore,
+// which allows staging changes before committing them.
+func (t *ncTarget) CanPrepare() bool {
+	return t.sbiConfig.NetconfOptions.CommitDatastore == "candidate"
+}
+
+// Prepare edits the candidate with the changes of the source without committing them.
+func (t *ncTarget) Prepare(ctx context.Context, source TargetSource) (rsp *sdcpb.SetDataResponse, err error) {
+	err = t.queue.do(ctx, opPriorityApply, func() error {
+		rsp, err = t.prepare(ctx, source)
+		return err
+	})
+	return rsp, err
+}
+
+func (t *ncTarget) prepare(_ context.Context, source TargetSource) (*sdcpb.SetDataResponse, error) {
+	if !t.conn.IsConnected() {
+		return nil, fmt.Errorf("not connected")
+	}
+	if !t.CanPrepare() {
+		return nil, fmt.Errorf("target %s: preparing changes requires the candidate commit-datastore", t.name)
+	}
+	rpcWarnings, _, err := t.editCandidate(source)
 	if err != nil {
-		if strings.Contains(err.Error(), "EOF") {
-			t.Close()
-			t.connected = false
-			go t.reconnect()
-		}
 		return nil, err
 	}
 	return &sdcpb.SetDataResponse{
@@ -392,3 +641,79 @@ func (t *ncTarget) setCandidate(source TargetSource) (*sdcpb.SetDataResponse, er
 		Timestamp: time.Now().UnixNano(),
 	}, nil
 }
+
+// Commit commits the prepared changes of the candidate.
+func (t *ncTarget) Commit(ctx context.Context) error {
+	return t.queue.do(ctx, opPriorityApply, t.commit)
+}
+
+func (t *ncTarget) commit() error {
+	log.Infof("datastore %s: committing prepared changes on target", t.name)
+	err := t.driver.Commit()
+	if err != nil {
+		t.conn.HandleError(err)
+		return newNetconfSBIError(t.name, err)
+	}
+	return nil
+}
+
+// Discard discards the prepared changes of the candidate.
+func (t *ncTarget) Discard(ctx context.Context) error {
+	return t.queue.do(ctx, opPriorityApply, t.discard)
+}
+
+func (t *ncTarget) discard() error {
+	log.Infof("datastore %s: discarding prepared changes on target", t.name)
+	err := t.driver.Discard()
+	if err != nil {
+		if isConnectionError(err) {
+			t.conn.HandleError(err)
+			return err
+		}
+		return newNetconfSBIError(t.name, err)
+	}
+	return nil
+}
+
+// CommitConfirmed commits the prepared changes of the candidate on probation, the device rolls them back
+// unless the commit is confirmed within the confirm-timeout. The rollback also happens if the session drops.
+func (t *ncTarget) CommitConfirmed(ctx context.Context) error {
+	return t.queue.do(ctx, opPriorityApply, t.commitConfirmed)
+}
+
+func (t *ncTarget) commitConfirmed() error {
+	timeout := int64(t.sbiConfig.NetconfOptions.ConfirmTimeout.Seconds())
+	log.Infof("datastore %s: committing prepared changes on target, to be confirmed within %ds", t.name, timeout)
+	_, err := t.driver.RPC(fmt.Sprintf("<commit><confirmed/><confirm-timeout>%d</confirm-timeout></commit>", timeout))
+	if err != nil {
+		if isConnectionError(err) {
+			t.conn.HandleError(err)
+			return err
+		}
+		return newNetconfSBIError(t.name, err)
+	}
+	return nil
+}
+
+// ConfirmCommit confirms the pending confirmed commit.
+func (t *ncTarget) ConfirmCommit(ctx context.Context) error {
+	return t.queue.do(ctx, opPriorityApply, t.commit)
+}
+
+// CancelCommit rolls back the changes of the pending confirmed commit and discards the candidate.
+func (t *ncTarget) CancelCommit(ctx context.Context) error {
+	return t.queue.do(ctx, opPriorityApply, t.cancelCommit)
+}
+
+func (t *ncTarget) cancelCommit() error {
+	log.Infof("datastore %s: cancelling the confirmed commit on target", t.name)
+	_, err := t.driver.RPC("<cancel-commit/>")
+	if err != nil {
+		if isConnectionError(err) {
+			t.conn.HandleError(err)
+			return err
+		}
+		return newNetconfSBIError(t.name, err)
+	}
+	return t.discard()
+}
@@ -16,9 +16,12 @@ package target
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/beevik/etree"
 	"github.com/google/go-cmp/cmp"
@@ -238,7 +241,7 @@ func Test_ncTarget_Get(t *testing.T) {
 			tr := &ncTarget{
 				name:             tt.fields.name,
 				driver:           tt.fields.getDriver(mockCtrl, t),
-				connected:        tt.fields.connected,
+				conn:             testConnectionManager(tt.fields.connected),
 				schemaClient:     sc,
 				sbiConfig:        tt.fields.sbiConfig,
 				xml2sdcpbAdapter: netconf.NewXML2sdcpbConfigAdapter(sc),
@@ -256,6 +259,347 @@ func Test_ncTarget_Get(t *testing.T) {
 	}
 }
 
+func Test_ncTarget_internalSync_State(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	d := mocknetconf.NewMockDriver(mockCtrl)
+	responseDoc := etree.NewDocument()
+	err := responseDoc.ReadFromString("<data><interface><name>eth0</name><oper-state>up</oper-state></interface></data>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// state is retrieved via <get>, GetConfig must not be called
+	d.EXPECT().Get(gomock.Any()).Return(&types.NetconfResponse{Doc: responseDoc}, nil)
+
+	s := mockschemaclientbound.NewMockSchemaClientBound(mockCtrl)
+	s.EXPECT().GetSchema(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, path *sdcpb.Path) (*sdcpb.GetSchemaResponse, error) {
+			name := path.GetElem()[len(path.GetElem())-1].GetName()
+			if name == "interface" {
+				return &sdcpb.GetSchemaResponse{
+					Schema: &sdcpb.SchemaElem{
+						Schema: &sdcpb.SchemaElem_Container{
+							Container: &sdcpb.ContainerSchema{
+								Name: name,
+								Keys: []*sdcpb.LeafSchema{{Name: "name"}},
+							},
+						},
+					},
+				}, nil
+			}
+			return &sdcpb.GetSchemaResponse{
+				Schema: &sdcpb.SchemaElem{
+					Schema: &sdcpb.SchemaElem_Field{
+						Field: &sdcpb.LeafSchema{
+							Name:    name,
+							IsState: name == "oper-state",
+							Type:    &sdcpb.SchemaLeafType{Type: "string"},
+						},
+					},
+				},
+			}, nil
+		},
+	)
+
+	tr := &ncTarget{
+		name:   "TestDev",
+		driver: d,
+		conn:   testConnectionManager(true),
+		sbiConfig: &config.SBI{
+			NetconfOptions: &config.SBINetconfOptions{},
+		},
+		schemaClient:     s,
+		xml2sdcpbAdapter: netconf.NewXML2sdcpbConfigAdapter(s),
+	}
+
+	syncCh := make(chan *SyncUpdate, 10)
+	tr.internalSync(TestCtx, &config.SyncProtocol{
+		Name:     "state",
+		Paths:    []string{"/interface"},
+		DataType: SyncStoreState,
+	}, true, syncCh)
+	close(syncCh)
+
+	updates := 0
+	for su := range syncCh {
+		if su.Start || su.End {
+			continue
+		}
+		if su.Store != SyncStoreState {
+			t.Errorf("expected store %q, got %q", SyncStoreState, su.Store)
+		}
+		updates += len(su.Update.GetUpdate())
+	}
+	if updates != 2 {
+		t.Errorf("expected 2 updates, got %d", updates)
+	}
+}
+
+func Test_ncTarget_probe(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(*mocknetconf.MockDriver)
+		wantErr error
+	}{
+		{
+			name: "answered",
+			respond: func(d *mocknetconf.MockDriver) {
+				d.EXPECT().GetConfig("running", ncKeepaliveFilter).Return(&types.NetconfResponse{}, nil)
+			},
+		},
+		{
+			name: "rpc error",
+			respond: func(d *mocknetconf.MockDriver) {
+				d.EXPECT().GetConfig("running", ncKeepaliveFilter).Return(nil, errors.New("rpc-error: unknown-namespace"))
+			},
+		},
+		{
+			name: "connection closed",
+			respond: func(d *mocknetconf.MockDriver) {
+				d.EXPECT().GetConfig("running", ncKeepaliveFilter).Return(nil, io.EOF)
+			},
+			wantErr: io.EOF,
+		},
+		{
+			name: "not answered",
+			respond: func(d *mocknetconf.MockDriver) {
+				d.EXPECT().GetConfig("running", ncKeepaliveFilter).DoAndReturn(
+					func(string, string) (*types.NetconfResponse, error) {
+						time.Sleep(100 * time.Millisecond)
+						return &types.NetconfResponse{}, nil
+					},
+				)
+			},
+			wantErr: errKeepaliveTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			d := mocknetconf.NewMockDriver(mockCtrl)
+			tt.respond(d)
+
+			tr := &ncTarget{
+				name:      "TestDev",
+				driver:    d,
+				conn:      testConnectionManager(true),
+				sbiConfig: &config.SBI{Keepalive: time.Second, IdleTimeout: 10 * time.Millisecond},
+			}
+			if err := tr.probe(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ncTarget.probe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			// wait for the pending probe, such that the mock controller sees the call
+			time.Sleep(150 * time.Millisecond)
+			mockCtrl.Finish()
+		})
+	}
+}
+
+// xmlSource is a TargetSource that only provides the xml document
+type xmlSource struct {
+	TargetSource
+	xml string
+}
+
+func (x *xmlSource) ToXML(onlyNewOrUpdated bool, honorNamespace bool, operationWithNamespace bool, useOperationRemove bool) (*etree.Document, error) {
+	doc := etree.NewDocument()
+	if x.xml == "" {
+		return doc, nil
+	}
+	err := doc.ReadFromString(x.xml)
+	return doc, err
+}
+
+func Test_ncTarget_Diff(t *testing.T) {
+	diffRPC := `<get-configuration compare="rollback" rollback="0" format="text"/>`
+	change := `<interface><name>ethernet-1/1</name><description>foo</description></interface>`
+
+	tests := []struct {
+		name    string
+		xml     string
+		opts    *config.SBINetconfOptions
+		respond func(*mocknetconf.MockDriver)
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "diff",
+			xml:  change,
+			opts: &config.SBINetconfOptions{CommitDatastore: "candidate", DiffRPC: diffRPC},
+			respond: func(d *mocknetconf.MockDriver) {
+				reply := etree.NewDocument()
+				err := reply.ReadFromString(`<rpc-reply><configuration-information><configuration-output>
+[edit interfaces ethernet-1/1]
++   description foo;
+</configuration-output></configuration-information></rpc-reply>`)
+				if err != nil {
+					t.Fatal(err)
+				}
+				gomock.InOrder(
+					d.EXPECT().EditConfig("candidate", change).Return(&types.NetconfResponse{}, nil),
+					d.EXPECT().RPC(diffRPC).Return(types.NewNetconfResponse(reply), nil),
+					d.EXPECT().Discard().Return(nil),
+				)
+			},
+			want: "[edit interfaces ethernet-1/1]\n+   description foo;",
+		},
+		{
+			name: "no changes",
+			opts: &config.SBINetconfOptions{CommitDatastore: "candidate", DiffRPC: diffRPC},
+		},
+		{
+			name: "edit-config rejected",
+			xml:  change,
+			opts: &config.SBINetconfOptions{CommitDatastore: "candidate", DiffRPC: diffRPC},
+			respond: func(d *mocknetconf.MockDriver) {
+				d.EXPECT().EditConfig("candidate", change).Return(nil, errors.New("rpc-error: invalid-value"))
+				d.EXPECT().Discard().Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:    "no diff-rpc",
+			xml:     change,
+			opts:    &config.SBINetconfOptions{CommitDatastore: "candidate"},
+			wantErr: true,
+		},
+		{
+			name:    "running datastore",
+			xml:     change,
+			opts:    &config.SBINetconfOptions{CommitDatastore: "running", DiffRPC: diffRPC},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			d := mocknetconf.NewMockDriver(mockCtrl)
+			if tt.respond != nil {
+				tt.respond(d)
+			}
+
+			tr := &ncTarget{
+				name:      "TestDev",
+				driver:    d,
+				conn:      testConnectionManager(true),
+				sbiConfig: &config.SBI{NetconfOptions: tt.opts},
+			}
+			got, err := tr.Diff(TestCtx, &xmlSource{xml: tt.xml})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Diff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ncTarget_ReplaceAll(t *testing.T) {
+	change := `<interface><name>ethernet-1/1</name><description>foo</description></interface>`
+
+	tests := []struct {
+		name    string
+		xml     string
+		opts    *config.SBINetconfOptions
+		respond func(*mocknetconf.MockDriver)
+		wantErr bool
+	}{
+		{
+			name: "running",
+			xml:  change,
+			opts: &config.SBINetconfOptions{CommitDatastore: "running"},
+			respond: func(d *mocknetconf.MockDriver) {
+				d.EXPECT().RPC(`<copy-config><target><running/></target><source><config>`+change+`</config></source></copy-config>`).
+					Return(types.NewNetconfResponse(etree.NewDocument()), nil)
+			},
+		},
+		{
+			name: "candidate",
+			xml:  change,
+			opts: &config.SBINetconfOptions{CommitDatastore: "candidate"},
+			respond: func(d *mocknetconf.MockDriver) {
+				gomock.InOrder(
+					d.EXPECT().RPC(`<copy-config><target><candidate/></target><source><config>`+change+`</config></source></copy-config>`).
+						Return(types.NewNetconfResponse(etree.NewDocument()), nil),
+					d.EXPECT().Commit().Return(nil),
+				)
+			},
+		},
+		{
+			name: "empty config",
+			opts: &config.SBINetconfOptions{CommitDatastore: "running"},
+			respond: func(d *mocknetconf.MockDriver) {
+				d.EXPECT().RPC(`<copy-config><target><running/></target><source><config/></source></copy-config>`).
+					Return(types.NewNetconfResponse(etree.NewDocument()), nil)
+			},
+		},
+		{
+			name: "copy-config rejected",
+			xml:  change,
+			opts: &config.SBINetconfOptions{CommitDatastore: "candidate"},
+			respond: func(d *mocknetconf.MockDriver) {
+				gomock.InOrder(
+					d.EXPECT().RPC(gomock.Any()).Return(nil, errors.New("rpc-error: invalid-value")),
+					d.EXPECT().Discard().Return(nil),
+				)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			d := mocknetconf.NewMockDriver(mockCtrl)
+			if tt.respond != nil {
+				tt.respond(d)
+			}
+
+			tr := &ncTarget{
+				name:      "TestDev",
+				driver:    d,
+				conn:      testConnectionManager(true),
+				sbiConfig: &config.SBI{NetconfOptions: tt.opts},
+			}
+			rsp, err := tr.ReplaceAll(TestCtx, &xmlSource{xml: tt.xml})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReplaceAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(rsp.GetWarnings()) != 0 {
+				t.Errorf("ReplaceAll() warnings = %v", rsp.GetWarnings())
+			}
+		})
+	}
+}
+
+func Test_ncTarget_CommitConfirmed(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	d := mocknetconf.NewMockDriver(mockCtrl)
+	gomock.InOrder(
+		d.EXPECT().RPC(`<commit><confirmed/><confirm-timeout>30</confirm-timeout></commit>`).
+			Return(types.NewNetconfResponse(etree.NewDocument()), nil),
+		d.EXPECT().RPC(`<cancel-commit/>`).Return(types.NewNetconfResponse(etree.NewDocument()), nil),
+		d.EXPECT().Discard().Return(nil),
+	)
+
+	tr := &ncTarget{
+		name:   "TestDev",
+		driver: d,
+		conn:   testConnectionManager(true),
+		sbiConfig: &config.SBI{NetconfOptions: &config.SBINetconfOptions{
+			CommitDatastore: "candidate",
+			ConfirmTimeout:  30 * time.Second,
+		}},
+	}
+	if err := tr.CommitConfirmed(TestCtx); err != nil {
+		t.Fatalf("CommitConfirmed() error = %v", err)
+	}
+	if err := tr.CancelCommit(TestCtx); err != nil {
+		t.Fatalf("CancelCommit() error = %v", err)
+	}
+}
+
 func TestLeafList(t *testing.T) {
 
 	ctx := context.TODO()
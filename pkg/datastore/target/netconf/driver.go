@@ -29,6 +29,8 @@ type Driver interface {
 	Unlock(target string) (*types.NetconfResponse, error)
 	// validate a source datastore
 	Validate(source string) (*types.NetconfResponse, error)
+	// RPC sends the given rpc body and returns the reply
+	RPC(rpc string) (*types.NetconfResponse, error)
 	// Commit applies the candidate changes to the running config
 	Commit() error
 	// discard a candidate config
@@ -15,11 +15,14 @@
 package scrapligo
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/beevik/etree"
 	scraplinetconf "github.com/scrapli/scrapligo/driver/netconf"
 	"github.com/scrapli/scrapligo/driver/options"
+	"github.com/scrapli/scrapligo/response"
 	"github.com/scrapli/scrapligo/util"
 
 	"github.com/sdcio/data-server/pkg/config"
@@ -28,16 +31,19 @@ import (
 
 type ScrapligoNetconfTarget struct {
 	driver *scraplinetconf.Driver
+	// defaultOperation of the edit-config RPCs, merge if empty
+	defaultOperation string
 }
 
-// NewScrapligoNetconfTarget inits a new ScrapligoNetconfTarget which is already connected to the target node
-func NewScrapligoNetconfTarget(cfg *config.SBI) (*ScrapligoNetconfTarget, error) {
+// NewScrapligoNetconfTarget inits a new ScrapligoNetconfTarget which is already connected to the target node via the given address
+func NewScrapligoNetconfTarget(address string, cfg *config.SBI) (*ScrapligoNetconfTarget, error) {
 	opts := []util.Option{
 		options.WithAuthNoStrictKey(),
 		options.WithNetconfForceSelfClosingTags(),
 		options.WithTransportType("standard"),
 		options.WithPort(int(cfg.Port)),
 		options.WithTimeoutOps(cfg.Timeout),
+		options.WithTimeoutSocket(cfg.ConnectTimeout),
 	}
 
 	if cfg.Credentials != nil {
@@ -52,7 +58,7 @@ func NewScrapligoNetconfTarget(cfg *config.SBI) (*ScrapligoNetconfTarget, error)
 		)
 	}
 	// init the netconf driver
-	d, err := scraplinetconf.NewDriver(cfg.Address, opts...)
+	d, err := scraplinetconf.NewDriver(address, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +69,8 @@ func NewScrapligoNetconfTarget(cfg *config.SBI) (*ScrapligoNetconfTarget, error)
 	}
 
 	return &ScrapligoNetconfTarget{
-		driver: d,
+		driver:           d,
+		defaultOperation: cfg.NetconfOptions.DefaultOperation,
 	}, nil
 }
 
@@ -91,6 +98,11 @@ func (snt *ScrapligoNetconfTarget) IsAlive() bool {
 func (snt *ScrapligoNetconfTarget) EditConfig(target string, config string) (*types.NetconfResponse, error) {
 	// add the <config/> tag to the provided config data
 	xdoc := fmt.Sprintf("<config>%s</config>", config)
+	// scrapligo places the payload right after the <target/>, which is where
+	// the <default-operation/> belongs. merge is the NETCONF default.
+	if snt.defaultOperation != "" && snt.defaultOperation != "merge" {
+		xdoc = fmt.Sprintf("<default-operation>%s</default-operation>%s", snt.defaultOperation, xdoc)
+	}
 
 	// send the edit config rpc
 	resp, err := snt.driver.EditConfig(target, xdoc)
@@ -98,7 +110,7 @@ func (snt *ScrapligoNetconfTarget) EditConfig(target string, config string) (*ty
 		return nil, err
 	}
 	if len(resp.ErrorMessages) > 0 {
-		return nil, resp.Failed
+		return nil, failure(resp)
 	}
 
 	// creating a new etree Document and parsing the netconf rpc result
@@ -122,7 +134,7 @@ func (snt *ScrapligoNetconfTarget) GetConfig(source string, filter string) (*typ
 		return nil, err
 	}
 	if resp.Failed != nil {
-		return nil, resp.Failed
+		return nil, failure(resp)
 	}
 
 	// creating a new etree Document and parsing the netconf rpc result
@@ -147,6 +159,24 @@ func (snt *ScrapligoNetconfTarget) GetConfig(source string, filter string) (*typ
 	return types.NewNetconfResponse(x), nil
 }
 
+// RPC sends the given rpc body, e.g. a vendor specific rpc, and returns the reply.
+func (snt *ScrapligoNetconfTarget) RPC(rpc string) (*types.NetconfResponse, error) {
+	resp, err := snt.driver.RPC(createFilterOption(rpc))
+	if err != nil {
+		return nil, err
+	}
+	if resp.Failed != nil {
+		return nil, failure(resp)
+	}
+	x := etree.NewDocument()
+	err = x.ReadFromString(resp.Result)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.NewNetconfResponse(x), nil
+}
+
 func (snt *ScrapligoNetconfTarget) Commit() error {
 	// execute the Commit rpc
 	resp, err := snt.driver.Commit()
@@ -154,7 +184,7 @@ func (snt *ScrapligoNetconfTarget) Commit() error {
 		return err
 	}
 	if resp.Failed != nil {
-		return resp.Failed
+		return failure(resp)
 	}
 	return nil
 }
@@ -165,7 +195,7 @@ func (snt *ScrapligoNetconfTarget) Discard() error {
 		return err
 	}
 	if resp.Failed != nil {
-		return resp.Failed
+		return failure(resp)
 	}
 	return nil
 }
@@ -176,7 +206,7 @@ func (snt *ScrapligoNetconfTarget) Get(filter string) (*types.NetconfResponse, e
 		return nil, err
 	}
 	if resp.Failed != nil {
-		return nil, resp.Failed
+		return nil, failure(resp)
 	}
 	x := etree.NewDocument()
 	err = x.ReadFromString(resp.Result)
@@ -201,7 +231,7 @@ func (snt *ScrapligoNetconfTarget) Lock(target string) (*types.NetconfResponse,
 		return nil, err
 	}
 	if resp.Failed != nil {
-		return nil, resp.Failed
+		return nil, failure(resp)
 	}
 	x := etree.NewDocument()
 	err = x.ReadFromString(resp.Result)
@@ -218,7 +248,7 @@ func (snt *ScrapligoNetconfTarget) Unlock(target string) (*types.NetconfResponse
 		return nil, err
 	}
 	if resp.Failed != nil {
-		return nil, resp.Failed
+		return nil, failure(resp)
 	}
 	x := etree.NewDocument()
 	err = x.ReadFromString(resp.Result)
@@ -235,7 +265,7 @@ func (snt *ScrapligoNetconfTarget) Validate(source string) (*types.NetconfRespon
 		return nil, err
 	}
 	if resp.Failed != nil {
-		return nil, resp.Failed
+		return nil, failure(resp)
 	}
 	x := etree.NewDocument()
 	err = x.ReadFromString(resp.Result)
@@ -252,7 +282,7 @@ func (snt *ScrapligoNetconfTarget) CopyConfig(source, target string) (*types.Net
 		return nil, err
 	}
 	if resp.Failed != nil {
-		return nil, resp.Failed
+		return nil, failure(resp)
 	}
 	x := etree.NewDocument()
 	err = x.ReadFromString(resp.Result)
@@ -275,3 +305,18 @@ func createFilterOption(filter string) util.Option {
 		return nil
 	}
 }
+
+// failure returns the structured rpc-errors of the failed response, or the scrapligo error
+// if the response does not contain any rpc-error with severity error.
+func failure(resp *response.NetconfResponse) error {
+	x := etree.NewDocument()
+	if err := x.ReadFromString(resp.Result); err == nil {
+		if rpcErrs := types.ParseRPCErrors(x, "error"); len(rpcErrs) > 0 {
+			return types.RPCErrors(rpcErrs)
+		}
+	}
+	if resp.Failed != nil {
+		return resp.Failed
+	}
+	return errors.New(strings.Join(resp.ErrorMessages, "; "))
+}
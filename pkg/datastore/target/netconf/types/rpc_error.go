@@ -0,0 +1,84 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+// RPCError is a NETCONF <rpc-error> (RFC 6241, section 4.3).
+type RPCError struct {
+	Type     string
+	Tag      string
+	Severity string
+	AppTag   string
+	Path     string
+	Message  string
+}
+
+func (e *RPCError) String() string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "%s %s", e.Severity, e.Tag)
+	if e.Path != "" {
+		fmt.Fprintf(sb, " at %s", e.Path)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(sb, ": %s", e.Message)
+	}
+	return sb.String()
+}
+
+// RPCErrors are the <rpc-error>s with severity error the target replied with.
+type RPCErrors []*RPCError
+
+func (e RPCErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, re := range e {
+		msgs = append(msgs, re.String())
+	}
+	return "rpc-error: " + strings.Join(msgs, "; ")
+}
+
+// ParseRPCErrors returns the <rpc-error>s of the given document that carry the given severity.
+// All rpc-errors are returned if severity is empty.
+func ParseRPCErrors(doc *etree.Document, severity string) []*RPCError {
+	var result []*RPCError
+	for _, e := range doc.FindElements("//rpc-error") {
+		re := &RPCError{
+			Type:     childText(e, "error-type"),
+			Tag:      childText(e, "error-tag"),
+			Severity: childText(e, "error-severity"),
+			AppTag:   childText(e, "error-app-tag"),
+			Path:     childText(e, "error-path"),
+			Message:  childText(e, "error-message"),
+		}
+		if severity != "" && re.Severity != severity {
+			continue
+		}
+		result = append(result, re)
+	}
+	return result
+}
+
+func childText(e *etree.Element, tag string) string {
+	c := e.SelectElement(tag)
+	if c == nil {
+		return ""
+	}
+	return strings.TrimSpace(c.Text())
+}
@@ -37,6 +37,18 @@ func getNamespaceFromGetSchemaResponse(sr *sdcpb.GetSchemaResponse) string {
 	return ""
 }
 
+func getPrefixFromGetSchemaResponse(sr *sdcpb.GetSchemaResponse) string {
+	switch sr.GetSchema().Schema.(type) {
+	case *sdcpb.SchemaElem_Container:
+		return sr.Schema.GetContainer().GetPrefix()
+	case *sdcpb.SchemaElem_Field:
+		return sr.Schema.GetField().GetPrefix()
+	case *sdcpb.SchemaElem_Leaflist:
+		return sr.Schema.GetLeaflist().GetPrefix()
+	}
+	return ""
+}
+
 func valueAsString(v *sdcpb.TypedValue) (string, error) {
 	switch v.Value.(type) {
 	case *sdcpb.TypedValue_StringVal:
@@ -94,12 +106,46 @@ func pathElem2EtreePath(pe *sdcpb.PathElem) (etree.Path, error) {
 	return etree.CompilePath(xpathString)
 }
 
+// keyPredicates renders the keys of the PathElem as XPath predicates, sorted by key name.
+// e.g. [name='eth0'][index='1'] as used in the yang:key attribute.
+func keyPredicates(pe *sdcpb.PathElem) string {
+	keys := make([]string, 0, len(pe.GetKey()))
+	for k := range pe.GetKey() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sb := &strings.Builder{}
+	for _, k := range keys {
+		fmt.Fprintf(sb, "[%s=%s]", k, xpathLiteral(pe.GetKey()[k]))
+	}
+	return sb.String()
+}
+
+// xpathLiteral quotes the value as XPath string literal. Values containing both quote characters
+// are rendered via concat().
+func xpathLiteral(v string) string {
+	switch {
+	case !strings.Contains(v, "'"):
+		return "'" + v + "'"
+	case !strings.Contains(v, `"`):
+		return `"` + v + `"`
+	}
+	parts := strings.Split(v, "'")
+	for i, p := range parts {
+		parts[i] = "'" + p + "'"
+	}
+	return "concat(" + strings.Join(parts, `, "'", `) + ")"
+}
+
 // pathElem2XPath takes the given PathElem and generates the corresponding xpath expression
 func pathElem2XPath(pe *sdcpb.PathElem) (string, error) {
 	keys := make([]string, 0, len(pe.GetKey()))
 	// prepare the keys -> "k='v'"
 	for k, v := range pe.Key {
-		keys = append(keys, fmt.Sprintf("%s='%s'", k, v))
+		if strings.Contains(v, "'") && strings.Contains(v, `"`) {
+			return "", fmt.Errorf("key %s value %s contains both quote characters", k, v)
+		}
+		keys = append(keys, fmt.Sprintf("%s=%s", k, xpathLiteral(v)))
 	}
 	sort.Strings(keys)
 	keyString := ""
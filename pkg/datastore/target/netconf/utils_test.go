@@ -72,6 +72,33 @@ func Test_pathElem2Xpath(t *testing.T) {
 			want:    "./interface[name='eth0',state='up']",
 			wantErr: false,
 		},
+		{
+			name: "PathElem with key value containing a single quote",
+			args: args{
+				pe: &sdcpb.PathElem{
+					Name: "policy",
+					Key: map[string]string{
+						"name": "it's/1",
+					},
+				},
+				namespace: "",
+			},
+			want:    `./policy[name="it's/1"]`,
+			wantErr: false,
+		},
+		{
+			name: "PathElem with key value containing both quotes",
+			args: args{
+				pe: &sdcpb.PathElem{
+					Name: "policy",
+					Key: map[string]string{
+						"name": `it's "1"`,
+					},
+				},
+				namespace: "",
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -88,6 +115,20 @@ func Test_pathElem2Xpath(t *testing.T) {
 	}
 }
 
+func Test_keyPredicates(t *testing.T) {
+	pe := &sdcpb.PathElem{
+		Name: "policy",
+		Key: map[string]string{
+			"name": `it's "1"`,
+			"seq":  "10",
+		},
+	}
+	want := `[name=concat('it', "'", 's "1"')][seq='10']`
+	if got := keyPredicates(pe); got != want {
+		t.Errorf("keyPredicates() = %s, want %s", got, want)
+	}
+}
+
 // func TestXMLConfig_Add(t *testing.T) {
 // 	type fields struct {
 // 		doc *etree.Document
@@ -22,7 +22,6 @@ import (
 	"github.com/beevik/etree"
 	"github.com/sdcio/data-server/pkg/utils"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 
 	schemaClient "github.com/sdcio/data-server/pkg/datastore/clients/schema"
 )
@@ -73,6 +72,23 @@ func (x *XML2sdcpbConfigAdapter) transformRecursive(ctx context.Context, e *etre
 		return err
 	}
 
+	// the NETCONF operations of an edit-config delete the element, or replace it with its content
+	switch utils.XMLOperation(e.SelectAttrValue("operation", "")) {
+	case utils.XMLOperationDelete, utils.XMLOperationRemove:
+		p, err := x.elementPath(e, sr, pelems)
+		if err != nil {
+			return err
+		}
+		result.Delete = append(result.Delete, p)
+		return nil
+	case utils.XMLOperationReplace:
+		p, err := x.elementPath(e, sr, pelems)
+		if err != nil {
+			return err
+		}
+		result.Delete = append(result.Delete, p)
+	}
+
 	switch sr.GetSchema().Schema.(type) {
 	case *sdcpb.SchemaElem_Container:
 		// retrieved schema describes a yang container
@@ -102,6 +118,33 @@ func (x *XML2sdcpbConfigAdapter) transformRecursive(ctx context.Context, e *etre
 	return nil
 }
 
+// elementPath returns the path of the element, along with the keys of a list entry.
+// A list element without any of the keys refers to all the entries of the list.
+func (x *XML2sdcpbConfigAdapter) elementPath(e *etree.Element, sr *sdcpb.GetSchemaResponse, pelems []*sdcpb.PathElem) (*sdcpb.Path, error) {
+	p := &sdcpb.Path{Elem: make([]*sdcpb.PathElem, 0, len(pelems))}
+	for _, pe := range pelems {
+		p.Elem = append(p.Elem, &sdcpb.PathElem{Name: pe.GetName(), Key: pe.GetKey()})
+	}
+	keys := sr.GetSchema().GetContainer().GetKeys()
+	if len(keys) == 0 {
+		return p, nil
+	}
+	kv := make(map[string]string, len(keys))
+	for _, ls := range keys {
+		if keyElem := e.FindElement("./" + ls.Name); keyElem != nil {
+			kv[ls.Name] = keyElem.Text()
+		}
+	}
+	switch len(kv) {
+	case 0:
+	case len(keys):
+		p.Elem[len(p.Elem)-1].Key = kv
+	default:
+		return nil, fmt.Errorf("missing keys of list %s", utils.ToXPath(p, false))
+	}
+	return p, nil
+}
+
 // transformContainer transforms an etree.element of a configuration as an update into the provided *sdcpb.Notification.
 func (x *XML2sdcpbConfigAdapter) transformContainer(ctx context.Context, e *etree.Element, sr *sdcpb.GetSchemaResponse, pelems []*sdcpb.PathElem, result *sdcpb.Notification) error {
 	// copy pelems
@@ -117,13 +160,27 @@ func (x *XML2sdcpbConfigAdapter) transformContainer(ctx context.Context, e *etre
 		cPElem = append(cPElem, npe)
 	}
 
+	// the content of anydata and anyxml nodes is kept verbatim as a single value
+	if utils.IsAnyData(sr.GetSchema()) {
+		tv, err := utils.XMLInnerValue(e)
+		if err != nil {
+			return err
+		}
+		result.Update = append(result.Update, &sdcpb.Update{Path: &sdcpb.Path{Elem: cPElem}, Value: tv})
+		return nil
+	}
+
 	cs := sr.GetSchema().GetContainer()
 	// add keys to path elem
 	for _, ls := range cs.GetKeys() {
 		if cPElem[len(cPElem)-1].Key == nil {
 			cPElem[len(cPElem)-1].Key = map[string]string{}
 		}
-		cPElem[len(cPElem)-1].Key[ls.Name] = e.FindElement("./" + ls.Name).Text()
+		keyElem := e.FindElement("./" + ls.Name)
+		if keyElem == nil {
+			return fmt.Errorf("missing key %q of list %s", ls.Name, utils.ToXPath(&sdcpb.Path{Elem: pelems}, false))
+		}
+		cPElem[len(cPElem)-1].Key[ls.Name] = keyElem.Text()
 	}
 
 	ntc := NewTransformationContext(cPElem)
@@ -19,8 +19,11 @@ import (
 	"testing"
 
 	"github.com/beevik/etree"
+	"github.com/google/go-cmp/cmp"
 	"github.com/sdcio/data-server/mocks/mockschemaclientbound"
+	schemaClient "github.com/sdcio/data-server/pkg/datastore/clients/schema"
 	"github.com/sdcio/data-server/pkg/utils"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 	"go.uber.org/mock/gomock"
 )
@@ -79,7 +82,8 @@ func TestXML2sdcpbConfigAdapter_Transform(t *testing.T) {
 						case 0:
 							selem.Schema = &sdcpb.SchemaElem_Container{
 								Container: &sdcpb.ContainerSchema{
-									Name: "interfaces",
+									Name:     "interfaces",
+									Children: []string{"interface"},
 								},
 							}
 							expectedPath = "interfaces"
@@ -172,3 +176,84 @@ func TestXML2sdcpbConfigAdapter_Transform(t *testing.T) {
 		})
 	}
 }
+
+func FuzzXML2sdcpbConfigAdapter_Transform(f *testing.F) {
+	for _, x := range []string{
+		`<data/>`,
+		`<data><interface><name>ethernet-1/1</name><admin-state>enable</admin-state><subinterface><index>0</index></subinterface></interface></data>`,
+		`<data><interface><name>ethernet-1/1</name><mtu>notanumber</mtu></interface></data>`,
+		`<data><interface><admin-state>enable</admin-state></interface></data>`,
+		`<data><leaflist><entry>foo</entry><entry>bar</entry></leaflist></data>`,
+		`<data><network-instance><name>default</name><type xmlns:ni="urn:sdcio/model_ni">ni:default</type></network-instance></data>`,
+		`<data><choices><case1><case-elem><elem>foo</elem></case-elem></case1></choices></data>`,
+		`<data><doublekey><key1>a</key1><key2>b</key2></doublekey></data>`,
+		`<data><unknown><foo/></unknown></data>`,
+		`<data><patterntest><nested/></patterntest></data>`,
+	} {
+		f.Add(x)
+	}
+
+	// the gomock based schema client can not be used within the fuzz target
+	sc, schema, err := testhelper.InitSDCIOSchema()
+	if err != nil {
+		f.Fatal(err)
+	}
+	adapter := NewXML2sdcpbConfigAdapter(schemaClient.NewSchemaClientBound(schema.GetSchema(), sc))
+
+	f.Fuzz(func(t *testing.T, x string) {
+		doc := etree.NewDocument()
+		if err := doc.ReadFromString(x); err != nil {
+			return
+		}
+		// malformed device responses must be reported as errors, not panic
+		_, _ = adapter.Transform(context.Background(), doc)
+	})
+}
+
+func TestXML2sdcpbConfigAdapter_TransformOperations(t *testing.T) {
+	sc, schema, err := testhelper.InitSDCIOSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter := NewXML2sdcpbConfigAdapter(schemaClient.NewSchemaClientBound(schema.GetSchema(), sc))
+
+	doc := etree.NewDocument()
+	err = doc.ReadFromString(`<config xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0">` +
+		`<interface nc:operation="delete"><name>ethernet-1/1</name></interface>` +
+		`<interface nc:operation="replace"><name>ethernet-1/2</name><description>foo</description></interface>` +
+		`<interface><name>ethernet-1/3</name><description nc:operation="remove"/></interface>` +
+		`</config>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := adapter.Transform(context.Background(), doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var deletes, updates []string
+	for _, n := range got {
+		for _, p := range n.GetDelete() {
+			deletes = append(deletes, utils.ToXPath(p, false))
+		}
+		for _, u := range n.GetUpdate() {
+			updates = append(updates, utils.ToXPath(u.GetPath(), false))
+		}
+	}
+	wantDeletes := []string{
+		"interface[name=ethernet-1/1]",
+		"interface[name=ethernet-1/2]",
+		"interface[name=ethernet-1/3]/description",
+	}
+	wantUpdates := []string{
+		"interface[name=ethernet-1/2]/name",
+		"interface[name=ethernet-1/2]/description",
+		"interface[name=ethernet-1/3]/name",
+	}
+	if diff := cmp.Diff(wantDeletes, deletes); diff != "" {
+		t.Errorf("Transform() deletes mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantUpdates, updates); diff != "" {
+		t.Errorf("Transform() updates mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -17,6 +17,8 @@ package netconf
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/beevik/etree"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
@@ -31,6 +33,18 @@ const (
 	operationDelete  = "delete"
 	operationRemove  = "remove"
 	operationReplace = "replace"
+	// yang1 is the namespace of the insert, key and value attributes (RFC 7950, section 7.8.6)
+	yang1 = "urn:ietf:params:xml:ns:yang:1"
+)
+
+// InsertPosition defines where an entry of an `ordered-by user` list or leaf-list is inserted.
+type InsertPosition string
+
+const (
+	InsertFirst  InsertPosition = "first"
+	InsertLast   InsertPosition = "last"
+	InsertBefore InsertPosition = "before"
+	InsertAfter  InsertPosition = "after"
 )
 
 // XMLConfigBuilder is used to builds XML configuration or XML Filter documents
@@ -49,6 +63,9 @@ type XMLConfigBuilderOpts struct {
 	OperationWithNamespace bool
 	// UseOperationRemove if true, use NETCONF operation `remove` rather than `delete` in edit-config RPC.
 	UseOperationRemove bool
+	// NamespacePrefixes if true, elements are qualified with the prefix of their module. The prefix is declared
+	// (xmlns:prefix) where the module is entered and reused further down. Implies HonorNamespace.
+	NamespacePrefixes bool
 }
 
 // NewXMLConfigBuilder returns a new XMLConfigBuilder instance
@@ -111,25 +128,26 @@ func (x *XMLConfigBuilder) fastForward(ctx context.Context, p *sdcpb.Path) (*etr
 		}
 		var newChild *etree.Element
 		if newChild = parent.FindElementPath(path); newChild == nil {
-			namespaceUri, err := x.resolveNamespace(ctx, p, peIdx)
+			namespaceUri, prefix, err := x.resolvePrefixedNamespace(ctx, p, peIdx)
 			if err != nil {
 				return nil, err
 			}
 
 			// if there is no such element, create it
-			newChild = parent.CreateElement(pe.Name)
-			if x.cfg.HonorNamespace && namespaceUri != actualNamespace {
-				newChild.CreateAttr("xmlns", namespaceUri)
-			}
-			// with all its keys
+			newChild = x.createElement(parent, pe.Name, namespaceUri, prefix, actualNamespace)
+			// with all its keys, the keys are part of the lists module
 			for k, v := range pe.Key {
-				keyElem := newChild.CreateElement(k)
+				keyElem := newChild.CreateElement(qualifiedName(newChild.Space, k))
 				keyElem.CreateText(v)
 			}
 		}
 		//// prepare next iteration
-		// get default namespace definition of actual element, if unset default to actualNamespace
-		actualNamespace = newChild.SelectAttrValue("xmlns", actualNamespace)
+		if x.cfg.NamespacePrefixes {
+			actualNamespace = newChild.NamespaceURI()
+		} else {
+			// get default namespace definition of actual element, if unset default to actualNamespace
+			actualNamespace = newChild.SelectAttrValue("xmlns", actualNamespace)
+		}
 
 		// newChild will be parent in next iteration
 		parent = newChild
@@ -149,18 +167,26 @@ func (x *XMLConfigBuilder) AddValue(ctx context.Context, p *sdcpb.Path, v *sdcpb
 	switch val := v.GetValue().(type) {
 	case *sdcpb.TypedValue_LeaflistVal:
 		parent := elem.Parent()
-		// we add all the leaflist entries as their own values
-		for _, tv := range val.LeaflistVal.GetElement() {
-			subelem := parent.CreateElement(p.Elem[len(p.Elem)-1].Name)
+		sr, err := x.getSchema(ctx, p, len(p.GetElem())-1)
+		if err != nil {
+			return err
+		}
+		namespaceUri := getNamespaceFromGetSchemaResponse(sr)
+		prefix := getPrefixFromGetSchemaResponse(sr)
+		// entries of user ordered leaf-lists are inserted in the given order
+		userOrdered := sr.GetSchema().GetLeaflist().GetIsUserOrdered()
 
-			//perform namespace operations
-			namespaceUri, err := x.resolveNamespace(ctx, p, len(p.GetElem())-1)
-			if err != nil {
-				return err
-			}
-			if x.cfg.HonorNamespace && namespaceUri != parent.NamespaceURI() {
-				subelem.CreateAttr("xmlns", namespaceUri)
-			}
+		// the leaflist entries are rendered as repeated elements, taking the
+		// place of the element that fastForward created.
+		pos := elem.Index()
+		parent.RemoveChild(elem)
+		prev := ""
+		for _, tv := range val.LeaflistVal.GetElement() {
+			subelem := x.createElement(parent, p.Elem[len(p.Elem)-1].Name, namespaceUri, prefix, parent.NamespaceURI())
+			// move the element to the position of the one created by fastForward
+			parent.RemoveChild(subelem)
+			parent.InsertChildAt(pos, subelem)
+			pos++
 
 			value, err := valueAsString(tv)
 			if err != nil {
@@ -170,6 +196,15 @@ func (x *XMLConfigBuilder) AddValue(ctx context.Context, p *sdcpb.Path, v *sdcpb
 			// use SetText instead of CreateText to properly handle paths
 			// with a key as leaf.
 			subelem.SetText(value)
+
+			if userOrdered {
+				if prev == "" {
+					addInsertAttrs(subelem, InsertFirst, "", "")
+				} else {
+					addInsertAttrs(subelem, InsertAfter, "value", prev)
+				}
+				prev = value
+			}
 		}
 		operKey := "operation"
 		// add base1.0 as xmlns:nc attr
@@ -177,13 +212,9 @@ func (x *XMLConfigBuilder) AddValue(ctx context.Context, p *sdcpb.Path, v *sdcpb
 			parent.CreateAttr("xmlns:nc", ncBase1_0)
 			operKey = "nc:" + operKey
 		}
-		// add the delete operation attribute
+		// add the replace operation attribute
 		parent.CreateAttr(operKey, operationReplace)
 
-		// since fastForward did create an initial element, but we created all we
-		// need in the loop, we delete the element from its parent
-		parent.RemoveChild(elem)
-
 	default:
 		//perform namespace operations
 		namespaceUri, err := x.resolveNamespace(ctx, p, len(p.GetElem())-1)
@@ -191,7 +222,8 @@ func (x *XMLConfigBuilder) AddValue(ctx context.Context, p *sdcpb.Path, v *sdcpb
 			return err
 		}
 		parent := elem.Parent()
-		if x.cfg.HonorNamespace && (parent == nil || namespaceUri != parent.NamespaceURI()) {
+		// in prefix mode fastForward already qualified the element
+		if x.cfg.HonorNamespace && !x.cfg.NamespacePrefixes && (parent == nil || namespaceUri != parent.NamespaceURI()) {
 			elem.CreateAttr("xmlns", namespaceUri)
 		}
 
@@ -208,6 +240,57 @@ func (x *XMLConfigBuilder) AddValue(ctx context.Context, p *sdcpb.Path, v *sdcpb
 	return nil
 }
 
+// SetInsert adds the yang:insert attributes to the `ordered-by user` list or leaf-list entry referenced by p,
+// such that the entry is inserted at the given position. For InsertBefore and InsertAfter, relativeTo
+// is the list entry (its keys are used) or leaf-list value the position refers to.
+// The entry is created if it does not yet exist in the document.
+func (x *XMLConfigBuilder) SetInsert(ctx context.Context, p *sdcpb.Path, pos InsertPosition, relativeTo *sdcpb.PathElem, leaflistValue string) error {
+	if len(p.GetElem()) == 0 {
+		return fmt.Errorf("cannot set insert position on the root")
+	}
+	sr, err := x.getSchema(ctx, p, len(p.GetElem())-1)
+	if err != nil {
+		return err
+	}
+	var userOrdered, isLeaflist bool
+	switch s := sr.GetSchema().GetSchema().(type) {
+	case *sdcpb.SchemaElem_Container:
+		userOrdered = s.Container.GetIsUserOrdered()
+	case *sdcpb.SchemaElem_Leaflist:
+		userOrdered = s.Leaflist.GetIsUserOrdered()
+		isLeaflist = true
+	}
+	if !userOrdered {
+		return fmt.Errorf("path %s is not an ordered-by user list or leaf-list", p.String())
+	}
+
+	attrName, attrValue := "", ""
+	switch pos {
+	case InsertFirst, InsertLast:
+	case InsertBefore, InsertAfter:
+		if isLeaflist {
+			if leaflistValue == "" {
+				return fmt.Errorf("insert %s requires the leaf-list value it refers to", pos)
+			}
+			attrName, attrValue = "value", leaflistValue
+			break
+		}
+		if len(relativeTo.GetKey()) == 0 {
+			return fmt.Errorf("insert %s requires the keys of the list entry it refers to", pos)
+		}
+		attrName, attrValue = "key", keyPredicates(relativeTo)
+	default:
+		return fmt.Errorf("unknown insert position %q", pos)
+	}
+
+	elem, err := x.fastForward(ctx, p)
+	if err != nil {
+		return err
+	}
+	addInsertAttrs(elem, pos, attrName, attrValue)
+	return nil
+}
+
 // AddElements add a given *sdcpb.Path p to the xml document. This will not define a terminal value
 // under the given path. This is usefull when creating Netconf Filters where you provide an xml document
 // pointing to branches that you're intrested in receiving.
@@ -219,23 +302,105 @@ func (x *XMLConfigBuilder) AddElements(ctx context.Context, p *sdcpb.Path) error
 // resolveNamespace takes a *sdcpb.Path and a pathElementIndex (peIdx). It returns the namespace of
 // the element on position peIdx of the *sdcpb.path p
 func (x *XMLConfigBuilder) resolveNamespace(ctx context.Context, p *sdcpb.Path, peIdx int) (string, error) {
+	ns, _, err := x.resolvePrefixedNamespace(ctx, p, peIdx)
+	return ns, err
+}
+
+// resolvePrefixedNamespace returns the namespace and the module prefix of the element on position peIdx of the *sdcpb.path p
+func (x *XMLConfigBuilder) resolvePrefixedNamespace(ctx context.Context, p *sdcpb.Path, peIdx int) (string, string, error) {
+	sr, err := x.getSchema(ctx, p, peIdx)
+	if err != nil {
+		return "", "", err
+	}
+
+	// deduce namespace from SchemaRequest
+	return getNamespaceFromGetSchemaResponse(sr), getPrefixFromGetSchemaResponse(sr), nil
+}
 
+// createElement creates the child element name of parent in the given namespace.
+// Without NamespacePrefixes, the default namespace is declared if it differs from the inherited one.
+// With NamespacePrefixes, the element is qualified with a prefix bound to the namespace. A prefix
+// already bound to the namespace by an ancestor is reused, otherwise the module prefix is declared.
+func (x *XMLConfigBuilder) createElement(parent *etree.Element, name string, namespaceUri string, prefix string, inheritedNamespace string) *etree.Element {
+	if !x.cfg.NamespacePrefixes || namespaceUri == "" {
+		elem := parent.CreateElement(name)
+		if x.cfg.HonorNamespace && namespaceUri != inheritedNamespace {
+			elem.CreateAttr("xmlns", namespaceUri)
+		}
+		return elem
+	}
+	prefix, declare := resolvePrefix(parent, namespaceUri, prefix)
+	elem := parent.CreateElement(qualifiedName(prefix, name))
+	if declare {
+		elem.CreateAttr("xmlns:"+prefix, namespaceUri)
+	}
+	return elem
+}
+
+// resolvePrefix returns the prefix bound to namespaceUri in the scope of elem. If no such prefix exists,
+// the preferred prefix is returned, made unique in the scope, and declare is true.
+func resolvePrefix(elem *etree.Element, namespaceUri string, preferred string) (prefix string, declare bool) {
+	// the prefixes bound in the scope, the nearest declaration wins
+	bound := map[string]string{}
+	for e := elem; e != nil; e = e.Parent() {
+		for _, a := range e.Attr {
+			if a.Space != "xmlns" {
+				continue
+			}
+			if _, shadowed := bound[a.Key]; !shadowed {
+				bound[a.Key] = a.Value
+			}
+		}
+	}
+	for _, p := range slices.Sorted(maps.Keys(bound)) {
+		if bound[p] == namespaceUri {
+			return p, false
+		}
+	}
+	if preferred == "" {
+		preferred = "ns"
+	}
+	prefix = preferred
+	for i := 1; ; i++ {
+		if _, exists := bound[prefix]; !exists {
+			return prefix, true
+		}
+		prefix = fmt.Sprintf("%s%d", preferred, i)
+	}
+}
+
+// qualifiedName returns the name qualified with the given prefix, or the name if the prefix is empty.
+func qualifiedName(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + ":" + name
+}
+
+// getSchema returns the schema of the element on position peIdx of the *sdcpb.path p
+func (x *XMLConfigBuilder) getSchema(ctx context.Context, p *sdcpb.Path, peIdx int) (*sdcpb.GetSchemaResponse, error) {
 	if peIdx+1 > len(p.Elem) {
-		return "", fmt.Errorf("peIdx exceeds limit %d for path %s", len(p.Elem), p.String())
+		return nil, fmt.Errorf("peIdx exceeds limit %d for path %s", len(p.Elem), p.String())
 	}
 
 	// Perform schema queries
-	sr, err := x.schemaClient.GetSchema(ctx,
+	return x.schemaClient.GetSchema(ctx,
 		&sdcpb.Path{
 			Elem:   p.Elem[:peIdx+1],
 			Origin: p.Origin,
 			Target: p.Target,
 		},
 	)
-	if err != nil {
-		return "", err
-	}
+}
 
-	// deduce namespace from SchemaRequest
-	return getNamespaceFromGetSchemaResponse(sr), nil
+// addInsertAttrs adds the yang:insert attribute and, if attrName is set, the yang:key or yang:value
+// attribute referencing the entry the position is relative to.
+func addInsertAttrs(elem *etree.Element, pos InsertPosition, attrName string, attrValue string) {
+	if elem.SelectAttr("xmlns:yang") == nil {
+		elem.CreateAttr("xmlns:yang", yang1)
+	}
+	elem.CreateAttr("yang:insert", string(pos))
+	if attrName != "" {
+		elem.CreateAttr("yang:"+attrName, attrValue)
+	}
 }
@@ -720,6 +720,299 @@ func TestXMLConfigBuilder_AddValue(t *testing.T) {
 	}
 }
 
+// orderedSchemaClient returns a schema client mock, that reports "server" as leaf-list and
+// "interface" as list, both user ordered if userOrdered is set.
+func orderedSchemaClient(ctrl *gomock.Controller, userOrdered bool) *mockschemaclientbound.MockSchemaClientBound {
+	schemaClientMock := mockschemaclientbound.NewMockSchemaClientBound(ctrl)
+	schemaClientMock.EXPECT().GetSchema(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, path *sdcpb.Path) (*sdcpb.GetSchemaResponse, error) {
+			switch path.GetElem()[len(path.GetElem())-1].GetName() {
+			case "server":
+				return &sdcpb.GetSchemaResponse{
+					Schema: &sdcpb.SchemaElem{
+						Schema: &sdcpb.SchemaElem_Leaflist{
+							Leaflist: &sdcpb.LeafListSchema{Name: "server", IsUserOrdered: userOrdered},
+						},
+					},
+				}, nil
+			case "interface":
+				return &sdcpb.GetSchemaResponse{
+					Schema: &sdcpb.SchemaElem{
+						Schema: &sdcpb.SchemaElem_Container{
+							Container: &sdcpb.ContainerSchema{Name: "interface", IsUserOrdered: userOrdered},
+						},
+					},
+				}, nil
+			}
+			return &sdcpb.GetSchemaResponse{
+				Schema: &sdcpb.SchemaElem{
+					Schema: &sdcpb.SchemaElem_Container{
+						Container: &sdcpb.ContainerSchema{},
+					},
+				},
+			}, nil
+		},
+	)
+	return schemaClientMock
+}
+
+func TestXMLConfigBuilder_AddValue_Leaflist(t *testing.T) {
+	pathServer := &sdcpb.Path{
+		Elem: []*sdcpb.PathElem{
+			{Name: "dns"},
+			{Name: "server"},
+		},
+	}
+	pathDomain := &sdcpb.Path{
+		Elem: []*sdcpb.PathElem{
+			{Name: "dns"},
+			{Name: "domain"},
+		},
+	}
+	servers := &sdcpb.TypedValue{
+		Value: &sdcpb.TypedValue_LeaflistVal{
+			LeaflistVal: &sdcpb.ScalarArray{
+				Element: []*sdcpb.TypedValue{
+					{Value: &sdcpb.TypedValue_StringVal{StringVal: "10.0.0.1"}},
+					{Value: &sdcpb.TypedValue_StringVal{StringVal: "10.0.0.2"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		userOrdered bool
+		want        string
+	}{
+		{
+			name: "system ordered",
+			want: `<dns operation="replace">
+  <server>10.0.0.1</server>
+  <server>10.0.0.2</server>
+  <domain>example.com</domain>
+</dns>
+`,
+		},
+		{
+			name:        "user ordered",
+			userOrdered: true,
+			want: `<dns operation="replace">
+  <server xmlns:yang="urn:ietf:params:xml:ns:yang:1" yang:insert="first">10.0.0.1</server>
+  <server xmlns:yang="urn:ietf:params:xml:ns:yang:1" yang:insert="after" yang:value="10.0.0.1">10.0.0.2</server>
+  <domain>example.com</domain>
+</dns>
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			xmlBuilder := NewXMLConfigBuilder(orderedSchemaClient(mockCtrl, tt.userOrdered), &XMLConfigBuilderOpts{})
+			if err := xmlBuilder.AddValue(TestCtx, pathServer, servers); err != nil {
+				t.Fatal(err)
+			}
+			// siblings are rendered after the leaf-list entries
+			if err := xmlBuilder.AddValue(TestCtx, pathDomain, &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "example.com"}}); err != nil {
+				t.Fatal(err)
+			}
+			xdoc, err := xmlBuilder.GetDoc()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, xdoc); d != "" {
+				t.Errorf("mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestXMLConfigBuilder_SetInsert(t *testing.T) {
+	pathInterface := &sdcpb.Path{
+		Elem: []*sdcpb.PathElem{
+			{Name: "interfaces"},
+			{Name: "interface", Key: map[string]string{"name": "eth1"}},
+		},
+	}
+	pathServer := &sdcpb.Path{
+		Elem: []*sdcpb.PathElem{
+			{Name: "dns"},
+			{Name: "server"},
+		},
+	}
+
+	type args struct {
+		p             *sdcpb.Path
+		pos           InsertPosition
+		relativeTo    *sdcpb.PathElem
+		leaflistValue string
+	}
+	tests := []struct {
+		name        string
+		userOrdered bool
+		args        args
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "list first",
+			userOrdered: true,
+			args:        args{p: pathInterface, pos: InsertFirst},
+			want: `<interfaces>
+  <interface xmlns:yang="urn:ietf:params:xml:ns:yang:1" yang:insert="first">
+    <name>eth1</name>
+  </interface>
+</interfaces>
+`,
+		},
+		{
+			name:        "list after",
+			userOrdered: true,
+			args: args{
+				p:          pathInterface,
+				pos:        InsertAfter,
+				relativeTo: &sdcpb.PathElem{Name: "interface", Key: map[string]string{"name": "eth0"}},
+			},
+			want: `<interfaces>
+  <interface xmlns:yang="urn:ietf:params:xml:ns:yang:1" yang:insert="after" yang:key="[name=&apos;eth0&apos;]">
+    <name>eth1</name>
+  </interface>
+</interfaces>
+`,
+		},
+		{
+			name:        "leaf-list before",
+			userOrdered: true,
+			args:        args{p: pathServer, pos: InsertBefore, leaflistValue: "10.0.0.1"},
+			want: `<dns>
+  <server xmlns:yang="urn:ietf:params:xml:ns:yang:1" yang:insert="before" yang:value="10.0.0.1"/>
+</dns>
+`,
+		},
+		{
+			name:        "list after without keys",
+			userOrdered: true,
+			args:        args{p: pathInterface, pos: InsertAfter},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown position",
+			userOrdered: true,
+			args:        args{p: pathInterface, pos: "middle"},
+			wantErr:     true,
+		},
+		{
+			name:    "system ordered",
+			args:    args{p: pathInterface, pos: InsertFirst},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			xmlBuilder := NewXMLConfigBuilder(orderedSchemaClient(mockCtrl, tt.userOrdered), &XMLConfigBuilderOpts{})
+			err := xmlBuilder.SetInsert(TestCtx, tt.args.p, tt.args.pos, tt.args.relativeTo, tt.args.leaflistValue)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("XMLConfigBuilder.SetInsert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			xdoc, err := xmlBuilder.GetDoc()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.want, xdoc); d != "" {
+				t.Errorf("mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestXMLConfigBuilder_NamespacePrefixes(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// interfaces is defined in urn:if, subinterface is augmented from urn:vlan and
+	// description from a module that uses the same prefix as the interfaces module.
+	modules := map[string][2]string{
+		"interfaces":   {"urn:if", "if"},
+		"interface":    {"urn:if", "if"},
+		"mtu":          {"urn:if", "if"},
+		"subinterface": {"urn:vlan", "vlan"},
+		"vlan-id":      {"urn:vlan", "vlan"},
+		"description":  {"urn:other-if", "if"},
+	}
+	schemaClientMock := mockschemaclientbound.NewMockSchemaClientBound(mockCtrl)
+	schemaClientMock.EXPECT().GetSchema(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, path *sdcpb.Path) (*sdcpb.GetSchemaResponse, error) {
+			name := path.GetElem()[len(path.GetElem())-1].GetName()
+			m := modules[name]
+			if name == "interfaces" || name == "interface" || name == "subinterface" {
+				return &sdcpb.GetSchemaResponse{
+					Schema: &sdcpb.SchemaElem{
+						Schema: &sdcpb.SchemaElem_Container{
+							Container: &sdcpb.ContainerSchema{Name: name, Namespace: m[0], Prefix: m[1]},
+						},
+					},
+				}, nil
+			}
+			return &sdcpb.GetSchemaResponse{
+				Schema: &sdcpb.SchemaElem{
+					Schema: &sdcpb.SchemaElem_Field{
+						Field: &sdcpb.LeafSchema{Name: name, Namespace: m[0], Prefix: m[1]},
+					},
+				},
+			}, nil
+		},
+	)
+
+	interfacePath := []*sdcpb.PathElem{
+		{Name: "interfaces"},
+		{Name: "interface", Key: map[string]string{"name": "eth0"}},
+	}
+	values := []struct {
+		elem  []*sdcpb.PathElem
+		value string
+	}{
+		{elem: []*sdcpb.PathElem{{Name: "mtu"}}, value: "1500"},
+		{elem: []*sdcpb.PathElem{{Name: "subinterface", Key: map[string]string{"index": "1"}}, {Name: "vlan-id"}}, value: "5"},
+		{elem: []*sdcpb.PathElem{{Name: "description"}}, value: "uplink"},
+	}
+
+	xmlBuilder := NewXMLConfigBuilder(schemaClientMock, &XMLConfigBuilderOpts{NamespacePrefixes: true})
+	for _, v := range values {
+		p := &sdcpb.Path{Elem: append(append([]*sdcpb.PathElem{}, interfacePath...), v.elem...)}
+		if err := xmlBuilder.AddValue(TestCtx, p, &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: v.value}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := `<if:interfaces xmlns:if="urn:if">
+  <if:interface>
+    <if:name>eth0</if:name>
+    <if:mtu>1500</if:mtu>
+    <vlan:subinterface xmlns:vlan="urn:vlan">
+      <vlan:index>1</vlan:index>
+      <vlan:vlan-id>5</vlan:vlan-id>
+    </vlan:subinterface>
+    <if1:description xmlns:if1="urn:other-if">uplink</if1:description>
+  </if:interface>
+</if:interfaces>
+`
+	xdoc, err := xmlBuilder.GetDoc()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(want, xdoc); d != "" {
+		t.Errorf("mismatch (-want +got):\n%s", d)
+	}
+}
+
 func TestXMLConfigBuilder_Delete(t *testing.T) {
 
 	GetNewDoc := func() *etree.Document {
@@ -19,7 +19,6 @@ import (
 	"time"
 
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 
 	"github.com/sdcio/data-server/pkg/config"
 )
@@ -86,6 +85,26 @@ func (t *noopTarget) Set(ctx context.Context, source TargetSource) (*sdcpb.SetDa
 	return result, nil
 }
 
+// ReplaceAll reports the complete content of the source as updates.
+func (t *noopTarget) ReplaceAll(ctx context.Context, source TargetSource) (*sdcpb.SetDataResponse, error) {
+	upds, err := source.ToProtoUpdates(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &sdcpb.SetDataResponse{
+		Response:  make([]*sdcpb.UpdateResult, 0, len(upds)),
+		Timestamp: time.Now().UnixNano(),
+	}
+	for _, upd := range upds {
+		result.Response = append(result.Response, &sdcpb.UpdateResult{
+			Path: upd.GetPath(),
+			Op:   sdcpb.UpdateResult_REPLACE,
+		})
+	}
+	return result, nil
+}
+
 func (t *noopTarget) Status() string { return "N/A" }
 
 func (t *noopTarget) Sync(ctx context.Context, _ *config.Sync, syncCh chan *SyncUpdate) {
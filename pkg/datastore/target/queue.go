@@ -0,0 +1,103 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"context"
+	"sync"
+)
+
+// opPriority is the priority of an operation on the target, lower values are served first.
+type opPriority int
+
+const (
+	// opPriorityApply are the operations applying intents to the device
+	opPriorityApply opPriority = iota
+	// opPriorityRead are the reads requested by clients
+	opPriorityRead
+	// opPrioritySync are the reads of the background syncs
+	opPrioritySync
+
+	numOpPriorities
+)
+
+// opQueue serializes the operations on a target, such that e.g. a Set never interleaves
+// with a full sync read. Waiting operations are served by priority, in order of arrival per priority.
+type opQueue struct {
+	m       sync.Mutex
+	busy    bool
+	waiters [numOpPriorities][]chan struct{}
+}
+
+func newOpQueue() *opQueue {
+	return &opQueue{}
+}
+
+// do runs f once all operations ahead of it in the queue are done.
+func (q *opQueue) do(ctx context.Context, prio opPriority, f func() error) error {
+	if q == nil {
+		return f()
+	}
+	if err := q.acquire(ctx, prio); err != nil {
+		return err
+	}
+	defer q.release()
+	return f()
+}
+
+func (q *opQueue) acquire(ctx context.Context, prio opPriority) error {
+	q.m.Lock()
+	if !q.busy {
+		q.busy = true
+		q.m.Unlock()
+		return nil
+	}
+	ch := make(chan struct{})
+	q.waiters[prio] = append(q.waiters[prio], ch)
+	q.m.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		q.m.Lock()
+		for i, w := range q.waiters[prio] {
+			if w == ch {
+				q.waiters[prio] = append(q.waiters[prio][:i], q.waiters[prio][i+1:]...)
+				q.m.Unlock()
+				return ctx.Err()
+			}
+		}
+		q.m.Unlock()
+		// the queue was handed over concurrently, pass it on
+		q.release()
+		return ctx.Err()
+	}
+}
+
+// release hands the queue over to the first waiter with the highest priority.
+func (q *opQueue) release() {
+	q.m.Lock()
+	defer q.m.Unlock()
+	for prio, ws := range q.waiters {
+		if len(ws) == 0 {
+			continue
+		}
+		q.waiters[prio] = ws[1:]
+		close(ws[0])
+		return
+	}
+	q.busy = false
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_opQueue_priority(t *testing.T) {
+	q := newOpQueue()
+	if err := q.acquire(context.TODO(), opPriorityApply); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &sync.Mutex{}
+	order := []opPriority{}
+	wg := &sync.WaitGroup{}
+	// enqueue the operations one after the other, such that the arrival order is deterministic
+	for _, prio := range []opPriority{opPrioritySync, opPriorityRead, opPriorityApply, opPrioritySync} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = q.do(context.TODO(), prio, func() error {
+				m.Lock()
+				defer m.Unlock()
+				order = append(order, prio)
+				return nil
+			})
+		}()
+		waitForWaiters(t, q, prio)
+	}
+	q.release()
+	wg.Wait()
+
+	want := []opPriority{opPriorityApply, opPriorityRead, opPrioritySync, opPrioritySync}
+	if len(order) != len(want) {
+		t.Fatalf("expected order %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("expected order %v, got %v", want, order)
+		}
+	}
+}
+
+func Test_opQueue_cancelled(t *testing.T) {
+	q := newOpQueue()
+	if err := q.acquire(context.TODO(), opPriorityApply); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	err := q.do(ctx, opPrioritySync, func() error {
+		t.Error("the cancelled operation must not run")
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the operation to time out, got %v", err)
+	}
+	q.release()
+
+	// the queue is free again
+	if err = q.do(context.TODO(), opPriorityRead, func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForWaiters waits for an operation of the priority to be queued.
+func waitForWaiters(t *testing.T, q *opQueue, prio opPriority) {
+	t.Helper()
+	for range 100 {
+		q.m.Lock()
+		n := len(q.waiters[prio])
+		q.m.Unlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("no operation of priority %d queued", prio)
+}
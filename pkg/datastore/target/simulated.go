@@ -0,0 +1,262 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// simulatedTarget applies the changes to an in-memory copy of the device config instead of a device,
+// e.g. to test intents end-to-end in CI. The copy is seeded from the CONFIG store of the datastore
+// and synced back into it, such that the datastore behaves as if it managed a real device.
+type simulatedTarget struct {
+	name string
+
+	m sync.RWMutex
+	// the leaf values of the simulated config, by their xpath
+	config map[string]*sdcpb.Update
+}
+
+func newSimulatedTarget(_ context.Context, name string) (*simulatedTarget, error) {
+	return &simulatedTarget{
+		name:   name,
+		config: map[string]*sdcpb.Update{},
+	}, nil
+}
+
+// Seed replaces the simulated config with the given values, e.g. the content of the CONFIG store.
+func (t *simulatedTarget) Seed(upds []*sdcpb.Update) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.config = make(map[string]*sdcpb.Update, len(upds))
+	for _, u := range upds {
+		t.config[simulatedKey(u.GetPath())] = u
+	}
+	log.Infof("target %s: simulation seeded with %d values", t.name, len(upds))
+}
+
+func (t *simulatedTarget) Get(_ context.Context, req *sdcpb.GetDataRequest) (*sdcpb.GetDataResponse, error) {
+	t.m.RLock()
+	defer t.m.RUnlock()
+	n := &sdcpb.Notification{Timestamp: time.Now().UnixNano()}
+	// the state of a simulated device is not known
+	if req.GetDataType() != sdcpb.DataType_STATE {
+		n.Update = t.read(req.GetPath())
+	}
+	return &sdcpb.GetDataResponse{Notification: []*sdcpb.Notification{n}}, nil
+}
+
+func (t *simulatedTarget) Set(ctx context.Context, source TargetSource) (*sdcpb.SetDataResponse, error) {
+	upds, err := source.ToProtoUpdates(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	deletes, err := source.ToProtoDeletes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	result := &sdcpb.SetDataResponse{
+		Response:  make([]*sdcpb.UpdateResult, 0, len(upds)+len(deletes)),
+		Timestamp: time.Now().UnixNano(),
+	}
+	for _, p := range deletes {
+		t.delete(p)
+		result.Response = append(result.Response, &sdcpb.UpdateResult{
+			Path: p,
+			Op:   sdcpb.UpdateResult_DELETE,
+		})
+	}
+	for _, u := range upds {
+		t.config[simulatedKey(u.GetPath())] = u
+		result.Response = append(result.Response, &sdcpb.UpdateResult{
+			Path: u.GetPath(),
+			Op:   sdcpb.UpdateResult_UPDATE,
+		})
+	}
+	log.Debugf("target %s: simulated %d updates and %d deletes", t.name, len(upds), len(deletes))
+	return result, nil
+}
+
+// ReplaceAll replaces the simulated config with the complete content of the source.
+func (t *simulatedTarget) ReplaceAll(ctx context.Context, source TargetSource) (*sdcpb.SetDataResponse, error) {
+	upds, err := source.ToProtoUpdates(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &sdcpb.SetDataResponse{
+		Response:  make([]*sdcpb.UpdateResult, 0, len(upds)),
+		Timestamp: time.Now().UnixNano(),
+	}
+	for _, u := range upds {
+		result.Response = append(result.Response, &sdcpb.UpdateResult{
+			Path: u.GetPath(),
+			Op:   sdcpb.UpdateResult_REPLACE,
+		})
+	}
+	t.Seed(upds)
+	return result, nil
+}
+
+// Diff returns the changes of the source the simulated config would undergo, one line per value,
+// prefixed with "+" for added, "~" for changed and "-" for deleted values.
+func (t *simulatedTarget) Diff(ctx context.Context, source TargetSource) (string, error) {
+	upds, err := source.ToProtoUpdates(ctx, true)
+	if err != nil {
+		return "", err
+	}
+	deletes, err := source.ToProtoDeletes(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	t.m.RLock()
+	defer t.m.RUnlock()
+	lines := []string{}
+	for _, p := range deletes {
+		for _, u := range t.read([]*sdcpb.Path{p}) {
+			lines = append(lines, fmt.Sprintf("- %s: %s", simulatedKey(u.GetPath()), simulatedValue(u)))
+		}
+	}
+	for _, u := range upds {
+		k := simulatedKey(u.GetPath())
+		switch old, ok := t.config[k]; {
+		case !ok:
+			lines = append(lines, fmt.Sprintf("+ %s: %s", k, simulatedValue(u)))
+		case !proto.Equal(old.GetValue(), u.GetValue()):
+			lines = append(lines, fmt.Sprintf("~ %s: %s -> %s", k, simulatedValue(old), simulatedValue(u)))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func (t *simulatedTarget) Status() string { return "simulated" }
+
+// Sync periodically reports the simulated config, as the sync of a device would.
+func (t *simulatedTarget) Sync(ctx context.Context, syncConfig *config.Sync, syncCh chan *SyncUpdate) {
+	log.Infof("starting target %s simulated sync", t.name)
+	for _, sc := range syncConfig.Config {
+		go func(sc *config.SyncProtocol) {
+			t.internalSync(ctx, sc, true, syncCh)
+			timer := time.NewTimer(nextSyncDelay(sc, time.Now()))
+			defer timer.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-timer.C:
+					t.internalSync(ctx, sc, false, syncCh)
+					timer.Reset(nextSyncDelay(sc, time.Now()))
+				}
+			}
+		}(sc)
+	}
+	<-ctx.Done()
+}
+
+func (t *simulatedTarget) internalSync(ctx context.Context, sc *config.SyncProtocol, force bool, syncCh chan *SyncUpdate) {
+	paths := make([]*sdcpb.Path, 0, len(sc.Paths))
+	for _, p := range sc.Paths {
+		path, err := utils.ParsePath(p)
+		if err != nil {
+			log.Errorf("failed Parsing Path %q, %v", p, err)
+			return
+		}
+		paths = append(paths, path)
+	}
+	rsp, err := t.Get(ctx, &sdcpb.GetDataRequest{Path: paths, DataType: syncDataType(sc)})
+	if err != nil {
+		log.Errorf("target %s: simulated sync failed: %v", t.name, err)
+		return
+	}
+	syncCh <- &SyncUpdate{
+		Store: syncStore(sc),
+		Start: true,
+		Paths: paths,
+		Force: force,
+	}
+	for _, n := range rsp.GetNotification() {
+		syncCh <- &SyncUpdate{
+			Store:  syncStore(sc),
+			Update: n,
+		}
+	}
+	syncCh <- &SyncUpdate{
+		End: true,
+	}
+}
+
+func (t *simulatedTarget) Close() error { return nil }
+
+// read returns the values below the paths, sorted by their xpath. The lock must be held by the caller.
+func (t *simulatedTarget) read(paths []*sdcpb.Path) []*sdcpb.Update {
+	prefixes := make([]string, 0, len(paths))
+	for _, p := range paths {
+		prefixes = append(prefixes, simulatedKey(p))
+	}
+	keys := make([]string, 0, len(t.config))
+	for k := range t.config {
+		if len(prefixes) == 0 || slices.ContainsFunc(prefixes, func(prefix string) bool { return simulatedBelow(k, prefix) }) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	upds := make([]*sdcpb.Update, 0, len(keys))
+	for _, k := range keys {
+		upds = append(upds, t.config[k])
+	}
+	return upds
+}
+
+// delete removes the values below the path. The lock must be held by the caller.
+func (t *simulatedTarget) delete(p *sdcpb.Path) {
+	prefix := simulatedKey(p)
+	for k := range t.config {
+		if simulatedBelow(k, prefix) {
+			delete(t.config, k)
+		}
+	}
+}
+
+func simulatedKey(p *sdcpb.Path) string {
+	return utils.ToXPathOpts(p, utils.XPathOpts{NoOrigin: true})
+}
+
+// simulatedBelow returns true if the xpath k equals or is below the xpath prefix,
+// e.g. an entry of the list or a leaf of the container.
+func simulatedBelow(k, prefix string) bool {
+	if prefix == "" || k == prefix {
+		return true
+	}
+	return strings.HasPrefix(k, prefix+"/") || strings.HasPrefix(k, prefix+"[")
+}
+
+func simulatedValue(u *sdcpb.Update) string {
+	return utils.TypedValueToString(u.GetValue())
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package target
+
+import (
+	"context"
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// protoSource is a TargetSource providing the given updates and deletes.
+type protoSource struct {
+	TargetSource
+	upds    []*sdcpb.Update
+	deletes []*sdcpb.Path
+}
+
+func (p *protoSource) ToProtoUpdates(_ context.Context, _ bool) ([]*sdcpb.Update, error) {
+	return p.upds, nil
+}
+
+func (p *protoSource) ToProtoDeletes(_ context.Context) ([]*sdcpb.Path, error) {
+	return p.deletes, nil
+}
+
+func Test_simulatedTarget(t *testing.T) {
+	upd := func(p string, v string) *sdcpb.Update {
+		path, err := utils.ParsePath(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &sdcpb.Update{Path: path, Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: v}}}
+	}
+	path := func(p string) *sdcpb.Path {
+		path, err := utils.ParsePath(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tr, _ := newSimulatedTarget(TestCtx, "sim")
+	tr.Seed([]*sdcpb.Update{
+		upd("interface[name=ethernet-1/1]/description", "uplink"),
+		upd("interface[name=ethernet-1/10]/description", "downlink"),
+		upd("network-instance[name=default]/type", "default"),
+	})
+
+	source := &protoSource{
+		upds:    []*sdcpb.Update{upd("interface[name=ethernet-1/10]/description", "spare"), upd("interface[name=ethernet-1/2]/description", "new")},
+		deletes: []*sdcpb.Path{path("interface[name=ethernet-1/1]")},
+	}
+	diff, err := tr.Diff(TestCtx, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDiff := `- interface[name=ethernet-1/1]/description: uplink
+~ interface[name=ethernet-1/10]/description: downlink -> spare
++ interface[name=ethernet-1/2]/description: new`
+	if diff != wantDiff {
+		t.Errorf("Diff() =\n%s\nwant\n%s", diff, wantDiff)
+	}
+
+	if _, err = tr.Set(TestCtx, source); err != nil {
+		t.Fatal(err)
+	}
+	rsp, err := tr.Get(TestCtx, &sdcpb.GetDataRequest{Path: []*sdcpb.Path{path("interface")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, u := range rsp.GetNotification()[0].GetUpdate() {
+		got = append(got, simulatedKey(u.GetPath())+"="+u.GetValue().GetStringVal())
+	}
+	want := []string{
+		"interface[name=ethernet-1/10]/description=spare",
+		"interface[name=ethernet-1/2]/description=new",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Get() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Get() = %v, want %v", got, want)
+		}
+	}
+}
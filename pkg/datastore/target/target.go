@@ -17,6 +17,8 @@ package target
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"time"
 
 	"github.com/beevik/etree"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
@@ -30,6 +32,12 @@ const (
 	targetTypeNOOP    = "noop"
 	targetTypeNETCONF = "netconf"
 	targetTypeGNMI    = "gnmi"
+	targetTypeCLI     = "cli"
+	// targetTypeSimulated applies the changes to a copy of the CONFIG store instead of a device
+	targetTypeSimulated = "simulated"
+
+	// SyncStoreState is the SyncUpdate store that identifies state data
+	SyncStoreState = "state"
 )
 
 type Target interface {
@@ -46,12 +54,53 @@ func New(ctx context.Context, name string, cfg *config.SBI, schemaClient schemaC
 		return newGNMITarget(ctx, name, cfg, opts...)
 	case targetTypeNETCONF:
 		return newNCTarget(ctx, name, cfg, schemaClient)
+	case targetTypeCLI:
+		return newCLITarget(ctx, name, cfg)
 	case targetTypeNOOP, "":
 		return newNoopTarget(ctx, name)
+	case targetTypeSimulated:
+		return newSimulatedTarget(ctx, name)
 	}
 	return nil, fmt.Errorf("unknown DS target type %q", cfg.Type)
 }
 
+// Differ is implemented by the targets that can report the diff the changes of a
+// source would cause on the device, without applying them.
+type Differ interface {
+	Diff(ctx context.Context, source TargetSource) (string, error)
+}
+
+// FullReplacer is implemented by the targets that can replace their complete
+// configuration with the content of a source in a single operation.
+type FullReplacer interface {
+	ReplaceAll(ctx context.Context, source TargetSource) (*sdcpb.SetDataResponse, error)
+}
+
+// Preparer is implemented by the targets that can stage the changes of a source on the
+// device and commit or discard them afterwards, e.g. via a candidate datastore.
+type Preparer interface {
+	// CanPrepare returns true if the target is configured to stage changes
+	CanPrepare() bool
+	Prepare(ctx context.Context, source TargetSource) (*sdcpb.SetDataResponse, error)
+	Commit(ctx context.Context) error
+	Discard(ctx context.Context) error
+}
+
+// ConfirmedCommitter is implemented by the preparing targets that can commit the prepared changes
+// on probation. The device rolls the changes back unless the commit is confirmed within a timeout.
+type ConfirmedCommitter interface {
+	CommitConfirmed(ctx context.Context) error
+	ConfirmCommit(ctx context.Context) error
+	// CancelCommit rolls back the changes of the pending confirmed commit
+	CancelCommit(ctx context.Context) error
+}
+
+// ConfigSeeder is implemented by the targets that simulate a device,
+// their config is seeded with the content of the CONFIG store of the datastore.
+type ConfigSeeder interface {
+	Seed(upds []*sdcpb.Update)
+}
+
 type SyncUpdate struct {
 	// identifies the store this updates needs to be written to if Sync.Validate == false
 	Store string
@@ -59,6 +108,9 @@ type SyncUpdate struct {
 	Update *sdcpb.Notification
 	// if true indicates the start of cache pruning
 	Start bool
+	// set along with Start, the paths covered by the sync iteration.
+	// The CONFIG store paths below them that are not synced again are stale.
+	Paths []*sdcpb.Path
 	// if true and start is true indicates first sync iteration,
 	// it overrides any ongoing pruning in the cache.
 	Force bool
@@ -67,6 +119,36 @@ type SyncUpdate struct {
 	End bool
 }
 
+// syncStore returns the SyncUpdate store of the data synced by the given sync protocol,
+// SyncStoreState for state syncs, empty otherwise.
+func syncStore(sc *config.SyncProtocol) string {
+	if sc.DataType == SyncStoreState {
+		return SyncStoreState
+	}
+	return ""
+}
+
+// syncDataType returns the data type that is requested from the target by the given sync protocol.
+func syncDataType(sc *config.SyncProtocol) sdcpb.DataType {
+	if sc.DataType == SyncStoreState {
+		return sdcpb.DataType_STATE
+	}
+	return sdcpb.DataType_CONFIG
+}
+
+// nextSyncDelay returns the delay until the next periodic sync of the given sync protocol,
+// honoring the alignment and jitter settings.
+func nextSyncDelay(sc *config.SyncProtocol, now time.Time) time.Duration {
+	delay := sc.Interval
+	if sc.Align && sc.Interval > 0 {
+		delay = now.Truncate(sc.Interval).Add(sc.Interval).Sub(now)
+	}
+	if sc.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(sc.Jitter)))
+	}
+	return delay
+}
+
 type TargetSource interface {
 	// ToJson returns the Tree contained structure as JSON
 	// use e.g. json.MarshalIndent() on the returned struct
@@ -77,4 +159,7 @@ type TargetSource interface {
 	ToXML(onlyNewOrUpdated bool, honorNamespace bool, operationWithNamespace bool, useOperationRemove bool) (*etree.Document, error)
 	ToProtoUpdates(ctx context.Context, onlyNewOrUpdated bool) ([]*sdcpb.Update, error)
 	ToProtoDeletes(ctx context.Context) ([]*sdcpb.Path, error)
+	// ToProtoBranchReplaces returns the complete content of the changed top level branches
+	// as replace updates and the branches that are removed as a whole as deletes
+	ToProtoBranchReplaces(ctx context.Context, ietf bool) ([]*sdcpb.Update, []*sdcpb.Path, error)
 }
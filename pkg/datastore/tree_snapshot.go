@@ -0,0 +1,30 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"github.com/sdcio/data-server/pkg/tree"
+)
+
+// TreeSnapshot returns the snapshot of the tree of the last intents that were set, nil if no intent
+// was set since the datastore started. The snapshot is immutable, it can be read while the next intent is set.
+func (d *Datastore) TreeSnapshot() *tree.Snapshot {
+	return d.treeSnapshot.Load()
+}
+
+// setTreeSnapshot keeps a snapshot of the tree, once the intended store reflects it.
+func (d *Datastore) setTreeSnapshot(root *tree.RootEntry) {
+	d.treeSnapshot.Store(root.Snapshot())
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/mocks/mocktarget"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDatastore_TreeSnapshot(t *testing.T) {
+	dsName := "dev1"
+
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	cacheClient.EXPECT().Modify(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	testhelper.ConfigureCacheClientMock(t, cacheClient, nil, nil, nil, nil)
+
+	schemaClient, schema, err := testhelper.InitSDCIOSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Datastore{
+		config: &config.DatastoreConfig{
+			Name:   dsName,
+			Schema: schema,
+		},
+		sbi:          mocktarget.NewMockTarget(controller),
+		cacheClient:  cacheClient,
+		schemaClient: schemaClient,
+	}
+	ctx := context.Background()
+
+	if d.TreeSnapshot() != nil {
+		t.Fatal("expected no snapshot before an intent is set")
+	}
+
+	// setIntent builds the tree of the intent setting the description and stores it like SetIntentUpdate does
+	setIntent := func(intent string, description string) *tree.RootEntry {
+		req := &sdcpb.SetIntentRequest{
+			Name:     dsName,
+			Intent:   intent,
+			Priority: 10,
+			Update: []*sdcpb.Update{{
+				Path:  &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}}, {Name: "description"}}},
+				Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: description}},
+			}},
+		}
+		tc := tree.NewTreeContext(tree.NewTreeSchemaCacheClient(dsName, d.cacheClient, d.getValidationClient()), intent)
+		root, err := d.populateTree(ctx, tc, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root.FinishInsertionPhase()
+		if err = d.updateIntendedStore(ctx, root, req); err != nil {
+			t.Fatal(err)
+		}
+		return root
+	}
+
+	setIntent("intent1", "first")
+	first := d.TreeSnapshot()
+	if first == nil {
+		t.Fatal("expected a snapshot once the intent is set")
+	}
+	if diff := cmp.Diff([]string{"intent1"}, first.Owners()); diff != "" {
+		t.Errorf("snapshot owners mismatch (-want +got):\n%s", diff)
+	}
+	want, err := first.ToJson(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the next intent replaces the snapshot, the snapshot held by a reader remains unchanged
+	root := setIntent("intent2", "second")
+	second := d.TreeSnapshot()
+	if second == first {
+		t.Error("expected the snapshot to be replaced by the next intent")
+	}
+	got, err := first.ToJson(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("first snapshot changed (-want +got):\n%s", diff)
+	}
+
+	// modifying the tree does not modify its snapshot
+	want, err = second.ToJson(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = root.AddCacheUpdateRecursive(ctx, cache.NewUpdate([]string{"interface", "ethernet-1/1", "description"}, testhelper.GetStringTvProto(t, "third"), 5, "intent3", 0), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.FinishInsertionPhase()
+	got, err = second.ToJson(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("second snapshot changed along with the tree (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/datastore/clients"
+	"github.com/sdcio/data-server/pkg/tree"
+)
+
+// warmStartPrefix is the prefix of the warm start records in the intents store.
+var warmStartPrefix = "__warm_start__"
+
+// warmStartChunkSize is the number of keys per warm start record, keeping the records within the message size limits.
+var warmStartChunkSize = 10000
+
+const (
+	// warmStartIntentName is the holder of the intent lock while the warm start records are saved
+	warmStartIntentName = "__warm_start__"
+	warmStartTimeout    = 30 * time.Second
+)
+
+// warmStartRecord is a chunk of the index of the intended store keys, saved when the datastore is stopped.
+// The records are consumed by the next start, such that they are only used as long as the intended store
+// was not modified since, e.g. after a crash the index is loaded from the intended store again.
+type warmStartRecord struct {
+	// Timestamp identifies the chunks saved together
+	Timestamp int64           `json:"timestamp"`
+	Chunk     int             `json:"chunk"`
+	Chunks    int             `json:"chunks"`
+	Keys      []*warmStartKey `json:"keys"`
+}
+
+type warmStartKey struct {
+	Path     []string `json:"path"`
+	Owner    string   `json:"owner"`
+	Priority int32    `json:"priority"`
+}
+
+func warmStartKeyName(chunk int) string {
+	return warmStartPrefix + strconv.Itoa(chunk)
+}
+
+// saveWarmStart saves the index of the intended store keys to the intents store, see warmStartRecord.
+// The intent lock is acquired and kept, such that the intended store is not modified afterwards.
+func (d *Datastore) saveWarmStart(ctx context.Context) error {
+	if _, _, ok := d.intentLock.tryAcquire(ctx, warmStartIntentName); !ok {
+		return fmt.Errorf("an intent operation is ongoing")
+	}
+	ch, err := d.cacheClient.GetKeys(ctx, d.config.Name, cachepb.Store_INTENDED)
+	if err != nil {
+		return err
+	}
+	keys := []*warmStartKey{}
+	for u := range ch {
+		keys = append(keys, &warmStartKey{Path: u.GetPath(), Owner: u.Owner(), Priority: u.Priority()})
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	ts := time.Now().UnixNano()
+	chunks := (len(keys) + warmStartChunkSize - 1) / warmStartChunkSize
+	upds := make([]*cache.Update, 0, chunks)
+	for i := 0; i < chunks; i++ {
+		b, err := json.Marshal(&warmStartRecord{
+			Timestamp: ts,
+			Chunk:     i,
+			Chunks:    chunks,
+			Keys:      keys[i*warmStartChunkSize : min((i+1)*warmStartChunkSize, len(keys))],
+		})
+		if err != nil {
+			return err
+		}
+		upd, err := d.cacheClient.NewUpdate(&sdcpb.Update{
+			Path: &sdcpb.Path{
+				Elem: []*sdcpb.PathElem{{Name: warmStartKeyName(i)}},
+			},
+			Value: &sdcpb.TypedValue{
+				Value: &sdcpb.TypedValue_BytesVal{BytesVal: b},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		upds = append(upds, upd)
+	}
+	// the records of an earlier stop that were not consumed are replaced
+	dels, _, err := d.readWarmStart(ctx)
+	if err != nil {
+		return err
+	}
+	return d.cacheClient.Modify(ctx, d.config.Name, &cache.Opts{Store: cachepb.Store_INTENTS}, dels, upds)
+}
+
+// readWarmStart returns the keys and the records of the warm start remaining in the intents store.
+func (d *Datastore) readWarmStart(ctx context.Context) ([][]string, []*warmStartRecord, error) {
+	upds := d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store:    cachepb.Store_INTENTS,
+		KeysOnly: true,
+	}, [][]string{{"*"}}, 0)
+	paths := make([][]string, 0)
+	for _, upd := range upds {
+		if len(upd.GetPath()) == 0 || !strings.HasPrefix(upd.GetPath()[0], warmStartPrefix) {
+			continue
+		}
+		paths = append(paths, []string{upd.GetPath()[0]})
+	}
+	if len(paths) == 0 {
+		return nil, nil, nil
+	}
+	upds = d.cacheClient.Read(ctx, d.config.Name, &cache.Opts{
+		Store: cachepb.Store_INTENTS,
+	}, paths, 0)
+
+	result := make([]*warmStartRecord, 0, len(upds))
+	for _, upd := range upds {
+		val, err := upd.Value()
+		if err != nil {
+			return paths, nil, err
+		}
+		rec := &warmStartRecord{}
+		if err = json.Unmarshal(val.GetBytesVal(), rec); err != nil {
+			return paths, nil, fmt.Errorf("malformed warm start record %s: %w", upd.GetPath()[0], err)
+		}
+		result = append(result, rec)
+	}
+	return paths, result, nil
+}
+
+// restoreWarmStart consumes the warm start records and restores the index of the intended store keys from them.
+// It returns the restored keys, nil if there are no complete records.
+func (d *Datastore) restoreWarmStart(ctx context.Context) ([]*cache.Update, error) {
+	paths, recs, err := d.readWarmStart(ctx)
+	if len(paths) == 0 {
+		return nil, err
+	}
+	// the records are removed first, they must not outlive the following modifications of the intended store
+	derr := d.cacheClient.Modify(ctx, d.config.Name, &cache.Opts{Store: cachepb.Store_INTENTS}, paths, nil)
+	if err != nil {
+		return nil, err
+	}
+	if derr != nil {
+		return nil, fmt.Errorf("failed removing the warm start records: %w", derr)
+	}
+	if len(recs) == 0 || len(recs) != recs[0].Chunks {
+		return nil, fmt.Errorf("incomplete warm start records, %d records found", len(recs))
+	}
+
+	keys := []*cache.Update{}
+	for _, rec := range recs {
+		if rec.Timestamp != recs[0].Timestamp || rec.Chunks != recs[0].Chunks {
+			return nil, fmt.Errorf("warm start records of different stops found")
+		}
+		for _, k := range rec.Keys {
+			keys = append(keys, cache.NewUpdate(k.Path, nil, k.Priority, k.Owner, rec.Timestamp))
+		}
+	}
+	d.intendedIndex.Restore(d.config.Name, keys)
+	return keys, nil
+}
+
+// warmStart restores the index of the intended store keys saved by the last stop and warms the schemas up
+// in the background.
+func (d *Datastore) warmStart(ctx context.Context) {
+	keys, err := d.restoreWarmStart(ctx)
+	if err != nil {
+		log.Warnf("datastore %s: not restoring the intended store index: %v", d.Name(), err)
+		return
+	}
+	if keys == nil {
+		return
+	}
+	log.Infof("datastore %s: restored the index of %d intended store keys", d.Name(), len(keys))
+	// the validation client is set up before, it is not guarded against concurrent initialization
+	scb := d.getValidationClient()
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.warmUpSchemas(ctx, scb, keys)
+	}()
+}
+
+// warmUpSchemas looks the schemas of the given keys up, such that the schema client holds them once
+// the first SetIntent needs them, e.g. the cache of a remote schema server.
+func (d *Datastore) warmUpSchemas(ctx context.Context, scb clients.ValidationClient, keys []*cache.Update) {
+	start := time.Now()
+	tscc := tree.NewTreeSchemaCacheClient(d.Name(), nil, scb)
+	for _, k := range keys {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := tscc.GetSchema(ctx, k.GetPath()); err != nil {
+			log.Debugf("datastore %s: failed warming the schema of %v up: %v", d.Name(), k.GetPath(), err)
+		}
+	}
+	log.Infof("datastore %s: warmed the schemas of %d intended store keys up in %s", d.Name(), len(keys), time.Since(start))
+}
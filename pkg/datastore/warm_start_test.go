@@ -0,0 +1,133 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sdcio/cache/proto/cachepb"
+	"go.uber.org/mock/gomock"
+
+	"github.com/sdcio/data-server/mocks/mockcacheclient"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+func TestDatastore_WarmStart(t *testing.T) {
+	chunkSize := warmStartChunkSize
+	warmStartChunkSize = 2
+	t.Cleanup(func() { warmStartChunkSize = chunkSize })
+
+	controller := gomock.NewController(t)
+	cacheClient := mockcacheclient.NewMockClient(controller)
+	store := map[string]*cache.Update{}
+	configureIntentsStoreMock(cacheClient, store)
+
+	keys := []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "name"}, nil, 10, "intent1", 0),
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "description"}, nil, 10, "intent1", 0),
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "description"}, nil, 5, "intent2", 0),
+	}
+	// the intended store is only scanned by the datastore that is stopped
+	cacheClient.EXPECT().GetKeys(gomock.Any(), "dev1", cachepb.Store_INTENDED).Times(1).DoAndReturn(
+		func(ctx context.Context, name string, store cachepb.Store) (chan *cache.Update, error) {
+			ch := make(chan *cache.Update, len(keys))
+			for _, k := range keys {
+				ch <- k
+			}
+			close(ch)
+			return ch, nil
+		},
+	)
+
+	newDatastore := func() *Datastore {
+		ic := cache.NewKeysIndexClient(cacheClient)
+		return &Datastore{
+			config:        &config.DatastoreConfig{Name: "dev1", WarmStart: true},
+			cacheClient:   ic,
+			intendedIndex: ic,
+			intentLock:    newIntentLock(),
+		}
+	}
+	ctx := context.Background()
+
+	stopped := newDatastore()
+	if err := stopped.saveWarmStart(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stopped.IntentLockHolder() == nil {
+		t.Error("expected the intent lock to be kept")
+	}
+	records := 0
+	for k := range store {
+		if strings.HasPrefix(k, warmStartPrefix) {
+			records++
+		}
+	}
+	if records != 2 {
+		t.Fatalf("expected 2 warm start records, got %d", records)
+	}
+
+	// the index is restored from the records, which are consumed
+	started := newDatastore()
+	restored, err := started.restoreWarmStart(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != len(keys) {
+		t.Errorf("expected %d restored keys, got %d", len(keys), len(restored))
+	}
+	if len(store) != 0 {
+		t.Errorf("expected the warm start records to be removed, got %d", len(store))
+	}
+	ch, err := started.cacheClient.GetKeys(ctx, "dev1", cachepb.Store_INTENDED)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for u := range ch {
+		got = append(got, u.String())
+	}
+	want := []string{}
+	for _, k := range keys {
+		want = append(want, k.String())
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("restored keys mismatch (-want +got):\n%s", diff)
+	}
+
+	// incomplete records are removed without being restored
+	if err := stopped.saveWarmStart(ctx); err == nil {
+		t.Error("expected saving to fail while the intent lock is held")
+	}
+	stopped.intentLock = newIntentLock()
+	if err := stopped.saveWarmStart(ctx); err != nil {
+		t.Fatal(err)
+	}
+	delete(store, warmStartKeyName(1))
+	restored, err = newDatastore().restoreWarmStart(ctx)
+	if err == nil || restored != nil {
+		t.Errorf("expected incomplete records not to be restored, got %d keys, err: %v", len(restored), err)
+	}
+	if len(store) != 0 {
+		t.Errorf("expected the warm start records to be removed, got %d", len(store))
+	}
+}
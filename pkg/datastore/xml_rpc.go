@@ -0,0 +1,143 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+
+	"github.com/beevik/etree"
+	"github.com/sdcio/cache/proto/cachepb"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/datastore/target/netconf"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// GetConfigXML returns the configuration of the device (running) below the paths as XML,
+// the whole configuration if no path is given.
+func (d *Datastore) GetConfigXML(ctx context.Context, paths []*sdcpb.Path) (*etree.Document, error) {
+	cpaths := make([][]string, 0, len(paths))
+	for _, p := range paths {
+		if err := d.validatePath(ctx, p); err != nil {
+			return nil, err
+		}
+		cpaths = append(cpaths, utils.ToStrings(p, false, false))
+	}
+	if len(cpaths) == 0 {
+		cpaths = append(cpaths, []string{})
+	}
+
+	treeSCC := tree.NewTreeSchemaCacheClient(d.Name(), d.cacheClient, d.getValidationClient())
+	root, err := tree.NewTreeRoot(ctx, tree.NewTreeContext(treeSCC, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	// do not read while a sync iteration is swapped into the cache
+	d.syncSwapMutex.RLock()
+	upds := d.cacheClient.Read(ctx, d.Name(), &cache.Opts{Store: cachepb.Store_CONFIG}, cpaths, 0)
+	d.syncSwapMutex.RUnlock()
+
+	for _, upd := range upds {
+		if len(upd.GetPath()) == 0 {
+			continue
+		}
+		if _, err = root.AddCacheUpdateRecursive(ctx, upd, false); err != nil {
+			return nil, err
+		}
+	}
+	root.FinishInsertionPhase()
+	return root.ToXML(false, true, false, false)
+}
+
+// EditConfigXML merges the XML configuration, e.g. the config of a NETCONF edit-config, into the intent.
+// The NETCONF delete, remove and replace operations of the elements are honored, merge is the default operation.
+func (d *Datastore) EditConfigXML(ctx context.Context, intent string, priority int32, config *etree.Element) (*SetIntentResult, error) {
+	doc := etree.NewDocument()
+	doc.SetRoot(config.Copy())
+	ns, err := netconf.NewXML2sdcpbConfigAdapter(d.getValidationClient()).Transform(ctx, doc)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid config: %v", err)
+	}
+	req := &MergeIntentRequest{
+		Intent:   intent,
+		Priority: priority,
+	}
+	for _, n := range ns {
+		req.Deletes = append(req.Deletes, n.GetDelete()...)
+		req.Updates = append(req.Updates, n.GetUpdate()...)
+	}
+	return d.MergeIntent(ctx, req)
+}
+
+// SubtreeFilterPaths returns the paths selected by the NETCONF subtree filter. The content match nodes
+// are supported for the keys of the lists, a list is either selected by all its keys or as a whole.
+func (d *Datastore) SubtreeFilterPaths(ctx context.Context, filter *etree.Element) ([]*sdcpb.Path, error) {
+	var result []*sdcpb.Path
+	for _, e := range filter.ChildElements() {
+		ps, err := d.subtreeFilterPaths(ctx, e, nil)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ps...)
+	}
+	return result, nil
+}
+
+func (d *Datastore) subtreeFilterPaths(ctx context.Context, e *etree.Element, pelems []*sdcpb.PathElem) ([]*sdcpb.Path, error) {
+	pe := &sdcpb.PathElem{Name: e.Tag}
+	pelems = append(pelems[:len(pelems):len(pelems)], pe)
+	rsp, err := d.getValidationClient().GetSchema(ctx, &sdcpb.Path{Elem: pelems})
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+
+	keys := map[string]struct{}{}
+	for _, ls := range rsp.GetSchema().GetContainer().GetKeys() {
+		keyElem := e.SelectElement(ls.GetName())
+		if keyElem == nil || len(keyElem.ChildElements()) > 0 {
+			continue
+		}
+		if pe.Key == nil {
+			pe.Key = map[string]string{}
+		}
+		pe.Key[ls.GetName()] = keyElem.Text()
+		keys[ls.GetName()] = struct{}{}
+	}
+	if len(pe.GetKey()) > 0 && len(pe.GetKey()) != len(rsp.GetSchema().GetContainer().GetKeys()) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: missing keys of list %s", utils.ToXPath(&sdcpb.Path{Elem: pelems}, false))
+	}
+
+	var result []*sdcpb.Path
+	for _, c := range e.ChildElements() {
+		if _, ok := keys[c.Tag]; ok {
+			continue
+		}
+		ps, err := d.subtreeFilterPaths(ctx, c, pelems)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ps...)
+	}
+	// an element without selection nodes selects its whole subtree
+	if len(result) == 0 {
+		result = append(result, &sdcpb.Path{Elem: pelems})
+	}
+	return result, nil
+}
@@ -1,5 +1,236 @@
 package dslog
 
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
 const (
 	TraceLevel = -8
 )
+
+// the output formats of the log entries
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// the modules of the data-server, each with its own log level
+const (
+	ModuleMain      = "main"
+	ModuleConfig    = "config"
+	ModuleServer    = "server"
+	ModuleDatastore = "datastore"
+	ModuleTarget    = "target"
+	ModuleCache     = "cache"
+	ModuleSchema    = "schema"
+	ModuleTree      = "tree"
+	ModuleUtils     = "utils"
+)
+
+// Modules are the modules with their own log level.
+var Modules = []string{ModuleMain, ModuleConfig, ModuleServer, ModuleDatastore, ModuleTarget, ModuleCache, ModuleSchema, ModuleTree, ModuleUtils}
+
+// Logger is the logger of a module.
+type Logger interface {
+	Tracef(format string, args ...any)
+	Debugf(format string, args ...any)
+	Infof(format string, args ...any)
+	Warnf(format string, args ...any)
+	Errorf(format string, args ...any)
+	Debug(args ...any)
+	Info(args ...any)
+	Warn(args ...any)
+	Error(args ...any)
+	// WithFields returns a Logger adding the fields to all its entries
+	WithFields(fields map[string]any) Logger
+	// IsDebugEnabled returns true if the debug entries of the module are logged
+	IsDebugEnabled() bool
+}
+
+var (
+	mu      sync.Mutex
+	format  = FormatText
+	loggers = map[string]*logrus.Logger{}
+	// the level of the tree module, which logs via slog
+	treeLevel = new(slog.LevelVar)
+	// the length values are truncated to in the log entries
+	maxValueLength atomic.Int64
+)
+
+// SetMaxValueLength sets the length huge values, e.g. certificates or banners, are truncated to
+// in the log entries. 0 disables the truncation.
+func SetMaxValueLength(n int) {
+	maxValueLength.Store(int64(n))
+}
+
+// MaxValueLength returns the length values are truncated to in the log entries, 0 if they are not truncated.
+func MaxValueLength() int {
+	return int(maxValueLength.Load())
+}
+
+// New returns the Logger of the module.
+func New(module string) Logger {
+	return &logrusLogger{entry: moduleLogger(module).WithField("module", module)}
+}
+
+func moduleLogger(module string) *logrus.Logger {
+	mu.Lock()
+	defer mu.Unlock()
+	l, ok := loggers[module]
+	if !ok {
+		l = logrus.New()
+		l.SetFormatter(newFormatter(format))
+		loggers[module] = l
+	}
+	return l
+}
+
+func newFormatter(f string) logrus.Formatter {
+	if f == FormatJSON {
+		return &logrus.JSONFormatter{}
+	}
+	return &logrus.TextFormatter{FullTimestamp: true}
+}
+
+// Configure sets the format of the log entries along with the log level of all modules,
+// the modules levels override the level of the individual modules.
+func Configure(f string, level string, moduleLevels map[string]string) error {
+	switch f {
+	case "":
+		f = FormatText
+	case FormatText, FormatJSON:
+	default:
+		return fmt.Errorf("unknown log format: %s. Must be one of %s, %s", f, FormatText, FormatJSON)
+	}
+	if err := ValidateLevels(level, moduleLevels); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	format = f
+	for _, l := range loggers {
+		l.SetFormatter(newFormatter(f))
+	}
+	mu.Unlock()
+
+	opts := &slog.HandlerOptions{Level: treeLevel}
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, opts)
+	if f == FormatJSON {
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(h).With("module", ModuleTree))
+
+	if level != "" {
+		if err := SetLevel("", level); err != nil {
+			return err
+		}
+	}
+	for m, lvl := range moduleLevels {
+		if err := SetLevel(m, lvl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateLevels checks the level and the levels of the modules.
+func ValidateLevels(level string, moduleLevels map[string]string) error {
+	if level != "" {
+		if _, err := logrus.ParseLevel(level); err != nil {
+			return err
+		}
+	}
+	for m, lvl := range moduleLevels {
+		if !isModule(m) {
+			return fmt.Errorf("unknown log module: %s. Must be one of %v", m, Modules)
+		}
+		if _, err := logrus.ParseLevel(lvl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetLevel sets the log level of the module, of all modules if the module is empty.
+func SetLevel(module string, level string) error {
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	if module != "" && !isModule(module) {
+		return fmt.Errorf("unknown log module: %s. Must be one of %v", module, Modules)
+	}
+	for _, m := range Modules {
+		if module != "" && m != module {
+			continue
+		}
+		moduleLogger(m).SetLevel(lvl)
+		if m == ModuleTree {
+			treeLevel.Set(slogLevel(lvl))
+		}
+	}
+	return nil
+}
+
+// Levels returns the log levels of all modules.
+func Levels() map[string]string {
+	result := make(map[string]string, len(Modules))
+	for _, m := range Modules {
+		result[m] = moduleLogger(m).GetLevel().String()
+	}
+	return result
+}
+
+func isModule(module string) bool {
+	i := sort.SearchStrings(sortedModules, module)
+	return i < len(sortedModules) && sortedModules[i] == module
+}
+
+var sortedModules = func() []string {
+	s := append([]string{}, Modules...)
+	sort.Strings(s)
+	return s
+}()
+
+func slogLevel(lvl logrus.Level) slog.Level {
+	switch lvl {
+	case logrus.TraceLevel:
+		return TraceLevel
+	case logrus.DebugLevel:
+		return slog.LevelDebug
+	case logrus.InfoLevel:
+		return slog.LevelInfo
+	case logrus.WarnLevel:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
+type logrusLogger struct {
+	entry *logrus.Entry
+}
+
+func (l *logrusLogger) Tracef(format string, args ...any) { l.entry.Tracef(format, args...) }
+func (l *logrusLogger) Debugf(format string, args ...any) { l.entry.Debugf(format, args...) }
+func (l *logrusLogger) Infof(format string, args ...any)  { l.entry.Infof(format, args...) }
+func (l *logrusLogger) Warnf(format string, args ...any)  { l.entry.Warnf(format, args...) }
+func (l *logrusLogger) Errorf(format string, args ...any) { l.entry.Errorf(format, args...) }
+func (l *logrusLogger) Debug(args ...any)                 { l.entry.Debug(args...) }
+func (l *logrusLogger) Info(args ...any)                  { l.entry.Info(args...) }
+func (l *logrusLogger) Warn(args ...any)                  { l.entry.Warn(args...) }
+func (l *logrusLogger) Error(args ...any)                 { l.entry.Error(args...) }
+
+func (l *logrusLogger) WithFields(fields map[string]any) Logger {
+	return &logrusLogger{entry: l.entry.WithFields(fields)}
+}
+
+func (l *logrusLogger) IsDebugEnabled() bool {
+	return l.entry.Logger.IsLevelEnabled(logrus.DebugLevel)
+}
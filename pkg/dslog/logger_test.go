@@ -0,0 +1,49 @@
+package dslog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestSetLevel(t *testing.T) {
+	if err := Configure(FormatJSON, "info", map[string]string{ModuleTree: "debug"}); err != nil {
+		t.Fatal(err)
+	}
+	levels := Levels()
+	if levels[ModuleDatastore] != "info" || levels[ModuleTree] != "debug" {
+		t.Errorf("unexpected levels %v", levels)
+	}
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		t.Errorf("expected the tree module to log debug entries via slog")
+	}
+	if New(ModuleDatastore).IsDebugEnabled() {
+		t.Errorf("expected the datastore module not to log debug entries")
+	}
+
+	if err := SetLevel(ModuleDatastore, "debug"); err != nil {
+		t.Fatal(err)
+	}
+	if !New(ModuleDatastore).IsDebugEnabled() {
+		t.Errorf("expected the datastore module to log debug entries")
+	}
+
+	if err := SetLevel("", "warn"); err != nil {
+		t.Fatal(err)
+	}
+	for m, l := range Levels() {
+		if l != "warning" {
+			t.Errorf("expected module %s to be set to warning, got %s", m, l)
+		}
+	}
+
+	if err := SetLevel("unknown", "debug"); err == nil {
+		t.Errorf("expected an error for an unknown module")
+	}
+	if err := SetLevel(ModuleTree, "verbose"); err == nil {
+		t.Errorf("expected an error for an unknown level")
+	}
+	if err := Configure("xml", "", nil); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
@@ -17,6 +17,7 @@ package schema
 import (
 	"context"
 
+	schemaServerSchema "github.com/sdcio/schema-server/pkg/schema"
 	schemaStore "github.com/sdcio/schema-server/pkg/store"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 	"google.golang.org/grpc"
@@ -24,12 +25,21 @@ import (
 
 type localClient struct {
 	schemaStore.Store
+	// the parsed schemas, which report the YANG status of their nodes
+	schemas map[schemaStore.SchemaKey]*schemaServerSchema.Schema
 }
 
-func NewLocalClient(store schemaStore.Store) Client {
-	return &localClient{
-		Store: store,
+// NewLocalClient returns a Client of the local schema store. The status of the schema nodes
+// is reported for the given schemas, which have to be the ones parsed into the store.
+func NewLocalClient(store schemaStore.Store, schemas ...*schemaServerSchema.Schema) Client {
+	c := &localClient{
+		Store:   store,
+		schemas: make(map[schemaStore.SchemaKey]*schemaServerSchema.Schema, len(schemas)),
 	}
+	for _, sc := range schemas {
+		c.schemas[schemaStore.SchemaKey{Name: sc.Name(), Vendor: sc.Vendor(), Version: sc.Version()}] = sc
+	}
+	return c
 }
 
 // returns schema name, vendor, version, and files path(s)
@@ -84,3 +94,13 @@ func (c *localClient) ExpandPath(ctx context.Context, in *sdcpb.ExpandPathReques
 func (c *localClient) GetSchemaElements(ctx context.Context, in *sdcpb.GetSchemaRequest, opts ...grpc.CallOption) (chan *sdcpb.SchemaElem, error) {
 	return c.Store.GetSchemaElements(ctx, in)
 }
+
+// GetStatus returns the YANG status of the schema node of the path
+func (c *localClient) GetStatus(ctx context.Context, s *sdcpb.Schema, p *sdcpb.Path) (string, error) {
+	sc, ok := c.schemas[schemaKey(s)]
+	if !ok {
+		// e.g. schemas loaded from the persistent store without parsing them
+		return StatusCurrent, nil
+	}
+	return NodeStatus(sc, p)
+}
@@ -21,7 +21,6 @@ import (
 
 	"github.com/jellydator/ttlcache/v3"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/proto"
 
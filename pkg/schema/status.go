@@ -0,0 +1,75 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"context"
+
+	schemaServerSchema "github.com/sdcio/schema-server/pkg/schema"
+	schemaStore "github.com/sdcio/schema-server/pkg/store"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+// the YANG status of the schema nodes
+const (
+	StatusCurrent    = "current"
+	StatusDeprecated = "deprecated"
+	StatusObsolete   = "obsolete"
+)
+
+// StatusClient is implemented by the schema clients that report the YANG status of the schema nodes.
+// The sdcpb schema messages do not carry the status, hence it is only reported for the schemas
+// parsed by the local schema store.
+type StatusClient interface {
+	// GetStatus returns the status of the schema node of the path. Nodes inherit the status of
+	// their ancestors, e.g. the leafs of a deprecated container are deprecated as well.
+	// It returns StatusCurrent for nodes of unknown schemas.
+	GetStatus(ctx context.Context, s *sdcpb.Schema, p *sdcpb.Path) (string, error)
+}
+
+// NodeStatus returns the status of the schema node of the path within the schema.
+func NodeStatus(sc *schemaServerSchema.Schema, p *sdcpb.Path) (string, error) {
+	pes := make([]string, 0, len(p.GetElem()))
+	for _, pe := range p.GetElem() {
+		pes = append(pes, pe.GetName())
+	}
+	e, err := sc.GetEntry(pes)
+	if err != nil {
+		return "", err
+	}
+	result := StatusCurrent
+	for ; e != nil; e = e.Parent {
+		if e.Node == nil {
+			continue
+		}
+		for _, s := range e.Node.Statement().SubStatements() {
+			if s.Keyword != "status" {
+				continue
+			}
+			switch s.Argument {
+			case StatusObsolete:
+				return StatusObsolete, nil
+			case StatusDeprecated:
+				result = StatusDeprecated
+			}
+		}
+	}
+	return result, nil
+}
+
+// schemaKey returns the key of the schema within the schema store.
+func schemaKey(s *sdcpb.Schema) schemaStore.SchemaKey {
+	return schemaStore.SchemaKey{Name: s.GetName(), Vendor: s.GetVendor(), Version: s.GetVersion()}
+}
@@ -21,7 +21,6 @@ import (
 	"time"
 
 	cconfig "github.com/sdcio/cache/pkg/config"
-	log "github.com/sirupsen/logrus"
 
 	"github.com/sdcio/data-server/pkg/cache"
 )
@@ -53,14 +52,21 @@ START:
 }
 
 func (s *Server) createLocalCacheClient(_ context.Context) error {
-	var err error
 	log.Infof("initializing local cache client")
-	s.cacheClient, err = cache.NewLocalCache(&cconfig.CacheConfig{
+	c, err := cache.NewLocalCache(&cconfig.CacheConfig{
 		MaxCaches: -1,
 		StoreType: s.config.Cache.StoreType,
 		Dir:       s.config.Cache.Dir,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	var cc cache.Client = c
+	if b := s.config.Cache.Batching; b != nil {
+		cc = cache.NewBatchingClient(c, b.FlushInterval, b.MaxSize)
+	}
+	s.cacheClient = cache.NewStatsClient(cc)
+	return nil
 }
 
 // func (s *Server) createRemoteCacheClient(ctx context.Context) error {
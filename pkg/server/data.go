@@ -20,8 +20,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/sdcio/data-server/pkg/datastore"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
@@ -74,7 +74,8 @@ func (s *Server) GetData(req *sdcpb.GetDataRequest, stream sdcpb.DataServer_GetD
 			}
 		}
 	}()
-	err := ds.Get(stream.Context(), req, nCh)
+	// the notifications are chunked such that they fit the send limit of the server
+	err := ds.GetWithOpts(stream.Context(), req, &datastore.GetDataOpts{MaxMsgSize: s.config.GRPCServer.MaxSendMsgSize}, nCh)
 	if err != nil {
 		return err
 	}
@@ -16,14 +16,17 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
+	"slices"
 	"strings"
 	"time"
 
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 
@@ -39,12 +42,17 @@ func (s *Server) ListDataStore(ctx context.Context, req *sdcpb.ListDataStoreRequ
 	defer s.md.RUnlock()
 	numDs := len(s.datastores)
 	rs := make([]*sdcpb.GetDataStoreResponse, 0, numDs)
+	dss := make([]*datastore.Datastore, 0, numDs)
 	for _, ds := range s.datastores {
 		r, err := s.datastoreToRsp(ctx, ds)
 		if err != nil {
 			return nil, err
 		}
 		rs = append(rs, r)
+		dss = append(dss, ds)
+	}
+	if err := setDatastoreInfoHeader(ctx, dss...); err != nil {
+		return nil, err
 	}
 	return &sdcpb.ListDataStoreResponse{
 		Datastores: rs,
@@ -63,9 +71,91 @@ func (s *Server) GetDataStore(ctx context.Context, req *sdcpb.GetDataStoreReques
 	if !ok {
 		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
 	}
+	if err := setDatastoreInfoHeader(ctx, ds); err != nil {
+		return nil, err
+	}
 	return s.datastoreToRsp(ctx, ds)
 }
 
+// DatastoreInfoHeader is the binary gRPC response header of ListDataStore and GetDataStore, carrying the
+// runtime information the sdcpb.GetDataStoreResponse cannot carry. It holds a JSON encoded DatastoreInfo
+// per datastore of the response.
+const DatastoreInfoHeader = "sdcio-datastore-info-bin"
+
+// DatastoreInfo is the runtime information of a datastore.
+type DatastoreInfo struct {
+	Name            string          `json:"name"`
+	ConnectionState string          `json:"connection-state,omitempty"`
+	SyncState       string          `json:"sync-state,omitempty"`
+	SchemaVendor    string          `json:"schema-vendor,omitempty"`
+	SchemaVersion   string          `json:"schema-version,omitempty"`
+	Intents         int             `json:"intents"`
+	LastSync        *time.Time      `json:"last-sync,omitempty"`
+	LastApply       *DatastoreApply `json:"last-apply,omitempty"`
+	Maintenance     string          `json:"maintenance,omitempty"`
+	Dirty           bool            `json:"dirty,omitempty"`
+}
+
+// DatastoreApply describes the last transaction sent to the target of a datastore.
+type DatastoreApply struct {
+	Intent   string        `json:"intent,omitempty"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	// Error returned by the target, empty on success
+	Error string `json:"error,omitempty"`
+}
+
+func datastoreInfo(ctx context.Context, ds *datastore.Datastore) (*DatastoreInfo, error) {
+	info, err := ds.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &DatastoreInfo{
+		Name:            ds.Name(),
+		ConnectionState: info.ConnectionState,
+		SyncState:       info.SyncState,
+		SchemaVendor:    ds.Config().Schema.Vendor,
+		SchemaVersion:   ds.Config().Schema.Version,
+		Intents:         info.Intents,
+		Dirty:           info.Dirty,
+	}
+	if info.LastSync != nil {
+		t := info.LastSync.Start.Add(info.LastSync.Duration)
+		result.LastSync = &t
+	}
+	if a := info.LastApply; a != nil {
+		result.LastApply = &DatastoreApply{
+			Intent:   a.Intent,
+			Time:     a.Start,
+			Duration: a.Duration,
+			Error:    a.Error,
+		}
+	}
+	if info.Maintenance != nil {
+		result.Maintenance = info.Maintenance.Reason
+	}
+	return result, nil
+}
+
+// setDatastoreInfoHeader sets the DatastoreInfoHeader of the response.
+func setDatastoreInfoHeader(ctx context.Context, dss ...*datastore.Datastore) error {
+	vals := make([]string, 0, len(dss))
+	for _, ds := range dss {
+		info, err := datastoreInfo(ctx, ds)
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(info)
+		if err != nil {
+			return err
+		}
+		vals = append(vals, string(b))
+	}
+	// fails if the server is not invoked via gRPC, e.g. in tests, which do not need the header
+	_ = grpc.SetHeader(ctx, metadata.MD{DatastoreInfoHeader: vals})
+	return nil
+}
+
 func (s *Server) CreateDataStore(ctx context.Context, req *sdcpb.CreateDataStoreRequest) (*sdcpb.CreateDataStoreResponse, error) {
 	log.Debugf("Received CreateDataStoreRequest: %v", req)
 	name := req.GetName()
@@ -235,15 +325,15 @@ func (s *Server) DeleteDataStore(ctx context.Context, req *sdcpb.DeleteDataStore
 
 	switch {
 	case req.GetDatastore() == nil:
-		err := ds.Stop()
+		err := ds.Delete(ctx)
 		if err != nil {
-			log.Errorf("failed to stop datastore %s: %v", name, err)
-		}
-		err = ds.DeleteCache(ctx)
-		if err != nil {
-			log.Errorf("failed to delete the datastore %s cache: %v", name, err)
+			log.Errorf("failed to delete datastore %s: %v", name, err)
 		}
 		delete(s.datastores, name)
+		// drop the definition, such that the datastore is not recreated from the config
+		s.config.Datastores = slices.DeleteFunc(s.config.Datastores, func(dsCfg *config.DatastoreConfig) bool {
+			return dsCfg.Name == name
+		})
 		log.Infof("deleted datastore %s", name)
 		return &sdcpb.DeleteDataStoreResponse{}, nil
 	default:
@@ -320,6 +410,60 @@ func (s *Server) Discard(ctx context.Context, req *sdcpb.DiscardRequest) (*sdcpb
 	return &sdcpb.DiscardResponse{}, nil
 }
 
+// ResyncTarget forces an immediate full sync of the datastore's target.
+func (s *Server) ResyncTarget(ctx context.Context, name string) error {
+	log.Debugf("Received ResyncTarget request for datastore %s", name)
+	if name == "" {
+		return status.Error(codes.InvalidArgument, "missing name attribute")
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+	ds, ok := s.datastores[name]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	err := ds.ResyncTarget(ctx)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+	return nil
+}
+
+// IntentLockHolder returns the intent currently holding the SetIntent lock of the datastore, nil if it is free.
+func (s *Server) IntentLockHolder(ctx context.Context, name string) (*datastore.IntentLockHolder, error) {
+	log.Debugf("Received IntentLockHolder request for datastore %s", name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing name attribute")
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+	ds, ok := s.datastores[name]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	return ds.IntentLockHolder(), nil
+}
+
+// AbortIntent aborts the SetIntent currently holding the SetIntent lock of the datastore.
+// If force is set, the lock is released without waiting for the aborted SetIntent to return.
+func (s *Server) AbortIntent(ctx context.Context, name string, force bool) error {
+	log.Debugf("Received AbortIntent request for datastore %s, force=%t", name, force)
+	if name == "" {
+		return status.Error(codes.InvalidArgument, "missing name attribute")
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+	ds, ok := s.datastores[name]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	err := ds.AbortIntent(force)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+	return nil
+}
+
 func (s *Server) WatchDeviations(req *sdcpb.WatchDeviationRequest, stream sdcpb.DataServer_WatchDeviationsServer) error {
 	log.Debugf("Received WatchDeviationRequest: %v", req)
 	ctx := stream.Context()
@@ -360,7 +504,7 @@ func (s *Server) datastoreToRsp(ctx context.Context, ds *datastore.Datastore) (*
 	rsp.Datastore = append(rsp.Datastore, cands...)
 	rsp.Target = &sdcpb.Target{
 		Type:    ds.Config().SBI.Type,
-		Address: ds.Config().SBI.Address,
+		Address: ds.ActiveAddress(),
 	}
 	// map datastore sbi conn state to sdcpb.TargetStatus
 	switch ds.ConnectionState() {
@@ -377,6 +521,144 @@ func (s *Server) datastoreToRsp(ctx context.Context, ds *datastore.Datastore) (*
 		rsp.Target.StatusDetails = ds.ConnectionState()
 	}
 
+	// the sdcpb.GetDataStoreResponse cannot carry the maintenance, the sync pause and the pending changes,
+	// hence they are reported along with the target status
+	var details []string
+	if rsp.Target.StatusDetails != "" {
+		details = append(details, rsp.Target.StatusDetails)
+	}
+	if m := ds.Maintenance(); m != nil {
+		details = append(details, fmt.Sprintf("maintenance: %s", m.Reason))
+	}
+	if p := ds.SyncPause(); p != nil {
+		details = append(details, fmt.Sprintf("sync paused: %s", p.Reason))
+	}
+	if ds.Dirty() {
+		details = append(details, "pending changes")
+	}
+	rsp.Target.StatusDetails = strings.Join(details, ", ")
+
 	rsp.Schema = ds.Config().Schema.GetSchema()
 	return rsp, nil
 }
+
+// SetDatastoreMaintenance puts the datastore into maintenance mode, e.g. during device upgrades,
+// nil ends the maintenance. SetIntents are rejected or queued during the maintenance.
+// The sdcpb API does not define a maintenance RPC, hence it is exposed on the Server and the debug service.
+func (s *Server) SetDatastoreMaintenance(ctx context.Context, name string, m *datastore.Maintenance) error {
+	log.Debugf("Received SetDatastoreMaintenance request for datastore %s: %+v", name, m)
+	if name == "" {
+		return status.Error(codes.InvalidArgument, "missing datastore name")
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+	ds, ok := s.datastores[name]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	if m != nil && m.PauseSync && ds.Config().Sync == nil {
+		return status.Errorf(codes.InvalidArgument, "datastore %s has no sync configured", name)
+	}
+	ds.SetMaintenance(m)
+	return nil
+}
+
+// PauseDatastoreSync pauses the sync of the datastore, e.g. during bulk intent migrations,
+// until ResumeDatastoreSync is called.
+// The sdcpb API does not define a sync pause RPC, hence it is exposed on the Server and the debug service.
+func (s *Server) PauseDatastoreSync(ctx context.Context, name string, reason string) error {
+	log.Debugf("Received PauseDatastoreSync request for datastore %s: %s", name, reason)
+	ds, err := s.syncedDatastore(name)
+	if err != nil {
+		return err
+	}
+	ds.PauseSync(reason)
+	return nil
+}
+
+// ResumeDatastoreSync resumes the sync of the datastore paused by PauseDatastoreSync,
+// the sync restarts with a full sync.
+// The sdcpb API does not define a sync resume RPC, hence it is exposed on the Server and the debug service.
+func (s *Server) ResumeDatastoreSync(ctx context.Context, name string) error {
+	log.Debugf("Received ResumeDatastoreSync request for datastore %s", name)
+	ds, err := s.syncedDatastore(name)
+	if err != nil {
+		return err
+	}
+	ds.ResumeSync()
+	return nil
+}
+
+// syncedDatastore returns the named datastore, if it has a sync configured.
+func (s *Server) syncedDatastore(name string) (*datastore.Datastore, error) {
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing datastore name")
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+	ds, ok := s.datastores[name]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	if ds.Config().Sync == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "datastore %s has no sync configured", name)
+	}
+	return ds, nil
+}
+
+// PushDatastoreChanges pushes the changes accumulated by the SetIntents of the datastore to the device in a single
+// transaction, e.g. in manual push-mode. It returns the intents whose changes were pushed.
+// The sdcpb API does not define a push RPC, hence it is exposed on the Server and the debug service.
+func (s *Server) PushDatastoreChanges(ctx context.Context, name string) ([]string, error) {
+	log.Debugf("Received PushDatastoreChanges request for datastore %s", name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing datastore name")
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+	ds, ok := s.datastores[name]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	return ds.PushChanges(ctx)
+}
+
+// IntentJSONSchema returns the JSON Schema of the values of the intent updates at the path of the datastore.
+// The sdcpb API does not define a JSON Schema RPC, hence it is exposed on the Server and the debug service.
+func (s *Server) IntentJSONSchema(ctx context.Context, name string, p *sdcpb.Path) (map[string]any, error) {
+	log.Debugf("Received IntentJSONSchema request for datastore %s", name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing datastore name")
+	}
+	s.md.RLock()
+	ds, ok := s.datastores[name]
+	s.md.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	js, err := ds.IntentJSONSchema(ctx, p)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return js, nil
+}
+
+// Journal returns the journal of the requests sent to the target of the datastore.
+// The sdcpb API does not yet define a journal RPC, hence it is exposed on the Server only.
+func (s *Server) Journal(ctx context.Context, name string, q *datastore.JournalQuery) ([]*datastore.JournalEntry, error) {
+	log.Debugf("Received Journal request for datastore %s", name)
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing name attribute")
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+	ds, ok := s.datastores[name]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	rsp, err := ds.Journal(ctx, q)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return rsp, nil
+}
@@ -0,0 +1,570 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/datastore"
+	"github.com/sdcio/data-server/pkg/dslog"
+	"github.com/sdcio/data-server/pkg/tree"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+const (
+	debugProtoFile   = "sdcio/data/debug.proto"
+	debugServiceName = "sdcio.data.debug.Debug"
+)
+
+// debugServer is the debug service exposing runtime information for live troubleshooting,
+// along with changing the log levels at runtime.
+// The sdcpb API does not define such a service, hence its descriptor is built at runtime,
+// such that it can be discovered via the server reflection.
+type debugServer interface {
+	GetRuntimeInfo(context.Context, *emptypb.Empty) (*structpb.Struct, error)
+	GetLogLevels(context.Context, *emptypb.Empty) (*structpb.Struct, error)
+	SetLogLevel(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	SetMaintenance(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	PushChanges(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	GetBlame(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	GetIntentSchema(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	SetIntentsTransactional(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	PauseSync(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	ResumeSync(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	GetTreeSnapshot(context.Context, *structpb.Struct) (*structpb.Struct, error)
+}
+
+var debugServiceDesc = grpc.ServiceDesc{
+	ServiceName: debugServiceName,
+	HandlerType: (*debugServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRuntimeInfo",
+			Handler:    debugHandler("GetRuntimeInfo", debugServer.GetRuntimeInfo),
+		},
+		{
+			MethodName: "GetLogLevels",
+			Handler:    debugHandler("GetLogLevels", debugServer.GetLogLevels),
+		},
+		{
+			MethodName: "SetLogLevel",
+			Handler:    debugHandler("SetLogLevel", debugServer.SetLogLevel),
+		},
+		{
+			MethodName: "SetMaintenance",
+			Handler:    debugHandler("SetMaintenance", debugServer.SetMaintenance),
+		},
+		{
+			MethodName: "PushChanges",
+			Handler:    debugHandler("PushChanges", debugServer.PushChanges),
+		},
+		{
+			MethodName: "GetBlame",
+			Handler:    debugHandler("GetBlame", debugServer.GetBlame),
+		},
+		{
+			MethodName: "GetIntentSchema",
+			Handler:    debugHandler("GetIntentSchema", debugServer.GetIntentSchema),
+		},
+		{
+			MethodName: "SetIntentsTransactional",
+			Handler:    debugHandler("SetIntentsTransactional", debugServer.SetIntentsTransactional),
+		},
+		{
+			MethodName: "PauseSync",
+			Handler:    debugHandler("PauseSync", debugServer.PauseSync),
+		},
+		{
+			MethodName: "ResumeSync",
+			Handler:    debugHandler("ResumeSync", debugServer.ResumeSync),
+		},
+		{
+			MethodName: "GetTreeSnapshot",
+			Handler:    debugHandler("GetTreeSnapshot", debugServer.GetTreeSnapshot),
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: debugProtoFile,
+}
+
+// debugHandler returns the grpc handler of the debug service method.
+func debugHandler[T proto.Message](name string, f func(debugServer, context.Context, T) (*structpb.Struct, error)) grpc.MethodHandler {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		var in T
+		in = in.ProtoReflect().Type().New().Interface().(T)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return f(srv.(debugServer), ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/" + debugServiceName + "/" + name,
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return f(srv.(debugServer), ctx, req.(T))
+		}
+		return interceptor(ctx, in, info, handler)
+	}
+}
+
+// registerDebugServer registers the descriptor of the debug service and the service itself.
+func registerDebugServer(srv *grpc.Server, ds debugServer) error {
+	if _, err := protoregistry.GlobalFiles.FindFileByPath(debugProtoFile); err != nil {
+		fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+			Name:       proto.String(debugProtoFile),
+			Package:    proto.String("sdcio.data.debug"),
+			Dependency: []string{"google/protobuf/empty.proto", "google/protobuf/struct.proto"},
+			Syntax:     proto.String("proto3"),
+			Service: []*descriptorpb.ServiceDescriptorProto{{
+				Name: proto.String("Debug"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("GetRuntimeInfo"),
+						InputType:  proto.String(".google.protobuf.Empty"),
+						OutputType: proto.String(".google.protobuf.Struct"),
+					},
+					{
+						Name:       proto.String("GetLogLevels"),
+						InputType:  proto.String(".google.protobuf.Empty"),
+						OutputType: proto.String(".google.protobuf.Struct"),
+					},
+					{
+						Name:       proto.String("SetLogLevel"),
+						InputType:  proto.String(".google.protobuf.Struct"),
+						OutputType: proto.String(".google.protobuf.Struct"),
+					},
+					{
+						Name:       proto.String("SetMaintenance"),
+						InputType:  proto.String(".google.protobuf.Struct"),
+						OutputType: proto.String(".google.protobuf.Struct"),
+					},
+					{
+						Name:       proto.String("PushChanges"),
+						InputType:  proto.String(".google.protobuf.Struct"),
+						OutputType: proto.String(".google.protobuf.Struct"),
+					},
+					{
+						Name:       proto.String("GetBlame"),
+						InputType:  proto.String(".google.protobuf.Struct"),
+						OutputType: proto.String(".google.protobuf.Struct"),
+					},
+					{
+						Name:       proto.String("GetIntentSchema"),
+						InputType:  proto.String(".google.protobuf.Struct"),
+						OutputType: proto.String(".google.protobuf.Struct"),
+					},
+					{
+						Name:       proto.String("SetIntentsTransactional"),
+						InputType:  proto.String(".google.protobuf.Struct"),
+						OutputType: proto.String(".google.protobuf.Struct"),
+					},
+					{
+						Name:       proto.String("PauseSync"),
+						InputType:  proto.String(".google.protobuf.Struct"),
+						OutputType: proto.String(".google.protobuf.Struct"),
+					},
+					{
+						Name:       proto.String("ResumeSync"),
+						InputType:  proto.String(".google.protobuf.Struct"),
+						OutputType: proto.String(".google.protobuf.Struct"),
+					},
+					{
+						Name:       proto.String("GetTreeSnapshot"),
+						InputType:  proto.String(".google.protobuf.Struct"),
+						OutputType: proto.String(".google.protobuf.Struct"),
+					},
+				},
+			}},
+		}, protoregistry.GlobalFiles)
+		if err != nil {
+			return err
+		}
+		if err = protoregistry.GlobalFiles.RegisterFile(fd); err != nil {
+			return err
+		}
+	}
+	srv.RegisterService(&debugServiceDesc, ds)
+	return nil
+}
+
+// GetRuntimeInfo returns the goroutine counts per datastore, the states of the datastores
+// and their sync loops as well as the statistics of the cache client.
+func (s *Server) GetRuntimeInfo(ctx context.Context, _ *emptypb.Empty) (*structpb.Struct, error) {
+	goroutines, err := goroutinesByLabel(datastore.GoroutineLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	s.md.RLock()
+	dss := make(map[string]any, len(s.datastores))
+	for name, ds := range s.datastores {
+		info := map[string]any{
+			"goroutines":       goroutines[name],
+			"connection-state": ds.ConnectionState(),
+			"sync-state":       ds.SyncState(),
+			"dirty":            ds.Dirty(),
+		}
+		if stats := ds.LastSyncStats(); stats != nil {
+			info["last-sync"] = map[string]any{
+				"start":         stats.Start.Format(time.RFC3339Nano),
+				"duration":      stats.Duration.String(),
+				"notifications": stats.Notifications,
+				"updates":       stats.Updates,
+				"deletes":       stats.Deletes,
+			}
+		}
+		if stats := ds.LastApplyStats(); stats != nil {
+			info["last-apply"] = map[string]any{
+				"intent":   stats.Intent,
+				"start":    stats.Start.Format(time.RFC3339Nano),
+				"duration": stats.Duration.String(),
+				"error":    stats.Error,
+			}
+		}
+		if m := ds.Maintenance(); m != nil {
+			info["maintenance"] = maintenanceInfo(m)
+		}
+		if p := ds.SyncPause(); p != nil {
+			info["sync-pause"] = syncPauseInfo(p)
+		}
+		if holder := ds.IntentLockHolder(); holder != nil {
+			info["intent-lock"] = map[string]any{
+				"intent": holder.Intent,
+				"since":  holder.Since.Format(time.RFC3339Nano),
+			}
+		}
+		dss[name] = info
+	}
+	s.md.RUnlock()
+
+	result := map[string]any{
+		"goroutines": runtime.NumGoroutine(),
+		"datastores": dss,
+	}
+	if sc, ok := s.cacheClient.(*cache.StatsClient); ok {
+		stats := sc.Stats()
+		result["cache"] = map[string]any{
+			"reads":     stats.Reads,
+			"modifies":  stats.Modifies,
+			"key-reads": stats.KeyReads,
+			"errors":    stats.Errors,
+		}
+	}
+	return structpb.NewStruct(result)
+}
+
+// GetLogLevels returns the log levels of the modules.
+func (s *Server) GetLogLevels(ctx context.Context, _ *emptypb.Empty) (*structpb.Struct, error) {
+	return logLevels()
+}
+
+// SetLogLevel changes the log level at runtime, the request carries the level and optionally the module,
+// e.g. {"module": "datastore", "level": "debug"}. The level of all modules is set if the module is not set.
+// It returns the resulting log levels of the modules.
+func (s *Server) SetLogLevel(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	module := req.GetFields()["module"].GetStringValue()
+	level := req.GetFields()["level"].GetStringValue()
+	if level == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing level")
+	}
+	if err := dslog.SetLevel(module, level); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	log.Infof("log level of module %q set to %s", module, level)
+	return logLevels()
+}
+
+// SetMaintenance puts a datastore into maintenance mode or ends it, e.g.
+// {"datastore": "dev1", "enabled": true, "reason": "upgrade", "queue-intents": true, "pause-sync": false}.
+// It returns the resulting maintenance of the datastore.
+func (s *Server) SetMaintenance(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	fields := req.GetFields()
+	var m *datastore.Maintenance
+	if fields["enabled"].GetBoolValue() {
+		m = &datastore.Maintenance{
+			Reason:       fields["reason"].GetStringValue(),
+			QueueIntents: fields["queue-intents"].GetBoolValue(),
+			PauseSync:    fields["pause-sync"].GetBoolValue(),
+		}
+	}
+	name := fields["datastore"].GetStringValue()
+	if err := s.SetDatastoreMaintenance(ctx, name, m); err != nil {
+		return nil, err
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+	result := map[string]any{"datastore": name}
+	if ds, ok := s.datastores[name]; ok {
+		if m := ds.Maintenance(); m != nil {
+			result["maintenance"] = maintenanceInfo(m)
+		}
+	}
+	return structpb.NewStruct(result)
+}
+
+// PushChanges pushes the changes accumulated by the SetIntents of a datastore to the device, e.g. {"datastore": "dev1"}.
+// It returns the intents whose changes were pushed.
+func (s *Server) PushChanges(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	name := req.GetFields()["datastore"].GetStringValue()
+	intents, err := s.PushDatastoreChanges(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	pushed := make([]any, 0, len(intents))
+	for _, intent := range intents {
+		pushed = append(pushed, intent)
+	}
+	return structpb.NewStruct(map[string]any{
+		"datastore": name,
+		"intents":   pushed,
+	})
+}
+
+// GetBlame returns the intended configuration of a datastore, where every leaf is annotated with the owner
+// and priority of its value and whether the value on the device differs, e.g. {"datastore": "dev1"}.
+func (s *Server) GetBlame(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	name := req.GetFields()["datastore"].GetStringValue()
+	s.md.RLock()
+	ds, ok := s.datastores[name]
+	s.md.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	b, err := ds.BlameConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]any{"datastore": name}
+	if b != nil {
+		result["blame"] = blameInfo(b)
+	}
+	return structpb.NewStruct(result)
+}
+
+// GetIntentSchema returns the JSON Schema of the values of the intent updates at a path of a datastore,
+// e.g. {"datastore": "dev1", "path": "/interface"}. The path defaults to the root.
+func (s *Server) GetIntentSchema(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	fields := req.GetFields()
+	p, err := utils.ParsePath(fields["path"].GetStringValue())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid path: %v", err)
+	}
+	js, err := s.IntentJSONSchema(ctx, fields["datastore"].GetStringValue(), p)
+	if err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(js)
+}
+
+// SetIntentsTransactional applies the intents in a two-phase commit across their datastores,
+// e.g. {"intents": [{"name": "dev1", "intent": "i1", "priority": 10, "update": [...]}, ...]}.
+// The intents are SetIntentRequests in their protojson encoding.
+func (s *Server) SetIntentsTransactional(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	intents := req.GetFields()["intents"].GetListValue().GetValues()
+	reqs := make([]*sdcpb.SetIntentRequest, 0, len(intents))
+	for i, v := range intents {
+		b, err := protojson.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		r := &sdcpb.SetIntentRequest{}
+		if err = protojson.Unmarshal(b, r); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid intent %d: %v", i, err)
+		}
+		reqs = append(reqs, r)
+	}
+	rsp, err := s.SetIntentsInTransaction(ctx, reqs)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]any, 0, len(rsp.Results))
+	for i, r := range rsp.Results {
+		res := map[string]any{
+			"datastore": r.Datastore,
+			"intent":    reqs[i].GetIntent(),
+			"reverted":  r.Reverted,
+		}
+		if r.Err != nil {
+			res["error"] = r.Err.Error()
+		}
+		warnings := make([]any, 0, len(r.Response.GetWarnings()))
+		for _, w := range r.Response.GetWarnings() {
+			warnings = append(warnings, w)
+		}
+		res["warnings"] = warnings
+		results = append(results, res)
+	}
+	return structpb.NewStruct(map[string]any{
+		"failed":  rsp.Failed(),
+		"results": results,
+	})
+}
+
+// PauseSync pauses the sync of a datastore until it is resumed, e.g. {"datastore": "dev1", "reason": "migration"}.
+func (s *Server) PauseSync(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	fields := req.GetFields()
+	name := fields["datastore"].GetStringValue()
+	if err := s.PauseDatastoreSync(ctx, name, fields["reason"].GetStringValue()); err != nil {
+		return nil, err
+	}
+	return s.syncPauseResult(name)
+}
+
+// ResumeSync resumes the paused sync of a datastore, e.g. {"datastore": "dev1"}.
+func (s *Server) ResumeSync(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	name := req.GetFields()["datastore"].GetStringValue()
+	if err := s.ResumeDatastoreSync(ctx, name); err != nil {
+		return nil, err
+	}
+	return s.syncPauseResult(name)
+}
+
+// GetTreeSnapshot returns the tree of the last intents set on a datastore, e.g. {"datastore": "dev1"}.
+// The snapshot is read while further intents are set, the tree is omitted if no intent was set yet.
+func (s *Server) GetTreeSnapshot(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	name := req.GetFields()["datastore"].GetStringValue()
+	s.md.RLock()
+	ds, ok := s.datastores[name]
+	s.md.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	result := map[string]any{"datastore": name}
+	if snap := ds.TreeSnapshot(); snap != nil {
+		owners := make([]any, 0, len(snap.Owners()))
+		for _, o := range snap.Owners() {
+			owners = append(owners, o)
+		}
+		result["owners"] = owners
+		result["taken"] = snap.Taken().Format(time.RFC3339Nano)
+		result["tree"] = snap.String()
+	}
+	return structpb.NewStruct(result)
+}
+
+// syncPauseResult returns the sync state and the sync pause of the datastore.
+func (s *Server) syncPauseResult(name string) (*structpb.Struct, error) {
+	s.md.RLock()
+	defer s.md.RUnlock()
+	result := map[string]any{"datastore": name}
+	if ds, ok := s.datastores[name]; ok {
+		result["sync-state"] = ds.SyncState()
+		if p := ds.SyncPause(); p != nil {
+			result["sync-pause"] = syncPauseInfo(p)
+		}
+	}
+	return structpb.NewStruct(result)
+}
+
+func blameInfo(b *tree.BlameTreeElement) map[string]any {
+	result := map[string]any{"name": b.Name}
+	if b.Value != nil {
+		result["owner"] = b.Owner
+		result["priority"] = b.Priority
+		result["value"] = utils.TypedValueToString(b.Value)
+		result["running-differs"] = b.RunningDiffers
+		if b.RunningValue != nil {
+			result["running-value"] = utils.TypedValueToString(b.RunningValue)
+		}
+	}
+	if len(b.Childs) > 0 {
+		childs := make([]any, 0, len(b.Childs))
+		for _, c := range b.Childs {
+			childs = append(childs, blameInfo(c))
+		}
+		result["childs"] = childs
+	}
+	return result
+}
+
+func maintenanceInfo(m *datastore.Maintenance) map[string]any {
+	return map[string]any{
+		"reason":        m.Reason,
+		"since":         m.Since.Format(time.RFC3339Nano),
+		"queue-intents": m.QueueIntents,
+		"pause-sync":    m.PauseSync,
+	}
+}
+
+func syncPauseInfo(p *datastore.SyncPause) map[string]any {
+	return map[string]any{
+		"reason": p.Reason,
+		"since":  p.Since.Format(time.RFC3339Nano),
+	}
+}
+
+func logLevels() (*structpb.Struct, error) {
+	levels := dslog.Levels()
+	result := make(map[string]any, len(levels))
+	for m, l := range levels {
+		result[m] = l
+	}
+	return structpb.NewStruct(result)
+}
+
+// goroutinesByLabel counts the goroutines per value of the given pprof label.
+func goroutinesByLabel(key string) (map[string]int, error) {
+	buf := new(bytes.Buffer)
+	// debug level 1 prints the stacks along with their count and labels
+	err := pprof.Lookup("goroutine").WriteTo(buf, 1)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]int{}
+	count := 0
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if n, _, ok := strings.Cut(line, " @ "); ok {
+			count, _ = strconv.Atoi(n)
+			continue
+		}
+		labels, ok := strings.CutPrefix(line, "# labels: ")
+		if !ok {
+			continue
+		}
+		m := map[string]string{}
+		if err := json.Unmarshal([]byte(labels), &m); err != nil {
+			continue
+		}
+		if v, ok := m[key]; ok {
+			result[v] += count
+		}
+	}
+	return result, scanner.Err()
+}
@@ -0,0 +1,208 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/openconfig/gnmi/proto/gnmi"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/datastore"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// the gNMI specification version implemented by the gNMI service
+const gnmiVersion = "0.10.0"
+
+// gnmiServer is the compatibility layer for gNMI-only tooling. Get requests read the datastores,
+// Set requests are merged into the intent configured for gNMI.
+type gnmiServer struct {
+	gnmi.UnimplementedGNMIServer
+	s *Server
+}
+
+func (g *gnmiServer) Capabilities(ctx context.Context, req *gnmi.CapabilityRequest) (*gnmi.CapabilityResponse, error) {
+	g.s.md.RLock()
+	defer g.s.md.RUnlock()
+	models := make([]*gnmi.ModelData, 0, len(g.s.datastores))
+	seen := map[string]struct{}{}
+	for _, ds := range g.s.datastores {
+		sc := ds.Config().Schema
+		k := sc.Vendor + "/" + sc.Name + "/" + sc.Version
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		models = append(models, &gnmi.ModelData{
+			Name:         sc.Name,
+			Organization: sc.Vendor,
+			Version:      sc.Version,
+		})
+	}
+	return &gnmi.CapabilityResponse{
+		SupportedModels:    models,
+		SupportedEncodings: []gnmi.Encoding{gnmi.Encoding_JSON, gnmi.Encoding_JSON_IETF, gnmi.Encoding_ASCII, gnmi.Encoding_PROTO},
+		GNMIVersion:        gnmiVersion,
+	}, nil
+}
+
+func (g *gnmiServer) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+	pr, _ := peer.FromContext(ctx)
+	log.Debugf("received gNMI Get request %v from peer %s", req, pr.Addr.String())
+
+	name := req.GetPrefix().GetTarget()
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing target, the name of the datastore")
+	}
+	if len(req.GetPath()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "missing path attribute")
+	}
+	gdr := &sdcpb.GetDataRequest{
+		Name:      name,
+		Path:      make([]*sdcpb.Path, 0, len(req.GetPath())),
+		Datastore: &sdcpb.DataStore{Type: sdcpb.Type_MAIN},
+	}
+	for _, p := range req.GetPath() {
+		gdr.Path = append(gdr.Path, utils.FromGNMIPath(req.GetPrefix(), p))
+	}
+	switch req.GetType() {
+	case gnmi.GetRequest_ALL:
+		gdr.DataType = sdcpb.DataType_ALL
+	case gnmi.GetRequest_CONFIG:
+		gdr.DataType = sdcpb.DataType_CONFIG
+	case gnmi.GetRequest_STATE, gnmi.GetRequest_OPERATIONAL:
+		gdr.DataType = sdcpb.DataType_STATE
+	}
+	switch req.GetEncoding() {
+	case gnmi.Encoding_JSON:
+		gdr.Encoding = sdcpb.Encoding_JSON
+	case gnmi.Encoding_JSON_IETF:
+		gdr.Encoding = sdcpb.Encoding_JSON_IETF
+	case gnmi.Encoding_ASCII:
+		gdr.Encoding = sdcpb.Encoding_STRING
+	case gnmi.Encoding_PROTO:
+		gdr.Encoding = sdcpb.Encoding_PROTO
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "unsupported encoding %v", req.GetEncoding())
+	}
+
+	g.s.md.RLock()
+	defer g.s.md.RUnlock()
+	ds, ok := g.s.datastores[name]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+
+	nCh := make(chan *sdcpb.GetDataResponse)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ds.Get(ctx, gdr, nCh)
+	}()
+	rsp := &gnmi.GetResponse{}
+	for gr := range nCh {
+		for _, n := range gr.GetNotification() {
+			rsp.Notification = append(rsp.Notification, toGNMINotification(name, n))
+		}
+	}
+	if err := <-errCh; err != nil {
+		return nil, err
+	}
+	return rsp, nil
+}
+
+func (g *gnmiServer) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+	pr, _ := peer.FromContext(ctx)
+	log.Debugf("received gNMI Set request from peer %s", pr.Addr.String())
+
+	name := req.GetPrefix().GetTarget()
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing target, the name of the datastore")
+	}
+	if len(req.GetUnionReplace()) > 0 {
+		return nil, status.Error(codes.Unimplemented, "union_replace is not supported")
+	}
+	cfg := g.s.config.GRPCServer.GNMI
+	mreq := &datastore.MergeIntentRequest{
+		Intent:   cfg.Intent,
+		Priority: cfg.Priority,
+		Deletes:  make([]*sdcpb.Path, 0, len(req.GetDelete())),
+		Replaces: make([]*sdcpb.Update, 0, len(req.GetReplace())),
+		Updates:  make([]*sdcpb.Update, 0, len(req.GetUpdate())),
+	}
+	results := make([]*gnmi.UpdateResult, 0, len(req.GetDelete())+len(req.GetReplace())+len(req.GetUpdate()))
+	for _, p := range req.GetDelete() {
+		mreq.Deletes = append(mreq.Deletes, utils.FromGNMIPath(req.GetPrefix(), p))
+		results = append(results, &gnmi.UpdateResult{Path: p, Op: gnmi.UpdateResult_DELETE})
+	}
+	for _, upd := range req.GetReplace() {
+		mreq.Replaces = append(mreq.Replaces, &sdcpb.Update{
+			Path:  utils.FromGNMIPath(req.GetPrefix(), upd.GetPath()),
+			Value: utils.FromGNMITypedValue(upd.GetVal()),
+		})
+		results = append(results, &gnmi.UpdateResult{Path: upd.GetPath(), Op: gnmi.UpdateResult_REPLACE})
+	}
+	for _, upd := range req.GetUpdate() {
+		mreq.Updates = append(mreq.Updates, &sdcpb.Update{
+			Path:  utils.FromGNMIPath(req.GetPrefix(), upd.GetPath()),
+			Value: utils.FromGNMITypedValue(upd.GetVal()),
+		})
+		results = append(results, &gnmi.UpdateResult{Path: upd.GetPath(), Op: gnmi.UpdateResult_UPDATE})
+	}
+	if len(results) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "missing delete, replace or update")
+	}
+
+	g.s.md.RLock()
+	defer g.s.md.RUnlock()
+	ds, ok := g.s.datastores[name]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	if err := checkPriorityBand(ctx, ds.Config(), cfg.Priority); err != nil {
+		return nil, err
+	}
+	if _, err := ds.MergeIntent(ctx, mreq); err != nil {
+		return nil, err
+	}
+	return &gnmi.SetResponse{
+		Prefix:    req.GetPrefix(),
+		Response:  results,
+		Timestamp: time.Now().UnixNano(),
+	}, nil
+}
+
+func toGNMINotification(target string, n *sdcpb.Notification) *gnmi.Notification {
+	gn := &gnmi.Notification{
+		Timestamp: n.GetTimestamp(),
+		Prefix:    &gnmi.Path{Target: target},
+		Update:    make([]*gnmi.Update, 0, len(n.GetUpdate())),
+		Delete:    make([]*gnmi.Path, 0, len(n.GetDelete())),
+	}
+	for _, upd := range n.GetUpdate() {
+		gn.Update = append(gn.Update, &gnmi.Update{
+			Path: utils.ToGNMIPath(upd.GetPath()),
+			Val:  utils.ToGNMITypedValue(upd.GetValue()),
+		})
+	}
+	for _, p := range n.GetDelete() {
+		gn.Delete = append(gn.Delete, utils.ToGNMIPath(p))
+	}
+	return gn
+}
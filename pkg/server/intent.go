@@ -16,14 +16,31 @@ package server
 
 import (
 	"context"
+	"errors"
+	"path"
 
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/datastore"
 )
 
+// SetIntentNoOpHeader is the gRPC response header set to "true" if a SetIntent re-applied the stored intent
+// unchanged, without drift on the device, and was therefore answered without any further processing.
+const SetIntentNoOpHeader = "sdcio-set-intent-no-op"
+
+// SetIntentIdempotencyKeyHeader is the gRPC request header carrying the idempotency key of a SetIntent.
+// Retries of a request with the same key return the outcome of the original request instead of applying it again.
+const SetIntentIdempotencyKeyHeader = "sdcio-idempotency-key"
+
+// RevealSecretsHeader is the gRPC request header requesting the sensitive values of a GetIntent in clear text,
+// if set to "true". It is honored for the privileged roles of the datastore only.
+const RevealSecretsHeader = "sdcio-reveal-secrets"
+
 func (s *Server) GetIntent(ctx context.Context, req *sdcpb.GetIntentRequest) (*sdcpb.GetIntentResponse, error) {
 	pr, _ := peer.FromContext(ctx)
 	log.Debugf("received GetIntent request %v from peer %s", req, pr.Addr.String())
@@ -43,24 +60,62 @@ func (s *Server) GetIntent(ctx context.Context, req *sdcpb.GetIntentRequest) (*s
 	if !ok {
 		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", req.GetName())
 	}
-	return ds.GetIntent(ctx, req)
+	opts := &datastore.GetIntentOpts{}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get(RevealSecretsHeader); len(vals) > 0 && vals[0] == "true" {
+			if !ds.Config().Secrets.IsPrivileged(clientRole(ctx)) {
+				return nil, status.Error(codes.PermissionDenied, "revealing secrets requires a privileged role")
+			}
+			opts.RevealSecrets = true
+		}
+	}
+	return ds.GetIntentWithOpts(ctx, req, opts)
 }
 
 func (s *Server) SetIntent(ctx context.Context, req *sdcpb.SetIntentRequest) (*sdcpb.SetIntentResponse, error) {
 	pr, _ := peer.FromContext(ctx)
-	log.Debugf("received SetIntent request %v from peer %s", req, pr.Addr.String())
 
-	if req.GetName() == "" {
-		return nil, status.Error(codes.InvalidArgument, "missing datastore name")
+	if err := validateSetIntentRequest(req); err != nil {
+		return nil, err
 	}
-	if req.GetIntent() == "" {
-		return nil, status.Error(codes.InvalidArgument, "missing intent name")
+	s.md.RLock()
+	defer s.md.RUnlock()
+	ds, ok := s.datastores[req.GetName()]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", req.GetName())
 	}
-	if len(req.GetUpdate()) == 0 && !req.GetDelete() {
-		return nil, status.Error(codes.InvalidArgument, "updates or a delete flag must be set")
+	log.Debugf("received SetIntent request %v from peer %s", ds.RedactSetIntentRequest(req), pr.Addr.String())
+	if err := checkPriorityBand(ctx, ds.Config(), req.GetPriority()); err != nil {
+		return nil, err
 	}
-	if len(req.GetUpdate()) != 0 && req.GetDelete() {
-		return nil, status.Error(codes.InvalidArgument, "both updates and the delete flag cannot be set at the same time")
+	opts := &datastore.SetIntentOpts{Role: clientRole(ctx)}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if keys := md.Get(SetIntentIdempotencyKeyHeader); len(keys) > 0 && keys[0] != "" {
+			opts.IdempotencyKey = keys[0]
+		}
+	}
+	result, err := ds.SetIntentWithOpts(ctx, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	if result.NoOp {
+		// the SetIntentResponse cannot carry the no-op flag, hence it is signalled via the response header
+		_ = grpc.SetHeader(ctx, metadata.Pairs(SetIntentNoOpHeader, "true"))
+	}
+	return result.Response, nil
+}
+
+// SetIntentWithOpts is SetIntent with the options that are not part of the sdcpb.SetIntentRequest,
+// e.g. requesting the device generated diff of a dry-run.
+func (s *Server) SetIntentWithOpts(ctx context.Context, req *sdcpb.SetIntentRequest, opts *datastore.SetIntentOpts) (*datastore.SetIntentResult, error) {
+	if err := validateSetIntentRequest(req); err != nil {
+		return nil, err
+	}
+	if opts != nil && opts.DeviceDiff && !req.GetDryRun() {
+		return nil, status.Error(codes.InvalidArgument, "a device diff requires a dry-run")
+	}
+	if opts != nil && opts.Replace && req.GetDelete() {
+		return nil, status.Error(codes.InvalidArgument, "replace cannot be set along with the delete flag")
 	}
 	s.md.RLock()
 	defer s.md.RUnlock()
@@ -68,7 +123,27 @@ func (s *Server) SetIntent(ctx context.Context, req *sdcpb.SetIntentRequest) (*s
 	if !ok {
 		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", req.GetName())
 	}
-	return ds.SetIntent(ctx, req)
+	log.Debugf("received SetIntent request %v with options %+v", ds.RedactSetIntentRequest(req), opts)
+	if err := checkPriorityBand(ctx, ds.Config(), req.GetPriority()); err != nil {
+		return nil, err
+	}
+	return ds.SetIntentWithOpts(ctx, req, opts)
+}
+
+func validateSetIntentRequest(req *sdcpb.SetIntentRequest) error {
+	if req.GetName() == "" {
+		return status.Error(codes.InvalidArgument, "missing datastore name")
+	}
+	if req.GetIntent() == "" {
+		return status.Error(codes.InvalidArgument, "missing intent name")
+	}
+	if len(req.GetUpdate()) == 0 && !req.GetDelete() {
+		return status.Error(codes.InvalidArgument, "updates or a delete flag must be set")
+	}
+	if len(req.GetUpdate()) != 0 && req.GetDelete() {
+		return status.Error(codes.InvalidArgument, "both updates and the delete flag cannot be set at the same time")
+	}
+	return nil
 }
 
 func (s *Server) ListIntent(ctx context.Context, req *sdcpb.ListIntentRequest) (*sdcpb.ListIntentResponse, error) {
@@ -86,3 +161,78 @@ func (s *Server) ListIntent(ctx context.Context, req *sdcpb.ListIntentRequest) (
 	}
 	return ds.ListIntent(ctx, req)
 }
+
+// DebugIntent returns the tree of the intent with its choice resolutions, the precedence decisions
+// of its leafs and the validation results. The sdcpb API does not yet define a debug RPC,
+// hence it is exposed on the Server only.
+func (s *Server) DebugIntent(ctx context.Context, name string, intent string, priority int32) (*datastore.IntentDebugInfo, error) {
+	log.Debugf("received DebugIntent request for datastore %s intent %s priority %d", name, intent, priority)
+
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing datastore name")
+	}
+	if intent == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing intent name")
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+	ds, ok := s.datastores[name]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	rsp, err := ds.DebugIntent(ctx, intent, priority)
+	if err != nil {
+		if errors.Is(err, datastore.ErrIntentNotFound) {
+			return nil, status.Errorf(codes.NotFound, "intent %s with priority %d not found", intent, priority)
+		}
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return rsp, nil
+}
+
+// DeleteIntents deletes the intents of the datastore whose names match the glob pattern, all intents
+// if the pattern is empty, in a single pass towards the device. The sdcpb API does not define a bulk
+// delete RPC, hence it is exposed on the Server only.
+func (s *Server) DeleteIntents(ctx context.Context, name string, pattern string, onlyIntended bool, dryRun bool) (*datastore.DeleteIntentsResult, error) {
+	log.Debugf("received DeleteIntents request for datastore %s pattern %q", name, pattern)
+
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing datastore name")
+	}
+	req := &datastore.DeleteIntentsRequest{
+		OnlyIntended: onlyIntended,
+		DryRun:       dryRun,
+	}
+	if pattern != "" {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid intent pattern %q: %v", pattern, err)
+		}
+		req.Filter = func(in *sdcpb.Intent) bool {
+			ok, _ := path.Match(pattern, in.GetIntent())
+			return ok
+		}
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+	ds, ok := s.datastores[name]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+	}
+	// intents with priorities reserved for a different role than the one of the client are not deleted
+	role := clientRole(ctx)
+	match := req.Filter
+	req.Filter = func(in *sdcpb.Intent) bool {
+		if b := ds.Config().PriorityBand(in.GetPriority()); b != nil && b.Role != role {
+			return false
+		}
+		return match == nil || match(in)
+	}
+	rsp, err := ds.DeleteIntents(ctx, req)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return rsp, nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sdcio/data-server/pkg/datastore"
+)
+
+// FanOutSetIntentRequest applies one intent to multiple datastores.
+type FanOutSetIntentRequest struct {
+	// Datastores the intent is applied to
+	Datastores []string
+	// Intent is the intent applied to every datastore, its datastore name is ignored
+	Intent *sdcpb.SetIntentRequest
+	// Parallelism is the number of datastores the intent is applied to concurrently (a wave).
+	// 0 applies the intent to all the datastores at once.
+	Parallelism int
+	// AllOrNothing validates the intent against all the datastores before applying it to any of them.
+	// If applying fails on a datastore, the datastores the intent was already applied to are reverted.
+	AllOrNothing bool
+}
+
+// FanOutSetIntentResult is the result of the intent on a single datastore.
+type FanOutSetIntentResult struct {
+	Datastore string
+	Response  *sdcpb.SetIntentResponse
+	// Err is the validation or apply error of the datastore
+	Err error
+	// Reverted indicates that the intent was applied but reverted, due to a failure on another datastore
+	Reverted bool
+}
+
+// FanOutSetIntentResponse contains the results per datastore, in the order of the requested datastores.
+type FanOutSetIntentResponse struct {
+	Results []*FanOutSetIntentResult
+}
+
+// Failed returns true if the intent failed on any of the datastores.
+func (r *FanOutSetIntentResponse) Failed() bool {
+	for _, res := range r.Results {
+		if res.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// SetIntentFanOut applies the intent to all the given datastores, reporting the result per datastore.
+// The sdcpb API does not define a multi-target SetIntent, hence it is exposed on the Server only.
+func (s *Server) SetIntentFanOut(ctx context.Context, req *FanOutSetIntentRequest) (*FanOutSetIntentResponse, error) {
+	log.Debugf("received SetIntentFanOut request for datastores %v intent %s", req.Datastores, req.Intent.GetIntent())
+
+	if len(req.Datastores) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "missing datastores")
+	}
+	if req.Parallelism < 0 {
+		return nil, status.Error(codes.InvalidArgument, "parallelism must not be negative")
+	}
+
+	s.md.RLock()
+	defer s.md.RUnlock()
+	dss := make([]*datastore.Datastore, 0, len(req.Datastores))
+	seen := map[string]struct{}{}
+	for _, name := range req.Datastores {
+		if _, ok := seen[name]; ok {
+			return nil, status.Errorf(codes.InvalidArgument, "datastore %s listed multiple times", name)
+		}
+		seen[name] = struct{}{}
+		ds, ok := s.datastores[name]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", name)
+		}
+		if err := validateSetIntentRequest(fanOutIntent(req.Intent, name)); err != nil {
+			return nil, err
+		}
+		dss = append(dss, ds)
+	}
+
+	reqs := make([]*sdcpb.SetIntentRequest, 0, len(dss))
+	for _, ds := range dss {
+		reqs = append(reqs, fanOutIntent(req.Intent, ds.Name()))
+	}
+
+	if !req.AllOrNothing {
+		rsp := newFanOutSetIntentResponse(dss)
+		fanOut(dss, req.Parallelism, func(i int, ds *datastore.Datastore) {
+			rsp.Results[i].Response, rsp.Results[i].Err = ds.SetIntent(ctx, reqs[i])
+		})
+		return rsp, nil
+	}
+	return applyIntentsAtomically(ctx, dss, reqs, req.Parallelism)
+}
+
+func newFanOutSetIntentResponse(dss []*datastore.Datastore) *FanOutSetIntentResponse {
+	rsp := &FanOutSetIntentResponse{Results: make([]*FanOutSetIntentResult, len(dss))}
+	for i, ds := range dss {
+		rsp.Results[i] = &FanOutSetIntentResult{Datastore: ds.Name()}
+	}
+	return rsp
+}
+
+// applyIntentsAtomically applies reqs[i] to dss[i], either to all of the datastores or to none of them.
+// The intents are validated via dry-runs first, if applying fails on any datastore,
+// the datastores the intents were already applied to are reverted.
+// Every datastore must only be listed once, since a datastore processes a single SetIntent at a time.
+func applyIntentsAtomically(ctx context.Context, dss []*datastore.Datastore, reqs []*sdcpb.SetIntentRequest, parallelism int) (*FanOutSetIntentResponse, error) {
+	rsp := newFanOutSetIntentResponse(dss)
+
+	// validate the intents against all datastores, via dry-runs
+	fanOut(dss, parallelism, func(i int, ds *datastore.Datastore) {
+		dryRun := proto.Clone(reqs[i]).(*sdcpb.SetIntentRequest)
+		dryRun.DryRun = true
+		rsp.Results[i].Response, rsp.Results[i].Err = ds.SetIntent(ctx, dryRun)
+	})
+	if rsp.Failed() {
+		return rsp, nil
+	}
+
+	// capture the previous state of the intents, to be able to revert them
+	previous := make([]*sdcpb.Intent, len(dss))
+	for i, ds := range dss {
+		if reqs[i].GetDryRun() {
+			continue
+		}
+		prev, err := ds.GetIntent(ctx, &sdcpb.GetIntentRequest{Intent: reqs[i].GetIntent(), Priority: reqs[i].GetPriority()})
+		if err != nil && !errors.Is(err, datastore.ErrIntentNotFound) {
+			return nil, status.Errorf(codes.Internal, "failed reading intent %s of datastore %s: %v", reqs[i].GetIntent(), ds.Name(), err)
+		}
+		previous[i] = prev.GetIntent()
+	}
+
+	fanOut(dss, parallelism, func(i int, ds *datastore.Datastore) {
+		if reqs[i].GetDryRun() {
+			return
+		}
+		rsp.Results[i].Response, rsp.Results[i].Err = ds.SetIntent(ctx, reqs[i])
+	})
+	if !rsp.Failed() {
+		return rsp, nil
+	}
+
+	// revert the datastores the intents got applied to
+	fanOut(dss, parallelism, func(i int, ds *datastore.Datastore) {
+		if rsp.Results[i].Err != nil || reqs[i].GetDryRun() {
+			return
+		}
+		err := revertIntent(ctx, ds, reqs[i], previous[i])
+		if err != nil {
+			rsp.Results[i].Err = fmt.Errorf("failed reverting intent: %w", err)
+			return
+		}
+		rsp.Results[i].Reverted = true
+	})
+	return rsp, nil
+}
+
+// fanOut calls f for every datastore, running up to parallelism calls concurrently.
+// A parallelism of 0 runs all the calls concurrently.
+func fanOut(dss []*datastore.Datastore, parallelism int, f func(i int, ds *datastore.Datastore)) {
+	if parallelism <= 0 {
+		parallelism = len(dss)
+	}
+	for start := 0; start < len(dss); start += parallelism {
+		end := min(start+parallelism, len(dss))
+		wg := &sync.WaitGroup{}
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				f(i, dss[i])
+			}(i)
+		}
+		wg.Wait()
+	}
+}
+
+// fanOutIntent returns a copy of the intent, targeting the named datastore.
+func fanOutIntent(intent *sdcpb.SetIntentRequest, name string) *sdcpb.SetIntentRequest {
+	req := proto.Clone(intent).(*sdcpb.SetIntentRequest)
+	req.Name = name
+	return req
+}
+
+// revertIntent restores the previous state of the intent, deleting it if it did not exist before.
+func revertIntent(ctx context.Context, ds *datastore.Datastore, intent *sdcpb.SetIntentRequest, previous *sdcpb.Intent) error {
+	req := &sdcpb.SetIntentRequest{
+		Name:     ds.Name(),
+		Intent:   intent.GetIntent(),
+		Priority: intent.GetPriority(),
+	}
+	if previous == nil {
+		// a deleted intent that did not exist before is a noop
+		if intent.GetDelete() {
+			return nil
+		}
+		req.Delete = true
+	} else {
+		req.Priority = previous.GetPriority()
+		req.Update = previous.GetUpdate()
+	}
+	_, err := ds.SetIntent(ctx, req)
+	return err
+}
@@ -0,0 +1,280 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/datastore"
+)
+
+// ApplyIntentGroupRequest creates or updates an intent group.
+type ApplyIntentGroupRequest struct {
+	// Name of the group
+	Name string
+	// Intents of the group, at most one per datastore. The name of the request is the datastore.
+	Intents []*sdcpb.SetIntentRequest
+	// Parallelism is the number of datastores processed concurrently, 0 processes all at once.
+	Parallelism int
+}
+
+// ApplyIntentGroup applies the intents of the group to their datastores, either to all or to none of them.
+// Intents of a previous version of the group, that are no longer part of it, are deleted.
+// The sdcpb API does not define intent groups, hence they are exposed on the Server only.
+func (s *Server) ApplyIntentGroup(ctx context.Context, req *ApplyIntentGroupRequest) (*FanOutSetIntentResponse, error) {
+	log.Debugf("received ApplyIntentGroup request for group %s", req.Name)
+
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing intent group name")
+	}
+	if len(req.Intents) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "missing intents")
+	}
+
+	s.md.RLock()
+	defer s.md.RUnlock()
+
+	group := &datastore.IntentGroup{Name: req.Name}
+	dss := make([]*datastore.Datastore, 0, len(req.Intents))
+	for _, in := range req.Intents {
+		if err := validateSetIntentRequest(in); err != nil {
+			return nil, err
+		}
+		if in.GetDelete() {
+			return nil, status.Errorf(codes.InvalidArgument, "intent %s of datastore %s: group intents cannot be deletes", in.GetIntent(), in.GetName())
+		}
+		ds, ok := s.datastores[in.GetName()]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", in.GetName())
+		}
+		for _, m := range group.Members {
+			if m.Datastore == in.GetName() {
+				return nil, status.Errorf(codes.InvalidArgument, "datastore %s listed multiple times", in.GetName())
+			}
+		}
+		group.Members = append(group.Members, &datastore.IntentGroupMember{
+			Datastore: in.GetName(),
+			Intent:    in.GetIntent(),
+			Priority:  in.GetPriority(),
+		})
+		dss = append(dss, ds)
+	}
+
+	previous, err := s.intentGroup(ctx, req.Name)
+	if err != nil && !errors.Is(err, datastore.ErrIntentGroupNotFound) {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	rsp, err := applyIntentsAtomically(ctx, dss, req.Intents, req.Parallelism)
+	if err != nil || rsp.Failed() || isDryRun(req.Intents) {
+		return rsp, err
+	}
+
+	// delete the intents that are no longer part of the group
+	if previous != nil {
+		removed := make([]*datastore.IntentGroupMember, 0, len(previous.Members))
+		for _, pm := range previous.Members {
+			if !hasIntentGroupMember(group, pm) {
+				removed = append(removed, pm)
+			}
+		}
+		rmRsp, err := s.deleteIntentGroupMembers(ctx, removed, req.Parallelism, false)
+		if err != nil {
+			return nil, err
+		}
+		rsp.Results = append(rsp.Results, rmRsp.Results...)
+		if rmRsp.Failed() {
+			return rsp, nil
+		}
+	}
+
+	err = s.saveIntentGroup(ctx, group, previous)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed storing intent group %s: %v", req.Name, err)
+	}
+	return rsp, nil
+}
+
+// DeleteIntentGroup deletes all the intents of the group from their datastores, either from all or from none of them,
+// and removes the group.
+func (s *Server) DeleteIntentGroup(ctx context.Context, name string, parallelism int) (*FanOutSetIntentResponse, error) {
+	log.Debugf("received DeleteIntentGroup request for group %s", name)
+
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing intent group name")
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+
+	group, err := s.intentGroup(ctx, name)
+	if err != nil {
+		if errors.Is(err, datastore.ErrIntentGroupNotFound) {
+			return nil, status.Errorf(codes.NotFound, "intent group %s not found", name)
+		}
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	rsp, err := s.deleteIntentGroupMembers(ctx, group.Members, parallelism, true)
+	if err != nil || rsp.Failed() {
+		return rsp, err
+	}
+
+	err = s.saveIntentGroup(ctx, nil, group)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed removing intent group %s: %v", name, err)
+	}
+	return rsp, nil
+}
+
+// GetIntentGroup returns the intent group with the given name.
+func (s *Server) GetIntentGroup(ctx context.Context, name string) (*datastore.IntentGroup, error) {
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing intent group name")
+	}
+	s.md.RLock()
+	defer s.md.RUnlock()
+
+	group, err := s.intentGroup(ctx, name)
+	if err != nil {
+		if errors.Is(err, datastore.ErrIntentGroupNotFound) {
+			return nil, status.Errorf(codes.NotFound, "intent group %s not found", name)
+		}
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return group, nil
+}
+
+// ListIntentGroups returns the names of all the intent groups, sorted by name.
+func (s *Server) ListIntentGroups(ctx context.Context) []string {
+	s.md.RLock()
+	defer s.md.RUnlock()
+
+	names := map[string]struct{}{}
+	for _, ds := range s.datastores {
+		for _, n := range ds.ListIntentGroups(ctx) {
+			names[n] = struct{}{}
+		}
+	}
+	result := make([]string, 0, len(names))
+	for n := range names {
+		result = append(result, n)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// intentGroup looks up the intent group in the datastores, every member datastore stores a copy of the group.
+func (s *Server) intentGroup(ctx context.Context, name string) (*datastore.IntentGroup, error) {
+	for _, ds := range s.datastores {
+		g, err := ds.GetIntentGroup(ctx, name)
+		if errors.Is(err, datastore.ErrIntentGroupNotFound) {
+			continue
+		}
+		return g, err
+	}
+	return nil, datastore.ErrIntentGroupNotFound
+}
+
+// saveIntentGroup stores the group in all its member datastores and removes it from the
+// datastores that were only members of the previous version of the group. A nil group removes the previous group.
+func (s *Server) saveIntentGroup(ctx context.Context, group *datastore.IntentGroup, previous *datastore.IntentGroup) error {
+	var errs []error
+	if group != nil {
+		for _, m := range group.Members {
+			if err := s.datastores[m.Datastore].SaveIntentGroup(ctx, group); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if previous != nil {
+		for _, m := range previous.Members {
+			if group != nil && slicesContainsDatastore(group.Members, m.Datastore) {
+				continue
+			}
+			ds, ok := s.datastores[m.Datastore]
+			if !ok {
+				continue
+			}
+			if err := ds.DeleteIntentGroup(ctx, previous.Name); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deleteIntentGroupMembers deletes the intents of the members, all or nothing if atomic is set.
+func (s *Server) deleteIntentGroupMembers(ctx context.Context, members []*datastore.IntentGroupMember, parallelism int, atomic bool) (*FanOutSetIntentResponse, error) {
+	dss := make([]*datastore.Datastore, 0, len(members))
+	reqs := make([]*sdcpb.SetIntentRequest, 0, len(members))
+	for _, m := range members {
+		ds, ok := s.datastores[m.Datastore]
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "datastore %s of intent %s does not exist", m.Datastore, m.Intent)
+		}
+		dss = append(dss, ds)
+		reqs = append(reqs, &sdcpb.SetIntentRequest{
+			Name:     m.Datastore,
+			Intent:   m.Intent,
+			Priority: m.Priority,
+			Delete:   true,
+		})
+	}
+	if atomic {
+		return applyIntentsAtomically(ctx, dss, reqs, parallelism)
+	}
+	rsp := newFanOutSetIntentResponse(dss)
+	fanOut(dss, parallelism, func(i int, ds *datastore.Datastore) {
+		rsp.Results[i].Response, rsp.Results[i].Err = ds.SetIntent(ctx, reqs[i])
+		if rsp.Results[i].Err != nil {
+			rsp.Results[i].Err = fmt.Errorf("failed deleting intent %s removed from the group: %w", reqs[i].GetIntent(), rsp.Results[i].Err)
+		}
+	})
+	return rsp, nil
+}
+
+func hasIntentGroupMember(g *datastore.IntentGroup, m *datastore.IntentGroupMember) bool {
+	for _, gm := range g.Members {
+		if *gm == *m {
+			return true
+		}
+	}
+	return false
+}
+
+func slicesContainsDatastore(members []*datastore.IntentGroupMember, name string) bool {
+	for _, m := range members {
+		if m.Datastore == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isDryRun(reqs []*sdcpb.SetIntentRequest) bool {
+	for _, r := range reqs {
+		if !r.GetDryRun() {
+			return false
+		}
+	}
+	return true
+}
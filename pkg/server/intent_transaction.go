@@ -0,0 +1,108 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/datastore"
+)
+
+// SetIntentsInTransaction applies the intents, each to the datastore it names, in a two-phase commit.
+// Every datastore first prepares the intent, validating it and staging the changes on the device where the
+// target supports it, e.g. in the netconf candidate. The changes are only committed on the devices once all
+// datastores prepared them, otherwise they are discarded everywhere. Devices supporting it commit the changes
+// via confirmed commits, that are only confirmed once all devices accepted the changes and rolled back otherwise.
+// The intents committed on the datastores without confirmed commits are reverted if committing fails on a device.
+// The sdcpb API does not define a multi-target SetIntent, hence it is exposed on the Server and the debug service only.
+func (s *Server) SetIntentsInTransaction(ctx context.Context, reqs []*sdcpb.SetIntentRequest) (*FanOutSetIntentResponse, error) {
+	if len(reqs) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "missing intents")
+	}
+
+	s.md.RLock()
+	defer s.md.RUnlock()
+	dss := make([]*datastore.Datastore, 0, len(reqs))
+	seen := map[string]struct{}{}
+	for _, req := range reqs {
+		if err := validateSetIntentRequest(req); err != nil {
+			return nil, err
+		}
+		// a datastore processes a single SetIntent at a time, hence the transaction would block itself
+		if _, ok := seen[req.GetName()]; ok {
+			return nil, status.Errorf(codes.InvalidArgument, "datastore %s listed multiple times", req.GetName())
+		}
+		seen[req.GetName()] = struct{}{}
+		ds, ok := s.datastores[req.GetName()]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", req.GetName())
+		}
+		if err := checkPriorityBand(ctx, ds.Config(), req.GetPriority()); err != nil {
+			return nil, err
+		}
+		dss = append(dss, ds)
+	}
+	log.Debugf("received SetIntentsInTransaction request for %d datastores", len(dss))
+
+	// capture the previous state of the intents, to be able to revert them if a commit fails
+	previous := make([]*sdcpb.Intent, len(dss))
+	for i, ds := range dss {
+		if reqs[i].GetDryRun() {
+			continue
+		}
+		prev, err := ds.GetIntent(ctx, &sdcpb.GetIntentRequest{Intent: reqs[i].GetIntent(), Priority: reqs[i].GetPriority()})
+		if err != nil && !errors.Is(err, datastore.ErrIntentNotFound) {
+			return nil, status.Errorf(codes.Internal, "failed reading intent %s of datastore %s: %v", reqs[i].GetIntent(), ds.Name(), err)
+		}
+		previous[i] = prev.GetIntent()
+	}
+
+	// all participants have to run concurrently, since they wait for each other to be prepared
+	rsp := newFanOutSetIntentResponse(dss)
+	tx := datastore.NewTransaction(len(dss))
+	role := clientRole(ctx)
+	fanOut(dss, 0, func(i int, ds *datastore.Datastore) {
+		participant := tx.Join()
+		result, err := ds.SetIntentWithOpts(ctx, reqs[i], &datastore.SetIntentOpts{Role: role, Transaction: participant})
+		participant.Done(err)
+		if result != nil {
+			rsp.Results[i].Response = result.Response
+		}
+		rsp.Results[i].Err = err
+	})
+	if !rsp.Failed() || !tx.Committed() {
+		return rsp, nil
+	}
+
+	// committing failed on some of the devices, revert the datastores the intents got committed to
+	fanOut(dss, 0, func(i int, ds *datastore.Datastore) {
+		if rsp.Results[i].Err != nil || reqs[i].GetDryRun() {
+			return
+		}
+		err := revertIntent(ctx, ds, reqs[i], previous[i])
+		if err != nil {
+			rsp.Results[i].Err = fmt.Errorf("failed reverting intent: %w", err)
+			return
+		}
+		rsp.Results[i].Reverted = true
+	})
+	return rsp, nil
+}
@@ -0,0 +1,451 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/beevik/etree"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"golang.org/x/crypto/ssh"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/config"
+	"github.com/sdcio/data-server/pkg/datastore"
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+const (
+	netconfBaseNS  = "urn:ietf:params:xml:ns:netconf:base:1.0"
+	netconfBase10  = "urn:ietf:params:netconf:base:1.0"
+	netconfBase11  = "urn:ietf:params:netconf:base:1.1"
+	netconfXPath   = "urn:ietf:params:netconf:capability:xpath:1.0"
+	netconfEOM     = "]]>]]>"
+	maxChunkLength = 4294967295
+)
+
+// netconfServer is the NETCONF facade of the datastores, served via the SSH netconf subsystem.
+type netconfServer struct {
+	s         *Server
+	cfg       *config.NetconfServer
+	sshConfig *ssh.ServerConfig
+	sessionID atomic.Uint32
+}
+
+func newNetconfServer(s *Server, cfg *config.NetconfServer) (*netconfServer, error) {
+	n := &netconfServer{
+		s:   s,
+		cfg: cfg,
+	}
+	n.sshConfig = &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			user, _ := splitNetconfUser(conn.User())
+			if subtle.ConstantTimeCompare([]byte(user), []byte(cfg.Credentials.Username)) == 1 &&
+				subtle.ConstantTimeCompare(password, []byte(cfg.Credentials.Password)) == 1 {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("invalid credentials of user %s", conn.User())
+		},
+	}
+	var signer ssh.Signer
+	if cfg.HostKey != "" {
+		b, err := os.ReadFile(cfg.HostKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read netconf-server host key: %w", err)
+		}
+		signer, err = ssh.ParsePrivateKey(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse netconf-server host key: %w", err)
+		}
+	} else {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		signer, err = ssh.NewSignerFromKey(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	n.sshConfig.AddHostKey(signer)
+	return n, nil
+}
+
+// splitNetconfUser splits the SSH user <username>@<datastore> into the username and the datastore name.
+func splitNetconfUser(u string) (string, string) {
+	idx := strings.LastIndex(u, "@")
+	if idx < 0 {
+		return u, ""
+	}
+	return u[:idx], u[idx+1:]
+}
+
+func (n *netconfServer) serve(ctx context.Context) error {
+	l, err := net.Listen("tcp", n.cfg.Address)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+	log.Infof("starting netconf server on %s", n.cfg.Address)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go n.handleConn(ctx, conn)
+	}
+}
+
+func (n *netconfServer) handleConn(ctx context.Context, conn net.Conn) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, n.sshConfig)
+	if err != nil {
+		log.Debugf("netconf server: ssh handshake with %s failed: %v", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+	defer sconn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			_ = nc.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		ch, chReqs, err := nc.Accept()
+		if err != nil {
+			log.Errorf("netconf server: failed to accept channel of %s: %v", conn.RemoteAddr(), err)
+			continue
+		}
+		go func() {
+			for req := range chReqs {
+				// the payload of the subsystem request is the length prefixed subsystem name
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "netconf"
+				_ = req.Reply(ok, nil)
+				if ok {
+					go func() {
+						defer ch.Close()
+						n.handleSession(ctx, sconn.User(), ch)
+					}()
+				}
+			}
+		}()
+	}
+}
+
+// netconfSession is a NETCONF session of a client with a datastore.
+type netconfSession struct {
+	n  *netconfServer
+	id uint32
+	ds string
+	f  *netconfFramer
+}
+
+func (n *netconfServer) handleSession(ctx context.Context, user string, rw io.ReadWriter) {
+	s := &netconfSession{
+		n:  n,
+		id: n.sessionID.Add(1),
+		f:  &netconfFramer{r: bufio.NewReader(rw), w: rw},
+	}
+	_, s.ds = splitNetconfUser(user)
+
+	if err := s.f.writeMsg(s.hello()); err != nil {
+		return
+	}
+	b, err := s.f.readMsg()
+	if err != nil {
+		return
+	}
+	doc := etree.NewDocument()
+	if err = doc.ReadFromBytes(b); err != nil || doc.Root() == nil || doc.Root().Tag != "hello" {
+		log.Debugf("netconf session %d: invalid hello: %s", s.id, string(b))
+		return
+	}
+	for _, c := range doc.Root().FindElements("./capabilities/capability") {
+		if strings.TrimSpace(c.Text()) == netconfBase11 {
+			s.f.chunked = true
+		}
+	}
+
+	for ctx.Err() == nil {
+		b, err = s.f.readMsg()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Debugf("netconf session %d: %v", s.id, err)
+			}
+			return
+		}
+		rsp, closeSession := s.handleRPC(ctx, b)
+		if err = s.f.writeMsg(rsp); err != nil || closeSession {
+			return
+		}
+	}
+}
+
+func (s *netconfSession) hello() []byte {
+	doc := etree.NewDocument()
+	hello := doc.CreateElement("hello")
+	hello.CreateAttr("xmlns", netconfBaseNS)
+	caps := hello.CreateElement("capabilities")
+	for _, c := range []string{netconfBase10, netconfBase11, netconfXPath} {
+		caps.CreateElement("capability").SetText(c)
+	}
+	hello.CreateElement("session-id").SetText(strconv.FormatUint(uint64(s.id), 10))
+	b, _ := doc.WriteToBytes()
+	return b
+}
+
+// handleRPC returns the rpc-reply of the rpc and whether the session is to be closed.
+func (s *netconfSession) handleRPC(ctx context.Context, b []byte) ([]byte, bool) {
+	doc := etree.NewDocument()
+	reply := etree.NewDocument()
+	rr := reply.CreateElement("rpc-reply")
+	rr.CreateAttr("xmlns", netconfBaseNS)
+
+	var closeSession bool
+	err := doc.ReadFromBytes(b)
+	switch {
+	case err != nil:
+		err = newNetconfError("protocol", "malformed-message", err.Error())
+	case doc.Root() == nil || doc.Root().Tag != "rpc" || len(doc.Root().ChildElements()) != 1:
+		err = newNetconfError("rpc", "malformed-message", "expected an rpc with a single operation")
+	default:
+		// the attributes of the rpc, e.g. the message-id, are returned in the rpc-reply
+		for _, a := range doc.Root().Attr {
+			if a.Space == "" && a.Key == "xmlns" {
+				continue
+			}
+			rr.CreateAttr(a.FullKey(), a.Value)
+		}
+		op := doc.Root().ChildElements()[0]
+		switch op.Tag {
+		case "get-config", "get":
+			err = s.getConfig(ctx, op, rr)
+		case "edit-config":
+			err = s.editConfig(ctx, op)
+		case "lock", "unlock":
+			// the intents are applied one at a time by the datastore
+		case "close-session":
+			closeSession = true
+		default:
+			err = newNetconfError("protocol", "operation-not-supported", fmt.Sprintf("operation %s is not supported", op.Tag))
+		}
+	}
+	if err != nil {
+		rr.Child = nil
+		rr.AddChild(netconfRPCError(err))
+	} else if len(rr.ChildElements()) == 0 {
+		rr.CreateElement("ok")
+	}
+	rb, _ := reply.WriteToBytes()
+	return rb, closeSession
+}
+
+func (s *netconfSession) datastore() (*datastore.Datastore, error) {
+	s.n.s.md.RLock()
+	defer s.n.s.md.RUnlock()
+	if s.ds == "" {
+		if len(s.n.s.datastores) != 1 {
+			return nil, newNetconfError("application", "invalid-value", "missing datastore, the SSH user is expected as <username>@<datastore>")
+		}
+		for _, ds := range s.n.s.datastores {
+			return ds, nil
+		}
+	}
+	ds, ok := s.n.s.datastores[s.ds]
+	if !ok {
+		return nil, newNetconfError("application", "invalid-value", fmt.Sprintf("unknown datastore %s", s.ds))
+	}
+	return ds, nil
+}
+
+func (s *netconfSession) getConfig(ctx context.Context, op *etree.Element, rr *etree.Element) error {
+	if op.Tag == "get-config" {
+		if src := op.SelectElement("source"); src == nil || src.SelectElement("running") == nil {
+			return newNetconfError("protocol", "invalid-value", "only the running datastore is supported as source")
+		}
+	}
+	ds, err := s.datastore()
+	if err != nil {
+		return err
+	}
+	var paths []*sdcpb.Path
+	if filter := op.SelectElement("filter"); filter != nil {
+		switch filter.SelectAttrValue("type", "subtree") {
+		case "subtree":
+			paths, err = ds.SubtreeFilterPaths(ctx, filter)
+			if err != nil {
+				return err
+			}
+		case "xpath":
+			p, err := utils.ParsePath(filter.SelectAttrValue("select", ""))
+			if err != nil {
+				return newNetconfError("protocol", "invalid-value", err.Error())
+			}
+			paths = append(paths, p)
+		default:
+			return newNetconfError("protocol", "bad-attribute", "unknown filter type")
+		}
+	}
+	doc, err := ds.GetConfigXML(ctx, paths)
+	if err != nil {
+		return err
+	}
+	data := rr.CreateElement("data")
+	for _, e := range doc.ChildElements() {
+		data.AddChild(e)
+	}
+	return nil
+}
+
+func (s *netconfSession) editConfig(ctx context.Context, op *etree.Element) error {
+	if t := op.SelectElement("target"); t == nil || t.SelectElement("running") == nil {
+		return newNetconfError("protocol", "invalid-value", "only the running datastore is supported as target")
+	}
+	cfg := op.SelectElement("config")
+	if cfg == nil {
+		return newNetconfError("protocol", "missing-element", "missing config")
+	}
+	switch do := op.SelectElement("default-operation"); {
+	case do == nil, do.Text() == "merge":
+	case do.Text() == "replace":
+		// the config replaces the whole configuration set via edit-config
+		cfg = cfg.Copy()
+		for _, e := range cfg.ChildElements() {
+			e.CreateAttr("operation", "replace")
+		}
+	default:
+		return newNetconfError("protocol", "operation-not-supported", fmt.Sprintf("default-operation %s is not supported", do.Text()))
+	}
+	ds, err := s.datastore()
+	if err != nil {
+		return err
+	}
+	if err = checkPriorityBand(ctx, ds.Config(), s.n.cfg.Priority); err != nil {
+		return err
+	}
+	_, err = ds.EditConfigXML(ctx, s.n.cfg.Intent, s.n.cfg.Priority, cfg)
+	return err
+}
+
+// netconfError is a NETCONF rpc-error.
+type netconfError struct {
+	errType string
+	tag     string
+	msg     string
+}
+
+func newNetconfError(errType, tag, msg string) *netconfError {
+	return &netconfError{errType: errType, tag: tag, msg: msg}
+}
+
+func (e *netconfError) Error() string {
+	return e.msg
+}
+
+func netconfRPCError(err error) *etree.Element {
+	ne := &netconfError{}
+	if !errors.As(err, &ne) {
+		ne = newNetconfError("application", "operation-failed", err.Error())
+		if st, ok := status.FromError(err); ok {
+			ne.msg = st.Message()
+		}
+	}
+	e := etree.NewElement("rpc-error")
+	e.CreateElement("error-type").SetText(ne.errType)
+	e.CreateElement("error-tag").SetText(ne.tag)
+	e.CreateElement("error-severity").SetText("error")
+	e.CreateElement("error-message").SetText(ne.msg)
+	return e
+}
+
+// netconfFramer reads and writes the NETCONF messages, with the end-of-message framing of NETCONF 1.0
+// or the chunked framing of NETCONF 1.1.
+type netconfFramer struct {
+	r       *bufio.Reader
+	w       io.Writer
+	chunked bool
+}
+
+func (f *netconfFramer) readMsg() ([]byte, error) {
+	if !f.chunked {
+		var msg []byte
+		for {
+			b, err := f.r.ReadBytes('>')
+			msg = append(msg, b...)
+			if bytes.HasSuffix(msg, []byte(netconfEOM)) {
+				return bytes.TrimSpace(msg[:len(msg)-len(netconfEOM)]), nil
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	var msg []byte
+	for {
+		hdr := make([]byte, 2)
+		if _, err := io.ReadFull(f.r, hdr); err != nil {
+			return nil, err
+		}
+		if string(hdr) != "\n#" {
+			return nil, fmt.Errorf("malformed chunk header %q", hdr)
+		}
+		line, err := f.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "#" {
+			return msg, nil
+		}
+		size, err := strconv.ParseUint(line, 10, 32)
+		if err != nil || size == 0 || size > maxChunkLength {
+			return nil, fmt.Errorf("malformed chunk size %q", line)
+		}
+		chunk := make([]byte, size)
+		if _, err = io.ReadFull(f.r, chunk); err != nil {
+			return nil, err
+		}
+		msg = append(msg, chunk...)
+	}
+}
+
+func (f *netconfFramer) writeMsg(b []byte) error {
+	var err error
+	if f.chunked {
+		_, err = fmt.Fprintf(f.w, "\n#%d\n%s\n##\n", len(b), b)
+	} else {
+		_, err = fmt.Fprintf(f.w, "%s%s", b, netconfEOM)
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/config"
+)
+
+// clientRole returns the role of the client, which is the first organizational unit
+// of its verified TLS client certificate. It is empty for unauthenticated clients.
+func clientRole(ctx context.Context) string {
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	tlsInfo, ok := pr.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	ous := tlsInfo.State.VerifiedChains[0][0].Subject.OrganizationalUnit
+	if len(ous) == 0 {
+		return ""
+	}
+	return ous[0]
+}
+
+// checkPriorityBand rejects priorities that are reserved for a different role than the one of the client.
+func checkPriorityBand(ctx context.Context, cfg *config.DatastoreConfig, priority int32) error {
+	b := cfg.PriorityBand(priority)
+	if b == nil {
+		return nil
+	}
+	if role := clientRole(ctx); role != b.Role {
+		return status.Errorf(codes.PermissionDenied, "priority %d is reserved for role %s", priority, b.Role)
+	}
+	return nil
+}
@@ -26,10 +26,12 @@ import (
 	schemaMemoryStore "github.com/sdcio/schema-server/pkg/store/memstore"
 	schemaPersistentStore "github.com/sdcio/schema-server/pkg/store/persiststore"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/sdcio/data-server/pkg/schema"
 )
@@ -64,6 +66,10 @@ func (s *Server) createLocalSchemaStore(ctx context.Context) {
 	numSchemas := len(s.config.SchemaStore.Schemas)
 	log.Infof("parsing %d schema(s)...", numSchemas)
 
+	// the parsed schemas report the YANG status of their nodes
+	var parsedMu sync.Mutex
+	parsed := make([]*schemaServerSchema.Schema, 0, numSchemas)
+
 	wg := new(sync.WaitGroup)
 	wg.Add(numSchemas)
 	for _, sCfg := range s.config.SchemaStore.Schemas {
@@ -84,10 +90,13 @@ func (s *Server) createLocalSchemaStore(ctx context.Context) {
 				return
 			}
 			store.AddSchema(sc)
+			parsedMu.Lock()
+			parsed = append(parsed, sc)
+			parsedMu.Unlock()
 		}(sCfg, store)
 	}
 	wg.Wait()
-	s.schemaClient = schema.NewLocalClient(store)
+	s.schemaClient = schema.NewLocalClient(store, parsed...)
 }
 
 func (s *Server) createRemoteSchemaClient(ctx context.Context) {
@@ -125,8 +134,41 @@ SCHEMA_CONNECT:
 	s.schemaClient = schema.NewRemoteClient(cc, s.config.SchemaServer.Cache)
 }
 
+// SchemaDatastoreHeader is the gRPC request header of the schema server RPCs selecting the schema of a datastore.
+// It applies to the requests that do not specify a schema, such that clients can browse the schema of a datastore
+// without knowing its name, vendor and version.
+const SchemaDatastoreHeader = "sdcio-datastore"
+
+// requestSchema returns the schema of a request, the schema of the datastore selected by the SchemaDatastoreHeader
+// if the request does not specify one.
+func (s *Server) requestSchema(ctx context.Context, sc *sdcpb.Schema) (*sdcpb.Schema, error) {
+	if sc.GetName() != "" || sc.GetVendor() != "" || sc.GetVersion() != "" {
+		return sc, nil
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return sc, nil
+	}
+	names := md.Get(SchemaDatastoreHeader)
+	if len(names) == 0 || names[0] == "" {
+		return sc, nil
+	}
+	s.md.RLock()
+	ds, ok := s.datastores[names[0]]
+	s.md.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown datastore %s", names[0])
+	}
+	return ds.Config().Schema.GetSchema(), nil
+}
+
 func (s *Server) GetSchema(ctx context.Context, req *sdcpb.GetSchemaRequest) (*sdcpb.GetSchemaResponse, error) {
 	log.Debugf("received GetSchemaRequest: %v", req)
+	sc, err := s.requestSchema(ctx, req.GetSchema())
+	if err != nil {
+		return nil, err
+	}
+	req.Schema = sc
 	return s.schemaClient.GetSchema(ctx, req)
 }
 
@@ -137,6 +179,11 @@ func (s *Server) ListSchema(ctx context.Context, req *sdcpb.ListSchemaRequest) (
 
 func (s *Server) GetSchemaDetails(ctx context.Context, req *sdcpb.GetSchemaDetailsRequest) (*sdcpb.GetSchemaDetailsResponse, error) {
 	log.Debugf("received GetSchemaDetails: %v", req)
+	sc, err := s.requestSchema(ctx, req.GetSchema())
+	if err != nil {
+		return nil, err
+	}
+	req.Schema = sc
 	return s.schemaClient.GetSchemaDetails(ctx, req)
 }
 
@@ -157,11 +204,21 @@ func (s *Server) DeleteSchema(ctx context.Context, req *sdcpb.DeleteSchemaReques
 
 func (s *Server) ToPath(ctx context.Context, req *sdcpb.ToPathRequest) (*sdcpb.ToPathResponse, error) {
 	log.Debugf("received ToPath: %v", req)
+	sc, err := s.requestSchema(ctx, req.GetSchema())
+	if err != nil {
+		return nil, err
+	}
+	req.Schema = sc
 	return s.schemaClient.ToPath(ctx, req)
 }
 
 func (s *Server) ExpandPath(ctx context.Context, req *sdcpb.ExpandPathRequest) (*sdcpb.ExpandPathResponse, error) {
 	log.Debugf("received ExpandPath: %v", req)
+	sc, err := s.requestSchema(ctx, req.GetSchema())
+	if err != nil {
+		return nil, err
+	}
+	req.Schema = sc
 	return s.schemaClient.ExpandPath(ctx, req)
 }
 
@@ -186,6 +243,11 @@ func (s *Server) UploadSchema(stream sdcpb.SchemaServer_UploadSchemaServer) erro
 
 func (s *Server) GetSchemaElements(req *sdcpb.GetSchemaRequest, stream sdcpb.SchemaServer_GetSchemaElementsServer) error {
 	ctx := stream.Context()
+	sc, err := s.requestSchema(ctx, req.GetSchema())
+	if err != nil {
+		return err
+	}
+	req.Schema = sc
 	ch, err := s.schemaClient.GetSchemaElements(ctx, req)
 	if err != nil {
 		return err
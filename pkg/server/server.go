@@ -16,29 +16,33 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
+	"runtime/debug"
 	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	_ "google.golang.org/grpc/encoding/gzip" // Install the gzip compressor
+	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
 
 	"github.com/sdcio/data-server/pkg/cache"
 	"github.com/sdcio/data-server/pkg/config"
 	"github.com/sdcio/data-server/pkg/datastore"
 	"github.com/sdcio/data-server/pkg/schema"
+	"github.com/sdcio/data-server/pkg/utils"
 )
 
 const (
@@ -88,12 +92,18 @@ func New(ctx context.Context, c *config.Config) (*Server, error) {
 	// gRPC server options
 	opts := []grpc.ServerOption{
 		grpc.MaxRecvMsgSize(c.GRPCServer.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(c.GRPCServer.MaxSendMsgSize),
 	}
 	// unary interceptors
 	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		recoveryInterceptor,
 		s.readyInterceptor,
 		s.timeoutInterceptor,
 	}
+	// stream interceptors
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		recoveryStreamInterceptor,
+	}
 
 	if c.Prometheus != nil {
 		// add gRPC client interceptors for gNMI
@@ -106,21 +116,38 @@ func New(ctx context.Context, c *config.Config) (*Server, error) {
 
 		// add gRPC server interceptors for the Schema/Data server
 		grpcMetrics := grpc_prometheus.NewServerMetrics()
-		opts = append(opts,
-			grpc.StreamInterceptor(grpcMetrics.StreamServerInterceptor()),
-		)
-
+		streamInterceptors = append(streamInterceptors, grpcMetrics.StreamServerInterceptor())
 		unaryInterceptors = append(unaryInterceptors, grpcMetrics.UnaryServerInterceptor())
 		s.reg.MustRegister(grpcMetrics)
+
+		// datastore metrics
+		s.reg.MustRegister(datastore.Collectors()...)
+		// cache metrics
+		s.reg.MustRegister(cache.Collectors()...)
 	}
 
-	opts = append(opts, grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(unaryInterceptors...)))
+	opts = append(opts,
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(unaryInterceptors...)),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(streamInterceptors...)),
+	)
+
+	if c.Normalization != nil {
+		err := utils.SetDisabledNormalizations(c.Normalization.Disable)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if c.GRPCServer.TLS != nil {
 		tlsCfg, err := c.GRPCServer.TLS.NewConfig(ctx)
 		if err != nil {
 			return nil, err
 		}
+		// verify the client certificates if presented, they carry the role of the client
+		if tlsCfg.RootCAs != nil {
+			tlsCfg.ClientCAs = tlsCfg.RootCAs
+			tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+		}
 		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
 	}
 
@@ -134,6 +161,23 @@ func New(ctx context.Context, c *config.Config) (*Server, error) {
 		sdcpb.RegisterSchemaServerServer(s.srv, s)
 	}
 
+	// register the gNMI compatibility layer
+	if s.config.GRPCServer.GNMI != nil && s.config.GRPCServer.GNMI.Enabled {
+		gnmi.RegisterGNMIServer(s.srv, &gnmiServer{s: s})
+	}
+
+	// register the debug service
+	if s.config.GRPCServer.Debug {
+		err := registerDebugServer(s.srv, s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.config.GRPCServer.Reflection {
+		reflection.Register(s.srv)
+	}
+
 	return s, nil
 }
 
@@ -149,6 +193,18 @@ func (s *Server) Serve(ctx context.Context) error {
 
 	go s.startDataServer(ctx)
 
+	if s.config.NetconfServer != nil {
+		ns, err := newNetconfServer(s, s.config.NetconfServer)
+		if err != nil {
+			return err
+		}
+		go func() {
+			if err := ns.serve(s.ctx); err != nil {
+				log.Errorf("netconf server stopped: %v", err)
+			}
+		}()
+	}
+
 	log.Infof("starting server on %s", s.config.GRPCServer.Address)
 	err = s.srv.Serve(l)
 	if err != nil {
@@ -199,6 +255,10 @@ func (s *Server) startDataServer(ctx context.Context) {
 	}()
 
 	wg.Wait()
+	// the snippet library is shared by all datastores
+	if err := datastore.CreateSnippetCache(ctx, s.cacheClient); err != nil {
+		log.Errorf("failed to create the snippet library: %v", err)
+	}
 	// init datastores
 	s.createInitialDatastores(ctx)
 	s.ready = true
@@ -233,6 +293,31 @@ func (s *Server) timeoutInterceptor(ctx context.Context, req interface{}, info *
 	return handler(ctx, req)
 }
 
+// recoveryInterceptor converts panics of the RPC handlers into Internal errors, logging the stack.
+func recoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredError(info.FullMethod, r)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// recoveryStreamInterceptor converts panics of the streaming RPC handlers into Internal errors, logging the stack.
+func recoveryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = recoveredError(info.FullMethod, r)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func recoveredError(method string, r any) error {
+	log.Errorf("panic in %s: %v\n%s", method, r, debug.Stack())
+	return status.Errorf(codes.Internal, "internal error in %s: %v", method, r)
+}
+
 func (s *Server) readyInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
 	if !s.ready {
 		return nil, status.Error(codes.Unavailable, "not ready")
@@ -0,0 +1,80 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/sdcio/data-server/pkg/datastore"
+)
+
+// SaveSnippet stores the value as the new version of the snippet of the data-server's snippet library.
+// The sdcpb API does not define snippets, hence they are exposed on the Server only.
+func (s *Server) SaveSnippet(ctx context.Context, name string, value []byte) (*datastore.Snippet, error) {
+	log.Debugf("received SaveSnippet request for snippet %s", name)
+
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing snippet name")
+	}
+	sn, err := datastore.SaveSnippet(ctx, s.cacheClient, name, value)
+	if err != nil {
+		if errors.Is(err, datastore.ErrSnippetNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return sn, nil
+}
+
+// GetSnippet returns the version of the snippet, the latest version if the version is 0.
+func (s *Server) GetSnippet(ctx context.Context, name string, version uint64) (*datastore.Snippet, error) {
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing snippet name")
+	}
+	sn, err := datastore.GetSnippet(ctx, s.cacheClient, name, version)
+	if err != nil {
+		if errors.Is(err, datastore.ErrSnippetNotFound) {
+			return nil, status.Errorf(codes.NotFound, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	return sn, nil
+}
+
+// ListSnippets returns the versions of all snippets of the library, keyed by name.
+func (s *Server) ListSnippets(ctx context.Context) map[string][]uint64 {
+	return datastore.ListSnippets(ctx, s.cacheClient)
+}
+
+// DeleteSnippet removes all versions of the snippet from the library.
+func (s *Server) DeleteSnippet(ctx context.Context, name string) error {
+	log.Debugf("received DeleteSnippet request for snippet %s", name)
+
+	if name == "" {
+		return status.Error(codes.InvalidArgument, "missing snippet name")
+	}
+	err := datastore.DeleteSnippet(ctx, s.cacheClient, name)
+	if err != nil {
+		if errors.Is(err, datastore.ErrSnippetNotFound) {
+			return status.Errorf(codes.NotFound, "%v", err)
+		}
+		return status.Errorf(codes.Internal, "%v", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package tree
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/beevik/etree"
+	"github.com/sdcio/data-server/pkg/utils"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+// isAnyData returns true if the schema of the entry is an anydata or anyxml node.
+// The content of such entries is opaque, it is stored as a single value and neither expanded nor validated.
+func (s *sharedEntryAttributes) isAnyData() bool {
+	return utils.IsAnyData(s.schema)
+}
+
+// anyDataValue returns the highest precedence value of an anydata entry.
+// nil is returned if the entry is to be deleted or, in case of onlyNewOrUpdated, was not changed.
+func (s *sharedEntryAttributes) anyDataValue(onlyNewOrUpdated bool) (*sdcpb.TypedValue, error) {
+	if s.leafVariants.shouldDelete() {
+		return nil, nil
+	}
+	le := s.leafVariants.GetHighestPrecedence(onlyNewOrUpdated, false)
+	if le == nil {
+		return nil, nil
+	}
+	return le.Value()
+}
+
+// anyDataToJson returns the opaque anydata payload as a value that can be marshalled as JSON.
+// JSON payloads are embedded as they are, XML payloads are returned as a string.
+func anyDataToJson(tv *sdcpb.TypedValue) (any, error) {
+	var b []byte
+	switch tv.GetValue().(type) {
+	case *sdcpb.TypedValue_JsonVal:
+		b = tv.GetJsonVal()
+	case *sdcpb.TypedValue_JsonIetfVal:
+		b = tv.GetJsonIetfVal()
+	default:
+		return utils.TypedValueToString(tv), nil
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// anyDataToXml adds the opaque anydata payload to the given element.
+// XML payloads are inserted verbatim, JSON payloads are rendered as elements named after their members.
+func anyDataToXml(elem *etree.Element, tv *sdcpb.TypedValue) error {
+	switch tv.GetValue().(type) {
+	case *sdcpb.TypedValue_JsonVal, *sdcpb.TypedValue_JsonIetfVal:
+		v, err := anyDataToJson(tv)
+		if err != nil {
+			return err
+		}
+		jsonToXmlElements(elem, v)
+		return nil
+	}
+	payload := strings.TrimSpace(utils.TypedValueToString(tv))
+	if !strings.HasPrefix(payload, "<") {
+		elem.SetText(payload)
+		return nil
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString("<anydata>" + payload + "</anydata>"); err != nil {
+		return fmt.Errorf("invalid anyxml payload: %w", err)
+	}
+	for _, c := range doc.Root().Child {
+		elem.AddChild(c)
+	}
+	return nil
+}
+
+// jsonToXmlElements renders the decoded JSON value v as the content of elem.
+func jsonToXmlElements(elem *etree.Element, v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			// strip the JSON_IETF module prefix
+			name := k
+			if _, n, found := strings.Cut(k, ":"); found {
+				name = n
+			}
+			switch mv := v[k].(type) {
+			case []any:
+				for _, e := range mv {
+					jsonToXmlElements(elem.CreateElement(name), e)
+				}
+			default:
+				jsonToXmlElements(elem.CreateElement(name), mv)
+			}
+		}
+	case nil:
+	default:
+		elem.SetText(fmt.Sprintf("%v", v))
+	}
+}
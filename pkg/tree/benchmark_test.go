@@ -0,0 +1,217 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	schemaClient "github.com/sdcio/data-server/pkg/datastore/clients/schema"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// benchmarkSizes are the number of leafs of the generated intents.
+var benchmarkSizes = []int{10_000, 100_000, 1_000_000}
+
+// benchmarkSubinterfaces is the number of subinterfaces per interface of the generated intents.
+const benchmarkSubinterfaces = 32
+
+// benchmarkIntent generates an intent of interfaces and subinterfaces with the given number of leafs.
+func benchmarkIntent(b *testing.B, leafs int, owner string, prio int32) []*cache.Update {
+	b.Helper()
+	subifType, err := proto.Marshal(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_IdentityrefVal{IdentityrefVal: &sdcpb.IdentityRef{Prefix: "sdcio_model_common", Value: "routed"}}})
+	if err != nil {
+		b.Fatal(err)
+	}
+	enable := testhelper.GetStringTvProto(b, "enable")
+
+	upds := make([]*cache.Update, 0, leafs)
+	for i := 0; len(upds) < leafs; i++ {
+		name := fmt.Sprintf("ethernet-%d/%d", i/128+1, i%128+1)
+		upds = append(upds,
+			cache.NewUpdate([]string{"interface", name, "name"}, testhelper.GetStringTvProto(b, name), prio, owner, 0),
+			cache.NewUpdate([]string{"interface", name, "admin-state"}, enable, prio, owner, 0),
+			cache.NewUpdate([]string{"interface", name, "description"}, testhelper.GetStringTvProto(b, "interface "+name), prio, owner, 0),
+		)
+		for j := 0; j < benchmarkSubinterfaces && len(upds) < leafs; j++ {
+			idx := strconv.Itoa(j)
+			upds = append(upds,
+				cache.NewUpdate([]string{"interface", name, "subinterface", idx, "index"}, testhelper.GetUIntTvProto(b, uint64(j)), prio, owner, 0),
+				cache.NewUpdate([]string{"interface", name, "subinterface", idx, "type"}, subifType, prio, owner, 0),
+				cache.NewUpdate([]string{"interface", name, "subinterface", idx, "description"}, testhelper.GetStringTvProto(b, "subinterface "+idx), prio, owner, 0),
+			)
+		}
+	}
+	return upds[:leafs]
+}
+
+// benchmarkSchemaClient returns the schema client shared by the benchmarks. It is created
+// upfront, such that the schema parsing neither affects the measurement nor the output.
+func benchmarkSchemaClient(b *testing.B) schemaClient.SchemaClientBound {
+	b.Helper()
+	scb, err := testhelper.GetSchemaClientBound(b)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return scb
+}
+
+// benchmarkTree returns a tree populated with the given updates.
+func benchmarkTree(b *testing.B, scb schemaClient.SchemaClientBound, upds []*cache.Update, owner string, new bool, finish bool) *RootEntry {
+	b.Helper()
+	ctx := context.TODO()
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, u := range upds {
+		if _, err := root.AddCacheUpdateRecursive(ctx, u, new); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if finish {
+		root.FinishInsertionPhase()
+	}
+	return root
+}
+
+// BenchmarkTree_Populate measures adding the updates of an intent to the tree, as done by populateTree.
+func BenchmarkTree_Populate(b *testing.B) {
+	scb := benchmarkSchemaClient(b)
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			upds := benchmarkIntent(b, size, "owner1", 5)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				benchmarkTree(b, scb, upds, "owner1", true, false)
+			}
+		})
+	}
+}
+
+// BenchmarkTree_FinishInsertionPhase measures the choice/case calculation after the insertion.
+func BenchmarkTree_FinishInsertionPhase(b *testing.B) {
+	scb := benchmarkSchemaClient(b)
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			upds := benchmarkIntent(b, size, "owner1", 5)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				root := benchmarkTree(b, scb, upds, "owner1", true, false)
+				b.StartTimer()
+				root.FinishInsertionPhase()
+			}
+		})
+	}
+}
+
+// BenchmarkTree_GetHighestPrecedence measures the calculation of the updates sent to the device.
+func BenchmarkTree_GetHighestPrecedence(b *testing.B) {
+	scb := benchmarkSchemaClient(b)
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			root := benchmarkTree(b, scb, benchmarkIntent(b, size, "owner1", 5), "owner1", true, true)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if upds := root.GetHighestPrecedence(true); len(upds) != size {
+					b.Fatalf("expected %d updates, got %d", size, len(upds))
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkTree_GetDeletes measures the calculation of the deletes sent to the device, when an intent is removed.
+func BenchmarkTree_GetDeletes(b *testing.B) {
+	scb := benchmarkSchemaClient(b)
+	for _, size := range benchmarkSizes {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			root := benchmarkTree(b, scb, benchmarkIntent(b, size, "owner1", 5), "owner1", false, false)
+			root.markOwnerDelete("owner1")
+			root.FinishInsertionPhase()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := root.GetDeletes(DeleteAggregationInstance); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkTree_Incremental measures applying a changed intent of 99 leafs to a tree holding a running config of
+// the given size, once by building a new tree as populateTree does and once by reusing the tree of the previous
+// version of the intent, taking over the running changes and settling the tree for the next intent.
+func BenchmarkTree_Incremental(b *testing.B) {
+	scb := benchmarkSchemaClient(b)
+	ctx := context.TODO()
+	for _, size := range benchmarkSizes {
+		running := benchmarkIntent(b, size, RunningIntentName, RunningValuesPrio)
+		stored := benchmarkIntent(b, 99, "owner1", 5)
+		// the intent toggles its descriptions
+		changed := make([]*cache.Update, 0, len(stored))
+		for _, u := range stored {
+			if u.GetPath()[len(u.GetPath())-1] == "description" {
+				u = cache.NewUpdate(u.GetPath(), testhelper.GetStringTvProto(b, "changed"), u.Priority(), u.Owner(), 0)
+			}
+			changed = append(changed, u)
+		}
+		versions := [][]*cache.Update{stored, changed}
+		addIntent := func(root *RootEntry, stored, changed []*cache.Update) {
+			for _, u := range stored {
+				if _, err := root.AddCacheUpdateRecursive(ctx, u, false); err != nil {
+					b.Fatal(err)
+				}
+			}
+			root.markOwnerDelete("owner1")
+			for _, u := range changed {
+				if _, err := root.AddCacheUpdateRecursive(ctx, u, true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+
+		b.Run(fmt.Sprintf("rebuild/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				root := benchmarkTree(b, scb, nil, "owner1", false, false)
+				addIntent(root, stored, changed)
+				for _, u := range running {
+					if _, err := root.AddCacheUpdateRecursive(ctx, u, false); err != nil {
+						b.Fatal(err)
+					}
+				}
+				root.FinishInsertionPhase()
+			}
+		})
+
+		b.Run(fmt.Sprintf("incremental/%d", size), func(b *testing.B) {
+			// the tree of the previous version of the intent, which was written back to running
+			root := benchmarkTree(b, scb, stored, "owner1", true, false)
+			for _, u := range running {
+				if _, err := root.AddCacheUpdateRecursive(ctx, u, false); err != nil {
+					b.Fatal(err)
+				}
+			}
+			root.FinishInsertionPhase()
+			root.Settle()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				prev, next := versions[i%2], versions[(i+1)%2]
+				if err := root.Reuse(NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), "owner1")); err != nil {
+					b.Fatal(err)
+				}
+				if err := root.ApplyRunningChanges(ctx, nil, prev); err != nil {
+					b.Fatal(err)
+				}
+				addIntent(root, prev, next)
+				root.FinishInsertionPhase()
+				root.Settle()
+			}
+		})
+	}
+}
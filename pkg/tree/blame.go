@@ -0,0 +1,82 @@
+package tree
+
+import (
+	"slices"
+
+	"github.com/sdcio/data-server/pkg/utils"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+// BlameTreeElement is an element of the blame tree. The blame tree holds the intended
+// configuration, where every leaf is annotated with the intent that provides its value.
+type BlameTreeElement struct {
+	Name string `json:"name"`
+	// Owner is the intent providing the highest precedence value, set on leafs only
+	Owner string `json:"owner,omitempty"`
+	// Priority of the highest precedence value
+	Priority int32 `json:"priority,omitempty"`
+	// Timestamp of the last modification of the highest precedence value
+	Timestamp int64 `json:"timestamp,omitempty"`
+	// Value is the highest precedence value
+	Value *sdcpb.TypedValue `json:"value,omitempty"`
+	// RunningDiffers indicates that the value on the device (running) deviates from the intended value
+	RunningDiffers bool `json:"running-differs,omitempty"`
+	// RunningValue is the deviating running value, nil if the value is missing on the device
+	RunningValue *sdcpb.TypedValue   `json:"running-value,omitempty"`
+	Childs       []*BlameTreeElement `json:"childs,omitempty"`
+}
+
+// BlameConfig returns the blame tree of the intended configuration below this entry.
+// Values that are only present in running are not part of the result.
+// nil is returned if the branch carries no intended value.
+func (s *sharedEntryAttributes) BlameConfig() (*BlameTreeElement, error) {
+	result := &BlameTreeElement{
+		Name: s.pathElemName,
+	}
+
+	// process the childs in a stable order
+	childNames := s.childs.GetKeys()
+	slices.Sort(childNames)
+	for _, childName := range childNames {
+		child, _ := s.childs.GetEntry(childName)
+		childBlame, err := child.BlameConfig()
+		if err != nil {
+			return nil, err
+		}
+		if childBlame != nil {
+			result.Childs = append(result.Childs, childBlame)
+		}
+	}
+
+	// process the value
+	highest := s.leafVariants.GetHighestPrecedence(false, false)
+	if highest != nil && highest.Owner() != RunningIntentName {
+		val, err := highest.Value()
+		if err != nil {
+			return nil, err
+		}
+		result.Owner = highest.Owner()
+		result.Priority = highest.Priority()
+		result.Timestamp = highest.TS()
+		result.Value = val
+
+		// check if running equals the intended value
+		result.RunningDiffers = true
+		if running := s.leafVariants.GetByOwner(RunningIntentName); running != nil {
+			runVal, err := running.Value()
+			if err != nil {
+				return nil, err
+			}
+			if utils.EqualTypedValues(runVal, val) {
+				result.RunningDiffers = false
+			} else {
+				result.RunningValue = runVal
+			}
+		}
+	}
+
+	if result.Value == nil && len(result.Childs) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package tree
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+)
+
+// DebugInfo is the structured representation of the decisions taken in the tree.
+type DebugInfo struct {
+	// Tree is the string representation of the tree
+	Tree string
+	// Choices holds the resolution of all the choices in the tree
+	Choices []*ChoiceDebugInfo
+	// Leafs holds the precedence decision of all the leafs in the tree
+	Leafs []*LeafDebugInfo
+}
+
+// ChoiceDebugInfo describes the case resolution of a choice.
+type ChoiceDebugInfo struct {
+	// Path of the container the choice is defined in
+	Path   PathSlice
+	Choice string
+	// ActiveCase is the case with the highest precedence, empty if no case is populated
+	ActiveCase string
+	// PreviousCase is the case that was active before the actual changes
+	PreviousCase string
+	// Cases maps the populated cases to their highest precedence (lowest priority value)
+	Cases map[string]int32
+}
+
+// LeafDebugInfo describes the precedence decision of a leaf.
+type LeafDebugInfo struct {
+	Path PathSlice
+	// Selected is the value that takes precedence, nil if no value remains
+	Selected *LeafEntry
+	// Variants are all the values of the leaf, ordered by priority
+	Variants []*LeafEntry
+}
+
+// DebugInfo returns the choice resolutions and the leaf precedence decisions of the tree.
+func (r *RootEntry) DebugInfo() *DebugInfo {
+	result := &DebugInfo{
+		Tree:    r.String(),
+		Choices: []*ChoiceDebugInfo{},
+		Leafs:   []*LeafDebugInfo{},
+	}
+	// the visitor does not return errors
+	_ = r.Walk(func(s *sharedEntryAttributes) error {
+		for name, c := range s.choicesResolvers {
+			ci := &ChoiceDebugInfo{
+				Path:         s.Path(),
+				Choice:       name,
+				ActiveCase:   c.getBestCaseName(),
+				PreviousCase: c.getOldBestCaseName(),
+				Cases:        map[string]int32{},
+			}
+			for caseName, cas := range c.cases {
+				if prio := cas.GetLowestPriorityValue(); prio != math.MaxInt32 {
+					ci.Cases[caseName] = prio
+				}
+			}
+			result.Choices = append(result.Choices, ci)
+		}
+		if s.leafVariants.Length() == 0 {
+			return nil
+		}
+		li := &LeafDebugInfo{
+			Path:     s.Path(),
+			Selected: s.leafVariants.GetHighestPrecedence(false, true),
+			Variants: slices.Collect(s.leafVariants.Items()),
+		}
+		if s.treeContext.redaction.isSensitive(li.Path) {
+			li.Selected = s.treeContext.redaction.redactLeafEntry(li.Path, li.Selected)
+			for i, le := range li.Variants {
+				li.Variants[i] = s.treeContext.redaction.redactLeafEntry(li.Path, le)
+			}
+		}
+		slices.SortFunc(li.Variants, func(a, b *LeafEntry) int {
+			if c := cmp.Compare(a.Priority(), b.Priority()); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Owner(), b.Owner())
+		})
+		result.Leafs = append(result.Leafs, li)
+		return nil
+	})
+	slices.SortFunc(result.Choices, func(a, b *ChoiceDebugInfo) int {
+		if c := strings.Compare(a.Path.String(), b.Path.String()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Choice, b.Choice)
+	})
+	slices.SortFunc(result.Leafs, func(a, b *LeafDebugInfo) int {
+		return strings.Compare(a.Path.String(), b.Path.String())
+	})
+	return result
+}
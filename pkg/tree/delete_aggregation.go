@@ -0,0 +1,15 @@
+package tree
+
+// DeleteAggregation defines up to which level deletes are aggregated, before being sent to the device.
+type DeleteAggregation string
+
+const (
+	// DeleteAggregationLeaf deletes the individual leafs. List instances are only deleted
+	// as a whole after all their other leafs, since the keys can not be deleted on their own.
+	DeleteAggregationLeaf DeleteAggregation = "leaf"
+	// DeleteAggregationInstance aggregates deletes up to the list instance or container level.
+	DeleteAggregationInstance DeleteAggregation = "instance"
+	// DeleteAggregationAncestor aggregates deletes to the highest ancestor that is removed
+	// as a whole, which might be an entire list.
+	DeleteAggregationAncestor DeleteAggregation = "ancestor"
+)
@@ -55,15 +55,35 @@ type Entry interface {
 	// If the onlyNewOrUpdated option is set to true, only the New or Updated entries will be returned
 	// It will append to the given list and provide a new pointer to the slice
 	GetHighestPrecedence(result LeafVariantSlice, onlyNewOrUpdated bool) LeafVariantSlice
+	// getUpdatesDivergingFromRunning appends the new or updated highest precedence LeafEntries of the branch,
+	// that differ from the running value, to the given result
+	getUpdatesDivergingFromRunning(result LeafVariantSlice) LeafVariantSlice
 	// getHighestPrecedenceLeafValue returns the highest LeafValue of the Entry at hand
 	// will return an error if the Entry is not a Leaf
 	getHighestPrecedenceLeafValue(context.Context) (*LeafEntry, error)
 	// GetByOwner returns the branches Updates by owner
 	GetByOwner(owner string, result []*LeafEntry) []*LeafEntry
+	// BlameConfig returns the intended configuration of the branch, annotated with the owner and priority of every leaf
+	BlameConfig() (*BlameTreeElement, error)
 	// markOwnerDelete Sets the delete flag on all the LeafEntries belonging to the given owner.
 	markOwnerDelete(o string)
-	// GetDeletes returns the cache-updates that are not updated, have no lower priority value left and hence should be deleted completely
-	GetDeletes(entries []DeleteEntry, aggregatePaths bool) ([]DeleteEntry, error)
+	// snapshotCopy returns a copy of the branch for a snapshot of the tree, see RootEntry.Snapshot
+	snapshotCopy(parent Entry, tc *TreeContext) *sharedEntryAttributes
+	// setTreeContext moves the branch over to the given TreeContext, see RootEntry.Reuse
+	setTreeContext(tc *TreeContext)
+	// markDirty marks the entry and its ancestors as modified since the last FinishInsertionPhase
+	markDirty()
+	// setRunningValue sets the running value, creating the entries of its path if necessary
+	setRunningValue(ctx context.Context, u *cache.Update) error
+	// removeRunningValues removes the running values below the given path, "*" matching any path element.
+	// It returns true if the entry holds neither childs nor leaf variants afterwards.
+	removeRunningValues(path []string) bool
+	// settle drops the defaults and the deleted leaf variants and resets the flags of the remaining ones, see RootEntry.Settle.
+	// It returns true if the entry holds neither childs nor leaf variants afterwards.
+	settle() bool
+	// GetDeletes returns the cache-updates that are not updated, have no lower priority value left and hence should be deleted completely.
+	// The aggregation defines up to which level the deletes are aggregated.
+	GetDeletes(entries []DeleteEntry, aggregation DeleteAggregation) ([]DeleteEntry, error)
 	// Walk takes the EntryVisitor and applies it to every Entry in the tree
 	Walk(f EntryVisitor) error
 	// Validate kicks off validation
@@ -109,6 +129,9 @@ type Entry interface {
 	// The returned boolean will in indicate if the value remains existing (true) after the setintent.
 	// Or will disappear from device (running) as part of the SetIntent action.
 	remainsToExist() bool
+	// remainsInIntended indicates if the entry is part of the merged intents after the update,
+	// in contrast to remainsToExist, values that solely exist in running are not considered.
+	remainsInIntended() bool
 	getChildren() map[string]Entry
 	FilterChilds(keys map[string]string) ([]Entry, error)
 	// ToJson returns the Tree contained structure as JSON
@@ -117,9 +140,12 @@ type Entry interface {
 	// ToJsonIETF returns the Tree contained structure as JSON_IETF
 	// use e.g. json.MarshalIndent() on the returned struct
 	ToJsonIETF(onlyNewOrUpdated bool) (any, error)
+	// ToJsonWithOrigin returns the Tree contained structure as JSON or JSON_IETF,
+	// annotating every leaf with the owner and priority of its value (RFC 7952)
+	ToJsonWithOrigin(ietf bool) (any, error)
 	// toJsonInternal the internal function that produces JSON and JSON_IETF
 	// Not for external usage
-	toJsonInternal(onlyNewOrUpdated bool, ietf bool) (j any, err error)
+	toJsonInternal(onlyNewOrUpdated bool, ietf bool, annotateOrigin bool) (j any, err error)
 	ToXML(onlyNewOrUpdated bool, honorNamespace bool, operationWithNamespace bool, useOperationRemove bool) (*etree.Document, error)
 	toXmlInternal(parent *etree.Element, onlyNewOrUpdated bool, honorNamespace bool, operationWithNamespace bool, useOperationRemove bool) (doAdd bool, err error)
 	// ImportConfig allows importing config data received from e.g. the device in different formats (json, xml) to be imported into the tree.
@@ -2,6 +2,8 @@ package tree
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"slices"
 	"strings"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/utils"
 	"github.com/sdcio/data-server/pkg/utils/testhelper"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 	"google.golang.org/protobuf/proto"
@@ -621,7 +624,7 @@ func Test_Entry_Delete_Aggregation(t *testing.T) {
 	root.FinishInsertionPhase()
 
 	// retrieve the Deletes
-	deletesSlices, err := root.GetDeletes(true)
+	deletesSlices, err := root.GetDeletes(DeleteAggregationInstance)
 	if err != nil {
 		t.Error(err)
 	}
@@ -646,6 +649,471 @@ func Test_Entry_Delete_Aggregation(t *testing.T) {
 	}
 }
 
+func Test_Entry_Delete_Aggregation_Strategies(t *testing.T) {
+	desc3 := testhelper.GetStringTvProto(t, "DescriptionThree")
+	prio50 := int32(50)
+	owner1 := "OwnerOne"
+	ts1 := int64(9999999)
+
+	existing := []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, desc3, prio50, owner1, ts1),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), prio50, owner1, ts1),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "0", "index"}, testhelper.GetUIntTvProto(t, 0), prio50, owner1, ts1),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "0", "description"}, desc3, prio50, owner1, ts1),
+	}
+	remaining := []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/1", "description"}, desc3, prio50, owner1, ts1),
+		cache.NewUpdate([]string{"interface", "ethernet-0/1", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/1"), prio50, owner1, ts1),
+	}
+
+	tests := []struct {
+		name        string
+		aggregation DeleteAggregation
+		new         []*cache.Update
+		expected    []string
+	}{
+		{
+			name:        "leaf",
+			aggregation: DeleteAggregationLeaf,
+			new:         remaining,
+			expected: []string{
+				"interface/ethernet-0/0",
+				"interface/ethernet-0/0/description",
+				"interface/ethernet-0/0/subinterface/0",
+				"interface/ethernet-0/0/subinterface/0/description",
+			},
+		},
+		{
+			name:        "instance",
+			aggregation: DeleteAggregationInstance,
+			new:         remaining,
+			expected:    []string{"interface/ethernet-0/0"},
+		},
+		{
+			name:        "ancestor, list remains",
+			aggregation: DeleteAggregationAncestor,
+			new:         remaining,
+			expected:    []string{"interface/ethernet-0/0"},
+		},
+		{
+			name:        "instance, all instances removed",
+			aggregation: DeleteAggregationInstance,
+			expected:    []string{"interface/ethernet-0/0"},
+		},
+		{
+			name:        "ancestor, all instances removed",
+			aggregation: DeleteAggregationAncestor,
+			expected:    []string{"interface"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+
+			scb, err := testhelper.GetSchemaClientBound(t)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+			root, err := NewTreeRoot(ctx, tc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, u := range existing {
+				_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			root.markOwnerDelete(owner1)
+			for _, u := range tt.new {
+				_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			root.FinishInsertionPhase()
+
+			deletesSlices, err := root.GetDeletes(tt.aggregation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			deletes := make([]string, 0, len(deletesSlices))
+			for _, x := range deletesSlices {
+				deletes = append(deletes, strings.Join(x.Path(), "/"))
+			}
+			slices.Sort(deletes)
+			if diff := cmp.Diff(tt.expected, deletes); diff != "" {
+				t.Errorf("root.GetDeletes() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_RootEntry_ToProtoBranchReplaces(t *testing.T) {
+	prio50 := int32(50)
+	owner1 := "OwnerOne"
+	ts1 := int64(9999999)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), prio50, owner1, ts1),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "old"), prio50, owner1, ts1),
+		cache.NewUpdate([]string{"interface", "ethernet-0/1", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/1"), prio50, owner1, ts1),
+		cache.NewUpdate([]string{"interface", "ethernet-0/1", "description"}, testhelper.GetStringTvProto(t, "old"), prio50, owner1, ts1),
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo"), prio50, owner1, ts1),
+		// patterntest is already present on the device
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo"), RunningValuesPrio, RunningIntentName, ts1),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root.markOwnerDelete(owner1)
+
+	// change ethernet-0/0, remove ethernet-0/1 and keep patterntest unchanged
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), prio50, owner1, ts1),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "new"), prio50, owner1, ts1),
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo"), prio50, owner1, ts1),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.FinishInsertionPhase()
+
+	replaces, deletes, err := root.ToProtoBranchReplaces(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replacesStr := make([]string, 0, len(replaces))
+	for _, r := range replaces {
+		replacesStr = append(replacesStr, fmt.Sprintf("%s: %s", utils.ToXPath(r.GetPath(), false), r.GetValue().GetJsonVal()))
+	}
+	expectedReplaces := []string{`interface[name=ethernet-0/0]: {"description":"new","name":"ethernet-0/0"}`}
+	if diff := cmp.Diff(expectedReplaces, replacesStr); diff != "" {
+		t.Errorf("root.ToProtoBranchReplaces() replaces mismatch (-want +got):\n%s", diff)
+	}
+
+	deletesStr := make([]string, 0, len(deletes))
+	for _, d := range deletes {
+		deletesStr = append(deletesStr, utils.ToXPath(d, false))
+	}
+	expectedDeletes := []string{"interface[name=ethernet-0/1]"}
+	if diff := cmp.Diff(expectedDeletes, deletesStr); diff != "" {
+		t.Errorf("root.ToProtoBranchReplaces() deletes mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_Entry_PresenceContainer(t *testing.T) {
+	prio50 := int32(50)
+	owner1 := "OwnerOne"
+	ts1 := int64(9999999)
+
+	emptyVal, err := proto.Marshal(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_EmptyVal{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	niName := cache.NewUpdate([]string{"network-instance", "default", "name"}, testhelper.GetStringTvProto(t, "default"), prio50, owner1, ts1)
+	bgp := cache.NewUpdate([]string{"network-instance", "default", "protocol", "bgp"}, emptyVal, prio50, owner1, ts1)
+	bgpAs := cache.NewUpdate([]string{"network-instance", "default", "protocol", "bgp", "autonomous-system"}, testhelper.GetUIntTvProto(t, 65000), prio50, owner1, ts1)
+
+	tests := []struct {
+		name            string
+		existing        []*cache.Update
+		new             []*cache.Update
+		expectedJson    string
+		expectedXml     string
+		expectedDeletes []string
+	}{
+		{
+			name:     "presence with childs",
+			existing: nil,
+			new:      []*cache.Update{niName, bgp, bgpAs},
+			expectedJson: `{
+  "network-instance": [
+    {
+      "name": "default",
+      "protocol": {
+        "bgp": {
+          "autonomous-system": 65000
+        }
+      }
+    }
+  ]
+}`,
+			expectedXml: `<network-instance>
+  <name>default</name>
+  <protocol>
+    <bgp>
+      <autonomous-system>65000</autonomous-system>
+    </bgp>
+  </protocol>
+</network-instance>
+`,
+			expectedDeletes: []string{},
+		},
+		{
+			name:     "childs removed, presence remains",
+			existing: []*cache.Update{niName, bgp, bgpAs},
+			new:      []*cache.Update{niName, bgp},
+			expectedJson: `{
+  "network-instance": [
+    {
+      "name": "default",
+      "protocol": {
+        "bgp": {}
+      }
+    }
+  ]
+}`,
+			expectedXml: `<network-instance>
+  <name>default</name>
+  <protocol>
+    <bgp>
+      <autonomous-system operation="delete"/>
+    </bgp>
+  </protocol>
+</network-instance>
+`,
+			expectedDeletes: []string{"network-instance/default/protocol/bgp/autonomous-system"},
+		},
+		{
+			name:     "presence removed",
+			existing: []*cache.Update{niName, bgp, bgpAs},
+			new:      []*cache.Update{niName},
+			expectedJson: `{
+  "network-instance": [
+    {
+      "name": "default"
+    }
+  ]
+}`,
+			expectedXml: `<network-instance>
+  <name>default</name>
+  <protocol operation="delete"/>
+</network-instance>
+`,
+			expectedDeletes: []string{"network-instance/default/protocol"},
+		},
+		{
+			name:     "empty presence removed",
+			existing: []*cache.Update{niName, bgp},
+			new:      []*cache.Update{niName},
+			expectedJson: `{
+  "network-instance": [
+    {
+      "name": "default"
+    }
+  ]
+}`,
+			expectedXml: `<network-instance>
+  <name>default</name>
+  <protocol operation="delete"/>
+</network-instance>
+`,
+			expectedDeletes: []string{"network-instance/default/protocol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+
+			scb, err := testhelper.GetSchemaClientBound(t)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+			root, err := NewTreeRoot(ctx, tc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, u := range tt.existing {
+				_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			root.markOwnerDelete(owner1)
+			for _, u := range tt.new {
+				_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			root.FinishInsertionPhase()
+
+			j, err := root.ToJson(false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			jBytes, err := json.MarshalIndent(j, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectedJson, string(jBytes)); diff != "" {
+				t.Errorf("root.ToJson() mismatch (-want +got):\n%s", diff)
+			}
+
+			xmlDoc, err := root.ToXML(false, false, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			xmlDoc.Indent(2)
+			xmlDocStr, err := xmlDoc.WriteToString()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectedXml, xmlDocStr); diff != "" {
+				t.Errorf("root.ToXML() mismatch (-want +got):\n%s", diff)
+			}
+
+			deletesSlices, err := root.GetDeletes(DeleteAggregationInstance)
+			if err != nil {
+				t.Fatal(err)
+			}
+			deletes := make([]string, 0, len(deletesSlices))
+			for _, x := range deletesSlices {
+				deletes = append(deletes, strings.Join(x.Path(), "/"))
+			}
+			slices.Sort(deletes)
+			if diff := cmp.Diff(tt.expectedDeletes, deletes); diff != "" {
+				t.Errorf("root.GetDeletes() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_Entry_AnyData(t *testing.T) {
+	prio50 := int32(50)
+	owner1 := "OwnerOne"
+	ts1 := int64(9999999)
+	ctx := context.TODO()
+
+	jsonVal, err := proto.Marshal(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: []byte(`{"vendor":{"knob":[1,2],"mode":"fast"}}`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	xmlVal, err := proto.Marshal(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_AsciiVal{AsciiVal: `<blob xmlns="urn:vendor"><x>1</x></blob>`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"opaque", "name"}, testhelper.GetStringTvProto(t, "foo"), prio50, owner1, ts1),
+		cache.NewUpdate([]string{"opaque", "data"}, jsonVal, prio50, owner1, ts1),
+		cache.NewUpdate([]string{"opaque", "xml-data"}, xmlVal, prio50, owner1, ts1),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.FinishInsertionPhase()
+
+	validationErrors := []error{}
+	validationErrChan := make(chan error)
+	go func() {
+		root.Validate(ctx, validationErrChan, nil, false)
+		close(validationErrChan)
+	}()
+	for e := range validationErrChan {
+		validationErrors = append(validationErrors, e)
+	}
+	if len(validationErrors) > 0 {
+		t.Errorf("unexpected validation errors: %v", validationErrors)
+	}
+
+	j, err := root.ToJson(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jBytes, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedJson := `{
+  "opaque": {
+    "data": {
+      "vendor": {
+        "knob": [
+          1,
+          2
+        ],
+        "mode": "fast"
+      }
+    },
+    "name": "foo",
+    "xml-data": "\u003cblob xmlns=\"urn:vendor\"\u003e\u003cx\u003e1\u003c/x\u003e\u003c/blob\u003e"
+  }
+}`
+	if diff := cmp.Diff(expectedJson, string(jBytes)); diff != "" {
+		t.Errorf("root.ToJson() mismatch (-want +got):\n%s", diff)
+	}
+
+	xmlDoc, err := root.ToXML(false, false, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xmlDoc.Indent(2)
+	xmlDocStr, err := xmlDoc.WriteToString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedXml := `<opaque>
+  <name>foo</name>
+  <data>
+    <vendor>
+      <knob>1</knob>
+      <knob>2</knob>
+      <mode>fast</mode>
+    </vendor>
+  </data>
+  <xml-data>
+    <blob xmlns="urn:vendor">
+      <x>1</x>
+    </blob>
+  </xml-data>
+</opaque>
+`
+	if diff := cmp.Diff(expectedXml, xmlDocStr); diff != "" {
+		t.Errorf("root.ToXML() mismatch (-want +got):\n%s", diff)
+	}
+}
+
 // TestLeafVariants_GetHighesPrio
 func TestLeafVariants_GetHighesPrio(t *testing.T) {
 	owner1 := "owner1"
@@ -764,21 +1232,680 @@ func TestLeafVariants_GetHighesPrio(t *testing.T) {
 		},
 	)
 
-	// make sure the secondhighes is also populated if the highes was the first entry
-	t.Run("secondhighes populated if highes was first",
-		func(t *testing.T) {
-			lv := newLeafVariants(&TreeContext{})
-			lv.Add(NewLeafEntry(cache.NewUpdate(path, nil, 1, owner1, ts), false, nil))
-			lv.les[0].MarkDelete()
-			lv.Add(NewLeafEntry(cache.NewUpdate(path, nil, 2, owner2, ts), false, nil))
+	// make sure the secondhighes is also populated if the highes was the first entry
+	t.Run("secondhighes populated if highes was first",
+		func(t *testing.T) {
+			lv := newLeafVariants(&TreeContext{})
+			lv.Add(NewLeafEntry(cache.NewUpdate(path, nil, 1, owner1, ts), false, nil))
+			lv.les[0].MarkDelete()
+			lv.Add(NewLeafEntry(cache.NewUpdate(path, nil, 2, owner2, ts), false, nil))
+
+			le := lv.GetHighestPrecedence(true, false)
+
+			if le != lv.les[1] {
+				t.Errorf("expected to get entry %v, got %v", lv.les[1], le)
+			}
+		},
+	)
+
+	// same priority values of the actual owner and an existing intent are resolved via the conflict policy
+	for policy, winner := range map[ConflictPolicy]string{
+		ConflictPolicyFirstWins: owner1,
+		ConflictPolicyLastWins:  owner2,
+		"":                      owner1,
+	} {
+		t.Run(fmt.Sprintf("same priority conflict policy %q", policy),
+			func(t *testing.T) {
+				tc := &TreeContext{actualOwners: []string{owner2}}
+				tc.SetConflictPolicy(policy)
+				lv := newLeafVariants(tc)
+				lv.Add(NewLeafEntry(cache.NewUpdate(path, nil, 5, owner1, ts), false, nil))
+				lv.Add(NewLeafEntry(cache.NewUpdate(path, nil, 5, owner2, ts), true, nil))
+
+				le := lv.GetHighestPrecedence(false, false)
+
+				if le.Owner() != winner {
+					t.Errorf("expected owner %s to win, got %s", winner, le.Owner())
+				}
+			},
+		)
+	}
+}
+
+func TestLeafVariants_GetHighestPrecedence_TieBreak(t *testing.T) {
+	path := []string{"firstPathElem"}
+
+	tests := []struct {
+		name     string
+		tieBreak TieBreak
+		entries  []*cache.Update
+		winner   string
+	}{
+		{
+			name: "lexically lower owner wins",
+			entries: []*cache.Update{
+				cache.NewUpdate(path, nil, 5, "owner-b", 1),
+				cache.NewUpdate(path, nil, 5, "owner-a", 2),
+				cache.NewUpdate(path, nil, 5, "owner-c", 0),
+			},
+			winner: "owner-a",
+		},
+		{
+			name: "higher priority wins over lexically lower owner",
+			entries: []*cache.Update{
+				cache.NewUpdate(path, nil, 5, "owner-a", 0),
+				cache.NewUpdate(path, nil, 4, "owner-b", 0),
+			},
+			winner: "owner-b",
+		},
+		{
+			name:     "most recently modified wins",
+			tieBreak: TieBreakNewest,
+			entries: []*cache.Update{
+				cache.NewUpdate(path, nil, 5, "owner-b", 1),
+				cache.NewUpdate(path, nil, 5, "owner-a", 2),
+				cache.NewUpdate(path, nil, 5, "owner-c", 3),
+			},
+			winner: "owner-c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reversed := slices.Clone(tt.entries)
+			slices.Reverse(reversed)
+
+			// the winner must not depend on the insertion order
+			for _, entries := range [][]*cache.Update{tt.entries, reversed} {
+				lv := newLeafVariants(&TreeContext{tieBreak: tt.tieBreak})
+				for _, u := range entries {
+					lv.Add(NewLeafEntry(u, false, nil))
+				}
+
+				le := lv.GetHighestPrecedence(false, false)
+
+				if le.Owner() != tt.winner {
+					t.Errorf("expected owner %s to win, got %s", tt.winner, le.Owner())
+				}
+			}
+		})
+	}
+}
+
+func Test_RootEntry_GetPriorityConflicts(t *testing.T) {
+	owner1 := "owner1"
+	owner2 := "owner2"
+	owner3 := "owner3"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*cache.Update{
+		// owner2 sets a different description with the same priority
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Foo"), 5, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Bar"), 5, owner2, ts),
+		// owner2 sets the same name with the same priority, which is no conflict
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 5, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 5, owner2, ts),
+		// owner3 sets a different value with a different priority
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo F"), 5, owner1, ts),
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo 0"), 6, owner3, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conflicts := root.GetPriorityConflicts(owner1)
+
+	expected := []*PriorityConflict{
+		{
+			Path:             PathSlice{"interface", "ethernet-0/0", "description"},
+			Priority:         5,
+			Owner:            owner1,
+			ConflictingOwner: owner2,
+		},
+	}
+	if diff := cmp.Diff(expected, conflicts); diff != "" {
+		t.Errorf("GetPriorityConflicts() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_RootEntry_GetPrecedenceConflicts(t *testing.T) {
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), "")
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Foo"), 5, "owner-c", ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Bar"), 5, "owner-b", ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Baz"), 5, "owner-a", ts),
+		// same value, no conflict
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 5, "owner-a", ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 5, "owner-b", ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conflicts := root.GetPrecedenceConflicts()
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d: %v", len(conflicts), conflicts)
+	}
+
+	losers := []string{}
+	for _, l := range conflicts[0].Losers {
+		losers = append(losers, l.Owner())
+	}
+	if conflicts[0].Winner.Owner() != "owner-a" {
+		t.Errorf("expected owner-a to win, got %s", conflicts[0].Winner.Owner())
+	}
+	if diff := cmp.Diff([]string{"owner-b", "owner-c"}, losers); diff != "" {
+		t.Errorf("GetPrecedenceConflicts() losers mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(PathSlice{"interface", "ethernet-0/0", "description"}, conflicts[0].Path); diff != "" {
+		t.Errorf("GetPrecedenceConflicts() path mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_RootEntry_GetRunningOverwrites(t *testing.T) {
+	owner1 := "owner1"
+	owner2 := "owner2"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	// the description of ethernet-0/1 is owned by owner2
+	tc.SetStoreIndex(NewStoreIndexFromUpdates(
+		cache.NewUpdate([]string{"interface", "ethernet-0/1", "description"}, nil, 10, owner2, ts),
+	))
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Device"), RunningValuesPrio, RunningIntentName, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), RunningValuesPrio, RunningIntentName, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/1", "description"}, testhelper.GetStringTvProto(t, "Owner2"), RunningValuesPrio, RunningIntentName, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, u := range []*cache.Update{
+		// overwrites the value learned from the device
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Foo"), 5, owner1, ts),
+		// sets the same value as the device
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 5, owner1, ts),
+		// overwrites the value of another intent
+		cache.NewUpdate([]string{"interface", "ethernet-0/1", "description"}, testhelper.GetStringTvProto(t, "Foo"), 5, owner1, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	overwrites := root.GetRunningOverwrites(owner1)
+	if len(overwrites) != 1 {
+		t.Fatalf("expected 1 running overwrite, got %d: %v", len(overwrites), overwrites)
+	}
+	expected := `interface/ethernet-0/0/description: value of intent "owner1" (priority 5) overwrites the running value that is not owned by any intent`
+	if diff := cmp.Diff(expected, overwrites[0].String()); diff != "" {
+		t.Errorf("GetRunningOverwrites() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_RootEntry_ReplaceSubtrees(t *testing.T) {
+	owner1 := "owner1"
+	owner2 := "owner2"
+	owner3 := "owner3"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*cache.Update{
+		// a subinterface that only exists in running
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "1", "index"}, testhelper.GetUIntTvProto(t, 1), RunningValuesPrio, RunningIntentName, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "1", "description"}, testhelper.GetStringTvProto(t, "Foo"), RunningValuesPrio, RunningIntentName, ts),
+		// a subinterface of an intent with lower precedence
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "2", "index"}, testhelper.GetUIntTvProto(t, 2), 20, owner2, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "2", "description"}, testhelper.GetStringTvProto(t, "Bar"), 20, owner2, ts),
+		// a subinterface of an intent with higher precedence
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "3", "index"}, testhelper.GetUIntTvProto(t, 3), 5, owner3, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "3", "description"}, testhelper.GetStringTvProto(t, "Baz"), 5, owner3, ts),
+		// a different interface, not covered by the replaced subtree
+		cache.NewUpdate([]string{"interface", "ethernet-0/1", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/1"), RunningValuesPrio, RunningIntentName, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 10, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Owned"), 10, owner1, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = root.ReplaceSubtrees(ctx, owner1, 10, PathSlices{{"interface", "ethernet-0/0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.FinishInsertionPhase()
+
+	deletesSlices, err := root.GetDeletes(DeleteAggregationInstance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deletes := make([]string, 0, len(deletesSlices))
+	for _, x := range deletesSlices {
+		deletes = append(deletes, strings.Join(x.Path(), "/"))
+	}
+	slices.Sort(deletes)
+
+	expects := []string{
+		"interface/ethernet-0/0/subinterface/1",
+		"interface/ethernet-0/0/subinterface/2",
+	}
+	if diff := cmp.Diff(expects, deletes); diff != "" {
+		t.Errorf("root.GetDeletes() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_RootEntry_DeleteUnownedRunning(t *testing.T) {
+	owner1 := "owner1"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), RunningValuesPrio, RunningIntentName, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 10, owner1, ts),
+		// a subinterface that was removed by an intent, it only exists in running
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "1", "index"}, testhelper.GetUIntTvProto(t, 1), RunningValuesPrio, RunningIntentName, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "1", "description"}, testhelper.GetStringTvProto(t, "Foo"), RunningValuesPrio, RunningIntentName, ts),
+		// a subinterface that is still set by an intent
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "2", "index"}, testhelper.GetUIntTvProto(t, 2), RunningValuesPrio, RunningIntentName, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "2", "description"}, testhelper.GetStringTvProto(t, "Bar"), RunningValuesPrio, RunningIntentName, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "2", "index"}, testhelper.GetUIntTvProto(t, 2), 10, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "2", "description"}, testhelper.GetStringTvProto(t, "Bar"), 10, owner1, ts),
+		// running config outside of the subtrees
+		cache.NewUpdate([]string{"interface", "ethernet-0/1", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/1"), RunningValuesPrio, RunningIntentName, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = root.DeleteUnownedRunning(ctx, "pending", PathSlices{
+		{"interface", "ethernet-0/0", "subinterface", "1"},
+		{"interface", "ethernet-0/0", "subinterface", "2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.FinishInsertionPhase()
+
+	deletesSlices, err := root.GetDeletes(DeleteAggregationInstance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deletes := make([]string, 0, len(deletesSlices))
+	for _, x := range deletesSlices {
+		deletes = append(deletes, strings.Join(x.Path(), "/"))
+	}
+
+	expects := []string{
+		"interface/ethernet-0/0/subinterface/1",
+	}
+	if diff := cmp.Diff(expects, deletes); diff != "" {
+		t.Errorf("root.GetDeletes() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_RootEntry_GetUncovered(t *testing.T) {
+	owner1 := "owner1"
+	owner2 := "owner2"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), "")
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*cache.Update{
+		// owner2 takes over the description once owner1 is removed
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Foo"), 5, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Bar"), 10, owner2, ts),
+		// owner2 already takes precedence
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 20, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 10, owner2, ts),
+		// running values do not take over
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo F"), 5, owner1, ts),
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo 0"), RunningValuesPrio, RunningIntentName, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if uncovered := root.GetUncovered(owner1); len(uncovered) != 0 {
+		t.Errorf("expected no uncovered entries before the deletion, got %v", uncovered)
+	}
+
+	root.markOwnerDelete(owner1)
+
+	uncovered := root.GetUncovered(owner1)
+	if len(uncovered) != 1 {
+		t.Fatalf("expected 1 uncovered entry, got %d: %v", len(uncovered), uncovered)
+	}
+	expected := `interface/ethernet-0/0/description: value of removed intent "owner1" (priority 5) is now served by intent "owner2" (priority 10)`
+	if diff := cmp.Diff(expected, uncovered[0].String()); diff != "" {
+		t.Errorf("GetUncovered() mismatch (-want +got):\n%s", diff)
+	}
+
+	removed, err := root.GetDeletedPathsForOwner(owner1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 3 {
+		t.Errorf("expected 3 removed paths, got %d: %v", len(removed), removed)
+	}
+}
+
+func Test_RootEntry_GetShadowed(t *testing.T) {
+	owner1 := "owner1"
+	owner2 := "owner2"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), "")
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*cache.Update{
+		// owner2 overrides the description with a higher precedence
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Foo"), 10, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Bar"), 5, owner2, ts),
+		// owner2 sets the same value with a higher precedence
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 10, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 5, owner2, ts),
+		// running values do not shadow intents
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo F"), 10, owner1, ts),
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo 0"), RunningValuesPrio, RunningIntentName, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	shadowed := root.GetShadowed(owner1)
+	if len(shadowed) != 1 {
+		t.Fatalf("expected 1 shadowed entry, got %d: %v", len(shadowed), shadowed)
+	}
+	expected := `interface/ethernet-0/0/description: value of intent "owner1" (priority 10) is shadowed by intent "owner2" (priority 5)`
+	if diff := cmp.Diff(expected, shadowed[0].String()); diff != "" {
+		t.Errorf("GetShadowed() mismatch (-want +got):\n%s", diff)
+	}
+
+	if shadowed := root.GetShadowed(owner2); len(shadowed) != 0 {
+		t.Errorf("expected no shadowed entries for %s, got %v", owner2, shadowed)
+	}
+}
+
+func Test_RootEntry_GetUpdatesDivergingFromRunning(t *testing.T) {
+	owner1 := "owner1"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the device already carries the name and description of the interface
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), RunningValuesPrio, RunningIntentName, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Foo"), RunningValuesPrio, RunningIntentName, ts),
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo 0"), RunningValuesPrio, RunningIntentName, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the intent re-applies the description unchanged, but changes patterntest
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 10, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Foo"), 10, owner1, ts),
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo F"), 10, owner1, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.FinishInsertionPhase()
+
+	if got := len(root.GetHighestPrecedence(true)); got != 3 {
+		t.Errorf("expected 3 new highest precedence entries, got %d", got)
+	}
+
+	updates := root.GetUpdatesDivergingFromRunning()
+	paths := make([]string, 0, len(updates))
+	for _, u := range updates {
+		paths = append(paths, strings.Join(u.GetPath(), "/"))
+	}
+	if diff := cmp.Diff([]string{"patterntest"}, paths); diff != "" {
+		t.Errorf("GetUpdatesDivergingFromRunning() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_TreeContext_RunningReader(t *testing.T) {
+	owner1 := "owner1"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	tc.SetRunningReader(NewStaticRunningReader([]*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Foo"), RunningValuesPrio, RunningIntentName, ts),
+	}))
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = root.AddCacheUpdateRecursive(ctx, cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), 10, owner1, ts), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the description is not part of the tree, it is loaded from the running reader
+	e, err := root.NavigateSdcpbPath(ctx, []*sdcpb.PathElem{{Name: "interface", Key: map[string]string{"name": "ethernet-0/0"}}, {Name: "description"}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lv := e.GetHighestPrecedence(LeafVariantSlice{}, false)
+	if len(lv) != 1 || lv[0].Owner() != RunningIntentName {
+		t.Errorf("expected the running description to be loaded, got %v", lv)
+	}
+
+	upds, err := tc.ReadRunningFull(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(upds) != 1 {
+		t.Errorf("ReadRunningFull() returned %d updates, expected 1", len(upds))
+	}
+}
+
+func Test_RootEntry_DebugInfo(t *testing.T) {
+	owner1 := "owner1"
+	owner2 := "owner2"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
 
-			le := lv.GetHighestPrecedence(true, false)
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"choices", "case1", "case-elem", "elem"}, testhelper.GetStringTvProto(t, "Foo"), 10, owner1, ts),
+		// owner2 populates the other case with a higher precedence
+		cache.NewUpdate([]string{"choices", "case2", "log"}, testhelper.GetStringTvProto(t, "true"), 5, owner2, ts),
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo F"), 10, owner1, ts),
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo 0"), RunningValuesPrio, RunningIntentName, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.FinishInsertionPhase()
 
-			if le != lv.les[1] {
-				t.Errorf("expected to get entry %v, got %v", lv.les[1], le)
-			}
+	di := root.DebugInfo()
+	// the order of the childs in the tree string is not deterministic
+	for _, s := range []string{"patterntest", "case-elem", "Owner: running"} {
+		if !strings.Contains(di.Tree, s) {
+			t.Errorf("expected the tree string to contain %q, got %q", s, di.Tree)
+		}
+	}
+
+	expectedChoices := []*ChoiceDebugInfo{
+		{
+			Path:       PathSlice{"choices"},
+			Choice:     "choicecase",
+			ActiveCase: "case2",
+			Cases:      map[string]int32{"case1": 10, "case2": 5},
 		},
-	)
+	}
+	if diff := cmp.Diff(expectedChoices, di.Choices); diff != "" {
+		t.Errorf("DebugInfo() choices mismatch (-want +got):\n%s", diff)
+	}
+
+	type leafDecision struct {
+		Path     string
+		Selected string
+		Variants []string
+	}
+	got := make([]leafDecision, 0, len(di.Leafs))
+	for _, l := range di.Leafs {
+		ld := leafDecision{Path: l.Path.String(), Selected: l.Selected.Owner()}
+		for _, v := range l.Variants {
+			ld.Variants = append(ld.Variants, v.Owner())
+		}
+		got = append(got, ld)
+	}
+	expectedLeafs := []leafDecision{
+		{Path: "choices/case1/case-elem/elem", Selected: owner1, Variants: []string{owner1}},
+		{Path: "choices/case2/log", Selected: owner2, Variants: []string{owner2}},
+		{Path: "patterntest", Selected: owner1, Variants: []string{owner1, RunningIntentName}},
+	}
+	if diff := cmp.Diff(expectedLeafs, got); diff != "" {
+		t.Errorf("DebugInfo() leafs mismatch (-want +got):\n%s", diff)
+	}
 }
 
 func expectNil(t *testing.T, a any, name string) {
@@ -1174,6 +2301,47 @@ func Test_Validation_String_Pattern(t *testing.T) {
 		},
 	)
 
+	// patterns are implicitly anchored, a partial match is not sufficient
+	t.Run("Test_Validation_String_Pattern - Anchored",
+		func(t *testing.T) {
+			for value, expectedErrs := range map[string]int{"hallo DU": 1, "hallo DA": 0} {
+				tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+				root, err := NewTreeRoot(ctx, tc)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				leafval := testhelper.GetStringTvProto(t, value)
+
+				u1 := cache.NewUpdate([]string{"patterntest"}, leafval, prio50, owner1, ts1)
+
+				_, err = root.AddCacheUpdateRecursive(ctx, u1, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				root.FinishInsertionPhase()
+
+				validationErrors := []error{}
+				validationErrChan := make(chan error)
+				validationWarnChan := make(chan error)
+				go func() {
+					root.Validate(context.TODO(), validationErrChan, validationWarnChan, false)
+					close(validationErrChan)
+				}()
+
+				// read from the Error channel
+				for e := range validationErrChan {
+					validationErrors = append(validationErrors, e)
+				}
+
+				if len(validationErrors) != expectedErrs {
+					t.Errorf("value %q: expected %d error but got %d, %v", value, expectedErrs, len(validationErrors), validationErrors)
+				}
+			}
+		},
+	)
+
 	// t.Run("Test_Validation_String_Pattern - Test Invert",
 	// 	func(t *testing.T) {
 
@@ -1271,3 +2439,356 @@ func Test_Validation_Deref(t *testing.T) {
 		},
 	)
 }
+
+func Test_Validation_Leafref_Deleted_Reference(t *testing.T) {
+	prio50 := int32(50)
+	owner1 := "OwnerOne"
+	owner2 := "OwnerTwo"
+	ts1 := int64(9999999)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner2)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// owner2 provides the interface, owner1 references it
+	u1 := cache.NewUpdate([]string{"interface", "mgmt0", "name"}, testhelper.GetStringTvProto(t, "mgmt0"), prio50, owner2, ts1)
+	u2 := cache.NewUpdate([]string{"interface", "mgmt0", "description"}, testhelper.GetStringTvProto(t, "foo"), prio50, owner2, ts1)
+	u3 := cache.NewUpdate([]string{"mgmt-interface", "name"}, testhelper.GetStringTvProto(t, "mgmt0"), prio50, owner1, ts1)
+
+	for _, u := range []*cache.Update{u1, u2, u3} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// delete the intent of owner2
+	root.markOwnerDelete(owner2)
+
+	root.FinishInsertionPhase()
+
+	validationErrors := []error{}
+	validationErrChan := make(chan error, 10)
+	validationWarnChan := make(chan error, 10)
+	go func() {
+		root.Validate(context.TODO(), validationErrChan, validationWarnChan, false)
+		close(validationErrChan)
+	}()
+
+	// read from the Error channel
+	for e := range validationErrChan {
+		validationErrors = append(validationErrors, e)
+	}
+
+	if len(validationErrors) != 1 {
+		t.Fatalf("expected 1 error but got %d, %v", len(validationErrors), validationErrors)
+	}
+	for _, s := range []string{"mgmt-interface/name", owner1, "interface/mgmt0/name"} {
+		if !strings.Contains(validationErrors[0].Error(), s) {
+			t.Errorf("expected error %q to contain %q", validationErrors[0], s)
+		}
+	}
+
+	// check the reverse leafref index
+	refs := tc.GetLeafrefReferences(PathSlice{"interface", "mgmt0", "name"})
+	if len(refs) != 1 {
+		t.Fatalf("expected 1 leafref reference but got %d", len(refs))
+	}
+	if diff := cmp.Diff(PathSlice{"mgmt-interface", "name"}, refs[0].Path); diff != "" {
+		t.Errorf("GetLeafrefReferences() path mismatch (-want +got):\n%s", diff)
+	}
+	if refs[0].Owner != owner1 || refs[0].Priority != prio50 {
+		t.Errorf("GetLeafrefReferences() expected owner %s priority %d, got %s %d", owner1, prio50, refs[0].Owner, refs[0].Priority)
+	}
+}
+
+func Test_Validation_Scope(t *testing.T) {
+	prio50 := int32(50)
+	owner1 := "OwnerOne"
+	ts1 := int64(9999999)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		scope      ValidationScope
+		wantErrors int
+	}{
+		{name: "intended and running", scope: ValidationScopeIntendedAndRunning, wantErrors: 0},
+		{name: "intended", scope: ValidationScopeIntended, wantErrors: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+			tc.SetValidationScope(tt.scope)
+			root, err := NewTreeRoot(ctx, tc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the referenced interface solely exists on the device
+			running := []*cache.Update{
+				cache.NewUpdate([]string{"interface", "mgmt0", "name"}, testhelper.GetStringTvProto(t, "mgmt0"), RunningValuesPrio, RunningIntentName, ts1),
+				cache.NewUpdate([]string{"interface", "mgmt0", "admin-state"}, testhelper.GetStringTvProto(t, "enable"), RunningValuesPrio, RunningIntentName, ts1),
+			}
+			for _, u := range running {
+				if _, err := root.AddCacheUpdateRecursive(ctx, u, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+			u1 := cache.NewUpdate([]string{"mgmt-interface", "name"}, testhelper.GetStringTvProto(t, "mgmt0"), prio50, owner1, ts1)
+			if _, err := root.AddCacheUpdateRecursive(ctx, u1, true); err != nil {
+				t.Fatal(err)
+			}
+
+			root.FinishInsertionPhase()
+
+			validationErrors := []error{}
+			validationErrChan := make(chan error, 10)
+			validationWarnChan := make(chan error, 10)
+			go func() {
+				root.Validate(context.TODO(), validationErrChan, validationWarnChan, false)
+				close(validationErrChan)
+			}()
+
+			// read from the Error channel
+			for e := range validationErrChan {
+				validationErrors = append(validationErrors, e)
+			}
+
+			if len(validationErrors) != tt.wantErrors {
+				t.Fatalf("expected %d errors but got %d, %v", tt.wantErrors, len(validationErrors), validationErrors)
+			}
+			for _, e := range validationErrors {
+				if !strings.Contains(e.Error(), "not part of the intended config") {
+					t.Errorf("unexpected error %v", e)
+				}
+			}
+		})
+	}
+}
+
+func Test_Entry_Delete_Order_Leafref(t *testing.T) {
+	prio50 := int32(50)
+	owner1 := "OwnerOne"
+	ts1 := int64(9999999)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the intent provides the interface as well as the reference to it
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "mgmt0", "name"}, testhelper.GetStringTvProto(t, "mgmt0"), prio50, owner1, ts1),
+		cache.NewUpdate([]string{"interface", "mgmt0", "description"}, testhelper.GetStringTvProto(t, "foo"), prio50, owner1, ts1),
+		cache.NewUpdate([]string{"mgmt-interface", "name"}, testhelper.GetStringTvProto(t, "mgmt0"), prio50, owner1, ts1),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// delete the intent
+	root.markOwnerDelete(owner1)
+	root.FinishInsertionPhase()
+
+	validationErrChan := make(chan error, 10)
+	validationWarnChan := make(chan error, 10)
+	go func() {
+		root.Validate(ctx, validationErrChan, validationWarnChan, false)
+		close(validationErrChan)
+	}()
+	for e := range validationErrChan {
+		t.Error(e)
+	}
+
+	deletesSlices, err := root.GetDeletes(DeleteAggregationInstance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deletes := make([]string, 0, len(deletesSlices))
+	for _, x := range deletesSlices {
+		deletes = append(deletes, x.Path().String())
+	}
+
+	// the referrer must be deleted before the referenced interface
+	expects := []string{
+		"mgmt-interface",
+		"interface/mgmt0",
+	}
+	if diff := cmp.Diff(expects, deletes); diff != "" {
+		t.Errorf("root.GetDeletes() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_Validation_KeyLeaf(t *testing.T) {
+	prio50 := int32(50)
+	owner1 := "OwnerOne"
+	ts1 := int64(9999999)
+
+	tests := []struct {
+		name          string
+		updates       func(t *testing.T) []*cache.Update
+		expectedError string
+	}{
+		{
+			name: "matching keys",
+			updates: func(t *testing.T) []*cache.Update {
+				return []*cache.Update{
+					cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), prio50, owner1, ts1),
+					cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "5", "index"}, testhelper.GetUIntTvProto(t, 5), prio50, owner1, ts1),
+				}
+			},
+		},
+		{
+			name: "diverging string key",
+			updates: func(t *testing.T) []*cache.Update {
+				return []*cache.Update{
+					cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/1"), prio50, owner1, ts1),
+				}
+			},
+			expectedError: `key leaf interface/ethernet-0/0/name of intent "OwnerOne" has value "ethernet-0/1", which diverges from the key value "ethernet-0/0" of the list entry`,
+		},
+		{
+			name: "diverging uint key",
+			updates: func(t *testing.T) []*cache.Update {
+				return []*cache.Update{
+					cache.NewUpdate([]string{"interface", "ethernet-0/0", "name"}, testhelper.GetStringTvProto(t, "ethernet-0/0"), prio50, owner1, ts1),
+					cache.NewUpdate([]string{"interface", "ethernet-0/0", "subinterface", "5", "index"}, testhelper.GetUIntTvProto(t, 6), prio50, owner1, ts1),
+				}
+			},
+			expectedError: `key leaf interface/ethernet-0/0/subinterface/5/index of intent "OwnerOne" has value "6", which diverges from the key value "5" of the list entry`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.TODO()
+
+			scb, err := testhelper.GetSchemaClientBound(t)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+			root, err := NewTreeRoot(ctx, tc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, u := range tt.updates(t) {
+				_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			root.FinishInsertionPhase()
+
+			validationErrChan := make(chan error, 10)
+			validationWarnChan := make(chan error, 10)
+			go func() {
+				root.Validate(ctx, validationErrChan, validationWarnChan, false)
+				close(validationErrChan)
+			}()
+
+			var keyErrors []string
+			for e := range validationErrChan {
+				if strings.HasPrefix(e.Error(), "key leaf") {
+					keyErrors = append(keyErrors, e.Error())
+				}
+			}
+
+			var expected []string
+			if tt.expectedError != "" {
+				expected = []string{tt.expectedError}
+			}
+			if diff := cmp.Diff(expected, keyErrors); diff != "" {
+				t.Errorf("root.Validate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_RootEntry_Redaction(t *testing.T) {
+	owner1 := "owner1"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	tc.SetRedaction(&Redaction{
+		Sensitive: func(path []string) bool {
+			return slices.Equal(path, []string{"interface", "ethernet-0/0", "description"})
+		},
+		MaxValueLen: 8,
+	})
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-0/0", "description"}, testhelper.GetStringTvProto(t, "Secret"), 5, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-0/1", "description"}, testhelper.GetStringTvProto(t, "A very long description"), 5, owner1, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.FinishInsertionPhase()
+
+	s := root.String()
+	if strings.Contains(s, "Secret") {
+		t.Errorf("String() renders the sensitive value:\n%s", s)
+	}
+	if !strings.Contains(s, RedactedValue) {
+		t.Errorf("String() does not render the redacted value:\n%s", s)
+	}
+	if strings.Contains(s, "A very long description") || !strings.Contains(s, "bytes truncated") {
+		t.Errorf("String() does not truncate the long value:\n%s", s)
+	}
+
+	info := root.DebugInfo()
+	for _, l := range info.Leafs {
+		sensitive := l.Path[1] == "ethernet-0/0"
+		for _, le := range append(l.Variants, l.Selected) {
+			v, err := le.Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if redacted := v.GetStringVal() == RedactedValue; redacted != sensitive {
+				t.Errorf("DebugInfo() leaf %s value %s, redacted %t, want %t", l.Path, v.GetStringVal(), redacted, sensitive)
+			}
+		}
+	}
+}
@@ -22,6 +22,8 @@ type ImportConfigAdapter interface {
 	GetKeyValue() string
 	// GetTVValue returns the TypedValue based value defined via the SchemaLeafType. Can also only be called on Leafs or LeafLists
 	GetTVValue(slt *sdcpb.SchemaLeafType) (*sdcpb.TypedValue, error)
+	// GetAnyDataValue returns the content of an anydata or anyxml node as a single opaque TypedValue.
+	GetAnyDataValue() (*sdcpb.TypedValue, error)
 	// returns the name of the actual Level.
 	GetName() string
 }
@@ -7,7 +7,6 @@ import (
 	"github.com/sdcio/data-server/pkg/tree/importer"
 	"github.com/sdcio/data-server/pkg/utils"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 )
 
 type JsonTreeImporter struct {
@@ -79,6 +78,10 @@ func (j *JsonTreeImporter) GetTVValue(slt *sdcpb.SchemaLeafType) (*sdcpb.TypedVa
 	return utils.ConvertJsonValueToTv(j.data, slt)
 }
 
+func (j *JsonTreeImporter) GetAnyDataValue() (*sdcpb.TypedValue, error) {
+	return utils.AnyDataValue(j.data)
+}
+
 func (j *JsonTreeImporter) GetName() string {
 	return j.name
 }
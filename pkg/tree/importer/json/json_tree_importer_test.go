@@ -61,7 +61,7 @@ func TestJsonTreeImporter(t *testing.T) {
 				}
 				}
 			],
-			"patterntest": "hallo DU",
+			"patterntest": "hallo DA",
 			"emptyconf": {}
 			}`,
 		},
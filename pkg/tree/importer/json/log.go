@@ -0,0 +1,5 @@
+package json
+
+import "github.com/sdcio/data-server/pkg/dslog"
+
+var log = dslog.New(dslog.ModuleTree)
@@ -48,6 +48,11 @@ func (x *XmlTreeImporter) GetTVValue(slt *sdcpb.SchemaLeafType) (*sdcpb.TypedVal
 	return utils.Convert(x.elem.Text(), slt)
 }
 
+// GetAnyDataValue returns the inner XML of the element as an AsciiVal.
+func (x *XmlTreeImporter) GetAnyDataValue() (*sdcpb.TypedValue, error) {
+	return utils.XMLInnerValue(x.elem)
+}
+
 func (x *XmlTreeImporter) GetName() string {
 	return x.elem.Tag
 }
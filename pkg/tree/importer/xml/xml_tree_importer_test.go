@@ -62,7 +62,7 @@ func TestXmlTreeImporter(t *testing.T) {
   </protocol>
   <type>sdcio_model_ni:default</type>
 </network-instance>
-<patterntest>hallo DU</patterntest>
+<patterntest>hallo DA</patterntest>
 	`,
 		},
 	}
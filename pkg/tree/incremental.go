@@ -0,0 +1,139 @@
+package tree
+
+import (
+	"context"
+
+	"github.com/sdcio/data-server/pkg/cache"
+)
+
+// Reuse moves the tree over to the given TreeContext, which must not have a root yet, for the next intent.
+// The choice resolutions and the state caches are kept, such that FinishInsertionPhase only recomputes the
+// branches modified afterwards. Together with ApplyRunningChanges and Settle, this allows to keep a tree
+// across SetIntents and to apply the changes of the intents and of running incrementally, instead of building
+// a new tree per intent. The tree is modified in place, it must be dropped if the intent is not stored.
+func (r *RootEntry) Reuse(tc *TreeContext) error {
+	err := tc.SetRoot(r.sharedEntryAttributes)
+	if err != nil {
+		return err
+	}
+	r.sharedEntryAttributes.setTreeContext(tc)
+	return nil
+}
+
+// ApplyRunningChanges applies the modifications of running, as applied to the CONFIG store, to the running values
+// of the tree. The deletes are applied before the updates. As in the cache, the deletes remove all the values
+// below the given paths and "*" matches any path element. Entries left empty are removed.
+func (r *RootEntry) ApplyRunningChanges(ctx context.Context, deletes [][]string, updates []*cache.Update) error {
+	for _, path := range deletes {
+		r.sharedEntryAttributes.removeRunningValues(path)
+	}
+	for _, u := range updates {
+		upd := cache.NewUpdate(u.GetPath(), u.Bytes(), RunningValuesPrio, RunningIntentName, 0)
+		err := r.sharedEntryAttributes.setRunningValue(ctx, upd)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Settle turns the tree, once its changes are stored, into the tree as it would be loaded from the stores.
+// The default values and the leaf variants of the actual owners marked for deletion are dropped, the flags of the
+// remaining ones are reset, e.g. of the values of other intents only removed from the device by a replace,
+// and entries left empty are removed. Running is not changed, the changes applied to the device are taken over
+// with the next ApplyRunningChanges. Only the branches modified since the last Settle are visited.
+func (r *RootEntry) Settle() {
+	r.sharedEntryAttributes.settle()
+}
+
+func (s *sharedEntryAttributes) setRunningValue(ctx context.Context, u *cache.Update) error {
+	idx := 0
+	// if it is the root node, index remains == 0
+	if s.parent != nil {
+		idx = s.GetLevel()
+	}
+	if idx == len(u.GetPath()) {
+		s.leafVariants.set(NewLeafEntry(u, false, s))
+		return nil
+	}
+
+	e, exists := s.childs.GetEntry(u.GetPath()[idx])
+	if !exists {
+		var err error
+		e, err = newEntry(ctx, s, u.GetPath()[idx], s.treeContext)
+		if err != nil {
+			return err
+		}
+	}
+	return e.setRunningValue(ctx, u)
+}
+
+func (s *sharedEntryAttributes) setTreeContext(tc *TreeContext) {
+	s.treeContext = tc
+	s.leafVariants.tc = tc
+	for _, c := range s.childs.Items() {
+		c.setTreeContext(tc)
+	}
+}
+
+func (s *sharedEntryAttributes) removeRunningValues(path []string) bool {
+	idx := 0
+	if s.parent != nil {
+		idx = s.GetLevel()
+	}
+	if idx >= len(path) {
+		// the entry is part of the deleted branch
+		s.leafVariants.remove(func(le *LeafEntry) bool {
+			return le.Owner() == RunningIntentName
+		})
+	}
+	var empty []string
+	for name, c := range s.childs.Items() {
+		if idx < len(path) && path[idx] != "*" && path[idx] != name {
+			continue
+		}
+		if c.removeRunningValues(path) {
+			empty = append(empty, name)
+		}
+	}
+	s.deleteChilds(empty)
+	return s.isEmpty()
+}
+
+func (s *sharedEntryAttributes) settle() bool {
+	if !s.unsettled.Load() {
+		return false
+	}
+	s.leafVariants.remove(func(le *LeafEntry) bool {
+		return le.Owner() == DefaultsIntentName || le.GetDeleteFlag() && s.treeContext.isActualOwner(le.Owner())
+	})
+	for le := range s.leafVariants.Items() {
+		le.clearFlags()
+	}
+	var empty []string
+	for name, c := range s.childs.Items() {
+		if c.settle() {
+			empty = append(empty, name)
+		}
+	}
+	s.deleteChilds(empty)
+	// the flags are cleared, only the removal of childs remains to be recomputed by FinishInsertionPhase
+	s.unsettled.Store(false)
+	return s.isEmpty()
+}
+
+// deleteChilds removes the childs with the given names.
+func (s *sharedEntryAttributes) deleteChilds(names []string) {
+	if len(names) == 0 {
+		return
+	}
+	for _, name := range names {
+		s.childs.Delete(name)
+	}
+	s.markDirty()
+}
+
+// isEmpty returns true if the entry, other than the root, holds neither childs nor leaf variants.
+func (s *sharedEntryAttributes) isEmpty() bool {
+	return !s.IsRoot() && s.childs.Length() == 0 && s.leafVariants.Length() == 0
+}
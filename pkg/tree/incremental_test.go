@@ -0,0 +1,188 @@
+package tree
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/utils"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+)
+
+func TestRootEntry_Incremental(t *testing.T) {
+	owner1 := "owner1"
+	owner2 := "owner2"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upd := func(path []string, v string, prio int32, owner string) *cache.Update {
+		return cache.NewUpdate(path, testhelper.GetStringTvProto(t, v), prio, owner, ts)
+	}
+	name := []string{"interface", "ethernet-1/1", "name"}
+	description := []string{"interface", "ethernet-1/1", "description"}
+	case1 := []string{"choices", "case1", "case-elem", "elem"}
+	case2 := []string{"choices", "case2", "log"}
+	patterntest := []string{"patterntest"}
+
+	// round 1: owner1 changes the description and switches the choice to case2
+	stored1 := []*cache.Update{
+		upd(name, "ethernet-1/1", 5, owner1),
+		upd(description, "Desc", 5, owner1),
+		upd(case1, "Foo", 10, owner2),
+	}
+	running1 := []*cache.Update{
+		upd(name, "ethernet-1/1", RunningValuesPrio, RunningIntentName),
+		upd(description, "Desc", RunningValuesPrio, RunningIntentName),
+		upd(case1, "Foo", RunningValuesPrio, RunningIntentName),
+		upd(patterntest, "hallo 0", RunningValuesPrio, RunningIntentName),
+	}
+	intent1 := []*cache.Update{
+		upd(name, "ethernet-1/1", 5, owner1),
+		upd(description, "Changed", 5, owner1),
+		upd(case2, "true", 5, owner1),
+	}
+	root := newIncrementalTestTree(ctx, t, scb, owner1, stored1, intent1, running1)
+	root.FinishInsertionPhase()
+	root.Settle()
+
+	if len(root.getByOwnerFiltered(owner1, FilterNonDeleted)) != len(intent1) || len(root.getByOwnerFiltered(owner1, FilterDeleted)) > 0 {
+		t.Fatal("expected the deleted values of owner1 to be dropped by Settle")
+	}
+	for _, le := range root.GetHighestPrecedence(false) {
+		if le.GetNewFlag() || le.GetUpdateFlag() || le.GetDeleteFlag() {
+			t.Errorf("expected the flags of %s to be reset by Settle", le)
+		}
+	}
+
+	// the changes of round 1 are applied to the device and written back to running
+	runningDeletes := [][]string{{"choices", "case1"}}
+	runningUpdates := []*cache.Update{
+		upd(description, "Changed", 5, owner1),
+		upd(case2, "true", 5, owner1),
+	}
+
+	// round 2: owner1 removes case2, hence case1 of owner2 takes over again
+	stored2 := []*cache.Update{
+		upd(name, "ethernet-1/1", 5, owner1),
+		upd(description, "Changed", 5, owner1),
+		upd(case2, "true", 5, owner1),
+		upd(case1, "Foo", 10, owner2),
+	}
+	running2 := []*cache.Update{
+		upd(name, "ethernet-1/1", RunningValuesPrio, RunningIntentName),
+		upd(description, "Changed", RunningValuesPrio, RunningIntentName),
+		upd(case2, "true", RunningValuesPrio, RunningIntentName),
+		upd(patterntest, "hallo 0", RunningValuesPrio, RunningIntentName),
+	}
+	intent2 := []*cache.Update{
+		upd(name, "ethernet-1/1", 5, owner1),
+		upd(description, "Changed", 5, owner1),
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	err = root.Reuse(tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = root.ApplyRunningChanges(ctx, runningDeletes, runningUpdates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addIncrementalTestIntent(ctx, t, root, owner1, stored2, intent2)
+
+	// branches that were not modified keep their state
+	e, exists := root.childs.GetEntry(patterntest[0])
+	if !exists {
+		t.Fatalf("expected %s to exist", patterntest[0])
+	}
+	if e.(*EntryImpl).dirty.Load() {
+		t.Error("expected the unmodified entry not to be dirty")
+	}
+	if !root.dirty.Load() {
+		t.Error("expected the root to be dirty")
+	}
+	if e.(*EntryImpl).treeContext != tc {
+		t.Error("expected the entries to be moved over to the new TreeContext")
+	}
+	root.FinishInsertionPhase()
+
+	fresh := newIncrementalTestTree(ctx, t, scb, owner1, stored2, intent2, running2)
+	fresh.FinishInsertionPhase()
+
+	if diff := cmp.Diff(incrementalTestLeafs(fresh.GetUpdatesDivergingFromRunning()), incrementalTestLeafs(root.GetUpdatesDivergingFromRunning())); diff != "" {
+		t.Errorf("updates mismatch (-fresh +incremental):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"choices/case2"}, incrementalTestDeletes(t, root)); diff != "" {
+		t.Errorf("deletes mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(incrementalTestDeletes(t, fresh), incrementalTestDeletes(t, root)); diff != "" {
+		t.Errorf("deletes mismatch (-fresh +incremental):\n%s", diff)
+	}
+	if diff := cmp.Diff(incrementalTestLeafs(fresh.GetHighestPrecedence(false)), incrementalTestLeafs(root.GetHighestPrecedence(false))); diff != "" {
+		t.Errorf("tree mismatch (-fresh +incremental):\n%s", diff)
+	}
+}
+
+// newIncrementalTestTree returns a tree the intent of the owner is added to, along with the stored values and running.
+func newIncrementalTestTree(ctx context.Context, t *testing.T, scb utils.SchemaClientBound, owner string, stored, intent, running []*cache.Update) *RootEntry {
+	t.Helper()
+	root, err := NewTreeRoot(ctx, NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addIncrementalTestIntent(ctx, t, root, owner, stored, intent)
+	for _, u := range running {
+		if _, err := root.AddCacheUpdateRecursive(ctx, u, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// addIncrementalTestIntent adds the intent of the owner to the tree, as the datastore does.
+func addIncrementalTestIntent(ctx context.Context, t *testing.T, root *RootEntry, owner string, stored, intent []*cache.Update) {
+	t.Helper()
+	for _, u := range stored {
+		if _, err := root.AddCacheUpdateRecursive(ctx, u, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.markOwnerDelete(owner)
+	for _, u := range intent {
+		if _, err := root.AddCacheUpdateRecursive(ctx, u, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// incrementalTestLeafs returns the sorted string representations, including the flags, of the leafs.
+func incrementalTestLeafs(les LeafVariantSlice) []string {
+	result := make([]string, 0, len(les))
+	for _, le := range les {
+		result = append(result, le.String())
+	}
+	slices.Sort(result)
+	return result
+}
+
+func incrementalTestDeletes(t *testing.T, root *RootEntry) []string {
+	t.Helper()
+	deletes, err := root.GetDeletes(DeleteAggregationInstance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make([]string, 0, len(deletes))
+	for _, d := range deletes {
+		result = append(result, d.Path().String())
+	}
+	slices.Sort(result)
+	return result
+}
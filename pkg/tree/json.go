@@ -8,8 +8,17 @@ import (
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 )
 
+const (
+	// OriginOwnerAnnotation is the RFC 7952 annotation carrying the owner (intent) of a leaf value
+	OriginOwnerAnnotation = "sdcio:owner"
+	// OriginPriorityAnnotation is the RFC 7952 annotation carrying the priority of a leaf value
+	OriginPriorityAnnotation = "sdcio:priority"
+	// OriginTimestampAnnotation is the RFC 7952 annotation carrying the modification time of a leaf value
+	OriginTimestampAnnotation = "sdcio:timestamp"
+)
+
 func (s *sharedEntryAttributes) ToJson(onlyNewOrUpdated bool) (any, error) {
-	result, err := s.toJsonInternal(onlyNewOrUpdated, false)
+	result, err := s.toJsonInternal(onlyNewOrUpdated, false, false)
 	if err != nil {
 		return nil, err
 	}
@@ -20,7 +29,18 @@ func (s *sharedEntryAttributes) ToJson(onlyNewOrUpdated bool) (any, error) {
 }
 
 func (s *sharedEntryAttributes) ToJsonIETF(onlyNewOrUpdated bool) (any, error) {
-	result, err := s.toJsonInternal(onlyNewOrUpdated, true)
+	result, err := s.toJsonInternal(onlyNewOrUpdated, true, false)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return map[string]any{}, nil
+	}
+	return result, err
+}
+
+func (s *sharedEntryAttributes) ToJsonWithOrigin(ietf bool) (any, error) {
+	result, err := s.toJsonInternal(false, ietf, true)
 	if err != nil {
 		return nil, err
 	}
@@ -34,7 +54,8 @@ func (s *sharedEntryAttributes) ToJsonIETF(onlyNewOrUpdated bool) (any, error) {
 // If the ietf parameter is set to true, JSON_IETF encoding is used.
 // The actualPrefix is used only for the JSON_IETF encoding and can be ignored for JSON
 // In the initial / users call with ietf == true, actualPrefix should be set to ""
-func (s *sharedEntryAttributes) toJsonInternal(onlyNewOrUpdated bool, ietf bool) (any, error) {
+// If annotateOrigin is set, the leafs are annotated with the owner and priority of their value.
+func (s *sharedEntryAttributes) toJsonInternal(onlyNewOrUpdated bool, ietf bool, annotateOrigin bool) (any, error) {
 	switch s.schema.GetSchema().(type) {
 	case nil:
 		// we're operating on a key level, no schema attached, but the
@@ -45,12 +66,15 @@ func (s *sharedEntryAttributes) toJsonInternal(onlyNewOrUpdated bool, ietf bool)
 			ancest, _ := s.GetFirstAncestorWithSchema()
 			prefixedKey := jsonGetIetfPrefixConditional(key, c, ancest, ietf)
 			// recurse the call
-			js, err := c.toJsonInternal(onlyNewOrUpdated, ietf)
+			js, err := c.toJsonInternal(onlyNewOrUpdated, ietf, annotateOrigin)
 			if err != nil {
 				return nil, err
 			}
 			if js != nil {
 				result[prefixedKey] = js
+				if annotateOrigin {
+					jsonAddOriginAnnotation(result, prefixedKey, c)
+				}
 			}
 		}
 		if len(result) == 0 {
@@ -60,6 +84,13 @@ func (s *sharedEntryAttributes) toJsonInternal(onlyNewOrUpdated bool, ietf bool)
 		return result, nil
 	case *sdcpb.SchemaElem_Container:
 		switch {
+		case s.isAnyData():
+			// anydata and anyxml payloads are rendered verbatim
+			tv, err := s.anyDataValue(onlyNewOrUpdated)
+			if err != nil || tv == nil {
+				return nil, err
+			}
+			return anyDataToJson(tv)
 		case len(s.GetSchemaKeys()) > 0:
 			// if the container contains keys, then it is a list
 			// hence must be rendered as an array
@@ -73,7 +104,7 @@ func (s *sharedEntryAttributes) toJsonInternal(onlyNewOrUpdated bool, ietf bool)
 
 			result := make([]any, 0, len(childs))
 			for _, c := range childs {
-				j, err := c.toJsonInternal(onlyNewOrUpdated, ietf)
+				j, err := c.toJsonInternal(onlyNewOrUpdated, ietf, annotateOrigin)
 				if err != nil {
 					return nil, err
 				}
@@ -85,17 +116,11 @@ func (s *sharedEntryAttributes) toJsonInternal(onlyNewOrUpdated bool, ietf bool)
 				return nil, nil
 			}
 			return result, nil
-		case s.childs.Length() == 0 && s.schema.GetContainer().IsPresence:
+		case s.childs.Length() == 0 && s.isPresenceContainer():
 			// Presence container without any childs
-			if onlyNewOrUpdated {
-				// presence containers have leafvariantes with typedValue_Empty, so check that
-				if s.leafVariants.shouldDelete() {
-					return nil, nil
-				}
-				le := s.leafVariants.GetHighestPrecedence(false, false)
-				if onlyNewOrUpdated && !(le.IsNew || le.IsUpdated) {
-					return nil, nil
-				}
+			// presence containers have leafvariantes with typedValue_Empty, so check that
+			if !s.presenceExplicitlySet(onlyNewOrUpdated) {
+				return nil, nil
 			}
 			return map[string]any{}, nil
 		default:
@@ -103,15 +128,19 @@ func (s *sharedEntryAttributes) toJsonInternal(onlyNewOrUpdated bool, ietf bool)
 			result := map[string]any{}
 			for key, c := range s.filterActiveChoiceCaseChilds() {
 				prefixedKey := jsonGetIetfPrefixConditional(key, c, s, ietf)
-				js, err := c.toJsonInternal(onlyNewOrUpdated, ietf)
+				js, err := c.toJsonInternal(onlyNewOrUpdated, ietf, annotateOrigin)
 				if err != nil {
 					return nil, err
 				}
 				if js != nil {
 					result[prefixedKey] = js
+					if annotateOrigin {
+						jsonAddOriginAnnotation(result, prefixedKey, c)
+					}
 				}
 			}
-			if len(result) == 0 {
+			// a presence container whose childs are all gone still exists on its own
+			if len(result) == 0 && !s.presenceExplicitlySet(onlyNewOrUpdated) {
 				return nil, nil
 			}
 			return result, nil
@@ -125,7 +154,7 @@ func (s *sharedEntryAttributes) toJsonInternal(onlyNewOrUpdated bool, ietf bool)
 		if le == nil {
 			return nil, nil
 		}
-		v, err := le.Update.Value()
+		v, err := le.Value()
 		if err != nil {
 			return nil, err
 		}
@@ -134,6 +163,44 @@ func (s *sharedEntryAttributes) toJsonInternal(onlyNewOrUpdated bool, ietf bool)
 	return nil, fmt.Errorf("unable to convert to json (%s)", s.Path())
 }
 
+// jsonAddOriginAnnotation adds the RFC 7952 metadata annotation carrying the owner and priority
+// of the given leaf or leaflist entry to dict. Entries without an owner are not annotated.
+func jsonAddOriginAnnotation(dict map[string]any, key string, e Entry) {
+	var count int
+	switch e.GetSchema().GetSchema().(type) {
+	case *sdcpb.SchemaElem_Field:
+	case *sdcpb.SchemaElem_Leaflist:
+		// a leaflist carries an annotation per element
+		if elems, ok := dict[key].([]any); ok {
+			count = len(elems)
+		}
+	default:
+		return
+	}
+	// for a leaf this is at most its own highest precedence LeafEntry
+	les := e.GetHighestPrecedence(nil, false)
+	if len(les) == 0 || les[0].Owner() == "" {
+		return
+	}
+	annotation := map[string]any{
+		OriginOwnerAnnotation:    les[0].Owner(),
+		OriginPriorityAnnotation: les[0].Priority(),
+	}
+	if ts := les[0].TS(); ts != 0 {
+		annotation[OriginTimestampAnnotation] = ts
+	}
+	annotationKey := "@" + key
+	if count == 0 {
+		dict[annotationKey] = annotation
+		return
+	}
+	annotations := make([]any, 0, count)
+	for range count {
+		annotations = append(annotations, annotation)
+	}
+	dict[annotationKey] = annotations
+}
+
 // jsonAddIetfPrefixConditional adds the module name
 func jsonGetIetfPrefixConditional(key string, a Entry, b Entry, ietf bool) string {
 	// if not ietf, then we do not need module prefixes
@@ -553,3 +553,85 @@ func addToRoot(ctx context.Context, root *RootEntry, updates []*sdcpb.Update, is
 	}
 	return nil
 }
+
+func TestToJsonWithOrigin(t *testing.T) {
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), "owner1")
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// owner1 sets the interface, owner2 overrides the description with a higher precedence
+	// and the leaflist is only present in running
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "name"}, testhelper.GetStringTvProto(t, "ethernet-1/1"), 10, "owner1", 0),
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "description"}, testhelper.GetStringTvProto(t, "Foo"), 10, "owner1", 0),
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "description"}, testhelper.GetStringTvProto(t, "Bar"), 5, "owner2", 1700000000),
+		cache.NewUpdate([]string{"leaflist", "entry"}, testhelper.GetLeafListTvProto(t, []*sdcpb.TypedValue{
+			{Value: &sdcpb.TypedValue_StringVal{StringVal: "foo"}},
+			{Value: &sdcpb.TypedValue_StringVal{StringVal: "bar"}},
+		}), 10, "owner1", 0),
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo F"), RunningValuesPrio, "", 0),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.FinishInsertionPhase()
+
+	jsonStruct, err := root.ToJsonWithOrigin(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonStr, err := json.MarshalIndent(jsonStruct, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{
+  "interface": [
+    {
+      "@description": {
+        "sdcio:owner": "owner2",
+        "sdcio:priority": 5,
+        "sdcio:timestamp": 1700000000
+      },
+      "@name": {
+        "sdcio:owner": "owner1",
+        "sdcio:priority": 10
+      },
+      "description": "Bar",
+      "name": "ethernet-1/1"
+    }
+  ],
+  "leaflist": {
+    "@entry": [
+      {
+        "sdcio:owner": "owner1",
+        "sdcio:priority": 10
+      },
+      {
+        "sdcio:owner": "owner1",
+        "sdcio:priority": 10
+      }
+    ],
+    "entry": [
+      "foo",
+      "bar"
+    ]
+  },
+  "patterntest": "hallo F"
+}`
+	if diff := cmp.Diff(expected, string(jsonStr)); diff != "" {
+		t.Fatalf("ToJsonWithOrigin() failed.\nDiff:\n%s", diff)
+	}
+}
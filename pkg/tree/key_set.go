@@ -1,33 +1,48 @@
 package tree
 
-import "strings"
-
+// PathSet is a set of paths, stored in a PathTrie.
 type PathSet struct {
-	index map[string]struct{}
-	paths PathSlices
+	trie *PathTrie[struct{}]
 }
 
 func NewPathSet() *PathSet {
 	return &PathSet{
-		index: map[string]struct{}{},
-		paths: PathSlices{},
+		trie: NewPathTrie[struct{}](),
 	}
 }
 
 func (p *PathSet) AddPath(path []string) {
-	k := strings.Join(path, KeysIndexSep)
-	if _, exists := p.index[k]; !exists {
-		p.paths = append(p.paths, path)
-		p.index[k] = struct{}{}
-	}
+	p.trie.Set(path, struct{}{})
 }
 
 func (p *PathSet) Join(other *PathSet) {
-	for _, x := range other.paths {
-		p.AddPath(x)
-	}
+	other.trie.Walk(func(path PathSlice, _ struct{}) bool {
+		p.AddPath(path)
+		return true
+	})
+}
+
+// Contains returns true if the path is part of the set.
+func (p *PathSet) Contains(path []string) bool {
+	_, exists := p.trie.Get(path)
+	return exists
+}
+
+// HasPrefix returns true if a path of the set is located at or below the prefix.
+func (p *PathSet) HasPrefix(prefix []string) bool {
+	return p.trie.HasPrefix(prefix)
+}
+
+func (p *PathSet) Len() int {
+	return p.trie.Len()
 }
 
+// GetPaths returns the paths of the set, sorted by path.
 func (p *PathSet) GetPaths() PathSlices {
-	return p.paths
+	result := make(PathSlices, 0, p.trie.Len())
+	p.trie.Walk(func(path PathSlice, _ struct{}) bool {
+		result = append(result, path)
+		return true
+	})
+	return result
 }
@@ -5,16 +5,19 @@ import (
 	"sync"
 
 	"github.com/sdcio/data-server/pkg/cache"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 )
 
 // LeafEntry stores the *cache.Update along with additional attributes.
 // These Attributes indicate if the entry is to be deleted / added (new) or updated.
+// The validation workers read the entries concurrently, hence the update and the flags
+// are only accessed via the methods, which are guarded by the mutex.
 type LeafEntry struct {
-	*cache.Update
+	update      *cache.Update
 	parentEntry Entry
-	IsNew       bool
-	Delete      bool
-	IsUpdated   bool
+	isNew       bool
+	delete      bool
+	isUpdated   bool
 	mu          sync.RWMutex
 }
 
@@ -22,46 +25,134 @@ func (l *LeafEntry) GetEntry() Entry {
 	return l.parentEntry
 }
 
+// GetUpdate returns the *cache.Update of the entry.
+func (l *LeafEntry) GetUpdate() *cache.Update {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.update
+}
+
+// setUpdate replaces the *cache.Update of the entry, without changing the flags.
+func (l *LeafEntry) setUpdate(u *cache.Update) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.update = u
+	l.markEntryDirty()
+}
+
+// clearFlags resets the new, update and delete flags, once the entry is stored.
+func (l *LeafEntry) clearFlags() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isNew || l.isUpdated || l.delete {
+		l.isNew, l.isUpdated, l.delete = false, false, false
+		l.markEntryDirty()
+	}
+}
+
+// markEntryDirty marks the entry the leaf belongs to as modified.
+func (l *LeafEntry) markEntryDirty() {
+	if l.parentEntry != nil {
+		l.parentEntry.markDirty()
+	}
+}
+
+func (l *LeafEntry) GetPath() []string {
+	return l.GetUpdate().GetPath()
+}
+
+func (l *LeafEntry) Value() (*sdcpb.TypedValue, error) {
+	return l.GetUpdate().Value()
+}
+
+func (l *LeafEntry) Bytes() []byte {
+	return l.GetUpdate().Bytes()
+}
+
+func (l *LeafEntry) Priority() int32 {
+	return l.GetUpdate().Priority()
+}
+
+func (l *LeafEntry) Owner() string {
+	return l.GetUpdate().Owner()
+}
+
+func (l *LeafEntry) TS() int64 {
+	return l.GetUpdate().TS()
+}
+
+// EqualSkipPath reports if the value, priority and owner of the entry equal the ones of the update.
+func (l *LeafEntry) EqualSkipPath(other *cache.Update) bool {
+	return l.GetUpdate().EqualSkipPath(other)
+}
+
 // MarkUpdate indicate that the entry is an Updated value
 func (l *LeafEntry) MarkUpdate(u *cache.Update) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	// set the new value
-	l.Update = u
+	l.update = u
 	// set the update flag
-	l.IsUpdated = true
+	l.isUpdated = true
 	// reset the delete flag
-	l.Delete = false
+	l.delete = false
+	l.markEntryDirty()
+}
+
+// merge takes over the update of another entry of the same owner. An equal value just drops the delete flag,
+// since the entry is not deleted, a different value marks the entry as updated.
+func (l *LeafEntry) merge(u *cache.Update) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	changed := l.delete
+	if !l.update.EqualSkipPath(u) {
+		l.update = u
+		l.isUpdated = true
+		changed = true
+	}
+	l.delete = false
+	if changed {
+		l.markEntryDirty()
+	}
 }
 
 func (l *LeafEntry) GetDeleteFlag() bool {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
-	return l.Delete
+	return l.delete
 }
 func (l *LeafEntry) GetUpdateFlag() bool {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
-	return l.IsUpdated
+	return l.isUpdated
 }
 func (l *LeafEntry) GetNewFlag() bool {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
-	return l.IsNew
+	return l.isNew
+}
+
+// flags returns the new, update and delete flags at once, consistent with each other.
+func (l *LeafEntry) flags() (isNew, isUpdated, isDelete bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.isNew, l.isUpdated, l.delete
 }
 
 func (l *LeafEntry) DropDeleteFlag() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.Delete = false
+	l.delete = false
+	l.markEntryDirty()
 }
 
 // MarkDelete indicate that the entry is to be deleted
 func (l *LeafEntry) MarkDelete() {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	l.Delete = true
-	l.IsUpdated = false
+	l.delete = true
+	l.isUpdated = false
+	l.markEntryDirty()
 }
 
 func (l *LeafEntry) GetRootBasedEntryChain() []Entry {
@@ -70,21 +161,27 @@ func (l *LeafEntry) GetRootBasedEntryChain() []Entry {
 
 // String returns a string representation of the LeafEntry
 func (l *LeafEntry) String() string {
+	return l.stringWithValue(l.valueString())
+}
+
+func (l *LeafEntry) valueString() string {
 	tv, err := l.Value()
-	var v string
 	if err != nil {
-		v = err.Error()
-	} else {
-		v = tv.String()
+		return err.Error()
 	}
-	return fmt.Sprintf("Owner: %s, Priority: %d, Value: %s, New: %t, Delete: %t, Update: %t", l.Owner(), l.Priority(), v, l.GetNewFlag(), l.GetDeleteFlag(), l.GetUpdateFlag())
+	return tv.String()
+}
+
+func (l *LeafEntry) stringWithValue(v string) string {
+	isNew, isUpdated, isDelete := l.flags()
+	return fmt.Sprintf("Owner: %s, Priority: %d, Value: %s, New: %t, Delete: %t, Update: %t", l.Owner(), l.Priority(), v, isNew, isDelete, isUpdated)
 }
 
 // NewLeafEntry constructor for a new LeafEntry
 func NewLeafEntry(c *cache.Update, new bool, parent Entry) *LeafEntry {
 	return &LeafEntry{
 		parentEntry: parent,
-		Update:      c,
-		IsNew:       new,
+		update:      c,
+		isNew:       new,
 	}
 }
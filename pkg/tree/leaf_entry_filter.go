@@ -38,7 +38,7 @@ func Unfiltered(l *LeafEntry) bool {
 func LeafEntriesToCacheUpdates(l []*LeafEntry) []*cache.Update {
 	result := make([]*cache.Update, 0, len(l))
 	for _, e := range l {
-		result = append(result, e.Update)
+		result = append(result, e.GetUpdate())
 	}
 	return result
 }
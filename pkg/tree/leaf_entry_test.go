@@ -0,0 +1,83 @@
+package tree
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/sdcio/data-server/pkg/cache"
+)
+
+func TestLeafEntry_merge(t *testing.T) {
+	path := []string{"interface", "ethernet-1/1", "description"}
+	foo := cache.NewUpdate(path, []byte("foo"), 5, "owner1", 0)
+	bar := cache.NewUpdate(path, []byte("bar"), 5, "owner1", 0)
+
+	tests := []struct {
+		name        string
+		update      *cache.Update
+		wantUpdated bool
+		wantValue   string
+	}{
+		{
+			name:      "equal value",
+			update:    cache.NewUpdate(path, []byte("foo"), 5, "owner1", 0),
+			wantValue: "foo",
+		},
+		{
+			name:        "different value",
+			update:      bar,
+			wantUpdated: true,
+			wantValue:   "bar",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			le := NewLeafEntry(foo, false, nil)
+			le.MarkDelete()
+			le.merge(tt.update)
+
+			isNew, isUpdated, isDelete := le.flags()
+			if isNew || isDelete || isUpdated != tt.wantUpdated {
+				t.Errorf("merge() flags new %t, updated %t, delete %t, want updated %t", isNew, isUpdated, isDelete, tt.wantUpdated)
+			}
+			if got := string(le.Bytes()); got != tt.wantValue {
+				t.Errorf("merge() value %q, want %q", got, tt.wantValue)
+			}
+		})
+	}
+}
+
+// TestLeafEntry_concurrentAccess changes the state of the entry while it is read, as done by the validation workers.
+// It is meant to be run with -race.
+func TestLeafEntry_concurrentAccess(t *testing.T) {
+	path := []string{"interface", "ethernet-1/1", "description"}
+	le := NewLeafEntry(cache.NewUpdate(path, []byte("foo"), 5, "owner1", 0), false, nil)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				le.MarkDelete()
+				le.merge(cache.NewUpdate(path, []byte("bar"), 5, "owner1", 0))
+				le.DropDeleteFlag()
+				le.MarkUpdate(cache.NewUpdate(path, []byte("foo"), 5, "owner1", 0))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = le.String()
+				_ = le.GetDeleteFlag() || le.GetNewFlag() || le.GetUpdateFlag()
+				_ = le.Owner() == RunningIntentName
+				_ = le.GetUpdate().Bytes()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if le.GetDeleteFlag() || !le.GetUpdateFlag() {
+		t.Errorf("expected the final state to be updated, got %s", le.String())
+	}
+}
@@ -3,6 +3,7 @@ package tree
 import (
 	"iter"
 	"math"
+	"slices"
 	"sync"
 
 	"github.com/sdcio/data-server/pkg/utils"
@@ -23,19 +24,48 @@ func newLeafVariants(tc *TreeContext) *LeafVariants {
 
 func (lv *LeafVariants) Add(le *LeafEntry) {
 	if leafVariant := lv.GetByOwner(le.Owner()); leafVariant != nil {
-		if leafVariant.EqualSkipPath(le.Update) {
-			// it seems like the element was not deleted, so drop the delete flag
-			leafVariant.DropDeleteFlag()
-		} else {
-			// if a leafentry of the same owner exists with different value, mark it for update
-			leafVariant.MarkUpdate(le.Update)
-		}
+		// if the value is equal, the element was not deleted, so the delete flag is dropped.
+		// if a leafentry of the same owner exists with different value, it is marked for update
+		leafVariant.merge(le.GetUpdate())
 	} else {
 		lv.lesMutex.Lock()
 		defer lv.lesMutex.Unlock()
 		// if LeafVaraint with same owner does not exist, add the new entry
 		lv.les = append(lv.les, le)
+		le.markEntryDirty()
+	}
+}
+
+// set sets the entry of the owner, replacing the update of an existing entry without changing its flags.
+func (lv *LeafVariants) set(le *LeafEntry) {
+	if leafVariant := lv.GetByOwner(le.Owner()); leafVariant != nil {
+		leafVariant.setUpdate(le.GetUpdate())
+		return
+	}
+	lv.Add(le)
+}
+
+// remove removes the entries the given function returns true for and reports whether any was removed.
+func (lv *LeafVariants) remove(f func(le *LeafEntry) bool) bool {
+	lv.lesMutex.Lock()
+	defer lv.lesMutex.Unlock()
+	les := lv.les[:0]
+	var removed *LeafEntry
+	for _, le := range lv.les {
+		if f(le) {
+			removed = le
+			continue
+		}
+		les = append(les, le)
+	}
+	// drop the references held by the tail of the slice
+	clear(lv.les[len(les):])
+	lv.les = les
+	if removed == nil {
+		return false
 	}
+	removed.markEntryDirty()
+	return true
 }
 
 // Items iterator for the LeafVariants
@@ -69,7 +99,7 @@ func (lv *LeafVariants) shouldDelete() bool {
 	}
 
 	// if only running exists return false
-	if lv.les[0].Update.Owner() == RunningIntentName && len(lv.les) == 1 {
+	if lv.les[0].Owner() == RunningIntentName && len(lv.les) == 1 {
 		return false
 	}
 
@@ -77,7 +107,7 @@ func (lv *LeafVariants) shouldDelete() bool {
 	for _, l := range lv.les {
 		// if not running is set and not the owner is running then
 		// it should not be deleted
-		if !(l.GetDeleteFlag() || l.Update.Owner() == RunningIntentName) {
+		if !(l.GetDeleteFlag() || l.Owner() == RunningIntentName) {
 			return false
 		}
 	}
@@ -90,8 +120,8 @@ func (lv *LeafVariants) GetHighestPrecedenceValue() int32 {
 	defer lv.lesMutex.RUnlock()
 	result := int32(math.MaxInt32)
 	for _, e := range lv.les {
-		if !e.GetDeleteFlag() && e.Owner() != DefaultsIntentName && e.Update.Priority() < result {
-			result = e.Update.Priority()
+		if !e.GetDeleteFlag() && e.Owner() != DefaultsIntentName && e.Priority() < result {
+			result = e.Priority()
 		}
 	}
 	return result
@@ -121,19 +151,19 @@ func (lv *LeafVariants) GetHighestPrecedence(onlyNewOrUpdated bool, includeDefau
 		// on a result != nil that is then not marked for deletion
 		// start comparing priorities and choose the one with the
 		// higher prio (lower number)
-		if highest.Priority() > e.Priority() {
+		if highest.Priority() > e.Priority() || (highest.Priority() == e.Priority() && lv.winsTie(e, highest)) {
 			secondHighest = highest
 			highest = e
 		} else {
 			// check if the update is at least higher prio (lower number) then the secondHighest
-			if secondHighest == nil || secondHighest.Priority() > e.Priority() {
+			if secondHighest == nil || secondHighest.Priority() > e.Priority() || (secondHighest.Priority() == e.Priority() && lv.winsTie(e, secondHighest)) {
 				secondHighest = e
 			}
 		}
 	}
 
 	// do not include defaults loaded at validation time
-	if !includeDefaults && highest.Update.Owner() == DefaultsIntentName {
+	if !includeDefaults && highest.Owner() == DefaultsIntentName {
 		return nil
 	}
 
@@ -145,13 +175,13 @@ func (lv *LeafVariants) GetHighestPrecedence(onlyNewOrUpdated bool, includeDefau
 
 	// if the highes is not marked for deletion and new or updated (=PrioChanged) return it
 	if !highest.GetDeleteFlag() {
-		if highest.GetNewFlag() || highest.GetUpdateFlag() || (lv.tc.actualOwner != "" && highest.Update.Owner() == lv.tc.actualOwner && lv.highestNotRunning(highest)) {
+		if highest.GetNewFlag() || highest.GetUpdateFlag() || (lv.tc.isActualOwner(highest.Owner()) && lv.highestNotRunning(highest)) {
 			return highest
 		}
 		return nil
 	}
 	// otherwise if the secondhighest is not marked for deletion return it
-	if secondHighest != nil && !secondHighest.GetDeleteFlag() && secondHighest.Update.Owner() != RunningIntentName {
+	if secondHighest != nil && !secondHighest.GetDeleteFlag() && secondHighest.Owner() != RunningIntentName {
 		return secondHighest
 	}
 
@@ -159,9 +189,95 @@ func (lv *LeafVariants) GetHighestPrecedence(onlyNewOrUpdated bool, includeDefau
 	return nil
 }
 
+// winsTie returns true if a takes precedence over b, given both have the same priority.
+// If exactly one of them belongs to an actual owner, the decision is taken based on the conflict policy, values
+// of the actual owner win with the last-wins policy and lose otherwise.
+// All other ties are broken deterministically by the lexically lower owner, then by the older timestamp.
+// With the newest tie-break, the most recently modified value is preferred over the lexically lower owner.
+func (lv *LeafVariants) winsTie(a, b *LeafEntry) bool {
+	if lv.tc != nil {
+		aActual, bActual := lv.tc.isActualOwner(a.Owner()), lv.tc.isActualOwner(b.Owner())
+		if aActual != bActual {
+			if lv.tc.conflictPolicy == ConflictPolicyLastWins {
+				return aActual
+			}
+			return bActual
+		}
+	}
+	if lv.tc != nil && lv.tc.tieBreak == TieBreakNewest && a.TS() != b.TS() {
+		return a.TS() > b.TS()
+	}
+	if a.Owner() != b.Owner() {
+		return a.Owner() < b.Owner()
+	}
+	return a.TS() < b.TS()
+}
+
+// precedenceConflict returns the highest precedence entry along with the entries that have the same
+// priority but a different value and therefore lost the tie-break.
+// Entries marked for deletion as well as running and default values are not considered.
+func (lv *LeafVariants) precedenceConflict() (winner *LeafEntry, losers []*LeafEntry) {
+	lv.lesMutex.RLock()
+	defer lv.lesMutex.RUnlock()
+
+	candidates := make([]*LeafEntry, 0, len(lv.les))
+	for _, e := range lv.les {
+		if e.GetDeleteFlag() || e.Owner() == RunningIntentName || e.Owner() == DefaultsIntentName {
+			continue
+		}
+		if winner == nil || winner.Priority() > e.Priority() || (winner.Priority() == e.Priority() && lv.winsTie(e, winner)) {
+			winner = e
+		}
+		candidates = append(candidates, e)
+	}
+	if winner == nil {
+		return nil, nil
+	}
+
+	winnerVal, err := winner.Value()
+	if err != nil {
+		return nil, nil
+	}
+	for _, e := range candidates {
+		if e == winner || e.Priority() != winner.Priority() {
+			continue
+		}
+		if val, err := e.Value(); err == nil && utils.EqualTypedValues(val, winnerVal) {
+			continue
+		}
+		losers = append(losers, e)
+	}
+	slices.SortFunc(losers, func(a, b *LeafEntry) int {
+		switch {
+		case lv.winsTie(a, b):
+			return -1
+		case lv.winsTie(b, a):
+			return 1
+		}
+		return 0
+	})
+	return winner, losers
+}
+
+// precedesAll returns true if the given entry takes precedence over all the other entries,
+// regardless of them being marked for deletion. Running and default values are not considered.
+func (lv *LeafVariants) precedesAll(le *LeafEntry) bool {
+	lv.lesMutex.RLock()
+	defer lv.lesMutex.RUnlock()
+	for _, e := range lv.les {
+		if e == le || e.Owner() == RunningIntentName || e.Owner() == DefaultsIntentName {
+			continue
+		}
+		if e.Priority() < le.Priority() || (e.Priority() == le.Priority() && lv.winsTie(e, le)) {
+			return false
+		}
+	}
+	return true
+}
+
 func (lv *LeafVariants) highestNotRunning(highest *LeafEntry) bool {
 	// if highes is already running or even default, return false
-	if highest.Update.Owner() == RunningIntentName {
+	if highest.Owner() == RunningIntentName {
 		return false
 	}
 
@@ -0,0 +1,133 @@
+package tree
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// LeafrefReference describes a leafref that points to a certain entry.
+type LeafrefReference struct {
+	// Path of the referencing leafref
+	Path PathSlice
+	// Owner is the intent that provides the referencing value
+	Owner string
+	// Priority of the referencing value
+	Priority int32
+}
+
+// leafrefIndex is the reverse leafref index, it maps the paths of referenced
+// entries to the leafrefs pointing to them.
+type leafrefIndex struct {
+	refs  map[string]*leafrefTarget // target path -> referenced entry
+	mutex sync.RWMutex
+}
+
+// leafrefTarget is a referenced entry with the references pointing to it.
+type leafrefTarget struct {
+	path PathSlice
+	refs map[string]*LeafrefReference // referencing path -> reference
+}
+
+func newLeafrefIndex() *leafrefIndex {
+	return &leafrefIndex{
+		refs: map[string]*leafrefTarget{},
+	}
+}
+
+// add registers the reference pointing to the target path.
+func (l *leafrefIndex) add(target PathSlice, ref *LeafrefReference) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	targetKey := PathKey(target)
+	t, exists := l.refs[targetKey]
+	if !exists {
+		t = &leafrefTarget{path: target, refs: map[string]*LeafrefReference{}}
+		l.refs[targetKey] = t
+	}
+	t.refs[PathKey(ref.Path)] = ref
+}
+
+// get returns the references pointing to the target path, sorted by the referencing path.
+func (l *leafrefIndex) get(target PathSlice) []*LeafrefReference {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	t, exists := l.refs[PathKey(target)]
+	if !exists {
+		return []*LeafrefReference{}
+	}
+	result := make([]*LeafrefReference, 0, len(t.refs))
+	for _, r := range t.refs {
+		result = append(result, r)
+	}
+	slices.SortFunc(result, func(a, b *LeafrefReference) int {
+		return strings.Compare(a.Path.String(), b.Path.String())
+	})
+	return result
+}
+
+// orderDeletes orders the deletes such that deletes of branches containing leafrefs are placed before the
+// deletes of the branches they reference. Hence the referrers are removed from the device before the referents.
+// Apart from that the original order is retained, deletes that reference each other keep their original order.
+func (l *leafrefIndex) orderDeletes(deletes []DeleteEntry) []DeleteEntry {
+	indexOf := func(p PathSlice) int {
+		return slices.IndexFunc(deletes, func(d DeleteEntry) bool { return p.HasPrefix(d.Path()) })
+	}
+
+	// before[i] holds the indexes of the deletes that need to be performed before delete i
+	before := make([]map[int]struct{}, len(deletes))
+	l.mutex.RLock()
+	for _, t := range l.refs {
+		referent := indexOf(t.path)
+		if referent < 0 {
+			continue
+		}
+		for _, r := range t.refs {
+			referrer := indexOf(r.Path)
+			if referrer < 0 || referrer == referent {
+				continue
+			}
+			if before[referent] == nil {
+				before[referent] = map[int]struct{}{}
+			}
+			before[referent][referrer] = struct{}{}
+		}
+	}
+	l.mutex.RUnlock()
+
+	result := make([]DeleteEntry, 0, len(deletes))
+	done := make([]bool, len(deletes))
+	for len(result) < len(deletes) {
+		progress := false
+		for i, d := range deletes {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for j := range before[i] {
+				if !done[j] {
+					ready = false
+					break
+				}
+			}
+			if !ready {
+				continue
+			}
+			result = append(result, d)
+			done[i] = true
+			progress = true
+			// restart, such that earlier deletes that became ready retain their position
+			break
+		}
+		if !progress {
+			// circular references, append the rest in the original order
+			for i, d := range deletes {
+				if !done[i] {
+					result = append(result, d)
+					done[i] = true
+				}
+			}
+		}
+	}
+	return result
+}
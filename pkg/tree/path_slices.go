@@ -1,6 +1,7 @@
 package tree
 
 import (
+	"slices"
 	"strings"
 )
 
@@ -15,6 +16,11 @@ func (p PathSlice) ToStringSlice() []string {
 	return p
 }
 
+// HasPrefix returns true if p equals or is located below the given prefix path.
+func (p PathSlice) HasPrefix(prefix PathSlice) bool {
+	return len(p) >= len(prefix) && slices.Equal(p[:len(prefix)], prefix)
+}
+
 // PathSlices is the slice collection of multiple PathSlice objects.
 type PathSlices []PathSlice
 
@@ -33,3 +39,34 @@ func (p PathSlices) ToStringSlice() [][]string {
 	}
 	return result
 }
+
+// Compact returns the paths that are not located below another path of the slice, the prefixes
+// that cover all the paths of the slice.
+func (p PathSlices) Compact() PathSlices {
+	sorted := slices.Clone(p)
+	// prefixes sort before the paths below them
+	slices.SortFunc(sorted, func(a, b PathSlice) int { return slices.Compare(a, b) })
+	result := make(PathSlices, 0, len(sorted))
+	for _, x := range sorted {
+		if len(result) > 0 && x.HasPrefix(result[len(result)-1]) {
+			continue
+		}
+		result = append(result, x)
+	}
+	return result
+}
+
+var pathKeyEscaper = strings.NewReplacer(`\`, `\\`, KeysIndexSep, `\`+KeysIndexSep)
+
+// PathKey returns the key of the path in the indexes, the elements joined by KeysIndexSep. Elements,
+// e.g. key values, containing KeysIndexSep are escaped, such that distinct paths never share a key.
+func PathKey(path []string) string {
+	if !slices.ContainsFunc(path, func(e string) bool { return strings.ContainsAny(e, `\`+KeysIndexSep) }) {
+		return strings.Join(path, KeysIndexSep)
+	}
+	escaped := make([]string, 0, len(path))
+	for _, e := range path {
+		escaped = append(escaped, pathKeyEscaper.Replace(e))
+	}
+	return strings.Join(escaped, KeysIndexSep)
+}
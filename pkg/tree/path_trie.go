@@ -0,0 +1,198 @@
+package tree
+
+import (
+	"maps"
+	"slices"
+)
+
+// PathWildcard is the path element that matches any element in the prefixes and patterns of a PathTrie.
+const PathWildcard = "*"
+
+// PathTrie stores values by path. Paths sharing a prefix share the nodes of the prefix, which keeps the
+// memory footprint of millions of paths low and allows prefix queries without scanning all the paths.
+type PathTrie[T any] struct {
+	root *trieNode[T]
+	size int
+}
+
+type trieNode[T any] struct {
+	children map[string]*trieNode[T]
+	value    T
+	set      bool
+}
+
+// NewPathTrie returns an empty PathTrie.
+func NewPathTrie[T any]() *PathTrie[T] {
+	return &PathTrie[T]{root: &trieNode[T]{}}
+}
+
+// Len returns the number of paths with a value.
+func (t *PathTrie[T]) Len() int {
+	if t == nil {
+		return 0
+	}
+	return t.size
+}
+
+// Set sets the value of the path.
+func (t *PathTrie[T]) Set(path []string, v T) {
+	n := t.root
+	for _, e := range path {
+		c, exists := n.children[e]
+		if !exists {
+			if n.children == nil {
+				n.children = map[string]*trieNode[T]{}
+			}
+			c = &trieNode[T]{}
+			n.children[e] = c
+		}
+		n = c
+	}
+	if !n.set {
+		t.size++
+	}
+	n.value = v
+	n.set = true
+}
+
+// Get returns the value of the path and whether the path has a value.
+func (t *PathTrie[T]) Get(path []string) (T, bool) {
+	var zero T
+	if t == nil {
+		return zero, false
+	}
+	n := t.root.find(path)
+	if n == nil || !n.set {
+		return zero, false
+	}
+	return n.value, true
+}
+
+// Delete removes the value of the path, returning whether the path had a value.
+func (t *PathTrie[T]) Delete(path []string) bool {
+	if t == nil || !t.root.delete(path) {
+		return false
+	}
+	t.size--
+	return true
+}
+
+// HasPrefix returns true if a value exists at or below the prefix.
+func (t *PathTrie[T]) HasPrefix(prefix []string) bool {
+	found := false
+	t.WalkPrefix(prefix, func(PathSlice, T) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+// Walk calls f for all the paths with a value, sorted by path, until f returns false.
+func (t *PathTrie[T]) Walk(f func(path PathSlice, v T) bool) {
+	t.WalkPrefix(nil, f)
+}
+
+// WalkPrefix calls f for the paths with a value at or below the prefix, sorted by path, until f returns false.
+// Wildcard elements of the prefix match any element.
+func (t *PathTrie[T]) WalkPrefix(prefix []string, f func(path PathSlice, v T) bool) {
+	if t == nil {
+		return
+	}
+	t.root.match(make(PathSlice, 0, len(prefix)), prefix, true, f)
+}
+
+// Expand returns the paths with a value matching the pattern, whose wildcard elements match any element.
+// Other than for WalkPrefix, the paths below the matching paths are not included.
+func (t *PathTrie[T]) Expand(pattern []string) PathSlices {
+	result := PathSlices{}
+	if t == nil {
+		return result
+	}
+	t.root.match(make(PathSlice, 0, len(pattern)), pattern, false, func(p PathSlice, _ T) bool {
+		result = append(result, p)
+		return true
+	})
+	return result
+}
+
+// Children returns the elements of the paths below the prefix.
+func (t *PathTrie[T]) Children(prefix []string) []string {
+	if t == nil {
+		return nil
+	}
+	n := t.root.find(prefix)
+	if n == nil {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(n.children))
+}
+
+func (n *trieNode[T]) find(path []string) *trieNode[T] {
+	for _, e := range path {
+		n = n.children[e]
+		if n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+func (n *trieNode[T]) delete(path []string) bool {
+	if len(path) == 0 {
+		if !n.set {
+			return false
+		}
+		var zero T
+		n.value = zero
+		n.set = false
+		return true
+	}
+	c := n.children[path[0]]
+	if c == nil || !c.delete(path[1:]) {
+		return false
+	}
+	// drop the nodes that no longer lead to a value
+	if !c.set && len(c.children) == 0 {
+		delete(n.children, path[0])
+	}
+	return true
+}
+
+// match calls f for the values matching the pattern, including the values below the matches if subtree is set.
+// It returns false once f returned false.
+func (n *trieNode[T]) match(path PathSlice, pattern []string, subtree bool, f func(PathSlice, T) bool) bool {
+	if len(pattern) == 0 {
+		if subtree {
+			return n.walk(path, f)
+		}
+		if n.set {
+			return f(slices.Clone(path), n.value)
+		}
+		return true
+	}
+	if pattern[0] != PathWildcard {
+		c := n.children[pattern[0]]
+		if c == nil {
+			return true
+		}
+		return c.match(append(path, pattern[0]), pattern[1:], subtree, f)
+	}
+	for _, e := range slices.Sorted(maps.Keys(n.children)) {
+		if !n.children[e].match(append(path, e), pattern[1:], subtree, f) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n *trieNode[T]) walk(path PathSlice, f func(PathSlice, T) bool) bool {
+	if n.set && !f(slices.Clone(path), n.value) {
+		return false
+	}
+	for _, e := range slices.Sorted(maps.Keys(n.children)) {
+		if !n.children[e].walk(append(path, e), f) {
+			return false
+		}
+	}
+	return true
+}
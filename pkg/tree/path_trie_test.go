@@ -0,0 +1,66 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPathTrie(t *testing.T) {
+	trie := NewPathTrie[int]()
+	for i, p := range []PathSlice{
+		{"interface", "ethernet-1/1", "description"},
+		{"interface", "ethernet-1/1", "admin-state"},
+		{"interface", "ethernet-1/2", "description"},
+		{"interfaces"},
+		{"network-instance", "default", "type"},
+	} {
+		trie.Set(p, i)
+	}
+	trie.Set(PathSlice{"interfaces"}, 10)
+
+	if trie.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", trie.Len())
+	}
+	if v, ok := trie.Get(PathSlice{"interfaces"}); !ok || v != 10 {
+		t.Errorf("Get() = %d, %t, want 10, true", v, ok)
+	}
+	if _, ok := trie.Get(PathSlice{"interface", "ethernet-1/1"}); ok {
+		t.Errorf("Get() of a path without value must not succeed")
+	}
+
+	prefixed := []string{}
+	trie.WalkPrefix(PathSlice{"interface"}, func(p PathSlice, _ int) bool {
+		prefixed = append(prefixed, p.String())
+		return true
+	})
+	want := []string{"interface/ethernet-1/1/admin-state", "interface/ethernet-1/1/description", "interface/ethernet-1/2/description"}
+	if diff := cmp.Diff(want, prefixed); diff != "" {
+		t.Errorf("WalkPrefix() mismatch (-want +got):\n%s", diff)
+	}
+
+	expanded := trie.Expand(PathSlice{"interface", PathWildcard, "description"}).StringSlice()
+	want = []string{"interface/ethernet-1/1/description", "interface/ethernet-1/2/description"}
+	if diff := cmp.Diff(want, expanded); diff != "" {
+		t.Errorf("Expand() mismatch (-want +got):\n%s", diff)
+	}
+
+	if !trie.Delete(PathSlice{"network-instance", "default", "type"}) {
+		t.Errorf("Delete() of an existing path must succeed")
+	}
+	if trie.HasPrefix(PathSlice{"network-instance"}) {
+		t.Errorf("HasPrefix() must not find the deleted path")
+	}
+	if diff := cmp.Diff([]string{"interface", "interfaces"}, trie.Children(nil)); diff != "" {
+		t.Errorf("Children() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPathKey(t *testing.T) {
+	if PathKey([]string{"a_b", "c"}) == PathKey([]string{"a", "b_c"}) {
+		t.Errorf("PathKey() must differ for paths whose elements contain the separator")
+	}
+	if got := PathKey([]string{"interface", "ethernet-1/1"}); got != "interface_ethernet-1/1" {
+		t.Errorf("PathKey() = %s, want interface_ethernet-1/1", got)
+	}
+}
@@ -0,0 +1,126 @@
+package tree
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// ConflictPolicy defines how two intents that write the same path with the same priority are handled.
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyReject rejects the intent that introduces the conflict
+	ConflictPolicyReject ConflictPolicy = "reject"
+	// ConflictPolicyFirstWins keeps the value of the already existing intent
+	ConflictPolicyFirstWins ConflictPolicy = "first-wins"
+	// ConflictPolicyLastWins applies the value of the intent that is being set
+	ConflictPolicyLastWins ConflictPolicy = "last-wins"
+)
+
+// TieBreak defines how ties between values with the same priority are broken,
+// that are not decided by the ConflictPolicy.
+type TieBreak string
+
+const (
+	// TieBreakOwner prefers the lexically lower owner
+	TieBreakOwner TieBreak = "owner"
+	// TieBreakNewest prefers the most recently modified value
+	TieBreakNewest TieBreak = "newest"
+)
+
+// PriorityConflict describes two intents that write different values to the same path with the same priority.
+type PriorityConflict struct {
+	Path             PathSlice
+	Priority         int32
+	Owner            string
+	ConflictingOwner string
+}
+
+func (p *PriorityConflict) String() string {
+	return fmt.Sprintf("%s: intent %q and intent %q both set a value with priority %d", p.Path.String(), p.Owner, p.ConflictingOwner, p.Priority)
+}
+
+// GetPriorityConflicts returns the paths where the given owner and another intent set different
+// values with the same priority. Entries marked for deletion are not considered.
+func (r *RootEntry) GetPriorityConflicts(owner string) []*PriorityConflict {
+	result := []*PriorityConflict{}
+	// the visitor does not return errors
+	_ = r.Walk(func(s *sharedEntryAttributes) error {
+		ownerLe := s.leafVariants.GetByOwner(owner)
+		if ownerLe == nil || ownerLe.GetDeleteFlag() {
+			return nil
+		}
+		ownerVal, err := ownerLe.Value()
+		if err != nil {
+			return nil
+		}
+		for le := range s.leafVariants.Items() {
+			if le == ownerLe || le.GetDeleteFlag() || le.Priority() != ownerLe.Priority() {
+				continue
+			}
+			val, err := le.Value()
+			if err == nil && utils.EqualTypedValues(val, ownerVal) {
+				continue
+			}
+			result = append(result, &PriorityConflict{
+				Path:             s.Path(),
+				Priority:         le.Priority(),
+				Owner:            owner,
+				ConflictingOwner: le.Owner(),
+			})
+		}
+		return nil
+	})
+	slices.SortFunc(result, func(a, b *PriorityConflict) int {
+		if c := strings.Compare(a.Path.String(), b.Path.String()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ConflictingOwner, b.ConflictingOwner)
+	})
+	return result
+}
+
+// PrecedenceConflict describes a path where values with the same priority compete for precedence.
+type PrecedenceConflict struct {
+	Path     PathSlice
+	Priority int32
+	// Winner is the entry that takes precedence after tie-breaking
+	Winner *LeafEntry
+	// Losers are the entries with the same priority but a different value, that lost the tie-break
+	Losers []*LeafEntry
+}
+
+func (p *PrecedenceConflict) String() string {
+	losers := make([]string, 0, len(p.Losers))
+	for _, l := range p.Losers {
+		losers = append(losers, fmt.Sprintf("%q", l.Owner()))
+	}
+	return fmt.Sprintf("%s: intent %q takes precedence over %s with priority %d", p.Path.String(), p.Winner.Owner(), strings.Join(losers, ", "), p.Priority)
+}
+
+// GetPrecedenceConflicts returns all the paths where the highest precedence value was chosen
+// via tie-breaking, along with the entries that lost.
+func (r *RootEntry) GetPrecedenceConflicts() []*PrecedenceConflict {
+	result := []*PrecedenceConflict{}
+	// the visitor does not return errors
+	_ = r.Walk(func(s *sharedEntryAttributes) error {
+		winner, losers := s.leafVariants.precedenceConflict()
+		if len(losers) == 0 {
+			return nil
+		}
+		result = append(result, &PrecedenceConflict{
+			Path:     s.Path(),
+			Priority: winner.Priority(),
+			Winner:   winner,
+			Losers:   losers,
+		})
+		return nil
+	})
+	slices.SortFunc(result, func(a, b *PrecedenceConflict) int {
+		return strings.Compare(a.Path.String(), b.Path.String())
+	})
+	return result
+}
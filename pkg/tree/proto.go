@@ -2,6 +2,9 @@ package tree
 
 import (
 	"context"
+	"encoding/json"
+	"slices"
+	"strings"
 
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 )
@@ -31,7 +34,7 @@ func (r *RootEntry) ToProtoUpdates(ctx context.Context, onlyNewOrUpdated bool) (
 
 func (r *RootEntry) ToProtoDeletes(ctx context.Context) ([]*sdcpb.Path, error) {
 
-	cacheDeletes, err := r.GetDeletes(true)
+	cacheDeletes, err := r.GetDeletes(r.treeContext.GetDeleteAggregation())
 	if err != nil {
 		return nil, err
 	}
@@ -49,3 +52,60 @@ func (r *RootEntry) ToProtoDeletes(ctx context.Context) ([]*sdcpb.Path, error) {
 
 	return deletes, nil
 }
+
+// ToProtoBranchReplaces returns the changed top level branches of the tree, list instances are considered
+// separate branches. Branches that remain to exist are returned as updates that carry the complete JSON or
+// JSON_IETF content of the branch and are meant to be applied as replace. Branches that are removed as a whole
+// are returned as deletes.
+func (r *RootEntry) ToProtoBranchReplaces(ctx context.Context, ietf bool) ([]*sdcpb.Update, []*sdcpb.Path, error) {
+	branches := []Entry{}
+	for _, c := range r.filterActiveChoiceCaseChilds() {
+		if len(c.GetSchemaKeys()) == 0 {
+			branches = append(branches, c)
+			continue
+		}
+		instances, err := c.FilterChilds(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		branches = append(branches, instances...)
+	}
+	slices.SortFunc(branches, func(a, b Entry) int {
+		return strings.Compare(a.Path().String(), b.Path().String())
+	})
+
+	replaces := []*sdcpb.Update{}
+	deletes := []*sdcpb.Path{}
+	for _, b := range branches {
+		branchDeletes, err := b.GetDeletes([]DeleteEntry{}, DeleteAggregationInstance)
+		if err != nil {
+			return nil, nil, err
+		}
+		// skip the branches without any changes
+		if len(branchDeletes) == 0 && len(b.GetHighestPrecedence(LeafVariantSlice{}, true)) == 0 {
+			continue
+		}
+		path, err := b.SdcpbPath()
+		if err != nil {
+			return nil, nil, err
+		}
+		if !b.remainsToExist() {
+			deletes = append(deletes, path)
+			continue
+		}
+		j, err := b.toJsonInternal(false, ietf, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		jBytes, err := json.Marshal(j)
+		if err != nil {
+			return nil, nil, err
+		}
+		tv := &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: jBytes}}
+		if ietf {
+			tv = &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonIetfVal{JsonIetfVal: jBytes}}
+		}
+		replaces = append(replaces, &sdcpb.Update{Path: path, Value: tv})
+	}
+	return replaces, deletes, nil
+}
@@ -0,0 +1,57 @@
+package tree
+
+import (
+	"fmt"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// RedactedValue replaces the sensitive values in the string representation and the debug information of the tree.
+const RedactedValue = "<redacted>"
+
+// Redaction defines how the leaf values are rendered by the string representation and the debug information of the tree.
+type Redaction struct {
+	// Sensitive returns true if the value of the path must not be rendered
+	Sensitive func(path []string) bool
+	// MaxValueLen truncates the rendered values that are longer, e.g. certificates or banners. 0 disables the truncation.
+	MaxValueLen int
+}
+
+func (r *Redaction) isSensitive(p PathSlice) bool {
+	return r != nil && r.Sensitive != nil && r.Sensitive(p)
+}
+
+// leafEntryString returns the string representation of the LeafEntry of the given path.
+func (r *Redaction) leafEntryString(p PathSlice, l *LeafEntry) string {
+	switch {
+	case r.isSensitive(p):
+		return l.stringWithValue(RedactedValue)
+	case r != nil && r.MaxValueLen > 0:
+		return l.stringWithValue(TruncateValue(l.valueString(), r.MaxValueLen))
+	}
+	return l.String()
+}
+
+// redactLeafEntry returns a detached copy of the LeafEntry with the value redacted, if it is sensitive.
+func (r *Redaction) redactLeafEntry(p PathSlice, l *LeafEntry) *LeafEntry {
+	if l == nil || !r.isSensitive(p) {
+		return l
+	}
+	b, _ := proto.Marshal(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: RedactedValue}})
+	u := l.GetUpdate()
+	isNew, isUpdated, isDelete := l.flags()
+	result := NewLeafEntry(cache.NewUpdate(u.GetPath(), b, u.Priority(), u.Owner(), u.TS()), isNew, l.parentEntry)
+	result.delete = isDelete
+	result.isUpdated = isUpdated
+	return result
+}
+
+// TruncateValue truncates values that are longer than maxLen, noting the number of truncated bytes.
+func TruncateValue(v string, maxLen int) string {
+	if maxLen <= 0 || len(v) <= maxLen {
+		return v
+	}
+	return fmt.Sprintf("%s... (%d bytes truncated)", v[:maxLen], len(v)-maxLen)
+}
@@ -0,0 +1,112 @@
+package tree
+
+import (
+	"context"
+
+	"github.com/sdcio/data-server/pkg/cache"
+)
+
+// ReplaceSubtrees makes the given owner the exclusive owner of the given subtrees.
+// Values below the subtrees that are not set by the owner or by an intent with at least the
+// same precedence, hence values of intents with lower precedence and values that only exist
+// in running, are marked for deletion. The values of the other intents remain in the intended store,
+// they are only removed from the device.
+func (r *RootEntry) ReplaceSubtrees(ctx context.Context, owner string, priority int32, subtrees PathSlices) error {
+	for _, p := range subtrees {
+		e, err := r.Navigate(ctx, p, true)
+		if err != nil {
+			// nothing exists below the subtree
+			continue
+		}
+		err = e.Walk(func(s *sharedEntryAttributes) error {
+			s.replaceLeafVariants(owner, priority)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceLeafVariants marks the leaf variants of the entry for deletion, unless the owner or an intent
+// with at least the same precedence sets the value.
+func (s *sharedEntryAttributes) replaceLeafVariants(owner string, priority int32) {
+	if s.leafVariants.Length() == 0 {
+		return
+	}
+	if le := s.leafVariants.GetByOwner(owner); le != nil && !le.GetDeleteFlag() {
+		return
+	}
+
+	// the intended store index also covers intents that are not loaded into the tree
+	for _, u := range s.treeContext.IntendedStoreIndex.Get(s.Path()) {
+		if u.Owner() != owner && u.Priority() <= priority {
+			return
+		}
+	}
+
+	var running *LeafEntry
+	for le := range s.leafVariants.Items() {
+		switch {
+		case le.Owner() == RunningIntentName:
+			running = le
+		case le.Owner() == DefaultsIntentName, le.Owner() == owner, le.GetDeleteFlag():
+		case le.Priority() <= priority:
+			return
+		}
+	}
+	for le := range s.leafVariants.Items() {
+		if le.Owner() != RunningIntentName && le.Owner() != DefaultsIntentName {
+			le.MarkDelete()
+		}
+	}
+
+	// a value that only exists in running requires a deleted entry of the owner to be removed from the device
+	if running != nil && s.leafVariants.GetByOwner(owner) == nil {
+		le := NewLeafEntry(cache.NewUpdate(running.GetPath(), running.Bytes(), priority, owner, 0), false, s)
+		le.MarkDelete()
+		s.leafVariants.Add(le)
+	}
+}
+
+// DeleteUnownedRunning marks the running values below the given subtrees for deletion that are not set by
+// any intent of the tree, e.g. the values removed by intents whose changes were not yet pushed to the device.
+// The deletes are carried by deleted entries of the given owner.
+func (r *RootEntry) DeleteUnownedRunning(ctx context.Context, owner string, subtrees PathSlices) error {
+	for _, p := range subtrees {
+		e, err := r.Navigate(ctx, p, true)
+		if err != nil {
+			// nothing exists below the subtree
+			continue
+		}
+		err = e.Walk(func(s *sharedEntryAttributes) error {
+			s.deleteUnownedRunning(owner)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteUnownedRunning adds a deleted entry of the owner if the entry only carries a running value.
+func (s *sharedEntryAttributes) deleteUnownedRunning(owner string) {
+	var running *LeafEntry
+	for le := range s.leafVariants.Items() {
+		switch {
+		case le.Owner() == RunningIntentName:
+			running = le
+		case le.Owner() == DefaultsIntentName, le.GetDeleteFlag():
+		default:
+			return
+		}
+	}
+	if running == nil {
+		return
+	}
+	le := NewLeafEntry(cache.NewUpdate(running.GetPath(), running.Bytes(), running.Priority(), owner, 0), false, s)
+	le.MarkDelete()
+	s.leafVariants.Add(le)
+}
@@ -2,8 +2,11 @@ package tree
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/sdcio/data-server/pkg/utils"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 )
 
@@ -32,23 +35,32 @@ func NewTreeRoot(ctx context.Context, tc *TreeContext) (*RootEntry, error) {
 }
 
 func (r *RootEntry) LoadIntendedStoreOwnerData(ctx context.Context, owner string, pathKeySet *PathSet) {
-	tc := r.getTreeContext()
-	ownerPaths := tc.GetPathsOfOwner(owner)
+	r.LoadIntendedStoreOwnersData(ctx, []string{owner}, pathKeySet)
+}
 
-	// add the given paths as well
-	ownerPaths.Join(pathKeySet)
+// LoadIntendedStoreOwnersData loads the entries of the given owners / intents along with the entries
+// that take over once they are removed, and marks the entries of the owners as deleted.
+// The entries of the given paths, e.g. the paths the new versions of the intents set, are loaded as well.
+func (r *RootEntry) LoadIntendedStoreOwnersData(ctx context.Context, owners []string, pathKeySet *PathSet) {
+	tc := r.getTreeContext()
+	ownerPaths := NewPathSet()
+	for _, owner := range owners {
+		ownerPaths.Join(tc.GetPathsOfOwner(owner))
+	}
+	if pathKeySet != nil {
+		ownerPaths.Join(pathKeySet)
+	}
 
-	// Get all entries of the already existing intent
-	highesCurrentCacheEntries := tc.ReadCurrentUpdatesHighestPriorities(ctx, ownerPaths.GetPaths(), 2)
+	// all the owners might be set on the same path, read one more entry to get the one taking over
+	highesCurrentCacheEntries := tc.ReadCurrentUpdatesHighestPriorities(ctx, ownerPaths.GetPaths(), uint64(len(owners)+1))
 
-	// add all the existing entries
 	for _, entry := range highesCurrentCacheEntries {
 		r.AddCacheUpdateRecursive(ctx, entry, false)
 	}
 
-	// Mark all the entries that belong to the owner / intent as deleted.
-	// This is to allow for intent updates. We mark all existing entries for deletion up front.
-	r.markOwnerDelete(owner)
+	for _, owner := range owners {
+		r.markOwnerDelete(owner)
+	}
 }
 
 // String returns the string representation of the Tree.
@@ -66,6 +78,12 @@ func (r *RootEntry) GetUpdatesForOwner(owner string) UpdateSlice {
 	return LeafEntriesToCacheUpdates(r.getByOwnerFiltered(owner, FilterNonDeletedButNewOrUpdated))
 }
 
+// GetIntendedForOwner returns all the updates of the given intent / owner that remain
+// in the tree, including the unchanged ones.
+func (r *RootEntry) GetIntendedForOwner(owner string) UpdateSlice {
+	return LeafEntriesToCacheUpdates(r.getByOwnerFiltered(owner, FilterNonDeleted))
+}
+
 // GetDeletesForOwner returns the deletes that have been calculated for the given intent / owner
 func (r *RootEntry) GetDeletesForOwner(owner string) PathSlices {
 	// retrieve all entries from the tree that belong to the given user
@@ -89,10 +107,125 @@ func (r *RootEntry) GetHighestPrecedence(onlyNewOrUpdated bool) LeafVariantSlice
 	return r.sharedEntryAttributes.GetHighestPrecedence(make(LeafVariantSlice, 0), onlyNewOrUpdated)
 }
 
+// GetUpdatesDivergingFromRunning returns the new or updated highest precedence entries of the tree,
+// skipping those whose value is already present on the device (running).
+// This avoids no-op writes towards the device when intents are re-applied unchanged.
+func (r *RootEntry) GetUpdatesDivergingFromRunning() LeafVariantSlice {
+	return r.sharedEntryAttributes.getUpdatesDivergingFromRunning(make(LeafVariantSlice, 0))
+}
+
 // GetDeletes returns the paths that due to the Tree content are to be deleted from the southbound device.
-func (r *RootEntry) GetDeletes(aggregatePaths bool) ([]DeleteEntry, error) {
-	deletes := []DeleteEntry{}
-	return r.sharedEntryAttributes.GetDeletes(deletes, aggregatePaths)
+// Branches that hold leafrefs are deleted before the branches they reference, given the tree was validated
+// and the reverse leafref index is populated.
+func (r *RootEntry) GetDeletes(aggregation DeleteAggregation) ([]DeleteEntry, error) {
+	deletes, err := r.sharedEntryAttributes.GetDeletes([]DeleteEntry{}, aggregation)
+	if err != nil {
+		return nil, err
+	}
+	return r.treeContext.leafrefIndex.orderDeletes(deletes), nil
+}
+
+// ShadowedEntry is a value of an intent that is overridden by a different value with higher precedence.
+type ShadowedEntry struct {
+	Path PathSlice
+	// Shadowed is the LeafEntry of the requested owner
+	Shadowed *LeafEntry
+	// ShadowedBy is the LeafEntry that takes precedence
+	ShadowedBy *LeafEntry
+}
+
+func (s *ShadowedEntry) String() string {
+	return fmt.Sprintf("%s: value of intent %q (priority %d) is shadowed by intent %q (priority %d)", s.Path.String(), s.Shadowed.Owner(), s.Shadowed.Priority(), s.ShadowedBy.Owner(), s.ShadowedBy.Priority())
+}
+
+// GetShadowed returns the leafs of the given owner, that are overridden by a different value of another intent
+// with higher precedence. Hence these values of the intent are not present on the device.
+func (r *RootEntry) GetShadowed(owner string) []*ShadowedEntry {
+	result := []*ShadowedEntry{}
+	// the visitor does not return errors
+	_ = r.Walk(func(s *sharedEntryAttributes) error {
+		le := s.leafVariants.GetByOwner(owner)
+		if le == nil || le.GetDeleteFlag() {
+			return nil
+		}
+		winner, _ := s.leafVariants.precedenceConflict()
+		if winner == nil || winner == le {
+			return nil
+		}
+		// an identical value is present on the device, regardless of the owner
+		winnerVal, err := winner.Value()
+		if err != nil {
+			return nil
+		}
+		if val, err := le.Value(); err == nil && utils.EqualTypedValues(val, winnerVal) {
+			return nil
+		}
+		result = append(result, &ShadowedEntry{
+			Path:       s.Path(),
+			Shadowed:   le,
+			ShadowedBy: winner,
+		})
+		return nil
+	})
+	slices.SortFunc(result, func(a, b *ShadowedEntry) int {
+		return strings.Compare(a.Path.String(), b.Path.String())
+	})
+	return result
+}
+
+// UncoveredEntry is a value of an intent that is removed, which was present on the device and is
+// now served by the value of a different intent with lower precedence.
+type UncoveredEntry struct {
+	Path PathSlice
+	// Removed is the LeafEntry of the removed owner
+	Removed *LeafEntry
+	// UncoveredBy is the LeafEntry that takes over
+	UncoveredBy *LeafEntry
+}
+
+func (u *UncoveredEntry) String() string {
+	return fmt.Sprintf("%s: value of removed intent %q (priority %d) is now served by intent %q (priority %d)", u.Path.String(), u.Removed.Owner(), u.Removed.Priority(), u.UncoveredBy.Owner(), u.UncoveredBy.Priority())
+}
+
+// GetUncovered returns the leafs of the given owner that are marked for deletion and took precedence so far,
+// for which the value of a different intent with lower precedence takes over.
+func (r *RootEntry) GetUncovered(owner string) []*UncoveredEntry {
+	result := []*UncoveredEntry{}
+	// the visitor does not return errors
+	_ = r.Walk(func(s *sharedEntryAttributes) error {
+		le := s.leafVariants.GetByOwner(owner)
+		if le == nil || !le.GetDeleteFlag() || !s.leafVariants.precedesAll(le) {
+			return nil
+		}
+		winner, _ := s.leafVariants.precedenceConflict()
+		if winner == nil {
+			return nil
+		}
+		result = append(result, &UncoveredEntry{
+			Path:        s.Path(),
+			Removed:     le,
+			UncoveredBy: winner,
+		})
+		return nil
+	})
+	slices.SortFunc(result, func(a, b *UncoveredEntry) int {
+		return strings.Compare(a.Path.String(), b.Path.String())
+	})
+	return result
+}
+
+// GetDeletedPathsForOwner returns the paths of the leafs of the given owner that are marked for deletion.
+func (r *RootEntry) GetDeletedPathsForOwner(owner string) ([]*sdcpb.Path, error) {
+	les := r.getByOwnerFiltered(owner, FilterDeleted)
+	result := make([]*sdcpb.Path, 0, len(les))
+	for _, le := range les {
+		p, err := le.GetEntry().SdcpbPath()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	return result, nil
 }
 
 // getTreeContext returns the handle to the TreeContext
@@ -0,0 +1,69 @@
+package tree
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sdcio/data-server/pkg/utils"
+)
+
+// RunningProtection defines whether intents may overwrite config that only exists in running,
+// hence config learned from the device that is not owned by any intent.
+type RunningProtection string
+
+const (
+	// RunningProtectionOverwrite lets intents overwrite the running config, the overwrites are reported
+	RunningProtectionOverwrite RunningProtection = "overwrite"
+	// RunningProtectionForce lets intents overwrite the running config only if forced explicitly
+	RunningProtectionForce RunningProtection = "force"
+	// RunningProtectionNever rejects intents that overwrite the running config
+	RunningProtectionNever RunningProtection = "never"
+)
+
+// RunningOverwrite describes a value of an intent that overwrites a different value, that only exists in running.
+type RunningOverwrite struct {
+	Path PathSlice
+	// Running is the LeafEntry of the running config
+	Running *LeafEntry
+	// OverwrittenBy is the LeafEntry of the intent
+	OverwrittenBy *LeafEntry
+}
+
+func (r *RunningOverwrite) String() string {
+	return fmt.Sprintf("%s: value of intent %q (priority %d) overwrites the running value that is not owned by any intent", r.Path.String(), r.OverwrittenBy.Owner(), r.OverwrittenBy.Priority())
+}
+
+// GetRunningOverwrites returns the values of the given owner, that overwrite a different running value
+// which is not present in the intended store, hence not owned by any intent.
+func (r *RootEntry) GetRunningOverwrites(owner string) []*RunningOverwrite {
+	result := []*RunningOverwrite{}
+	// the visitor does not return errors
+	_ = r.Walk(func(s *sharedEntryAttributes) error {
+		ownerLe := s.leafVariants.GetByOwner(owner)
+		if ownerLe == nil || ownerLe.GetDeleteFlag() {
+			return nil
+		}
+		runningLe := s.leafVariants.GetByOwner(RunningIntentName)
+		if runningLe == nil || s.treeContext.PathExists(s.Path()) {
+			return nil
+		}
+		ownerVal, err := ownerLe.Value()
+		if err != nil {
+			return nil
+		}
+		if val, err := runningLe.Value(); err == nil && utils.EqualTypedValues(val, ownerVal) {
+			return nil
+		}
+		result = append(result, &RunningOverwrite{
+			Path:          s.Path(),
+			Running:       runningLe,
+			OverwrittenBy: ownerLe,
+		})
+		return nil
+	})
+	slices.SortFunc(result, func(a, b *RunningOverwrite) int {
+		return strings.Compare(a.Path.String(), b.Path.String())
+	})
+	return result
+}
@@ -0,0 +1,70 @@
+package tree
+
+import (
+	"context"
+
+	"github.com/sdcio/cache/proto/cachepb"
+	"github.com/sdcio/data-server/pkg/cache"
+)
+
+// RunningReader is the source of the running config (the config of the device) the tree is populated with.
+type RunningReader interface {
+	// ReadRunning returns the running value of the path, nil if the path does not exist
+	ReadRunning(ctx context.Context, path PathSlice) (*cache.Update, error)
+	// ReadRunningFull returns all the values of the running config
+	ReadRunningFull(ctx context.Context) ([]*cache.Update, error)
+}
+
+// cacheRunningReader reads the running config from the CONFIG store of the cache.
+type cacheRunningReader struct {
+	tc *TreeContext
+}
+
+func (r *cacheRunningReader) ReadRunning(ctx context.Context, path PathSlice) (*cache.Update, error) {
+	// check if the value exists in running
+	_, exists := r.tc.RunningStoreIndex[PathKey(path)]
+	if !exists {
+		return nil, nil
+	}
+
+	updates := r.tc.treeSchemaCacheClient.Read(ctx, &cache.Opts{
+		Store:         cachepb.Store_CONFIG,
+		PriorityCount: 1,
+	}, [][]string{path})
+
+	return updates[0], nil
+}
+
+func (r *cacheRunningReader) ReadRunningFull(ctx context.Context) ([]*cache.Update, error) {
+	updates := r.tc.treeSchemaCacheClient.Read(ctx, &cache.Opts{
+		Store: cachepb.Store_CONFIG,
+	}, [][]string{{}})
+
+	return updates, nil
+}
+
+// StaticRunningReader provides a fixed set of values as the running config, e.g. fixtures in tests.
+type StaticRunningReader struct {
+	updates map[string]*cache.Update
+}
+
+// NewStaticRunningReader returns a StaticRunningReader holding the given values.
+func NewStaticRunningReader(upds []*cache.Update) *StaticRunningReader {
+	r := &StaticRunningReader{updates: make(map[string]*cache.Update, len(upds))}
+	for _, u := range upds {
+		r.updates[PathKey(u.GetPath())] = u
+	}
+	return r
+}
+
+func (r *StaticRunningReader) ReadRunning(_ context.Context, path PathSlice) (*cache.Update, error) {
+	return r.updates[PathKey(path)], nil
+}
+
+func (r *StaticRunningReader) ReadRunningFull(_ context.Context) ([]*cache.Update, error) {
+	result := make([]*cache.Update, 0, len(r.updates))
+	for _, u := range r.updates {
+		result = append(result, u)
+	}
+	return result, nil
+}
@@ -3,12 +3,15 @@ package tree
 import (
 	"context"
 	"fmt"
+	"iter"
+	"log/slog"
 	"math"
-	"regexp"
+	"runtime/debug"
 	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"unicode/utf8"
 
 	"github.com/sdcio/data-server/pkg/cache"
@@ -40,8 +43,14 @@ type sharedEntryAttributes struct {
 	treeContext *TreeContext
 
 	// state cache
-	remains      *bool
-	remainsMutex sync.Mutex
+	remains *bool
+	// remainsIntended caches the result of remainsInIntended, guarded by remainsMutex
+	remainsIntended *bool
+	remainsMutex    sync.Mutex
+	// dirty indicates that the entry or its childs were modified since the last FinishInsertionPhase
+	dirty atomic.Bool
+	// unsettled indicates that the entry or its childs were modified since the last Settle
+	unsettled atomic.Bool
 }
 
 type childMap struct {
@@ -61,6 +70,12 @@ func (c *childMap) Add(e Entry) {
 	c.c[e.PathName()] = e
 }
 
+func (c *childMap) Delete(s string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.c, s)
+}
+
 func (c *childMap) GetEntry(s string) (Entry, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -79,6 +94,19 @@ func (c *childMap) GetAll() map[string]Entry {
 	return result
 }
 
+// Items iterates over the childs, which must not be added or deleted meanwhile.
+func (c *childMap) Items() iter.Seq2[string, Entry] {
+	return func(yield func(string, Entry) bool) {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		for k, v := range c.c {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
 func (c *childMap) GetKeys() []string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -104,6 +132,8 @@ func newSharedEntryAttributes(ctx context.Context, parent Entry, pathElemName st
 		leafVariants: newLeafVariants(tc),
 		treeContext:  tc,
 	}
+	s.dirty.Store(true)
+	s.unsettled.Store(true)
 
 	// populate the schema
 	err := s.populateSchema(ctx)
@@ -284,7 +314,7 @@ func (s *sharedEntryAttributes) GetSchemaKeys() []string {
 // getAggregatedDeletes is called on levels that have no schema attached, meaning key schemas.
 // here we might delete the whole branch of the tree, if all key elements are being deleted
 // if not, we continue with regular deltes
-func (s *sharedEntryAttributes) getAggregatedDeletes(deletes []DeleteEntry, aggregatePaths bool) ([]DeleteEntry, error) {
+func (s *sharedEntryAttributes) getAggregatedDeletes(deletes []DeleteEntry, aggregation DeleteAggregation) ([]DeleteEntry, error) {
 	var err error
 	// we take a look into the level(s) up
 	// trying to get the schema
@@ -310,12 +340,24 @@ func (s *sharedEntryAttributes) getAggregatedDeletes(deletes []DeleteEntry, aggr
 		}
 		// if aggregate delet is possible do it
 		if doAggregateDelete {
+			if aggregation == DeleteAggregationLeaf {
+				// delete the leafs of the list instance first, the keys are deleted along with the instance
+				for _, c := range s.childs.GetAll() {
+					if slices.Contains(keys, c.PathName()) {
+						continue
+					}
+					deletes, err = c.GetDeletes(deletes, aggregation)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
 			// by adding the key path to the deletes
 			deletes = append(deletes, s)
 		} else {
 			// otherwise continue with deletion on the childs.
 			for _, c := range s.childs.GetAll() {
-				deletes, err = c.GetDeletes(deletes, aggregatePaths)
+				deletes, err = c.GetDeletes(deletes, aggregation)
 				if err != nil {
 					return nil, err
 				}
@@ -323,7 +365,7 @@ func (s *sharedEntryAttributes) getAggregatedDeletes(deletes []DeleteEntry, aggr
 		}
 		return deletes, nil
 	}
-	return s.getRegularDeletes(deletes, aggregatePaths)
+	return s.getRegularDeletes(deletes, aggregation)
 }
 
 func (s *sharedEntryAttributes) remainsToExist() bool {
@@ -359,8 +401,37 @@ func (s *sharedEntryAttributes) remainsToExist() bool {
 	return remains
 }
 
+// remainsInIntended indicates if a LeafEntry of an intent, rather than just running,
+// exists for this entry or any of its active childs after the update.
+func (s *sharedEntryAttributes) remainsInIntended() bool {
+	s.remainsMutex.Lock()
+	defer s.remainsMutex.Unlock()
+	if s.remainsIntended != nil {
+		return *s.remainsIntended
+	}
+
+	remains := false
+	for le := range s.leafVariants.Items() {
+		if le.Owner() != RunningIntentName && !le.GetDeleteFlag() {
+			remains = true
+			break
+		}
+	}
+	if !remains {
+		for _, c := range s.filterActiveChoiceCaseChilds() {
+			if c.remainsInIntended() {
+				remains = true
+				break
+			}
+		}
+	}
+	s.remainsIntended = &remains
+
+	return remains
+}
+
 // getRegularDeletes performs deletion calculation on elements that have a schema attached.
-func (s *sharedEntryAttributes) getRegularDeletes(deletes []DeleteEntry, aggregate bool) ([]DeleteEntry, error) {
+func (s *sharedEntryAttributes) getRegularDeletes(deletes []DeleteEntry, aggregation DeleteAggregation) ([]DeleteEntry, error) {
 	var err error
 	// if entry is a container type, check the keys, to be able to
 	// issue a delte for the whole branch at once via keys
@@ -391,12 +462,31 @@ func (s *sharedEntryAttributes) getRegularDeletes(deletes []DeleteEntry, aggrega
 		}
 	}
 
-	if !s.remainsToExist() && !s.IsRoot() && len(s.GetSchemaKeys()) == 0 {
-		return append(deletes, s), nil
+	if !s.remainsToExist() && !s.IsRoot() {
+		switch aggregation {
+		case DeleteAggregationLeaf:
+			// only entries that hold leafs (fields, leaflists and presence containers) are deleted,
+			// after their childs
+			if s.leafVariants.Length() > 0 {
+				for _, e := range s.childs.GetAll() {
+					deletes, err = e.GetDeletes(deletes, aggregation)
+					if err != nil {
+						return nil, err
+					}
+				}
+				return append(deletes, s), nil
+			}
+		case DeleteAggregationAncestor:
+			return append(deletes, s), nil
+		default:
+			if len(s.GetSchemaKeys()) == 0 {
+				return append(deletes, s), nil
+			}
+		}
 	}
 
 	for _, e := range s.childs.GetAll() {
-		deletes, err = e.GetDeletes(deletes, aggregate)
+		deletes, err = e.GetDeletes(deletes, aggregation)
 		if err != nil {
 			return nil, err
 		}
@@ -405,16 +495,16 @@ func (s *sharedEntryAttributes) getRegularDeletes(deletes []DeleteEntry, aggrega
 }
 
 // GetDeletes calculate the deletes that need to be send to the device.
-func (s *sharedEntryAttributes) GetDeletes(deletes []DeleteEntry, aggregatePaths bool) ([]DeleteEntry, error) {
+func (s *sharedEntryAttributes) GetDeletes(deletes []DeleteEntry, aggregation DeleteAggregation) ([]DeleteEntry, error) {
 
 	// if the actual level has no schema assigned we're on a key level
 	// element. Hence we try deletion via aggregation
-	if s.schema == nil && aggregatePaths {
-		return s.getAggregatedDeletes(deletes, aggregatePaths)
+	if s.schema == nil && aggregation != DeleteAggregationAncestor {
+		return s.getAggregatedDeletes(deletes, aggregation)
 	}
 
 	// else perform regular deletion
-	return s.getRegularDeletes(deletes, aggregatePaths)
+	return s.getRegularDeletes(deletes, aggregation)
 
 }
 
@@ -474,21 +564,48 @@ func (s *sharedEntryAttributes) String() string {
 // addChild add an entry to the list of child entries for the entry.
 func (s *sharedEntryAttributes) addChild(ctx context.Context, e Entry) error {
 	// make sure Entry should not only hold LeafEntries
-	if s.leafVariants.Length() > 0 {
-		// An exception are presence containers
-		_, is_container := s.schema.Schema.(*sdcpb.SchemaElem_Container)
-		if !is_container && !s.schema.GetContainer().IsPresence {
-			return fmt.Errorf("cannot add child to %s since it holds Leafs", s)
-		}
+	// An exception are presence containers, that carry an empty leaf variant to be representable without childs
+	if s.leafVariants.Length() > 0 && !s.isPresenceContainer() {
+		return fmt.Errorf("cannot add child to %s since it holds Leafs", s)
 	}
 	// check the path of child is a subpath of s
 	if !slices.Equal(s.Path(), e.Path()[:len(e.Path())-1]) {
 		return fmt.Errorf("adding Child with diverging path, parent: %s, child: %s", s, strings.Join(e.Path()[:len(e.Path())-1], "/"))
 	}
 	s.childs.Add(e)
+	s.markDirty()
 	return nil
 }
 
+// markDirty marks the entry and its ancestors as modified, such that their choice resolutions
+// and state caches are recomputed by the next FinishInsertionPhase and they are visited by the next Settle.
+func (s *sharedEntryAttributes) markDirty() {
+	s.dirty.Store(true)
+	s.unsettled.Store(true)
+	if s.parent != nil {
+		s.parent.markDirty()
+	}
+}
+
+// isPresenceContainer returns true if the schema of the entry is a presence container.
+func (s *sharedEntryAttributes) isPresenceContainer() bool {
+	return s.schema.GetContainer().GetIsPresence()
+}
+
+// presenceExplicitlySet returns true if the entry is a presence container whose own
+// presence value remains to exist, independent of any childs.
+// If onlyNewOrUpdated is set, the presence value must additionally be new or updated.
+func (s *sharedEntryAttributes) presenceExplicitlySet(onlyNewOrUpdated bool) bool {
+	if !s.isPresenceContainer() || s.leafVariants.Length() == 0 || s.leafVariants.shouldDelete() {
+		return false
+	}
+	le := s.leafVariants.GetHighestPrecedence(false, false)
+	if le == nil {
+		return false
+	}
+	return !onlyNewOrUpdated || le.GetNewFlag() || le.GetUpdateFlag()
+}
+
 func (s *sharedEntryAttributes) NavigateSdcpbPath(ctx context.Context, pathElems []*sdcpb.PathElem, isRootPath bool) (Entry, error) {
 	var err error
 	if len(pathElems) == 0 {
@@ -672,6 +789,20 @@ func (s *sharedEntryAttributes) GetHighestPrecedence(result LeafVariantSlice, on
 	return result
 }
 
+func (s *sharedEntryAttributes) getUpdatesDivergingFromRunning(result LeafVariantSlice) LeafVariantSlice {
+	// skip the highest precedence LeafVariant if the device already carries the same value
+	lv := s.leafVariants.GetHighestPrecedence(true, false)
+	if lv != nil && s.leafVariants.highestNotRunning(lv) {
+		result = append(result, lv)
+	}
+
+	// continue with the "active" childs
+	for _, c := range s.filterActiveChoiceCaseChilds() {
+		result = c.getUpdatesDivergingFromRunning(result)
+	}
+	return result
+}
+
 func (s *sharedEntryAttributes) getHighestPrecedenceLeafValue(ctx context.Context) (*LeafEntry, error) {
 	for _, x := range []string{"existing", "default"} {
 		lv := s.leafVariants.GetHighestPrecedence(false, true)
@@ -714,6 +845,10 @@ func (s *sharedEntryAttributes) getHighestPrecedenceValueOfBranch() int32 {
 // Validate is the highlevel function to perform validation.
 // it will multiplex all the different Validations that need to happen
 func (s *sharedEntryAttributes) Validate(ctx context.Context, errChan chan<- error, warnChan chan<- error, concurrent bool) {
+	// stop descending once the validation is cancelled or timed out
+	if ctx.Err() != nil {
+		return
+	}
 
 	// recurse the call to the child elements
 	wg := sync.WaitGroup{}
@@ -721,8 +856,15 @@ func (s *sharedEntryAttributes) Validate(ctx context.Context, errChan chan<- err
 	for _, c := range s.filterActiveChoiceCaseChilds() {
 		wg.Add(1)
 		valFunc := func(x Entry) { // HINT: for Must-Statement debugging, remove "go " such that the debugger is triggered one after the other
+			defer wg.Done()
+			// a panic in a validation goroutine would take the whole process down, report it as validation error
+			defer func() {
+				if r := recover(); r != nil {
+					slog.Error("panic during validation", slog.String("path", strings.Join(x.Path(), "/")), slog.Any("panic", r), slog.String("stack", string(debug.Stack())))
+					errChan <- fmt.Errorf("internal error validating %s: %v", strings.Join(x.Path(), "/"), r)
+				}
+			}()
 			x.Validate(ctx, errChan, warnChan, concurrent)
-			wg.Done()
 		}
 		if concurrent {
 			go valFunc(c)
@@ -734,12 +876,18 @@ func (s *sharedEntryAttributes) Validate(ctx context.Context, errChan chan<- err
 	// validate the mandatory statement on this entry
 	if s.remainsToExist() {
 		s.validateMandatory(errChan)
-		s.validateLeafRefs(ctx, errChan, warnChan)
 		s.validateLeafListMinMaxAttributes(errChan)
 		s.validatePattern(errChan)
-		s.validateMustStatements(ctx, errChan)
+		s.validateKeyLeaf(errChan)
 		s.validateLength(errChan)
 		s.validateRange(errChan)
+		// leafrefs and must-statements are only validated if the entry is part of the validation scope
+		if s.treeContext.inValidationScope(s) {
+			s.validateLeafRefs(ctx, errChan, warnChan)
+			s.validateMustStatements(ctx, errChan)
+		}
+	} else {
+		s.indexLeafRefs(ctx)
 	}
 }
 
@@ -774,7 +922,7 @@ func (s *sharedEntryAttributes) validateLeafListMinMaxAttributes(errchan chan<-
 	if schema := s.schema.GetLeaflist(); schema != nil {
 		if schema.MinElements > 0 {
 			if lv := s.leafVariants.GetHighestPrecedence(false, true); lv != nil {
-				tv, err := lv.Update.Value()
+				tv, err := lv.Value()
 				if err != nil {
 					errchan <- fmt.Errorf("validating LeafList Min Attribute: %v", err)
 				}
@@ -832,27 +980,66 @@ func (s *sharedEntryAttributes) validatePattern(errchan chan<- error) {
 			return
 		}
 		lv := s.leafVariants.GetHighestPrecedence(false, true)
-		tv, err := lv.Update.Value()
+		if lv == nil {
+			return
+		}
+		tv, err := lv.Value()
 		if err != nil {
 			errchan <- fmt.Errorf("failed reading value from %s LeafVariant %v: %w", s.Path(), lv, err)
 			return
 		}
 		value := tv.GetStringVal()
+		// all patterns must match, inverted ones must not
 		for _, pattern := range schema.Type.Patterns {
-			if p := pattern.GetPattern(); p != "" {
-				matched, err := regexp.MatchString(p, value)
-				if err != nil {
-					errchan <- fmt.Errorf("failed compiling regex %s defined for %s", p, s.Path())
-					continue
-				}
-				if (!matched && !pattern.Inverted) || (pattern.GetInverted() && matched) {
-					errchan <- fmt.Errorf("value %s of %s does not match regex %s (inverted: %t)", value, s.Path(), p, pattern.GetInverted())
-				}
+			if err := utils.MatchPattern(value, pattern); err != nil {
+				errchan <- fmt.Errorf("invalid value of %s: %w", s.Path(), err)
 			}
 		}
 	}
 }
 
+// validateKeyLeaf validates that the values of a key leaf match the key value
+// encoded in the path of the list entry it belongs to.
+func (s *sharedEntryAttributes) validateKeyLeaf(errchan chan<- error) {
+	if s.schema.GetField() == nil {
+		return
+	}
+	// key leafs are located below the key levels of the list
+	ancestor, levelsUp := s.GetFirstAncestorWithSchema()
+	if ancestor == nil || levelsUp < 2 {
+		return
+	}
+	schemaKeys := ancestor.GetSchemaKeys()
+	// the key levels are inserted into the tree sorted by the key names
+	sort.Strings(schemaKeys)
+	idx := slices.Index(schemaKeys, s.PathName())
+	if idx < 0 || len(schemaKeys) != levelsUp-1 {
+		return
+	}
+	path := s.Path()
+	keyValue := path[len(path)-levelsUp+idx]
+
+	for le := range s.leafVariants.Items() {
+		if le.GetDeleteFlag() {
+			continue
+		}
+		tv, err := le.Value()
+		if err != nil {
+			errchan <- fmt.Errorf("failed reading value from %s LeafVariant %v: %w", path, le, err)
+			continue
+		}
+		value := utils.TypedValueToString(tv)
+		expected := keyValue
+		if _, after, found := strings.Cut(keyValue, ":"); found && tv.GetIdentityrefVal() != nil {
+			// the key in the path might carry the module prefix
+			expected = after
+		}
+		if value != expected {
+			errchan <- fmt.Errorf("key leaf %s of intent %q has value %q, which diverges from the key value %q of the list entry", path, le.Owner(), value, keyValue)
+		}
+	}
+}
+
 func (s *sharedEntryAttributes) ImportConfig(ctx context.Context, t importer.ImportConfigAdapter, intentName string, intentPrio int32) error {
 	var err error
 
@@ -886,6 +1073,18 @@ func (s *sharedEntryAttributes) ImportConfig(ctx context.Context, t importer.Imp
 			if err != nil {
 				return err
 			}
+		case s.isAnyData():
+			// the content of anydata and anyxml nodes is imported as a single opaque value
+			tv, err := t.GetAnyDataValue()
+			if err != nil {
+				return err
+			}
+			tvVal, err := proto.Marshal(tv)
+			if err != nil {
+				return err
+			}
+			upd := cache.NewUpdate(s.Path(), tvVal, intentPrio, intentName, 0)
+			s.leafVariants.Add(NewLeafEntry(upd, false, s))
 		default:
 			if len(t.GetElements()) == 0 {
 				// it might be a presence container
@@ -952,7 +1151,7 @@ func (s *sharedEntryAttributes) ImportConfig(ctx context.Context, t importer.Imp
 		mustAdd := false
 		le := s.leafVariants.GetByOwner(intentName)
 		if le != nil {
-			llvTv, err := le.Update.Value()
+			llvTv, err := le.Value()
 			if err != nil {
 				return err
 			}
@@ -978,7 +1177,7 @@ func (s *sharedEntryAttributes) ImportConfig(ctx context.Context, t importer.Imp
 			return err
 		}
 
-		le.Update = cache.NewUpdate(s.Path(), tvVal, intentPrio, intentName, 0)
+		le.setUpdate(cache.NewUpdate(s.Path(), tvVal, intentPrio, intentName, 0))
 		if mustAdd {
 			s.leafVariants.Add(le)
 		}
@@ -1057,10 +1256,18 @@ func (s *sharedEntryAttributes) initChoiceCasesResolvers() {
 
 // FinishInsertionPhase certain values that are costly to calculate but used multiple times
 // will be calculated and stored for later use. However therefore the insertion phase into the
-// tree needs to be over. Calling this function indicated the end of the phase and thereby triggers the calculation
+// tree needs to be over. Calling this function indicated the end of the phase and thereby triggers the calculation.
+// Branches that were not modified since the last call keep their values.
 func (s *sharedEntryAttributes) FinishInsertionPhase() {
+	if !s.dirty.Load() {
+		return
+	}
 
-	// populate the ChoiceCaseResolvers to determine the active case
+	// populate the ChoiceCaseResolvers to determine the active case,
+	// starting over since branches might have been removed
+	if len(s.choicesResolvers) > 0 {
+		s.initChoiceCasesResolvers()
+	}
 	s.populateChoiceCaseResolvers()
 
 	// recurse the call to all (active) entries within the tree.
@@ -1071,6 +1278,8 @@ func (s *sharedEntryAttributes) FinishInsertionPhase() {
 
 	// reset state
 	s.remains = nil
+	s.remainsIntended = nil
+	s.dirty.Store(false)
 }
 
 // populateChoiceCaseResolvers iterates through the ChoiceCaseResolvers,
@@ -1080,16 +1289,21 @@ func (s *sharedEntryAttributes) FinishInsertionPhase() {
 // the choiceResolver is fed with the resulting values and thereby ready to be queried
 // in a later stage (filterActiveChoiceCaseChilds()).
 func (s *sharedEntryAttributes) populateChoiceCaseResolvers() {
-	if s.schema == nil {
+	if s.schema == nil || len(s.choicesResolvers) == 0 {
 		return
 	}
+	// the values of the actual owners are taken from the tree
+	ownerFilters := make([]CacheUpdateFilter, 0, len(s.treeContext.GetActualOwners()))
+	for _, owner := range s.treeContext.GetActualOwners() {
+		ownerFilters = append(ownerFilters, CacheUpdateFilterExcludeOwner(owner))
+	}
 	// if choice/cases exist, process it
 	for _, choiceResolver := range s.choicesResolvers {
 		for _, elem := range choiceResolver.GetElementNames() {
 			isNew := false
 			var val2 *int32
 			// Query the Index, stored in the treeContext for the per branch highes precedence
-			v := s.treeContext.GetBranchesHighesPrecedence(append(s.Path(), elem), CacheUpdateFilterExcludeOwner(s.treeContext.GetActualOwner()))
+			v := s.treeContext.GetBranchesHighesPrecedence(append(s.Path(), elem), ownerFilters...)
 
 			child, childExists := s.childs.GetEntry(elem)
 			// set the value from the tree as well
@@ -1141,7 +1355,7 @@ func (s *sharedEntryAttributes) StringIndent(result []string) []string {
 	}
 	// range over LeafVariants
 	for l := range s.leafVariants.Items() {
-		result = append(result, fmt.Sprintf("%s -> %s", strings.Repeat("  ", s.GetLevel()), l.String()))
+		result = append(result, fmt.Sprintf("%s -> %s", strings.Repeat("  ", s.GetLevel()), s.treeContext.redaction.leafEntryString(s.Path(), l)))
 	}
 	return result
 }
@@ -1154,7 +1368,7 @@ func (s *sharedEntryAttributes) markOwnerDelete(o string) {
 		lvEntry.MarkDelete()
 	}
 	// recurse into childs
-	for _, child := range s.childs.GetAll() {
+	for _, child := range s.childs.Items() {
 		child.markOwnerDelete(o)
 	}
 }
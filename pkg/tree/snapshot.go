@@ -0,0 +1,196 @@
+package tree
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/beevik/etree"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+// Snapshot is an immutable copy of a tree, taken once the insertion phase is finished.
+// The tree the snapshot was taken from can be modified further, or a new tree can be built,
+// while any number of readers use the snapshot concurrently.
+// Hence the Snapshot exposes the read-only operations of the RootEntry only.
+type Snapshot struct {
+	root  *RootEntry
+	taken time.Time
+}
+
+// Snapshot takes a snapshot of the tree. It must be called after FinishInsertionPhase, by the
+// goroutine building the tree, as the tree must not be modified while the snapshot is taken.
+// The entries, the leaf variants and the choice resolutions are copied, the values, i.e. the
+// cache updates, and the schemas are immutable and shared with the tree.
+func (r *RootEntry) Snapshot() *Snapshot {
+	tc := r.treeContext.snapshot()
+	root := &RootEntry{
+		sharedEntryAttributes: r.sharedEntryAttributes.snapshotCopy(nil, tc),
+	}
+	tc.root = root.sharedEntryAttributes
+	return &Snapshot{
+		root:  root,
+		taken: time.Now(),
+	}
+}
+
+// Taken returns the time the snapshot was taken at.
+func (s *Snapshot) Taken() time.Time {
+	return s.taken
+}
+
+// Owners returns the owners of the intents the tree was computed for.
+func (s *Snapshot) Owners() []string {
+	return s.root.treeContext.GetActualOwners()
+}
+
+// String returns the string representation of the tree.
+func (s *Snapshot) String() string {
+	return s.root.String()
+}
+
+// DebugInfo returns the choice resolutions and the leaf precedence decisions of the tree.
+func (s *Snapshot) DebugInfo() *DebugInfo {
+	return s.root.DebugInfo()
+}
+
+// BlameConfig returns the config of the tree, annotated with the owners of the values.
+func (s *Snapshot) BlameConfig() (*BlameTreeElement, error) {
+	return s.root.BlameConfig()
+}
+
+// GetHighestPrecedence returns the values of the tree that take precedence.
+func (s *Snapshot) GetHighestPrecedence(onlyNewOrUpdated bool) LeafVariantSlice {
+	return s.root.GetHighestPrecedence(onlyNewOrUpdated)
+}
+
+// ToJson returns the tree as JSON.
+func (s *Snapshot) ToJson(onlyNewOrUpdated bool) (any, error) {
+	return s.root.ToJson(onlyNewOrUpdated)
+}
+
+// ToJsonIETF returns the tree as JSON_IETF.
+func (s *Snapshot) ToJsonIETF(onlyNewOrUpdated bool) (any, error) {
+	return s.root.ToJsonIETF(onlyNewOrUpdated)
+}
+
+// ToXML returns the tree as XML.
+func (s *Snapshot) ToXML(onlyNewOrUpdated, honorNamespace, operationWithNamespace, useOperationRemove bool) (*etree.Document, error) {
+	return s.root.ToXML(onlyNewOrUpdated, honorNamespace, operationWithNamespace, useOperationRemove)
+}
+
+// ToProtoUpdates returns the tree as sdcpb updates.
+func (s *Snapshot) ToProtoUpdates(ctx context.Context, onlyNewOrUpdated bool) ([]*sdcpb.Update, error) {
+	return s.root.ToProtoUpdates(ctx, onlyNewOrUpdated)
+}
+
+// snapshotCopy returns a copy of the entry and its childs, being part of the tree of the given TreeContext.
+func (s *sharedEntryAttributes) snapshotCopy(parent Entry, tc *TreeContext) *sharedEntryAttributes {
+	s.schemaMutex.RLock()
+	schema := s.schema
+	s.schemaMutex.RUnlock()
+
+	result := &sharedEntryAttributes{
+		parent:           parent,
+		pathElemName:     s.pathElemName,
+		childs:           newChildMap(),
+		schema:           schema,
+		choicesResolvers: s.choicesResolvers.snapshotCopy(),
+		treeContext:      tc,
+	}
+	result.leafVariants = s.leafVariants.snapshotCopy(result, tc)
+	for _, c := range s.childs.GetAll() {
+		result.childs.Add(&EntryImpl{sharedEntryAttributes: c.snapshotCopy(result, tc)})
+	}
+	return result
+}
+
+// snapshotCopy returns a copy of the leaf variants, belonging to the given entry.
+func (lv *LeafVariants) snapshotCopy(parent Entry, tc *TreeContext) *LeafVariants {
+	lv.lesMutex.RLock()
+	defer lv.lesMutex.RUnlock()
+	result := &LeafVariants{
+		les: make([]*LeafEntry, 0, len(lv.les)),
+		tc:  tc,
+	}
+	for _, le := range lv.les {
+		result.les = append(result.les, le.snapshotCopy(parent))
+	}
+	return result
+}
+
+// snapshotCopy returns a copy of the leaf entry with its state, belonging to the given entry.
+// The update is immutable and therefore shared.
+func (l *LeafEntry) snapshotCopy(parent Entry) *LeafEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return &LeafEntry{
+		update:      l.update,
+		parentEntry: parent,
+		isNew:       l.isNew,
+		delete:      l.delete,
+		isUpdated:   l.isUpdated,
+	}
+}
+
+// snapshotCopy returns a deep copy of the choice resolutions.
+func (c choiceCasesResolvers) snapshotCopy() choiceCasesResolvers {
+	result := make(choiceCasesResolvers, len(c))
+	for name, r := range c {
+		cr := &choiceCasesResolver{
+			cases:                make(map[string]*choicesCase, len(r.cases)),
+			elementToCaseMapping: make(map[string]string, len(r.elementToCaseMapping)),
+		}
+		for elem, caseName := range r.elementToCaseMapping {
+			cr.elementToCaseMapping[elem] = caseName
+		}
+		for caseName, cas := range r.cases {
+			cc := &choicesCase{
+				name:     cas.name,
+				elements: make(map[string]*choicesCaseElement, len(cas.elements)),
+			}
+			for elemName, e := range cas.elements {
+				ce := *e
+				cc.elements[elemName] = &ce
+			}
+			cr.cases[caseName] = cc
+		}
+		result[name] = cr
+	}
+	return result
+}
+
+// snapshot returns the TreeContext of a snapshot of the tree. The clients, the store indexes and
+// the options are shared, the leafref index is copied and the xpath navigations, pointing to the
+// entries of the tree, are not taken over.
+func (t *TreeContext) snapshot() *TreeContext {
+	return &TreeContext{
+		IntendedStoreIndex:    t.IntendedStoreIndex,
+		RunningStoreIndex:     t.RunningStoreIndex,
+		treeSchemaCacheClient: t.treeSchemaCacheClient,
+		actualOwners:          slices.Clone(t.actualOwners),
+		leafrefIndex:          t.leafrefIndex.snapshotCopy(),
+		conflictPolicy:        t.conflictPolicy,
+		tieBreak:              t.tieBreak,
+		deleteAggregation:     t.deleteAggregation,
+		validationScope:       t.validationScope,
+		createNew:             t.createNew,
+		runningReader:         t.runningReader,
+		redaction:             t.redaction,
+	}
+}
+
+// snapshotCopy returns a copy of the leafref index, the references are immutable and therefore shared.
+func (l *leafrefIndex) snapshotCopy() *leafrefIndex {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	result := newLeafrefIndex()
+	for k, t := range l.refs {
+		refs := make(map[string]*LeafrefReference, len(t.refs))
+		for rk, r := range t.refs {
+			refs[rk] = r
+		}
+		result.refs[k] = &leafrefTarget{path: t.path, refs: refs}
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+package tree
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+)
+
+func TestRootEntry_Snapshot(t *testing.T) {
+	owner1 := "owner1"
+	owner2 := "owner2"
+	ts := int64(0)
+
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner1)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "name"}, testhelper.GetStringTvProto(t, "ethernet-1/1"), 10, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "description"}, testhelper.GetStringTvProto(t, "MyDescription"), 10, owner1, ts),
+		cache.NewUpdate([]string{"choices", "case1", "case-elem", "elem"}, testhelper.GetStringTvProto(t, "Foo"), 10, owner1, ts),
+		cache.NewUpdate([]string{"patterntest"}, testhelper.GetStringTvProto(t, "hallo 0"), RunningValuesPrio, RunningIntentName, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.FinishInsertionPhase()
+
+	snap := root.Snapshot()
+	want, err := snap.ToJson(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLeafs := snapshotLeafStrings(snap)
+
+	// the entries of the snapshot belong to the snapshot
+	if snap.root.GetRoot() != snap.root.sharedEntryAttributes || snap.root.treeContext == tc {
+		t.Fatal("snapshot root is not detached from the tree")
+	}
+	for _, le := range snap.GetHighestPrecedence(false) {
+		if le.GetEntry().GetRoot() != snap.root.sharedEntryAttributes {
+			t.Errorf("leaf %s of the snapshot refers to the tree", le.GetEntry().Path())
+		}
+	}
+
+	// readers use the snapshot, while the tree is modified
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := snap.ToJson(false); err != nil {
+					t.Error(err)
+				}
+				_ = snap.DebugInfo()
+				if _, err := snap.BlameConfig(); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "description"}, testhelper.GetStringTvProto(t, "Changed"), 10, owner1, ts),
+		cache.NewUpdate([]string{"interface", "ethernet-1/2", "name"}, testhelper.GetStringTvProto(t, "ethernet-1/2"), 10, owner1, ts),
+		cache.NewUpdate([]string{"choices", "case2", "log"}, testhelper.GetStringTvProto(t, "true"), 5, owner2, ts),
+	} {
+		_, err := root.AddCacheUpdateRecursive(ctx, u, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.markOwnerDelete(owner1)
+	root.FinishInsertionPhase()
+	wg.Wait()
+
+	got, err := snap.ToJson(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("snapshot changed along with the tree (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(wantLeafs, snapshotLeafStrings(snap)); diff != "" {
+		t.Errorf("snapshot leafs changed along with the tree (-want +got):\n%s", diff)
+	}
+
+	treeJson, err := root.ToJson(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmp.Equal(want, treeJson) {
+		t.Error("expected the tree to differ from the snapshot")
+	}
+}
+
+// snapshotLeafStrings returns the sorted string representations, including the flags, of the leafs of the snapshot.
+func snapshotLeafStrings(s *Snapshot) []string {
+	result := []string{}
+	for _, l := range s.DebugInfo().Leafs {
+		for _, v := range l.Variants {
+			result = append(result, v.String())
+		}
+	}
+	slices.Sort(result)
+	return result
+}
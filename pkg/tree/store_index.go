@@ -0,0 +1,76 @@
+package tree
+
+import "github.com/sdcio/data-server/pkg/cache"
+
+// StoreIndex holds the key meta data of a cache store, i.e. the entries of the intents per path,
+// without the values. A nil StoreIndex is empty.
+type StoreIndex struct {
+	trie *PathTrie[UpdateSlice]
+}
+
+func NewStoreIndex() *StoreIndex {
+	return &StoreIndex{trie: NewPathTrie[UpdateSlice]()}
+}
+
+// NewStoreIndexFromUpdates returns the StoreIndex holding the given entries.
+func NewStoreIndexFromUpdates(upds ...*cache.Update) *StoreIndex {
+	si := NewStoreIndex()
+	for _, u := range upds {
+		si.Add(u)
+	}
+	return si
+}
+
+// Add adds the entry to the entries of its path.
+func (si *StoreIndex) Add(u *cache.Update) {
+	upds, _ := si.trie.Get(u.GetPath())
+	si.trie.Set(u.GetPath(), append(upds, u))
+}
+
+// Get returns the entries of the path.
+func (si *StoreIndex) Get(path []string) UpdateSlice {
+	if si == nil {
+		return nil
+	}
+	upds, _ := si.trie.Get(path)
+	return upds
+}
+
+// Exists returns true if the path has entries.
+func (si *StoreIndex) Exists(path []string) bool {
+	return len(si.Get(path)) > 0
+}
+
+// Len returns the number of paths with entries.
+func (si *StoreIndex) Len() int {
+	if si == nil {
+		return 0
+	}
+	return si.trie.Len()
+}
+
+// Walk calls f with the entries of every path, sorted by path, until f returns false.
+func (si *StoreIndex) Walk(f func(path PathSlice, upds UpdateSlice) bool) {
+	si.WalkPrefix(nil, f)
+}
+
+// WalkPrefix calls f with the entries of the paths at or below the prefix, sorted by path, until f returns false.
+func (si *StoreIndex) WalkPrefix(prefix []string, f func(path PathSlice, upds UpdateSlice) bool) {
+	if si == nil {
+		return
+	}
+	si.trie.WalkPrefix(prefix, f)
+}
+
+// Prefixes returns the top level paths, the prefixes covering all the paths of the index.
+func (si *StoreIndex) Prefixes() PathSlices {
+	if si == nil {
+		return PathSlices{}
+	}
+	children := si.trie.Children(nil)
+	result := make(PathSlices, 0, len(children))
+	for _, c := range children {
+		result = append(result, PathSlice{c})
+	}
+	return result
+}
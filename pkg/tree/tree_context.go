@@ -5,7 +5,8 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
-	"strings"
+	"slices"
+	"sync"
 
 	"github.com/sdcio/cache/proto/cachepb"
 	"github.com/sdcio/data-server/pkg/cache"
@@ -13,17 +14,30 @@ import (
 
 type TreeContext struct {
 	root                  Entry                    // the trees root element
-	IntendedStoreIndex    map[string]UpdateSlice   // contains the keys that the intended store holds in the cache
+	IntendedStoreIndex    *StoreIndex              // contains the keys that the intended store holds in the cache
 	RunningStoreIndex     map[string]*cache.Update // contains the keys of the running config
 	treeSchemaCacheClient TreeSchemaCacheClient
-	actualOwner           string
+	actualOwners          []string      // the owners of the intents the tree is computed for
+	leafrefIndex          *leafrefIndex // reverse leafref index, populated during validation
+	conflictPolicy        ConflictPolicy
+	tieBreak              TieBreak
+	deleteAggregation     DeleteAggregation
+	validationScope       ValidationScope
+	createNew             bool
+	runningReader         RunningReader
+	xpathNavigations      sync.Map // memoized navigations of the xpath evaluation, path + xpath -> Entry
+	redaction             *Redaction
 }
 
 func NewTreeContext(tscc TreeSchemaCacheClient, actualOwner string) *TreeContext {
-	return &TreeContext{
+	tc := &TreeContext{
 		treeSchemaCacheClient: tscc,
-		actualOwner:           actualOwner,
+		leafrefIndex:          newLeafrefIndex(),
 	}
+	if actualOwner != "" {
+		tc.actualOwners = []string{actualOwner}
+	}
+	return tc
 }
 
 func (t *TreeContext) SetRoot(e Entry) error {
@@ -34,76 +48,163 @@ func (t *TreeContext) SetRoot(e Entry) error {
 	return nil
 }
 
+// GetActualOwner returns the owner of the intent the tree is computed for, the first one if the tree
+// merges several intents.
 func (t *TreeContext) GetActualOwner() string {
-	return t.actualOwner
+	if len(t.actualOwners) == 0 {
+		return ""
+	}
+	return t.actualOwners[0]
+}
+
+// SetActualOwners sets the owners of the intents the tree is computed for, if the tree merges several intents.
+func (t *TreeContext) SetActualOwners(owners ...string) {
+	t.actualOwners = owners
+}
+
+// GetActualOwners returns the owners of the intents the tree is computed for.
+func (t *TreeContext) GetActualOwners() []string {
+	return t.actualOwners
+}
+
+func (t *TreeContext) isActualOwner(owner string) bool {
+	return slices.Contains(t.actualOwners, owner)
+}
+
+// SetConflictPolicy sets the policy that decides which of two values with the same priority takes precedence.
+func (t *TreeContext) SetConflictPolicy(p ConflictPolicy) {
+	t.conflictPolicy = p
+}
+
+// SetTieBreak sets how ties that are not decided by the conflict policy are broken.
+func (t *TreeContext) SetTieBreak(tb TieBreak) {
+	t.tieBreak = tb
+}
+
+// SetDeleteAggregation sets up to which level the deletes towards the device are aggregated.
+func (t *TreeContext) SetDeleteAggregation(da DeleteAggregation) {
+	t.deleteAggregation = da
+}
+
+// GetDeleteAggregation returns the delete aggregation, DeleteAggregationInstance if not set.
+func (t *TreeContext) GetDeleteAggregation() DeleteAggregation {
+	if t.deleteAggregation == "" {
+		return DeleteAggregationInstance
+	}
+	return t.deleteAggregation
+}
+
+// SetRedaction sets how the leaf values are rendered by the string representation and the debug information of the tree.
+func (t *TreeContext) SetRedaction(r *Redaction) {
+	t.redaction = r
+}
+
+// SetValidationScope sets the config that must-statements and leafrefs are evaluated against.
+func (t *TreeContext) SetValidationScope(vs ValidationScope) {
+	t.validationScope = vs
+}
+
+// GetValidationScope returns the validation scope, ValidationScopeIntendedAndRunning if not set.
+func (t *TreeContext) GetValidationScope() ValidationScope {
+	if t.validationScope == "" {
+		return ValidationScopeIntendedAndRunning
+	}
+	return t.validationScope
+}
+
+// inValidationScope returns true if the entry is visible to must-statements and leafrefs.
+func (t *TreeContext) inValidationScope(e Entry) bool {
+	if t.GetValidationScope() == ValidationScopeIntended {
+		return e.remainsInIntended()
+	}
+	return e.remainsToExist()
+}
+
+// SetCreateNew sets whether values and list entries that do not yet exist on the device are
+// rendered with the create operation, rather than being merged.
+func (t *TreeContext) SetCreateNew(b bool) {
+	t.createNew = b
+}
+
+// GetLeafrefReferences returns the leafrefs that point to the entry with the given path.
+// The reverse index is populated while validating the tree.
+func (t *TreeContext) GetLeafrefReferences(path PathSlice) []*LeafrefReference {
+	return t.leafrefIndex.get(path)
 }
 
 func (t *TreeContext) PathExists(path []string) bool {
-	_, exists := t.IntendedStoreIndex[strings.Join(path, KeysIndexSep)]
-	return exists
+	return t.IntendedStoreIndex.Exists(path)
 }
 
 func (t *TreeContext) GetBranchesHighesPrecedence(path []string, filters ...CacheUpdateFilter) int32 {
 	result := int32(math.MaxInt32)
-	pathKey := strings.Join(path, KeysIndexSep)
-
-	// TODO: Improve this, since it is probably an expensive operation
-	for key, entries := range t.IntendedStoreIndex {
-		if strings.HasPrefix(key, pathKey) {
-			if prio := entries.GetLowestPriorityValue(filters); prio < result {
-				result = prio
-			}
+	t.IntendedStoreIndex.WalkPrefix(path, func(_ PathSlice, entries UpdateSlice) bool {
+		if prio := entries.GetLowestPriorityValue(filters); prio < result {
+			result = prio
 		}
-	}
+		return true
+	})
 	return result
 }
 
+// ReadCurrentUpdatesHighestPriorities reads the values of the count highest priorities of every path located
+// at or below the given paths from the intended store. The values are grouped and selected per path by the cache,
+// hence prefixes can be given rather than listing all the paths below them.
 func (tc *TreeContext) ReadCurrentUpdatesHighestPriorities(ctx context.Context, ccp PathSlices, count uint64) UpdateSlice {
+	paths := ccp.Compact()
+	// the root path is no prefix of the stored keys, it is covered by the top level paths
+	if len(paths) > 0 && len(paths[0]) == 0 {
+		paths = tc.IntendedStorePrefixes()
+	}
 	return tc.treeSchemaCacheClient.Read(ctx, &cache.Opts{
 		Store:         cachepb.Store_INTENDED,
 		PriorityCount: count,
-	}, ccp.ToStringSlice())
+	}, paths.ToStringSlice())
+}
+
+// IntendedStorePrefixes returns the top level paths of the intended store index, the prefixes
+// covering all the paths of the intended store.
+func (t *TreeContext) IntendedStorePrefixes() PathSlices {
+	return t.IntendedStoreIndex.Prefixes()
 }
 
 func (t *TreeContext) GetPathsOfOwner(owner string) *PathSet {
 	p := NewPathSet()
-	for _, keyMeta := range t.IntendedStoreIndex {
+	t.IntendedStoreIndex.Walk(func(path PathSlice, keyMeta UpdateSlice) bool {
 		for _, k := range keyMeta {
 			if k.Owner() == owner {
-				// if the key is not yet listed in the keys slice, add it otherwise skip
-				p.AddPath(k.GetPath())
+				p.AddPath(path)
+				break
 			}
 		}
-	}
+		return true
+	})
 	return p
 }
 
-func (t *TreeContext) SetStoreIndex(si map[string]UpdateSlice) {
-	slog.Debug("setting intended store index", slog.Int("length", len(si)))
+func (t *TreeContext) SetStoreIndex(si *StoreIndex) {
+	slog.Debug("setting intended store index", slog.Int("length", si.Len()))
 	t.IntendedStoreIndex = si
 }
 
-// ReadRunning reads the value from running if the value does not exist, nil is returned
-func (t *TreeContext) ReadRunning(ctx context.Context, path PathSlice) (*cache.Update, error) {
-	// check if the value exists in running
-	_, exists := t.RunningStoreIndex[strings.Join(path, KeysIndexSep)]
-	if !exists {
-		return nil, nil
-	}
-
-	updates := t.treeSchemaCacheClient.Read(ctx, &cache.Opts{
-		Store:         cachepb.Store_CONFIG,
-		PriorityCount: 1,
-	}, [][]string{path})
+// SetRunningReader sets the source of the running config, by default it is read from the CONFIG store of the cache.
+func (t *TreeContext) SetRunningReader(r RunningReader) {
+	t.runningReader = r
+}
 
-	return updates[0], nil
+func (t *TreeContext) getRunningReader() RunningReader {
+	if t.runningReader == nil {
+		return &cacheRunningReader{tc: t}
+	}
+	return t.runningReader
 }
 
 // ReadRunning reads the value from running if the value does not exist, nil is returned
-func (t *TreeContext) ReadRunningFull(ctx context.Context) ([]*cache.Update, error) {
-	updates := t.treeSchemaCacheClient.Read(ctx, &cache.Opts{
-		Store: cachepb.Store_CONFIG,
-	}, [][]string{{}})
+func (t *TreeContext) ReadRunning(ctx context.Context, path PathSlice) (*cache.Update, error) {
+	return t.getRunningReader().ReadRunning(ctx, path)
+}
 
-	return updates, nil
+// ReadRunningFull reads all the values of running
+func (t *TreeContext) ReadRunningFull(ctx context.Context) ([]*cache.Update, error) {
+	return t.getRunningReader().ReadRunningFull(ctx)
 }
@@ -1,7 +1,9 @@
 package tree
 
 import (
+	"cmp"
 	"math"
+	"slices"
 
 	"github.com/sdcio/data-server/pkg/cache"
 )
@@ -20,6 +22,75 @@ func (u UpdateSlice) GetLowestPriorityValue(filters []CacheUpdateFilter) int32 {
 	return result
 }
 
+// IndexByPath returns the updates indexed by the PathKey of their path.
+// The last update of a path wins.
+func (u UpdateSlice) IndexByPath() map[string]*cache.Update {
+	result := make(map[string]*cache.Update, len(u))
+	for _, upd := range u {
+		result[PathKey(upd.GetPath())] = upd
+	}
+	return result
+}
+
+// DeduplicateByPath returns the updates with a single update per path, the last one given for the path.
+// The paths keep the order of their first occurrence.
+func (u UpdateSlice) DeduplicateByPath() UpdateSlice {
+	index := make(map[string]int, len(u))
+	result := make(UpdateSlice, 0, len(u))
+	for _, upd := range u {
+		key := PathKey(upd.GetPath())
+		if i, exists := index[key]; exists {
+			result[i] = upd
+			continue
+		}
+		index[key] = len(result)
+		result = append(result, upd)
+	}
+	return result
+}
+
+// SortByPathThenPriority sorts the updates in place by their path. The updates of the same path
+// are sorted by precedence, the lowest priority value and for equal priorities the oldest update first.
+func (u UpdateSlice) SortByPathThenPriority() {
+	slices.SortStableFunc(u, func(a, b *cache.Update) int {
+		if c := slices.Compare(a.GetPath(), b.GetPath()); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Priority(), b.Priority()); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.TS(), b.TS())
+	})
+}
+
+// IntersectByPath returns the updates whose path is present in other.
+func (u UpdateSlice) IntersectByPath(other UpdateSlice) UpdateSlice {
+	index := other.IndexByPath()
+	return u.filterByPath(func(key string) bool {
+		_, exists := index[key]
+		return exists
+	})
+}
+
+// SubtractByPath returns the updates whose path is not present in other.
+func (u UpdateSlice) SubtractByPath(other UpdateSlice) UpdateSlice {
+	index := other.IndexByPath()
+	return u.filterByPath(func(key string) bool {
+		_, exists := index[key]
+		return !exists
+	})
+}
+
+func (u UpdateSlice) filterByPath(keep func(key string) bool) UpdateSlice {
+	result := make(UpdateSlice, 0, len(u))
+	for _, upd := range u {
+		if keep(PathKey(upd.GetPath())) {
+			result = append(result, upd)
+		}
+	}
+	return result
+}
+
 func Map[T any](u UpdateSlice, f func(*cache.Update) T) []T {
 	vsm := make([]T, len(u))
 	for i, v := range u {
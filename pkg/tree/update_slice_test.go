@@ -0,0 +1,63 @@
+package tree
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sdcio/data-server/pkg/cache"
+)
+
+func updateStrings(u UpdateSlice) []string {
+	return Map(u, func(u *cache.Update) string {
+		return PathSlice(u.GetPath()).String() + "@" + u.Owner()
+	})
+}
+
+func TestUpdateSlice_DeduplicateByPath(t *testing.T) {
+	u := UpdateSlice{
+		cache.NewUpdate([]string{"a", "b"}, nil, 5, "o1", 0),
+		cache.NewUpdate([]string{"c"}, nil, 5, "o1", 0),
+		cache.NewUpdate([]string{"a", "b"}, nil, 5, "o2", 0),
+	}
+	want := []string{"a/b@o2", "c@o1"}
+	if diff := cmp.Diff(want, updateStrings(u.DeduplicateByPath())); diff != "" {
+		t.Errorf("DeduplicateByPath() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUpdateSlice_SortByPathThenPriority(t *testing.T) {
+	u := UpdateSlice{
+		cache.NewUpdate([]string{"b"}, nil, 5, "o1", 0),
+		cache.NewUpdate([]string{"a", "b"}, nil, 10, "o2", 0),
+		cache.NewUpdate([]string{"a", "b"}, nil, 5, "o3", 2),
+		cache.NewUpdate([]string{"a", "b"}, nil, 5, "o4", 1),
+		cache.NewUpdate([]string{"a"}, nil, 20, "o5", 0),
+	}
+	u.SortByPathThenPriority()
+	want := []string{"a@o5", "a/b@o4", "a/b@o3", "a/b@o2", "b@o1"}
+	if diff := cmp.Diff(want, updateStrings(u)); diff != "" {
+		t.Errorf("SortByPathThenPriority() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestUpdateSlice_IntersectSubtractByPath(t *testing.T) {
+	u := UpdateSlice{
+		cache.NewUpdate([]string{"a"}, nil, 5, "o1", 0),
+		cache.NewUpdate([]string{"b"}, nil, 5, "o1", 0),
+		cache.NewUpdate([]string{"c"}, nil, 5, "o1", 0),
+	}
+	other := UpdateSlice{
+		cache.NewUpdate([]string{"b"}, nil, 5, "o2", 0),
+		cache.NewUpdate([]string{"d"}, nil, 5, "o2", 0),
+	}
+	if diff := cmp.Diff([]string{"b@o1"}, updateStrings(u.IntersectByPath(other))); diff != "" {
+		t.Errorf("IntersectByPath() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"a@o1", "c@o1"}, updateStrings(u.SubtractByPath(other))); diff != "" {
+		t.Errorf("SubtractByPath() mismatch (-want +got):\n%s", diff)
+	}
+	if got := u.SubtractByPath(nil); len(got) != len(u) {
+		t.Errorf("SubtractByPath(nil) returned %d updates, want %d", len(got), len(u))
+	}
+}
@@ -9,18 +9,30 @@ import (
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 )
 
-// NavigateLeafRef
-func (s *sharedEntryAttributes) NavigateLeafRef(ctx context.Context) ([]Entry, error) {
-
-	var lref string
+// leafrefType returns the type of the leaf or leaflist if it is a leafref, nil otherwise
+func (s *sharedEntryAttributes) leafrefType() *sdcpb.SchemaLeafType {
 	switch {
 	case s.GetSchema().GetField().GetType().GetLeafref() != "":
-		lref = s.schema.GetField().GetType().GetLeafref()
+		return s.GetSchema().GetField().GetType()
 	case s.GetSchema().GetLeaflist().GetType().GetLeafref() != "":
-		lref = s.GetSchema().GetLeaflist().GetType().GetLeafref()
-	default:
+		return s.GetSchema().GetLeaflist().GetType()
+	}
+	return nil
+}
+
+// NavigateLeafRef
+func (s *sharedEntryAttributes) NavigateLeafRef(ctx context.Context) ([]Entry, error) {
+	return s.navigateLeafRef(ctx, false)
+}
+
+// navigateLeafRef follows the leafref and returns the referenced entries.
+// If includeDeleted is set, entries that are marked for deletion are included in the result.
+func (s *sharedEntryAttributes) navigateLeafRef(ctx context.Context, includeDeleted bool) ([]Entry, error) {
+	lrefType := s.leafrefType()
+	if lrefType == nil {
 		return nil, fmt.Errorf("error not a leafref %s", s.Path().String())
 	}
+	lref := lrefType.GetLeafref()
 
 	lv := s.leafVariants.GetHighestPrecedence(false, true)
 
@@ -40,7 +52,7 @@ func (s *sharedEntryAttributes) NavigateLeafRef(ctx context.Context) ([]Entry, e
 		isRootBasedPath = true
 	}
 
-	tv, err := lv.Update.Value()
+	tv, err := lv.Value()
 	if err != nil {
 		return nil, fmt.Errorf("failed reading value from %s LeafVariant %v: %w", s.Path(), lv, err)
 	}
@@ -101,7 +113,7 @@ func (s *sharedEntryAttributes) NavigateLeafRef(ctx context.Context) ([]Entry, e
 			}
 
 			// if the entry is marked for deletion, skip it
-			if !entry.remainsToExist() {
+			if !includeDeleted && !entry.remainsToExist() {
 				continue
 			}
 			// if we're at the final level, no child filtering is needed any more,
@@ -137,7 +149,7 @@ func (s *sharedEntryAttributes) NavigateLeafRef(ctx context.Context) ([]Entry, e
 		if err != nil {
 			return nil, err
 		}
-		val, err := r.Update.Value()
+		val, err := r.Value()
 		if err != nil {
 			return nil, err
 		}
@@ -190,16 +202,18 @@ func (s *sharedEntryAttributes) resolve_leafref_key_path(ctx context.Context, ke
 }
 
 func (s *sharedEntryAttributes) validateLeafRefs(ctx context.Context, errchan chan<- error, warnChan chan<- error) {
-
-	lref := s.schema.GetField().GetType().GetLeafref()
-	if s.schema == nil || lref == "" {
+	lrefType := s.leafrefType()
+	if lrefType == nil {
 		return
 	}
+	lref := lrefType.GetLeafref()
 
-	entry, err := s.NavigateLeafRef(ctx)
-	if err != nil || len(entry) == 0 {
+	// resolve including the entries that are about to be deleted,
+	// such that references to deleted entries can be reported with both paths
+	entries, err := s.navigateLeafRef(ctx, true)
+	if err != nil || len(entries) == 0 {
 		// check if the OptionalInstance (!require-instances [https://datatracker.ietf.org/doc/html/rfc7950#section-9.9.3])
-		if s.schema.GetField().GetType().GetOptionalInstance() {
+		if lrefType.GetOptionalInstance() {
 			generateOptionalWarning(ctx, s, lref, errchan, warnChan)
 			return
 		}
@@ -208,20 +222,57 @@ func (s *sharedEntryAttributes) validateLeafRefs(ctx context.Context, errchan ch
 		return
 	}
 
-	// Only if the value remains, even after the SetIntent made it through, the LeafRef can be considered resolved.
-	if !entry[0].remainsToExist() {
-		lv := s.leafVariants.GetHighestPrecedence(false, false)
-		EntryPath, _ := s.SdcpbPath()
+	lv := s.leafVariants.GetHighestPrecedence(false, false)
+	ref := &LeafrefReference{Path: s.Path()}
+	if lv != nil {
+		ref.Owner = lv.Owner()
+		ref.Priority = lv.Priority()
+	}
 
-		// check if the OptionalInstance (!require-instances [https://datatracker.ietf.org/doc/html/rfc7950#section-9.9.3])
-		if s.schema.GetField().GetType().GetOptionalInstance() {
-			generateOptionalWarning(ctx, s, lref, errchan, warnChan)
+	// populate the reverse index and check if any of the referenced entries remains
+	var deleted Entry
+	for _, e := range entries {
+		s.treeContext.leafrefIndex.add(e.Path(), ref)
+		if s.treeContext.inValidationScope(e) {
 			return
 		}
-		// if required, issue error
-		errchan <- fmt.Errorf("missing leaf reference: failed resolving leafref %s for %s to path %s LeafVariant %v", lref, utils.ToXPath(EntryPath, false), s.Path().String(), lv)
+		deleted = e
+	}
+
+	// Only if the value remains, even after the SetIntent made it through, the LeafRef can be considered resolved.
+	// check if the OptionalInstance (!require-instances [https://datatracker.ietf.org/doc/html/rfc7950#section-9.9.3])
+	if lrefType.GetOptionalInstance() {
+		generateOptionalWarning(ctx, s, lref, errchan, warnChan)
+		return
+	}
+	// if required, issue error
+	if deleted.remainsToExist() {
+		// the referenced entry solely exists in running, which is not part of the validation scope
+		errchan <- fmt.Errorf("missing leaf reference: leafref %s of intent %q (priority %d) references %s which is not part of the intended config", s.Path().String(), ref.Owner, ref.Priority, deleted.Path().String())
+		return
+	}
+	errchan <- fmt.Errorf("broken leaf reference: leafref %s of intent %q (priority %d) references %s which is being deleted", s.Path().String(), ref.Owner, ref.Priority, deleted.Path().String())
+}
+
+// indexLeafRefs adds the references of a leafref that is being deleted to the reverse leafref index.
+// This is not a validation, unresolvable references are ignored.
+func (s *sharedEntryAttributes) indexLeafRefs(ctx context.Context) {
+	if s.leafrefType() == nil || s.leafVariants.Length() == 0 {
+		return
+	}
+	entries, err := s.navigateLeafRef(ctx, true)
+	if err != nil {
 		return
 	}
+	lv := s.leafVariants.GetHighestPrecedence(false, false)
+	ref := &LeafrefReference{Path: s.Path()}
+	if lv != nil {
+		ref.Owner = lv.Owner()
+		ref.Priority = lv.Priority()
+	}
+	for _, e := range entries {
+		s.treeContext.leafrefIndex.add(e.Path(), ref)
+	}
 }
 
 func generateOptionalWarning(ctx context.Context, s Entry, lref string, errchan chan<- error, warnChan chan<- error) {
@@ -230,7 +281,7 @@ func generateOptionalWarning(ctx context.Context, s Entry, lref string, errchan
 		errchan <- err
 		return
 	}
-	tvVal, err := lrefval.Update.Value()
+	tvVal, err := lrefval.Value()
 	if err != nil {
 		errchan <- err
 		return
@@ -8,7 +8,6 @@ import (
 
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 	"github.com/sdcio/yang-parser/xpath"
-	"github.com/sdcio/yang-parser/xpath/grammars/expr"
 )
 
 func (s *sharedEntryAttributes) validateMustStatements(ctx context.Context, errchan chan<- error) {
@@ -28,24 +27,23 @@ func (s *sharedEntryAttributes) validateMustStatements(ctx context.Context, errc
 		mustStatements = schem.Field.GetMustStatements()
 	}
 
+	if len(mustStatements) == 0 {
+		return
+	}
+	sp := schemaPath(s)
+
 	for _, must := range mustStatements {
 		// extract actual must statement
 		exprStr := must.Statement
-		// init a ProgramBuilder
-		prgbuilder := xpath.NewProgBuilder(exprStr)
-		// init an ExpressionLexer
-		lexer := expr.NewExprLex(exprStr, prgbuilder, nil)
-		// parse the provided Must-Expression
-		lexer.Parse()
-		prog, err := lexer.CreateProgram(exprStr)
+		// retrieve the compiled Must-Expression
+		machine, err := mustProgramCache.get(sp, exprStr)
 		if err != nil {
 			errchan <- err
 			return
 		}
-		machine := xpath.NewMachine(exprStr, prog, exprStr)
 
 		// run the must statement evaluation virtual machine
-		yctx := xpath.NewCtxFromCurrent(ctx, machine, newYangParserEntryAdapter(ctx, s))
+		yctx := xpath.NewCtxFromCurrent(ctx, machine, newYangParserEntryAdapter(ctx, s.treeContext, s))
 		yctx.SetDebug(false)
 
 		res1 := yctx.Run()
@@ -0,0 +1,155 @@
+package tree
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/utils/testhelper"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"github.com/sdcio/yang-parser/xpath"
+	"google.golang.org/protobuf/proto"
+)
+
+// Test_XPathFunctions evaluates must-expressions, as they are used in vendor models, against the tree entries.
+func Test_XPathFunctions(t *testing.T) {
+	owner := "owner1"
+	prio := int32(5)
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner)
+	root, err := NewTreeRoot(ctx, tc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	niType, err := proto.Marshal(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_IdentityrefVal{IdentityrefVal: &sdcpb.IdentityRef{Prefix: "sdcio_model_ni", Value: "default"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, u := range []*cache.Update{
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "name"}, testhelper.GetStringTvProto(t, "ethernet-1/1"), prio, owner, 0),
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "admin-state"}, testhelper.GetStringTvProto(t, "enable"), prio, owner, 0),
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "subinterface", "0", "index"}, testhelper.GetUIntTvProto(t, 0), prio, owner, 0),
+		cache.NewUpdate([]string{"interface", "ethernet-1/1", "subinterface", "1", "index"}, testhelper.GetUIntTvProto(t, 1), prio, owner, 0),
+		cache.NewUpdate([]string{"network-instance", "default", "name"}, testhelper.GetStringTvProto(t, "default"), prio, owner, 0),
+		cache.NewUpdate([]string{"network-instance", "default", "type"}, niType, prio, owner, 0),
+		cache.NewUpdate([]string{"mgmt-interface", "name"}, testhelper.GetStringTvProto(t, "ethernet-1/1"), prio, owner, 0),
+	} {
+		if _, err := root.AddCacheUpdateRecursive(ctx, u, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	root.FinishInsertionPhase()
+
+	adminState := []string{"interface", "ethernet-1/1", "admin-state"}
+	niName := []string{"network-instance", "default", "name"}
+
+	tests := []struct {
+		name string
+		path []string
+		expr string
+		want bool
+	}{
+		{name: "count", path: adminState, expr: "count(../subinterface) = 2", want: true},
+		{name: "count exceeded", path: adminState, expr: "count(../subinterface) <= 1", want: false},
+		{name: "count with existence", path: adminState, expr: "../subinterface and count(../subinterface) > 1", want: true},
+		{name: "re-match", path: adminState, expr: "re-match(../name, 'ethernet-([1-9][0-9]?)/([1-9][0-9]?)')", want: true},
+		{name: "re-match no match", path: adminState, expr: "re-match(../name, 'lag[0-9]+')", want: false},
+		{name: "current", path: adminState, expr: "current() = 'enable'", want: true},
+		{name: "current in predicate", path: adminState, expr: "/interface[name=current()/../name]/admin-state = 'enable'", want: true},
+		{name: "deref", path: []string{"mgmt-interface", "name"}, expr: "deref(.)/../admin-state = 'enable'", want: true},
+		{name: "derived-from-or-self", path: niName, expr: "derived-from-or-self(../type, 'ni:default')", want: true},
+		{name: "derived-from-or-self module name", path: niName, expr: "derived-from-or-self(../type, 'sdcio_model_ni:default')", want: true},
+		{name: "derived-from-or-self other identity", path: niName, expr: "derived-from-or-self(../type, 'ni:mac-vrf')", want: false},
+		{name: "not derived-from-or-self", path: niName, expr: "not(derived-from-or-self(../type, 'ni:ip-vrf'))", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := root.Navigate(ctx, tt.path, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			machine, err := mustProgramCache.get(schemaPath(e), tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := xpath.NewCtxFromCurrent(ctx, machine, newYangParserEntryAdapter(ctx, tc, e)).Run()
+			got, err := res.GetBoolResult()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %t, want %t", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkValidateMustStatements validates an intent with a large number of interfaces and
+// subinterfaces, whose leafs carry must-statements.
+func BenchmarkValidateMustStatements(b *testing.B) {
+	owner := "owner1"
+	prio := int32(10)
+	ctx := context.TODO()
+
+	scb, err := testhelper.GetSchemaClientBound(b)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	upds := []*cache.Update{}
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("ethernet-%d/%d", i/100+1, i%100+1)
+		upds = append(upds,
+			cache.NewUpdate([]string{"interface", name, "name"}, testhelper.GetStringTvProto(b, name), prio, owner, 0),
+			cache.NewUpdate([]string{"interface", name, "admin-state"}, testhelper.GetStringTvProto(b, "enable"), prio, owner, 0),
+		)
+		for j := 0; j < 4; j++ {
+			idx := strconv.Itoa(j)
+			upds = append(upds,
+				cache.NewUpdate([]string{"interface", name, "subinterface", idx, "index"}, testhelper.GetUIntTvProto(b, uint64(j)), prio, owner, 0),
+				cache.NewUpdate([]string{"interface", name, "subinterface", idx, "type"}, testhelper.GetStringTvProto(b, "routed"), prio, owner, 0),
+			)
+		}
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner)
+		root, err := NewTreeRoot(ctx, tc)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, u := range upds {
+			if _, err := root.AddCacheUpdateRecursive(ctx, u, true); err != nil {
+				b.Fatal(err)
+			}
+		}
+		root.FinishInsertionPhase()
+		b.StartTimer()
+
+		errChan := make(chan error, 10)
+		warnChan := make(chan error, 10)
+		go func() {
+			root.Validate(ctx, errChan, warnChan, true)
+			close(errChan)
+			close(warnChan)
+		}()
+		go func() {
+			for range warnChan {
+			}
+		}()
+		for e := range errChan {
+			b.Error(e)
+		}
+	}
+}
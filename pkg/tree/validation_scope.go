@@ -0,0 +1,12 @@
+package tree
+
+// ValidationScope defines the config that must-statements and leafrefs are evaluated against.
+type ValidationScope string
+
+const (
+	// ValidationScopeIntended evaluates against the merged intents only. Config that exists
+	// solely on the device is invisible and is itself not validated.
+	ValidationScopeIntended ValidationScope = "intended"
+	// ValidationScopeIntendedAndRunning evaluates against the merged intents and the running config of the device.
+	ValidationScopeIntendedAndRunning ValidationScope = "intended-and-running"
+)
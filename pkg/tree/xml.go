@@ -105,6 +105,10 @@ func (s *sharedEntryAttributes) toXmlInternal(parent *etree.Element, onlyNewOrUp
 				newElem := etree.NewElement(s.PathName())
 				// process the honorNamespace instruction
 				xmlAddNamespaceConditional(s, s.parent, newElem, honorNamespace)
+				// list entries that do not yet exist on the device are created as a whole
+				if onlyNewOrUpdated && s.treeContext.createNew && xmlNewToDevice(child) {
+					utils.AddXMLOperation(newElem, utils.XMLOperationCreate, operationWithNamespace, useOperationRemove)
+				}
 				// recurse the call
 				doAdd, err := child.toXmlInternal(newElem, onlyNewOrUpdated, honorNamespace, operationWithNamespace, useOperationRemove)
 				if err != nil {
@@ -119,8 +123,8 @@ func (s *sharedEntryAttributes) toXmlInternal(parent *etree.Element, onlyNewOrUp
 				}
 			}
 			return overallDoAdd, nil
-		case !s.remainsToExist():
-			// s is meant to be removed
+		case !s.remainsToExist() && !s.IsRoot():
+			// s is meant to be removed, the root is not rendered itself, its childs carry the deletes
 			// if delete, create the element as child of parent
 			newElem := parent.CreateElement(s.pathElemName)
 			// add namespace if we create doc with namespace and the actual namespace differs from the parent namespace
@@ -128,17 +132,23 @@ func (s *sharedEntryAttributes) toXmlInternal(parent *etree.Element, onlyNewOrUp
 			// add the delete / remove operation
 			utils.AddXMLOperation(newElem, utils.XMLOperationDelete, operationWithNamespace, useOperationRemove)
 			return true, nil
-		case s.childs.Length() == 0 && s.GetSchema().GetContainer().IsPresence:
+		case s.isAnyData():
+			// anydata and anyxml payloads are inserted verbatim
+			tv, err := s.anyDataValue(onlyNewOrUpdated)
+			if err != nil || tv == nil {
+				return false, err
+			}
+			newElem := parent.CreateElement(s.PathName())
+			xmlAddNamespaceConditional(s, s.parent, newElem, honorNamespace)
+			if err := anyDataToXml(newElem, tv); err != nil {
+				return false, err
+			}
+			return true, nil
+		case s.childs.Length() == 0 && s.isPresenceContainer():
 			// process presence cotnainers with no childs
-			if onlyNewOrUpdated {
-				// presence containers have leafvariantes with typedValue_Empty, so check that
-				if s.leafVariants.shouldDelete() {
-					return false, nil
-				}
-				le := s.leafVariants.GetHighestPrecedence(false, false)
-				if onlyNewOrUpdated && !(le.IsNew || le.IsUpdated) {
-					return false, nil
-				}
+			// presence containers have leafvariantes with typedValue_Empty, so check that
+			if !s.presenceExplicitlySet(onlyNewOrUpdated) {
+				return false, nil
 			}
 			newElem := parent.CreateElement(s.PathName())
 			// process the honorNamespace instruction
@@ -193,6 +203,12 @@ func (s *sharedEntryAttributes) toXmlInternal(parent *etree.Element, onlyNewOrUp
 			if overallDoAdd && s.parent != nil {
 				parent.AddChild(newElem)
 			}
+			// a presence container whose childs are all gone still needs to be rendered
+			if !overallDoAdd && s.presenceExplicitlySet(onlyNewOrUpdated) {
+				xmlAddNamespaceConditional(s, s.parent, newElem, honorNamespace)
+				parent.AddChild(newElem)
+				return true, nil
+			}
 			return overallDoAdd, nil
 		}
 
@@ -213,7 +229,7 @@ func (s *sharedEntryAttributes) toXmlInternal(parent *etree.Element, onlyNewOrUp
 		if le == nil {
 			return false, nil
 		}
-		v, err := le.Update.Value()
+		v, err := le.Value()
 		if err != nil {
 			return false, err
 		}
@@ -224,11 +240,38 @@ func (s *sharedEntryAttributes) toXmlInternal(parent *etree.Element, onlyNewOrUp
 		}
 		// convert value to XML and add to parent
 		utils.TypedValueToXML(parent, v, s.PathName(), ns, onlyNewOrUpdated, operationWithNamespace, useOperationRemove)
+		// fields that do not yet exist on the device are created, unless an ancestor is created already
+		if _, isField := s.schema.GetSchema().(*sdcpb.SchemaElem_Field); isField && onlyNewOrUpdated && s.treeContext.createNew &&
+			xmlNewToDevice(s) && !utils.HasXMLOperation(parent, utils.XMLOperationCreate) {
+			if elems := parent.ChildElements(); len(elems) > 0 {
+				utils.AddXMLOperation(elems[len(elems)-1], utils.XMLOperationCreate, operationWithNamespace, useOperationRemove)
+			}
+		}
 		return true, nil
 	}
 	return false, fmt.Errorf("unable to convert to xml (%s)", s.Path())
 }
 
+// xmlNewToDevice returns true if none of the values of the branch exist on the device and
+// the values that will be sent are new.
+func xmlNewToDevice(e Entry) bool {
+	newToDevice := true
+	_ = e.Walk(func(s *sharedEntryAttributes) error {
+		if s.leafVariants.Length() == 0 {
+			return nil
+		}
+		if s.leafVariants.GetByOwner(RunningIntentName) != nil {
+			newToDevice = false
+			return nil
+		}
+		if le := s.leafVariants.GetHighestPrecedence(false, false); le == nil || !le.GetNewFlag() {
+			newToDevice = false
+		}
+		return nil
+	})
+	return newToDevice
+}
+
 // namespaceIsEqual takes the two given Entries, gets the namespace
 // and reports if both belong to the same namespace
 func namespaceIsEqual(a Entry, b Entry) bool {
@@ -20,6 +20,7 @@ func TestToXMLTable(t *testing.T) {
 		honorNamespace         bool
 		operationWithNamespace bool
 		useOperationRemove     bool
+		createNew              bool
 		existingConfig         func(ctx context.Context, converter *utils.Converter) ([]*sdcpb.Update, error)
 		runningConfig          func(ctx context.Context, converter *utils.Converter) ([]*sdcpb.Update, error)
 		newConfig              func(ctx context.Context, converter *utils.Converter) ([]*sdcpb.Update, error)
@@ -233,6 +234,42 @@ func TestToXMLTable(t *testing.T) {
 				return expandUpdateFromConfig(ctx, c, converter)
 			},
 		},
+		{
+			name:             "XML - create new",
+			onlyNewOrUpdated: true,
+			createNew:        true,
+			existingConfig: func(ctx context.Context, converter *utils.Converter) ([]*sdcpb.Update, error) {
+				c := config1()
+				c.NetworkInstance["default"].Description = nil
+				return expandUpdateFromConfig(ctx, c, converter)
+			},
+			runningConfig: func(ctx context.Context, converter *utils.Converter) ([]*sdcpb.Update, error) {
+				c := config1()
+				c.NetworkInstance["default"].Description = nil
+				return expandUpdateFromConfig(ctx, c, converter)
+			},
+			expected: `<interface operation="create">
+  <admin-state>enable</admin-state>
+  <description>Test</description>
+  <name>ethernet-1/2</name>
+</interface>
+<network-instance>
+  <description operation="create">Default NI</description>
+  <name>default</name>
+</network-instance>
+<patterntest>bar</patterntest>
+`,
+			newConfig: func(ctx context.Context, converter *utils.Converter) ([]*sdcpb.Update, error) {
+				c := config1()
+				c.Interface["ethernet-1/2"] = &sdcio_schema.SdcioModel_Interface{
+					AdminState:  sdcio_schema.SdcioModelIf_AdminState_enable,
+					Name:        ygot.String("ethernet-1/2"),
+					Description: ygot.String("Test"),
+				}
+				c.Patterntest = ygot.String("bar")
+				return expandUpdateFromConfig(ctx, c, converter)
+			},
+		},
 		{
 			name:             "XML - replace direct leaf and choice",
 			onlyNewOrUpdated: true,
@@ -348,6 +385,7 @@ func TestToXMLTable(t *testing.T) {
 			converter := utils.NewConverter(scb)
 
 			tc := NewTreeContext(NewTreeSchemaCacheClient("dev1", nil, scb), owner)
+			tc.SetCreateNew(tt.createNew)
 			root, err := NewTreeRoot(ctx, tc)
 			if err != nil {
 				t.Fatal(err)
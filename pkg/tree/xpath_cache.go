@@ -0,0 +1,54 @@
+package tree
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/sdcio/yang-parser/xpath"
+	"github.com/sdcio/yang-parser/xpath/grammars/expr"
+)
+
+// xpathProgramCache caches the compiled xpath programs, keyed by the schema path and the expression.
+// The compiled programs are immutable, hence they are shared across trees and goroutines.
+type xpathProgramCache struct {
+	programs sync.Map // schema path + expression -> *xpath.Machine
+}
+
+// mustProgramCache is the cache of the compiled must-statements.
+var mustProgramCache = &xpathProgramCache{}
+
+// get returns the compiled program of the expression, compiling it on the first request.
+func (c *xpathProgramCache) get(schemaPath string, exprStr string) (*xpath.Machine, error) {
+	key := schemaPath + " " + exprStr
+	if m, ok := c.programs.Load(key); ok {
+		return m.(*xpath.Machine), nil
+	}
+	// init a ProgramBuilder
+	prgbuilder := xpath.NewProgBuilder(exprStr)
+	// init an ExpressionLexer
+	lexer := expr.NewExprLex(exprStr, prgbuilder, nil)
+	// allow the functions registered in xpath_functions.go
+	lexer.AllowCustomFns()
+	// parse the provided expression
+	lexer.Parse()
+	prog, err := lexer.CreateProgram(exprStr)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := c.programs.LoadOrStore(key, xpath.NewMachine(exprStr, prog, exprStr))
+	return m.(*xpath.Machine), nil
+}
+
+// schemaPath returns the path of the entry without the key levels, which is identical
+// for all the instances of a list.
+func schemaPath(e Entry) string {
+	chain := e.GetRootBasedEntryChain()
+	elems := make([]string, 0, len(chain))
+	for _, x := range chain {
+		if x.IsRoot() || x.GetSchema() == nil {
+			continue
+		}
+		elems = append(elems, x.PathName())
+	}
+	return strings.Join(elems, "/")
+}
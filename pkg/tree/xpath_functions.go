@@ -0,0 +1,46 @@
+package tree
+
+import (
+	"strings"
+
+	"github.com/sdcio/yang-parser/xpath"
+)
+
+// xpathFunctions are the YANG 1.1 XPath functions (RFC 7950, 10) that the yang-parser does
+// not provide itself and which are commonly used in the must and when statements of vendor models.
+var xpathFunctions = []xpath.CustomFunctionInfo{
+	{
+		Name:          "derived-from-or-self",
+		FnPtr:         derivedFromOrSelf,
+		Args:          []xpath.DatumTypeChecker{xpath.TypeIsObject, xpath.TypeIsLiteral},
+		RetType:       xpath.TypeIsBool,
+		DefaultRetVal: xpath.NewBoolDatum(false),
+	},
+}
+
+func init() {
+	xpath.RegisterCustomFunctions(xpathFunctions)
+}
+
+// derivedFromOrSelf implements derived-from-or-self(node-set, identity).
+// The schema does not carry the identity hierarchy, only the identities that are valid for a leaf.
+// Since the value was validated against these, the function reduces to the "or-self" part, comparing
+// the identity names. Prefixes are not compared, values carry the module name while expressions
+// use the module prefix.
+func derivedFromOrSelf(args []xpath.Datum) xpath.Datum {
+	identity := identityName(args[1].Literal("derived-from-or-self"))
+	for _, v := range args[0].DatumSlice("derived-from-or-self") {
+		if identityName(v.Literal("derived-from-or-self")) == identity {
+			return xpath.NewBoolDatum(true)
+		}
+	}
+	return xpath.NewBoolDatum(false)
+}
+
+// identityName strips the prefix off the given identityref value.
+func identityName(s string) string {
+	if idx := strings.LastIndex(s, ":"); idx >= 0 {
+		return s[idx+1:]
+	}
+	return s
+}
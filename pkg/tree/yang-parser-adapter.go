@@ -2,7 +2,10 @@ package tree
 
 import (
 	"context"
+	"encoding/xml"
 	"fmt"
+	"slices"
+	"strings"
 
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 	"github.com/sdcio/yang-parser/xpath"
@@ -12,17 +15,20 @@ import (
 type yangParserEntryAdapter struct {
 	e   Entry
 	ctx context.Context
+	// tc memoizes the navigations, may be nil
+	tc *TreeContext
 }
 
-func newYangParserEntryAdapter(ctx context.Context, e Entry) *yangParserEntryAdapter {
+func newYangParserEntryAdapter(ctx context.Context, tc *TreeContext, e Entry) *yangParserEntryAdapter {
 	return &yangParserEntryAdapter{
 		e:   e,
 		ctx: ctx,
+		tc:  tc,
 	}
 }
 
 func (y *yangParserEntryAdapter) Copy() xpath.Entry {
-	return newYangParserEntryAdapter(y.ctx, y.e)
+	return newYangParserEntryAdapter(y.ctx, y.tc, y.e)
 }
 
 func (y *yangParserEntryAdapter) valueToDatum(tv *sdcpb.TypedValue) xpath.Datum {
@@ -48,6 +54,10 @@ func (y *yangParserEntryAdapter) valueToDatum(tv *sdcpb.TypedValue) xpath.Datum
 }
 
 func (y *yangParserEntryAdapter) GetValue() (xpath.Datum, error) {
+	// a list yields a nodeset with one node per instance, such that e.g. count() can be applied
+	if keys := y.e.GetSchemaKeys(); len(keys) > 0 && y.e.GetSchema() != nil {
+		return xpath.NewNodesetDatum(y.listInstances(y.e, len(keys))), nil
+	}
 	if y.e.GetSchema() == nil || y.e.GetSchema().GetContainer() != nil {
 		return xpath.NewBoolDatum(true), nil
 	}
@@ -56,7 +66,7 @@ func (y *yangParserEntryAdapter) GetValue() (xpath.Datum, error) {
 	if lv == nil {
 		return xpath.NewNodesetDatum([]xutils.XpathNode{}), nil
 	}
-	tv, err := lv.Update.Value()
+	tv, err := lv.Value()
 	if err != nil {
 		return nil, err
 	}
@@ -64,17 +74,37 @@ func (y *yangParserEntryAdapter) GetValue() (xpath.Datum, error) {
 	return y.valueToDatum(tv), nil
 }
 
+// listInstances returns a node for every instance of the list, descending the given number of key levels.
+// Instances that will disappear from the device or are not part of the validation scope are skipped.
+func (y *yangParserEntryAdapter) listInstances(e Entry, keyLevels int) []xutils.XpathNode {
+	result := []xutils.XpathNode{}
+	for _, c := range e.getChildren() {
+		if keyLevels > 1 {
+			result = append(result, y.listInstances(c, keyLevels-1)...)
+			continue
+		}
+		if (y.tc == nil && c.remainsToExist()) || (y.tc != nil && y.tc.inValidationScope(c)) {
+			result = append(result, &yangParserListNode{e: c})
+		}
+	}
+	return result
+}
+
 func (y *yangParserEntryAdapter) FollowLeafRef() (xpath.Entry, error) {
 	entries, err := y.e.NavigateLeafRef(y.ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	if y.tc != nil && y.tc.GetValidationScope() == ValidationScopeIntended {
+		entries = slices.DeleteFunc(entries, func(e Entry) bool { return !e.remainsInIntended() })
+	}
+
 	if len(entries) == 0 {
 		return nil, fmt.Errorf("error resolving leafref for %s", y.e.Path())
 	}
 
-	return newYangParserEntryAdapter(y.ctx, entries[0]), nil
+	return newYangParserEntryAdapter(y.ctx, y.tc, entries[0]), nil
 }
 
 func (y *yangParserEntryAdapter) GetPath() []string {
@@ -89,6 +119,15 @@ func (y *yangParserEntryAdapter) Navigate(p []string) (xpath.Entry, error) {
 		return y, nil
 	}
 
+	// the same navigations are performed by all the must-statements of an entry
+	navKey := ""
+	if y.tc != nil {
+		navKey = PathKey(y.e.Path()) + " " + strings.Join(p, "/")
+		if e, ok := y.tc.xpathNavigations.Load(navKey); ok {
+			return newYangParserEntryAdapter(y.ctx, y.tc, e.(Entry)), nil
+		}
+	}
+
 	// if the path slice starts with a / then it is a root based path.
 	if p[0] == "/" {
 		p = p[1:]
@@ -111,7 +150,16 @@ func (y *yangParserEntryAdapter) Navigate(p []string) (xpath.Entry, error) {
 		}
 	}
 
-	return newYangParserEntryAdapter(y.ctx, lookedUpEntry), nil
+	// entries that solely exist in running are invisible if the validation is scoped to the intended config
+	if y.tc != nil && y.tc.GetValidationScope() == ValidationScopeIntended && !lookedUpEntry.remainsInIntended() {
+		return newYangParserValueEntry(xpath.NewNodesetDatum([]xutils.XpathNode{}), nil), nil
+	}
+
+	// only successful navigations are memoized, entries might be added to the tree later on, e.g. defaults
+	if y.tc != nil {
+		y.tc.xpathNavigations.Store(navKey, lookedUpEntry)
+	}
+	return newYangParserEntryAdapter(y.ctx, y.tc, lookedUpEntry), nil
 }
 
 type yangParserValueEntry struct {
@@ -145,3 +193,35 @@ func (y *yangParserValueEntry) GetValue() (xpath.Datum, error) {
 func (y *yangParserValueEntry) GetPath() []string {
 	return nil
 }
+
+// yangParserListNode is the xutils.XpathNode of a list instance. It carries no value,
+// it allows functions like count() to be applied to lists.
+type yangParserListNode struct {
+	e Entry
+}
+
+func (n *yangParserListNode) XParent() xutils.XpathNode { return nil }
+
+func (n *yangParserListNode) XChildren(filter xutils.XFilter, sortSpec xutils.SortSpec) []xutils.XpathNode {
+	return nil
+}
+
+func (n *yangParserListNode) XPath() xutils.PathType { return xutils.PathType(n.e.Path()) }
+
+func (n *yangParserListNode) XRoot() xutils.XpathNode { return nil }
+
+func (n *yangParserListNode) XName() string { return n.e.PathName() }
+
+func (n *yangParserListNode) XValue() string { return "" }
+
+func (n *yangParserListNode) XIsLeaf() bool { return false }
+
+func (n *yangParserListNode) XIsLeafList() bool { return false }
+
+func (n *yangParserListNode) XIsNonPresCont() bool { return false }
+
+func (n *yangParserListNode) XIsEphemeral() bool { return false }
+
+func (n *yangParserListNode) XListKeyMatches(key xml.Name, val string) bool { return false }
+
+func (n *yangParserListNode) XListKeys() []xutils.NodeRefKey { return nil }
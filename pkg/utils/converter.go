@@ -11,7 +11,7 @@ import (
 	"strings"
 
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -47,7 +47,15 @@ func (c *Converter) ExpandUpdates(ctx context.Context, updates []*sdcpb.Update,
 }
 
 // expandUpdate Expands the value, in case of json to single typed value updates
+// The value is expanded according to its TypedValue variant:
+//   - JsonVal: plain JSON, member names are not namespaced
+//   - JsonIetfVal: JSON_IETF (RFC 7951), member names may be prefixed with their module name
+//   - ProtoBytes: a proto encoded sdcpb.TypedValue, either binary or protojson, that is expanded according to its own variant
 func (c *Converter) ExpandUpdate(ctx context.Context, upd *sdcpb.Update, includeKeysAsLeaf bool) ([]*sdcpb.Update, error) {
+	upd, err := decodeProtoBytesUpdate(upd)
+	if err != nil {
+		return nil, err
+	}
 	upds := make([]*sdcpb.Update, 0)
 	if includeKeysAsLeaf {
 		// expand update path if it contains keys
@@ -65,6 +73,10 @@ func (c *Converter) ExpandUpdate(ctx context.Context, upd *sdcpb.Update, include
 	switch rsp := rsp.GetSchema().Schema.(type) {
 	case *sdcpb.SchemaElem_Container:
 		log.Debugf("expanding update %v on container %q", upd, rsp.Container.Name)
+		// the content of anydata and anyxml nodes is opaque and kept verbatim
+		if IsAnyData(&sdcpb.SchemaElem{Schema: rsp}) {
+			return append(upds, upd), nil
+		}
 		var v interface{}
 		var err error
 		var jsonDecoder *json.Decoder
@@ -83,6 +95,9 @@ func (c *Converter) ExpandUpdate(ctx context.Context, upd *sdcpb.Update, include
 		if err != nil {
 			return nil, err
 		}
+		if upd.GetValue().GetJsonIetfVal() != nil {
+			v = stripJSONIETFNamespaces(v)
+		}
 		log.Debugf("update has jsonVal: %T, %v\n", v, v)
 		rs, err := c.ExpandContainerValue(ctx, upd.GetPath(), v, rsp, includeKeysAsLeaf)
 		if err != nil {
@@ -91,74 +106,131 @@ func (c *Converter) ExpandUpdate(ctx context.Context, upd *sdcpb.Update, include
 		upds := append(upds, rs...)
 		return upds, nil
 	case *sdcpb.SchemaElem_Field:
-		var v interface{}
-		var err error
-
-		var jsonValue []byte
-		switch upd.GetValue().Value.(type) {
-		case *sdcpb.TypedValue_JsonVal:
-			jsonValue = upd.GetValue().GetJsonVal()
-		case *sdcpb.TypedValue_JsonIetfVal:
-			jsonValue = upd.GetValue().GetJsonIetfVal()
+		jsonValue, v, err := decodeJSONValue(upd.GetValue())
+		if err != nil {
+			return nil, err
 		}
-
-		// process value
-		if jsonValue != nil {
-			err = json.Unmarshal(jsonValue, &v)
-			if err != nil {
-				return nil, err
-			}
+		if jsonValue {
 			switch v := v.(type) {
+			case nil, map[string]any, []any:
+				return nil, fmt.Errorf("leaf %s expects a scalar value, but %v was given", rsp.Field.GetName(), v)
 			case string:
-				upd.Value = &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: v}}
+				// a JSON string value is kept as is, the conversion to the YANG type is left to the schema aware processing
+				upd = &sdcpb.Update{Path: upd.GetPath(), Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: v}}}
+			default:
+				tv, err := TypedValueToYANGType(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: fmt.Sprintf("%v", v)}}, &sdcpb.SchemaElem{Schema: rsp})
+				if err != nil {
+					return nil, err
+				}
+				upd = &sdcpb.Update{Path: upd.GetPath(), Value: tv}
 			}
 		}
-
-		// TODO: Check if value is json and convert to String ?
 		upds = append(upds, upd)
 		return upds, nil
 	case *sdcpb.SchemaElem_Leaflist:
-		// TODO: Check if value is json and convert to String ?
+		jsonValue, v, err := decodeJSONValue(upd.GetValue())
+		if err != nil {
+			return nil, err
+		}
+		if jsonValue {
+			elems, ok := v.([]any)
+			if !ok {
+				return nil, fmt.Errorf("leaflist %s expects array as input, but %v was given", rsp.Leaflist.GetName(), v)
+			}
+			list := make([]*sdcpb.TypedValue, 0, len(elems))
+			for _, e := range elems {
+				tv, err := TypedValueToYANGType(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: fmt.Sprintf("%v", e)}}, &sdcpb.SchemaElem{Schema: rsp})
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, tv)
+			}
+			upd = &sdcpb.Update{
+				Path:  upd.GetPath(),
+				Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_LeaflistVal{LeaflistVal: &sdcpb.ScalarArray{Element: list}}},
+			}
+		}
 		upds = append(upds, upd)
 		return upds, nil
 	}
 	return nil, nil
 }
 
-func (c *Converter) ExpandUpdateKeysAsLeaf(ctx context.Context, upd *sdcpb.Update) ([]*sdcpb.Update, error) {
-	upds := make([]*sdcpb.Update, 0)
-	// expand update path if it contains keys
-	for i, pe := range upd.GetPath().GetElem() {
-		if len(pe.GetKey()) == 0 {
-			continue
-		}
+// decodeProtoBytesUpdate returns the update with the proto encoded TypedValue of a ProtoBytes value decoded.
+// Other updates are returned as is.
+func decodeProtoBytesUpdate(upd *sdcpb.Update) (*sdcpb.Update, error) {
+	b := upd.GetValue().GetProtoBytes()
+	if b == nil {
+		return upd, nil
+	}
+	tv := &sdcpb.TypedValue{}
+	var err error
+	if trimmed := bytes.TrimSpace(b); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = protojson.Unmarshal(b, tv)
+	} else {
+		err = proto.Unmarshal(b, tv)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed decoding the proto encoded value of %s: %w", ToXPath(upd.GetPath(), false), err)
+	}
+	if tv.GetProtoBytes() != nil {
+		return nil, fmt.Errorf("nested proto encoded value of %s", ToXPath(upd.GetPath(), false))
+	}
+	return &sdcpb.Update{Path: upd.GetPath(), Value: tv}, nil
+}
 
-		for k, v := range pe.GetKey() {
-			intUpd := &sdcpb.Update{
-				Path: &sdcpb.Path{
-					Elem: make([]*sdcpb.PathElem, 0, i+1+1),
-				},
-			}
-			for j := 0; j <= i; j++ {
-				intUpd.Path.Elem = append(intUpd.Path.Elem,
-					&sdcpb.PathElem{
-						Name: upd.GetPath().GetElem()[j].GetName(),
-						Key:  upd.GetPath().GetElem()[j].GetKey(),
-					},
-				)
-			}
-			intUpd.Path.Elem = append(intUpd.Path.Elem, &sdcpb.PathElem{Name: k})
+// decodeJSONValue decodes JSON and JSON_IETF values, numbers are kept as json.Number to
+// not lose the precision of long integers. false is returned for all other values.
+func decodeJSONValue(tv *sdcpb.TypedValue) (bool, any, error) {
+	var b []byte
+	switch tv.GetValue().(type) {
+	case *sdcpb.TypedValue_JsonVal:
+		b = tv.GetJsonVal()
+	case *sdcpb.TypedValue_JsonIetfVal:
+		b = tv.GetJsonIetfVal()
+	default:
+		return false, nil, nil
+	}
+	var v any
+	jsonDecoder := json.NewDecoder(bytes.NewReader(b))
+	jsonDecoder.UseNumber()
+	if err := jsonDecoder.Decode(&v); err != nil {
+		return true, nil, err
+	}
+	return true, v, nil
+}
 
-			schemaRsp, err := c.schemaClientBound.GetSchema(ctx, intUpd.Path)
-			if err != nil {
-				return nil, err
-			}
-			intUpd.Value, err = TypedValueToYANGType(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: v}}, schemaRsp.GetSchema())
-			if err != nil {
-				return nil, err
+// stripJSONIETFNamespaces removes the module name prefixes of the member names of a JSON_IETF value (RFC 7951 Section 4).
+func stripJSONIETFNamespaces(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for k, mv := range v {
+			if _, name, found := strings.Cut(k, ":"); found {
+				k = name
 			}
-			upds = append(upds, intUpd)
+			result[k] = stripJSONIETFNamespaces(mv)
+		}
+		return result
+	case []any:
+		for i, e := range v {
+			v[i] = stripJSONIETFNamespaces(e)
 		}
+		return v
+	}
+	return v
+}
+
+// ExpandUpdateKeysAsLeaf returns the key leaf updates of all the list entries referenced by the path of the update.
+func (c *Converter) ExpandUpdateKeysAsLeaf(ctx context.Context, upd *sdcpb.Update) ([]*sdcpb.Update, error) {
+	keyLeaves := KeysAsLeaves(upd.GetPath())
+	upds := make([]*sdcpb.Update, 0, len(keyLeaves))
+	for _, kl := range keyLeaves {
+		intUpd, err := c.keyAsLeafUpdate(ctx, kl.Path, kl.Value)
+		if err != nil {
+			return nil, err
+		}
+		upds = append(upds, intUpd)
 	}
 	return upds, nil
 }
@@ -215,18 +287,10 @@ func (c *Converter) ExpandContainerValue(ctx context.Context, p *sdcpb.Path, jv
 				if includeKeysAsLeaf {
 					np := proto.Clone(p).(*sdcpb.Path)
 					np.Elem = append(np.Elem, &sdcpb.PathElem{Name: k.Name})
-					schemaRsp, err := c.schemaClientBound.GetSchema(ctx, np)
-					if err != nil {
-						return nil, err
-					}
-					updVal, err := TypedValueToYANGType(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: fmt.Sprintf("%v", v)}}, schemaRsp.GetSchema())
+					upd, err := c.keyAsLeafUpdate(ctx, np, fmt.Sprintf("%v", v))
 					if err != nil {
 						return nil, err
 					}
-					upd := &sdcpb.Update{
-						Path:  np,
-						Value: updVal,
-					}
 					upds = append(upds, upd)
 				}
 				continue
@@ -322,7 +386,15 @@ func (c *Converter) ExpandContainerValue(ctx context.Context, p *sdcpb.Path, jv
 					var rs []*sdcpb.Update
 					// code for presence containers
 					m, ok := v.(map[string]any)
-					if ok && len(m) == 0 && rsp.Container.IsPresence {
+					switch {
+					case IsAnyData(&sdcpb.SchemaElem{Schema: rsp}):
+						// anydata and anyxml content is not expanded but kept as a single opaque value
+						tv, err := AnyDataValue(v)
+						if err != nil {
+							return nil, err
+						}
+						rs = []*sdcpb.Update{{Path: np, Value: tv}}
+					case ok && len(m) == 0 && rsp.Container.IsPresence:
 						rs = []*sdcpb.Update{
 							{
 								Path: np,
@@ -330,7 +402,7 @@ func (c *Converter) ExpandContainerValue(ctx context.Context, p *sdcpb.Path, jv
 									Value: &sdcpb.TypedValue_EmptyVal{},
 								},
 							}}
-					} else {
+					default:
 						rs, err = c.ExpandContainerValue(ctx, np, v, rsp, includeKeysAsLeaf)
 						if err != nil {
 							return nil, err
@@ -382,7 +454,10 @@ func TypedValueToYANGType(tv *sdcpb.TypedValue, schemaObject *sdcpb.SchemaElem)
 	case *sdcpb.TypedValue_BytesVal:
 		return tv, nil
 	case *sdcpb.TypedValue_DecimalVal:
-		return tv, nil
+		return &sdcpb.TypedValue{
+			Timestamp: tv.GetTimestamp(),
+			Value:     &sdcpb.TypedValue_DecimalVal{DecimalVal: NormalizeDecimal64(tv.GetDecimalVal())},
+		}, nil
 	case *sdcpb.TypedValue_FloatVal:
 		return tv, nil
 	case *sdcpb.TypedValue_DoubleVal:
@@ -427,7 +502,7 @@ func convertStringToTv(schemaType *sdcpb.SchemaLeafType, v string, ts uint64) (*
 	case "string":
 		return &sdcpb.TypedValue{
 
-			Value: &sdcpb.TypedValue_StringVal{StringVal: v},
+			Value: &sdcpb.TypedValue_StringVal{StringVal: NormalizeString(v, schemaType)},
 		}, nil
 	case "uint64", "uint32", "uint16", "uint8":
 		i, err := strconv.ParseUint(v, 10, 64)
@@ -457,24 +532,16 @@ func convertStringToTv(schemaType *sdcpb.SchemaLeafType, v string, ts uint64) (*
 			Value:     &sdcpb.TypedValue_BoolVal{BoolVal: b},
 		}, nil
 	case "decimal64":
-		arr := strings.SplitN(v, ".", 2)
-		digits, err := strconv.ParseInt(arr[0], 10, 64)
-		if err != nil {
-			return nil, err
-		}
-		precision64, err := strconv.ParseUint(arr[1], 10, 32)
+		d64, err := ParseDecimal64(v)
 		if err != nil {
 			return nil, err
 		}
-		precision := uint32(precision64)
 		return &sdcpb.TypedValue{
-			Value: &sdcpb.TypedValue_DecimalVal{DecimalVal: &sdcpb.Decimal64{Digits: digits, Precision: precision}},
+			Value: &sdcpb.TypedValue_DecimalVal{DecimalVal: NormalizeDecimal64(d64)},
 		}, nil
 	case "identityref":
-		before, name, found := strings.Cut(v, ":")
-		if !found {
-			name = before
-		}
+		// accept bare, prefixed or differently cased identities and use their canonical name
+		name, _ := canonicalIdentity(v, schemaType)
 		prefix, ok := schemaType.IdentityPrefixesMap[name]
 		if !ok {
 			identities := make([]string, 0, len(schemaType.IdentityPrefixesMap))
@@ -498,15 +565,17 @@ func convertStringToTv(schemaType *sdcpb.SchemaLeafType, v string, ts uint64) (*
 	case "leafref":
 		return convertStringToTv(schemaType.LeafrefTargetType, v, ts)
 	case "union":
-		for _, ut := range schemaType.GetUnionTypes() {
-			tv, err := convertStringToTv(ut, v, ts)
-			if err == nil {
-				return tv, nil
-			}
+		tv, err := ConvertUnion(v, schemaType.GetUnionTypes())
+		if err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("invalid value %s for union type: %v", v, schemaType)
+		tv.Timestamp = ts
+		return tv, nil
 	case "enumeration":
 		// TODO: get correct type, assuming string
+		if item, ok := canonicalEnum(v, schemaType.GetEnumNames()); ok {
+			v = item
+		}
 		return &sdcpb.TypedValue{
 			Timestamp: ts,
 			Value:     &sdcpb.TypedValue_StringVal{StringVal: v},
@@ -615,6 +684,9 @@ func ConvertTypedValueToYANGType(schemaElem *sdcpb.SchemaElem, tv *sdcpb.TypedVa
 				Value:     &sdcpb.TypedValue_EmptyVal{},
 			}, nil
 		}
+		if IsAnyData(schemaElem) {
+			return tv, nil
+		}
 	case schemaElem.GetLeaflist() != nil:
 		switch tv.Value.(type) {
 		case *sdcpb.TypedValue_LeaflistVal:
@@ -657,7 +729,12 @@ func ConvertTypedValueToYANGType(schemaElem *sdcpb.SchemaElem, tv *sdcpb.TypedVa
 		case "enumeration":
 			return tv, nil
 		case "union":
-			return tv, nil
+			ctv, err := ConvertUnionTypedValue(tv, schemaElem.GetField().GetType().GetUnionTypes())
+			if err != nil {
+				return nil, err
+			}
+			ctv.Timestamp = tv.GetTimestamp()
+			return ctv, nil
 		case "boolean":
 			v, err := strconv.ParseBool(TypedValueToString(tv))
 			if err != nil {
@@ -671,7 +748,7 @@ func ConvertTypedValueToYANGType(schemaElem *sdcpb.SchemaElem, tv *sdcpb.TypedVa
 			}
 			return &sdcpb.TypedValue{
 				Value: &sdcpb.TypedValue_DecimalVal{
-					DecimalVal: d64,
+					DecimalVal: NormalizeDecimal64(d64),
 				},
 			}, nil
 		case "float":
@@ -691,7 +768,7 @@ func ConvertTypedValueToYANGType(schemaElem *sdcpb.SchemaElem, tv *sdcpb.TypedVa
 func convertUpdateTypedValue(_ context.Context, upd *sdcpb.Update, scRsp *sdcpb.GetSchemaResponse, leaflists map[string]*leafListNotification) (*sdcpb.Update, error) {
 	switch {
 	case scRsp.GetSchema().GetContainer() != nil:
-		if !scRsp.GetSchema().GetContainer().GetIsPresence() {
+		if !scRsp.GetSchema().GetContainer().GetIsPresence() && !IsAnyData(scRsp.GetSchema()) {
 			return nil, nil
 		}
 		return upd, nil
@@ -0,0 +1,198 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+// JSONSchemaDialect is the JSON Schema dialect of the generated schemas, which is also the dialect
+// of the schema objects of OpenAPI 3.1.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// the bounds of the integer types
+var integerBounds = map[string][2]float64{
+	"int8":   {math.MinInt8, math.MaxInt8},
+	"int16":  {math.MinInt16, math.MaxInt16},
+	"int32":  {math.MinInt32, math.MaxInt32},
+	"int64":  {math.MinInt64, math.MaxInt64},
+	"uint8":  {0, math.MaxUint8},
+	"uint16": {0, math.MaxUint16},
+	"uint32": {0, math.MaxUint32},
+	"uint64": {0, math.MaxUint64},
+}
+
+// LeafTypeJSONSchema returns the JSON Schema of the JSON values of a leaf of the given type.
+// It accepts the values the intents accept: numbers and booleans may also be given as strings,
+// as done by JSON_IETF for the 64 bit types (RFC 7951 Section 6.1).
+func LeafTypeJSONSchema(t *sdcpb.SchemaLeafType) map[string]any {
+	switch t.GetType() {
+	case "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
+		s := map[string]any{
+			"type":    []any{"integer", "string"},
+			"pattern": `^[+-]?[0-9]+$`,
+		}
+		bounds := integerBounds[t.GetType()]
+		addRanges(s, "minimum", "maximum", t.GetRange(), bounds[0], bounds[1])
+		return s
+	case "decimal64":
+		s := map[string]any{
+			"type":    []any{"number", "string"},
+			"pattern": `^[+-]?[0-9]+(\.[0-9]+)?$`,
+		}
+		addRanges(s, "minimum", "maximum", t.GetRange(), math.Inf(-1), math.Inf(1))
+		return s
+	case "boolean":
+		return map[string]any{"enum": []any{true, false, "true", "false"}}
+	case "enumeration":
+		names := make([]any, 0, len(t.GetEnumNames()))
+		for _, n := range t.GetEnumNames() {
+			names = append(names, n)
+		}
+		return map[string]any{"type": "string", "enum": names}
+	case "identityref":
+		s := map[string]any{"type": "string"}
+		if len(t.GetIdentityPrefixesMap()) > 0 {
+			names := make([]string, 0, len(t.GetIdentityPrefixesMap()))
+			for n := range t.GetIdentityPrefixesMap() {
+				names = append(names, regexp.QuoteMeta(n))
+			}
+			sort.Strings(names)
+			// the identities may be prefixed by their module name or prefix
+			s["pattern"] = "^([^:]+:)?(" + strings.Join(names, "|") + ")$"
+		}
+		return s
+	case "union":
+		members := make([]any, 0, len(t.GetUnionTypes()))
+		for _, ut := range t.GetUnionTypes() {
+			members = append(members, LeafTypeJSONSchema(ut))
+		}
+		return map[string]any{"anyOf": members}
+	case "leafref":
+		if t.GetLeafrefTargetType() != nil {
+			return LeafTypeJSONSchema(t.GetLeafrefTargetType())
+		}
+		return map[string]any{"type": "string"}
+	case "empty":
+		// the value of an empty leaf is ignored, RFC 7951 encodes it as [null]
+		return map[string]any{}
+	case "binary":
+		return map[string]any{"type": "string", "contentEncoding": "base64"}
+	case "string":
+		s := map[string]any{"type": "string"}
+		addRanges(s, "minLength", "maxLength", t.GetLength(), 0, math.Inf(1))
+		addPatterns(s, t.GetPatterns())
+		return s
+	}
+	// bits, instance-identifier and the unknown types are validated as strings
+	return map[string]any{"type": "string"}
+}
+
+// addRanges adds the bounds of the ranges, limited to the bounds of the type, to the schema.
+// Several ranges are added as alternatives.
+func addRanges(s map[string]any, minKey, maxKey string, ranges []*sdcpb.SchemaMinMaxType, typeMin, typeMax float64) {
+	bounds := func(r *sdcpb.SchemaMinMaxType) map[string]any {
+		lo, hi := typeMin, typeMax
+		if r.GetMin() != nil {
+			lo = max(lo, numberValue(r.GetMin()))
+		}
+		if r.GetMax() != nil {
+			hi = min(hi, numberValue(r.GetMax()))
+		}
+		b := map[string]any{}
+		// a minimal length of 0 does not restrict the value
+		if !math.IsInf(lo, 0) && (minKey != "minLength" || lo > 0) {
+			b[minKey] = lo
+		}
+		if !math.IsInf(hi, 0) {
+			b[maxKey] = hi
+		}
+		return b
+	}
+	if len(ranges) <= 1 {
+		var r *sdcpb.SchemaMinMaxType
+		if len(ranges) == 1 {
+			r = ranges[0]
+		}
+		for k, v := range bounds(r) {
+			s[k] = v
+		}
+		return
+	}
+	alternatives := make([]any, 0, len(ranges))
+	for _, r := range ranges {
+		alternatives = append(alternatives, bounds(r))
+	}
+	s["anyOf"] = alternatives
+}
+
+// addPatterns adds the patterns to the schema. The YANG patterns are translated into anchored
+// ECMA-262 regular expressions, the patterns that cannot be translated are omitted.
+func addPatterns(s map[string]any, patterns []*sdcpb.SchemaPattern) {
+	all := make([]any, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := XSDPatternToRE2(p.GetPattern())
+		if err != nil {
+			log.Debugf("omitting pattern %q from the JSON schema: %v", p.GetPattern(), err)
+			continue
+		}
+		ps := map[string]any{"pattern": re2ToECMA(re)}
+		if p.GetInverted() {
+			ps = map[string]any{"not": ps}
+		}
+		all = append(all, ps)
+	}
+	switch len(all) {
+	case 0:
+	case 1:
+		for k, v := range all[0].(map[string]any) {
+			s[k] = v
+		}
+	default:
+		s["allOf"] = all
+	}
+}
+
+// re2ToECMA rewrites the code point escapes of the RE2 expressions generated by XSDPatternToRE2,
+// \x{...}, into their ECMA-262 (unicode mode) form \u{...}.
+func re2ToECMA(re string) string {
+	sb := &strings.Builder{}
+	for i := 0; i < len(re); i++ {
+		if re[i] != '\\' || i+1 == len(re) {
+			sb.WriteByte(re[i])
+			continue
+		}
+		if strings.HasPrefix(re[i+1:], "x{") {
+			sb.WriteString(`\u`)
+		} else {
+			sb.WriteByte(re[i])
+			sb.WriteByte(re[i+1])
+		}
+		i++
+	}
+	return sb.String()
+}
+
+func numberValue(n *sdcpb.Number) float64 {
+	if n.GetNegative() {
+		return -float64(n.GetValue())
+	}
+	return float64(n.GetValue())
+}
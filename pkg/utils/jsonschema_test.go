@@ -0,0 +1,123 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+func TestLeafTypeJSONSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		t    *sdcpb.SchemaLeafType
+		want map[string]any
+	}{
+		{
+			name: "unsigned integer bounds",
+			t:    &sdcpb.SchemaLeafType{Type: "uint16"},
+			want: map[string]any{
+				"type":    []any{"integer", "string"},
+				"pattern": `^[+-]?[0-9]+$`,
+				"minimum": float64(0),
+				"maximum": float64(math.MaxUint16),
+			},
+		},
+		{
+			name: "integer ranges",
+			t: &sdcpb.SchemaLeafType{
+				Type: "int8",
+				Range: []*sdcpb.SchemaMinMaxType{
+					{Min: &sdcpb.Number{Value: 5, Negative: true}, Max: &sdcpb.Number{Value: 0}},
+					{Min: &sdcpb.Number{Value: 10}, Max: &sdcpb.Number{Value: 200}},
+				},
+			},
+			want: map[string]any{
+				"type":    []any{"integer", "string"},
+				"pattern": `^[+-]?[0-9]+$`,
+				"anyOf": []any{
+					map[string]any{"minimum": float64(-5), "maximum": float64(0)},
+					map[string]any{"minimum": float64(10), "maximum": float64(math.MaxInt8)},
+				},
+			},
+		},
+		{
+			name: "string length and patterns",
+			t: &sdcpb.SchemaLeafType{
+				Type:     "string",
+				Length:   []*sdcpb.SchemaMinMaxType{{Min: &sdcpb.Number{Value: 0}, Max: &sdcpb.Number{Value: 8}}},
+				Patterns: []*sdcpb.SchemaPattern{{Pattern: `a$b`}, {Pattern: `x.*`, Inverted: true}},
+			},
+			want: map[string]any{
+				"type":      "string",
+				"maxLength": float64(8),
+				"allOf": []any{
+					map[string]any{"pattern": `^(?:a\$b)$`},
+					map[string]any{"not": map[string]any{"pattern": `^(?:x[^\n\r]*)$`}},
+				},
+			},
+		},
+		{
+			name: "code point escapes",
+			t: &sdcpb.SchemaLeafType{
+				Type:     "string",
+				Patterns: []*sdcpb.SchemaPattern{{Pattern: `[ab]`}},
+			},
+			want: map[string]any{
+				"type":    "string",
+				"pattern": `^(?:[\u{61}-\u{62}])$`,
+			},
+		},
+		{
+			name: "enumeration",
+			t:    &sdcpb.SchemaLeafType{Type: "enumeration", EnumNames: []string{"enable", "disable"}},
+			want: map[string]any{"type": "string", "enum": []any{"enable", "disable"}},
+		},
+		{
+			name: "identityref",
+			t: &sdcpb.SchemaLeafType{
+				Type:                "identityref",
+				IdentityPrefixesMap: map[string]string{"rsa": "crypto", "des3": "crypto"},
+			},
+			want: map[string]any{"type": "string", "pattern": `^([^:]+:)?(des3|rsa)$`},
+		},
+		{
+			name: "union of leafref and boolean",
+			t: &sdcpb.SchemaLeafType{
+				Type: "union",
+				UnionTypes: []*sdcpb.SchemaLeafType{
+					{Type: "leafref", LeafrefTargetType: &sdcpb.SchemaLeafType{Type: "enumeration", EnumNames: []string{"auto"}}},
+					{Type: "boolean"},
+				},
+			},
+			want: map[string]any{
+				"anyOf": []any{
+					map[string]any{"type": "string", "enum": []any{"auto"}},
+					map[string]any{"enum": []any{true, false, "true", "false"}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LeafTypeJSONSchema(tt.t); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LeafTypeJSONSchema() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"maps"
+	"sort"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+// PathIsKeyAsLeaf returns true if the last element of the path is a key leaf
+// of the list entry referenced by the element before it, e.g. interface[name=eth0]/name.
+func PathIsKeyAsLeaf(p *sdcpb.Path) bool {
+	numPElem := len(p.GetElem())
+	if numPElem < 2 {
+		return false
+	}
+	_, ok := p.GetElem()[numPElem-2].GetKey()[p.GetElem()[numPElem-1].GetName()]
+	return ok
+}
+
+// KeyAsLeaf is a key leaf synthesized from the keys of a path.
+type KeyAsLeaf struct {
+	// Path of the key leaf, e.g. interface[name=eth0]/name
+	Path *sdcpb.Path
+	// Value is the key value as found in the path
+	Value string
+}
+
+// KeysAsLeaves returns the key leaves of all the list entries referenced by the path.
+// They are ordered by their depth in the path and by key name within a list entry.
+func KeysAsLeaves(p *sdcpb.Path) []*KeyAsLeaf {
+	result := make([]*KeyAsLeaf, 0)
+	for i, pe := range p.GetElem() {
+		if len(pe.GetKey()) == 0 {
+			continue
+		}
+		keyNames := make([]string, 0, len(pe.GetKey()))
+		for k := range pe.GetKey() {
+			keyNames = append(keyNames, k)
+		}
+		sort.Strings(keyNames)
+
+		for _, k := range keyNames {
+			kp := &sdcpb.Path{
+				Origin: p.GetOrigin(),
+				Target: p.GetTarget(),
+				Elem:   make([]*sdcpb.PathElem, 0, i+2),
+			}
+			for _, ppe := range p.GetElem()[:i+1] {
+				kp.Elem = append(kp.Elem, &sdcpb.PathElem{Name: ppe.GetName(), Key: maps.Clone(ppe.GetKey())})
+			}
+			kp.Elem = append(kp.Elem, &sdcpb.PathElem{Name: k})
+			result = append(result, &KeyAsLeaf{Path: kp, Value: pe.GetKey()[k]})
+		}
+	}
+	return result
+}
+
+// keyAsLeafUpdate returns the update of the key leaf at p, with the key value converted to the YANG type of the key leaf.
+func (c *Converter) keyAsLeafUpdate(ctx context.Context, p *sdcpb.Path, value string) (*sdcpb.Update, error) {
+	schemaRsp, err := c.schemaClientBound.GetSchema(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	tv, err := TypedValueToYANGType(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: value}}, schemaRsp.GetSchema())
+	if err != nil {
+		return nil, err
+	}
+	return &sdcpb.Update{Path: p, Value: tv}, nil
+}
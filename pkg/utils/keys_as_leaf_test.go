@@ -0,0 +1,97 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+func TestPathIsKeyAsLeaf(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *sdcpb.Path
+		want bool
+	}{
+		{
+			name: "key leaf",
+			p: &sdcpb.Path{Elem: []*sdcpb.PathElem{
+				{Name: "interface", Key: map[string]string{"name": "eth0"}},
+				{Name: "name"},
+			}},
+			want: true,
+		},
+		{
+			name: "non key leaf",
+			p: &sdcpb.Path{Elem: []*sdcpb.PathElem{
+				{Name: "interface", Key: map[string]string{"name": "eth0"}},
+				{Name: "mtu"},
+			}},
+			want: false,
+		},
+		{
+			name: "single element",
+			p:    &sdcpb.Path{Elem: []*sdcpb.PathElem{{Name: "name"}}},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PathIsKeyAsLeaf(tt.p); got != tt.want {
+				t.Errorf("PathIsKeyAsLeaf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysAsLeaves(t *testing.T) {
+	p := &sdcpb.Path{Elem: []*sdcpb.PathElem{
+		{Name: "network-instance", Key: map[string]string{"name": "default"}},
+		{Name: "protocol"},
+		{Name: "neighbor", Key: map[string]string{"peer": "10.0.0.1", "as": "65000"}},
+		{Name: "description"},
+	}}
+
+	got := KeysAsLeaves(p)
+	want := []struct {
+		path  string
+		value string
+	}{
+		{path: "network-instance[name=default]/name", value: "default"},
+		{path: "network-instance[name=default]/protocol/neighbor[as=65000][peer=10.0.0.1]/as", value: "65000"},
+		{path: "network-instance[name=default]/protocol/neighbor[as=65000][peer=10.0.0.1]/peer", value: "10.0.0.1"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("KeysAsLeaves() returned %d key leaves, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if gp := ToXPath(got[i].Path, false); gp != w.path {
+			t.Errorf("KeysAsLeaves()[%d] path = %s, want %s", i, gp, w.path)
+		}
+		if got[i].Value != w.value {
+			t.Errorf("KeysAsLeaves()[%d] value = %s, want %s", i, got[i].Value, w.value)
+		}
+		if !PathIsKeyAsLeaf(got[i].Path) {
+			t.Errorf("KeysAsLeaves()[%d] is not a key leaf path", i)
+		}
+	}
+
+	// the key maps of the returned paths must not alias the input path
+	got[0].Path.GetElem()[0].Key["name"] = "other"
+	if p.GetElem()[0].GetKey()["name"] != "default" {
+		t.Errorf("KeysAsLeaves() modified the input path")
+	}
+}
@@ -17,12 +17,10 @@ package utils
 import (
 	"fmt"
 	"math"
-	"regexp"
 	"strconv"
 	"strings"
 
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -94,25 +92,67 @@ func ConvertBinary(value string, slt *sdcpb.SchemaLeafType) (*sdcpb.TypedValue,
 func ConvertLeafRef(value string, slt *sdcpb.SchemaLeafType) (*sdcpb.TypedValue, error) {
 	// a leafref should basically be a string value that also exists somewhere else in the config as a value.
 	// we leave the validation of the leafrefs to a different party at a later stage
-	return ConvertString(value, slt)
+	if slt.GetLeafrefTargetType() == nil {
+		return ConvertString(value, slt)
+	}
+	// the value must however be valid for the type of the referenced leaf,
+	// which also defines its representation
+	return Convert(value, slt.GetLeafrefTargetType())
 }
 
 func ConvertEnumeration(value string, slt *sdcpb.SchemaLeafType) (*sdcpb.TypedValue, error) {
 	// iterate the valid values as per schema
-	for _, item := range slt.EnumNames {
+	if item, ok := canonicalEnum(value, slt.EnumNames); ok {
 		// if value is found, return a StringVal
-		if value == item {
-			return &sdcpb.TypedValue{
-				Value: &sdcpb.TypedValue_StringVal{
-					StringVal: value,
-				},
-			}, nil
-		}
+		return &sdcpb.TypedValue{
+			Value: &sdcpb.TypedValue_StringVal{
+				StringVal: item,
+			},
+		}, nil
 	}
 	// If value is not found return an error
 	return nil, fmt.Errorf("value %q does not match any valid enum values [%s]", value, strings.Join(slt.EnumNames, ", "))
 }
 
+// canonicalEnum returns the enum name as defined in the schema. Values that do not match
+// any name exactly are matched case-insensitively, if the match is unambiguous.
+func canonicalEnum(value string, enumNames []string) (string, bool) {
+	var match string
+	matches := 0
+	for _, item := range enumNames {
+		if value == item {
+			return item, true
+		}
+		if strings.EqualFold(value, item) {
+			match = item
+			matches++
+		}
+	}
+	return match, matches == 1
+}
+
+// canonicalIdentity returns the identity name as defined in the schema. The value may be bare or
+// prefixed with the module name or prefix. Names that do not match any identity exactly are matched
+// case-insensitively, if the match is unambiguous.
+func canonicalIdentity(value string, slt *sdcpb.SchemaLeafType) (string, bool) {
+	before, name, found := strings.Cut(value, ":")
+	if !found {
+		name = before
+	}
+	if _, ok := slt.GetIdentityPrefixesMap()[name]; ok {
+		return name, true
+	}
+	var match string
+	matches := 0
+	for identity := range slt.GetIdentityPrefixesMap() {
+		if strings.EqualFold(name, identity) {
+			match = identity
+			matches++
+		}
+	}
+	return match, matches == 1
+}
+
 func ConvertBoolean(value string, _ *sdcpb.SchemaLeafType) (*sdcpb.TypedValue, error) {
 	var bval bool
 	// check for true or false in string representation
@@ -272,33 +312,16 @@ func ConvertString(value string, lst *sdcpb.SchemaLeafType) (*sdcpb.TypedValue,
 
 	}
 
-	overallMatch := true
 	// If the type has multiple "pattern" statements, the expressions are
 	// ANDed together, i.e., all such expressions have to match.
-	for _, sp := range lst.Patterns {
-		re, err := regexp.Compile(sp.Pattern)
-		if err != nil {
-			log.Errorf("unable to compile regex %q", sp.Pattern)
-		}
-		match := re.MatchString(value)
-		// if it is a match and not inverted
-		// or it is not a match but inverted
-		// then this is valid
-		if (match && !sp.Inverted) || (!match && sp.Inverted) {
-			continue
-		} else {
-			overallMatch = false
-			break
-		}
-	}
-	if overallMatch {
-		return &sdcpb.TypedValue{
-			Value: &sdcpb.TypedValue_StringVal{
-				StringVal: value,
-			},
-		}, nil
+	if err := MatchPatterns(value, lst.Patterns); err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("%q does not match patterns", value)
+	return &sdcpb.TypedValue{
+		Value: &sdcpb.TypedValue_StringVal{
+			StringVal: NormalizeString(value, lst),
+		},
+	}, nil
 
 }
 
@@ -310,23 +333,74 @@ func ConvertDecimal64(value string, lst *sdcpb.SchemaLeafType) (*sdcpb.TypedValu
 
 	return &sdcpb.TypedValue{
 		Value: &sdcpb.TypedValue_DecimalVal{
-			DecimalVal: d64,
+			DecimalVal: NormalizeDecimal64(d64),
 		},
 	}, nil
 }
 
+// ConvertUnion resolves the value against the union member types in schema order (RFC 7950 Section 9.12).
+// The first member type accepting the value, including its ranges, lengths, patterns and enum values,
+// determines the returned representation. Leafref members are checked against their target type.
 func ConvertUnion(value string, slts []*sdcpb.SchemaLeafType) (*sdcpb.TypedValue, error) {
+	errs := make([]string, 0, len(slts))
 	// iterate over the union types try to convert without error
 	for _, slt := range slts {
 		tv, err := Convert(value, slt)
 		// if no error type conversion was fine
 		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", slt.GetType(), err))
 			continue
 		}
 		// return the TypedValue
 		return tv, nil
 	}
-	return nil, fmt.Errorf("no union type fit the provided value %q", value)
+	return nil, fmt.Errorf("no union type fit the provided value %q: [%s]", value, strings.Join(errs, "; "))
+}
+
+// ConvertUnionTypedValue resolves an already typed value against the union member types.
+// Values that are not strings are first matched against the member types of the same kind,
+// e.g. an UintVal is resolved as an integer member even if a string member precedes it.
+// Otherwise the string representation is resolved in schema order, see ConvertUnion.
+func ConvertUnionTypedValue(tv *sdcpb.TypedValue, slts []*sdcpb.SchemaLeafType) (*sdcpb.TypedValue, error) {
+	value := TypedValueToString(tv)
+	if _, isString := tv.GetValue().(*sdcpb.TypedValue_StringVal); !isString {
+		for _, slt := range slts {
+			if !leafTypeMatchesKind(slt, tv) {
+				continue
+			}
+			if rtv, err := Convert(value, slt); err == nil {
+				return rtv, nil
+			}
+		}
+	}
+	return ConvertUnion(value, slts)
+}
+
+// leafTypeMatchesKind reports whether the kind of the typed value natively represents the leaf type.
+func leafTypeMatchesKind(slt *sdcpb.SchemaLeafType, tv *sdcpb.TypedValue) bool {
+	switch slt.GetType() {
+	case "union":
+		for _, ut := range slt.GetUnionTypes() {
+			if leafTypeMatchesKind(ut, tv) {
+				return true
+			}
+		}
+		return false
+	case "leafref":
+		return slt.GetLeafrefTargetType() != nil && leafTypeMatchesKind(slt.GetLeafrefTargetType(), tv)
+	}
+	switch tv.GetValue().(type) {
+	case *sdcpb.TypedValue_UintVal, *sdcpb.TypedValue_IntVal:
+		switch slt.GetType() {
+		case "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64":
+			return true
+		}
+	case *sdcpb.TypedValue_BoolVal:
+		return slt.GetType() == "boolean"
+	case *sdcpb.TypedValue_DecimalVal:
+		return slt.GetType() == "decimal64"
+	}
+	return false
 }
 
 func ConvertJsonValueToTv(d any, slt *sdcpb.SchemaLeafType) (*sdcpb.TypedValue, error) {
@@ -338,7 +412,7 @@ func ConvertJsonValueToTv(d any, slt *sdcpb.SchemaLeafType) (*sdcpb.TypedValue,
 			return nil, fmt.Errorf("error converting %v to string", v)
 		}
 		return &sdcpb.TypedValue{
-			Value: &sdcpb.TypedValue_StringVal{StringVal: v},
+			Value: &sdcpb.TypedValue_StringVal{StringVal: NormalizeString(v, slt)},
 		}, nil
 	case "leafref":
 		return ConvertJsonValueToTv(d, slt.LeafrefTargetType)
@@ -408,31 +482,43 @@ func ConvertJsonValueToTv(d any, slt *sdcpb.SchemaLeafType) (*sdcpb.TypedValue,
 			Value: &sdcpb.TypedValue_BoolVal{BoolVal: b},
 		}, nil
 	case "decimal64":
-		arr := strings.SplitN(d.(string), ".", 2)
-		digits, err := strconv.ParseInt(arr[0], 10, 64)
-		if err != nil {
-			return nil, err
-		}
-		precision64, err := strconv.ParseUint(arr[1], 10, 32)
-		if err != nil {
-			return nil, err
+		var v string
+		switch dv := d.(type) {
+		case string:
+			v = dv
+		case float64:
+			v = strconv.FormatFloat(dv, 'f', -1, 64)
+		default:
+			return nil, fmt.Errorf("error converting %v to decimal64", d)
 		}
-		precision := uint32(precision64)
-		return &sdcpb.TypedValue{
-			Value: &sdcpb.TypedValue_DecimalVal{DecimalVal: &sdcpb.Decimal64{Digits: digits, Precision: precision}},
-		}, nil
+		return ConvertDecimal64(v, slt)
 	case "union":
-		for _, ut := range slt.GetUnionTypes() {
-			tv, err := ConvertJsonValueToTv(d, ut)
-			if err == nil {
-				return tv, nil
+		// strings (which includes 64 bit integers and decimal64 in JSON_IETF) are resolved
+		// in schema order, numbers and booleans prefer the member types of their kind
+		switch v := d.(type) {
+		case string:
+			return ConvertUnion(v, slt.GetUnionTypes())
+		case bool:
+			return ConvertUnionTypedValue(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_BoolVal{BoolVal: v}}, slt.GetUnionTypes())
+		case float64:
+			switch {
+			case v != math.Trunc(v):
+				return ConvertUnion(strconv.FormatFloat(v, 'f', -1, 64), slt.GetUnionTypes())
+			case v < 0:
+				return ConvertUnionTypedValue(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_IntVal{IntVal: int64(v)}}, slt.GetUnionTypes())
+			default:
+				return ConvertUnionTypedValue(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_UintVal{UintVal: uint64(v)}}, slt.GetUnionTypes())
 			}
 		}
-		return nil, fmt.Errorf("invalid value %s for union type: %v", d, slt.GetUnionTypes())
+		return ConvertUnion(fmt.Sprintf("%v", d), slt.GetUnionTypes())
 	case "enumeration":
 		// TODO: get correct type, assuming string
+		v := fmt.Sprintf("%v", d)
+		if item, ok := canonicalEnum(v, slt.GetEnumNames()); ok {
+			v = item
+		}
 		return &sdcpb.TypedValue{
-			Value: &sdcpb.TypedValue_StringVal{StringVal: fmt.Sprintf("%v", d)},
+			Value: &sdcpb.TypedValue_StringVal{StringVal: v},
 		}, nil
 	case "empty":
 		return &sdcpb.TypedValue{Value: &sdcpb.TypedValue_EmptyVal{EmptyVal: &emptypb.Empty{}}}, nil
@@ -0,0 +1,220 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConvertUnion(t *testing.T) {
+	// union {
+	//   type uint8 { range "1..10"; }
+	//   type enumeration { enum auto; }
+	//   type leafref { path ...; } -> int16
+	//   type string { pattern 'vlan-[0-9]+'; }
+	// }
+	union := []*sdcpb.SchemaLeafType{
+		{
+			Type:  "uint8",
+			Range: []*sdcpb.SchemaMinMaxType{{Min: &sdcpb.Number{Value: 1}, Max: &sdcpb.Number{Value: 10}}},
+		},
+		{
+			Type:      "enumeration",
+			EnumNames: []string{"auto"},
+		},
+		{
+			Type:              "leafref",
+			LeafrefTargetType: &sdcpb.SchemaLeafType{Type: "int16"},
+		},
+		{
+			Type:     "string",
+			Patterns: []*sdcpb.SchemaPattern{{Pattern: `vlan-[0-9]+`}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    *sdcpb.TypedValue
+		wantErr bool
+	}{
+		{
+			name:  "first member within range",
+			value: "5",
+			want:  &sdcpb.TypedValue{Value: &sdcpb.TypedValue_UintVal{UintVal: 5}},
+		},
+		{
+			name:  "out of range for first member resolves via leafref target type",
+			value: "-20",
+			want:  &sdcpb.TypedValue{Value: &sdcpb.TypedValue_IntVal{IntVal: -20}},
+		},
+		{
+			name:  "enum member",
+			value: "auto",
+			want:  &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "auto"}},
+		},
+		{
+			name:  "string member pattern",
+			value: "vlan-10",
+			want:  &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "vlan-10"}},
+		},
+		{
+			name:    "no member matches",
+			value:   "eth-1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertUnion(tt.value, union)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertUnion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("ConvertUnion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertUnionTypedValue(t *testing.T) {
+	// union { type string; type uint32; type boolean; }
+	union := []*sdcpb.SchemaLeafType{
+		{Type: "string"},
+		{Type: "uint32"},
+		{Type: "boolean"},
+	}
+
+	tests := []struct {
+		name string
+		tv   *sdcpb.TypedValue
+		want *sdcpb.TypedValue
+	}{
+		{
+			name: "string value resolved in schema order",
+			tv:   &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "42"}},
+			want: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "42"}},
+		},
+		{
+			name: "uint value prefers the integer member",
+			tv:   &sdcpb.TypedValue{Value: &sdcpb.TypedValue_UintVal{UintVal: 42}},
+			want: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_UintVal{UintVal: 42}},
+		},
+		{
+			name: "bool value prefers the boolean member",
+			tv:   &sdcpb.TypedValue{Value: &sdcpb.TypedValue_BoolVal{BoolVal: true}},
+			want: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_BoolVal{BoolVal: true}},
+		},
+		{
+			name: "negative int without matching member falls back to string",
+			tv:   &sdcpb.TypedValue{Value: &sdcpb.TypedValue_IntVal{IntVal: -1}},
+			want: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "-1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertUnionTypedValue(tt.tv, union)
+			if err != nil {
+				t.Fatalf("ConvertUnionTypedValue() error = %v", err)
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("ConvertUnionTypedValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertCanonicalEnumIdentity(t *testing.T) {
+	enum := &sdcpb.SchemaLeafType{Type: "enumeration", EnumNames: []string{"enable", "disable", "Auto", "AUTO"}}
+	identity := &sdcpb.SchemaLeafType{
+		Type:                "identityref",
+		IdentityPrefixesMap: map[string]string{"ip-vrf": "ni", "mac-vrf": "ni"},
+		ModulePrefixMap:     map[string]string{"ip-vrf": "sdcio_model_ni", "mac-vrf": "sdcio_model_ni"},
+	}
+	identityTv := func(name string) *sdcpb.TypedValue {
+		return &sdcpb.TypedValue{Value: &sdcpb.TypedValue_IdentityrefVal{IdentityrefVal: &sdcpb.IdentityRef{Value: name, Prefix: "ni", Module: "sdcio_model_ni"}}}
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		slt     *sdcpb.SchemaLeafType
+		want    *sdcpb.TypedValue
+		wantErr bool
+	}{
+		{
+			name:  "enum exact",
+			value: "enable",
+			slt:   enum,
+			want:  &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "enable"}},
+		},
+		{
+			name:  "enum case-insensitive",
+			value: "Enable",
+			slt:   enum,
+			want:  &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "enable"}},
+		},
+		{
+			name:    "enum ambiguous",
+			value:   "auto",
+			slt:     enum,
+			wantErr: true,
+		},
+		{
+			name:    "enum unknown",
+			value:   "unknown",
+			slt:     enum,
+			wantErr: true,
+		},
+		{
+			name:  "identity bare",
+			value: "ip-vrf",
+			slt:   identity,
+			want:  identityTv("ip-vrf"),
+		},
+		{
+			name:  "identity module prefixed",
+			value: "sdcio_model_ni:ip-vrf",
+			slt:   identity,
+			want:  identityTv("ip-vrf"),
+		},
+		{
+			name:  "identity prefixed case-insensitive",
+			value: "ni:MAC-VRF",
+			slt:   identity,
+			want:  identityTv("mac-vrf"),
+		},
+		{
+			name:    "identity unknown",
+			value:   "ni:default",
+			slt:     identity,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(tt.value, tt.slt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Convert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !proto.Equal(got, tt.want) {
+				t.Errorf("Convert() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+// The kinds of value normalization. Devices echo back values in their canonical form,
+// which would otherwise not compare equal to the values of the intents.
+const (
+	// NormalizationMAC lower cases and zero pads the octets of MAC addresses, e.g. 0:1A:FF -> 00:1a:ff
+	NormalizationMAC = "mac"
+	// NormalizationIP renders IPv4 and IPv6 addresses in their canonical form (RFC 5952), e.g. 2001:DB8:0::1 -> 2001:db8::1
+	NormalizationIP = "ip"
+	// NormalizationIPPrefix renders the address of IPv4 and IPv6 prefixes in its canonical form, e.g. 2001:DB8::/32 -> 2001:db8::/32
+	NormalizationIPPrefix = "ip-prefix"
+	// NormalizationDecimal removes the trailing zeros of decimal64 values, e.g. 1.50 -> 1.5
+	NormalizationDecimal = "decimal"
+)
+
+// Normalizations lists all the kinds of value normalization.
+var Normalizations = []string{NormalizationMAC, NormalizationIP, NormalizationIPPrefix, NormalizationDecimal}
+
+// normalizationTypes maps the YANG typedef names to the kind of normalization applied to their values.
+var normalizationTypes = map[string]string{
+	"mac-address":                NormalizationMAC,
+	"phys-address":               NormalizationMAC,
+	"ip-address":                 NormalizationIP,
+	"ipv4-address":               NormalizationIP,
+	"ipv6-address":               NormalizationIP,
+	"ip-address-no-zone":         NormalizationIP,
+	"ipv4-address-no-zone":       NormalizationIP,
+	"ipv6-address-no-zone":       NormalizationIP,
+	"ip-prefix":                  NormalizationIPPrefix,
+	"ipv4-prefix":                NormalizationIPPrefix,
+	"ipv6-prefix":                NormalizationIPPrefix,
+	"ip-prefix-with-host-bits":   NormalizationIPPrefix,
+	"ipv4-prefix-with-host-bits": NormalizationIPPrefix,
+	"ipv6-prefix-with-host-bits": NormalizationIPPrefix,
+}
+
+var disabledNormalizations sync.Map // kind -> struct{}
+
+// SetDisabledNormalizations disables the given kinds of value normalization, all the others are enabled.
+func SetDisabledNormalizations(kinds []string) error {
+	for _, k := range kinds {
+		if !isNormalization(k) {
+			return fmt.Errorf("unknown normalization %q, must be one of [%s]", k, strings.Join(Normalizations, ", "))
+		}
+	}
+	disabledNormalizations.Clear()
+	for _, k := range kinds {
+		disabledNormalizations.Store(k, struct{}{})
+	}
+	return nil
+}
+
+func isNormalization(kind string) bool {
+	for _, n := range Normalizations {
+		if n == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizationEnabled(kind string) bool {
+	_, disabled := disabledNormalizations.Load(kind)
+	return !disabled
+}
+
+// NormalizeString returns the canonical form of the string value of the given type.
+// Values of types without normalization, or that cannot be parsed, are returned as is.
+func NormalizeString(v string, slt *sdcpb.SchemaLeafType) string {
+	typeName := slt.GetTypeName()
+	if _, name, found := strings.Cut(typeName, ":"); found {
+		typeName = name
+	}
+	kind, ok := normalizationTypes[typeName]
+	if !ok || !normalizationEnabled(kind) {
+		return v
+	}
+	switch kind {
+	case NormalizationMAC:
+		return normalizeMAC(v)
+	case NormalizationIP:
+		if a, err := netip.ParseAddr(v); err == nil {
+			return a.String()
+		}
+	case NormalizationIPPrefix:
+		if p, err := netip.ParsePrefix(v); err == nil {
+			return p.String()
+		}
+	}
+	return v
+}
+
+// normalizeMAC renders the octets of the MAC address as lower case, zero padded hex, separated by colons.
+func normalizeMAC(v string) string {
+	octets := strings.FieldsFunc(v, func(r rune) bool { return r == ':' || r == '-' })
+	if len(octets) < 2 {
+		return v
+	}
+	result := make([]string, 0, len(octets))
+	for _, o := range octets {
+		b, err := strconv.ParseUint(o, 16, 8)
+		if err != nil {
+			return v
+		}
+		result = append(result, fmt.Sprintf("%02x", b))
+	}
+	return strings.Join(result, ":")
+}
+
+// NormalizeDecimal64 removes the trailing zeros of the fractional part of the decimal.
+func NormalizeDecimal64(d *sdcpb.Decimal64) *sdcpb.Decimal64 {
+	if d == nil || !normalizationEnabled(NormalizationDecimal) {
+		return d
+	}
+	digits, precision := d.GetDigits(), d.GetPrecision()
+	for precision > 0 && digits%10 == 0 {
+		digits /= 10
+		precision--
+	}
+	return &sdcpb.Decimal64{Digits: digits, Precision: precision}
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNormalizeString(t *testing.T) {
+	tests := []struct {
+		name     string
+		typeName string
+		value    string
+		disable  []string
+		want     string
+	}{
+		{name: "mac", typeName: "mac-address", value: "0:1A:FF:b:c:D0", want: "00:1a:ff:0b:0c:d0"},
+		{name: "mac dashes", typeName: "srl_nokia-comm:mac-address", value: "00-1A-FF-0B-0C-D0", want: "00:1a:ff:0b:0c:d0"},
+		{name: "mac invalid", typeName: "mac-address", value: "00:1G:FF", want: "00:1G:FF"},
+		{name: "ipv4", typeName: "ipv4-address", value: "10.0.0.1", want: "10.0.0.1"},
+		{name: "ipv6", typeName: "ipv6-address", value: "2001:DB8:0:0::1", want: "2001:db8::1"},
+		{name: "ipv6 prefix", typeName: "ip-prefix", value: "2001:DB8:0::/48", want: "2001:db8::/48"},
+		{name: "ipv4 prefix", typeName: "ipv4-prefix", value: "10.1.0.0/16", want: "10.1.0.0/16"},
+		{name: "other type", typeName: "name", value: "AA:BB", want: "AA:BB"},
+		{name: "disabled", typeName: "mac-address", value: "0:1A:FF", disable: []string{NormalizationMAC}, want: "0:1A:FF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetDisabledNormalizations(tt.disable); err != nil {
+				t.Fatal(err)
+			}
+			defer SetDisabledNormalizations(nil)
+
+			got := NormalizeString(tt.value, &sdcpb.SchemaLeafType{Type: "string", TypeName: tt.typeName})
+			if got != tt.want {
+				t.Errorf("NormalizeString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeDecimal64(t *testing.T) {
+	tests := []struct {
+		value string
+		want  *sdcpb.Decimal64
+	}{
+		{value: "1.50", want: &sdcpb.Decimal64{Digits: 15, Precision: 1}},
+		{value: "1.5", want: &sdcpb.Decimal64{Digits: 15, Precision: 1}},
+		{value: "2.000", want: &sdcpb.Decimal64{Digits: 2, Precision: 0}},
+		{value: "100", want: &sdcpb.Decimal64{Digits: 100, Precision: 0}},
+		{value: "-0.10", want: &sdcpb.Decimal64{Digits: -1, Precision: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			tv, err := Convert(tt.value, &sdcpb.SchemaLeafType{Type: "decimal64"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(tv.GetDecimalVal(), tt.want) {
+				t.Errorf("Convert() = %v, want %v", tv.GetDecimalVal(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSetDisabledNormalizations(t *testing.T) {
+	if err := SetDisabledNormalizations([]string{"unknown"}); err == nil {
+		t.Errorf("expected an error for an unknown normalization")
+	}
+}
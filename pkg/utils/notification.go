@@ -17,7 +17,6 @@ package utils
 import (
 	"github.com/openconfig/gnmi/proto/gnmi"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
-	log "github.com/sirupsen/logrus"
 )
 
 func ToSchemaNotification(n *gnmi.Notification) *sdcpb.Notification {
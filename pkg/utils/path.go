@@ -20,15 +20,12 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/sdcio/schema-server/pkg/utils"
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 )
 
 var errMalformedXPath = errors.New("malformed xpath")
 var errMalformedXPathKey = errors.New("malformed xpath key")
 
-var escapedBracketsReplacer = strings.NewReplacer(`\]`, `]`, `\[`, `[`)
-
 func relativeToAbsPath(p *sdcpb.Path, currentPath []*sdcpb.PathElem) *sdcpb.Path {
 	np := &sdcpb.Path{
 		Elem: make([]*sdcpb.PathElem, 0, len(p.GetElem())+len(currentPath)),
@@ -60,8 +57,13 @@ func hasRelativePathElem(p *sdcpb.Path) bool {
 	return false
 }
 
+// NormalizedAbsPath parses the, possibly relative, xpath p and returns it as absolute path,
+// resolving ".." elements against the currentPath. The module prefixes of the element names are removed.
 func NormalizedAbsPath(p string, currentPath []*sdcpb.PathElem) (*sdcpb.Path, error) {
-	scp, _ := utils.ParsePath(p)
+	scp, err := ParsePath(p)
+	if err != nil {
+		return nil, err
+	}
 	if hasRelativePathElem(scp) {
 		scp = relativeToAbsPath(scp, currentPath)
 	}
@@ -76,7 +78,9 @@ func NormalizedAbsPath(p string, currentPath []*sdcpb.PathElem) (*sdcpb.Path, er
 }
 
 // ParsePath creates a sdcpb.Path out of a p string, check if the first element is prefixed by an origin,
-// removes it from the xpath and adds it to the returned sdcpb.Path
+// removes it from the xpath and adds it to the returned sdcpb.Path.
+// Key values may contain any character, '[', ']' and '\' have to be escaped by a backslash,
+// see EscapeKeyValue.
 func ParsePath(p string) (*sdcpb.Path, error) {
 	lp := len(p)
 	if lp == 0 {
@@ -85,7 +89,7 @@ func ParsePath(p string) (*sdcpb.Path, error) {
 	var origin string
 
 	idx := strings.Index(p, ":")
-	if idx >= 0 && p[0] != '/' && !strings.Contains(p[:idx], "/") &&
+	if idx >= 0 && p[0] != '/' && !strings.ContainsAny(p[:idx], "/[") &&
 		// path == origin:/ || path == origin:
 		((idx+1 < lp && p[idx+1] == '/') || (lp == idx+1)) {
 		origin = p[:idx]
@@ -102,41 +106,49 @@ func ParsePath(p string) (*sdcpb.Path, error) {
 	}, nil
 }
 
+// EscapeKeyValue escapes the characters of a key value that delimit the keys of a xpath.
+func EscapeKeyValue(v string) string {
+	return keyValueEscaper.Replace(v)
+}
+
+// UnescapeKeyValue reverts EscapeKeyValue.
+func UnescapeKeyValue(v string) string {
+	return keyValueUnescaper.Replace(v)
+}
+
+var (
+	keyValueEscaper   = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`)
+	keyValueUnescaper = strings.NewReplacer(`\\`, `\`, `\[`, `[`, `\]`, `]`)
+)
+
 // toPathElems parses a xpath and returns a list of path elements
 func toPathElems(p string) ([]*sdcpb.PathElem, error) {
-	if !strings.HasSuffix(p, "/") {
-		p += "/"
-	}
-	buffer := make([]rune, 0)
+	buffer := make([]rune, 0, len(p))
 	null := rune(0)
-	prevC := rune(0)
-	// track if the loop is traversing a key
+	// track if the loop is traversing a key and if the current rune is escaped
 	inKey := false
+	escaped := false
 	for _, r := range p {
-		switch r {
-		case '[':
-			if inKey && prevC != '\\' {
-				return nil, errMalformedXPath
-			}
-			if prevC != '\\' {
-				inKey = true
-			}
-		case ']':
-			if !inKey && prevC != '\\' {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '[':
+			if inKey {
 				return nil, errMalformedXPath
 			}
-			if prevC != '\\' {
-				inKey = false
-			}
-		case '/':
+			inKey = true
+		case r == ']':
 			if !inKey {
-				buffer = append(buffer, null)
-				prevC = r
-				continue
+				return nil, errMalformedXPath
 			}
+			inKey = false
+		case r == '/' && !inKey:
+			buffer = append(buffer, null)
+			continue
 		}
 		buffer = append(buffer, r)
-		prevC = r
 	}
 	if inKey {
 		return nil, errMalformedXPath
@@ -158,15 +170,7 @@ func toPathElems(p string) ([]*sdcpb.PathElem, error) {
 
 // toPathElem take a xpath formatted path element such as "elem1[k=v]" and returns the corresponding sdcpb.PathElem
 func toPathElem(s string) (*sdcpb.PathElem, error) {
-	idx := -1
-	prevC := rune(0)
-	for i, r := range s {
-		if r == '[' && prevC != '\\' {
-			idx = i
-			break
-		}
-		prevC = r
-	}
+	idx := strings.IndexByte(s, '[')
 	var kvs map[string]string
 	if idx > 0 {
 		var err error
@@ -186,28 +190,25 @@ func parseXPathKeys(s string) (map[string]string, error) {
 	}
 	kvs := make(map[string]string)
 	inKey := false
+	escaped := false
 	start := 0
-	prevRune := rune(0)
 	for i, r := range s {
-		switch r {
-		case '[':
-			if prevRune == '\\' {
-				prevRune = r
-				continue
-			}
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '[':
 			if inKey {
 				return nil, errMalformedXPathKey
 			}
 			inKey = true
 			start = i + 1
-		case ']':
-			if prevRune == '\\' {
-				prevRune = r
-				continue
-			}
+		case r == ']':
 			if !inKey {
 				return nil, errMalformedXPathKey
 			}
+			// key names can not contain '=', the first one separates the name from the value
 			eq := strings.Index(s[start:i], "=")
 			if eq < 0 {
 				return nil, errMalformedXPathKey
@@ -216,10 +217,9 @@ func parseXPathKeys(s string) (map[string]string, error) {
 			if len(k) == 0 || len(v) == 0 {
 				return nil, errMalformedXPathKey
 			}
-			kvs[strings.TrimSpace(escapedBracketsReplacer.Replace(k))] = strings.TrimSpace(escapedBracketsReplacer.Replace(v))
+			kvs[strings.TrimSpace(k)] = strings.TrimSpace(UnescapeKeyValue(v))
 			inKey = false
 		}
-		prevRune = r
 	}
 	if inKey {
 		return nil, errMalformedXPathKey
@@ -315,21 +315,48 @@ func CompletePathFromString(s string) ([]string, error) {
 	return CompletePath(nil, p)
 }
 
+// XPathOpts defines the rendering of a path by ToXPathOpts.
+type XPathOpts struct {
+	// NoKeys omits the keys of the path elements
+	NoKeys bool
+	// NoOrigin omits the origin of the path
+	NoOrigin bool
+	// Absolute prefixes the path elements with a '/', which is always the case if the origin is included
+	Absolute bool
+	// StripNamespaces removes the module prefixes of the element and key names
+	StripNamespaces bool
+}
+
+// ToXPath returns the xpath of p, including the origin, e.g. "origin:/a/b[k=v]". The keys of the elements are sorted by name,
+// their values are escaped, such that ParsePath returns the same path.
 func ToXPath(p *sdcpb.Path, noKeys bool) string {
+	return ToXPathOpts(p, XPathOpts{NoKeys: noKeys})
+}
+
+// ToXPathOpts returns the xpath of p, rendered as defined by the opts.
+func ToXPathOpts(p *sdcpb.Path, opts XPathOpts) string {
 	if p == nil {
 		return ""
 	}
 	sb := strings.Builder{}
-	if p.Origin != "" {
+	// the origin is followed by an absolute path, otherwise it is not told apart from a module prefix
+	withOrigin := p.Origin != "" && !opts.NoOrigin
+	if withOrigin {
 		sb.WriteString(p.Origin)
 		sb.WriteString(":")
 	}
+	if opts.Absolute || withOrigin {
+		sb.WriteString("/")
+	}
 	elems := p.GetElem()
 	numElems := len(elems)
 	for i, pe := range elems {
-		sb.WriteString(pe.GetName())
-		if !noKeys {
-
+		name := pe.GetName()
+		if opts.StripNamespaces {
+			name = stripNamespace(name)
+		}
+		sb.WriteString(name)
+		if !opts.NoKeys {
 			// need to sort the keys to get them in the correct order
 			kvMap := pe.GetKey()
 			// create a slice for the keys
@@ -344,9 +371,13 @@ func ToXPath(p *sdcpb.Path, noKeys bool) string {
 			// iterate over the sorted keys slice
 			for _, k := range keySlice {
 				sb.WriteString("[")
-				sb.WriteString(k)
+				if opts.StripNamespaces {
+					sb.WriteString(stripNamespace(k))
+				} else {
+					sb.WriteString(k)
+				}
 				sb.WriteString("=")
-				sb.WriteString(kvMap[k])
+				sb.WriteString(EscapeKeyValue(kvMap[k]))
 				sb.WriteString("]")
 			}
 		}
@@ -357,11 +388,18 @@ func ToXPath(p *sdcpb.Path, noKeys bool) string {
 	return sb.String()
 }
 
+func stripNamespace(name string) string {
+	if i := strings.Index(name, ":"); i > 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// StripPathElemPrefixPath removes the module prefixes of the element names, the key names
+// and the key values, e.g. of identityrefs, of p.
 func StripPathElemPrefixPath(p *sdcpb.Path) {
 	for _, pe := range p.GetElem() {
-		if i := strings.Index(pe.Name, ":"); i > 0 {
-			pe.Name = pe.Name[i+1:]
-		}
+		pe.Name = stripNamespace(pe.Name)
 		// process keys
 		for k, v := range pe.Key {
 			// delete prefix from key name
@@ -373,9 +411,7 @@ func StripPathElemPrefixPath(p *sdcpb.Path) {
 			if strings.Contains(v, ":") {
 				kelems := strings.Split(v, "/")
 				for idx, kelem := range kelems {
-					if i := strings.Index(kelem, ":"); i > 0 {
-						kelems[idx] = kelem[i+1:]
-					}
+					kelems[idx] = stripNamespace(kelem)
 				}
 				v = strings.Join(kelems, "/")
 			}
@@ -384,40 +420,14 @@ func StripPathElemPrefixPath(p *sdcpb.Path) {
 	}
 }
 
+// StripPathElemPrefix returns the xpath p without module prefixes, see StripPathElemPrefixPath.
 func StripPathElemPrefix(p string) (string, error) {
 	sp, err := ParsePath(p)
 	if err != nil {
 		return "", err
 	}
-	for _, pe := range sp.GetElem() {
-		if i := strings.Index(pe.Name, ":"); i > 0 {
-			pe.Name = pe.Name[i+1:]
-		}
-		// process keys
-		for k, v := range pe.Key {
-			// delete prefix from key name
-			if i := strings.Index(k, ":"); i > 0 {
-				delete(pe.Key, k)
-				k = k[i+1:]
-			}
-			// delete prefix from key value
-			if strings.Contains(v, ":") {
-				kelems := strings.Split(v, "/")
-				for idx, kelem := range kelems {
-					if i := strings.Index(kelem, ":"); i > 0 {
-						kelems[idx] = kelem[i+1:]
-					}
-				}
-				v = strings.Join(kelems, "/")
-			}
-			pe.Key[k] = v
-		}
-	}
-	prefix := ""
-	if strings.HasPrefix(strings.TrimSpace(p), "/") {
-		prefix = "/"
-	}
-	return prefix + ToXPath(sp, false), nil
+	StripPathElemPrefixPath(sp)
+	return ToXPathOpts(sp, XPathOpts{Absolute: strings.HasPrefix(strings.TrimSpace(p), "/")}), nil
 }
 
 func PathsEqual(p1, p2 *sdcpb.Path) bool {
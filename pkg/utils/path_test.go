@@ -266,3 +266,88 @@ func TestToXPath(t *testing.T) {
 		})
 	}
 }
+
+func FuzzParsePath(f *testing.F) {
+	for _, p := range []string{
+		"",
+		"/",
+		"interface[name=ethernet-1/1]/subinterface[index=0]/admin-state",
+		"/interface[name=ethernet-1/1][schnitzel=foo]/description",
+		"openconfig:/interfaces/interface[name=eth0]",
+		"origin:",
+		"a[b=\\]]/c",
+		"a[b=[c]]",
+		"a[b]/c",
+		"a[=]",
+		"a]",
+		"../foo",
+	} {
+		f.Add(p)
+	}
+	f.Fuzz(func(t *testing.T, p string) {
+		path, err := ParsePath(p)
+		if err != nil {
+			return
+		}
+		// a successfully parsed path must be renderable
+		_ = ToXPath(path, false)
+	})
+}
+
+func TestParsePathRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		p    *sdcpb.Path
+		want string
+	}{
+		{
+			name: "key value with slashes",
+			p: &sdcpb.Path{Elem: []*sdcpb.PathElem{
+				{Name: "interface", Key: map[string]string{"name": "ethernet-1/1"}},
+				{Name: "description"},
+			}},
+			want: "interface[name=ethernet-1/1]/description",
+		},
+		{
+			name: "key value with brackets, equals and backslashes",
+			p: &sdcpb.Path{Elem: []*sdcpb.PathElem{
+				{Name: "acl", Key: map[string]string{"name": `a[0]=b\c`, "type": "ipv4"}},
+			}},
+			want: `acl[name=a\[0\]=b\\c][type=ipv4]`,
+		},
+		{
+			name: "origin and key value with colon",
+			p: &sdcpb.Path{Origin: "openconfig", Elem: []*sdcpb.PathElem{
+				{Name: "network-instance", Key: map[string]string{"name": "vrf:1/2"}},
+			}},
+			want: "openconfig:/network-instance[name=vrf:1/2]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xp := ToXPath(tt.p, false)
+			if xp != tt.want {
+				t.Errorf("ToXPath() = %s, want %s", xp, tt.want)
+			}
+			got, err := ParsePath(xp)
+			if err != nil {
+				t.Fatalf("ParsePath() error = %v", err)
+			}
+			if got.GetOrigin() != tt.p.GetOrigin() || !PathsEqual(got, tt.p) {
+				t.Errorf("ParsePath() = %v, want %v", got, tt.p)
+			}
+		})
+	}
+}
+
+func TestToXPathOpts(t *testing.T) {
+	p := &sdcpb.Path{Origin: "srl", Elem: []*sdcpb.PathElem{
+		{Name: "srl_nokia-interfaces:interface", Key: map[string]string{"srl_nokia-interfaces:name": "ethernet-1/1"}},
+		{Name: "srl_nokia-interfaces:description"},
+	}}
+	got := ToXPathOpts(p, XPathOpts{NoOrigin: true, Absolute: true, StripNamespaces: true})
+	want := "/interface[name=ethernet-1/1]/description"
+	if got != want {
+		t.Errorf("ToXPathOpts() = %s, want %s", got, want)
+	}
+}
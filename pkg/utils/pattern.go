@@ -0,0 +1,572 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+// YANG patterns (RFC 7950 Section 9.4.5) use the regular expression syntax
+// of W3C XML Schema Part 2, Appendix F. The most relevant differences to
+// Go's RE2 syntax are:
+//   - the pattern is implicitly anchored at both ends
+//   - ^ and $ are ordinary characters
+//   - \d, \w and . are Unicode aware, . does not match \n and \r
+//   - the multi character escapes \i, \I, \c and \C exist
+//   - \p{IsBlockName} refers to Unicode blocks
+//   - character classes support subtraction, e.g. [a-z-[aeiou]]
+//
+// XSDPatternToRE2 takes care of translating such patterns into RE2 expressions.
+// Escapes and character classes are resolved into explicit rune ranges, which
+// covers negation and subtraction, neither of which RE2 can express within a class.
+
+var (
+	// ErrUnsupportedPattern is returned for patterns that can not be translated into RE2 syntax.
+	ErrUnsupportedPattern = errors.New("unsupported pattern")
+	// ErrPatternLimit is returned for valid patterns exceeding the limits of the RE2 engine,
+	// e.g. repetition counts above 1000.
+	ErrPatternLimit = errors.New("pattern exceeds regex engine limits")
+)
+
+// xsdBlocks maps the block names of XML Schema 1.0 (Unicode 3.1, as referenced by
+// Appendix F) to their code point ranges, since RE2 only knows scripts and categories.
+// Blocks introduced by later Unicode versions are not part of XSD 1.0 and are rejected.
+var xsdBlocks = map[string][]runeRange{
+	"BasicLatin":                           {{0x0000, 0x007F}},
+	"Latin-1Supplement":                    {{0x0080, 0x00FF}},
+	"LatinExtended-A":                      {{0x0100, 0x017F}},
+	"LatinExtended-B":                      {{0x0180, 0x024F}},
+	"IPAExtensions":                        {{0x0250, 0x02AF}},
+	"SpacingModifierLetters":               {{0x02B0, 0x02FF}},
+	"CombiningDiacriticalMarks":            {{0x0300, 0x036F}},
+	"Greek":                                {{0x0370, 0x03FF}},
+	"Cyrillic":                             {{0x0400, 0x04FF}},
+	"Armenian":                             {{0x0530, 0x058F}},
+	"Hebrew":                               {{0x0590, 0x05FF}},
+	"Arabic":                               {{0x0600, 0x06FF}},
+	"Syriac":                               {{0x0700, 0x074F}},
+	"Thaana":                               {{0x0780, 0x07BF}},
+	"Devanagari":                           {{0x0900, 0x097F}},
+	"Bengali":                              {{0x0980, 0x09FF}},
+	"Gurmukhi":                             {{0x0A00, 0x0A7F}},
+	"Gujarati":                             {{0x0A80, 0x0AFF}},
+	"Oriya":                                {{0x0B00, 0x0B7F}},
+	"Tamil":                                {{0x0B80, 0x0BFF}},
+	"Telugu":                               {{0x0C00, 0x0C7F}},
+	"Kannada":                              {{0x0C80, 0x0CFF}},
+	"Malayalam":                            {{0x0D00, 0x0D7F}},
+	"Sinhala":                              {{0x0D80, 0x0DFF}},
+	"Thai":                                 {{0x0E00, 0x0E7F}},
+	"Lao":                                  {{0x0E80, 0x0EFF}},
+	"Tibetan":                              {{0x0F00, 0x0FFF}},
+	"Myanmar":                              {{0x1000, 0x109F}},
+	"Georgian":                             {{0x10A0, 0x10FF}},
+	"HangulJamo":                           {{0x1100, 0x11FF}},
+	"Ethiopic":                             {{0x1200, 0x137F}},
+	"Cherokee":                             {{0x13A0, 0x13FF}},
+	"UnifiedCanadianAboriginalSyllabics":   {{0x1400, 0x167F}},
+	"Ogham":                                {{0x1680, 0x169F}},
+	"Runic":                                {{0x16A0, 0x16FF}},
+	"Khmer":                                {{0x1780, 0x17FF}},
+	"Mongolian":                            {{0x1800, 0x18AF}},
+	"LatinExtendedAdditional":              {{0x1E00, 0x1EFF}},
+	"GreekExtended":                        {{0x1F00, 0x1FFF}},
+	"GeneralPunctuation":                   {{0x2000, 0x206F}},
+	"SuperscriptsandSubscripts":            {{0x2070, 0x209F}},
+	"CurrencySymbols":                      {{0x20A0, 0x20CF}},
+	"CombiningMarksforSymbols":             {{0x20D0, 0x20FF}},
+	"LetterlikeSymbols":                    {{0x2100, 0x214F}},
+	"NumberForms":                          {{0x2150, 0x218F}},
+	"Arrows":                               {{0x2190, 0x21FF}},
+	"MathematicalOperators":                {{0x2200, 0x22FF}},
+	"MiscellaneousTechnical":               {{0x2300, 0x23FF}},
+	"ControlPictures":                      {{0x2400, 0x243F}},
+	"OpticalCharacterRecognition":          {{0x2440, 0x245F}},
+	"EnclosedAlphanumerics":                {{0x2460, 0x24FF}},
+	"BoxDrawing":                           {{0x2500, 0x257F}},
+	"BlockElements":                        {{0x2580, 0x259F}},
+	"GeometricShapes":                      {{0x25A0, 0x25FF}},
+	"MiscellaneousSymbols":                 {{0x2600, 0x26FF}},
+	"Dingbats":                             {{0x2700, 0x27BF}},
+	"BraillePatterns":                      {{0x2800, 0x28FF}},
+	"CJKRadicalsSupplement":                {{0x2E80, 0x2EFF}},
+	"KangxiRadicals":                       {{0x2F00, 0x2FDF}},
+	"IdeographicDescriptionCharacters":     {{0x2FF0, 0x2FFF}},
+	"CJKSymbolsandPunctuation":             {{0x3000, 0x303F}},
+	"Hiragana":                             {{0x3040, 0x309F}},
+	"Katakana":                             {{0x30A0, 0x30FF}},
+	"Bopomofo":                             {{0x3100, 0x312F}},
+	"HangulCompatibilityJamo":              {{0x3130, 0x318F}},
+	"Kanbun":                               {{0x3190, 0x319F}},
+	"BopomofoExtended":                     {{0x31A0, 0x31BF}},
+	"EnclosedCJKLettersandMonths":          {{0x3200, 0x32FF}},
+	"CJKCompatibility":                     {{0x3300, 0x33FF}},
+	"CJKUnifiedIdeographsExtensionA":       {{0x3400, 0x4DB5}},
+	"CJKUnifiedIdeographs":                 {{0x4E00, 0x9FFF}},
+	"YiSyllables":                          {{0xA000, 0xA48F}},
+	"YiRadicals":                           {{0xA490, 0xA4CF}},
+	"HangulSyllables":                      {{0xAC00, 0xD7A3}},
+	"HighSurrogates":                       {{0xD800, 0xDB7F}},
+	"HighPrivateUseSurrogates":             {{0xDB80, 0xDBFF}},
+	"LowSurrogates":                        {{0xDC00, 0xDFFF}},
+	"PrivateUse":                           {{0xE000, 0xF8FF}, {0xF0000, 0xFFFFD}, {0x100000, 0x10FFFD}},
+	"CJKCompatibilityIdeographs":           {{0xF900, 0xFAFF}},
+	"AlphabeticPresentationForms":          {{0xFB00, 0xFB4F}},
+	"ArabicPresentationForms-A":            {{0xFB50, 0xFDFF}},
+	"CombiningHalfMarks":                   {{0xFE20, 0xFE2F}},
+	"CJKCompatibilityForms":                {{0xFE30, 0xFE4F}},
+	"SmallFormVariants":                    {{0xFE50, 0xFE6F}},
+	"ArabicPresentationForms-B":            {{0xFE70, 0xFEFE}},
+	"Specials":                             {{0xFEFF, 0xFEFF}, {0xFFF0, 0xFFFD}},
+	"HalfwidthandFullwidthForms":           {{0xFF00, 0xFFEF}},
+	"OldItalic":                            {{0x10300, 0x1032F}},
+	"Gothic":                               {{0x10330, 0x1034F}},
+	"Deseret":                              {{0x10400, 0x1044F}},
+	"ByzantineMusicalSymbols":              {{0x1D000, 0x1D0FF}},
+	"MusicalSymbols":                       {{0x1D100, 0x1D1FF}},
+	"MathematicalAlphanumericSymbols":      {{0x1D400, 0x1D7FF}},
+	"CJKUnifiedIdeographsExtensionB":       {{0x20000, 0x2A6D6}},
+	"CJKCompatibilityIdeographsSupplement": {{0x2F800, 0x2FA1F}},
+	"Tags":                                 {{0xE0000, 0xE007F}},
+}
+
+// xsdPatternCache caches the compiled patterns, keyed by the original XSD pattern.
+var xsdPatternCache sync.Map // string -> *xsdPatternCacheEntry
+
+type xsdPatternCacheEntry struct {
+	re  *regexp.Regexp
+	err error
+}
+
+// CompileXSDPattern translates the given YANG / XSD pattern into RE2 syntax and compiles it.
+// Results, including failures, are cached so repeated validations of the same schema type are cheap.
+// Failures are logged once, when the pattern is first seen. The returned error wraps
+// ErrPatternLimit if the pattern is valid but exceeds the RE2 limits, ErrUnsupportedPattern otherwise.
+func CompileXSDPattern(pattern string) (*regexp.Regexp, error) {
+	if e, ok := xsdPatternCache.Load(pattern); ok {
+		entry := e.(*xsdPatternCacheEntry)
+		return entry.re, entry.err
+	}
+	entry := &xsdPatternCacheEntry{}
+	re2, err := XSDPatternToRE2(pattern)
+	if err != nil {
+		entry.err = fmt.Errorf("%w %q: %v", ErrUnsupportedPattern, pattern, err)
+	} else {
+		entry.re, err = regexp.Compile(re2)
+		var serr *syntax.Error
+		switch {
+		case err == nil:
+		case errors.As(err, &serr) && (serr.Code == syntax.ErrInvalidRepeatSize || serr.Code == syntax.ErrLarge):
+			entry.err = fmt.Errorf("%w %q: %v", ErrPatternLimit, pattern, err)
+		default:
+			entry.err = fmt.Errorf("%w %q: %v", ErrUnsupportedPattern, pattern, err)
+		}
+	}
+	if e, loaded := xsdPatternCache.LoadOrStore(pattern, entry); loaded {
+		entry = e.(*xsdPatternCacheEntry)
+	} else if entry.err != nil {
+		log.Warnf("failed compiling pattern: %v", entry.err)
+	}
+	return entry.re, entry.err
+}
+
+// MatchPattern checks the value against the given pattern, honouring the invert-match modifier.
+// Patterns exceeding the RE2 limits are skipped. Patterns that can not be translated
+// result in an error, so that validation never silently accepts a value.
+func MatchPattern(value string, sp *sdcpb.SchemaPattern) error {
+	p := sp.GetPattern()
+	if p == "" {
+		return nil
+	}
+	re, err := CompileXSDPattern(p)
+	switch {
+	case errors.Is(err, ErrPatternLimit):
+		return nil
+	case err != nil:
+		return err
+	}
+	if re.MatchString(value) == sp.GetInverted() {
+		return fmt.Errorf("value %q does not match pattern %q (inverted: %t)", value, p, sp.GetInverted())
+	}
+	return nil
+}
+
+// MatchPatterns checks the value against all the given patterns.
+// If a type defines multiple patterns, they are ANDed together, patterns carrying
+// the invert-match modifier must not match.
+func MatchPatterns(value string, patterns []*sdcpb.SchemaPattern) error {
+	for _, sp := range patterns {
+		if err := MatchPattern(value, sp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// XSDPatternToRE2 translates a W3C XML Schema regular expression into an anchored RE2 expression.
+func XSDPatternToRE2(pattern string) (string, error) {
+	sb := &strings.Builder{}
+	sb.WriteString(`^(?:`)
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		switch r {
+		case '\\':
+			rs, n, err := xsdEscapeRanges(pattern[i:])
+			if err != nil {
+				return "", err
+			}
+			writeRuneRanges(sb, rs)
+			i += n
+			continue
+		case '[':
+			rs, n, err := xsdClassSet(pattern[i:])
+			if err != nil {
+				return "", err
+			}
+			writeRuneRanges(sb, rs)
+			i += n
+			continue
+		case '.':
+			sb.WriteString(`[^\n\r]`)
+		case '^', '$':
+			// no anchors in XSD, these are ordinary characters
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case '(':
+			// (?...) constructs do not exist in XSD, every group is a plain group
+			if strings.HasPrefix(pattern[i:], "(?") {
+				return "", fmt.Errorf("invalid group construct at offset %d", i)
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+		i += size
+	}
+	sb.WriteString(`)$`)
+	return sb.String(), nil
+}
+
+// xsdSplitProperty splits the \p{...} or \P{...} escape that s starts with into the
+// category or block name. It returns the name, whether it refers to a block and the
+// number of bytes consumed.
+func xsdSplitProperty(s string) (string, bool, int, error) {
+	end := strings.IndexByte(s, '}')
+	if len(s) < 4 || s[2] != '{' || end < 0 {
+		return "", false, 0, fmt.Errorf("malformed property escape %q", s)
+	}
+	name := s[3:end]
+	block, isBlock := strings.CutPrefix(name, "Is")
+	if isBlock {
+		name = block
+	}
+	return name, isBlock, end + 1, nil
+}
+
+// xsdClassSet resolves the character class expression that s starts with into rune ranges.
+// It returns the ranges and the number of bytes consumed.
+// A negated class with subtraction, e.g. [^G-[S]], is the complement of G minus S.
+func xsdClassSet(s string) ([]runeRange, int, error) {
+	body, negated, subtract, n, err := xsdSplitClass(s)
+	if err != nil {
+		return nil, 0, err
+	}
+	rs, err := xsdClassRanges(body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if negated {
+		rs = complementRuneRanges(rs)
+	}
+	if subtract != "" {
+		sub, _, err := xsdClassSet(subtract)
+		if err != nil {
+			return nil, 0, err
+		}
+		rs = subtractRuneRanges(rs, sub)
+	}
+	return rs, n, nil
+}
+
+// xsdSplitClass splits the class expression that s starts with into its body, the negation
+// indicator and the (optional) subtracted class expression.
+func xsdSplitClass(s string) (body string, negated bool, subtract string, n int, err error) {
+	i := 1
+	if strings.HasPrefix(s[i:], "^") {
+		negated = true
+		i++
+	}
+	start := i
+	for i < len(s) {
+		switch s[i] {
+		case '\\':
+			i += 2
+			continue
+		case '[':
+			return "", false, "", 0, fmt.Errorf("unexpected '[' in character class %q", s)
+		case '-':
+			if i+1 < len(s) && s[i+1] == '[' {
+				body = s[start:i]
+				depth := 0
+				j := i + 1
+			SUBTRACT:
+				for ; j < len(s); j++ {
+					switch s[j] {
+					case '\\':
+						j++
+					case '[':
+						depth++
+					case ']':
+						depth--
+						if depth == 0 {
+							break SUBTRACT
+						}
+					}
+				}
+				if j+1 >= len(s) || s[j+1] != ']' {
+					return "", false, "", 0, fmt.Errorf("unterminated character class subtraction %q", s)
+				}
+				return body, negated, s[i+1 : j+1], j + 2, nil
+			}
+		case ']':
+			if i == start {
+				return "", false, "", 0, fmt.Errorf("empty character class %q", s)
+			}
+			return s[start:i], negated, "", i + 1, nil
+		}
+		i++
+	}
+	return "", false, "", 0, fmt.Errorf("unterminated character class %q", s)
+}
+
+type runeRange struct {
+	lo, hi rune
+}
+
+// xsdEscapeRanges resolves the escape sequence that s starts with into rune ranges.
+// It returns the ranges and the number of bytes consumed.
+func xsdEscapeRanges(s string) ([]runeRange, int, error) {
+	if len(s) < 2 {
+		return nil, 0, fmt.Errorf("trailing backslash")
+	}
+	r, size := utf8.DecodeRuneInString(s[1:])
+	n := 1 + size
+
+	var rs []runeRange
+	negated := false
+	switch r {
+	case 'n':
+		rs = []runeRange{{'\n', '\n'}}
+	case 'r':
+		rs = []runeRange{{'\r', '\r'}}
+	case 't':
+		rs = []runeRange{{'\t', '\t'}}
+	case '\\', '|', '.', '?', '*', '+', '(', ')', '{', '}', '-', '[', ']', '^', '$':
+		rs = []runeRange{{r, r}}
+	case 'd', 'D':
+		rs = tableRuneRanges(unicode.Nd)
+		negated = r == 'D'
+	case 's', 'S':
+		rs = []runeRange{{'\t', '\n'}, {'\r', '\r'}, {' ', ' '}}
+		negated = r == 'S'
+	case 'w', 'W':
+		rs = tableRuneRanges(unicode.L, unicode.M, unicode.N, unicode.S)
+		negated = r == 'W'
+	case 'i', 'I':
+		rs = append(tableRuneRanges(unicode.L), runeRange{'_', '_'}, runeRange{':', ':'})
+		negated = r == 'I'
+	case 'c', 'C':
+		rs = append(tableRuneRanges(unicode.L, unicode.Mn, unicode.Mc, unicode.Nd),
+			runeRange{'.', '.'}, runeRange{'_', '_'}, runeRange{':', ':'}, runeRange{'-', '-'})
+		negated = r == 'C'
+	case 'p', 'P':
+		name, isBlock, pn, err := xsdSplitProperty(s)
+		if err != nil {
+			return nil, 0, err
+		}
+		n = pn
+		negated = r == 'P'
+		if isBlock {
+			block, exists := xsdBlocks[name]
+			if !exists {
+				return nil, 0, fmt.Errorf("unknown unicode block %q", name)
+			}
+			rs = block
+			break
+		}
+		table, exists := unicode.Categories[name]
+		if !exists {
+			return nil, 0, fmt.Errorf("unknown unicode category %q", name)
+		}
+		rs = tableRuneRanges(table)
+	default:
+		return nil, 0, fmt.Errorf("unknown escape sequence \\%c", r)
+	}
+	rs = normalizeRuneRanges(rs)
+	if negated {
+		rs = complementRuneRanges(rs)
+	}
+	return rs, n, nil
+}
+
+// xsdClassRanges resolves a class body (between the brackets) into rune ranges.
+func xsdClassRanges(body string) ([]runeRange, error) {
+	// atom returns the rune ranges of the single character or escape body[i:] starts with
+	atom := func(i int) ([]runeRange, int, error) {
+		if body[i] == '\\' {
+			return xsdEscapeRanges(body[i:])
+		}
+		r, size := utf8.DecodeRuneInString(body[i:])
+		return []runeRange{{r, r}}, size, nil
+	}
+	isChar := func(rs []runeRange) bool {
+		return len(rs) == 1 && rs[0].lo == rs[0].hi
+	}
+
+	var result []runeRange
+	for i := 0; i < len(body); {
+		rs, n, err := atom(i)
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		// a '-' that is neither the first nor the last character denotes a range
+		if isChar(rs) && i < len(body)-1 && body[i] == '-' {
+			hi, hn, err := atom(i + 1)
+			if err != nil {
+				return nil, err
+			}
+			if !isChar(hi) || rs[0].lo > hi[0].lo {
+				return nil, fmt.Errorf("invalid range in character class %q", body)
+			}
+			rs = []runeRange{{rs[0].lo, hi[0].lo}}
+			i += 1 + hn
+		}
+		result = append(result, rs...)
+	}
+	return normalizeRuneRanges(result), nil
+}
+
+// tableRuneRanges returns the runes of the given unicode tables as ranges.
+func tableRuneRanges(tables ...*unicode.RangeTable) []runeRange {
+	result := []runeRange{}
+	add := func(lo, hi, stride rune) {
+		if stride == 1 {
+			result = append(result, runeRange{lo, hi})
+			return
+		}
+		for c := lo; c <= hi; c += stride {
+			result = append(result, runeRange{c, c})
+		}
+	}
+	for _, t := range tables {
+		for _, r := range t.R16 {
+			add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+		}
+		for _, r := range t.R32 {
+			add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+		}
+	}
+	return normalizeRuneRanges(result)
+}
+
+// normalizeRuneRanges sorts the ranges and merges overlapping and adjacent ones.
+func normalizeRuneRanges(rs []runeRange) []runeRange {
+	if len(rs) == 0 {
+		return rs
+	}
+	sorted := slices.Clone(rs)
+	slices.SortFunc(sorted, func(a, b runeRange) int { return cmp.Compare(a.lo, b.lo) })
+	result := []runeRange{sorted[0]}
+	for _, r := range sorted[1:] {
+		last := &result[len(result)-1]
+		if r.lo <= last.hi+1 {
+			last.hi = max(last.hi, r.hi)
+			continue
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+// complementRuneRanges returns all the runes not contained in the normalized ranges rs.
+func complementRuneRanges(rs []runeRange) []runeRange {
+	result := []runeRange{}
+	next := rune(0)
+	for _, r := range rs {
+		if r.lo > next {
+			result = append(result, runeRange{next, r.lo - 1})
+		}
+		next = r.hi + 1
+	}
+	if next <= unicode.MaxRune {
+		result = append(result, runeRange{next, unicode.MaxRune})
+	}
+	return result
+}
+
+// subtractRuneRanges returns all the runes contained in a but not in b as ranges.
+func subtractRuneRanges(a, b []runeRange) []runeRange {
+	result := a
+	for _, sub := range b {
+		next := make([]runeRange, 0, len(result))
+		for _, r := range result {
+			if sub.hi < r.lo || sub.lo > r.hi {
+				next = append(next, r)
+				continue
+			}
+			if sub.lo > r.lo {
+				next = append(next, runeRange{r.lo, sub.lo - 1})
+			}
+			if sub.hi < r.hi {
+				next = append(next, runeRange{sub.hi + 1, r.hi})
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+// writeRuneRanges writes the rune ranges as RE2 expression matching a single character.
+func writeRuneRanges(sb *strings.Builder, rs []runeRange) {
+	switch {
+	case len(rs) == 0:
+		// nothing remains, so the class can never match
+		sb.WriteString(`[^\x00-\x{10FFFF}]`)
+	case len(rs) == 1 && rs[0].lo == rs[0].hi:
+		sb.WriteString(regexp.QuoteMeta(string(rs[0].lo)))
+	default:
+		sb.WriteRune('[')
+		for _, r := range rs {
+			if r.lo == r.hi {
+				fmt.Fprintf(sb, `\x{%X}`, r.lo)
+				continue
+			}
+			fmt.Fprintf(sb, `\x{%X}-\x{%X}`, r.lo, r.hi)
+		}
+		sb.WriteRune(']')
+	}
+}
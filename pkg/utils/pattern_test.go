@@ -0,0 +1,254 @@
+// Copyright 2024 Nokia
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+)
+
+func TestCompileXSDPattern(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		matches    []string
+		nonMatches []string
+		wantErr    error
+	}{
+		{
+			name:       "implicitly anchored",
+			pattern:    `hallo [0-9a-fA-F]*`,
+			matches:    []string{"hallo ", "hallo F00"},
+			nonMatches: []string{"xhallo F", "hallo Fx"},
+		},
+		{
+			name:       "caret and dollar are literals",
+			pattern:    `^a$`,
+			matches:    []string{"^a$"},
+			nonMatches: []string{"a"},
+		},
+		{
+			name:       "dot does not match newline or carriage return",
+			pattern:    `a.b`,
+			matches:    []string{"a-b", "aäb"},
+			nonMatches: []string{"a\nb", "a\rb"},
+		},
+		{
+			name:       "unicode digits",
+			pattern:    `\d+`,
+			matches:    []string{"123", "١٢٣"},
+			nonMatches: []string{"12a"},
+		},
+		{
+			name:       "name char escapes",
+			pattern:    `\i\c*`,
+			matches:    []string{"_foo-bar.1", "interface:name"},
+			nonMatches: []string{"1foo", "-foo"},
+		},
+		{
+			name:       "unicode block",
+			pattern:    `\p{IsBasicLatin}+`,
+			matches:    []string{"abc"},
+			nonMatches: []string{"äbc"},
+		},
+		{
+			name:       "class subtraction",
+			pattern:    `[a-z-[aeiou]]+`,
+			matches:    []string{"bcd", "xyz"},
+			nonMatches: []string{"abc", "u"},
+		},
+		{
+			name:       "negated class subtraction",
+			pattern:    `[^a-z-[aeiou]]+`,
+			matches:    []string{"B1", "_"},
+			nonMatches: []string{"a", "e", "b"},
+		},
+		{
+			name:       "nested class subtraction",
+			pattern:    `[a-z-[aeiou-[e]]]+`,
+			matches:    []string{"bce"},
+			nonMatches: []string{"a", "u"},
+		},
+		{
+			name:       "class subtraction with multi character escapes (NCName)",
+			pattern:    `[\i-[:]][\c-[:]]*`,
+			matches:    []string{"foo-bar.1", "_x", "äb"},
+			nonMatches: []string{"a:b", ":x", "1x", "-x"},
+		},
+		{
+			name:       "class subtraction with category escape",
+			pattern:    `[\p{L}-[a-c]]+`,
+			matches:    []string{"xyzä"},
+			nonMatches: []string{"b"},
+		},
+		{
+			name:       "negated multi character escapes within a class",
+			pattern:    `[\S ]+`,
+			matches:    []string{"a b"},
+			nonMatches: []string{"a\tb", "a\nb"},
+		},
+		{
+			name:       "negated name escapes within a class",
+			pattern:    `[\I\C]+`,
+			matches:    []string{"#%-"},
+			nonMatches: []string{"a", "_:"},
+		},
+		{
+			name:       "negated unicode block within a class",
+			pattern:    `[\P{IsBasicLatin}]+`,
+			matches:    []string{"äö"},
+			nonMatches: []string{"a"},
+		},
+		{
+			name:       "unicode block from the XSD 1.0 block list",
+			pattern:    `\p{IsArmenian}+`,
+			matches:    []string{"Հայ"},
+			nonMatches: []string{"abc"},
+		},
+		{
+			name:       "unicode block with multiple ranges",
+			pattern:    `\p{IsPrivateUse}`,
+			matches:    []string{"\uE000", "\U00100000"},
+			nonMatches: []string{"a"},
+		},
+		{
+			name:       "negated word escape",
+			pattern:    `\w+\W\w+`,
+			matches:    []string{"foo bar", "foo-bar"},
+			nonMatches: []string{"foobar"},
+		},
+		{
+			name:       "ipv4 address pattern from ietf-inet-types",
+			pattern:    `(([0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5])\.){3}([0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5])(%[\p{N}\p{L}]+)?`,
+			matches:    []string{"10.0.0.1", "192.168.1.254%eth0"},
+			nonMatches: []string{"256.0.0.1", "10.0.0"},
+		},
+		{
+			name:    "perl group constructs are invalid",
+			pattern: `(?i)abc`,
+			wantErr: ErrUnsupportedPattern,
+		},
+		{
+			name:    "unknown escape",
+			pattern: `\q`,
+			wantErr: ErrUnsupportedPattern,
+		},
+		{
+			name:    "unterminated class",
+			pattern: `[abc`,
+			wantErr: ErrUnsupportedPattern,
+		},
+		{
+			name:    "repeat count exceeding the RE2 limit",
+			pattern: `.{1,2000}`,
+			wantErr: ErrPatternLimit,
+		},
+		{
+			name:    "unicode block not part of XSD 1.0",
+			pattern: `\p{IsLatinExtended-C}`,
+			wantErr: ErrUnsupportedPattern,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := CompileXSDPattern(tt.pattern)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompileXSDPattern() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			for _, m := range tt.matches {
+				if !re.MatchString(m) {
+					t.Errorf("pattern %q (%s) expected to match %q", tt.pattern, re.String(), m)
+				}
+			}
+			for _, m := range tt.nonMatches {
+				if re.MatchString(m) {
+					t.Errorf("pattern %q (%s) expected not to match %q", tt.pattern, re.String(), m)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		patterns []*sdcpb.SchemaPattern
+		wantErr  bool
+	}{
+		{
+			name:  "all patterns match",
+			value: "abc123",
+			patterns: []*sdcpb.SchemaPattern{
+				{Pattern: `[a-z0-9]+`},
+				{Pattern: `.{6}`},
+			},
+		},
+		{
+			name:  "second pattern fails",
+			value: "abc123",
+			patterns: []*sdcpb.SchemaPattern{
+				{Pattern: `[a-z0-9]+`},
+				{Pattern: `.{3}`},
+			},
+			wantErr: true,
+		},
+		{
+			name:  "invert-match not matching",
+			value: "abc",
+			patterns: []*sdcpb.SchemaPattern{
+				{Pattern: `[xX][mM][lL].*`, Inverted: true},
+			},
+		},
+		{
+			name:  "invert-match matching",
+			value: "xml-foo",
+			patterns: []*sdcpb.SchemaPattern{
+				{Pattern: `[xX][mM][lL].*`, Inverted: true},
+			},
+			wantErr: true,
+		},
+		{
+			// patterns that can not be compiled are skipped rather than rejecting every value
+			name:  "unsupported repeat count is skipped",
+			value: "abc",
+			patterns: []*sdcpb.SchemaPattern{
+				{Pattern: `.{1,2000}`},
+			},
+		},
+		{
+			// untranslatable patterns must not silently accept any value
+			name:  "untranslatable pattern rejects the value",
+			value: "abc",
+			patterns: []*sdcpb.SchemaPattern{
+				{Pattern: `[a-z]+`},
+				{Pattern: `\p{IsUnknownBlock}*`},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := MatchPatterns(tt.value, tt.patterns); (err != nil) != tt.wantErr {
+				t.Errorf("MatchPatterns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
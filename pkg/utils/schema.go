@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"encoding/json"
+
 	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
 )
 
@@ -27,3 +29,23 @@ func GetNamespaceFromGetSchema(s *sdcpb.SchemaElem) string {
 	}
 	return ""
 }
+
+// IsAnyData returns true if the schema describes an anydata or anyxml node.
+// The schema server exposes these as containers without any keys, fields, leaflists or childs,
+// that are not presence containers. Their content is opaque and carried as a single value.
+func IsAnyData(s *sdcpb.SchemaElem) bool {
+	c := s.GetContainer()
+	if c == nil || c.GetIsPresence() {
+		return false
+	}
+	return len(c.GetKeys()) == 0 && len(c.GetFields()) == 0 && len(c.GetLeaflists()) == 0 && len(c.GetChildren()) == 0
+}
+
+// AnyDataValue returns the opaque JSON encoded TypedValue of the given decoded anydata content.
+func AnyDataValue(v any) (*sdcpb.TypedValue, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonVal{JsonVal: b}}, nil
+}
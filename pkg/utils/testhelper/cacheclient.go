@@ -2,6 +2,7 @@ package testhelper
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -60,10 +61,14 @@ func ConfigureCacheClientMock(t *testing.T, cacheClient *mockcacheclient.MockCli
 			if len(paths) == 1 && len(paths[0]) == 0 {
 				return updatesRunning
 			}
+			// the cache reads the values at and below the given paths
 			result := make([]*cache.Update, 0, len(paths))
 			for _, p := range paths {
-				if val, exists := updatesMap[opts.Store][strings.Join(p, pathSep)]; exists {
-					result = append(result, val...)
+				prefix := strings.Join(p, pathSep)
+				for key, val := range updatesMap[opts.Store] {
+					if key == prefix || strings.HasPrefix(key, prefix+pathSep) {
+						result = append(result, highestPriorities(val, opts)...)
+					}
 				}
 			}
 			return result
@@ -106,3 +111,28 @@ func ConfigureCacheClientMock(t *testing.T, cacheClient *mockcacheclient.MockCli
 		},
 	)
 }
+
+// highestPriorities returns the values of the opts.PriorityCount highest priorities of a path,
+// as selected by the cache when reading the highest priorities.
+func highestPriorities(upds []*cache.Update, opts *cache.Opts) []*cache.Update {
+	if opts.Store != cachepb.Store_INTENDED || opts.Priority != 0 || opts.PriorityCount == 0 {
+		return upds
+	}
+	priorities := make([]int32, 0, len(upds))
+	for _, u := range upds {
+		if !slices.Contains(priorities, u.Priority()) {
+			priorities = append(priorities, u.Priority())
+		}
+	}
+	slices.Sort(priorities)
+	if len(priorities) > int(opts.PriorityCount) {
+		priorities = priorities[:opts.PriorityCount]
+	}
+	result := make([]*cache.Update, 0, len(upds))
+	for _, u := range upds {
+		if slices.Contains(priorities, u.Priority()) {
+			result = append(result, u)
+		}
+	}
+	return result
+}
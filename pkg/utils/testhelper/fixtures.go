@@ -0,0 +1,152 @@
+package testhelper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/sdcio/data-server/pkg/cache"
+	"github.com/sdcio/data-server/pkg/utils"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// location of the device config fixtures from the projects root
+	SDCIO_FIXTURE_LOCATION = "tests/fixtures"
+)
+
+// LoadConfigFixture reads a device config dump and expands it into leaf updates.
+// The dump is either a JSON / JSON_IETF document (.json) or the XML config of a
+// netconf <data> or <config> element (.xml), relative names are looked up in SDCIO_FIXTURE_LOCATION.
+// Key leafs are included, so that the updates can be stored in the cache like the ones of a
+// synced device or an applied intent, see FixtureCacheUpdates.
+func LoadConfigFixture(t testing.TB, scb utils.SchemaClientBound, name string) []*sdcpb.Update {
+	t.Helper()
+	if !filepath.IsAbs(name) {
+		// HT: workaround to fixed paths here. Considering all unit tests are executed in pkg, split the paths on pkg.
+		dir, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		name = path.Join(strings.Split(dir, "pkg")[0], SDCIO_FIXTURE_LOCATION, name)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var upds []*sdcpb.Update
+	switch ext := filepath.Ext(name); ext {
+	case ".json":
+		upds, err = JSONConfigUpdates(context.Background(), scb, b)
+	case ".xml":
+		upds, err = XMLConfigUpdates(context.Background(), scb, b)
+	default:
+		err = fmt.Errorf("unknown fixture format %q", ext)
+	}
+	if err != nil {
+		t.Fatalf("failed loading fixture %s: %v", name, err)
+	}
+	return upds
+}
+
+// JSONConfigUpdates expands a JSON or JSON_IETF config document into leaf updates.
+func JSONConfigUpdates(ctx context.Context, scb utils.SchemaClientBound, b []byte) ([]*sdcpb.Update, error) {
+	// the module name prefixes of JSON_IETF are optional, so plain JSON is decoded as well
+	return utils.NewConverter(scb).ExpandUpdate(ctx, &sdcpb.Update{
+		Path:  &sdcpb.Path{},
+		Value: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_JsonIetfVal{JsonIetfVal: b}},
+	}, true)
+}
+
+// XMLConfigUpdates expands an XML config document into leaf updates.
+// The children of the root element, e.g. <data> or <config>, are the top level nodes of the config.
+func XMLConfigUpdates(ctx context.Context, scb utils.SchemaClientBound, b []byte) ([]*sdcpb.Update, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(b); err != nil {
+		return nil, err
+	}
+	if doc.Root() == nil {
+		return nil, nil
+	}
+	v, err := xmlConfigValue(ctx, scb, &sdcpb.Path{}, doc.Root())
+	if err != nil {
+		return nil, err
+	}
+	// hand the config over to the converter in the JSON form it expects
+	jv, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return JSONConfigUpdates(ctx, scb, jv)
+}
+
+// xmlConfigValue converts the child elements of e into their JSON representation.
+// XML does not tell lists and leaf-lists from containers and leafs, the schema is used to do so.
+func xmlConfigValue(ctx context.Context, scb utils.SchemaClientBound, p *sdcpb.Path, e *etree.Element) (map[string]any, error) {
+	result := map[string]any{}
+	for _, c := range e.ChildElements() {
+		np := &sdcpb.Path{Elem: append(slices.Clone(p.GetElem()), &sdcpb.PathElem{Name: c.Tag})}
+		rsp, err := scb.GetSchema(ctx, np)
+		if err != nil {
+			return nil, fmt.Errorf("unknown element %q: %w", utils.ToXPath(np, false), err)
+		}
+		switch s := rsp.GetSchema().GetSchema().(type) {
+		case *sdcpb.SchemaElem_Container:
+			v, err := xmlConfigValue(ctx, scb, np, c)
+			if err != nil {
+				return nil, err
+			}
+			if len(s.Container.GetKeys()) == 0 {
+				result[c.Tag] = v
+				continue
+			}
+			l, _ := result[c.Tag].([]any)
+			result[c.Tag] = append(l, v)
+		case *sdcpb.SchemaElem_Leaflist:
+			l, _ := result[c.Tag].([]any)
+			result[c.Tag] = append(l, strings.TrimSpace(c.Text()))
+		default:
+			result[c.Tag] = strings.TrimSpace(c.Text())
+		}
+	}
+	return result, nil
+}
+
+// FixtureCacheUpdates converts the fixture updates into cache updates of the given owner and priority.
+// Use tree.RunningValuesPrio and tree.RunningIntentName for the CONFIG store, e.g.
+//
+//	upds := testhelper.LoadConfigFixture(t, scb, "sdcio_config.json")
+//	running := testhelper.FixtureCacheUpdates(t, upds, tree.RunningValuesPrio, tree.RunningIntentName)
+//	intended := testhelper.FixtureCacheUpdates(t, upds, 10, "owner1")
+//	testhelper.ConfigureCacheClientMock(t, cacheClient, intended, running, nil, nil)
+func FixtureCacheUpdates(t testing.TB, upds []*sdcpb.Update, prio int32, owner string) []*cache.Update {
+	t.Helper()
+	result := make([]*cache.Update, 0, len(upds))
+	for _, u := range upds {
+		b, err := proto.Marshal(u.GetValue())
+		if err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, cache.NewUpdate(utils.ToStrings(u.GetPath(), false, false), b, prio, owner, 0))
+	}
+	return result
+}
+
+// FixtureUpdatesByPath indexes the fixture updates by their xpath, for lookups in assertions.
+func FixtureUpdatesByPath(upds []*sdcpb.Update) map[string]*sdcpb.Update {
+	result := make(map[string]*sdcpb.Update, len(upds))
+	for _, u := range upds {
+		result[utils.ToXPath(u.GetPath(), false)] = u
+	}
+	return result
+}
+
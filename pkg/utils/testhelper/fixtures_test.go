@@ -0,0 +1,68 @@
+package testhelper
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	sdcpb "github.com/sdcio/sdc-protos/sdcpb"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestLoadConfigFixture(t *testing.T) {
+	scb, err := GetSchemaClientBound(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jsonUpds := LoadConfigFixture(t, scb, "sdcio_config.json")
+	xmlUpds := LoadConfigFixture(t, scb, "sdcio_config.xml")
+
+	// both dumps hold the same config
+	if diff := DiffCacheUpdates(FixtureCacheUpdates(t, jsonUpds, 5, "owner1"), FixtureCacheUpdates(t, xmlUpds, 5, "owner1")); diff != "" {
+		t.Errorf("json and xml fixture mismatch (-json +xml):\n%s", diff)
+	}
+
+	// 32 interfaces with 4 leafs and 2 subinterfaces of 3 leafs, 2 network-instances with 4 leafs
+	// and 32 interfaces each, a leaf-list and a leaf
+	if want := 32*(4+2*3) + 2*(4+32) + 2; len(jsonUpds) != want {
+		t.Errorf("expected %d updates, got %d", want, len(jsonUpds))
+	}
+
+	byPath := FixtureUpdatesByPath(xmlUpds)
+	tests := []struct {
+		path string
+		want *sdcpb.TypedValue
+	}{
+		{
+			path: "interface[name=ethernet-1/1]/name",
+			want: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "ethernet-1/1"}},
+		},
+		{
+			path: "interface[name=ethernet-1/1]/mtu",
+			want: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_UintVal{UintVal: 9000}},
+		},
+		{
+			path: "interface[name=ethernet-1/32]/subinterface[index=1]/description",
+			want: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: "ethernet-1/32 bridged"}},
+		},
+		{
+			path: "leaflist/entry",
+			want: &sdcpb.TypedValue{Value: &sdcpb.TypedValue_LeaflistVal{LeaflistVal: &sdcpb.ScalarArray{Element: []*sdcpb.TypedValue{
+				{Value: &sdcpb.TypedValue_StringVal{StringVal: "foo"}},
+				{Value: &sdcpb.TypedValue_StringVal{StringVal: "bar"}},
+				{Value: &sdcpb.TypedValue_StringVal{StringVal: "baz"}},
+			}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			u, ok := byPath[tt.path]
+			if !ok {
+				t.Fatalf("no update for %s", tt.path)
+			}
+			if diff := cmp.Diff(tt.want, u.GetValue(), protocmp.Transform()); diff != "" {
+				t.Errorf("value mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
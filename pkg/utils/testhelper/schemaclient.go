@@ -25,6 +25,7 @@ var (
 
 type SchemaClient struct {
 	schemaStore.Store
+	schema *schema.Schema
 }
 
 // returns schema name, vendor, version, and files path(s)
@@ -70,6 +71,11 @@ func (s *SchemaClient) ExpandPath(ctx context.Context, in *sdcpb.ExpandPathReque
 	return s.Store.ExpandPath(ctx, in)
 }
 
+// GetStatus returns the YANG status of the schema node of the path
+func (s *SchemaClient) GetStatus(ctx context.Context, sc *sdcpb.Schema, p *sdcpb.Path) (string, error) {
+	return dataschema.NodeStatus(s.schema, p)
+}
+
 func (s *SchemaClient) GetSchemaElements(ctx context.Context, req *sdcpb.GetSchemaRequest, opts ...grpc.CallOption) (chan *sdcpb.SchemaElem, error) {
 	return s.Store.GetSchemaElements(ctx, req)
 }
@@ -114,7 +120,7 @@ func InitSDCIOSchema() (dataschema.Client, *dConfig.SchemaConfig, error) {
 		return nil, nil, err
 	}
 
-	return &SchemaClient{Store: schemaMemStore}, dsc, nil
+	return &SchemaClient{Store: schemaMemStore, schema: schema}, dsc, nil
 }
 
 func GetTestFilename() string {
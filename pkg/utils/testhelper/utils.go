@@ -36,7 +36,7 @@ func CacheUpdateSliceToStringSlice(s []*cache.Update) []string {
 }
 
 // GetStringTvProto takes a string and returns the sdcpb.TypedValue for it in proto encoding as []byte
-func GetStringTvProto(t *testing.T, s string) []byte {
+func GetStringTvProto(t testing.TB, s string) []byte {
 	result, err := proto.Marshal(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_StringVal{StringVal: s}})
 	if err != nil {
 		t.Error(err)
@@ -53,7 +53,7 @@ func GetLeafListTvProto(t *testing.T, tvs []*sdcpb.TypedValue) []byte {
 }
 
 // GetStringTvProto takes a string and returns the sdcpb.TypedValue for it in proto encoding as []byte
-func GetUIntTvProto(t *testing.T, i uint64) []byte {
+func GetUIntTvProto(t testing.TB, i uint64) []byte {
 	result, err := proto.Marshal(&sdcpb.TypedValue{Value: &sdcpb.TypedValue_UintVal{UintVal: uint64(i)}})
 	if err != nil {
 		t.Error(err)
@@ -109,7 +109,7 @@ func DiffDoubleStringPathSlice(s1, s2 [][]string) string {
 }
 
 // GetSchemaClientBound creates a SchemaClientBound mock that responds to certain GetSchema requests
-func GetSchemaClientBound(t *testing.T) (*mockschemaclientbound.MockSchemaClientBound, error) {
+func GetSchemaClientBound(t testing.TB) (*mockschemaclientbound.MockSchemaClientBound, error) {
 
 	x, schema, err := InitSDCIOSchema()
 	if err != nil {
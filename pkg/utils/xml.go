@@ -18,6 +18,7 @@ const (
 	XMLOperationDelete  XMLOperation = "delete"
 	XMLOperationRemove  XMLOperation = "remove"
 	XMLOperationReplace XMLOperation = "replace"
+	XMLOperationCreate  XMLOperation = "create"
 )
 
 func TypedValueToXML(parent *etree.Element, tv *sdcpb.TypedValue, name string, namespace string, onlyNewOrUpdated bool, operationWithNamespace bool, useOperationRemove bool) {
@@ -60,8 +61,8 @@ func AddXMLOperation(elem *etree.Element, operation XMLOperation, operationWithN
 		if useOperationRemove {
 			operName = string(XMLOperationRemove)
 		}
-	case XMLOperationReplace:
-		operName = string(XMLOperationReplace)
+	case XMLOperationReplace, XMLOperationCreate:
+		operName = string(operation)
 	}
 
 	operKey := "operation"
@@ -74,6 +75,18 @@ func AddXMLOperation(elem *etree.Element, operation XMLOperation, operationWithN
 	elem.CreateAttr(operKey, string(operName))
 }
 
+// HasXMLOperation returns true if the element or one of its ancestors carries the given operation.
+func HasXMLOperation(elem *etree.Element, operation XMLOperation) bool {
+	for e := elem; e != nil; e = e.Parent() {
+		for _, a := range e.Attr {
+			if a.Key == "operation" && a.Value == string(operation) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // XmlRecursiveSortElementsByTagName - is a function used in testing to recursively sort XML elements by their tag name
 func XmlRecursiveSortElementsByTagName(element *etree.Element) {
 	// Sort the child elements by their tag name
@@ -104,3 +117,17 @@ func XmlRecursiveSortElementsByTagName(element *etree.Element) {
 		}
 	}
 }
+
+// XMLInnerValue returns the content of the given element, without the element itself, as an AsciiVal.
+// It is used to carry the opaque content of anyxml and anydata nodes.
+func XMLInnerValue(elem *etree.Element) (*sdcpb.TypedValue, error) {
+	doc := etree.NewDocument()
+	for _, c := range elem.Copy().Child {
+		doc.AddChild(c)
+	}
+	s, err := doc.WriteToString()
+	if err != nil {
+		return nil, err
+	}
+	return &sdcpb.TypedValue{Value: &sdcpb.TypedValue_AsciiVal{AsciiVal: strings.TrimSpace(s)}}, nil
+}
@@ -0,0 +1,35 @@
+#!/usr/bin/env bash
+# Compares the results of a benchmark run against the baseline.
+# Fails if the mean ns/op of any benchmark exceeds the baseline by more than the threshold (in percent).
+#
+# usage: compare.sh <baseline> <current> [threshold]
+set -euo pipefail
+
+BASELINE=$1
+CURRENT=$2
+THRESHOLD=${3:-20}
+
+awk -v threshold="$THRESHOLD" '
+	# strip the GOMAXPROCS suffix, such that results of different machines are comparable
+	function name(n) { sub(/-[0-9]+$/, "", n); return n }
+	/^Benchmark/ && $4 == "ns/op" {
+		if (FNR == NR) { base[name($1)] += $3; baseCount[name($1)]++ }
+		else { cur[name($1)] += $3; curCount[name($1)]++ }
+	}
+	END {
+		failed = 0
+		for (n in cur) {
+			c = cur[n] / curCount[n]
+			if (!(n in base)) {
+				printf "%-60s %15.0f ns/op (no baseline)\n", n, c
+				continue
+			}
+			b = base[n] / baseCount[n]
+			delta = (c - b) / b * 100
+			status = "ok"
+			if (delta > threshold) { status = "REGRESSION"; failed = 1 }
+			printf "%-60s %15.0f ns/op %15.0f ns/op %+7.1f%% %s\n", n, b, c, delta, status
+		}
+		exit failed
+	}
+' "$BASELINE" "$CURRENT"